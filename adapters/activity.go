@@ -0,0 +1,53 @@
+package adapters
+
+import "time"
+
+// sessionActivity accumulates the duration, assistant turns, and tool calls of a session
+// as an adapter parses its entries for listing.
+type sessionActivity struct {
+	first, last   time.Time
+	awaitingReply bool
+	turns         int
+	toolCalls     int
+}
+
+// seen records the timestamp of an entry. Zero timestamps are ignored.
+func (a *sessionActivity) seen(ts time.Time) {
+	if ts.IsZero() {
+		return
+	}
+	if a.first.IsZero() || ts.Before(a.first) {
+		a.first = ts
+	}
+	if ts.After(a.last) {
+		a.last = ts
+	}
+}
+
+// userMessage records a message the user wrote, which the next assistant message replies to
+func (a *sessionActivity) userMessage() {
+	a.awaitingReply = true
+}
+
+// assistantMessage records an assistant message making toolCalls tool calls. Agents that
+// write a response as several entries count as one turn, since only the first of them
+// replies to the user.
+func (a *sessionActivity) assistantMessage(toolCalls int) {
+	if a.awaitingReply {
+		a.turns++
+		a.awaitingReply = false
+	}
+	a.toolCalls += toolCalls
+}
+
+// durationSeconds is the time between the first and last timestamped entries
+func (a *sessionActivity) durationSeconds() int64 {
+	return int64(a.last.Sub(a.first) / time.Second)
+}
+
+// apply sets the activity fields of a session
+func (a *sessionActivity) apply(session *Session) {
+	session.DurationSeconds = a.durationSeconds()
+	session.AssistantTurns = a.turns
+	session.ToolCallCount = a.toolCalls
+}
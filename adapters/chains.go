@@ -0,0 +1,57 @@
+package adapters
+
+// LinkChains collapses sessions that resume one another into one entry per chain.
+// Each session that is not continued by another listed session is kept, and the sessions
+// it continues (following ContinuedFrom links within the same source) are recorded in its
+// Chain, oldest first. Sessions whose predecessor is not in the list keep their
+// ContinuedFrom but get no chain. The order of the remaining sessions is preserved.
+func LinkChains(sessions []Session) []Session {
+	type key struct{ source, id string }
+
+	byKey := make(map[key]Session, len(sessions))
+	for _, session := range sessions {
+		byKey[key{session.Source, session.ID}] = session
+	}
+
+	continued := make(map[key]bool)
+	for _, session := range sessions {
+		if session.ContinuedFrom == "" {
+			continue
+		}
+		if _, ok := byKey[key{session.Source, session.ContinuedFrom}]; ok {
+			continued[key{session.Source, session.ContinuedFrom}] = true
+		}
+	}
+
+	linked := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		if continued[key{session.Source, session.ID}] {
+			continue
+		}
+
+		var chain []ChainLink
+		seen := map[string]bool{session.ID: true}
+		for prevID := session.ContinuedFrom; prevID != "" && !seen[prevID]; {
+			prev, ok := byKey[key{session.Source, prevID}]
+			if !ok {
+				break
+			}
+			seen[prevID] = true
+			chain = append(chain, ChainLink{
+				ID:               prev.ID,
+				FirstMessage:     prev.FirstMessage,
+				Timestamp:        prev.Timestamp,
+				UserMessageCount: prev.UserMessageCount,
+			})
+			prevID = prev.ContinuedFrom
+		}
+
+		// Links were collected newest first
+		for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+			chain[i], chain[j] = chain[j], chain[i]
+		}
+		session.Chain = chain
+		linked = append(linked, session)
+	}
+	return linked
+}
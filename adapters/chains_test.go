@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkChains(t *testing.T) {
+	day := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	sessions := []Session{
+		{ID: "c", Source: "codex", ContinuedFrom: "b", Timestamp: day.Add(48 * time.Hour)},
+		{ID: "other", Source: "claude", Timestamp: day.Add(36 * time.Hour)},
+		{ID: "b", Source: "codex", ContinuedFrom: "a", Timestamp: day.Add(24 * time.Hour), FirstMessage: "keep going"},
+		{ID: "a", Source: "codex", Timestamp: day, FirstMessage: "start migration", UserMessageCount: 3},
+		{ID: "orphan", Source: "claude", ContinuedFrom: "gone", Timestamp: day},
+		// Same ID from a different source must not be linked
+		{ID: "x", Source: "claude", ContinuedFrom: "a", Timestamp: day},
+	}
+
+	linked := LinkChains(sessions)
+	var ids []string
+	for _, s := range linked {
+		ids = append(ids, s.ID)
+	}
+	if len(linked) != 4 || ids[0] != "c" || ids[1] != "other" || ids[2] != "orphan" || ids[3] != "x" {
+		t.Fatalf("unexpected sessions after linking: %v", ids)
+	}
+
+	chain := linked[0].Chain
+	if len(chain) != 2 || chain[0].ID != "a" || chain[1].ID != "b" {
+		t.Fatalf("expected chain a -> b, got %+v", chain)
+	}
+	if chain[0].FirstMessage != "start migration" || chain[0].UserMessageCount != 3 || !chain[0].Timestamp.Equal(day) {
+		t.Fatalf("chain link lost session details: %+v", chain[0])
+	}
+	if linked[2].ContinuedFrom != "gone" || linked[2].Chain != nil {
+		t.Fatalf("orphan should keep its link without a chain: %+v", linked[2])
+	}
+	if linked[3].Chain != nil {
+		t.Fatalf("sessions from other sources should not be chained: %+v", linked[3])
+	}
+}
+
+func TestLinkChainsStopsOnCycles(t *testing.T) {
+	sessions := []Session{
+		{ID: "a", Source: "claude", ContinuedFrom: "b"},
+		{ID: "b", Source: "claude", ContinuedFrom: "a"},
+		{ID: "c", Source: "claude", ContinuedFrom: "a"},
+	}
+	linked := LinkChains(sessions)
+	if len(linked) != 1 || linked[0].ID != "c" || len(linked[0].Chain) != 2 {
+		t.Fatalf("unexpected result for cyclic links: %+v", linked)
+	}
+}
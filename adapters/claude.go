@@ -3,19 +3,24 @@ package adapters
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
+	"time"
 )
 
 // ClaudeAdapter implements SessionAdapter for Claude Code CLI sessions.
 // Claude Code stores sessions as JSONL files in ~/.claude/projects/[PROJECT_DIR]/
 // where PROJECT_DIR is derived from the actual project path.
 type ClaudeAdapter struct {
+	metadataCaching
 	homeDir string
+	dataDir string // Claude Code's data directory; ~/.claude when empty
 }
 
 // NewClaudeAdapter creates a new Claude Code session adapter.
@@ -25,7 +30,16 @@ func NewClaudeAdapter() (*ClaudeAdapter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
-	return &ClaudeAdapter{homeDir: homeDir}, nil
+	return &ClaudeAdapter{homeDir: homeDir, dataDir: claudeDataDir(homeDir)}, nil
+}
+
+// projectsDir returns the directory holding a directory of sessions per project.
+func (c *ClaudeAdapter) projectsDir() string {
+	dataDir := c.dataDir
+	if dataDir == "" {
+		dataDir = filepath.Join(c.homeDir, ".claude")
+	}
+	return filepath.Join(dataDir, "projects")
 }
 
 // Name returns the adapter name.
@@ -41,65 +55,99 @@ type claudeMessage struct {
 	Content     interface{}            `json:"content,omitempty"`
 	Message     *claudeNestedMessage   `json:"message,omitempty"` // Nested message format
 	CWD         string                 `json:"cwd,omitempty"`
+	GitBranch   string                 `json:"gitBranch,omitempty"`
+	UUID        string                 `json:"uuid,omitempty"`
+	ParentUUID  string                 `json:"parentUuid,omitempty"` // Links entries into a tree; siblings are branches
+	Timestamp   string                 `json:"timestamp,omitempty"`
 	LeafUUID    string                 `json:"leafUuid,omitempty"`
-	IsSidechain bool                   `json:"isSidechain,omitempty"` // Skip sidechain messages
-	Metadata    map[string]interface{} `json:"-"`                     // Capture any extra fields
+	SessionID   string                 `json:"sessionId,omitempty"`
+	IsSidechain bool                   `json:"isSidechain,omitempty"` // Subagent activity, not part of the main conversation
+	AgentID     string                 `json:"agentId,omitempty"`     // Subagent that wrote a sidechain entry
+	Subtype     string                 `json:"subtype,omitempty"`     // Kind of system event
+	Level       string                 `json:"level,omitempty"`
+	ToolUseID   string                 `json:"toolUseID,omitempty"` // Tool call a system event (e.g. a hook) ran for
+	Metadata    map[string]interface{} `json:"-"`                   // Capture any extra fields
 }
 
 // claudeNestedMessage represents the nested message structure in newer Claude Code format
 type claudeNestedMessage struct {
-	Role    string      `json:"role"`
-	Content interface{} `json:"content"`
+	ID      string                 `json:"id,omitempty"`
+	Role    string                 `json:"role"`
+	Content interface{}            `json:"content"`
+	Model   string                 `json:"model,omitempty"`
+	Usage   map[string]interface{} `json:"usage,omitempty"`
 }
 
-// projectDirName converts an absolute project path to Claude's directory naming format.
-// Claude uses the path with slashes converted to hyphens.
+// projectDirName converts an absolute project path to the directory naming format of
+// older Claude Code versions, which only converted slashes to hyphens. Current versions
+// use encodeClaudeProjectDir.
 func projectDirName(projectPath string) string {
-	// Clean the path and replace slashes with hyphens
-	cleaned := filepath.Clean(projectPath)
+	// Clean the path and replace separators with hyphens
+	cleaned := filepath.ToSlash(filepath.Clean(projectPath))
 	return strings.ReplaceAll(cleaned, "/", "-")
 }
 
 // ListSessions returns all Claude Code sessions for the given project.
 // If projectPath is empty, returns sessions from ALL projects.
 func (c *ClaudeAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
-	claudeProjectsDir := filepath.Join(c.homeDir, ".claude", "projects")
+	return c.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (c *ClaudeAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	claudeProjectsDir := c.projectsDir()
 
 	// If no project path specified, list sessions from ALL projects
 	if projectPath == "" {
-		return c.listAllSessions(claudeProjectsDir, limit)
+		return c.listAllSessions(ctx, claudeProjectsDir, limit)
 	}
 
 	// Get absolute path
-	projectPath, err := filepath.Abs(projectPath)
-	if err != nil {
+	if _, err := filepath.Abs(projectPath); err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	// Convert to Claude's directory naming format
-	dirName := projectDirName(projectPath)
-	sessionsDir := filepath.Join(claudeProjectsDir, dirName)
-
-	// Check if directory exists
-	if _, err := os.Stat(sessionsDir); os.IsNotExist(err) {
+	// Claude names the directory after the path it was started in, which may be the
+	// path as given or with symlinks resolved, in the current or the older encoding
+	sessionsDir := ""
+	for _, variant := range projectPathVariants(projectPath) {
+		for _, dirName := range []string{encodeClaudeProjectDir(variant), projectDirName(variant)} {
+			if info, err := os.Stat(filepath.Join(claudeProjectsDir, dirName)); err == nil && info.IsDir() {
+				sessionsDir = filepath.Join(claudeProjectsDir, dirName)
+				break
+			}
+		}
+		if sessionsDir != "" {
+			break
+		}
+	}
+	if sessionsDir == "" {
 		return []Session{}, nil // No sessions for this project
 	}
+	projectPath = CanonicalProjectPath(projectPath)
 
 	// Read all .jsonl files
-	files, err := filepath.Glob(filepath.Join(sessionsDir, "*.jsonl"))
+	files, err := claudeSessionFiles(sessionsDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list session files: %w", err)
 	}
 
 	sessions := make([]Session, 0, len(files))
+	infos := make(map[string]claudeFileInfo, len(files))
 	for _, filePath := range files {
-		session, err := c.parseSessionMetadata(filePath, projectPath)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		session, info, err := c.loadSessionFile(filePath, projectPath)
 		if err != nil {
 			// Skip files we can't parse
 			continue
 		}
 		sessions = append(sessions, session)
+		infos[session.ID] = info
 	}
+	sessions = dedupeBranchedSessions(sessions, infos)
+	linkClaudeContinuations(sessions, infos)
 
 	// Sort by timestamp (newest first)
 	sort.Slice(sessions, func(i, j int) bool {
@@ -115,7 +163,7 @@ func (c *ClaudeAdapter) ListSessions(projectPath string, limit int) ([]Session,
 }
 
 // listAllSessions lists sessions from all projects.
-func (c *ClaudeAdapter) listAllSessions(claudeProjectsDir string, limit int) ([]Session, error) {
+func (c *ClaudeAdapter) listAllSessions(ctx context.Context, claudeProjectsDir string, limit int) ([]Session, error) {
 	// Check if projects directory exists
 	if _, err := os.Stat(claudeProjectsDir); os.IsNotExist(err) {
 		return []Session{}, nil
@@ -128,27 +176,40 @@ func (c *ClaudeAdapter) listAllSessions(claudeProjectsDir string, limit int) ([]
 	}
 
 	var allSessions []Session
+	infos := make(map[string]claudeFileInfo)
 	for _, dir := range projectDirs {
 		if !dir.IsDir() {
 			continue
 		}
 
 		projectDir := filepath.Join(claudeProjectsDir, dir.Name())
-		files, err := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+		files, err := claudeSessionFiles(projectDir)
 		if err != nil {
 			continue
 		}
 
-		projectPath := filepath.Join(claudeProjectsDir, dir.Name())
+		// Sessions report the directory they were started in. For those that don't,
+		// recover the project from the directory name.
+		projectPath := decodeClaudeProjectDir(dir.Name())
+		if projectPath == "" {
+			projectPath = guessClaudeProjectDir(dir.Name())
+		}
+		projectPath = CanonicalProjectPath(projectPath)
 
 		for _, filePath := range files {
-			session, err := c.parseSessionMetadata(filePath, projectPath)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			session, info, err := c.loadSessionFile(filePath, projectPath)
 			if err != nil {
 				continue
 			}
 			allSessions = append(allSessions, session)
+			infos[session.ID] = info
 		}
 	}
+	allSessions = dedupeBranchedSessions(allSessions, infos)
+	linkClaudeContinuations(allSessions, infos)
 
 	// Sort by timestamp (newest first)
 	sort.Slice(allSessions, func(i, j int) bool {
@@ -163,15 +224,86 @@ func (c *ClaudeAdapter) listAllSessions(claudeProjectsDir string, limit int) ([]
 	return allSessions, nil
 }
 
+// claudeSessionFiles returns the session files of a project directory. Subagent
+// transcripts (agent-*.jsonl) belong to the session that started the agent, so they are
+// read as part of that session rather than listed on their own.
+func claudeSessionFiles(projectDir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(projectDir, "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sessions := files[:0]
+	for _, file := range files {
+		if !strings.HasPrefix(filepath.Base(file), "agent-") {
+			sessions = append(sessions, file)
+		}
+	}
+	return sessions, nil
+}
+
+// claudeFileInfo holds the conversation links of a session file, used to detect
+// files that only repeat a conversation continued in another file.
+type claudeFileInfo struct {
+	uuids    map[string]bool
+	lastUUID string
+	leafRefs []string // leafUuid values of summary entries
+}
+
+// claudeCachedFile is the metadata cache form of a parsed session file.
+type claudeCachedFile struct {
+	Session  Session  `json:"session"`
+	UUIDs    []string `json:"uuids,omitempty"`
+	LastUUID string   `json:"last_uuid,omitempty"`
+	LeafRefs []string `json:"leaf_refs,omitempty"`
+}
+
+// loadSessionFile returns the parsed metadata of a session file, from the metadata
+// cache when the file hasn't changed since it was last parsed.
+func (c *ClaudeAdapter) loadSessionFile(filePath, projectPath string) (Session, claudeFileInfo, error) {
+	cached, err := cachedMetadata(&c.metadataCaching, filePath, projectPath, func() (claudeCachedFile, error) {
+		session, info, err := c.parseSessionFile(filePath, projectPath)
+		if err != nil {
+			return claudeCachedFile{}, err
+		}
+		c.recordSessionFile("claude", session.ID, filePath)
+		file := claudeCachedFile{Session: session, LastUUID: info.lastUUID, LeafRefs: info.leafRefs}
+		for uuid := range info.uuids {
+			file.UUIDs = append(file.UUIDs, uuid)
+		}
+		return file, nil
+	})
+	if err != nil {
+		return Session{}, claudeFileInfo{}, err
+	}
+
+	info := claudeFileInfo{
+		uuids:    make(map[string]bool, len(cached.UUIDs)),
+		lastUUID: cached.LastUUID,
+		leafRefs: cached.LeafRefs,
+	}
+	for _, uuid := range cached.UUIDs {
+		info.uuids[uuid] = true
+	}
+	return cached.Session, info, nil
+}
+
 // parseSessionMetadata extracts metadata from a Claude Code session file.
 // It reads the first few lines to get the summary and first user message.
 func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Session, error) {
+	session, _, err := c.parseSessionFile(filePath, projectPath)
+	return session, err
+}
+
+// parseSessionFile extracts metadata and conversation links from a Claude Code session file.
+func (c *ClaudeAdapter) parseSessionFile(filePath, projectPath string) (Session, claudeFileInfo, error) {
+	info := claudeFileInfo{uuids: make(map[string]bool)}
+
 	// Performance optimization: Quick pre-scan using fast byte search
 	// to detect if there are any user messages before doing expensive JSON parsing.
 	// This allows us to skip files with no user messages entirely.
 	fileData, err := os.ReadFile(filePath)
 	if err != nil {
-		return Session{}, fmt.Errorf("failed to read session file: %w", err)
+		return Session{}, info, fmt.Errorf("failed to read session file: %w", err)
 	}
 
 	var session Session
@@ -179,6 +311,7 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	session.Source = "claude"
 	session.ProjectPath = projectPath
 	session.FilePath = filePath
+	session.FileSize = int64(len(fileData))
 
 	// Get file modification time as a fallback timestamp
 	if stat, err := os.Stat(filePath); err == nil {
@@ -190,9 +323,10 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	// This is much faster than JSON parsing and allows us to skip empty sessions early.
 	hasUserMessages := bytes.Contains(fileData, []byte(`"type":"user"`))
 	if !hasUserMessages {
-		session.FirstMessage = "(Empty session)"
+		session.FirstMessage = EmptySessionMessage
 		session.UserMessageCount = 0
-		return session, nil
+		session.MessageCount = bytes.Count(fileData, []byte(`"type":"assistant"`)) + bytes.Count(fileData, []byte(`"type":"system"`))
+		return session, info, nil
 	}
 
 	// File has user messages - do full JSON parse to get exact count and first message
@@ -200,6 +334,7 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	foundFirstMessage := false
 	userMessageCount := 0
 	projectPathFromLog := ""
+	var activity sessionActivity
 
 	// Read through the file to find summary and first user message
 	for scanner.Scan() {
@@ -208,13 +343,47 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 			continue // Skip malformed lines
 		}
 
+		if msg.Type == "user" || msg.Type == "assistant" || (msg.Type == "system" && isClaudeSystemEvent(msg)) {
+			session.MessageCount++
+		}
+
 		// Capture summary if available
 		if msg.Type == "summary" && msg.Summary != "" {
 			session.Summary = msg.Summary
 		}
+		if msg.Type == "summary" && msg.LeafUUID != "" {
+			info.leafRefs = append(info.leafRefs, msg.LeafUUID)
+		}
+
+		// Entries copied from a resumed session keep that session's ID
+		if session.ContinuedFrom == "" && msg.SessionID != "" && msg.SessionID != session.ID {
+			session.ContinuedFrom = msg.SessionID
+		}
+
+		if msg.UUID != "" && !msg.IsSidechain {
+			info.uuids[msg.UUID] = true
+			info.lastUUID = msg.UUID
+		}
 
 		if projectPathFromLog == "" && msg.CWD != "" {
-			projectPathFromLog = filepath.Clean(msg.CWD)
+			projectPathFromLog = CanonicalProjectPath(msg.CWD)
+		}
+
+		if !msg.IsSidechain {
+			if ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil {
+				activity.seen(ts)
+			}
+			// The branch may change during the session: the last one is kept
+			if msg.GitBranch != "" && msg.GitBranch != "HEAD" {
+				session.Branch = msg.GitBranch
+			}
+		}
+		if msg.Type == "assistant" && !msg.IsSidechain {
+			content := msg.Content
+			if msg.Message != nil {
+				content = msg.Message.Content
+			}
+			activity.assistantMessage(claudeToolUseCount(content))
 		}
 
 		// Capture first user message (skip system messages and sidechain messages)
@@ -230,26 +399,15 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 				content = msg.Message.Content
 			}
 
-			// Extract the text and check if it's a system message to skip
+			// Skip empty messages, slash commands, command output, and other messages
+			// the user didn't write
 			firstLine := extractFirstLine(content)
-			trimmed := strings.TrimSpace(firstLine)
-
-			// Skip empty messages
-			if trimmed == "" {
-				continue
-			}
-
-			// Skip caveat messages, slash commands, and other system messages
-			if strings.HasPrefix(trimmed, "Caveat:") ||
-				strings.HasPrefix(trimmed, "<command-name>") ||
-				strings.HasPrefix(trimmed, "<bash-input>") ||
-				strings.HasPrefix(trimmed, "<local-command-stdout>") ||
-				(strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")) {
-				// Skip and continue looking for the next user message
+			if firstLine == "" {
 				continue
 			}
 
 			userMessageCount++
+			activity.userMessage()
 
 			if !foundFirstMessage {
 				session.FirstMessage = firstLine
@@ -259,12 +417,12 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	}
 
 	if err := scanner.Err(); err != nil {
-		return session, fmt.Errorf("error reading session file: %w", err)
+		return session, info, fmt.Errorf("error reading session file: %w", err)
 	}
 
 	// If no valid first message was found, use a placeholder
 	if session.FirstMessage == "" {
-		session.FirstMessage = "(Empty session)"
+		session.FirstMessage = EmptySessionMessage
 	}
 
 	if projectPathFromLog != "" {
@@ -272,199 +430,508 @@ func (c *ClaudeAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	}
 
 	session.UserMessageCount = userMessageCount
+	activity.apply(&session)
+
+	return session, info, nil
+}
 
-	return session, nil
-}
-
-// stripSystemXMLTags removes system XML tags from the beginning of a message.
-// These tags contain metadata that shouldn't be displayed as the first message.
-func stripSystemXMLTags(text string) string {
-	// List of system XML tags to strip from the beginning
-	// (matches SYSTEM_XML_TAGS from claude-transcripts/src/components/TranscriptViewer.tsx)
-	systemTags := []string{
-		"ide_opened_file",
-		"ide_selection",
-		"ide_diagnostics",
-		"post-tool-use-hook",
-		"system-reminder",
-		"user-prompt-submit-hook",
-		"local-command-stdout",
-	}
-
-	for {
-		trimmed := strings.TrimSpace(text)
-		removed := false
-
-		for _, tag := range systemTags {
-			openTag := "<" + tag + ">"
-			closeTag := "</" + tag + ">"
-
-			if strings.HasPrefix(trimmed, openTag) {
-				closeIdx := strings.Index(trimmed, closeTag)
-				if closeIdx != -1 {
-					trimmed = strings.TrimSpace(trimmed[closeIdx+len(closeTag):])
-					removed = true
-					break // Restart scanning with updated text
+// dedupeBranchedSessions drops sessions whose whole conversation also appears in another
+// session file. Resuming or branching a Claude Code session writes a new file that copies
+// the earlier messages, so the older file adds nothing to a listing. When two files hold
+// the same conversation, the one with the lexically greater ID is kept.
+func dedupeBranchedSessions(sessions []Session, infos map[string]claudeFileInfo) []Session {
+	kept := make([]Session, 0, len(sessions))
+	for _, session := range sessions {
+		info := infos[session.ID]
+		redundant := false
+		if info.lastUUID != "" {
+			for otherID, other := range infos {
+				if otherID == session.ID || !other.uuids[info.lastUUID] {
+					continue
+				}
+				// Identical conversations contain each other; keep exactly one of them
+				if len(other.uuids) > len(info.uuids) || (len(other.uuids) == len(info.uuids) && otherID > session.ID) {
+					redundant = true
+					break
 				}
 			}
 		}
-
-		text = trimmed
-		if !removed {
-			break
+		if !redundant {
+			kept = append(kept, session)
 		}
 	}
-
-	return text
+	return kept
 }
 
-// extractFirstLine extracts the first non-empty line from content.
-// Content can be a string or a structured object.
-func extractFirstLine(content interface{}) string {
-	switch v := content.(type) {
-	case string:
-		lines := strings.Split(v, "\n")
-		for _, line := range lines {
-			trimmed := strings.TrimSpace(line)
-			if trimmed != "" {
-				// Strip system XML tags from the beginning
-				trimmed = stripSystemXMLTags(trimmed)
-				trimmed = strings.TrimSpace(trimmed)
-
-				// If empty after stripping tags, continue to next line
-				if trimmed == "" {
-					continue
-				}
-
-				// Limit to 200 characters
-				if len(trimmed) > 200 {
-					return trimmed[:200] + "..."
-				}
-				return trimmed
-			}
+// linkClaudeContinuations sets ContinuedFrom for sessions that start with a summary of
+// another session. Continuing a Claude Code session writes a summary entry whose leafUuid
+// points at the last message of the session it picks up from.
+func linkClaudeContinuations(sessions []Session, infos map[string]claudeFileInfo) {
+	for i := range sessions {
+		if sessions[i].ContinuedFrom != "" {
+			continue
 		}
-	case []interface{}:
-		// Claude sometimes uses structured content blocks
-		for _, item := range v {
-			if m, ok := item.(map[string]interface{}); ok {
-				if text, ok := m["text"].(string); ok {
-					return extractFirstLine(text)
+		info := infos[sessions[i].ID]
+		for _, ref := range info.leafRefs {
+			if info.uuids[ref] {
+				continue
+			}
+			for otherID, other := range infos {
+				if otherID != sessions[i].ID && other.uuids[ref] {
+					sessions[i].ContinuedFrom = otherID
+					break
 				}
 			}
-		}
-	case map[string]interface{}:
-		if text, ok := v["text"].(string); ok {
-			return extractFirstLine(text)
+			if sessions[i].ContinuedFrom != "" {
+				break
+			}
 		}
 	}
-	return ""
 }
 
 // GetSession retrieves the full content of a Claude Code session with pagination.
 func (c *ClaudeAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
-	// Find the session file
+	sessionFile, err := c.findSessionFile(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Stop reading at the end of the requested page
+	return collectPage(func(fn func(Message) bool) error {
+		return c.streamMessages(sessionFile, fn)
+	}, page, pageSize)
+}
+
+// StreamSession calls fn with each message of a session, in order, until fn returns false.
+func (c *ClaudeAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	sessionFile, err := c.findSessionFile(sessionID)
+	if err != nil {
+		return err
+	}
+	return c.streamMessages(sessionFile, fn)
+}
+
+// findSessionFile returns the file of a session, using the path recorded while listing
+// when available and otherwise looking for it in every project directory.
+func (c *ClaudeAdapter) findSessionFile(sessionID string) (string, error) {
+	if path := c.lookupSessionFile("claude", sessionID); path != "" {
+		return path, nil
+	}
+
 	// We need to search all project directories since we only have the session ID
-	claudeDir := filepath.Join(c.homeDir, ".claude", "projects")
+	claudeDir := c.projectsDir()
 	projectDirs, err := os.ReadDir(claudeDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read Claude projects directory: %w", err)
+		return "", fmt.Errorf("failed to read Claude projects directory: %w", err)
 	}
 
-	var sessionFile string
 	for _, dir := range projectDirs {
 		if !dir.IsDir() {
 			continue
 		}
 		candidate := filepath.Join(claudeDir, dir.Name(), sessionID+".jsonl")
 		if _, err := os.Stat(candidate); err == nil {
-			sessionFile = candidate
-			break
+			c.recordSessionFile("claude", sessionID, candidate)
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("session not found: %s", sessionID)
+}
+
+// ReadSession reads every message of a session listed by ListSessions.
+func (c *ClaudeAdapter) ReadSession(session Session) ([]Message, error) {
+	return c.readAllMessages(session.FilePath)
+}
+
+// readAllMessages reads all messages from a Claude Code session file.
+func (c *ClaudeAdapter) readAllMessages(filePath string) ([]Message, error) {
+	return collectAll(func(fn func(Message) bool) error {
+		return c.streamMessages(filePath, fn)
+	})
+}
+
+// streamMessages reads a session file one entry at a time, calling fn with each message
+// until fn returns false. The transcripts of subagents the session started follow the
+// main conversation.
+func (c *ClaudeAdapter) streamMessages(filePath string, fn func(Message) bool) error {
+	stream := newClaudeStream(fn)
+	if err := stream.readFile(filePath); err != nil || stream.stopped {
+		return err
+	}
+
+	sessionID := strings.TrimSuffix(filepath.Base(filePath), ".jsonl")
+	for _, agentFile := range claudeAgentFiles(filePath, sessionID) {
+		if err := stream.readFile(agentFile); err != nil || stream.stopped {
+			return err
 		}
 	}
+	return nil
+}
 
-	if sessionFile == "" {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+// claudeAgentFiles returns the subagent transcripts of a session: agent-*.jsonl files
+// next to the session file that were written by the session, and files in the session's
+// subagents directory.
+func claudeAgentFiles(filePath, sessionID string) []string {
+	dir := filepath.Dir(filePath)
+	var files []string
+	if siblings, err := filepath.Glob(filepath.Join(dir, "agent-*.jsonl")); err == nil {
+		for _, file := range siblings {
+			if claudeFileSessionID(file) == sessionID {
+				files = append(files, file)
+			}
+		}
+	}
+	if nested, err := filepath.Glob(filepath.Join(dir, sessionID, "subagents", "agent-*.jsonl")); err == nil {
+		files = append(files, nested...)
 	}
+	return files
+}
 
-	// Read all messages from the file
-	messages, err := c.readAllMessages(sessionFile)
+// claudeFileSessionID returns the session ID recorded in the first entry of a file that has one.
+func claudeFileSessionID(filePath string) string {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return ""
 	}
+	defer file.Close()
 
-	// Apply pagination
-	start := page * pageSize
-	if start >= len(messages) {
-		return []Message{}, nil
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		var entry struct {
+			SessionID string `json:"sessionId"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.SessionID != "" {
+			return entry.SessionID
+		}
 	}
+	return ""
+}
 
-	end := start + pageSize
-	if end > len(messages) {
-		end = len(messages)
-	}
+// claudeStream converts the entries of a session's files into messages, keeping the
+// state that links entries across lines and files.
+type claudeStream struct {
+	fn      func(Message) bool
+	stopped bool
+
+	// Parent links of every entry, so messages can be linked past skipped entries.
+	// Parents are written before their children, so links are known when a message is read.
+	parents  map[string]string
+	included map[string]bool
+
+	// Each content block of a response is written as its own entry with a snapshot of
+	// the response's usage, which grows as it streams. Usage is only recorded on the
+	// latest entry of a response, so this holds the metadata carrying it by response ID.
+	seenResponses map[string]map[string]interface{}
+
+	// Subagents are started by Task tool calls whose prompt becomes the first message
+	// of the agent's sidechain, which is how sidechain entries get an agent name
+	taskAgents      map[string]string // Task prompt -> subagent type
+	sidechainAgents map[string]string // Sidechain entry UUID -> subagent type
+}
 
-	return messages[start:end], nil
+func newClaudeStream(fn func(Message) bool) *claudeStream {
+	return &claudeStream{
+		fn:              fn,
+		parents:         make(map[string]string),
+		included:        make(map[string]bool),
+		seenResponses:   make(map[string]map[string]interface{}),
+		taskAgents:      make(map[string]string),
+		sidechainAgents: make(map[string]string),
+	}
 }
 
-// readAllMessages reads all messages from a Claude Code session file.
-func (c *ClaudeAdapter) readAllMessages(filePath string) ([]Message, error) {
+// readFile emits the messages of one file, stopping early once fn returns false.
+func (s *claudeStream) readFile(filePath string) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open session file: %w", err)
+		return fmt.Errorf("failed to open session file: %w", err)
 	}
 	defer file.Close()
 
-	var messages []Message
 	scanner := bufio.NewScanner(file)
 
 	// Increase buffer size for large messages
 	buf := make([]byte, 0, 1024*1024) // 1MB buffer
 	scanner.Buffer(buf, 10*1024*1024) // Max 10MB per line
 
-	for scanner.Scan() {
+	for !s.stopped && scanner.Scan() {
 		var msg claudeMessage
 		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
 			continue // Skip malformed lines
 		}
+		if message, ok := s.toMessage(msg); ok && !s.fn(message) {
+			s.stopped = true
+		}
+	}
 
-		// Only process user and assistant messages
-		if msg.Type != "user" && msg.Type != "assistant" {
-			continue
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading session file: %w", err)
+	}
+	return nil
+}
+
+// toMessage converts an entry into a message. Only user and assistant entries and system
+// events become messages. Sidechain entries and system events are flagged in metadata and
+// carry no uuid, so they stay out of the conversation tree.
+func (s *claudeStream) toMessage(msg claudeMessage) (Message, bool) {
+	if msg.UUID != "" {
+		s.parents[msg.UUID] = msg.ParentUUID
+	}
+
+	if msg.Type == "system" {
+		return claudeSystemEvent(msg)
+	}
+
+	// Only process user and assistant messages
+	if msg.Type != "user" && msg.Type != "assistant" {
+		return Message{}, false
+	}
+
+	// Handle both old and new message formats
+	content := msg.Content
+	role := msg.Type
+	if msg.Message != nil {
+		content = msg.Message.Content
+		role = msg.Message.Role
+	}
+
+	message := Message{
+		Role:     role,
+		Content:  contentToString(content),
+		Metadata: make(map[string]interface{}),
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil {
+		message.Timestamp = ts
+	}
+
+	applyClaudeContentBlocks(&message, content)
+
+	if msg.IsSidechain {
+		message.Metadata["is_sidechain"] = true
+		if msg.AgentID != "" {
+			message.Metadata["agent_id"] = msg.AgentID
+		}
+		agent := s.sidechainAgents[msg.ParentUUID]
+		if agent == "" && role == "user" {
+			agent = s.taskAgents[strings.TrimSpace(message.Content)]
+		}
+		if agent != "" {
+			message.Metadata["agent_name"] = agent
+			if msg.UUID != "" {
+				s.sidechainAgents[msg.UUID] = agent
+			}
+		}
+	} else if msg.UUID != "" {
+		message.Metadata["uuid"] = msg.UUID
+		// Point the message at its nearest ancestor that is also a message
+		if parent := nearestIncludedAncestor(msg.UUID, s.parents, s.included); parent != "" {
+			message.Metadata["parent_uuid"] = parent
 		}
+		s.included[msg.UUID] = true
+	}
 
-		// Skip sidechain messages
-		if msg.IsSidechain {
+	for _, call := range message.ToolCalls {
+		if call.Name != "Task" {
 			continue
 		}
+		prompt, _ := call.Input["prompt"].(string)
+		agent, _ := call.Input["subagent_type"].(string)
+		if prompt != "" && agent != "" {
+			s.taskAgents[strings.TrimSpace(prompt)] = agent
+		}
+	}
 
-		// Handle both old and new message formats
-		content := msg.Content
-		role := msg.Type
-		if msg.Message != nil {
-			content = msg.Message.Content
-			role = msg.Message.Role
+	if msg.Message != nil && msg.Message.Model != "" && msg.Message.Model != "<synthetic>" {
+		message.Metadata["model"] = msg.Message.Model
+		if len(msg.Message.Usage) > 0 {
+			if earlier := s.seenResponses[msg.Message.ID]; earlier != nil {
+				delete(earlier, "usage")
+			}
+			message.Metadata["usage"] = msg.Message.Usage
+			if msg.Message.ID != "" {
+				s.seenResponses[msg.Message.ID] = message.Metadata
+			}
 		}
+	}
+
+	// Add any additional metadata
+	if role == "assistant" {
+		// Preserve structured content for tool calls, thinking blocks, etc.
+		message.Metadata["raw_content"] = content
+	} else if isToolResultContent(content) {
+		// User entries that only carry tool results are tool output, not human input
+		message.Metadata["is_tool_result"] = true
+		message.Metadata["raw_content"] = content
+	}
+
+	return message, true
+}
+
+// ansiEscape matches the terminal color codes Claude Code writes into system messages
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
 
-		message := Message{
-			Role:     role,
-			Content:  contentToString(content),
-			Metadata: make(map[string]interface{}),
+// claudeHookEvents are the hook events Claude Code reports in system entries
+var claudeHookEvents = []string{
+	"PreToolUse", "PostToolUse", "UserPromptSubmit", "Notification", "Stop",
+	"SubagentStop", "PreCompact", "SessionStart", "SessionEnd",
+}
+
+// isClaudeSystemEvent reports whether a system entry has anything to show, and becomes a
+// message
+func isClaudeSystemEvent(msg claudeMessage) bool {
+	return msg.Subtype != "" || strings.TrimSpace(ansiEscape.ReplaceAllString(contentToString(msg.Content), "")) != ""
+}
+
+// claudeSystemEvent converts a system entry (hook output, compaction, command output, ...)
+// into a "system" message. The kind of event is recorded as metadata "event", and hook
+// events also record the hook event name as "hook_event".
+func claudeSystemEvent(msg claudeMessage) (Message, bool) {
+	if !isClaudeSystemEvent(msg) {
+		return Message{}, false
+	}
+	text := strings.TrimSpace(ansiEscape.ReplaceAllString(contentToString(msg.Content), ""))
+
+	message := Message{
+		Role:     "system",
+		Content:  text,
+		Metadata: map[string]interface{}{"event": "system"},
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, msg.Timestamp); err == nil {
+		message.Timestamp = ts
+	}
+	if msg.Subtype != "" {
+		message.Metadata["event"] = msg.Subtype
+	}
+	if msg.Level != "" {
+		message.Metadata["level"] = msg.Level
+	}
+	if msg.ToolUseID != "" {
+		message.Metadata["tool_use_id"] = msg.ToolUseID
+	}
+	if msg.IsSidechain {
+		message.Metadata["is_sidechain"] = true
+	}
+
+	for _, event := range claudeHookEvents {
+		if text == event || strings.HasPrefix(text, event+":") || strings.HasPrefix(text, event+" ") ||
+			strings.EqualFold(strings.TrimSuffix(msg.Subtype, "_hook_summary"), event) {
+			message.Metadata["hook_event"] = event
+			break
 		}
+	}
+	return message, true
+}
 
-		// Add any additional metadata
-		if role == "assistant" {
-			// Preserve structured content for tool calls, thinking blocks, etc.
-			message.Metadata["raw_content"] = content
+// nearestIncludedAncestor follows parent links from uuid until it reaches an included entry.
+func nearestIncludedAncestor(uuid string, parents map[string]string, included map[string]bool) string {
+	seen := map[string]bool{uuid: true}
+	for parent := parents[uuid]; parent != ""; parent = parents[parent] {
+		if seen[parent] {
+			return "" // Malformed cycle
 		}
+		seen[parent] = true
+		if included[parent] {
+			return parent
+		}
+	}
+	return ""
+}
 
-		messages = append(messages, message)
+// applyClaudeContentBlocks fills a message's tool calls, tool results, thinking, and
+// attachments from Claude's structured content blocks.
+func applyClaudeContentBlocks(message *Message, content interface{}) {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading session file: %w", err)
+	var thinking []string
+	for _, item := range blocks {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		switch block["type"] {
+		case "thinking":
+			if text, ok := block["thinking"].(string); ok && strings.TrimSpace(text) != "" {
+				thinking = append(thinking, text)
+			}
+		case "tool_use":
+			call := ToolCall{}
+			call.ID, _ = block["id"].(string)
+			call.Name, _ = block["name"].(string)
+			call.Input, _ = block["input"].(map[string]interface{})
+			message.ToolCalls = append(message.ToolCalls, call)
+		case "tool_result":
+			result := ToolResult{Output: contentToString(block["content"])}
+			result.ToolCallID, _ = block["tool_use_id"].(string)
+			result.IsError, _ = block["is_error"].(bool)
+			message.ToolResults = append(message.ToolResults, result)
+			// Screenshots and other images can come back inside tool results
+			if nested, ok := block["content"].([]interface{}); ok {
+				for _, n := range nested {
+					if m, ok := n.(map[string]interface{}); ok && m["type"] == "image" {
+						message.Attachments = append(message.Attachments, claudeAttachment(m))
+					}
+				}
+			}
+		case "image", "document":
+			message.Attachments = append(message.Attachments, claudeAttachment(block))
+		}
+	}
+	message.Thinking = strings.Join(thinking, "\n\n")
+}
+
+// claudeAttachment describes an image or document content block without its data.
+func claudeAttachment(block map[string]interface{}) Attachment {
+	attachment := Attachment{Type: "image"}
+	if block["type"] == "document" {
+		attachment.Type = "file"
 	}
+	attachment.Name, _ = block["title"].(string)
 
-	return messages, nil
+	source, _ := block["source"].(map[string]interface{})
+	attachment.MediaType, _ = source["media_type"].(string)
+	if data, ok := source["data"].(string); ok && source["type"] == "base64" {
+		attachment.Size = len(data) * 3 / 4
+	}
+	if url, ok := source["url"].(string); ok && attachment.Name == "" {
+		attachment.Name = url
+	}
+	return attachment
+}
+
+// claudeToolUseCount counts the tool_use blocks of an assistant entry's content
+func claudeToolUseCount(content interface{}) int {
+	blocks, ok := content.([]interface{})
+	if !ok {
+		return 0
+	}
+	count := 0
+	for _, block := range blocks {
+		if m, ok := block.(map[string]interface{}); ok && m["type"] == "tool_use" {
+			count++
+		}
+	}
+	return count
+}
+
+// isToolResultContent reports whether content consists solely of tool_result blocks.
+func isToolResultContent(content interface{}) bool {
+	blocks, ok := content.([]interface{})
+	if !ok || len(blocks) == 0 {
+		return false
+	}
+	for _, item := range blocks {
+		m, ok := item.(map[string]interface{})
+		if !ok || m["type"] != "tool_result" {
+			return false
+		}
+	}
+	return true
 }
 
 // contentToString converts various content formats to a plain string.
@@ -0,0 +1,304 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeClaudeSession(t *testing.T, dir, id string, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(dir, id+".jsonl")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("write session: %v", err)
+	}
+	return path
+}
+
+func TestClaudeReadAllMessagesLinksParents(t *testing.T) {
+	path := writeClaudeSession(t, t.TempDir(), "s1",
+		`{"type":"user","uuid":"u1","timestamp":"2025-01-02T10:00:00.000Z","message":{"role":"user","content":"hello"}}`,
+		`{"type":"system","uuid":"sys1","parentUuid":"u1","content":"hook ran"}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"sys1","timestamp":"2025-01-02T10:00:05.000Z","message":{"role":"assistant","content":[{"type":"text","text":"hi"}]}}`,
+	)
+
+	messages, err := (&ClaudeAdapter{}).readAllMessages(path)
+	if err != nil {
+		t.Fatalf("readAllMessages failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	if messages[1].Role != "system" || messages[1].Metadata["uuid"] != nil {
+		t.Fatalf("system entry should be a system message outside the tree: %+v", messages[1])
+	}
+	if messages[2].Metadata["uuid"] != "a1" || messages[2].Metadata["parent_uuid"] != "u1" {
+		t.Fatalf("parent should skip the system entry: %+v", messages[2].Metadata)
+	}
+	if _, ok := messages[0].Metadata["parent_uuid"]; ok {
+		t.Fatal("root message should have no parent")
+	}
+	if messages[0].Timestamp.IsZero() || messages[2].Timestamp.Sub(messages[0].Timestamp).Seconds() != 5 {
+		t.Fatalf("timestamps not parsed: %v %v", messages[0].Timestamp, messages[2].Timestamp)
+	}
+}
+
+func TestClaudeReadAllMessagesFlagsSidechainsAndHooks(t *testing.T) {
+	dir := t.TempDir()
+	path := writeClaudeSession(t, dir, "s1",
+		`{"type":"user","uuid":"u1","sessionId":"s1","message":{"role":"user","content":"review the diff"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"s1","message":{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Task","input":{"subagent_type":"code-reviewer","prompt":"Review the staged changes"}}]}}`,
+		`{"type":"user","uuid":"sc1","isSidechain":true,"sessionId":"s1","message":{"role":"user","content":"Review the staged changes"}}`,
+		`{"type":"assistant","uuid":"sc2","parentUuid":"sc1","isSidechain":true,"sessionId":"s1","message":{"role":"assistant","content":[{"type":"text","text":"Looks good"}]}}`,
+		`{"type":"system","uuid":"h1","parentUuid":"a1","subtype":"informational","level":"info","toolUseID":"t1","content":"\u001b[1mPostToolUse\u001b[22m [lint] completed successfully"}`,
+	)
+	writeClaudeSession(t, dir, "agent-9f2c",
+		`{"type":"user","uuid":"ag1","isSidechain":true,"agentId":"9f2c","sessionId":"s1","message":{"role":"user","content":"Explore the repo"}}`)
+	writeClaudeSession(t, dir, "agent-other",
+		`{"type":"user","uuid":"ot1","isSidechain":true,"sessionId":"s2","message":{"role":"user","content":"unrelated"}}`)
+
+	messages, err := (&ClaudeAdapter{}).readAllMessages(path)
+	if err != nil {
+		t.Fatalf("readAllMessages failed: %v", err)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("expected 6 messages, got %d: %+v", len(messages), messages)
+	}
+
+	for _, i := range []int{2, 3} {
+		meta := messages[i].Metadata
+		if meta["is_sidechain"] != true || meta["agent_name"] != "code-reviewer" || meta["uuid"] != nil {
+			t.Fatalf("message %d should be a sidechain of code-reviewer: %+v", i, meta)
+		}
+	}
+
+	hook := messages[4]
+	if hook.Role != "system" || hook.Content != "PostToolUse [lint] completed successfully" {
+		t.Fatalf("unexpected hook message: %+v", hook)
+	}
+	if hook.Metadata["hook_event"] != "PostToolUse" || hook.Metadata["event"] != "informational" || hook.Metadata["tool_use_id"] != "t1" {
+		t.Fatalf("unexpected hook metadata: %+v", hook.Metadata)
+	}
+
+	agent := messages[5]
+	if agent.Content != "Explore the repo" || agent.Metadata["agent_id"] != "9f2c" || agent.Metadata["is_sidechain"] != true {
+		t.Fatalf("expected the session's agent transcript to follow it: %+v", agent)
+	}
+}
+
+func TestClaudeListSessionsSkipsAgentTranscripts(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "main",
+		`{"type":"user","uuid":"u1","cwd":"/work/app","sessionId":"main","message":{"role":"user","content":"start"}}`)
+	writeClaudeSession(t, projectDir, "agent-1",
+		`{"type":"user","uuid":"g1","cwd":"/work/app","isSidechain":true,"sessionId":"main","message":{"role":"user","content":"subtask"}}`)
+
+	sessions, err := (&ClaudeAdapter{homeDir: home}).ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "main" {
+		t.Fatalf("expected only the main session, got %+v", sessions)
+	}
+}
+
+func TestClaudeListSessionsDedupesResumedCopies(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	first := `{"type":"user","uuid":"u1","cwd":"/work/app","message":{"role":"user","content":"fix the login bug"}}`
+	reply := `{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"on it"}}`
+	writeClaudeSession(t, projectDir, "original", first, reply)
+	writeClaudeSession(t, projectDir, "resumed", first, reply,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":"now add a test"}}`)
+	writeClaudeSession(t, projectDir, "unrelated",
+		`{"type":"user","uuid":"x1","cwd":"/work/app","message":{"role":"user","content":"update docs"}}`)
+
+	adapter := &ClaudeAdapter{homeDir: home}
+	sessions, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+
+	ids := make(map[string]bool)
+	for _, s := range sessions {
+		ids[s.ID] = true
+	}
+	if len(sessions) != 2 || !ids["resumed"] || !ids["unrelated"] {
+		t.Fatalf("expected the resumed copy to replace the original, got %v", ids)
+	}
+}
+
+func TestClaudeListSessionsDetectsContinuations(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	writeClaudeSession(t, projectDir, "day1",
+		`{"type":"user","uuid":"u1","sessionId":"day1","cwd":"/work/app","message":{"role":"user","content":"start the migration"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","sessionId":"day1","message":{"role":"assistant","content":"started"}}`)
+	writeClaudeSession(t, projectDir, "day2",
+		`{"type":"summary","summary":"Database migration","leafUuid":"a1"}`,
+		`{"type":"user","uuid":"u2","sessionId":"day2","cwd":"/work/app","message":{"role":"user","content":"finish the migration"}}`)
+	writeClaudeSession(t, projectDir, "day3",
+		`{"type":"user","uuid":"v1","sessionId":"day2","cwd":"/work/app","message":{"role":"user","content":"finish the migration"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"v1","sessionId":"day3","message":{"role":"user","content":"and clean up"}}`)
+
+	sessions, err := (&ClaudeAdapter{homeDir: home}).ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+
+	continued := make(map[string]string)
+	for _, s := range sessions {
+		continued[s.ID] = s.ContinuedFrom
+	}
+	if continued["day1"] != "" || continued["day2"] != "day1" || continued["day3"] != "day2" {
+		t.Fatalf("unexpected continuation links: %v", continued)
+	}
+}
+
+func TestApplyClaudeContentBlocks(t *testing.T) {
+	var assistant Message
+	applyClaudeContentBlocks(&assistant, []interface{}{
+		map[string]interface{}{"type": "thinking", "thinking": "check the config first"},
+		map[string]interface{}{"type": "text", "text": "Reading the config."},
+		map[string]interface{}{"type": "tool_use", "id": "t1", "name": "Read", "input": map[string]interface{}{"file_path": "config.yml"}},
+	})
+	if assistant.Thinking != "check the config first" {
+		t.Fatalf("unexpected thinking: %q", assistant.Thinking)
+	}
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "t1" || assistant.ToolCalls[0].Input["file_path"] != "config.yml" {
+		t.Fatalf("unexpected tool calls: %+v", assistant.ToolCalls)
+	}
+
+	var user Message
+	applyClaudeContentBlocks(&user, []interface{}{
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "t1", "is_error": true, "content": []interface{}{
+			map[string]interface{}{"type": "text", "text": "file not found"},
+		}},
+		map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "AAAAAAAA"}},
+	})
+	if len(user.ToolResults) != 1 || user.ToolResults[0].Output != "file not found" || !user.ToolResults[0].IsError {
+		t.Fatalf("unexpected tool results: %+v", user.ToolResults)
+	}
+	if len(user.Attachments) != 1 || user.Attachments[0].MediaType != "image/png" || user.Attachments[0].Size != 6 {
+		t.Fatalf("unexpected attachments: %+v", user.Attachments)
+	}
+}
+
+func TestClaudeReadAllMessagesRecordsUsageOncePerResponse(t *testing.T) {
+	// Streamed responses repeat a snapshot of their usage on each entry, final last
+	partial := `"usage":{"input_tokens":10,"output_tokens":1,"cache_read_input_tokens":2000}`
+	final := `"usage":{"input_tokens":10,"output_tokens":50,"cache_read_input_tokens":2000}`
+	path := writeClaudeSession(t, t.TempDir(), "s1",
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"hi"}}`,
+		`{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5-20250929",`+partial+`,"content":[{"type":"text","text":"Let me look."}]}}`,
+		`{"type":"assistant","uuid":"a2","parentUuid":"a1","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5-20250929",`+final+`,"content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}]}}`,
+	)
+
+	messages, err := (&ClaudeAdapter{}).readAllMessages(path)
+	if err != nil {
+		t.Fatalf("readAllMessages failed: %v", err)
+	}
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d", len(messages))
+	}
+	usage, ok := messages[2].Metadata["usage"].(map[string]interface{})
+	if !ok || usage["output_tokens"] != 50.0 || messages[2].Metadata["model"] != "claude-sonnet-4-5-20250929" {
+		t.Fatalf("last entry of a response should carry model and final usage: %+v", messages[2].Metadata)
+	}
+	if _, ok := messages[1].Metadata["usage"]; ok {
+		t.Fatal("usage repeated on earlier entries of the same response would be double counted")
+	}
+}
+
+func TestClaudeListSessionsContextStopsWhenCancelled(t *testing.T) {
+	dataDir := t.TempDir()
+	projectDir := filepath.Join(dataDir, "projects", projectDirName("/work/app"))
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "s1",
+		`{"type":"user","uuid":"u1","cwd":"/work/app","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"hello"}}`)
+	adapter := NewGuardedAdapter(&ClaudeAdapter{dataDir: dataDir}, Guard{DenyProjects: []string{"/work/secret"}})
+
+	if sessions, err := ListSessionsContext(context.Background(), adapter, "", 0); err != nil || len(sessions) != 1 {
+		t.Fatalf("expected one session, got %+v (%v)", sessions, err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, projectPath := range []string{"", "/work/app"} {
+		if _, err := ListSessionsContext(ctx, adapter, projectPath, 0); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected listing %q to stop with context.Canceled, got %v", projectPath, err)
+		}
+	}
+}
+
+func TestClaudeListSessionsSkipsCommandPreambles(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "main",
+		`{"type":"user","uuid":"u1","cwd":"/work/app","message":{"role":"user","content":"Caveat: The messages below were generated by the user while running local commands."}}`,
+		`{"type":"user","uuid":"u2","parentUuid":"u1","message":{"role":"user","content":"<command-name>/model</command-name>\n<command-message>model</command-message>\n<command-args></command-args>"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"u2","message":{"role":"user","content":"<local-command-stdout>Set model to opus</local-command-stdout>"}}`,
+		`{"type":"user","uuid":"u4","parentUuid":"u3","message":{"role":"user","content":"/clear"}}`,
+		`{"type":"user","uuid":"u5","parentUuid":"u4","message":{"role":"user","content":[{"type":"text","text":"<ide_selection>func main()</ide_selection>"},{"type":"text","text":"Why does this panic?"}]}}`)
+
+	sessions, err := (&ClaudeAdapter{homeDir: home}).ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].FirstMessage != "Why does this panic?" || sessions[0].UserMessageCount != 1 {
+		t.Fatalf("expected the first message the user wrote, got %+v", sessions)
+	}
+}
+
+func TestClaudeListSessionsCountsActivity(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "main",
+		`{"type":"user","uuid":"u1","timestamp":"2025-03-01T09:00:00Z","message":{"role":"user","content":"Fix the build"}}`,
+		`{"type":"assistant","uuid":"a1","timestamp":"2025-03-01T09:00:05Z","message":{"id":"r1","role":"assistant","content":[{"type":"text","text":"Looking."}]}}`,
+		`{"type":"assistant","uuid":"a2","timestamp":"2025-03-01T09:00:06Z","message":{"id":"r1","role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Bash","input":{"command":"go build"}}]}}`,
+		`{"type":"user","uuid":"u2","timestamp":"2025-03-01T09:00:10Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]}}`,
+		`{"type":"assistant","uuid":"a3","timestamp":"2025-03-01T09:00:20Z","message":{"id":"r2","role":"assistant","content":[{"type":"tool_use","id":"t2","name":"Read","input":{}},{"type":"tool_use","id":"t3","name":"Read","input":{}}]}}`,
+		`{"type":"assistant","uuid":"s1","isSidechain":true,"timestamp":"2025-03-01T11:00:00Z","message":{"id":"r3","role":"assistant","content":[{"type":"tool_use","id":"t4","name":"Grep","input":{}}]}}`,
+		`{"type":"user","uuid":"u3","timestamp":"2025-03-01T09:30:00Z","message":{"role":"user","content":"Now run the tests"}}`,
+		`{"type":"assistant","uuid":"a4","timestamp":"2025-03-01T09:31:00Z","message":{"id":"r4","role":"assistant","content":[{"type":"text","text":"Done."}]}}`)
+
+	sessions, err := (&ClaudeAdapter{homeDir: home}).ListSessions("", 0)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("ListSessions failed: %+v (%v)", sessions, err)
+	}
+	session := sessions[0]
+	if session.DurationSeconds != 31*60 || session.AssistantTurns != 2 || session.ToolCallCount != 3 {
+		t.Fatalf("expected 31 minutes, 2 turns and 3 tool calls, got %ds, %d turns, %d tool calls",
+			session.DurationSeconds, session.AssistantTurns, session.ToolCallCount)
+	}
+	info, err := os.Stat(session.FilePath)
+	if err != nil {
+		t.Fatalf("stat session file: %v", err)
+	}
+	if session.MessageCount != 8 || session.FileSize != info.Size() {
+		t.Fatalf("expected 8 messages and %d bytes, got %d messages and %d bytes", info.Size(), session.MessageCount, session.FileSize)
+	}
+}
@@ -3,6 +3,7 @@ package adapters
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -16,7 +17,9 @@ import (
 // Codex stores sessions as JSONL files in ~/.codex/sessions and ~/.codex/archived_sessions
 // Files are named rollout-*.jsonl and contain structured log entries.
 type CodexAdapter struct {
+	metadataCaching
 	homeDir string
+	dataDir string // Codex's data directory; ~/.codex when empty
 }
 
 // NewCodexAdapter creates a new Codex CLI session adapter.
@@ -25,7 +28,19 @@ func NewCodexAdapter() (*CodexAdapter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
-	return &CodexAdapter{homeDir: homeDir}, nil
+	return &CodexAdapter{homeDir: homeDir, dataDir: codexDataDir(homeDir)}, nil
+}
+
+// sessionDirs returns the directories holding active and archived rollout files.
+func (c *CodexAdapter) sessionDirs() []string {
+	codexHome := c.dataDir
+	if codexHome == "" {
+		codexHome = filepath.Join(c.homeDir, ".codex")
+	}
+	return []string{
+		filepath.Join(codexHome, "sessions"),
+		filepath.Join(codexHome, "archived_sessions"),
+	}
 }
 
 // Name returns the adapter name.
@@ -49,6 +64,13 @@ type sessionInfo struct {
 	SessionMetaTimestamp  string
 	FilePath              string
 	UserMessageCount      int
+	MessageCount          int
+	FileSize              int64
+	DurationSeconds       int64
+	AssistantTurns        int
+	ToolCallCount         int
+	ContinuedFrom         string // ID of an earlier session whose history this rollout carries
+	Branch                string // Git branch recorded when the session started
 }
 
 // parseCodexTimestamp parses timestamps produced by Codex rollout files.
@@ -74,26 +96,23 @@ func parseCodexTimestamp(ts string) (time.Time, error) {
 // ListSessions returns all Codex sessions for the given project.
 // If projectPath is empty, returns sessions from ALL projects.
 func (c *CodexAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
-	codexHome := filepath.Join(c.homeDir, ".codex")
-	sessionDirs := []string{
-		filepath.Join(codexHome, "sessions"),
-		filepath.Join(codexHome, "archived_sessions"),
-	}
+	return c.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (c *CodexAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	sessionDirs := c.sessionDirs()
 
 	// If no project path specified, list sessions from ALL projects
 	if projectPath == "" {
-		return c.listAllSessions(sessionDirs, limit)
+		return c.listAllSessions(ctx, sessionDirs, limit)
 	}
 
 	// Get absolute path and resolve symlinks
-	projectPath, err := filepath.Abs(projectPath)
-	if err != nil {
+	if _, err := filepath.Abs(projectPath); err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
-	projectPath, err = filepath.EvalSymlinks(projectPath)
-	if err != nil {
-		projectPath, _ = filepath.Abs(projectPath) // Fallback if symlink resolution fails
-	}
+	projectPath = CanonicalProjectPath(projectPath)
 
 	// Find all rollout files
 	var allFiles []string
@@ -112,7 +131,10 @@ func (c *CodexAdapter) ListSessions(projectPath string, limit int) ([]Session, e
 	// Parse each file and filter by project path
 	var sessions []Session
 	for _, file := range allFiles {
-		info, err := c.scanRolloutFile(file, projectPath)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := c.loadRolloutFile(file)
 		if err != nil || !info.CWDMatches(projectPath) {
 			continue
 		}
@@ -123,7 +145,14 @@ func (c *CodexAdapter) ListSessions(projectPath string, limit int) ([]Session, e
 			ProjectPath:      projectPath,
 			FirstMessage:     info.FirstUserMessage,
 			UserMessageCount: info.UserMessageCount,
+			MessageCount:     info.MessageCount,
+			FileSize:         info.FileSize,
+			DurationSeconds:  info.DurationSeconds,
+			AssistantTurns:   info.AssistantTurns,
+			ToolCallCount:    info.ToolCallCount,
+			Branch:           info.Branch,
 			FilePath:         info.FilePath,
+			ContinuedFrom:    info.ContinuedFrom,
 		}
 
 		// Parse timestamp
@@ -152,7 +181,7 @@ func (c *CodexAdapter) ListSessions(projectPath string, limit int) ([]Session, e
 }
 
 // listAllSessions lists sessions from all projects.
-func (c *CodexAdapter) listAllSessions(sessionDirs []string, limit int) ([]Session, error) {
+func (c *CodexAdapter) listAllSessions(ctx context.Context, sessionDirs []string, limit int) ([]Session, error) {
 	var allFiles []string
 	for _, dir := range sessionDirs {
 		files, err := c.findRolloutFiles(dir)
@@ -168,7 +197,10 @@ func (c *CodexAdapter) listAllSessions(sessionDirs []string, limit int) ([]Sessi
 
 	var allSessions []Session
 	for _, file := range allFiles {
-		info, err := c.scanRolloutFile(file, "")
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		info, err := c.loadRolloutFile(file)
 		if err != nil || info.CWD == "" {
 			continue
 		}
@@ -176,10 +208,17 @@ func (c *CodexAdapter) listAllSessions(sessionDirs []string, limit int) ([]Sessi
 		session := Session{
 			ID:               info.ID,
 			Source:           "codex",
-			ProjectPath:      info.CWD,
+			ProjectPath:      CanonicalProjectPath(info.CWD),
 			FirstMessage:     info.FirstUserMessage,
 			UserMessageCount: info.UserMessageCount,
+			MessageCount:     info.MessageCount,
+			FileSize:         info.FileSize,
+			DurationSeconds:  info.DurationSeconds,
+			AssistantTurns:   info.AssistantTurns,
+			ToolCallCount:    info.ToolCallCount,
+			Branch:           info.Branch,
 			FilePath:         info.FilePath,
+			ContinuedFrom:    info.ContinuedFrom,
 		}
 
 		// Parse timestamp
@@ -222,6 +261,18 @@ func (c *CodexAdapter) findRolloutFiles(root string) ([]string, error) {
 	return files, err
 }
 
+// loadRolloutFile returns the session information of a rollout file, from the metadata
+// cache when the file hasn't changed since it was last scanned.
+func (c *CodexAdapter) loadRolloutFile(filePath string) (*sessionInfo, error) {
+	return cachedMetadata(&c.metadataCaching, filePath, "", func() (*sessionInfo, error) {
+		info, err := c.scanRolloutFile(filePath, "")
+		if err == nil {
+			c.recordSessionFile("codex", info.ID, filePath)
+		}
+		return info, err
+	})
+}
+
 // scanRolloutFile scans a Codex rollout file to extract session information.
 // It reads until it finds both the CWD and the first user message.
 func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo, error) {
@@ -234,6 +285,7 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 
 	info := &sessionInfo{
 		FilePath: filePath,
+		FileSize: int64(len(fileData)),
 	}
 
 	// Fast check: does this file contain ANY user messages?
@@ -294,11 +346,15 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 	buf := make([]byte, 0, 1024*1024) // 1MB buffer
 	scanner.Buffer(buf, 10*1024*1024) // Max 10MB per line
 
+	var activity sessionActivity
 	for scanner.Scan() {
 		var entry codexEntry
 		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
 			continue // Skip malformed lines
 		}
+		if ts, err := parseCodexTimestamp(entry.Timestamp); err == nil {
+			activity.seen(ts)
+		}
 
 		switch entry.Type {
 		case "session_meta":
@@ -312,10 +368,16 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 			}
 			if id, ok := entry.Payload["id"].(string); ok && info.ID == "" {
 				info.ID = id
+			} else if ok && id != info.ID && info.ContinuedFrom == "" {
+				// Resumed and forked rollouts replay the original session_meta
+				info.ContinuedFrom = id
 			}
 			if ts, ok := entry.Payload["timestamp"].(string); ok && info.SessionMetaTimestamp == "" {
 				info.SessionMetaTimestamp = ts
 			}
+			if git, ok := entry.Payload["git"].(map[string]interface{}); ok && info.Branch == "" {
+				info.Branch, _ = git["branch"].(string)
+			}
 
 		case "turn_context":
 			if cwd, ok := entry.Payload["cwd"].(string); ok && info.CWD == "" {
@@ -327,6 +389,20 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 			}
 
 		case "response_item":
+			if c.isMessageItem(entry.Payload) {
+				info.MessageCount++
+			}
+
+			// Tool invocations are recorded as their own response items
+			if _, ok := codexToolCall(entry.Payload); ok {
+				activity.assistantMessage(1)
+				continue
+			}
+			if entry.Payload["type"] == "message" && entry.Payload["role"] == "assistant" {
+				activity.assistantMessage(0)
+				continue
+			}
+
 			// Look for first user message
 			if riType, ok := entry.Payload["type"].(string); ok && riType == "message" {
 				if role, ok := entry.Payload["role"].(string); ok && role == "user" {
@@ -338,9 +414,10 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 						}
 
 						info.UserMessageCount++
+						activity.userMessage()
 
 						if info.FirstUserMessage == "" {
-							info.FirstUserMessage = c.extractFirstLine(text)
+							info.FirstUserMessage = FirstMessageLine(text)
 							info.FirstMessageTimestamp = entry.Timestamp
 							if info.FirstMessageTimestamp == "" {
 								info.FirstMessageTimestamp = info.SessionMetaTimestamp
@@ -356,15 +433,42 @@ func (c *CodexAdapter) scanRolloutFile(filePath, targetCWD string) (*sessionInfo
 		return nil, fmt.Errorf("error scanning rollout file: %w", err)
 	}
 
+	info.DurationSeconds = activity.durationSeconds()
+	info.AssistantTurns = activity.turns
+	info.ToolCallCount = activity.toolCalls
+
 	return info, nil
 }
 
+// isMessageItem reports whether a response item becomes a message when the session is
+// read (see streamMessages)
+func (c *CodexAdapter) isMessageItem(payload map[string]interface{}) bool {
+	if _, ok := codexToolCall(payload); ok {
+		return true
+	}
+	if _, ok := codexReasoning(payload); ok {
+		return true
+	}
+	if _, ok := codexToolResult(payload); ok {
+		return true
+	}
+	role, ok := payload["role"].(string)
+	if !ok || payload["type"] != "message" {
+		return false
+	}
+	if role == "user" {
+		content, _ := payload["content"].([]interface{})
+		return !c.isSessionPrefix(strings.TrimSpace(c.extractUserText(content)))
+	}
+	return true
+}
+
 // CWDMatches checks if the session's CWD matches the target path.
 func (info *sessionInfo) CWDMatches(targetPath string) bool {
 	if info.CWD == "" {
 		return false
 	}
-	return info.CWD == targetPath
+	return sameProjectPath(info.CWD, targetPath)
 }
 
 // extractUserText extracts text from Codex content blocks.
@@ -393,97 +497,199 @@ func (c *CodexAdapter) isSessionPrefix(text string) bool {
 		(strings.HasPrefix(lower, "<environment_context>") && strings.HasSuffix(lower, "</environment_context>"))
 }
 
-// extractFirstLine extracts the first non-empty line from text.
-func (c *CodexAdapter) extractFirstLine(text string) string {
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			if len(trimmed) > 200 {
-				return trimmed[:200] + "..."
-			}
-			return trimmed
-		}
+// GetSession retrieves the full content of a Codex session with pagination.
+func (c *CodexAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	sessionFile := c.findSessionFile(sessionID)
+	if sessionFile == "" {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
 	}
-	return ""
+
+	// Stop reading at the end of the requested page
+	return collectPage(func(fn func(Message) bool) error {
+		return c.streamMessages(sessionFile, fn)
+	}, page, pageSize)
 }
 
-// GetSession retrieves the full content of a Codex session with pagination.
-func (c *CodexAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
-	// Find the session file by scanning all rollout files
-	codexHome := filepath.Join(c.homeDir, ".codex")
-	sessionDirs := []string{
-		filepath.Join(codexHome, "sessions"),
-		filepath.Join(codexHome, "archived_sessions"),
+// StreamSession calls fn with each message of a session, in order, until fn returns false.
+func (c *CodexAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	sessionFile := c.findSessionFile(sessionID)
+	if sessionFile == "" {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return c.streamMessages(sessionFile, fn)
+}
+
+// findSessionFile returns the rollout file of a session, or "" if there is none.
+// Files recorded in the metadata cache while listing are looked up directly. Otherwise the
+// file is found by name, since Codex names rollouts rollout-<timestamp>-<session ID>.jsonl,
+// and rollout contents are only scanned as a last resort (e.g. for renamed files).
+func (c *CodexAdapter) findSessionFile(sessionID string) string {
+	if path := c.lookupSessionFile("codex", sessionID); path != "" {
+		return path
+	}
+
+	sessionDirs := c.sessionDirs()
+
+	suffix := "-" + sessionID + ".jsonl"
+	for _, dir := range sessionDirs {
+		var found string
+		_ = filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip inaccessible files
+			}
+			if d.IsDir() || !strings.HasPrefix(d.Name(), "rollout-") || !strings.HasSuffix(d.Name(), suffix) {
+				return nil
+			}
+			// The ID could be the tail of a longer ID, so confirm it
+			if info, err := c.loadRolloutFile(path); err == nil && info.ID == sessionID {
+				found = path
+				return filepath.SkipAll
+			}
+			return nil
+		})
+		if found != "" {
+			return found
+		}
 	}
 
-	var sessionFile string
 	for _, dir := range sessionDirs {
 		files, err := c.findRolloutFiles(dir)
 		if err != nil {
 			continue
 		}
-
 		for _, file := range files {
-			// Quick check: does this file contain the session ID?
-			if info, err := c.scanRolloutFile(file, ""); err == nil && info.ID == sessionID {
-				sessionFile = file
-				break
+			if info, err := c.loadRolloutFile(file); err == nil && info.ID == sessionID {
+				return file
 			}
 		}
-
-		if sessionFile != "" {
-			break
-		}
-	}
-
-	if sessionFile == "" {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
-	}
-
-	// Read all messages from the file
-	messages, err := c.readAllMessages(sessionFile)
-	if err != nil {
-		return nil, err
-	}
-
-	// Apply pagination
-	start := page * pageSize
-	if start >= len(messages) {
-		return []Message{}, nil
-	}
-
-	end := start + pageSize
-	if end > len(messages) {
-		end = len(messages)
 	}
+	return ""
+}
 
-	return messages[start:end], nil
+// ReadSession reads every message of a session listed by ListSessions.
+func (c *CodexAdapter) ReadSession(session Session) ([]Message, error) {
+	return c.readAllMessages(session.FilePath)
 }
 
 // readAllMessages reads all messages from a Codex rollout file.
 func (c *CodexAdapter) readAllMessages(filePath string) ([]Message, error) {
+	return collectAll(func(fn func(Message) bool) error {
+		return c.streamMessages(filePath, fn)
+	})
+}
+
+// streamMessages reads a rollout file one entry at a time, calling fn with each message
+// until fn returns false.
+func (c *CodexAdapter) streamMessages(filePath string, fn func(Message) bool) error {
 	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open rollout file: %w", err)
+		return fmt.Errorf("failed to open rollout file: %w", err)
 	}
 	defer file.Close()
 
-	var messages []Message
+	// Token usage arrives after the response it belongs to, so messages from the latest
+	// assistant message onwards are held back until the next assistant message
+	var pending []Message
+	stopped := false
+	emit := func(message Message) {
+		if message.Role == "assistant" {
+			for _, held := range pending {
+				if !fn(held) {
+					stopped = true
+					return
+				}
+			}
+			pending = pending[:0]
+		}
+		if message.Role == "assistant" || len(pending) > 0 {
+			pending = append(pending, message)
+			return
+		}
+		if !fn(message) {
+			stopped = true
+		}
+	}
+
 	scanner := bufio.NewScanner(file)
 	buf := make([]byte, 0, 1024*1024)
 	scanner.Buffer(buf, 10*1024*1024)
 
-	for scanner.Scan() {
+	var lastTotalTokens float64
+	var model string
+	for !stopped && scanner.Scan() {
 		var entry codexEntry
 		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
 			continue
 		}
 
+		// Each turn records the model it runs with
+		if entry.Type == "turn_context" {
+			if m, ok := entry.Payload["model"].(string); ok && m != "" {
+				model = m
+			}
+			continue
+		}
+
+		// Token usage is reported in separate events after each model response.
+		// Codex repeats the event when nothing changed, so only count it when the running total moves.
+		if entry.Type == "event_msg" {
+			if usage, total, ok := codexTokenUsage(entry.Payload); ok && total != lastTotalTokens {
+				lastTotalTokens = total
+				if last := lastAssistantMessage(pending); last != nil {
+					last.Metadata["token_usage"] = usage
+					if model != "" {
+						last.Metadata["model"] = model
+					}
+				}
+			}
+			continue
+		}
+
 		if entry.Type != "response_item" {
 			continue
 		}
 
+		// Tool invocations are recorded as their own response items
+		if call, ok := codexToolCall(entry.Payload); ok {
+			message := Message{
+				Role:      "assistant",
+				ToolCalls: []ToolCall{call},
+				Metadata:  make(map[string]interface{}),
+			}
+			if ts, err := parseCodexTimestamp(entry.Timestamp); err == nil {
+				message.Timestamp = ts
+			}
+			emit(message)
+			continue
+		}
+
+		if thinking, ok := codexReasoning(entry.Payload); ok {
+			message := Message{
+				Role:     "assistant",
+				Thinking: thinking,
+				Metadata: make(map[string]interface{}),
+			}
+			if ts, err := parseCodexTimestamp(entry.Timestamp); err == nil {
+				message.Timestamp = ts
+			}
+			emit(message)
+			continue
+		}
+
+		if result, ok := codexToolResult(entry.Payload); ok {
+			message := Message{
+				Role:        "tool",
+				Content:     result.Output,
+				ToolResults: []ToolResult{result},
+				Metadata:    make(map[string]interface{}),
+			}
+			if ts, err := parseCodexTimestamp(entry.Timestamp); err == nil {
+				message.Timestamp = ts
+			}
+			emit(message)
+			continue
+		}
+
 		if riType, ok := entry.Payload["type"].(string); ok && riType == "message" {
 			if role, ok := entry.Payload["role"].(string); ok {
 				message := Message{
@@ -500,6 +706,7 @@ func (c *CodexAdapter) readAllMessages(filePath string) ([]Message, error) {
 				if content, ok := entry.Payload["content"].([]interface{}); ok {
 					if role == "user" {
 						message.Content = c.extractUserText(content)
+						message.Attachments = codexAttachments(content)
 					} else {
 						// For assistant messages, extract all text parts
 						message.Content = c.extractAllText(content)
@@ -512,16 +719,181 @@ func (c *CodexAdapter) readAllMessages(filePath string) ([]Message, error) {
 					continue
 				}
 
-				messages = append(messages, message)
+				emit(message)
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading rollout file: %w", err)
+		return fmt.Errorf("error reading rollout file: %w", err)
+	}
+
+	for _, held := range pending {
+		if stopped || !fn(held) {
+			break
+		}
+	}
+	return nil
+}
+
+// codexAttachments describes the images attached to a Codex user message.
+func codexAttachments(content []interface{}) []Attachment {
+	var attachments []Attachment
+	for _, item := range content {
+		block, ok := item.(map[string]interface{})
+		if !ok || block["type"] != "input_image" {
+			continue
+		}
+		attachment := Attachment{Type: "image"}
+		url, _ := block["image_url"].(string)
+		if strings.HasPrefix(url, "data:") {
+			// data:<media type>;base64,<data>
+			header, data, _ := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+			attachment.MediaType = strings.TrimSuffix(header, ";base64")
+			attachment.Size = len(data) * 3 / 4
+		} else {
+			attachment.Name = url
+		}
+		attachments = append(attachments, attachment)
+	}
+	return attachments
+}
+
+// codexToolCall converts a function_call, custom_tool_call, local_shell_call, or
+// web_search_call payload into a ToolCall. Function call arguments are JSON-encoded
+// strings, custom tool input is raw text, and built-in tools record an action.
+func codexToolCall(payload map[string]interface{}) (ToolCall, bool) {
+	riType, _ := payload["type"].(string)
+	switch riType {
+	case "function_call", "custom_tool_call":
+	case "local_shell_call", "web_search_call":
+		// Built-in tools record what they did as an action rather than arguments
+		call := ToolCall{Name: strings.TrimSuffix(riType, "_call"), Input: make(map[string]interface{})}
+		call.ID, _ = payload["call_id"].(string)
+		if call.ID == "" {
+			call.ID, _ = payload["id"].(string)
+		}
+		if action, ok := payload["action"].(map[string]interface{}); ok {
+			call.Input = action
+		}
+		return call, true
+	default:
+		return ToolCall{}, false
+	}
+
+	call := ToolCall{Input: make(map[string]interface{})}
+	call.ID, _ = payload["call_id"].(string)
+	call.Name, _ = payload["name"].(string)
+
+	if args, ok := payload["arguments"].(string); ok && args != "" {
+		if err := json.Unmarshal([]byte(args), &call.Input); err != nil {
+			call.Input = map[string]interface{}{"arguments": args}
+		}
+	}
+	if input, ok := payload["input"].(string); ok {
+		call.Input["input"] = input
+	}
+
+	return call, true
+}
+
+// codexReasoning returns the reasoning text of a reasoning response item: its summary, or
+// the raw reasoning when no summary was recorded. Encrypted reasoning has no text.
+func codexReasoning(payload map[string]interface{}) (string, bool) {
+	if payload["type"] != "reasoning" {
+		return "", false
+	}
+	for _, field := range []string{"summary", "content"} {
+		items, _ := payload[field].([]interface{})
+		var parts []string
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				if text, ok := m["text"].(string); ok && strings.TrimSpace(text) != "" {
+					parts = append(parts, strings.TrimSpace(text))
+				}
+			}
+		}
+		if len(parts) > 0 {
+			return strings.Join(parts, "\n\n"), true
+		}
+	}
+	return "", false
+}
+
+// codexToolResult converts a function_call_output or custom_tool_call_output payload into a ToolResult.
+// Shell outputs are usually a JSON-encoded object carrying the exit code in its metadata.
+func codexToolResult(payload map[string]interface{}) (ToolResult, bool) {
+	riType, _ := payload["type"].(string)
+	if riType != "function_call_output" && riType != "custom_tool_call_output" {
+		return ToolResult{}, false
+	}
+
+	result := ToolResult{}
+	result.ToolCallID, _ = payload["call_id"].(string)
+
+	var raw string
+	switch output := payload["output"].(type) {
+	case string:
+		raw = output
+	case map[string]interface{}:
+		if content, ok := output["content"].(string); ok {
+			raw = content
+		}
+		if success, ok := output["success"].(bool); ok && !success {
+			result.IsError = true
+		}
 	}
+	result.Output = raw
 
-	return messages, nil
+	var structured struct {
+		Output   string `json:"output"`
+		Metadata struct {
+			ExitCode *int `json:"exit_code"`
+		} `json:"metadata"`
+	}
+	if strings.HasPrefix(strings.TrimSpace(raw), "{") && json.Unmarshal([]byte(raw), &structured) == nil {
+		result.Output = structured.Output
+		result.ExitCode = structured.Metadata.ExitCode
+	}
+	if result.ExitCode != nil && *result.ExitCode != 0 {
+		result.IsError = true
+	}
+
+	return result, true
+}
+
+// codexTokenUsage extracts the per-response token usage from a token_count event,
+// along with the running session total used to detect repeated events.
+func codexTokenUsage(payload map[string]interface{}) (map[string]interface{}, float64, bool) {
+	if payload["type"] != "token_count" {
+		return nil, 0, false
+	}
+	info, ok := payload["info"].(map[string]interface{})
+	if !ok {
+		return nil, 0, false
+	}
+	last, ok := info["last_token_usage"].(map[string]interface{})
+	if !ok {
+		return nil, 0, false
+	}
+	var total float64
+	if totals, ok := info["total_token_usage"].(map[string]interface{}); ok {
+		total, _ = totals["total_tokens"].(float64)
+	}
+	return last, total, true
+}
+
+// lastAssistantMessage returns the most recent assistant message, if any.
+func lastAssistantMessage(messages []Message) *Message {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "assistant" {
+			if messages[i].Metadata == nil {
+				messages[i].Metadata = make(map[string]interface{})
+			}
+			return &messages[i]
+		}
+	}
+	return nil
 }
 
 // extractAllText extracts all text from content blocks (for assistant messages).
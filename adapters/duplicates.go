@@ -0,0 +1,43 @@
+package adapters
+
+// CollapseDuplicates collapses copies of the same session into one entry. Sessions are
+// copies when they have the same source and ID, such as a session file left in two
+// project directories or mirrored from another machine, or when they have the same
+// content hash. Empty sessions are only collapsed by ID, since they all look alike. The
+// first copy of each session is kept, in the position of that copy, and the others are
+// recorded in its Duplicates.
+func CollapseDuplicates(sessions []Session) []Session {
+	type key struct{ source, kind, value string }
+
+	collapsed := make([]Session, 0, len(sessions))
+	kept := make(map[key]int)
+	for _, session := range sessions {
+		keys := []key{{session.Source, "id", session.ID}}
+		if session.ContentHash != "" && session.FirstMessage != EmptySessionMessage {
+			keys = append(keys, key{session.Source, "content", session.ContentHash})
+		}
+
+		i, found := -1, false
+		for _, k := range keys {
+			if i, found = kept[k]; found {
+				break
+			}
+		}
+		if !found {
+			i = len(collapsed)
+			collapsed = append(collapsed, session)
+		} else {
+			collapsed[i].Duplicates = append(collapsed[i].Duplicates, SessionCopy{
+				ID:       session.ID,
+				FilePath: session.FilePath,
+				Machine:  session.Machine,
+			})
+		}
+		for _, k := range keys {
+			if _, ok := kept[k]; !ok {
+				kept[k] = i
+			}
+		}
+	}
+	return collapsed
+}
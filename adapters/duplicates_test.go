@@ -0,0 +1,41 @@
+package adapters
+
+import "testing"
+
+func TestCollapseDuplicates(t *testing.T) {
+	sessions := []Session{
+		{ID: "a", Source: "claude", FilePath: "/p/new/a.jsonl", FirstMessage: "fix the build"},
+		{ID: "b", Source: "claude", FilePath: "/p/b.jsonl", FirstMessage: "add tests", ContentHash: "h1"},
+		{ID: "a", Source: "claude", FilePath: "/p/old/a.jsonl", FirstMessage: "fix the build"},
+		{ID: "c", Source: "claude", FilePath: "/p/c.jsonl", FirstMessage: "add tests", ContentHash: "h1"},
+		// Same hash from another source is a different session
+		{ID: "d", Source: "codex", FilePath: "/p/d.jsonl", FirstMessage: "add tests", ContentHash: "h1"},
+		// Empty sessions are alike without being copies
+		{ID: "e1", Source: "claude", FirstMessage: EmptySessionMessage, ContentHash: "empty"},
+		{ID: "e2", Source: "claude", FirstMessage: EmptySessionMessage, ContentHash: "empty"},
+		{ID: "a", Source: "claude", FilePath: "/mirror/a.jsonl", Machine: "laptop"},
+	}
+
+	collapsed := CollapseDuplicates(sessions)
+	var ids []string
+	for _, s := range collapsed {
+		ids = append(ids, s.ID)
+	}
+	if len(collapsed) != 5 || ids[0] != "a" || ids[1] != "b" || ids[2] != "d" || ids[3] != "e1" || ids[4] != "e2" {
+		t.Fatalf("unexpected sessions after collapsing: %v", ids)
+	}
+
+	a := collapsed[0]
+	if a.FilePath != "/p/new/a.jsonl" || len(a.Duplicates) != 2 {
+		t.Fatalf("expected the first copy of a with two duplicates, got %+v", a)
+	}
+	if a.Duplicates[0].FilePath != "/p/old/a.jsonl" || a.Duplicates[1].Machine != "laptop" {
+		t.Fatalf("unexpected duplicates of a: %+v", a.Duplicates)
+	}
+	if b := collapsed[1]; len(b.Duplicates) != 1 || b.Duplicates[0].ID != "c" {
+		t.Fatalf("expected c collapsed into b by content, got %+v", b.Duplicates)
+	}
+	if collapsed[2].Duplicates != nil || collapsed[3].Duplicates != nil {
+		t.Fatal("sessions of other sources and empty sessions should not be collapsed")
+	}
+}
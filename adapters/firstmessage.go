@@ -0,0 +1,115 @@
+package adapters
+
+import (
+	"regexp"
+	"strings"
+)
+
+// preambleTags wrap text that agents add to user messages rather than what the user
+// typed: editor context, hook output, and the invocation and output of slash commands
+// and shell escapes (matches SYSTEM_XML_TAGS from
+// claude-transcripts/src/components/TranscriptViewer.tsx, plus Codex's context blocks)
+var preambleTags = []string{
+	"ide_opened_file",
+	"ide_selection",
+	"ide_diagnostics",
+	"post-tool-use-hook",
+	"system-reminder",
+	"user-prompt-submit-hook",
+	"local-command-stdout",
+	"local-command-stderr",
+	"command-name",
+	"command-message",
+	"command-args",
+	"bash-input",
+	"bash-stdout",
+	"bash-stderr",
+	"user_instructions",
+	"environment_context",
+}
+
+// preamblePrefixes start messages that agents write on the user's behalf
+var preamblePrefixes = []string{
+	"Caveat:",                      // Claude's note before the output of local commands
+	"# AGENTS.md instructions for", // Codex's project instructions
+}
+
+// slashCommandPattern matches a slash command typed on its own, such as /clear or
+// /mcp:tools, but not an absolute path
+var slashCommandPattern = regexp.MustCompile(`^/[A-Za-z][\w:-]*$`)
+
+// FirstMessageLine returns the first meaningful line of a user message, shortened for
+// listings. It is empty when the message has nothing the user wrote: only preamble
+// blocks, a note the agent added, an interruption marker such as
+// "[Request interrupted by user]", or a bare slash command. Listings use the first user
+// message for which it isn't empty.
+func FirstMessageLine(text string) string {
+	text = stripSystemXMLTags(text)
+	for _, prefix := range preamblePrefixes {
+		if strings.HasPrefix(text, prefix) {
+			return ""
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+		if slashCommandPattern.MatchString(trimmed) || (strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")) {
+			return ""
+		}
+		return truncateFirstLine(trimmed)
+	}
+	return ""
+}
+
+// extractFirstLine returns the first meaningful line of a user message's content, which
+// is a string or structured content blocks with text fields (see FirstMessageLine)
+func extractFirstLine(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return FirstMessageLine(v)
+	case []interface{}:
+		// Editors send their context as separate blocks before the user's text
+		for _, item := range v {
+			if line := extractFirstLine(item); line != "" {
+				return line
+			}
+		}
+	case map[string]interface{}:
+		if text, ok := v["text"].(string); ok {
+			return FirstMessageLine(text)
+		}
+	}
+	return ""
+}
+
+// stripSystemXMLTags removes preamble blocks (see preambleTags) from the beginning of a
+// message
+func stripSystemXMLTags(text string) string {
+	for {
+		trimmed := strings.TrimSpace(text)
+		removed := false
+
+		for _, tag := range preambleTags {
+			openTag := "<" + tag + ">"
+			closeTag := "</" + tag + ">"
+
+			if strings.HasPrefix(trimmed, openTag) {
+				closeIdx := strings.Index(trimmed, closeTag)
+				if closeIdx != -1 {
+					trimmed = strings.TrimSpace(trimmed[closeIdx+len(closeTag):])
+					removed = true
+					break // Restart scanning with updated text
+				}
+			}
+		}
+
+		text = trimmed
+		if !removed {
+			break
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,44 @@
+package adapters
+
+import "testing"
+
+func TestFirstMessageLine(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain", "  Fix the login bug\nIt fails on Safari", "Fix the login bug"},
+		{"slash command wrapper", "<command-name>/clear</command-name>\n<command-message>clear</command-message>\n<command-args></command-args>", ""},
+		{"command output", "<local-command-stdout>Set model to opus</local-command-stdout>", ""},
+		{"command stderr", "<local-command-stderr>error: unknown command</local-command-stderr>", ""},
+		{"bash escape", "<bash-input>git status</bash-input>", ""},
+		{"bash output", "<bash-stdout>On branch main</bash-stdout><bash-stderr></bash-stderr>", ""},
+		{"caveat", "Caveat: The messages below were generated by the user while running local commands.", ""},
+		{"interrupted", "[Request interrupted by user for tool use]", ""},
+		{"bare slash command", "/compact", ""},
+		{"namespaced slash command", "  /mcp:tools  ", ""},
+		{"slash command with arguments", "/review the parser changes", "/review the parser changes"},
+		{"absolute path", "/Users/me/app/main.go crashes on start", "/Users/me/app/main.go crashes on start"},
+		{"multi-line reminder", "<system-reminder>\nThe user opened a file.\n</system-reminder>\nWhy is this slow?", "Why is this slow?"},
+		{"codex context", "<environment_context>\n<cwd>/repo</cwd>\n</environment_context>", ""},
+		{"codex instructions", "# AGENTS.md instructions for /repo\n\n<INSTRUCTIONS>be nice</INSTRUCTIONS>", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FirstMessageLine(tt.text); got != tt.want {
+				t.Errorf("FirstMessageLine(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractFirstLineSkipsPreambleBlocks(t *testing.T) {
+	content := []interface{}{
+		map[string]interface{}{"type": "text", "text": "<ide_opened_file>The user opened main.go</ide_opened_file>"},
+		map[string]interface{}{"type": "text", "text": "Add a --verbose flag"},
+	}
+	if got := extractFirstLine(content); got != "Add a --verbose flag" {
+		t.Fatalf("expected the user's text, got %q", got)
+	}
+}
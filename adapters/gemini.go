@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"bufio"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -16,10 +18,19 @@ import (
 // Gemini stores sessions as JSON files in ~/.gemini/tmp/[PROJECT_HASH]/chats/
 // where PROJECT_HASH is SHA256(absolute project path).
 type GeminiAdapter struct {
+	metadataCaching
 	homeDir      string
+	dataDir      string // Gemini CLI's data directory; ~/.gemini when empty
 	projectCache map[string]string
+	projectRoots []string        // Directories whose subdirectories may be projects
+	projectHints func() []string // Project directories known to other sources
 }
 
+// ProjectRootsEnv names the environment variable listing directories (separated like
+// PATH) that contain project directories, such as ~/code. Gemini only records a hash of
+// the project path, so listing all sessions hashes these directories to recover it.
+const ProjectRootsEnv = "AI_SESSIONS_PROJECT_ROOTS"
+
 // NewGeminiAdapter creates a new Gemini CLI session adapter.
 func NewGeminiAdapter() (*GeminiAdapter, error) {
 	homeDir, err := os.UserHomeDir()
@@ -28,10 +39,27 @@ func NewGeminiAdapter() (*GeminiAdapter, error) {
 	}
 	return &GeminiAdapter{
 		homeDir:      homeDir,
+		dataDir:      geminiDataDir(homeDir),
 		projectCache: make(map[string]string),
+		projectRoots: filepath.SplitList(os.Getenv(ProjectRootsEnv)),
 	}, nil
 }
 
+// tmpDir returns the directory holding a directory of sessions per project hash.
+func (g *GeminiAdapter) tmpDir() string {
+	dataDir := g.dataDir
+	if dataDir == "" {
+		dataDir = filepath.Join(g.homeDir, ".gemini")
+	}
+	return filepath.Join(dataDir, "tmp")
+}
+
+// SetProjectHints sets a function returning project directories known to other sources,
+// used to recover the project path of sessions listed across all projects.
+func (g *GeminiAdapter) SetProjectHints(hints func() []string) {
+	g.projectHints = hints
+}
+
 // Name returns the adapter name.
 func (g *GeminiAdapter) Name() string {
 	return "gemini"
@@ -51,11 +79,33 @@ type geminiMessage struct {
 	Content   interface{}      `json:"content"`
 	Timestamp string           `json:"timestamp,omitempty"`
 	ToolCalls []geminiToolCall `json:"toolCalls,omitempty"`
+	Thoughts  []geminiThought  `json:"thoughts,omitempty"`
+	Tokens    *geminiTokens    `json:"tokens,omitempty"`
+	Model     string           `json:"model,omitempty"`
+}
+
+// geminiTokens is the token usage Gemini CLI records for a model response.
+// Input includes cached tokens; thoughts and tool tokens are reported separately.
+type geminiTokens struct {
+	Input    int `json:"input"`
+	Output   int `json:"output"`
+	Cached   int `json:"cached"`
+	Thoughts int `json:"thoughts"`
+	Tool     int `json:"tool"`
+}
+
+// geminiThought is a reasoning step Gemini CLI records alongside a model message.
+type geminiThought struct {
+	Subject     string `json:"subject,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 type geminiToolCall struct {
-	Name string                 `json:"name"`
-	Args map[string]interface{} `json:"args,omitempty"`
+	ID     string                 `json:"id,omitempty"`
+	Name   string                 `json:"name"`
+	Args   map[string]interface{} `json:"args,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Result []interface{}          `json:"result,omitempty"`
 }
 
 // hashProjectPath computes the SHA256 hash of the project path.
@@ -68,27 +118,37 @@ func hashProjectPath(path string) string {
 // ListSessions returns all Gemini sessions for the given project.
 // If projectPath is empty, returns sessions from ALL projects.
 func (g *GeminiAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
-	geminiTmpDir := filepath.Join(g.homeDir, ".gemini", "tmp")
+	return g.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (g *GeminiAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	geminiTmpDir := g.tmpDir()
 
 	// If no project path specified, list sessions from ALL projects
 	if projectPath == "" {
-		return g.listAllSessions(geminiTmpDir, limit)
+		return g.listAllSessions(ctx, geminiTmpDir, limit)
 	}
 
 	// Get absolute path
-	projectPath, err := filepath.Abs(projectPath)
-	if err != nil {
+	if _, err := filepath.Abs(projectPath); err != nil {
 		return nil, fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
-	// Compute project hash
-	projectHash := hashProjectPath(projectPath)
-	chatsDir := filepath.Join(geminiTmpDir, projectHash, "chats")
-
-	// Check if directory exists
-	if _, err := os.Stat(chatsDir); os.IsNotExist(err) {
+	// Gemini hashes the path it was started in, which may be the path as given or
+	// with symlinks resolved
+	chatsDir := ""
+	for _, variant := range projectPathVariants(projectPath) {
+		dir := filepath.Join(geminiTmpDir, hashProjectPath(variant), "chats")
+		if _, err := os.Stat(dir); err == nil {
+			chatsDir = dir
+			break
+		}
+	}
+	if chatsDir == "" {
 		return []Session{}, nil // No sessions for this project
 	}
+	projectPath = CanonicalProjectPath(projectPath)
 
 	// Read all session-*.json files
 	files, err := filepath.Glob(filepath.Join(chatsDir, "session-*.json"))
@@ -98,7 +158,10 @@ func (g *GeminiAdapter) ListSessions(projectPath string, limit int) ([]Session,
 
 	sessions := make([]Session, 0, len(files))
 	for _, filePath := range files {
-		session, err := g.parseSessionMetadata(filePath, projectPath)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		session, err := g.loadSessionMetadata(filePath, projectPath)
 		if err != nil {
 			// Skip files we can't parse
 			continue
@@ -120,7 +183,7 @@ func (g *GeminiAdapter) ListSessions(projectPath string, limit int) ([]Session,
 }
 
 // listAllSessions lists sessions from all projects.
-func (g *GeminiAdapter) listAllSessions(geminiTmpDir string, limit int) ([]Session, error) {
+func (g *GeminiAdapter) listAllSessions(ctx context.Context, geminiTmpDir string, limit int) ([]Session, error) {
 	// Check if tmp directory exists
 	if _, err := os.Stat(geminiTmpDir); os.IsNotExist(err) {
 		return []Session{}, nil
@@ -132,6 +195,16 @@ func (g *GeminiAdapter) listAllSessions(geminiTmpDir string, limit int) ([]Sessi
 		return nil, fmt.Errorf("failed to read Gemini tmp directory: %w", err)
 	}
 
+	// Only the hash of each project path is known. Hash known project directories to
+	// recover the paths, but only if some hash hasn't been resolved before.
+	var knownProjects map[string]string
+	for _, dir := range hashDirs {
+		if cached := g.projectCache[dir.Name()]; dir.IsDir() && (cached == "" || strings.HasPrefix(cached, "unknown-project-")) {
+			knownProjects = g.knownProjectHashes()
+			break
+		}
+	}
+
 	var allSessions []Session
 	for _, dir := range hashDirs {
 		if !dir.IsDir() {
@@ -144,9 +217,17 @@ func (g *GeminiAdapter) listAllSessions(geminiTmpDir string, limit int) ([]Sessi
 			continue
 		}
 
+		projectPath := knownProjects[dir.Name()]
+		if projectPath == "" {
+			// The session itself may reveal the path; otherwise the hash identifies the project
+			projectPath = "unknown-project-" + dir.Name()
+		}
+
 		for _, filePath := range files {
-			// We don't know the original project path, use hash as identifier
-			session, err := g.parseSessionMetadata(filePath, "unknown-project-"+dir.Name())
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			session, err := g.loadSessionMetadata(filePath, projectPath)
 			if err != nil {
 				continue
 			}
@@ -167,6 +248,58 @@ func (g *GeminiAdapter) listAllSessions(geminiTmpDir string, limit int) ([]Sessi
 	return allSessions, nil
 }
 
+// knownProjectHashes maps the Gemini project hash of every known project directory to
+// its path. Known directories are the project roots, their subdirectories two levels
+// deep, and the project directories other sources know about.
+func (g *GeminiAdapter) knownProjectHashes() map[string]string {
+	var candidates []string
+	for _, root := range g.projectRoots {
+		if root == "" {
+			continue
+		}
+		if root == "~" || strings.HasPrefix(root, "~/") {
+			root = filepath.Join(g.homeDir, strings.TrimPrefix(root, "~"))
+		}
+		if abs, err := filepath.Abs(root); err == nil {
+			root = abs
+		}
+		candidates = append(candidates, root)
+		children, _ := filepath.Glob(filepath.Join(root, "*"))
+		grandchildren, _ := filepath.Glob(filepath.Join(root, "*", "*"))
+		for _, dir := range append(children, grandchildren...) {
+			if info, err := os.Stat(dir); err == nil && info.IsDir() {
+				candidates = append(candidates, dir)
+			}
+		}
+	}
+	if g.projectHints != nil {
+		candidates = append(candidates, g.projectHints()...)
+	}
+
+	hashes := make(map[string]string, len(candidates))
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		for _, variant := range projectPathVariants(dir) {
+			hashes[hashProjectPath(variant)] = CanonicalProjectPath(variant)
+		}
+	}
+	return hashes
+}
+
+// loadSessionMetadata returns the metadata of a Gemini session file, from the metadata
+// cache when the file hasn't changed since it was last parsed.
+func (g *GeminiAdapter) loadSessionMetadata(filePath, projectPath string) (Session, error) {
+	return cachedMetadata(&g.metadataCaching, filePath, projectPath, func() (Session, error) {
+		session, err := g.parseSessionMetadata(filePath, projectPath)
+		if err == nil {
+			g.recordSessionFile("gemini", session.ID, filePath)
+		}
+		return session, err
+	})
+}
+
 // parseSessionMetadata extracts metadata from a Gemini session file.
 func (g *GeminiAdapter) parseSessionMetadata(filePath, projectPath string) (Session, error) {
 	data, err := os.ReadFile(filePath)
@@ -183,10 +316,12 @@ func (g *GeminiAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 	resolvedProjectPath := g.resolveProjectPath(hashDir, projectPath, &geminiSess)
 
 	session := Session{
-		ID:          geminiSess.SessionID,
-		Source:      "gemini",
-		ProjectPath: resolvedProjectPath,
-		FilePath:    filePath,
+		ID:           geminiSess.SessionID,
+		Source:       "gemini",
+		ProjectPath:  resolvedProjectPath,
+		FilePath:     filePath,
+		MessageCount: len(geminiSess.Messages),
+		FileSize:     int64(len(data)),
 	}
 
 	// Parse timestamp from first message or startTime
@@ -209,65 +344,69 @@ func (g *GeminiAdapter) parseSessionMetadata(filePath, projectPath string) (Sess
 
 	// Extract first user message and count all user messages
 	userCount := 0
+	var activity sessionActivity
 	for _, msg := range geminiSess.Messages {
+		if ts, err := time.Parse(time.RFC3339, msg.Timestamp); err == nil {
+			activity.seen(ts)
+		}
 		role := normalizeGeminiRole(msg)
+		if role == "assistant" {
+			activity.assistantMessage(len(msg.ToolCalls))
+		}
 		if role != "user" {
 			continue
 		}
 		userCount++
+		activity.userMessage()
 		if session.FirstMessage == "" {
-			session.FirstMessage = extractFirstLineFromContent(msg.Content)
+			session.FirstMessage = extractFirstLine(msg.Content)
 		}
 	}
 
 	session.UserMessageCount = userCount
+	activity.apply(&session)
 
 	return session, nil
 }
 
-// extractFirstLineFromContent extracts the first line from various content formats.
-func extractFirstLineFromContent(content interface{}) string {
-	switch v := content.(type) {
-	case string:
-		lines := strings.Split(v, "\n")
-		for _, line := range lines {
-			trimmed := strings.TrimSpace(line)
-			if trimmed != "" {
-				if len(trimmed) > 200 {
-					return trimmed[:200] + "..."
-				}
-				return trimmed
-			}
-		}
-	case []interface{}:
-		// Gemini may use structured content with text fields
-		for _, item := range v {
-			if m, ok := item.(map[string]interface{}); ok {
-				if text, ok := m["text"].(string); ok {
-					return extractFirstLineFromContent(text)
-				}
-			}
-		}
-	case map[string]interface{}:
-		if text, ok := v["text"].(string); ok {
-			return extractFirstLineFromContent(text)
-		}
+// GetSession retrieves the full content of a Gemini session with pagination.
+func (g *GeminiAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	sessionFile, err := g.findSessionFile(sessionID)
+	if err != nil {
+		return nil, err
 	}
-	return ""
+
+	// Stop reading at the end of the requested page
+	return collectPage(func(fn func(Message) bool) error {
+		return g.streamMessages(sessionFile, fn)
+	}, page, pageSize)
 }
 
-// GetSession retrieves the full content of a Gemini session with pagination.
-func (g *GeminiAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+// StreamSession calls fn with each message of a session, in order, until fn returns false.
+func (g *GeminiAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	sessionFile, err := g.findSessionFile(sessionID)
+	if err != nil {
+		return err
+	}
+	return g.streamMessages(sessionFile, fn)
+}
+
+// findSessionFile returns the file of a session, using the path recorded while listing
+// when available and otherwise checking the session ID of every session file.
+func (g *GeminiAdapter) findSessionFile(sessionID string) (string, error) {
+	if path := g.lookupSessionFile("gemini", sessionID); path != "" {
+		return path, nil
+	}
+
 	// We need to search for the session file since we don't know the project path
-	geminiTmpDir := filepath.Join(g.homeDir, ".gemini", "tmp")
+	geminiTmpDir := g.tmpDir()
 
 	// Read all project hash directories
 	projectDirs, err := os.ReadDir(geminiTmpDir)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read Gemini tmp directory: %w", err)
+		return "", fmt.Errorf("failed to read Gemini tmp directory: %w", err)
 	}
 
-	var sessionFile string
 	for _, dir := range projectDirs {
 		if !dir.IsDir() {
 			continue
@@ -281,85 +420,189 @@ func (g *GeminiAdapter) GetSession(sessionID string, page, pageSize int) ([]Mess
 		}
 
 		for _, file := range files {
-			// Read and check if this is the right session
-			data, err := os.ReadFile(file)
-			if err != nil {
-				continue
-			}
-
-			var sess geminiSession
-			if err := json.Unmarshal(data, &sess); err != nil {
-				continue
-			}
-
-			if sess.SessionID == sessionID {
-				sessionFile = file
-				break
+			if geminiSessionID(file) == sessionID {
+				g.recordSessionFile("gemini", sessionID, file)
+				return file, nil
 			}
 		}
-
-		if sessionFile != "" {
-			break
-		}
 	}
 
-	if sessionFile == "" {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
-	}
+	return "", fmt.Errorf("session not found: %s", sessionID)
+}
 
-	// Read the session file
-	messages, err := g.readAllMessages(sessionFile)
+// geminiSessionID returns the session ID of a session file, decoding only as much of the
+// file as needed to find it.
+func geminiSessionID(filePath string) string {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, err
+		return ""
 	}
+	defer file.Close()
 
-	// Apply pagination
-	start := page * pageSize
-	if start >= len(messages) {
-		return []Message{}, nil
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return ""
 	}
-
-	end := start + pageSize
-	if end > len(messages) {
-		end = len(messages)
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+		if key, _ := tok.(string); key == "sessionId" {
+			var id string
+			_ = decoder.Decode(&id)
+			return id
+		}
+		var skipped json.RawMessage
+		if err := decoder.Decode(&skipped); err != nil {
+			return ""
+		}
 	}
+	return ""
+}
 
-	return messages[start:end], nil
+// ReadSession reads every message of a session listed by ListSessions.
+func (g *GeminiAdapter) ReadSession(session Session) ([]Message, error) {
+	return g.readAllMessages(session.FilePath)
 }
 
 // readAllMessages reads all messages from a Gemini session file.
 func (g *GeminiAdapter) readAllMessages(filePath string) ([]Message, error) {
-	data, err := os.ReadFile(filePath)
+	return collectAll(func(fn func(Message) bool) error {
+		return g.streamMessages(filePath, fn)
+	})
+}
+
+// streamMessages decodes the messages array of a session file one message at a time,
+// calling fn with each message until fn returns false.
+func (g *GeminiAdapter) streamMessages(filePath string, fn func(Message) bool) error {
+	file, err := os.Open(filePath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read session file: %w", err)
+		return fmt.Errorf("failed to read session file: %w", err)
 	}
+	defer file.Close()
 
-	var sess geminiSession
-	if err := json.Unmarshal(data, &sess); err != nil {
-		return nil, fmt.Errorf("failed to parse session JSON: %w", err)
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	if tok, err := decoder.Token(); err != nil || tok != json.Delim('{') {
+		return fmt.Errorf("failed to parse session JSON: expected an object")
 	}
 
-	messages := make([]Message, 0, len(sess.Messages))
-	for _, msg := range sess.Messages {
-		role := normalizeGeminiRole(msg)
-
-		message := Message{
-			Role:     role,
-			Content:  contentToStringGemini(msg.Content),
-			Metadata: make(map[string]interface{}),
+	for decoder.More() {
+		tok, err := decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to parse session JSON: %w", err)
+		}
+		if key, _ := tok.(string); key != "messages" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return fmt.Errorf("failed to parse session JSON: %w", err)
+			}
+			continue
 		}
 
-		// Parse timestamp if available
-		if msg.Timestamp != "" {
-			if ts, err := time.Parse(time.RFC3339, msg.Timestamp); err == nil {
-				message.Timestamp = ts
+		if tok, err := decoder.Token(); err != nil || tok != json.Delim('[') {
+			return nil // No messages
+		}
+		for decoder.More() {
+			var msg geminiMessage
+			if err := decoder.Decode(&msg); err != nil {
+				return fmt.Errorf("failed to parse session JSON: %w", err)
+			}
+			if !fn(geminiToMessage(msg)) {
+				return nil
 			}
 		}
+		return nil
+	}
+	return nil
+}
 
-		messages = append(messages, message)
+// geminiToMessage converts a Gemini message to the unified format.
+func geminiToMessage(msg geminiMessage) Message {
+	role := normalizeGeminiRole(msg)
+
+	message := Message{
+		Role:     role,
+		Content:  contentToStringGemini(msg.Content),
+		Metadata: make(map[string]interface{}),
 	}
 
-	return messages, nil
+	// Parse timestamp if available
+	if msg.Timestamp != "" {
+		if ts, err := time.Parse(time.RFC3339, msg.Timestamp); err == nil {
+			message.Timestamp = ts
+		}
+	}
+
+	if msg.Model != "" {
+		message.Metadata["model"] = msg.Model
+	}
+	if msg.Tokens != nil {
+		// Normalize to the opencode layout understood by usage reporting
+		message.Metadata["tokens"] = map[string]interface{}{
+			"input":     float64(msg.Tokens.Input - msg.Tokens.Cached + msg.Tokens.Tool),
+			"output":    float64(msg.Tokens.Output),
+			"reasoning": float64(msg.Tokens.Thoughts),
+			"cache":     map[string]interface{}{"read": float64(msg.Tokens.Cached)},
+		}
+	}
+
+	if len(msg.Thoughts) > 0 {
+		thoughts := make([]string, 0, len(msg.Thoughts))
+		for _, thought := range msg.Thoughts {
+			text := strings.TrimSpace(thought.Description)
+			if thought.Subject != "" {
+				text = "**" + thought.Subject + "**\n" + text
+			}
+			thoughts = append(thoughts, text)
+		}
+		message.Thinking = strings.Join(thoughts, "\n\n")
+	}
+
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]ToolCall, 0, len(msg.ToolCalls))
+		var results []ToolResult
+		for _, tc := range msg.ToolCalls {
+			calls = append(calls, ToolCall{ID: tc.ID, Name: tc.Name, Input: tc.Args})
+			// Gemini records the outcome alongside the call rather than as a separate message
+			if tc.Status != "" || len(tc.Result) > 0 {
+				results = append(results, ToolResult{
+					ToolCallID: tc.ID,
+					Output:     geminiToolOutput(tc.Result),
+					IsError:    tc.Status == "error",
+				})
+			}
+		}
+		message.ToolCalls = calls
+		message.ToolResults = results
+	}
+
+	return message
+}
+
+// geminiToolOutput extracts the text output from a tool call's functionResponse parts.
+func geminiToolOutput(result []interface{}) string {
+	var parts []string
+	for _, item := range result {
+		part, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fr, ok := part["functionResponse"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		response, ok := fr["response"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if output, ok := response["output"].(string); ok {
+			parts = append(parts, output)
+		} else if errText, ok := response["error"].(string); ok {
+			parts = append(parts, errText)
+		}
+	}
+	return strings.Join(parts, "\n")
 }
 
 // contentToStringGemini converts Gemini content to a string.
@@ -424,7 +667,7 @@ func (g *GeminiAdapter) resolveProjectPath(hash, provided string, sess *geminiSe
 		return provided
 	}
 
-	if path, ok := g.projectCache[hash]; ok && path != "" {
+	if path, ok := g.projectCache[hash]; ok && path != "" && !strings.HasPrefix(path, "unknown-project-") {
 		return path
 	}
 
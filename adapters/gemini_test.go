@@ -2,6 +2,7 @@ package adapters
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -27,8 +28,9 @@ func TestParseSessionMetadataCountsUserMessagesCaseInsensitive(t *testing.T) {
 				Content: "First question?\nSecond line",
 			},
 			{
-				Type:    "GEMINI",
-				Content: "Some reply",
+				Type:     "GEMINI",
+				Content:  "Some reply",
+				Thoughts: []geminiThought{{Subject: "Planning", Description: "Answer briefly."}},
 			},
 		},
 	}
@@ -56,6 +58,10 @@ func TestParseSessionMetadataCountsUserMessagesCaseInsensitive(t *testing.T) {
 		t.Fatalf("expected FirstMessage to be %q, got %q", "First question?", session.FirstMessage)
 	}
 
+	if session.MessageCount != 2 || session.FileSize != int64(len(data)) {
+		t.Fatalf("expected 2 messages and %d bytes, got %d messages and %d bytes", len(data), session.MessageCount, session.FileSize)
+	}
+
 	messages, err := adapter.readAllMessages(sessionPath)
 	if err != nil {
 		t.Fatalf("readAllMessages returned error: %v", err)
@@ -71,6 +77,9 @@ func TestParseSessionMetadataCountsUserMessagesCaseInsensitive(t *testing.T) {
 	if messages[1].Role != "assistant" {
 		t.Fatalf("expected second message role to be 'assistant', got %q", messages[1].Role)
 	}
+	if messages[1].Thinking != "**Planning**\nAnswer briefly." {
+		t.Fatalf("expected thoughts to become thinking text, got %q", messages[1].Thinking)
+	}
 }
 
 func TestParseSessionMetadataInfersProjectPath(t *testing.T) {
@@ -119,6 +128,45 @@ func TestParseSessionMetadataInfersProjectPath(t *testing.T) {
 	}
 }
 
+func TestGeminiListAllSessionsRecoversProjectPaths(t *testing.T) {
+	home := t.TempDir()
+	roots := t.TempDir()
+	rootProject := filepath.Join(roots, "org", "api")
+	if err := os.MkdirAll(rootProject, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	hintedProject := "/work/web-app"
+
+	for i, project := range []string{rootProject, hintedProject, "/nowhere/known"} {
+		chatsDir := filepath.Join(home, ".gemini", "tmp", hashProjectPath(project), "chats")
+		if err := os.MkdirAll(chatsDir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		data := fmt.Sprintf(`{"sessionId":"s%d","messages":[{"type":"user","content":"hello"}]}`, i)
+		if err := os.WriteFile(filepath.Join(chatsDir, "session-1.json"), []byte(data), 0o600); err != nil {
+			t.Fatalf("write session: %v", err)
+		}
+	}
+
+	adapter := &GeminiAdapter{homeDir: home, projectCache: make(map[string]string), projectRoots: []string{roots}}
+	adapter.SetProjectHints(func() []string { return []string{hintedProject} })
+	sessions, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+
+	paths := make(map[string]string)
+	for _, session := range sessions {
+		paths[session.ID] = session.ProjectPath
+	}
+	if paths["s0"] != rootProject || paths["s1"] != hintedProject {
+		t.Fatalf("expected project paths to be recovered, got %v", paths)
+	}
+	if paths["s2"] != "unknown-project-"+hashProjectPath("/nowhere/known") {
+		t.Fatalf("expected an unknown project to keep its hash, got %q", paths["s2"])
+	}
+}
+
 func TestNormalizeGeminiRole(t *testing.T) {
 	table := []struct {
 		msg  geminiMessage
@@ -0,0 +1,278 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// HiddenToolOutput replaces tool output removed by a Guard
+const HiddenToolOutput = "[tool output hidden]"
+
+// Guard restricts what an adapter exposes, for running the server where its output may
+// be seen by others, such as a shared or recorded environment.
+type Guard struct {
+	// AllowProjects, when set, exposes only the sessions of these project directories
+	// and the directories below them
+	AllowProjects []string
+
+	// DenyProjects hides the sessions of these project directories and the directories
+	// below them, even when allowed
+	DenyProjects []string
+
+	// StripToolOutput replaces the output of tool calls with HiddenToolOutput
+	StripToolOutput bool
+
+	// Ignore hides the sessions of ignored projects
+	Ignore *IgnoreRules `json:"-"`
+}
+
+// Active reports whether the guard restricts anything.
+func (g Guard) Active() bool {
+	return g.restrictsProjects() || g.StripToolOutput
+}
+
+// restrictsProjects reports whether the guard hides the sessions of some projects. Ignore
+// rules only count once an ignore file was found, so adapters wrapped for them cost
+// nothing more while there are none.
+func (g Guard) restrictsProjects() bool {
+	return len(g.AllowProjects) > 0 || len(g.DenyProjects) > 0 || !g.Ignore.Empty()
+}
+
+// Allows reports whether the sessions of a project may be exposed. Sessions of an
+// unknown project are only exposed when no projects are allowed or denied.
+func (g Guard) Allows(projectPath string) bool {
+	if g.Ignore.Ignored(projectPath) {
+		return false
+	}
+	if len(g.AllowProjects) == 0 && len(g.DenyProjects) == 0 {
+		return true
+	}
+	if projectPath == "" {
+		return false
+	}
+	for _, denied := range g.DenyProjects {
+		if withinDir(projectPath, denied) {
+			return false
+		}
+	}
+	if len(g.AllowProjects) == 0 {
+		return true
+	}
+	for _, allowed := range g.AllowProjects {
+		if withinDir(projectPath, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// withinDir reports whether path is dir or below it
+func withinDir(path, dir string) bool {
+	path, dir = filepath.Clean(path), filepath.Clean(dir)
+	return path == dir || strings.HasPrefix(path, strings.TrimSuffix(dir, string(filepath.Separator))+string(filepath.Separator))
+}
+
+// Messages returns messages with tool output removed when the guard strips it. The
+// messages passed in are not modified.
+func (g Guard) Messages(messages []Message) []Message {
+	if !g.StripToolOutput {
+		return messages
+	}
+	stripped := make([]Message, len(messages))
+	for i, msg := range messages {
+		if len(msg.ToolResults) > 0 {
+			results := make([]ToolResult, len(msg.ToolResults))
+			for j, result := range msg.ToolResults {
+				result.Output = HiddenToolOutput
+				results[j] = result
+			}
+			msg.ToolResults = results
+		}
+		if msg.Metadata != nil {
+			metadata := make(map[string]interface{}, len(msg.Metadata))
+			for key, value := range msg.Metadata {
+				if key != "raw_content" { // Raw content blocks hold the output too
+					metadata[key] = value
+				}
+			}
+			msg.Metadata = metadata
+		}
+		if isToolResult, _ := msg.Metadata["is_tool_result"].(bool); msg.Role == "tool" || isToolResult {
+			msg.Content = HiddenToolOutput
+		}
+		stripped[i] = msg
+	}
+	return stripped
+}
+
+// GuardedAdapter exposes the sessions of an adapter that its Guard allows.
+type GuardedAdapter struct {
+	adapter SessionAdapter
+	guard   Guard
+
+	mu       sync.Mutex
+	projects map[string]string // Project of each session listed so far, by ID
+	listed   bool              // Whether every session was listed, checking their projects
+}
+
+// NewGuardedAdapter wraps adapter so it only exposes what guard allows.
+func NewGuardedAdapter(adapter SessionAdapter, guard Guard) *GuardedAdapter {
+	return &GuardedAdapter{adapter: adapter, guard: guard, projects: make(map[string]string)}
+}
+
+// Name returns the name of the wrapped adapter.
+func (g *GuardedAdapter) Name() string {
+	return g.adapter.Name()
+}
+
+// ListSessions lists the allowed sessions.
+func (g *GuardedAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
+	return g.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (g *GuardedAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	return g.list(limit, func(limit int) ([]Session, error) {
+		return ListSessionsContext(ctx, g.adapter, projectPath, limit)
+	})
+}
+
+// SearchSessions searches the allowed sessions.
+func (g *GuardedAdapter) SearchSessions(projectPath, query string, limit int) ([]Session, error) {
+	return g.list(limit, func(limit int) ([]Session, error) {
+		return g.adapter.SearchSessions(projectPath, query, limit)
+	})
+}
+
+// list returns up to limit allowed sessions of a listing. Without rules hiding sessions,
+// the limit is passed through; the sessions listed are still checked, since their
+// projects may have ignore files, and the listing is redone in full if any is hidden.
+func (g *GuardedAdapter) list(limit int, listFn func(limit int) ([]Session, error)) ([]Session, error) {
+	if !g.guard.restrictsProjects() {
+		sessions, err := listFn(limit)
+		allowed := g.filter(sessions, limit)
+		if err != nil || limit <= 0 || len(allowed) == len(sessions) {
+			return allowed, err
+		}
+	}
+	sessions, err := listFn(0)
+	return g.filter(sessions, limit), err
+}
+
+func (g *GuardedAdapter) filter(sessions []Session, limit int) []Session {
+	g.remember(sessions)
+	var allowed []Session
+	for _, session := range sessions {
+		if g.guard.Allows(session.ProjectPath) {
+			allowed = append(allowed, session)
+			if limit > 0 && len(allowed) == limit {
+				break
+			}
+		}
+	}
+	return allowed
+}
+
+// remember records the projects of listed sessions, so reading them doesn't need
+// another listing
+func (g *GuardedAdapter) remember(sessions []Session) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, session := range sessions {
+		g.projects[session.ID] = session.ProjectPath
+	}
+}
+
+func (g *GuardedAdapter) listedAll() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.listed
+}
+
+func (g *GuardedAdapter) project(sessionID string) (string, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	project, ok := g.projects[sessionID]
+	return project, ok
+}
+
+// check returns an error for a session the guard doesn't allow. Hidden sessions are
+// reported as not found, so their existence isn't revealed either.
+func (g *GuardedAdapter) check(sessionID string) error {
+	if !g.guard.restrictsProjects() && g.guard.Ignore == nil {
+		return nil
+	}
+	project, ok := g.project(sessionID)
+	if !ok {
+		// Ignore files are found in the projects of listed sessions. Once every session
+		// was listed without finding any, unknown sessions are read without listing
+		// the source again.
+		if g.listedAll() && !g.guard.restrictsProjects() {
+			return nil
+		}
+		sessions, err := g.adapter.ListSessions("", 0)
+		if err != nil {
+			return err
+		}
+		g.filter(sessions, 0)
+		g.mu.Lock()
+		g.listed = true
+		g.mu.Unlock()
+		project, _ = g.project(sessionID)
+	}
+	if !g.guard.Allows(project) {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	return nil
+}
+
+// GetSession returns a page of an allowed session.
+func (g *GuardedAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	if err := g.check(sessionID); err != nil {
+		return nil, err
+	}
+	messages, err := g.adapter.GetSession(sessionID, page, pageSize)
+	return g.guard.Messages(messages), err
+}
+
+// StreamSession streams an allowed session.
+func (g *GuardedAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	if err := g.check(sessionID); err != nil {
+		return err
+	}
+	return streamPages(g.adapter, sessionID, func(msg Message) bool {
+		return fn(g.guard.Messages([]Message{msg})[0])
+	})
+}
+
+// ReadSession reads an allowed listed session.
+func (g *GuardedAdapter) ReadSession(session Session) ([]Message, error) {
+	if err := g.check(session.ID); err != nil {
+		return nil, err
+	}
+	if reader, ok := g.adapter.(SessionReader); ok {
+		messages, err := reader.ReadSession(session)
+		return g.guard.Messages(messages), err
+	}
+	messages, err := collectAll(func(fn func(Message) bool) error {
+		return streamPages(g.adapter, session.ID, fn)
+	})
+	return g.guard.Messages(messages), err
+}
+
+// SetMetadataCache passes the metadata cache to the wrapped adapter.
+func (g *GuardedAdapter) SetMetadataCache(cache MetadataCache) {
+	if user, ok := g.adapter.(MetadataCacheUser); ok {
+		user.SetMetadataCache(cache)
+	}
+}
+
+// SetProjectHints passes the project hints to the wrapped adapter.
+func (g *GuardedAdapter) SetProjectHints(hints func() []string) {
+	if user, ok := g.adapter.(ProjectHintUser); ok {
+		user.SetProjectHints(hints)
+	}
+}
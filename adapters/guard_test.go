@@ -0,0 +1,98 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGuardAllows(t *testing.T) {
+	guard := Guard{AllowProjects: []string{"/work"}, DenyProjects: []string{"/work/secret"}}
+	cases := map[string]bool{
+		"/work":              true,
+		"/work/app":          true,
+		"/work/secret":       false,
+		"/work/secret/inner": false,
+		"/work/secrets":      true,
+		"/workshop":          false,
+		"/home/me":           false,
+		"":                   false,
+	}
+	for path, want := range cases {
+		if got := guard.Allows(path); got != want {
+			t.Errorf("Allows(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if !(Guard{}).Allows("") || !(Guard{StripToolOutput: true}).Allows("/anything") {
+		t.Error("a guard without project rules should allow every project")
+	}
+	if (Guard{DenyProjects: []string{"/work"}}).Allows("/work/app") || !(Guard{DenyProjects: []string{"/work"}}).Allows("/home") {
+		t.Error("a deny list alone should hide only the denied projects")
+	}
+}
+
+func TestGuardStripsToolOutput(t *testing.T) {
+	messages := []Message{
+		{Role: "assistant", Content: "running tests", ToolResults: []ToolResult{{ToolCallID: "t1", Output: "PASS"}}},
+		{Role: "user", Content: "token=abc", Metadata: map[string]interface{}{"is_tool_result": true, "raw_content": "token=abc"}},
+		{Role: "tool", Content: "file contents"},
+		{Role: "user", Content: "thanks"},
+	}
+	stripped := Guard{StripToolOutput: true}.Messages(messages)
+
+	if stripped[0].Content != "running tests" || stripped[0].ToolResults[0].Output != HiddenToolOutput {
+		t.Errorf("expected tool results to be hidden, got %+v", stripped[0])
+	}
+	if stripped[1].Content != HiddenToolOutput || stripped[1].Metadata["raw_content"] != nil || stripped[1].Metadata["is_tool_result"] != true {
+		t.Errorf("expected the tool result message to be hidden, got %+v", stripped[1])
+	}
+	if stripped[2].Content != HiddenToolOutput || stripped[3].Content != "thanks" {
+		t.Errorf("expected only tool messages to be hidden, got %+v", stripped[2:])
+	}
+	if messages[0].ToolResults[0].Output != "PASS" || messages[1].Metadata["raw_content"] != "token=abc" {
+		t.Error("stripping modified the original messages")
+	}
+}
+
+func TestGuardedAdapter(t *testing.T) {
+	dataDir := t.TempDir()
+	for _, project := range []string{"/work/app", "/work/secret"} {
+		name := filepath.Base(project)
+		projectDir := filepath.Join(dataDir, "projects", projectDirName(project))
+		if err := os.MkdirAll(projectDir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		writeClaudeSession(t, projectDir, name+"-1",
+			`{"type":"user","uuid":"`+name+`-u1","cwd":"`+project+`","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"work on `+name+`"}}`,
+			`{"type":"user","uuid":"`+name+`-u2","cwd":"`+project+`","timestamp":"2025-01-01T10:01:00Z","message":{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"api_key=hunter2"}]}}`)
+	}
+	claude, err := NewAdapterAt("claude", dataDir)
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	guarded := NewGuardedAdapter(claude, Guard{DenyProjects: []string{"/work/secret"}, StripToolOutput: true})
+
+	sessions, err := guarded.ListSessions("", 0)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "app-1" {
+		t.Fatalf("expected only the allowed session, got %+v (%v)", sessions, err)
+	}
+	if sessions, _ := guarded.SearchSessions("", "work", 0); len(sessions) != 1 || sessions[0].ID != "app-1" {
+		t.Fatalf("expected search to find only the allowed session, got %+v", sessions)
+	}
+
+	if _, err := guarded.GetSession("secret-1", 0, 10); err == nil {
+		t.Fatal("expected a denied session to be unreadable")
+	}
+	if _, err := guarded.ReadSession(Session{ID: "secret-1", Source: "claude"}); err == nil {
+		t.Fatal("expected a denied session to be unreadable by ReadSession")
+	}
+
+	messages, err := guarded.ReadSession(sessions[0])
+	if err != nil || len(messages) != 2 {
+		t.Fatalf("expected to read the allowed session, got %+v (%v)", messages, err)
+	}
+	if messages[1].Content != HiddenToolOutput {
+		t.Errorf("expected the tool output to be hidden, got %q", messages[1].Content)
+	}
+}
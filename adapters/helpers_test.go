@@ -1,6 +1,8 @@
 package adapters
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -52,16 +54,16 @@ func TestHashProjectPathStable(t *testing.T) {
 	}
 }
 
-func TestExtractFirstLineFromContentVariants(t *testing.T) {
-	if got := extractFirstLineFromContent("   first\nsecond"); got != "first" {
-		t.Fatalf("extractFirstLineFromContent string: %q", got)
+func TestExtractFirstLineContentVariants(t *testing.T) {
+	if got := extractFirstLine("   first\nsecond"); got != "first" {
+		t.Fatalf("extractFirstLine string: %q", got)
 	}
 
 	arrayContent := []interface{}{
 		map[string]interface{}{"text": "\nvalue from map\n"},
 	}
-	if got := extractFirstLineFromContent(arrayContent); got != "value from map" {
-		t.Fatalf("extractFirstLineFromContent array: %q", got)
+	if got := extractFirstLine(arrayContent); got != "value from map" {
+		t.Fatalf("extractFirstLine array: %q", got)
 	}
 }
 
@@ -106,10 +108,9 @@ func TestCodexIsSessionPrefix(t *testing.T) {
 }
 
 func TestCodexExtractFirstLine(t *testing.T) {
-	adapter := &CodexAdapter{}
 	text := "   line one\nline two"
-	if got := adapter.extractFirstLine(text); got != "line one" {
-		t.Fatalf("extractFirstLine returned %q", got)
+	if got := FirstMessageLine(text); got != "line one" {
+		t.Fatalf("FirstMessageLine returned %q", got)
 	}
 }
 
@@ -123,6 +124,106 @@ func TestSessionInfoCWDMatches(t *testing.T) {
 	}
 }
 
+func TestScanRolloutFileDetectsResumedSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout-2025-03-02T09-00-00-new.jsonl")
+	lines := []string{
+		`{"type":"session_meta","payload":{"id":"new","cwd":"/work/app","timestamp":"2025-03-02T09:00:00Z"}}`,
+		`{"type":"session_meta","payload":{"id":"old","cwd":"/work/app","timestamp":"2025-03-01T09:00:00Z"}}`,
+		`{"type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"keep going"}]}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatalf("write rollout: %v", err)
+	}
+
+	info, err := (&CodexAdapter{}).scanRolloutFile(path, "")
+	if err != nil {
+		t.Fatalf("scanRolloutFile failed: %v", err)
+	}
+	if info.ID != "new" || info.ContinuedFrom != "old" {
+		t.Fatalf("expected new to continue old, got id=%q continued_from=%q", info.ID, info.ContinuedFrom)
+	}
+}
+
+func TestScanRolloutFileCountsActivity(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout-2025-03-02T09-00-00-s1.jsonl")
+	lines := []string{
+		`{"type":"session_meta","timestamp":"2025-03-02T09:00:00Z","payload":{"id":"s1","cwd":"/work/app","timestamp":"2025-03-02T09:00:00Z"}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:00:01Z","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"list the files"}]}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:00:02Z","payload":{"type":"function_call","name":"shell","arguments":"{\"command\":[\"ls\"]}","call_id":"c1"}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:00:03Z","payload":{"type":"function_call_output","call_id":"c1","output":"main.go"}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:00:04Z","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"One file."}]}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:02:00Z","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"thanks"}]}}`,
+		`{"type":"response_item","timestamp":"2025-03-02T09:02:30Z","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"Sure."}]}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatalf("write rollout: %v", err)
+	}
+
+	info, err := (&CodexAdapter{}).scanRolloutFile(path, "")
+	if err != nil {
+		t.Fatalf("scanRolloutFile failed: %v", err)
+	}
+	if info.DurationSeconds != 150 || info.AssistantTurns != 2 || info.ToolCallCount != 1 {
+		t.Fatalf("expected 150s, 2 turns and 1 tool call, got %ds, %d turns, %d tool calls",
+			info.DurationSeconds, info.AssistantTurns, info.ToolCallCount)
+	}
+	if info.MessageCount != 6 || info.FileSize != int64(len(strings.Join(lines, "\n"))) {
+		t.Fatalf("expected 6 messages and %d bytes, got %d messages and %d bytes",
+			len(strings.Join(lines, "\n")), info.MessageCount, info.FileSize)
+	}
+}
+
+func TestCodexGetSessionFindsRolloutFile(t *testing.T) {
+	home := t.TempDir()
+	dayDir := filepath.Join(home, ".codex", "sessions", "2025", "03", "02")
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeRollout := func(name, id, text string) {
+		lines := []string{
+			`{"type":"session_meta","payload":{"id":"` + id + `","cwd":"/work/app","timestamp":"2025-03-02T09:00:00Z"}}`,
+			`{"type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"` + text + `"}]}}`,
+		}
+		if err := os.WriteFile(filepath.Join(dayDir, name), []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+			t.Fatalf("write rollout: %v", err)
+		}
+	}
+	writeRollout("rollout-2025-03-02T09-00-00-x-abc.jsonl", "x-abc", "longer id")
+	writeRollout("rollout-2025-03-02T10-00-00-abc.jsonl", "abc", "by name")
+	writeRollout("rollout-renamed.jsonl", "def", "by content")
+
+	adapter := &CodexAdapter{homeDir: home}
+	for id, want := range map[string]string{"abc": "by name", "x-abc": "longer id", "def": "by content"} {
+		messages, err := adapter.GetSession(id, 0, 10)
+		if err != nil {
+			t.Fatalf("GetSession(%s) failed: %v", id, err)
+		}
+		if len(messages) == 0 || messages[0].Content != want {
+			t.Fatalf("GetSession(%s) returned %+v, want %q", id, messages, want)
+		}
+	}
+	if _, err := adapter.GetSession("missing", 0, 10); err == nil {
+		t.Fatal("expected an error for a missing session")
+	}
+}
+
+func TestCodexAttachments(t *testing.T) {
+	attachments := codexAttachments([]interface{}{
+		map[string]interface{}{"type": "input_text", "text": "what is wrong here?"},
+		map[string]interface{}{"type": "input_image", "image_url": "data:image/jpeg;base64,AAAAAAAA"},
+		map[string]interface{}{"type": "input_image", "image_url": "https://example.com/shot.png"},
+	})
+	if len(attachments) != 2 {
+		t.Fatalf("expected 2 attachments, got %+v", attachments)
+	}
+	if attachments[0].MediaType != "image/jpeg" || attachments[0].Size != 6 || attachments[0].Name != "" {
+		t.Fatalf("unexpected inline attachment: %+v", attachments[0])
+	}
+	if attachments[1].Name != "https://example.com/shot.png" {
+		t.Fatalf("unexpected URL attachment: %+v", attachments[1])
+	}
+}
+
 func TestCursorAdapterNotImplemented(t *testing.T) {
 	if _, err := NewCursorAdapter(); err == nil {
 		t.Fatal("expected error from NewCursorAdapter")
@@ -138,3 +239,144 @@ func TestCursorAdapterNotImplemented(t *testing.T) {
 		t.Fatal("SearchSessions should return error")
 	}
 }
+
+func TestIsToolResultContent(t *testing.T) {
+	toolResults := []interface{}{
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "1", "content": "ok"},
+	}
+	if !isToolResultContent(toolResults) {
+		t.Fatal("expected tool_result-only content to be detected")
+	}
+
+	mixed := []interface{}{
+		map[string]interface{}{"type": "tool_result", "tool_use_id": "1"},
+		map[string]interface{}{"type": "text", "text": "also a question"},
+	}
+	if isToolResultContent(mixed) {
+		t.Fatal("content with text blocks should not be treated as a tool result")
+	}
+	if isToolResultContent("plain text") {
+		t.Fatal("string content should not be treated as a tool result")
+	}
+}
+
+func TestCodexToolCall(t *testing.T) {
+	call, ok := codexToolCall(map[string]interface{}{
+		"type":      "function_call",
+		"name":      "shell",
+		"call_id":   "call_1",
+		"arguments": `{"command":["bash","-lc","ls"]}`,
+	})
+	if !ok || call.ID != "call_1" || call.Name != "shell" {
+		t.Fatalf("unexpected function call: %+v", call)
+	}
+	if cmd, ok := call.Input["command"].([]interface{}); !ok || len(cmd) != 3 {
+		t.Fatalf("arguments not decoded: %+v", call.Input)
+	}
+
+	call, ok = codexToolCall(map[string]interface{}{
+		"type":  "custom_tool_call",
+		"name":  "apply_patch",
+		"input": "*** Begin Patch",
+	})
+	if !ok || call.Input["input"] != "*** Begin Patch" {
+		t.Fatalf("unexpected custom tool call: %+v", call)
+	}
+
+	call, ok = codexToolCall(map[string]interface{}{
+		"type":    "local_shell_call",
+		"call_id": "call_3",
+		"status":  "completed",
+		"action":  map[string]interface{}{"type": "exec", "command": []interface{}{"git", "status"}},
+	})
+	if !ok || call.Name != "local_shell" || call.ID != "call_3" || call.Input["type"] != "exec" {
+		t.Fatalf("unexpected local shell call: %+v", call)
+	}
+
+	call, ok = codexToolCall(map[string]interface{}{
+		"type":   "web_search_call",
+		"id":     "ws_1",
+		"action": map[string]interface{}{"type": "search", "query": "golang errgroup"},
+	})
+	if !ok || call.Name != "web_search" || call.ID != "ws_1" || call.Input["query"] != "golang errgroup" {
+		t.Fatalf("unexpected web search call: %+v", call)
+	}
+
+	if _, ok := codexToolCall(map[string]interface{}{"type": "message"}); ok {
+		t.Fatal("message payloads are not tool calls")
+	}
+}
+
+func TestCodexReasoning(t *testing.T) {
+	text, ok := codexReasoning(map[string]interface{}{
+		"type": "reasoning",
+		"summary": []interface{}{
+			map[string]interface{}{"type": "summary_text", "text": "**Inspecting tests**"},
+			map[string]interface{}{"type": "summary_text", "text": "Running the suite first."},
+		},
+		"content":           []interface{}{map[string]interface{}{"type": "reasoning_text", "text": "raw"}},
+		"encrypted_content": "gAAAA",
+	})
+	if !ok || text != "**Inspecting tests**\n\nRunning the suite first." {
+		t.Fatalf("unexpected reasoning: %q %v", text, ok)
+	}
+
+	text, ok = codexReasoning(map[string]interface{}{
+		"type":    "reasoning",
+		"content": []interface{}{map[string]interface{}{"type": "reasoning_text", "text": "raw thoughts"}},
+	})
+	if !ok || text != "raw thoughts" {
+		t.Fatalf("raw reasoning should be used without a summary: %q %v", text, ok)
+	}
+
+	if _, ok := codexReasoning(map[string]interface{}{"type": "reasoning", "summary": []interface{}{}, "encrypted_content": "gAAAA"}); ok {
+		t.Fatal("encrypted-only reasoning has no text")
+	}
+}
+
+func TestCodexToolResult(t *testing.T) {
+	result, ok := codexToolResult(map[string]interface{}{
+		"type":    "function_call_output",
+		"call_id": "call_1",
+		"output":  `{"output":"FAIL\n","metadata":{"exit_code":2,"duration_seconds":0.4}}`,
+	})
+	if !ok || result.ToolCallID != "call_1" || result.Output != "FAIL\n" {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if result.ExitCode == nil || *result.ExitCode != 2 || !result.IsError {
+		t.Fatalf("exit code not decoded: %+v", result)
+	}
+
+	result, ok = codexToolResult(map[string]interface{}{
+		"type":    "custom_tool_call_output",
+		"call_id": "call_2",
+		"output":  "Success. Updated the following files:\nM main.go",
+	})
+	if !ok || result.ExitCode != nil || result.IsError || result.Output == "" {
+		t.Fatalf("plain output should pass through: %+v", result)
+	}
+
+	if _, ok := codexToolResult(map[string]interface{}{"type": "function_call"}); ok {
+		t.Fatal("function calls are not tool results")
+	}
+}
+
+func TestCodexTokenUsage(t *testing.T) {
+	usage, total, ok := codexTokenUsage(map[string]interface{}{
+		"type": "token_count",
+		"info": map[string]interface{}{
+			"last_token_usage":  map[string]interface{}{"input_tokens": 10.0, "output_tokens": 5.0},
+			"total_token_usage": map[string]interface{}{"total_tokens": 120.0},
+		},
+	})
+	if !ok || total != 120 || usage["output_tokens"] != 5.0 {
+		t.Fatalf("unexpected token usage: %v %v %v", usage, total, ok)
+	}
+
+	if _, _, ok := codexTokenUsage(map[string]interface{}{"type": "token_count", "info": nil}); ok {
+		t.Fatal("events without info carry no usage")
+	}
+	if _, _, ok := codexTokenUsage(map[string]interface{}{"type": "agent_message"}); ok {
+		t.Fatal("only token_count events carry usage")
+	}
+}
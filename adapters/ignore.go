@@ -0,0 +1,166 @@
+package adapters
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IgnoreFileName is the name of the ignore file a project directory may contain
+const IgnoreFileName = ".aisessionsignore"
+
+// ignoreRefresh is how long ignore files are trusted before they are read again, so a
+// long-running server notices new ones without reading them for every session
+const ignoreRefresh = time.Minute
+
+// IgnoreRules decides which projects' sessions are ignored, as listed in a global ignore
+// file and in the .aisessionsignore files of project directories and their parents.
+//
+// Each line of an ignore file is a project path or a glob pattern, ignoring the matching
+// directories and everything below them. Blank lines and lines starting with # are
+// skipped. Patterns without a "/" match a directory by name; other relative patterns are
+// relative to the directory of the ignore file, or to the home directory for the global
+// file. A .aisessionsignore file without patterns ignores its own directory.
+type IgnoreRules struct {
+	globalPath string
+
+	mu     sync.Mutex
+	loaded time.Time
+	global []string
+	dirs   map[string][]string // Patterns of each directory's ignore file, nil if it has none
+	found  int                 // Directories of dirs with an ignore file
+}
+
+// NewIgnoreRules returns the ignore rules of the global ignore file at globalPath (which
+// need not exist) and of the .aisessionsignore files of projects. Files are read when
+// first needed.
+func NewIgnoreRules(globalPath string) *IgnoreRules {
+	return &IgnoreRules{globalPath: globalPath}
+}
+
+// Ignored reports whether the sessions of a project are ignored. Sessions of an unknown
+// project are never ignored.
+func (r *IgnoreRules) Ignored(projectPath string) bool {
+	if r == nil || projectPath == "" {
+		return false
+	}
+	projectPath = filepath.Clean(projectPath)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+
+	if matchesIgnorePatterns(projectPath, r.global) {
+		return true
+	}
+	for dir := projectPath; ; dir = filepath.Dir(dir) {
+		patterns, ok := r.dirs[dir]
+		if !ok {
+			patterns = readIgnoreFile(filepath.Join(dir, IgnoreFileName), dir, true)
+			r.dirs[dir] = patterns
+			if len(patterns) > 0 {
+				r.found++
+			}
+		}
+		if matchesIgnorePatterns(projectPath, patterns) {
+			return true
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			return false
+		}
+	}
+}
+
+// Empty reports whether no ignore rules were found: the global ignore file has no
+// patterns, and none of the project directories checked so far has an ignore file
+func (r *IgnoreRules) Empty() bool {
+	if r == nil {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.refresh()
+	return len(r.global) == 0 && r.found == 0
+}
+
+// refresh reads the ignore files again once they are older than ignoreRefresh
+func (r *IgnoreRules) refresh() {
+	if time.Since(r.loaded) <= ignoreRefresh {
+		return
+	}
+	r.global = r.readGlobal()
+	r.dirs = make(map[string][]string)
+	r.found = 0
+	r.loaded = time.Now()
+}
+
+func (r *IgnoreRules) readGlobal() []string {
+	if r.globalPath == "" {
+		return nil
+	}
+	home, _ := os.UserHomeDir()
+	return readIgnoreFile(r.globalPath, home, false)
+}
+
+// readIgnoreFile returns the patterns of an ignore file, made absolute against base
+// unless they match directories by name. A project's ignore file without patterns
+// ignores base itself.
+func readIgnoreFile(path, base string, project bool) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, ignorePattern(line, base))
+	}
+	if project && len(patterns) == 0 {
+		patterns = []string{base}
+	}
+	return patterns
+}
+
+// ignorePattern resolves a line of an ignore file
+func ignorePattern(line, base string) string {
+	line = strings.TrimSuffix(filepath.FromSlash(line), string(filepath.Separator))
+	if line == "~" || strings.HasPrefix(line, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, line[1:])
+		}
+	}
+	if filepath.IsAbs(line) || !strings.ContainsRune(line, filepath.Separator) {
+		return line
+	}
+	return filepath.Join(base, line)
+}
+
+// matchesIgnorePatterns reports whether projectPath or one of its parents matches a
+// pattern
+func matchesIgnorePatterns(projectPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		byName := !strings.ContainsRune(pattern, filepath.Separator)
+		for dir := projectPath; ; dir = filepath.Dir(dir) {
+			name := dir
+			if byName {
+				name = filepath.Base(dir)
+			}
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+			if parent := filepath.Dir(dir); parent == dir {
+				break
+			}
+		}
+	}
+	return false
+}
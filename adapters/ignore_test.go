@@ -0,0 +1,160 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	root := t.TempDir()
+	globalPath := filepath.Join(root, "ignore")
+	writeFile := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+	writeFile(globalPath, "# private work\n"+filepath.Join(root, "clients", "*")+"\n\n*-scratch\n")
+	writeFile(filepath.Join(root, "personal", IgnoreFileName), "")
+	writeFile(filepath.Join(root, "mono", IgnoreFileName), "# generated\nvendor/\nexperiments/*\n")
+
+	rules := NewIgnoreRules(globalPath)
+	cases := map[string]bool{
+		filepath.Join(root, "clients", "acme"):             true,
+		filepath.Join(root, "clients", "acme", "api"):      true,
+		filepath.Join(root, "clients"):                     false,
+		filepath.Join(root, "tmp", "demo-scratch"):         true,
+		filepath.Join(root, "personal"):                    true,
+		filepath.Join(root, "personal", "blog"):            true,
+		filepath.Join(root, "mono"):                        false,
+		filepath.Join(root, "mono", "vendor", "lib"):       true,
+		filepath.Join(root, "mono", "experiments", "a"):    true,
+		filepath.Join(root, "mono", "services", "billing"): false,
+		"": false,
+	}
+	for path, want := range cases {
+		if got := rules.Ignored(path); got != want {
+			t.Errorf("Ignored(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	var none *IgnoreRules
+	if none.Ignored(filepath.Join(root, "personal")) {
+		t.Error("nil rules should ignore nothing")
+	}
+}
+
+func TestGuardedAdapterIgnoresProjects(t *testing.T) {
+	root := t.TempDir()
+	dataDir := filepath.Join(root, "claude")
+	for _, name := range []string{"app", "private"} {
+		project := filepath.Join(root, name)
+		projectDir := filepath.Join(dataDir, "projects", projectDirName(project))
+		if err := os.MkdirAll(projectDir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		writeClaudeSession(t, projectDir, name+"-1",
+			`{"type":"user","uuid":"`+name+`-u1","cwd":"`+project+`","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"work on `+name+`"}}`)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "private"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "private", IgnoreFileName), nil, 0o644); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+
+	claude, err := NewAdapterAt("claude", dataDir)
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	guarded := NewGuardedAdapter(claude, Guard{Ignore: NewIgnoreRules("")})
+
+	sessions, err := guarded.ListSessions("", 0)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != "app-1" {
+		t.Fatalf("expected the ignored project's session to be hidden, got %+v (%v)", sessions, err)
+	}
+	if _, err := guarded.GetSession("private-1", 0, 10); err == nil {
+		t.Fatal("expected an ignored session to be unreadable")
+	}
+	if messages, err := guarded.GetSession("app-1", 0, 10); err != nil || len(messages) != 1 {
+		t.Fatalf("expected to read the other session, got %+v (%v)", messages, err)
+	}
+}
+
+// listingAdapter serves fixed sessions, recording the limits it is listed with
+type listingAdapter struct {
+	sessions []Session
+	limits   []int
+}
+
+func (a *listingAdapter) Name() string { return "stub" }
+
+func (a *listingAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
+	a.limits = append(a.limits, limit)
+	if limit > 0 && limit < len(a.sessions) {
+		return a.sessions[:limit], nil
+	}
+	return a.sessions, nil
+}
+
+func (a *listingAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	return []Message{{Role: "user", Content: sessionID}}, nil
+}
+
+func (a *listingAdapter) SearchSessions(projectPath, query string, limit int) ([]Session, error) {
+	return a.ListSessions(projectPath, limit)
+}
+
+func TestGuardedAdapterWithoutIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	var sessions []Session
+	for _, name := range []string{"a", "b", "c", "d"} {
+		project := filepath.Join(root, name)
+		if err := os.MkdirAll(project, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		sessions = append(sessions, Session{ID: name + "-1", ProjectPath: project})
+	}
+	stub := &listingAdapter{sessions: sessions}
+	guarded := NewGuardedAdapter(stub, Guard{Ignore: NewIgnoreRules("")})
+
+	// Without ignore files, the limit is passed through
+	if listed, err := guarded.ListSessions("", 2); err != nil || len(listed) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v (%v)", listed, err)
+	}
+	if len(stub.limits) != 1 || stub.limits[0] != 2 {
+		t.Fatalf("expected one listing limited to 2, got %v", stub.limits)
+	}
+
+	// Unknown sessions are checked with one listing of the source, not one each
+	for _, id := range []string{"c-1", "d-1", "new-1"} {
+		if _, err := guarded.GetSession(id, 0, 10); err != nil {
+			t.Fatalf("GetSession(%s) failed: %v", id, err)
+		}
+	}
+	if len(stub.limits) != 2 {
+		t.Fatalf("expected a single listing to check unknown sessions, got %v", stub.limits)
+	}
+
+	// An ignore file found in a listed project hides its sessions again, and the listing
+	// is redone in full to fill the limit
+	if err := os.WriteFile(filepath.Join(root, "a", IgnoreFileName), nil, 0o644); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+	guarded = NewGuardedAdapter(stub, Guard{Ignore: NewIgnoreRules("")})
+	stub.limits = nil
+	listed, err := guarded.ListSessions("", 2)
+	if err != nil || len(listed) != 2 || listed[0].ID != "b-1" || listed[1].ID != "c-1" {
+		t.Fatalf("expected the ignored project's session to be skipped, got %+v (%v)", listed, err)
+	}
+	if len(stub.limits) != 2 || stub.limits[1] != 0 {
+		t.Fatalf("expected the listing to be redone in full, got %v", stub.limits)
+	}
+	if _, err := guarded.GetSession("a-1", 0, 10); err == nil {
+		t.Fatal("expected the ignored session to be unreadable")
+	}
+}
@@ -0,0 +1,232 @@
+package adapters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// NewAdapterAt creates the adapter of source reading an agent data directory other than
+// the local one, such as a copy of another machine's ~/.claude. dataDir is the directory
+// the agent itself would use: ~/.claude, ~/.codex, ~/.gemini, or opencode's
+// ~/.local/share/opencode.
+func NewAdapterAt(source, dataDir string) (SessionAdapter, error) {
+	switch source {
+	case "claude":
+		return &ClaudeAdapter{dataDir: dataDir}, nil
+	case "codex":
+		return &CodexAdapter{dataDir: dataDir}, nil
+	case "gemini":
+		return &GeminiAdapter{dataDir: dataDir, projectCache: make(map[string]string)}, nil
+	case "opencode":
+		return &OpencodeAdapter{dataDir: dataDir}, nil
+	default:
+		return nil, fmt.Errorf("unknown source: %s", source)
+	}
+}
+
+// MachineAdapter is the adapter of one source reading the sessions of a named machine.
+type MachineAdapter struct {
+	Machine string
+	Adapter SessionAdapter
+
+	// Prepare, if set, runs once before the machine's sessions are first read, such as
+	// to sync a local mirror of them
+	Prepare func()
+}
+
+// MultiMachineAdapter merges the sessions of one source from several machines, such as
+// the local machine and synced copies of other machines' agent data. Sessions from other
+// machines carry the machine's name in Session.Machine. Sessions are looked up by ID on
+// each machine in order, so the local machine should come first.
+type MultiMachineAdapter struct {
+	machines []MachineAdapter
+	prepared []sync.Once
+}
+
+// NewMultiMachineAdapter merges the given machines. The local machine has an empty name.
+func NewMultiMachineAdapter(machines ...MachineAdapter) *MultiMachineAdapter {
+	return &MultiMachineAdapter{machines: machines, prepared: make([]sync.Once, len(machines))}
+}
+
+// adapter returns the adapter of the i-th machine, preparing it on first use
+func (m *MultiMachineAdapter) adapter(i int) SessionAdapter {
+	if prepare := m.machines[i].Prepare; prepare != nil {
+		m.prepared[i].Do(prepare)
+	}
+	return m.machines[i].Adapter
+}
+
+// Name returns the name of the merged source.
+func (m *MultiMachineAdapter) Name() string {
+	if len(m.machines) == 0 {
+		return ""
+	}
+	return m.machines[0].Adapter.Name()
+}
+
+// ListSessions lists the sessions of every machine, newest first. A machine that fails
+// is skipped unless every machine fails.
+func (m *MultiMachineAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
+	return m.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (m *MultiMachineAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	sessions, err := m.merge(limit, func(adapter SessionAdapter) ([]Session, error) {
+		return ListSessionsContext(ctx, adapter, projectPath, limit)
+	})
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return sessions, err
+}
+
+// SearchSessions searches the sessions of every machine.
+func (m *MultiMachineAdapter) SearchSessions(projectPath, query string, limit int) ([]Session, error) {
+	return m.merge(limit, func(adapter SessionAdapter) ([]Session, error) {
+		return adapter.SearchSessions(projectPath, query, limit)
+	})
+}
+
+func (m *MultiMachineAdapter) merge(limit int, list func(SessionAdapter) ([]Session, error)) ([]Session, error) {
+	var (
+		all  []Session
+		errs []error
+	)
+	for i, machine := range m.machines {
+		sessions, err := list(m.adapter(i))
+		if err != nil {
+			errs = append(errs, machineError(machine.Machine, err))
+			continue
+		}
+		for i := range sessions {
+			sessions[i].Machine = machine.Machine
+		}
+		all = append(all, sessions...)
+	}
+	if len(errs) == len(m.machines) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Timestamp.After(all[j].Timestamp)
+	})
+	if limit > 0 && len(all) > limit {
+		all = all[:limit]
+	}
+	return all, nil
+}
+
+// GetSession returns a page of the first machine that has the session.
+func (m *MultiMachineAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	var firstErr error
+	for i := range m.machines {
+		messages, err := m.adapter(i).GetSession(sessionID, page, pageSize)
+		if err == nil {
+			return messages, nil
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("session not found: %s", sessionID)
+	}
+	return nil, firstErr
+}
+
+// StreamSession streams the session from the first machine that has it.
+func (m *MultiMachineAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	var firstErr error
+	for i := range m.machines {
+		streamer, ok := m.adapter(i).(SessionStreamer)
+		if !ok {
+			continue
+		}
+		// Only fall through to the next machine if nothing was streamed yet
+		streamed := false
+		err := streamer.StreamSession(sessionID, func(msg Message) bool {
+			streamed = true
+			return fn(msg)
+		})
+		if err == nil || streamed {
+			return err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	if firstErr == nil {
+		firstErr = fmt.Errorf("session not found: %s", sessionID)
+	}
+	return firstErr
+}
+
+// ReadSession reads a listed session from the machine it was listed on.
+func (m *MultiMachineAdapter) ReadSession(session Session) ([]Message, error) {
+	for i, machine := range m.machines {
+		if machine.Machine != session.Machine {
+			continue
+		}
+		adapter := m.adapter(i)
+		if reader, ok := adapter.(SessionReader); ok {
+			return reader.ReadSession(session)
+		}
+		return collectAll(func(fn func(Message) bool) error {
+			return streamPages(adapter, session.ID, fn)
+		})
+	}
+	return nil, fmt.Errorf("unknown machine: %s", session.Machine)
+}
+
+// SetMetadataCache passes the metadata cache to every machine's adapter.
+func (m *MultiMachineAdapter) SetMetadataCache(cache MetadataCache) {
+	for _, machine := range m.machines {
+		if user, ok := machine.Adapter.(MetadataCacheUser); ok {
+			user.SetMetadataCache(cache)
+		}
+	}
+}
+
+// SetProjectHints passes the project hints to every machine's adapter.
+func (m *MultiMachineAdapter) SetProjectHints(hints func() []string) {
+	for _, machine := range m.machines {
+		if user, ok := machine.Adapter.(ProjectHintUser); ok {
+			user.SetProjectHints(hints)
+		}
+	}
+}
+
+// streamPages calls fn with each message of a session: streamed when the adapter is a
+// SessionStreamer, and otherwise read a page at a time through GetSession. Each page
+// then reads the session up to it again, so the stream is preferred.
+func streamPages(adapter SessionAdapter, sessionID string, fn func(Message) bool) error {
+	if streamer, ok := adapter.(SessionStreamer); ok {
+		return streamer.StreamSession(sessionID, fn)
+	}
+	const pageSize = 100
+	for page := 0; ; page++ {
+		messages, err := adapter.GetSession(sessionID, page, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, msg := range messages {
+			if !fn(msg) {
+				return nil
+			}
+		}
+		if len(messages) < pageSize {
+			return nil
+		}
+	}
+}
+
+func machineError(machine string, err error) error {
+	if machine == "" {
+		return err
+	}
+	return fmt.Errorf("machine %s: %w", machine, err)
+}
@@ -0,0 +1,60 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMultiMachineAdapterMergesMachines(t *testing.T) {
+	newClaudeDir := func(id, firstMessage, timestamp string) string {
+		dataDir := t.TempDir()
+		projectDir := filepath.Join(dataDir, "projects", "-work-app")
+		if err := os.MkdirAll(projectDir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		writeClaudeSession(t, projectDir, id,
+			`{"type":"user","uuid":"u1","cwd":"/work/app","timestamp":"`+timestamp+`","message":{"role":"user","content":"`+firstMessage+`"}}`)
+		return dataDir
+	}
+
+	local, err := NewAdapterAt("claude", newClaudeDir("local-1", "local work", "2025-01-01T10:00:00Z"))
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	laptop, err := NewAdapterAt("claude", newClaudeDir("laptop-1", "laptop work", "2025-01-02T10:00:00Z"))
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	prepared := 0
+	merged := NewMultiMachineAdapter(MachineAdapter{Adapter: local},
+		MachineAdapter{Machine: "laptop", Adapter: laptop, Prepare: func() { prepared++ }})
+	if prepared != 0 {
+		t.Fatal("expected a machine to be prepared only when its sessions are read")
+	}
+
+	sessions, err := merged.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "laptop-1" || sessions[0].Machine != "laptop" || sessions[1].Machine != "" {
+		t.Fatalf("expected both machines' sessions newest first with labels, got %+v", sessions)
+	}
+
+	messages, err := merged.GetSession("laptop-1", 0, 10)
+	if err != nil || len(messages) != 1 || messages[0].Content != "laptop work" {
+		t.Fatalf("expected to find the session on the second machine, got %+v (%v)", messages, err)
+	}
+
+	messages, err = merged.ReadSession(sessions[0])
+	if err != nil || len(messages) != 1 || messages[0].Content != "laptop work" {
+		t.Fatalf("expected to read the session from its machine, got %+v (%v)", messages, err)
+	}
+
+	if _, err := merged.GetSession("missing", 0, 10); err == nil {
+		t.Fatal("expected an error for a session no machine has")
+	}
+	if prepared != 1 {
+		t.Fatalf("expected the machine to be prepared once, got %d", prepared)
+	}
+}
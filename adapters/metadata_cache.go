@@ -0,0 +1,119 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// metadataFormatVersion is part of every metadata cache key. Bump it whenever the
+// listing metadata an adapter derives from a session file changes, so entries parsed
+// by an older version are ignored.
+const metadataFormatVersion = 8
+
+// MetadataCache stores the listing metadata parsed from session files, so that
+// unchanged files don't have to be read and parsed again on every listing.
+// An entry is only valid for the file modification time and size it was stored with.
+type MetadataCache interface {
+	// GetMetadata returns the data stored for key if it was stored for the same file state
+	GetMetadata(key string, modTime time.Time, size int64) ([]byte, bool)
+
+	// PutMetadata stores data for key, replacing any previous entry. filePath is the file
+	// or directory the data was parsed from, so the entry can be dropped along with it.
+	PutMetadata(key, filePath string, modTime time.Time, size int64, data []byte) error
+
+	// GetSessionFile returns the file a session was last seen in
+	GetSessionFile(source, sessionID string) (string, bool)
+
+	// PutSessionFile records the file a session is stored in
+	PutSessionFile(source, sessionID, filePath string) error
+}
+
+// MetadataCacheUser is implemented by adapters that can reuse cached listing metadata.
+type MetadataCacheUser interface {
+	// SetMetadataCache makes ListSessions reuse metadata for unchanged files
+	SetMetadataCache(cache MetadataCache)
+}
+
+// metadataCaching adds an optional MetadataCache to an adapter.
+type metadataCaching struct {
+	metadataCache MetadataCache
+}
+
+// SetMetadataCache makes ListSessions reuse metadata for unchanged files.
+func (m *metadataCaching) SetMetadataCache(cache MetadataCache) {
+	m.metadataCache = cache
+}
+
+// cachedMetadata returns the result of parse for the file at path, reusing the cached
+// result while the file is unchanged. variant distinguishes parses of the same file whose
+// result depends on other inputs. Errors are never cached.
+func cachedMetadata[T any](m *metadataCaching, path, variant string, parse func() (T, error)) (T, error) {
+	if m.metadataCache == nil {
+		return parse()
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return parse()
+	}
+	return cachedMetadataAt(m, path, variant, stat.ModTime(), stat.Size(), parse)
+}
+
+// cachedMetadataAt is cachedMetadata for a path whose state is given by modTime and size
+// rather than its own stat, such as a directory whose files change.
+func cachedMetadataAt[T any](m *metadataCaching, path, variant string, modTime time.Time, size int64, parse func() (T, error)) (T, error) {
+	if m.metadataCache == nil {
+		return parse()
+	}
+
+	key := metadataCacheKey(path, variant)
+	if data, ok := m.metadataCache.GetMetadata(key, modTime, size); ok {
+		var cached T
+		if err := json.Unmarshal(data, &cached); err == nil {
+			return cached, nil
+		}
+	}
+
+	result, err := parse()
+	if err != nil {
+		return result, err
+	}
+	if data, err := json.Marshal(result); err == nil {
+		_ = m.metadataCache.PutMetadata(key, path, modTime, size, data) // Caching is best effort
+	}
+	return result, nil
+}
+
+// lookupSessionFile returns the recorded file of a session if it still exists, or "".
+func (m *metadataCaching) lookupSessionFile(source, sessionID string) string {
+	if m.metadataCache == nil {
+		return ""
+	}
+	path, ok := m.metadataCache.GetSessionFile(source, sessionID)
+	if !ok {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// recordSessionFile remembers the file of a session so it can be opened directly by ID.
+func (m *metadataCaching) recordSessionFile(source, sessionID, filePath string) {
+	if m.metadataCache == nil || sessionID == "" {
+		return
+	}
+	_ = m.metadataCache.PutSessionFile(source, sessionID, filePath) // Best effort, like the metadata
+}
+
+// metadataCacheKey builds the cache key for a parse of the file at path.
+func metadataCacheKey(path, variant string) string {
+	key := fmt.Sprintf("v%d:%s", metadataFormatVersion, path)
+	if variant != "" {
+		key += "\x00" + variant
+	}
+	return key
+}
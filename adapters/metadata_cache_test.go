@@ -0,0 +1,202 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// memoryMetadataCache is an in-memory MetadataCache that counts hits and stores
+type memoryMetadataCache struct {
+	entries map[string]memoryMetadataEntry
+	files   map[string]string
+	hits    int
+	puts    int
+}
+
+type memoryMetadataEntry struct {
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+func newMemoryMetadataCache() *memoryMetadataCache {
+	return &memoryMetadataCache{entries: make(map[string]memoryMetadataEntry), files: make(map[string]string)}
+}
+
+func (m *memoryMetadataCache) GetMetadata(key string, modTime time.Time, size int64) ([]byte, bool) {
+	entry, ok := m.entries[key]
+	if !ok || !entry.modTime.Equal(modTime) || entry.size != size {
+		return nil, false
+	}
+	m.hits++
+	return entry.data, true
+}
+
+func (m *memoryMetadataCache) PutMetadata(key, filePath string, modTime time.Time, size int64, data []byte) error {
+	m.puts++
+	m.entries[key] = memoryMetadataEntry{modTime: modTime, size: size, data: data}
+	return nil
+}
+
+func (m *memoryMetadataCache) GetSessionFile(source, sessionID string) (string, bool) {
+	path, ok := m.files[source+"/"+sessionID]
+	return path, ok
+}
+
+func (m *memoryMetadataCache) PutSessionFile(source, sessionID, filePath string) error {
+	m.files[source+"/"+sessionID] = filePath
+	return nil
+}
+
+func TestClaudeListSessionsReusesCachedMetadata(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	first := `{"type":"user","uuid":"u1","cwd":"/work/app","message":{"role":"user","content":"fix the login bug"}}`
+	reply := `{"type":"assistant","uuid":"a1","parentUuid":"u1","message":{"role":"assistant","content":"on it"}}`
+	writeClaudeSession(t, projectDir, "original", first, reply)
+	writeClaudeSession(t, projectDir, "resumed", first, reply,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":"now add a test"}}`)
+
+	cache := newMemoryMetadataCache()
+	adapter := &ClaudeAdapter{homeDir: home}
+	adapter.SetMetadataCache(cache)
+
+	parsed, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if cache.puts != 2 || cache.hits != 0 {
+		t.Fatalf("first listing: puts=%d hits=%d, want 2 and 0", cache.puts, cache.hits)
+	}
+	if cache.files["claude/original"] != filepath.Join(projectDir, "original.jsonl") {
+		t.Fatalf("listing did not record session files: %+v", cache.files)
+	}
+
+	cached, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if cache.puts != 2 || cache.hits != 2 {
+		t.Fatalf("second listing: puts=%d hits=%d, want 2 and 2", cache.puts, cache.hits)
+	}
+	// Dedupe still works from cached conversation links
+	for i := range parsed {
+		parsed[i].Timestamp = parsed[i].Timestamp.Round(0).UTC() // Drop the monotonic reading lost in JSON
+		cached[i].Timestamp = cached[i].Timestamp.UTC()
+	}
+	if len(cached) != 1 || !reflect.DeepEqual(cached, parsed) {
+		t.Fatalf("cached listing differs:\n%+v\n%+v", cached, parsed)
+	}
+
+	// A changed file is parsed again
+	path := writeClaudeSession(t, projectDir, "resumed", first, reply,
+		`{"type":"user","uuid":"u2","parentUuid":"a1","message":{"role":"user","content":"now add a test"}}`,
+		`{"type":"user","uuid":"u3","parentUuid":"u2","message":{"role":"user","content":"and update the docs"}}`)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	updated, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if cache.puts != 3 || len(updated) != 1 || updated[0].UserMessageCount != 3 {
+		t.Fatalf("changed file not reparsed: puts=%d sessions=%+v", cache.puts, updated)
+	}
+}
+
+func TestCachedMetadataWithoutCacheParses(t *testing.T) {
+	calls := 0
+	parse := func() (Session, error) {
+		calls++
+		return Session{ID: "s"}, nil
+	}
+	for i := 0; i < 2; i++ {
+		if session, err := cachedMetadata(&metadataCaching{}, "/missing", "", parse); err != nil || session.ID != "s" {
+			t.Fatalf("cachedMetadata=%+v, %v", session, err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("expected every call to parse without a cache, got %d parses", calls)
+	}
+}
+
+func TestClaudeGetSessionUsesRecordedFile(t *testing.T) {
+	home := t.TempDir()
+	projectDir := filepath.Join(home, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "s1",
+		`{"type":"user","uuid":"u1","cwd":"/work/app","message":{"role":"user","content":"from the project dir"}}`)
+
+	// A file recorded elsewhere (e.g. by an earlier listing) is opened directly
+	elsewhere := t.TempDir()
+	recorded := writeClaudeSession(t, elsewhere, "s1",
+		`{"type":"user","uuid":"u1","message":{"role":"user","content":"from the recorded file"}}`)
+
+	cache := newMemoryMetadataCache()
+	cache.files["claude/s1"] = recorded
+	adapter := &ClaudeAdapter{homeDir: home}
+	adapter.SetMetadataCache(cache)
+
+	messages, err := adapter.GetSession("s1", 0, 10)
+	if err != nil || len(messages) != 1 || messages[0].Content != "from the recorded file" {
+		t.Fatalf("GetSession=%+v, %v", messages, err)
+	}
+
+	// A stale entry falls back to the directory scan and is replaced
+	if err := os.Remove(recorded); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	messages, err = adapter.GetSession("s1", 0, 10)
+	if err != nil || len(messages) != 1 || messages[0].Content != "from the project dir" {
+		t.Fatalf("GetSession after removal=%+v, %v", messages, err)
+	}
+	if cache.files["claude/s1"] != filepath.Join(projectDir, "s1.jsonl") {
+		t.Fatalf("recorded file not updated: %q", cache.files["claude/s1"])
+	}
+}
+
+func TestCodexRecordsSessionFiles(t *testing.T) {
+	home := t.TempDir()
+	dayDir := filepath.Join(home, ".codex", "sessions", "2025", "03", "02")
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	path := filepath.Join(dayDir, "rollout-renamed.jsonl")
+	rollout := `{"type":"session_meta","payload":{"id":"abc","cwd":"/work/app","timestamp":"2025-03-02T09:00:00Z"}}
+{"type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"hello"}]}}`
+	if err := os.WriteFile(path, []byte(rollout), 0o644); err != nil {
+		t.Fatalf("write rollout: %v", err)
+	}
+
+	cache := newMemoryMetadataCache()
+	adapter := &CodexAdapter{homeDir: home}
+	adapter.SetMetadataCache(cache)
+	if _, err := adapter.ListSessions("", 0); err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if cache.files["codex/abc"] != path {
+		t.Fatalf("expected the listing to record the rollout file, got %q", cache.files["codex/abc"])
+	}
+
+	// Another process opens the recorded file directly, without scanning rollouts
+	recorded := filepath.Join(t.TempDir(), "rollout-elsewhere.jsonl")
+	if err := os.WriteFile(recorded, []byte(rollout), 0o644); err != nil {
+		t.Fatalf("write rollout: %v", err)
+	}
+	cache.files["codex/abc"] = recorded
+	later := &CodexAdapter{homeDir: home}
+	later.SetMetadataCache(cache)
+	if found := later.findSessionFile("abc"); found != recorded {
+		t.Fatalf("findSessionFile=%q, want %q", found, recorded)
+	}
+}
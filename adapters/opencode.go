@@ -1,6 +1,7 @@
 package adapters
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -13,11 +14,15 @@ import (
 // OpencodeAdapter implements SessionAdapter for opencode CLI sessions.
 // opencode stores sessions in ~/.local/share/opencode/storage/
 // Structure:
-// - project/[PROJECT_ID].json - project metadata (worktree path, vcs)
-// - session/[PROJECT_ID]/ses_*.json - session metadata (title, timestamps)
-// - message/ses_*/msg_*.json - individual messages in each session
+//   - project/[PROJECT_ID].json - project metadata (worktree path, vcs)
+//   - session/[PROJECT_ID]/ses_*.json - session metadata (title, timestamps)
+//   - message/ses_*/msg_*.json - individual messages in each session
+//   - part/msg_*/prt_*.json - the content of each message (newer versions; older
+//     versions store content inline in the message file)
 type OpencodeAdapter struct {
+	metadataCaching
 	homeDir string
+	dataDir string // opencode's data directory; ~/.local/share/opencode when empty
 }
 
 // NewOpencodeAdapter creates a new opencode session adapter.
@@ -26,7 +31,16 @@ func NewOpencodeAdapter() (*OpencodeAdapter, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
-	return &OpencodeAdapter{homeDir: homeDir}, nil
+	return &OpencodeAdapter{homeDir: homeDir, dataDir: opencodeDataDir(homeDir)}, nil
+}
+
+// storageRoot returns opencode's storage directory.
+func (o *OpencodeAdapter) storageRoot() string {
+	dataDir := o.dataDir
+	if dataDir == "" {
+		dataDir = filepath.Join(o.homeDir, ".local", "share", "opencode")
+	}
+	return filepath.Join(dataDir, "storage")
 }
 
 // Name returns the adapter name.
@@ -71,10 +85,37 @@ type opencodeMessage struct {
 	SessionID string                 `json:"sessionID,omitempty"`
 }
 
+// opencodePart represents a part file in storage/part/[MESSAGE_ID]/. Newer opencode
+// versions store a message's text, reasoning, tool calls, and files as parts.
+type opencodePart struct {
+	ID        string `json:"id"`
+	MessageID string `json:"messageID"`
+	Type      string `json:"type"` // text, reasoning, tool, file, step-start, step-finish, ...
+	Text      string `json:"text,omitempty"`
+	Synthetic bool   `json:"synthetic,omitempty"` // Text added by opencode rather than typed by the user
+	Ignored   bool   `json:"ignored,omitempty"`
+	CallID    string `json:"callID,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	State     *struct {
+		Status string                 `json:"status"` // pending, running, completed, error
+		Input  map[string]interface{} `json:"input,omitempty"`
+		Output string                 `json:"output,omitempty"`
+		Error  string                 `json:"error,omitempty"`
+	} `json:"state,omitempty"`
+	Mime     string `json:"mime,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
 // ListSessions returns all opencode sessions for the given project.
 // If projectPath is empty, returns sessions from ALL projects.
 func (o *OpencodeAdapter) ListSessions(projectPath string, limit int) ([]Session, error) {
-	storageDir := filepath.Join(o.homeDir, ".local", "share", "opencode", "storage")
+	return o.ListSessionsContext(context.Background(), projectPath, limit)
+}
+
+// ListSessionsContext is ListSessions, stopping when ctx is done.
+func (o *OpencodeAdapter) ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error) {
+	storageDir := o.storageRoot()
 
 	// Check if storage directory exists
 	if _, err := os.Stat(storageDir); os.IsNotExist(err) {
@@ -105,6 +146,9 @@ func (o *OpencodeAdapter) ListSessions(projectPath string, limit int) ([]Session
 
 	var allSessions []Session
 	for _, projectDir := range projectDirs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if !projectDir.IsDir() {
 			continue
 		}
@@ -123,8 +167,11 @@ func (o *OpencodeAdapter) ListSessions(projectPath string, limit int) ([]Session
 		}
 
 		// List sessions for this project
-		sessions, err := o.listProjectSessions(storageDir, projectID, project.Worktree)
+		sessions, err := o.listProjectSessions(ctx, storageDir, projectID, project.Worktree)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
 			continue
 		}
 
@@ -163,7 +210,7 @@ func (o *OpencodeAdapter) findProjectIDByPath(storageDir, targetPath string) (st
 			continue
 		}
 
-		if project.Worktree == targetPath {
+		if sameProjectPath(project.Worktree, targetPath) {
 			return project.ID, nil
 		}
 	}
@@ -188,7 +235,7 @@ func (o *OpencodeAdapter) loadProject(storageDir, projectID string) (*opencodePr
 }
 
 // listProjectSessions lists all sessions for a specific project
-func (o *OpencodeAdapter) listProjectSessions(storageDir, projectID, worktree string) ([]Session, error) {
+func (o *OpencodeAdapter) listProjectSessions(ctx context.Context, storageDir, projectID, worktree string) ([]Session, error) {
 	sessionDir := filepath.Join(storageDir, "session", projectID)
 	files, err := filepath.Glob(filepath.Join(sessionDir, "ses_*.json"))
 	if err != nil {
@@ -197,6 +244,9 @@ func (o *OpencodeAdapter) listProjectSessions(storageDir, projectID, worktree st
 
 	var sessions []Session
 	for _, file := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		data, err := os.ReadFile(file)
 		if err != nil {
 			continue
@@ -207,22 +257,30 @@ func (o *OpencodeAdapter) listProjectSessions(storageDir, projectID, worktree st
 			continue
 		}
 
-		// Get first message content
-		firstMessage, userCount, err := o.getFirstUserMessageAndCount(storageDir, sess.ID)
+		// Get first message content and message counts
+		summary, err := o.loadMessageSummary(storageDir, sess.ID)
 		if err != nil {
-			firstMessage = "" // Continue even if we can't get first message
-			userCount = 0
+			summary = opencodeMessageSummary{} // Continue even if we can't read the messages
 		}
 
+		title := opencodeTitle(sess.Title)
 		session := Session{
 			ID:               sess.ID,
 			Source:           "opencode",
-			ProjectPath:      worktree,
-			FirstMessage:     firstMessage,
-			Summary:          sess.Title,
+			ProjectPath:      CanonicalProjectPath(worktree),
+			FirstMessage:     summary.FirstMessage,
+			Summary:          title,
+			Title:            title,
 			Timestamp:        time.UnixMilli(sess.Time.Created),
 			FilePath:         file,
-			UserMessageCount: userCount,
+			UserMessageCount: summary.UserMessageCount,
+			MessageCount:     summary.MessageCount,
+			FileSize:         int64(len(data)) + summary.FileSize,
+			AssistantTurns:   summary.AssistantTurns,
+			ToolCallCount:    summary.ToolCallCount,
+		}
+		if sess.Time.Updated > sess.Time.Created {
+			session.DurationSeconds = (sess.Time.Updated - sess.Time.Created) / 1000
 		}
 
 		sessions = append(sessions, session)
@@ -231,19 +289,74 @@ func (o *OpencodeAdapter) listProjectSessions(storageDir, projectID, worktree st
 	return sessions, nil
 }
 
-// getFirstUserMessageAndCount extracts the first user message from a session and counts all user messages.
-func (o *OpencodeAdapter) getFirstUserMessageAndCount(storageDir, sessionID string) (string, int, error) {
+// opencodeMessageSummary is the metadata cache form of a session's messages.
+type opencodeMessageSummary struct {
+	FirstMessage     string `json:"first_message"`
+	UserMessageCount int    `json:"user_message_count"`
+	MessageCount     int    `json:"message_count"`
+	FileSize         int64  `json:"file_size"` // Of the message and part files
+	AssistantTurns   int    `json:"assistant_turns"`
+	ToolCallCount    int    `json:"tool_call_count"`
+}
+
+// loadMessageSummary returns the first user message and message counts of a session,
+// from the metadata cache while no message file has been added, removed, or rewritten.
+func (o *OpencodeAdapter) loadMessageSummary(storageDir, sessionID string) (opencodeMessageSummary, error) {
+	messageDir := filepath.Join(storageDir, "message", sessionID)
+	parse := func() (opencodeMessageSummary, error) {
+		return o.summarizeMessages(storageDir, sessionID)
+	}
+	if o.metadataCache == nil {
+		return parse()
+	}
+	modTime, size, err := newestMessageFile(messageDir)
+	if err != nil {
+		return parse()
+	}
+	return cachedMetadataAt(&o.metadataCaching, messageDir, "", modTime, size, parse)
+}
+
+// newestMessageFile returns the latest modification time of the message files in
+// messageDir and their total size. Rewriting a message in place doesn't always change
+// the directory's own modification time, so the files are stat'd instead.
+func newestMessageFile(messageDir string) (time.Time, int64, error) {
+	entries, err := os.ReadDir(messageDir)
+	if err != nil {
+		return time.Time{}, 0, err
+	}
+	var newest time.Time
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "msg_") || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+		size += info.Size()
+	}
+	return newest, size, nil
+}
+
+// summarizeMessages extracts the first user message from a session and counts its
+// messages, user messages, assistant turns, and tool calls, and the size of its files.
+func (o *OpencodeAdapter) summarizeMessages(storageDir, sessionID string) (opencodeMessageSummary, error) {
+	var summary opencodeMessageSummary
 	messageDir := filepath.Join(storageDir, "message", sessionID)
 	files, err := filepath.Glob(filepath.Join(messageDir, "msg_*.json"))
 	if err != nil {
-		return "", 0, err
+		return summary, err
 	}
 
 	// Sort by filename (contains timestamp-like component)
 	sort.Strings(files)
 
-	firstMessage := ""
-	userCount := 0
+	usesParts := hasPartStorage(storageDir)
+	var activity sessionActivity
 
 	for _, file := range files {
 		data, err := os.ReadFile(file)
@@ -255,20 +368,41 @@ func (o *OpencodeAdapter) getFirstUserMessageAndCount(storageDir, sessionID stri
 		if err := json.Unmarshal(data, &msg); err != nil {
 			continue
 		}
+		summary.MessageCount++
+		summary.FileSize += int64(len(data))
+		if usesParts {
+			summary.FileSize += partsSize(storageDir, msg.ID)
+		}
 
-		// Find first user message
-		if msg.Role == "user" {
+		switch msg.Role {
+		case "user":
 			content := o.extractMessageContent(msg.Content)
+			if content == "" && usesParts {
+				content = partsText(readParts(storageDir, msg.ID), false)
+			}
 			if content != "" {
-				userCount++
-				if firstMessage == "" {
-					firstMessage = o.extractFirstLine(content)
+				summary.UserMessageCount++
+				activity.userMessage()
+				if summary.FirstMessage == "" {
+					summary.FirstMessage = FirstMessageLine(content)
 				}
 			}
+		case "assistant":
+			toolCalls := 0
+			if usesParts {
+				for _, part := range readParts(storageDir, msg.ID) {
+					if part.Type == "tool" {
+						toolCalls++
+					}
+				}
+			}
+			activity.assistantMessage(toolCalls)
 		}
 	}
 
-	return firstMessage, userCount, nil
+	summary.AssistantTurns = activity.turns
+	summary.ToolCallCount = activity.toolCalls
+	return summary, nil
 }
 
 // extractMessageContent converts message content to string
@@ -294,62 +428,196 @@ func (o *OpencodeAdapter) extractMessageContent(content interface{}) string {
 	return ""
 }
 
-// extractFirstLine extracts the first non-empty line from text
-func (o *OpencodeAdapter) extractFirstLine(text string) string {
-	lines := strings.Split(text, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			if len(trimmed) > 200 {
-				return trimmed[:200] + "..."
+// hasPartStorage reports whether the storage directory uses the newer layout, where
+// message content is stored in part files rather than inline in the message file.
+func hasPartStorage(storageDir string) bool {
+	info, err := os.Stat(filepath.Join(storageDir, "part"))
+	return err == nil && info.IsDir()
+}
+
+// readParts loads the parts of a message in order. Part IDs sort in creation order.
+func readParts(storageDir, messageID string) []opencodePart {
+	if messageID == "" {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(storageDir, "part", messageID, "*.json"))
+	if err != nil {
+		return nil
+	}
+	sort.Strings(files)
+
+	var parts []opencodePart
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var part opencodePart
+		if err := json.Unmarshal(data, &part); err != nil {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// partsSize returns the size of the part files of a message, without reading them
+func partsSize(storageDir, messageID string) int64 {
+	if messageID == "" {
+		return 0
+	}
+	files, err := filepath.Glob(filepath.Join(storageDir, "part", messageID, "*.json"))
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// partsText joins the text parts of a message. Synthetic text (such as file contents
+// opencode adds to a prompt) is only included when includeSynthetic is set.
+func partsText(parts []opencodePart, includeSynthetic bool) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Type != "text" || part.Ignored || (part.Synthetic && !includeSynthetic) {
+			continue
+		}
+		if text := strings.TrimSpace(part.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// applyParts fills a message's content, thinking, tool calls and results, and attachments
+// from its parts.
+func applyParts(message *Message, parts []opencodePart) {
+	if message.Content == "" {
+		message.Content = partsText(parts, true)
+	}
+
+	var thinking []string
+	for _, part := range parts {
+		switch part.Type {
+		case "reasoning":
+			if text := strings.TrimSpace(part.Text); text != "" {
+				thinking = append(thinking, text)
+			}
+		case "tool":
+			message.ToolCalls = append(message.ToolCalls, ToolCall{ID: part.CallID, Name: part.Tool})
+			if part.State == nil {
+				continue
+			}
+			message.ToolCalls[len(message.ToolCalls)-1].Input = part.State.Input
+			switch part.State.Status {
+			case "completed":
+				message.ToolResults = append(message.ToolResults, ToolResult{ToolCallID: part.CallID, Output: part.State.Output})
+			case "error":
+				message.ToolResults = append(message.ToolResults, ToolResult{ToolCallID: part.CallID, Output: part.State.Error, IsError: true})
 			}
-			return trimmed
+		case "file":
+			attachment := Attachment{Type: "file", MediaType: part.Mime, Name: part.Filename}
+			if strings.HasPrefix(part.Mime, "image/") {
+				attachment.Type = "image"
+			}
+			if attachment.Name == "" && !strings.HasPrefix(part.URL, "data:") {
+				attachment.Name = part.URL
+			}
+			message.Attachments = append(message.Attachments, attachment)
 		}
 	}
-	return ""
+	if len(thinking) > 0 {
+		message.Thinking = strings.Join(thinking, "\n\n")
+	}
+}
+
+// opencodeTitle returns the title opencode gave a session, or "" while it still has the
+// placeholder given to new sessions ("New session - 2025-01-02T03:04:05.678Z")
+func opencodeTitle(title string) string {
+	if strings.HasPrefix(title, "New session - ") {
+		return ""
+	}
+	return title
 }
 
 // GetSession retrieves the full content of an opencode session with pagination
 func (o *OpencodeAdapter) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
-	storageDir := filepath.Join(o.homeDir, ".local", "share", "opencode", "storage")
-	messageDir := filepath.Join(storageDir, "message", sessionID)
-
-	// Check if message directory exists
-	if _, err := os.Stat(messageDir); os.IsNotExist(err) {
-		return nil, fmt.Errorf("session not found: %s", sessionID)
+	messageDir, err := o.messageDir(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if pageSize <= 0 || page < 0 {
+		return []Message{}, nil
 	}
 
-	// Read all messages
-	messages, err := o.readAllMessages(messageDir)
+	// Skip the messages of earlier pages and stop once this one is full
+	messages := []Message{}
+	err = o.streamMessages(messageDir, page*pageSize, func(msg Message) bool {
+		messages = append(messages, msg)
+		return len(messages) < pageSize
+	})
 	if err != nil {
 		return nil, err
 	}
+	return messages, nil
+}
 
-	// Apply pagination
-	start := page * pageSize
-	if start >= len(messages) {
-		return []Message{}, nil
+// StreamSession calls fn with each message of a session, in order, until fn returns false.
+func (o *OpencodeAdapter) StreamSession(sessionID string, fn func(Message) bool) error {
+	messageDir, err := o.messageDir(sessionID)
+	if err != nil {
+		return err
 	}
+	return o.streamMessages(messageDir, 0, fn)
+}
 
-	end := start + pageSize
-	if end > len(messages) {
-		end = len(messages)
+// messageDir returns the directory holding a session's message files.
+func (o *OpencodeAdapter) messageDir(sessionID string) (string, error) {
+	storageDir := o.storageRoot()
+	messageDir := filepath.Join(storageDir, "message", sessionID)
+
+	// Check if message directory exists
+	if _, err := os.Stat(messageDir); os.IsNotExist(err) {
+		return "", fmt.Errorf("session not found: %s", sessionID)
 	}
+	return messageDir, nil
+}
 
-	return messages[start:end], nil
+// ReadSession reads every message of a session listed by ListSessions.
+func (o *OpencodeAdapter) ReadSession(session Session) ([]Message, error) {
+	storageDir := o.storageRoot()
+	return o.readAllMessages(filepath.Join(storageDir, "message", session.ID))
 }
 
 // readAllMessages reads all messages from a session directory
 func (o *OpencodeAdapter) readAllMessages(messageDir string) ([]Message, error) {
+	return collectAll(func(fn func(Message) bool) error {
+		return o.streamMessages(messageDir, 0, fn)
+	})
+}
+
+// streamMessages reads a session's message files in order, skipping the first skip
+// messages, calling fn with each message until fn returns false. Files that can't be read
+// or parsed aren't messages, so they don't count toward skip; the parts of skipped
+// messages are never read.
+func (o *OpencodeAdapter) streamMessages(messageDir string, skip int, fn func(Message) bool) error {
 	files, err := filepath.Glob(filepath.Join(messageDir, "msg_*.json"))
 	if err != nil {
-		return nil, fmt.Errorf("failed to list message files: %w", err)
+		return fmt.Errorf("failed to list message files: %w", err)
 	}
 
 	// Sort by filename (contains timestamp)
 	sort.Strings(files)
 
-	var messages []Message
+	// messageDir is storage/message/<session ID>
+	storageDir := filepath.Dir(filepath.Dir(messageDir))
+	usesParts := hasPartStorage(storageDir)
+
 	for _, file := range files {
 		data, err := os.ReadFile(file)
 		if err != nil {
@@ -360,38 +628,53 @@ func (o *OpencodeAdapter) readAllMessages(messageDir string) ([]Message, error)
 		if err := json.Unmarshal(data, &msg); err != nil {
 			continue
 		}
-
-		message := Message{
-			Role:     msg.Role,
-			Content:  o.extractMessageContent(msg.Content),
-			Metadata: make(map[string]interface{}),
-		}
-
-		// Parse timestamp from time.created
-		if msg.Time != nil {
-			if created, ok := msg.Time["created"].(float64); ok {
-				message.Timestamp = time.UnixMilli(int64(created))
-			}
+		if skip > 0 {
+			skip--
+			continue
 		}
 
-		// Add metadata
-		if msg.ModelID != "" {
-			message.Metadata["model"] = msg.ModelID
-		}
-		if msg.Mode != "" {
-			message.Metadata["mode"] = msg.Mode
+		var parts []opencodePart
+		if usesParts {
+			parts = readParts(storageDir, msg.ID)
 		}
-		if msg.Cost > 0 {
-			message.Metadata["cost"] = msg.Cost
+		if !fn(o.toMessage(msg, parts)) {
+			return nil
 		}
-		if msg.Tokens != nil {
-			message.Metadata["tokens"] = msg.Tokens
+	}
+	return nil
+}
+
+// toMessage converts an opencode message and its parts to the unified format.
+func (o *OpencodeAdapter) toMessage(msg opencodeMessage, parts []opencodePart) Message {
+	message := Message{
+		Role:     msg.Role,
+		Content:  o.extractMessageContent(msg.Content),
+		Metadata: make(map[string]interface{}),
+	}
+	applyParts(&message, parts)
+
+	// Parse timestamp from time.created
+	if msg.Time != nil {
+		if created, ok := msg.Time["created"].(float64); ok {
+			message.Timestamp = time.UnixMilli(int64(created))
 		}
+	}
 
-		messages = append(messages, message)
+	// Add metadata
+	if msg.ModelID != "" {
+		message.Metadata["model"] = msg.ModelID
+	}
+	if msg.Mode != "" {
+		message.Metadata["mode"] = msg.Mode
+	}
+	if msg.Cost > 0 {
+		message.Metadata["cost"] = msg.Cost
+	}
+	if msg.Tokens != nil {
+		message.Metadata["tokens"] = msg.Tokens
 	}
 
-	return messages, nil
+	return message
 }
 
 // SearchSessions searches opencode sessions for the given query
@@ -415,7 +698,7 @@ func (o *OpencodeAdapter) SearchSessions(projectPath, query string, limit int) (
 		}
 
 		// Search through full session content
-		storageDir := filepath.Join(o.homeDir, ".local", "share", "opencode", "storage")
+		storageDir := o.storageRoot()
 		messageDir := filepath.Join(storageDir, "message", session.ID)
 		messages, err := o.readAllMessages(messageDir)
 		if err != nil {
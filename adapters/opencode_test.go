@@ -0,0 +1,122 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeOpencodeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func TestOpencodeReadsMessageParts(t *testing.T) {
+	home := t.TempDir()
+	storage := filepath.Join(home, ".local", "share", "opencode", "storage")
+	writeOpencodeFile(t, filepath.Join(storage, "project", "p1.json"), `{"id":"p1","worktree":"/work/app"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "session", "p1", "ses_1.json"), `{"id":"ses_1","projectID":"p1","title":"Fix tests","time":{"created":1735812000000}}`)
+	writeOpencodeFile(t, filepath.Join(storage, "message", "ses_1", "msg_01.json"), `{"id":"msg_01","role":"user","sessionID":"ses_1"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "message", "ses_1", "msg_02.json"), `{"id":"msg_02","role":"assistant","sessionID":"ses_1","modelID":"claude-sonnet"}`)
+
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_01", "prt_01.json"), `{"id":"prt_01","messageID":"msg_01","type":"text","text":"the tests fail"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_01", "prt_02.json"), `{"id":"prt_02","messageID":"msg_01","type":"text","text":"contents of go.mod","synthetic":true}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_01", "prt_03.json"), `{"id":"prt_03","messageID":"msg_01","type":"file","mime":"image/png","filename":"error.png","url":"data:image/png;base64,AAAA"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_02", "prt_01.json"), `{"id":"prt_01","messageID":"msg_02","type":"step-start"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_02", "prt_02.json"), `{"id":"prt_02","messageID":"msg_02","type":"reasoning","text":"run them first"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_02", "prt_03.json"), `{"id":"prt_03","messageID":"msg_02","type":"tool","callID":"call_1","tool":"bash","state":{"status":"error","input":{"command":"go test ./..."},"error":"exit status 1"}}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_02", "prt_04.json"), `{"id":"prt_04","messageID":"msg_02","type":"text","text":"One test fails."}`)
+
+	adapter := &OpencodeAdapter{homeDir: home}
+	sessions, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].FirstMessage != "the tests fail" || sessions[0].UserMessageCount != 1 {
+		t.Fatalf("expected the first message from the user's text part, got %+v", sessions)
+	}
+	// The session's size adds up its session, message and part files
+	var size int64
+	for _, dir := range []string{"session", "message", "part"} {
+		filepath.Walk(filepath.Join(storage, dir), func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	if sessions[0].MessageCount != 2 || sessions[0].FileSize != size {
+		t.Fatalf("expected 2 messages and %d bytes, got %d messages and %d bytes", size, sessions[0].MessageCount, sessions[0].FileSize)
+	}
+
+	messages, err := adapter.GetSession("ses_1", 0, 10)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+
+	user := messages[0]
+	if user.Content != "the tests fail\ncontents of go.mod" {
+		t.Fatalf("unexpected user content: %q", user.Content)
+	}
+	if len(user.Attachments) != 1 || user.Attachments[0].Type != "image" || user.Attachments[0].Name != "error.png" {
+		t.Fatalf("unexpected attachments: %+v", user.Attachments)
+	}
+
+	assistant := messages[1]
+	if assistant.Content != "One test fails." || assistant.Thinking != "run them first" {
+		t.Fatalf("unexpected assistant message: %+v", assistant)
+	}
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Name != "bash" || assistant.ToolCalls[0].Input["command"] != "go test ./..." {
+		t.Fatalf("unexpected tool calls: %+v", assistant.ToolCalls)
+	}
+	if len(assistant.ToolResults) != 1 || !assistant.ToolResults[0].IsError || assistant.ToolResults[0].Output != "exit status 1" {
+		t.Fatalf("unexpected tool results: %+v", assistant.ToolResults)
+	}
+}
+
+func TestOpencodeMessageSummaryTracksMessageFiles(t *testing.T) {
+	home := t.TempDir()
+	storage := filepath.Join(home, ".local", "share", "opencode", "storage")
+	writeOpencodeFile(t, filepath.Join(storage, "project", "p1.json"), `{"id":"p1","worktree":"/work/app"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "session", "p1", "ses_1.json"), `{"id":"ses_1","projectID":"p1","title":"Fix tests","time":{"created":1735812000000}}`)
+	writeOpencodeFile(t, filepath.Join(storage, "message", "ses_1", "msg_01.json"), `{"id":"msg_01","role":"user","sessionID":"ses_1"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "message", "ses_1", "msg_02.json"), `{"id":"msg_02","role":"assistant","sessionID":"ses_1"}`)
+
+	cache := newMemoryMetadataCache()
+	adapter := &OpencodeAdapter{homeDir: home}
+	adapter.SetMetadataCache(cache)
+	if _, err := adapter.ListSessions("", 0); err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if _, err := adapter.ListSessions("", 0); err != nil || cache.puts != 1 || cache.hits != 1 {
+		t.Fatalf("expected the unchanged summary to be reused: puts=%d hits=%d (%v)", cache.puts, cache.hits, err)
+	}
+
+	// Rewriting a message in place leaves the directory's modification time alone
+	messageDir := filepath.Join(storage, "message", "ses_1")
+	dirInfo, err := os.Stat(messageDir)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	message := filepath.Join(messageDir, "msg_01.json")
+	writeOpencodeFile(t, message, `{"id":"msg_01","role":"user","sessionID":"ses_1","summary":{"title":"rewritten"}}`)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(message, future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := os.Chtimes(messageDir, dirInfo.ModTime(), dirInfo.ModTime()); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if _, err := adapter.ListSessions("", 0); err != nil || cache.puts != 2 {
+		t.Fatalf("expected a rewritten message to be summarized again: puts=%d (%v)", cache.puts, err)
+	}
+}
@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Storage locations of each agent. Agents keep their data under the home directory on
+// every OS (%USERPROFILE% on Windows) unless an environment variable moves it; opencode
+// follows the XDG base directory layout, which on Windows may also resolve to the
+// local or roaming application data directory.
+
+// claudeDataDir returns Claude Code's data directory: $CLAUDE_CONFIG_DIR, or ~/.claude.
+func claudeDataDir(homeDir string) string {
+	if dir := os.Getenv("CLAUDE_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir, ".claude")
+}
+
+// codexDataDir returns Codex's data directory: $CODEX_HOME, or ~/.codex.
+func codexDataDir(homeDir string) string {
+	if dir := os.Getenv("CODEX_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(homeDir, ".codex")
+}
+
+// geminiDataDir returns Gemini CLI's data directory, ~/.gemini.
+func geminiDataDir(homeDir string) string {
+	return filepath.Join(homeDir, ".gemini")
+}
+
+// opencodeDataDir returns opencode's data directory. $XDG_DATA_HOME/opencode is used
+// when set; on Windows, %LOCALAPPDATA%\opencode and %APPDATA%\opencode are used when
+// they exist. Otherwise it is ~/.local/share/opencode.
+func opencodeDataDir(homeDir string) string {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "opencode")
+	}
+	if runtime.GOOS == "windows" {
+		for _, env := range []string{"LOCALAPPDATA", "APPDATA"} {
+			if base := os.Getenv(env); base != "" {
+				dir := filepath.Join(base, "opencode")
+				if _, err := os.Stat(filepath.Join(dir, "storage")); err == nil {
+					return dir
+				}
+			}
+		}
+	}
+	return filepath.Join(homeDir, ".local", "share", "opencode")
+}
+
+// SessionFilePath returns where the local agent of source stores the session with the
+// given ID, so sessions converted from other sources can be placed where the agent will
+// find and resume them. Only Claude Code and Codex store one plain file per session.
+func SessionFilePath(source, projectPath, sessionID string, started time.Time) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	switch source {
+	case "claude":
+		if projectPath == "" {
+			return "", fmt.Errorf("a project path is required to place a Claude Code session")
+		}
+		projectDir := encodeClaudeProjectDir(CanonicalProjectPath(projectPath))
+		return filepath.Join(claudeDataDir(homeDir), "projects", projectDir, sessionID+".jsonl"), nil
+	case "codex":
+		if started.IsZero() {
+			started = time.Now()
+		}
+		started = started.Local()
+		name := fmt.Sprintf("rollout-%s-%s.jsonl", started.Format("2006-01-02T15-04-05"), sessionID)
+		return filepath.Join(codexDataDir(homeDir), "sessions", started.Format("2006"), started.Format("01"), started.Format("02"), name), nil
+	default:
+		return "", fmt.Errorf("cannot place sessions for source %s (supported: claude, codex)", source)
+	}
+}
+
+// SessionDirs returns the directories the local agent of source keeps its sessions in,
+// for reporting where sessions were looked for.
+func SessionDirs(source string) ([]string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	switch source {
+	case "claude":
+		return []string{filepath.Join(claudeDataDir(homeDir), "projects")}, nil
+	case "codex":
+		dataDir := codexDataDir(homeDir)
+		return []string{filepath.Join(dataDir, "sessions"), filepath.Join(dataDir, "archived_sessions")}, nil
+	case "gemini":
+		return []string{filepath.Join(geminiDataDir(homeDir), "tmp")}, nil
+	case "opencode":
+		return []string{filepath.Join(opencodeDataDir(homeDir), "storage")}, nil
+	default:
+		return nil, fmt.Errorf("unknown source: %s", source)
+	}
+}
@@ -0,0 +1,45 @@
+package adapters
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDataDirsHonorEnvironment(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	if got := claudeDataDir(home); got != filepath.Join(home, ".claude") {
+		t.Fatalf("claudeDataDir default: %q", got)
+	}
+	if got := codexDataDir(home); got != filepath.Join(home, ".codex") {
+		t.Fatalf("codexDataDir default: %q", got)
+	}
+	if got := opencodeDataDir(home); got != filepath.Join(home, ".local", "share", "opencode") {
+		t.Fatalf("opencodeDataDir default: %q", got)
+	}
+
+	t.Setenv("CLAUDE_CONFIG_DIR", "/custom/claude")
+	t.Setenv("CODEX_HOME", "/custom/codex")
+	t.Setenv("XDG_DATA_HOME", "/custom/data")
+
+	if got := claudeDataDir(home); got != "/custom/claude" {
+		t.Fatalf("claudeDataDir override: %q", got)
+	}
+	if got := codexDataDir(home); got != "/custom/codex" {
+		t.Fatalf("codexDataDir override: %q", got)
+	}
+	if got := opencodeDataDir(home); got != filepath.Join("/custom/data", "opencode") {
+		t.Fatalf("opencodeDataDir override: %q", got)
+	}
+
+	// Adapters built without a data directory use the default under the home directory
+	if got := (&ClaudeAdapter{homeDir: home}).projectsDir(); got != filepath.Join(home, ".claude", "projects") {
+		t.Fatalf("projectsDir default: %q", got)
+	}
+	if got := (&ClaudeAdapter{homeDir: home, dataDir: "/custom/claude"}).projectsDir(); got != filepath.Join("/custom/claude", "projects") {
+		t.Fatalf("projectsDir override: %q", got)
+	}
+}
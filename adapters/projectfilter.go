@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ProjectFilter selects sessions by project, as named by a user: a project path, which
+// may be relative to the working directory ("./app", "app/") or start with "~", a glob
+// pattern such as "~/work/*", or a directory name such as "ai-sessions-mcp". Patterns
+// and names match a project directory and everything below it, like ignore patterns
+// (see IgnoreRules), so a repository's name also finds sessions started in its
+// subdirectories and in other clones of it. Likewise, the path of a git repository's
+// root matches the sessions started anywhere in the repository.
+type ProjectFilter struct {
+	path    string // Canonical project path, for a filter naming a directory
+	repo    bool   // Whether path is the root of a git repository
+	pattern string // Glob pattern or directory name otherwise
+}
+
+// NewProjectFilter parses a project filter. An empty filter matches every project.
+func NewProjectFilter(filter string) ProjectFilter {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return ProjectFilter{}
+	}
+	if filter == "~" || strings.HasPrefix(filter, "~/") || strings.HasPrefix(filter, "~"+string(filepath.Separator)) {
+		if home, err := os.UserHomeDir(); err == nil {
+			filter = filepath.Join(home, filter[1:])
+		}
+	}
+	filter = filepath.FromSlash(filter)
+
+	// A name without a separator is a directory name: "app/" and "./app" are paths
+	if !strings.ContainsRune(filter, filepath.Separator) && filter != "." && filter != ".." {
+		return ProjectFilter{pattern: filter}
+	}
+	if filter != string(filepath.Separator) {
+		filter = strings.TrimSuffix(filter, string(filepath.Separator))
+	}
+	if strings.ContainsAny(filter, "*?[") {
+		if abs, err := filepath.Abs(filter); err == nil {
+			filter = abs
+		}
+		return ProjectFilter{pattern: filter}
+	}
+	path := CanonicalProjectPath(filter)
+	return ProjectFilter{path: path, repo: RepoRoot(path) == path}
+}
+
+// IsEmpty reports whether the filter matches every project
+func (f ProjectFilter) IsEmpty() bool {
+	return f.path == "" && f.pattern == ""
+}
+
+// Path returns the project path the filter names, which adapters can look up directly.
+// It is empty for patterns, directory names, and repositories, whose sessions are found
+// by listing every session and keeping those that match.
+func (f ProjectFilter) Path() string {
+	if f.repo {
+		return ""
+	}
+	return f.path
+}
+
+// Matches reports whether a session's project path is selected by the filter
+func (f ProjectFilter) Matches(projectPath string) bool {
+	switch {
+	case f.IsEmpty():
+		return true
+	case projectPath == "":
+		return false
+	case f.repo:
+		return withinDir(projectPath, f.path) || withinDir(CanonicalProjectPath(projectPath), f.path)
+	case f.path != "":
+		return sameProjectPath(f.path, projectPath)
+	}
+	projectPath = filepath.Clean(projectPath)
+	if matchesIgnorePatterns(projectPath, []string{f.pattern}) {
+		return true
+	}
+	canonical := CanonicalProjectPath(projectPath)
+	return canonical != projectPath && matchesIgnorePatterns(canonical, []string{f.pattern})
+}
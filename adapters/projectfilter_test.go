@@ -0,0 +1,54 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProjectFilter(t *testing.T) {
+	root := t.TempDir()
+	app := filepath.Join(root, "work", "ai-sessions-mcp")
+	api := filepath.Join(root, "work", "api")
+	repo := filepath.Join(root, "work", "repo")
+	for _, dir := range []string{app, filepath.Join(app, "cmd"), api, filepath.Join(repo, ".git", "objects"), filepath.Join(repo, "web")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	t.Chdir(filepath.Join(root, "work"))
+
+	cases := []struct {
+		filter  string
+		matches map[string]bool
+	}{
+		{"", map[string]bool{app: true, "": true}},
+		{app, map[string]bool{app: true, filepath.Join(app, "cmd"): false, api: false}},
+		{"./api", map[string]bool{api: true, app: false}},
+		{"api/", map[string]bool{api: true, app: false}},
+		{"ai-sessions-mcp", map[string]bool{app: true, filepath.Join(app, "cmd"): true, api: false, "": false}},
+		{"ai-*", map[string]bool{app: true, api: false}},
+		{filepath.Join(root, "work", "*"), map[string]bool{app: true, api: true, root: false}},
+		{"missing-repo", map[string]bool{app: false, filepath.Join(root, "old", "missing-repo"): true}},
+		{repo, map[string]bool{repo: true, filepath.Join(repo, "web"): true, filepath.Join(repo, "gone"): true, api: false}},
+		{filepath.Join(repo, "web"), map[string]bool{filepath.Join(repo, "web"): true, repo: false}},
+	}
+	for _, c := range cases {
+		filter := NewProjectFilter(c.filter)
+		for projectPath, want := range c.matches {
+			if got := filter.Matches(projectPath); got != want {
+				t.Errorf("NewProjectFilter(%q).Matches(%q) = %v, want %v", c.filter, projectPath, got, want)
+			}
+		}
+	}
+
+	if path := NewProjectFilter("./api").Path(); path != CanonicalProjectPath(api) {
+		t.Errorf("expected a relative path to resolve to %q, got %q", api, path)
+	}
+	if path := NewProjectFilter("ai-sessions-mcp").Path(); path != "" {
+		t.Errorf("expected a directory name to have no path, got %q", path)
+	}
+	if path := NewProjectFilter(repo).Path(); path != "" {
+		t.Errorf("expected a repository root to have no path, got %q", path)
+	}
+}
@@ -0,0 +1,158 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// canonicalPaths memoizes CanonicalProjectPath for paths that exist, since listings
+// resolve the same few project directories for every session.
+var canonicalPaths sync.Map
+
+// CanonicalProjectPath returns the form of a project path used to compare and report
+// projects across sources: absolute, cleaned, and with symlinks resolved when the path
+// exists. Paths that don't exist (e.g. deleted projects) are only made absolute and cleaned.
+func CanonicalProjectPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	if cached, ok := canonicalPaths.Load(path); ok {
+		return cached.(string)
+	}
+
+	canonical := path
+	if abs, err := filepath.Abs(path); err == nil {
+		canonical = abs
+	}
+	canonical = filepath.Clean(canonical)
+
+	resolved, err := filepath.EvalSymlinks(canonical)
+	if err != nil {
+		return canonical // Not memoized, so the path resolves once it exists
+	}
+	canonicalPaths.Store(path, resolved)
+	return resolved
+}
+
+// projectPathVariants returns the absolute form of a path as given and its canonical
+// form, without duplicates. Agents record the path they were started in, which may go
+// through a symlink, so lookups keyed on the exact path try both.
+func projectPathVariants(path string) []string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	abs = filepath.Clean(abs)
+	if canonical := CanonicalProjectPath(abs); canonical != abs {
+		return []string{abs, canonical}
+	}
+	return []string{abs}
+}
+
+// sameProjectPath reports whether two project paths refer to the same directory.
+func sameProjectPath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	return a == b || CanonicalProjectPath(a) == CanonicalProjectPath(b)
+}
+
+// encodeClaudeProjectDir converts a project path to the name of its Claude Code project
+// directory. Claude Code replaces every character that isn't an ASCII letter or digit
+// with "-", so "/Users/x/my_app.v2" becomes "-Users-x-my-app-v2" and "C:\Users\x"
+// becomes "C--Users-x".
+func encodeClaudeProjectDir(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if r < 0x80 && (isASCIILetter(byte(r)) || (r >= '0' && r <= '9')) {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// decodeClaudeProjectDir recovers the project path a Claude Code project directory name
+// was derived from. The encoding is lossy ("-" may have been "/", "-", "_", "." or a
+// space), so the name is matched against the directories that exist on disk, walking
+// down from the root one entry at a time. Returns "" if no existing directory matches.
+func decodeClaudeProjectDir(name string) string {
+	root, rest, ok := claudeProjectDirRoot(name)
+	if !ok {
+		return ""
+	}
+	return matchClaudeProjectDir(root, rest, 0)
+}
+
+// claudeProjectDirRoot splits a Claude Code project directory name into the root of the
+// encoded path and the encoded rest: "-Users-x" is "/" and "Users-x", and on Windows
+// "C--Users-x" is `C:\` and "Users-x".
+func claudeProjectDirRoot(name string) (root, rest string, ok bool) {
+	if filepath.Separator == '\\' && len(name) >= 3 && name[1:3] == "--" && isASCIILetter(name[0]) {
+		return name[:1] + ":\\", name[3:], true
+	}
+	if strings.HasPrefix(name, "-") {
+		return string(filepath.Separator), name[1:], true
+	}
+	return "", "", false
+}
+
+func isASCIILetter(b byte) bool {
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z')
+}
+
+// maxProjectDirDepth bounds the directory walk of decodeClaudeProjectDir
+const maxProjectDirDepth = 32
+
+// matchClaudeProjectDir finds a directory below dir whose relative path encodes to
+// remaining. Longer entry names are tried first, so "my-app" wins over "my/app" when
+// both exist.
+func matchClaudeProjectDir(dir, remaining string, depth int) string {
+	if remaining == "" {
+		return dir
+	}
+	if depth > maxProjectDirDepth {
+		return ""
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ""
+	}
+	var candidates []string
+	for _, entry := range entries {
+		encoded := encodeClaudeProjectDir(entry.Name())
+		if encoded == remaining || strings.HasPrefix(remaining, encoded+"-") {
+			candidates = append(candidates, entry.Name())
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return len(candidates[i]) > len(candidates[j])
+	})
+
+	for _, name := range candidates {
+		path := filepath.Join(dir, name)
+		if info, err := os.Stat(path); err != nil || !info.IsDir() {
+			continue
+		}
+		rest := strings.TrimPrefix(remaining, encodeClaudeProjectDir(name))
+		if found := matchClaudeProjectDir(path, strings.TrimPrefix(rest, "-"), depth+1); found != "" {
+			return found
+		}
+	}
+	return ""
+}
+
+// guessClaudeProjectDir decodes a Claude Code project directory name by treating every
+// "-" as a path separator. It is only a best guess for projects that no longer exist.
+func guessClaudeProjectDir(name string) string {
+	root, rest, ok := claudeProjectDirRoot(name)
+	if !ok {
+		root, rest = string(filepath.Separator), name
+	}
+	return root + strings.ReplaceAll(rest, "-", string(filepath.Separator))
+}
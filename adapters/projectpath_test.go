@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncodeClaudeProjectDir(t *testing.T) {
+	if got := encodeClaudeProjectDir("/Users/x/my_app.v2"); got != "-Users-x-my-app-v2" {
+		t.Fatalf("encodeClaudeProjectDir produced %q", got)
+	}
+}
+
+func TestDecodeClaudeProjectDirResolvesDashes(t *testing.T) {
+	root := CanonicalProjectPath(t.TempDir())
+	project := filepath.Join(root, "my-app", ".config")
+	for _, dir := range []string{project, filepath.Join(root, "my", "app")} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+
+	if got := decodeClaudeProjectDir(encodeClaudeProjectDir(project)); got != project {
+		t.Fatalf("expected %q, got %q", project, got)
+	}
+	// "my-app" and "my/app" encode alike; the name with the dash is preferred
+	if got := decodeClaudeProjectDir(encodeClaudeProjectDir(filepath.Join(root, "my", "app"))); got != filepath.Join(root, "my-app") {
+		t.Fatalf("expected the directory with a dash, got %q", got)
+	}
+	if got := decodeClaudeProjectDir(encodeClaudeProjectDir(filepath.Join(root, "my", "app", "src"))); got != "" {
+		t.Fatalf("expected no match, got %q", got)
+	}
+	if err := os.Mkdir(filepath.Join(root, "my", "app", "src"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if got := decodeClaudeProjectDir(encodeClaudeProjectDir(filepath.Join(root, "my", "app", "src"))); got != filepath.Join(root, "my", "app", "src") {
+		t.Fatalf("expected to backtrack into the nested directory, got %q", got)
+	}
+	if got := decodeClaudeProjectDir(encodeClaudeProjectDir(filepath.Join(root, "gone"))); got != "" {
+		t.Fatalf("expected no match for a missing directory, got %q", got)
+	}
+}
+
+func TestCanonicalProjectPathResolvesSymlinks(t *testing.T) {
+	root := CanonicalProjectPath(t.TempDir())
+	real := filepath.Join(root, "real")
+	link := filepath.Join(root, "link")
+	if err := os.Mkdir(real, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(real, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	if got := CanonicalProjectPath(link + "/"); got != real {
+		t.Fatalf("expected %q, got %q", real, got)
+	}
+	if !sameProjectPath(link, real) {
+		t.Fatal("expected a symlink and its target to be the same project")
+	}
+	if got := CanonicalProjectPath(filepath.Join(root, "missing", "..", "gone")); got != filepath.Join(root, "gone") {
+		t.Fatalf("expected a missing path to be cleaned, got %q", got)
+	}
+}
+
+func TestClaudeListAllSessionsDecodesProjectDirs(t *testing.T) {
+	home := t.TempDir()
+	project := filepath.Join(CanonicalProjectPath(t.TempDir()), "my-app")
+	if err := os.Mkdir(project, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	projectDir := filepath.Join(home, ".claude", "projects", encodeClaudeProjectDir(project))
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	// No entry records the working directory
+	writeClaudeSession(t, projectDir, "s1", `{"type":"user","uuid":"u1","message":{"role":"user","content":"hello"}}`)
+
+	adapter := &ClaudeAdapter{homeDir: home}
+	sessions, err := adapter.ListSessions("", 0)
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ProjectPath != project {
+		t.Fatalf("expected project path %q, got %+v", project, sessions)
+	}
+
+	sessions, err = adapter.ListSessions(project, 0)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("expected the session when listing by project, got %+v (%v)", sessions, err)
+	}
+}
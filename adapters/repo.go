@@ -0,0 +1,51 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// repoRoots memoizes RepoRoot, since listings look up the same few project directories
+// for every session
+var repoRoots sync.Map
+
+// RepoRoot returns the root of the git repository containing a project directory: the
+// closest directory at or above it with a .git entry, which is a directory in a clone
+// and a file in worktrees and submodules. It is empty when the project isn't in a
+// repository or no longer exists.
+func RepoRoot(projectPath string) string {
+	if projectPath == "" || !filepath.IsAbs(projectPath) {
+		return ""
+	}
+	projectPath = filepath.Clean(projectPath)
+	if cached, ok := repoRoots.Load(projectPath); ok {
+		return cached.(string)
+	}
+	if info, err := os.Stat(projectPath); err != nil || !info.IsDir() {
+		return "" // Not memoized, so the project is found once it exists
+	}
+
+	root := ""
+	for dir := projectPath; ; dir = filepath.Dir(dir) {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			root = dir
+			break
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+	repoRoots.Store(projectPath, root)
+	return root
+}
+
+// setRepos fills in the repository of sessions that don't have one. The projects of
+// sessions from other machines can't be looked up.
+func setRepos(sessions []Session) {
+	for i := range sessions {
+		if sessions[i].Repo == "" && sessions[i].Machine == "" {
+			sessions[i].Repo = RepoRoot(sessions[i].ProjectPath)
+		}
+	}
+}
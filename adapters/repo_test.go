@@ -0,0 +1,69 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoRoot(t *testing.T) {
+	root := t.TempDir()
+	clone := filepath.Join(root, "clone")
+	worktree := filepath.Join(root, "worktree")
+	plain := filepath.Join(root, "plain")
+	for _, dir := range []string{filepath.Join(clone, ".git"), filepath.Join(clone, "cmd", "server"), worktree, plain} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+	}
+	// Worktrees and submodules have a .git file pointing at the repository
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: /elsewhere\n"), 0o644); err != nil {
+		t.Fatalf("write .git: %v", err)
+	}
+
+	cases := map[string]string{
+		clone:                                 clone,
+		filepath.Join(clone, "cmd", "server"): clone,
+		worktree:                              worktree,
+		plain:                                 "",
+		filepath.Join(root, "missing"):        "",
+		"relative/path":                       "",
+		"":                                    "",
+	}
+	for projectPath, want := range cases {
+		if got := RepoRoot(projectPath); got != want {
+			t.Errorf("RepoRoot(%q) = %q, want %q", projectPath, got, want)
+		}
+	}
+}
+
+func TestListSessionsContextSetsRepos(t *testing.T) {
+	repo := t.TempDir()
+	web := filepath.Join(repo, "web")
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.MkdirAll(web, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	dataDir := t.TempDir()
+	projectDir := filepath.Join(dataDir, "projects", "-repo-web")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	writeClaudeSession(t, projectDir, "s1",
+		`{"type":"user","uuid":"u1","cwd":"`+filepath.ToSlash(web)+`","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"fix the web build"}}`)
+	adapter, err := NewAdapterAt("claude", dataDir)
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+
+	sessions, err := ListSessionsContext(t.Context(), adapter, "", 0)
+	if err != nil {
+		t.Fatalf("ListSessionsContext returned error: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].Repo != repo || sessions[0].GroupPath() != repo {
+		t.Fatalf("expected the session to be in repository %q, got %+v", repo, sessions)
+	}
+}
@@ -0,0 +1,45 @@
+package adapters
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SessionFiles returns the files and directories that hold a local session: its session
+// file and, depending on the agent, the transcripts of the subagents it started (Claude
+// Code) or the directories of its messages and their parts (opencode). Paths that don't
+// exist are left out.
+func SessionFiles(session Session) []string {
+	var paths []string
+	add := func(path string) {
+		if _, err := os.Lstat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	add(session.FilePath)
+
+	switch session.Source {
+	case "claude":
+		// Subagent transcripts are next to the session file, or in the session's directory
+		dir := filepath.Join(filepath.Dir(session.FilePath), session.ID)
+		for _, file := range claudeAgentFiles(session.FilePath, session.ID) {
+			if !strings.HasPrefix(file, dir+string(filepath.Separator)) {
+				paths = append(paths, file)
+			}
+		}
+		add(dir)
+	case "opencode":
+		// storage/session/<project>/<id>.json; messages are in storage/message/<id> and
+		// their parts in storage/part/<message id>
+		storageDir := filepath.Dir(filepath.Dir(filepath.Dir(session.FilePath)))
+		messageDir := filepath.Join(storageDir, "message", session.ID)
+		if files, err := filepath.Glob(filepath.Join(messageDir, "msg_*.json")); err == nil {
+			for _, file := range files {
+				add(filepath.Join(storageDir, "part", strings.TrimSuffix(filepath.Base(file), ".json")))
+			}
+		}
+		add(messageDir)
+	}
+	return paths
+}
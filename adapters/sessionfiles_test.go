@@ -0,0 +1,37 @@
+package adapters
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSessionFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := writeClaudeSession(t, dir, "s1", `{"type":"user","sessionId":"s1"}`)
+	sibling := writeClaudeSession(t, dir, "agent-a1", `{"type":"user","sessionId":"s1","isSidechain":true}`)
+	writeClaudeSession(t, dir, "agent-a2", `{"type":"user","sessionId":"other","isSidechain":true}`)
+	writeOpencodeFile(t, filepath.Join(dir, "s1", "subagents", "agent-a3.jsonl"), `{"type":"user","sessionId":"s1"}`)
+
+	files := SessionFiles(Session{ID: "s1", Source: "claude", FilePath: path})
+	if want := []string{path, sibling, filepath.Join(dir, "s1")}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+
+	storage := t.TempDir()
+	sessionFile := filepath.Join(storage, "session", "p1", "ses_1.json")
+	writeOpencodeFile(t, sessionFile, `{"id":"ses_1"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "message", "ses_1", "msg_01.json"), `{"id":"msg_01"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_01", "prt_01.json"), `{"id":"prt_01"}`)
+	writeOpencodeFile(t, filepath.Join(storage, "part", "msg_02", "prt_02.json"), `{"id":"prt_02"}`)
+
+	files = SessionFiles(Session{ID: "ses_1", Source: "opencode", FilePath: sessionFile})
+	want := []string{sessionFile, filepath.Join(storage, "part", "msg_01"), filepath.Join(storage, "message", "ses_1")}
+	if !reflect.DeepEqual(files, want) {
+		t.Fatalf("expected %v, got %v", want, files)
+	}
+
+	if files := SessionFiles(Session{ID: "gone", Source: "codex", FilePath: filepath.Join(dir, "gone.jsonl")}); len(files) != 0 {
+		t.Fatalf("expected no files for a missing session, got %v", files)
+	}
+}
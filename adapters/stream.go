@@ -0,0 +1,38 @@
+package adapters
+
+// collectPage returns the messages on the requested page of a message stream, reading no
+// further than the end of the page.
+func collectPage(stream func(fn func(Message) bool) error, page, pageSize int) ([]Message, error) {
+	messages := []Message{}
+	if pageSize <= 0 || page < 0 {
+		return messages, nil
+	}
+
+	start := page * pageSize
+	end := start + pageSize
+	index := 0
+	err := stream(func(msg Message) bool {
+		if index >= start {
+			messages = append(messages, msg)
+		}
+		index++
+		return index < end
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
+
+// collectAll returns every message of a message stream.
+func collectAll(stream func(fn func(Message) bool) error) ([]Message, error) {
+	var messages []Message
+	err := stream(func(msg Message) bool {
+		messages = append(messages, msg)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return messages, nil
+}
@@ -0,0 +1,176 @@
+package adapters
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectPageStopsAtEndOfPage(t *testing.T) {
+	read := 0
+	stream := func(fn func(Message) bool) error {
+		for i := 0; i < 100; i++ {
+			read++
+			if !fn(Message{Content: fmt.Sprint(i)}) {
+				return nil
+			}
+		}
+		return nil
+	}
+
+	page, err := collectPage(stream, 1, 3)
+	if err != nil {
+		t.Fatalf("collectPage failed: %v", err)
+	}
+	if len(page) != 3 || page[0].Content != "3" || page[2].Content != "5" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if read != 6 {
+		t.Fatalf("expected the stream to stop after 6 messages, read %d", read)
+	}
+
+	if page, _ := collectPage(stream, 50, 3); len(page) != 0 || page == nil {
+		t.Fatalf("expected an empty page past the end, got %+v", page)
+	}
+}
+
+// pagedStreamer is a SessionStreamer counting the pages read through GetSession
+type pagedStreamer struct {
+	messages []Message
+	pages    int
+}
+
+func (p *pagedStreamer) Name() string { return "paged" }
+
+func (p *pagedStreamer) ListSessions(string, int) ([]Session, error) { return nil, nil }
+
+func (p *pagedStreamer) SearchSessions(string, string, int) ([]Session, error) { return nil, nil }
+
+func (p *pagedStreamer) GetSession(sessionID string, page, pageSize int) ([]Message, error) {
+	p.pages++
+	return collectPage(func(fn func(Message) bool) error { return p.StreamSession(sessionID, fn) }, page, pageSize)
+}
+
+func (p *pagedStreamer) StreamSession(sessionID string, fn func(Message) bool) error {
+	for _, msg := range p.messages {
+		if !fn(msg) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestStreamPagesPrefersStreaming(t *testing.T) {
+	adapter := &pagedStreamer{messages: make([]Message, 250)}
+	messages, err := collectAll(func(fn func(Message) bool) error {
+		return streamPages(adapter, "s1", fn)
+	})
+	if err != nil || len(messages) != 250 {
+		t.Fatalf("expected 250 messages, got %d (%v)", len(messages), err)
+	}
+	if adapter.pages != 0 {
+		t.Fatalf("expected the session to be streamed once rather than read by page, got %d pages", adapter.pages)
+	}
+}
+
+func TestGeminiStreamMessagesDecodesIncrementally(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hash", "chats")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	path := filepath.Join(dir, "session-1.json")
+	// Keys in an unusual order, with the session ID after the messages
+	data := `{"projectHash":"hash","messages":[{"type":"user","content":"one"},{"type":"gemini","content":"two"},{"type":"user","content":"three"}],"sessionId":"abc","extra":{"nested":[1,2]}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if id := geminiSessionID(path); id != "abc" {
+		t.Fatalf("geminiSessionID=%q, want abc", id)
+	}
+
+	adapter := &GeminiAdapter{homeDir: filepath.Dir(filepath.Dir(filepath.Dir(dir)))}
+	var contents []string
+	err := adapter.streamMessages(path, func(msg Message) bool {
+		contents = append(contents, msg.Role+":"+msg.Content)
+		return len(contents) < 2
+	})
+	if err != nil {
+		t.Fatalf("streamMessages failed: %v", err)
+	}
+	if strings.Join(contents, ",") != "user:one,assistant:two" {
+		t.Fatalf("unexpected messages: %v", contents)
+	}
+
+	if err := os.WriteFile(path, []byte(`[1,2]`), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := adapter.readAllMessages(path); err == nil {
+		t.Fatal("expected an error for a file that isn't a session object")
+	}
+}
+
+func TestOpencodeGetSessionCountsParsedMessages(t *testing.T) {
+	home := t.TempDir()
+	messageDir := filepath.Join(home, ".local", "share", "opencode", "storage", "message", "ses_1")
+	if err := os.MkdirAll(messageDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	for i := 0; i < 5; i++ {
+		data := fmt.Sprintf(`{"id":"msg_%d","role":"user","content":"message %d"}`, i, i)
+		if err := os.WriteFile(filepath.Join(messageDir, fmt.Sprintf("msg_%02d.json", i)), []byte(data), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	// A broken file isn't a message, so pages line up with those of StreamSession
+	if err := os.WriteFile(filepath.Join(messageDir, "msg_00.json"), []byte("not json"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	adapter := &OpencodeAdapter{homeDir: home}
+	page, err := adapter.GetSession("ses_1", 1, 2)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if len(page) != 2 || page[0].Content != "message 3" || page[1].Content != "message 4" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+func TestCodexStreamMessagesHoldsResponsesForUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollout-2025-03-02T09-00-00-abc.jsonl")
+	lines := []string{
+		`{"type":"session_meta","payload":{"id":"abc","cwd":"/work/app"}}`,
+		`{"type":"turn_context","payload":{"model":"gpt-5"}}`,
+		`{"type":"response_item","payload":{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]}}`,
+		`{"type":"response_item","payload":{"type":"reasoning","summary":[{"type":"summary_text","text":"thinking"}]}}`,
+		`{"type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"hello"}]}}`,
+		`{"type":"response_item","payload":{"type":"function_call_output","call_id":"c1","output":"done"}}`,
+		`{"type":"event_msg","payload":{"type":"token_count","info":{"last_token_usage":{"input_tokens":10,"output_tokens":5},"total_token_usage":{"total_tokens":15}}}}`,
+		`{"type":"response_item","payload":{"type":"message","role":"assistant","content":[{"type":"output_text","text":"bye"}]}}`,
+	}
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatalf("write rollout: %v", err)
+	}
+
+	adapter := &CodexAdapter{}
+	page, err := collectPage(func(fn func(Message) bool) error {
+		return adapter.streamMessages(path, fn)
+	}, 0, 3)
+	if err != nil {
+		t.Fatalf("streamMessages failed: %v", err)
+	}
+	if len(page) != 3 || page[1].Thinking != "thinking" || page[2].Content != "hello" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	if page[2].Metadata["token_usage"] == nil || page[2].Metadata["model"] != "gpt-5" {
+		t.Fatalf("usage reported after a tool result was not attached: %+v", page[2].Metadata)
+	}
+
+	all, err := adapter.readAllMessages(path)
+	if err != nil || len(all) != 5 || all[4].Content != "bye" {
+		t.Fatalf("readAllMessages=%+v, %v", all, err)
+	}
+}
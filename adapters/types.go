@@ -2,7 +2,18 @@
 // from different CLI coding agents (Claude Code, Gemini CLI, OpenAI Codex, opencode).
 package adapters
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// EmptySessionMessage is the first message of sessions in which the user wrote nothing
+const EmptySessionMessage = "(Empty session)"
+
+// maxFirstLineLength is the length of the first message shown in listings, in characters
+const maxFirstLineLength = 200
 
 // Session represents a unified view of an AI assistant session, regardless of the source agent.
 // Each session contains metadata about when it occurred, what was discussed, and how to retrieve its full content.
@@ -25,11 +36,82 @@ type Session struct {
 	// UserMessageCount is the number of user-authored messages in the session
 	UserMessageCount int `json:"user_message_count,omitempty"`
 
+	// MessageCount is the number of messages in the session, of every role, so clients can
+	// tell how long it is before reading it
+	MessageCount int `json:"message_count,omitempty"`
+
+	// FileSize is the size of the session's files in bytes
+	FileSize int64 `json:"file_size,omitempty"`
+
+	// DurationSeconds is the time between the first and last timestamped entries of the session
+	DurationSeconds int64 `json:"duration_seconds,omitempty"`
+
+	// AssistantTurns is the number of user messages the assistant replied to
+	AssistantTurns int `json:"assistant_turns,omitempty"`
+
+	// ToolCallCount is the number of tool calls the assistant made
+	ToolCallCount int `json:"tool_call_count,omitempty"`
+
 	// FilePath is the absolute path to the session file on disk
 	FilePath string `json:"file_path"`
 
 	// Summary is an optional high-level summary of the session (if available)
 	Summary string `json:"summary,omitempty"`
+
+	// Title is a short human-readable name for the session: the agent's own title when it
+	// records one (opencode), otherwise one derived from its content when it is indexed
+	Title string `json:"title,omitempty"`
+
+	// Repo is the root of the git repository the project is in, so sessions started in
+	// different directories of a repository can be grouped together
+	Repo string `json:"repo,omitempty"`
+
+	// Branch is the git branch the session worked on, when the agent records it
+	Branch string `json:"branch,omitempty"`
+
+	// Keywords are the most distinctive terms of the session, computed when it is indexed
+	Keywords []string `json:"keywords,omitempty"`
+
+	// ContinuedFrom is the ID of the session this one resumes, when the agent records it
+	ContinuedFrom string `json:"continued_from,omitempty"`
+
+	// Chain lists the earlier sessions of a resumed conversation, oldest first (see LinkChains)
+	Chain []ChainLink `json:"chain,omitempty"`
+
+	// Machine names the machine the session was recorded on; empty for the local machine
+	Machine string `json:"machine,omitempty"`
+
+	// ContentHash is a hash of the session's text, set once it is indexed. Sessions whose
+	// text is the same have the same hash.
+	ContentHash string `json:"content_hash,omitempty"`
+
+	// Duplicates lists the other copies of the session, collapsed into this entry (see
+	// CollapseDuplicates)
+	Duplicates []SessionCopy `json:"duplicates,omitempty"`
+}
+
+// GroupPath returns the path a session is grouped under in per-project reports: its
+// repository, or its project directory outside one.
+func (s Session) GroupPath() string {
+	if s.Repo != "" {
+		return s.Repo
+	}
+	return s.ProjectPath
+}
+
+// ChainLink is an earlier session in a chain of resumed sessions.
+type ChainLink struct {
+	ID               string    `json:"id"`
+	FirstMessage     string    `json:"first_message"`
+	Timestamp        time.Time `json:"timestamp"`
+	UserMessageCount int       `json:"user_message_count,omitempty"`
+}
+
+// SessionCopy is a copy of a session collapsed into another listing entry.
+type SessionCopy struct {
+	ID       string `json:"id"`
+	FilePath string `json:"file_path"`
+	Machine  string `json:"machine,omitempty"`
 }
 
 // Message represents a single message within a session.
@@ -44,10 +126,64 @@ type Message struct {
 	// Timestamp is when this message was sent (may be empty for some agents)
 	Timestamp time.Time `json:"timestamp,omitempty"`
 
-	// Metadata contains agent-specific additional data (e.g., tool calls, thinking blocks)
+	// ToolCalls are the tools the assistant invoked in this message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+
+	// ToolResults are the tool outputs carried by this message
+	ToolResults []ToolResult `json:"tool_results,omitempty"`
+
+	// Thinking is the model's reasoning text, when the agent records it
+	Thinking string `json:"thinking,omitempty"`
+
+	// Attachments lists images and files attached to the message
+	Attachments []Attachment `json:"attachments,omitempty"`
+
+	// Metadata contains agent-specific additional data (e.g., raw content blocks, token usage)
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// ToolCall is a single tool invocation made by the assistant.
+type ToolCall struct {
+	// ID links the call to its result (format varies by source)
+	ID string `json:"id,omitempty"`
+
+	// Name is the tool name as reported by the agent (e.g., "Bash", "apply_patch", "read_file")
+	Name string `json:"name"`
+
+	// Input holds the tool arguments
+	Input map[string]interface{} `json:"input,omitempty"`
+}
+
+// ToolResult is the output returned to the assistant for a tool call.
+type ToolResult struct {
+	// ToolCallID is the ID of the ToolCall this result belongs to
+	ToolCallID string `json:"tool_call_id,omitempty"`
+
+	// Output is the text output of the tool
+	Output string `json:"output"`
+
+	// IsError reports whether the tool call failed
+	IsError bool `json:"is_error,omitempty"`
+
+	// ExitCode is the process exit status for shell tools, when the agent records it
+	ExitCode *int `json:"exit_code,omitempty"`
+}
+
+// Attachment is an image or file attached to a message. Inline data is not included.
+type Attachment struct {
+	// Type is "image" or "file"
+	Type string `json:"type"`
+
+	// MediaType is the MIME type, when known (e.g., "image/png")
+	MediaType string `json:"media_type,omitempty"`
+
+	// Name is the file name, path, or URL of the attachment, when known
+	Name string `json:"name,omitempty"`
+
+	// Size is the approximate size in bytes of inline attachment data
+	Size int `json:"size,omitempty"`
+}
+
 // SessionAdapter is the interface that each agent-specific adapter must implement.
 // It provides methods to list sessions and retrieve full session content.
 type SessionAdapter interface {
@@ -68,3 +204,55 @@ type SessionAdapter interface {
 	// Returns matching sessions with the query highlighted in context.
 	SearchSessions(projectPath, query string, limit int) ([]Session, error)
 }
+
+// ContextLister is implemented by adapters that stop listing sessions when a context is
+// done, so a cancelled or timed-out request doesn't keep reading session files.
+type ContextLister interface {
+	// ListSessionsContext is ListSessions, returning ctx.Err() once ctx is done
+	ListSessionsContext(ctx context.Context, projectPath string, limit int) ([]Session, error)
+}
+
+// ListSessionsContext lists the sessions of an adapter, stopping when ctx is done if the
+// adapter is a ContextLister, and fills in their repositories (see RepoRoot).
+func ListSessionsContext(ctx context.Context, adapter SessionAdapter, projectPath string, limit int) ([]Session, error) {
+	var sessions []Session
+	var err error
+	if lister, ok := adapter.(ContextLister); ok {
+		sessions, err = lister.ListSessionsContext(ctx, projectPath, limit)
+	} else if err = ctx.Err(); err == nil {
+		sessions, err = adapter.ListSessions(projectPath, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	setRepos(sessions)
+	return sessions, nil
+}
+
+// SessionReader is implemented by adapters that can read a listed session's messages
+// directly from its FilePath, without looking the session up again by ID.
+type SessionReader interface {
+	// ReadSession returns every message of a session returned by ListSessions
+	ReadSession(session Session) ([]Message, error)
+}
+
+// SessionStreamer is implemented by adapters that can read a session one message at a
+// time, so a page can be served without holding the whole session in memory.
+type SessionStreamer interface {
+	// StreamSession calls fn with each message of a session, in order, until fn returns false
+	StreamSession(sessionID string, fn func(Message) bool) error
+}
+
+// ProjectHintUser is implemented by adapters whose sessions don't always record their
+// project path, so they can recover it from project directories known elsewhere.
+type ProjectHintUser interface {
+	// SetProjectHints sets a function returning known project directories. It is only
+	// called when a listing contains sessions whose project is otherwise unknown.
+	SetProjectHints(hints func() []string)
+}
+
+// truncateFirstLine shortens the first line of a session's first message for listings,
+// without splitting a character
+func truncateFirstLine(line string) string {
+	return textutil.Truncate(line, maxFirstLineLength+len(textutil.Ellipsis))
+}
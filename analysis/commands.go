@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// maxCommandOutputLength caps the tool output kept for each command
+const maxCommandOutputLength = 500
+
+// exitCodePattern matches exit status lines such as "Exit code 1" or "Exit code: 1".
+var exitCodePattern = regexp.MustCompile(`(?im)^\s*exit code:?\s*(-?\d+)`)
+
+// CommandRun is a shell command executed by the assistant, paired with its outcome.
+type CommandRun struct {
+	Command   string    `json:"command"`
+	Tool      string    `json:"tool"`
+	ExitCode  *int      `json:"exit_code,omitempty"`
+	IsError   bool      `json:"is_error"`
+	Output    string    `json:"output,omitempty"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// Commands lists the shell commands executed in a session, in order. Results are
+// matched to their calls by ID, so commands whose output was never recorded (e.g.,
+// an interrupted session) are still listed, just without an exit status.
+func Commands(messages []adapters.Message) []CommandRun {
+	var runs []CommandRun
+	pending := make(map[string]int)
+
+	for _, msg := range messages {
+		for _, call := range ToolCalls(msg) {
+			cmd := toolCallCommand(call)
+			if cmd == "" {
+				continue
+			}
+			if call.ID != "" {
+				pending[call.ID] = len(runs)
+			}
+			runs = append(runs, CommandRun{
+				Command:   cmd,
+				Tool:      call.Name,
+				Timestamp: msg.Timestamp,
+			})
+		}
+
+		for _, result := range ToolResults(msg) {
+			idx, ok := pending[result.ToolCallID]
+			if !ok {
+				continue
+			}
+			delete(pending, result.ToolCallID)
+
+			run := &runs[idx]
+			run.ExitCode = result.ExitCode
+			if run.ExitCode == nil {
+				run.ExitCode = parseExitCode(result.Output)
+			}
+			run.IsError = result.IsError || (run.ExitCode != nil && *run.ExitCode != 0)
+			run.Output = textutil.TruncateBytes(strings.TrimSpace(result.Output), maxCommandOutputLength)
+		}
+	}
+
+	return runs
+}
+
+// toolCallCommand returns the shell command a tool call executed, if any.
+// Patch applications routed through the shell are file edits, not commands.
+func toolCallCommand(call adapters.ToolCall) string {
+	switch strings.ToLower(call.Name) {
+	case "bash", "shell", "run_shell_command", "exec_command", "local_shell":
+	default:
+		return ""
+	}
+
+	if cmd := stringInput(call.Input, "command", "cmd"); cmd != "" {
+		return cmd
+	}
+
+	argv := commandArgs(call.Input)
+	if len(argv) == 0 || argv[0] == "apply_patch" {
+		return ""
+	}
+	// Unwrap the `bash -lc "<script>"` form Codex uses for every shell call
+	if len(argv) == 3 && isShell(argv[0]) && (argv[1] == "-c" || argv[1] == "-lc") {
+		return argv[2]
+	}
+	return strings.Join(argv, " ")
+}
+
+// isShell reports whether a program name refers to a POSIX shell.
+func isShell(program string) bool {
+	switch program[strings.LastIndex(program, "/")+1:] {
+	case "bash", "sh", "zsh":
+		return true
+	}
+	return false
+}
+
+// parseExitCode reads an exit status line from tool output, if present.
+func parseExitCode(output string) *int {
+	match := exitCodePattern.FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+	code, err := strconv.Atoi(match[1])
+	if err != nil {
+		return nil
+	}
+	return &code
+}
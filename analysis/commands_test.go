@@ -0,0 +1,39 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestCommands(t *testing.T) {
+	zero := 0
+	messages := []adapters.Message{
+		claudeToolUse("1", "Bash", map[string]interface{}{"command": "go test ./..."}),
+		claudeToolResult("1", "Exit code 1\n--- FAIL: TestX", true),
+		claudeToolUse("2", "Read", map[string]interface{}{"file_path": "main.go"}),
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{ID: "c1", Name: "shell", Input: map[string]interface{}{
+			"command": []interface{}{"bash", "-lc", "go build ./..."},
+		}}}},
+		{Role: "tool", ToolResults: []adapters.ToolResult{{ToolCallID: "c1", Output: "ok", ExitCode: &zero}}},
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{ID: "c2", Name: "shell", Input: map[string]interface{}{
+			"command": []interface{}{"apply_patch", "*** Begin Patch"},
+		}}}},
+		claudeToolUse("3", "Bash", map[string]interface{}{"command": "sleep 100"}),
+	}
+
+	runs := Commands(messages)
+	if len(runs) != 3 {
+		t.Fatalf("expected 3 commands, got %d: %+v", len(runs), runs)
+	}
+
+	if runs[0].Command != "go test ./..." || !runs[0].IsError || runs[0].ExitCode == nil || *runs[0].ExitCode != 1 {
+		t.Fatalf("unexpected failed run: %+v", runs[0])
+	}
+	if runs[1].Command != "go build ./..." || runs[1].IsError || runs[1].ExitCode == nil || *runs[1].ExitCode != 0 {
+		t.Fatalf("unexpected successful run: %+v", runs[1])
+	}
+	if runs[2].Command != "sleep 100" || runs[2].ExitCode != nil || runs[2].IsError {
+		t.Fatalf("unanswered command should have no status: %+v", runs[2])
+	}
+}
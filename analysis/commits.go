@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// commitLinePattern matches the summary line git prints when it creates a commit, such
+// as "[main 1a2b3c4] Fix the parser" or "[feature/x (root-commit) 1a2b3c4] Initial commit".
+var commitLinePattern = regexp.MustCompile(`(?m)^\[(detached HEAD|\S+?)(?: \([a-z -]+\))? ([0-9a-f]{7,40})\] (.*)$`)
+
+// detachedBranch is the branch git reports for commits made outside any branch
+const detachedBranch = "detached HEAD"
+
+// Commit is a git commit created by a command the assistant ran.
+type Commit struct {
+	Hash      string    `json:"hash"`   // Abbreviated, as printed by git
+	Branch    string    `json:"branch"` // "detached HEAD" outside any branch
+	Subject   string    `json:"subject"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// Commits lists the git commits created during a session, in order, read from the output
+// of the git commands the assistant ran. Amended commits are listed under each hash.
+func Commits(messages []adapters.Message) []Commit {
+	var commits []Commit
+	seen := make(map[string]bool)
+	pending := make(map[string]time.Time)
+
+	for _, msg := range messages {
+		for _, call := range ToolCalls(msg) {
+			if call.ID != "" && strings.Contains(toolCallCommand(call), "git") {
+				pending[call.ID] = msg.Timestamp
+			}
+		}
+
+		for _, result := range ToolResults(msg) {
+			when, ok := pending[result.ToolCallID]
+			if !ok {
+				continue
+			}
+			delete(pending, result.ToolCallID)
+			if when.IsZero() {
+				when = msg.Timestamp
+			}
+
+			for _, match := range commitLinePattern.FindAllStringSubmatch(result.Output, -1) {
+				hash := match[2]
+				if seen[hash] {
+					continue
+				}
+				seen[hash] = true
+				commits = append(commits, Commit{
+					Hash:      hash,
+					Branch:    match[1],
+					Subject:   strings.TrimSpace(match[3]),
+					Timestamp: when,
+				})
+			}
+		}
+	}
+	return commits
+}
+
+// SessionBranch returns the git branch a session worked on: the one its agent recorded,
+// otherwise the branch of its last commit
+func SessionBranch(session adapters.Session, commits []Commit) string {
+	if session.Branch != "" {
+		return session.Branch
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		if commits[i].Branch != detachedBranch {
+			return commits[i].Branch
+		}
+	}
+	return ""
+}
@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestCommits(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	commit := claudeToolUse("1", "Bash", map[string]interface{}{"command": "git add -A && git commit -m 'Fix the parser'"})
+	commit.Timestamp = start
+	messages := []adapters.Message{
+		commit,
+		claudeToolResult("1", "[feature/parser 1a2b3c4] Fix the parser\n 2 files changed, 10 insertions(+)", false),
+		claudeToolUse("2", "Bash", map[string]interface{}{"command": "cat notes.md"}),
+		claudeToolResult("2", "[main 9999999] Not a commit, just a file that looks like one", false),
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{ID: "c1", Name: "shell", Input: map[string]interface{}{
+			"command": []interface{}{"bash", "-lc", "git commit --amend --no-edit"},
+		}}}},
+		{Role: "tool", Timestamp: start.Add(time.Minute), ToolResults: []adapters.ToolResult{{ToolCallID: "c1",
+			Output: "[detached HEAD 5e6f7a8b] Fix the parser\n Date: Thu Jan 2 10:01:00 2025"}}},
+	}
+
+	commits := Commits(messages)
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %+v", commits)
+	}
+	if commits[0] != (Commit{Hash: "1a2b3c4", Branch: "feature/parser", Subject: "Fix the parser", Timestamp: start}) {
+		t.Errorf("unexpected first commit: %+v", commits[0])
+	}
+	if commits[1].Hash != "5e6f7a8b" || commits[1].Branch != "detached HEAD" || !commits[1].Timestamp.Equal(start.Add(time.Minute)) {
+		t.Errorf("unexpected amended commit: %+v", commits[1])
+	}
+
+	if branch := SessionBranch(adapters.Session{}, commits); branch != "feature/parser" {
+		t.Errorf("expected the branch of the last commit on a branch, got %q", branch)
+	}
+	if branch := SessionBranch(adapters.Session{Branch: "main"}, commits); branch != "main" {
+		t.Errorf("expected the recorded branch to be kept, got %q", branch)
+	}
+}
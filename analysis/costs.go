@@ -0,0 +1,162 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// unknownModel is the model key used when the agent did not record a model.
+const unknownModel = "(unknown)"
+
+// CostBucket is the spend for one group of model responses (a day, project, or model).
+type CostBucket struct {
+	Key           string  `json:"key"`
+	Sessions      int     `json:"sessions"`
+	Usage         Usage   `json:"usage"`
+	Cost          float64 `json:"cost"`
+	EstimatedCost float64 `json:"estimated_cost,omitempty"` // Part of Cost derived from list prices rather than recorded by the agent
+}
+
+// CostReport summarizes spend across sessions.
+type CostReport struct {
+	Total          CostBucket   `json:"total"`
+	ByDay          []CostBucket `json:"by_day"`
+	ByProject      []CostBucket `json:"by_project"`
+	ByModel        []CostBucket `json:"by_model"`
+	UnpricedModels []string     `json:"unpriced_models,omitempty"` // Models with recorded usage but no known price
+}
+
+// Costs accumulates model usage into a CostReport.
+type Costs struct {
+	loc       *time.Location
+	total     *costAccumulator
+	byDay     map[string]*costAccumulator
+	byProject map[string]*costAccumulator
+	byModel   map[string]*costAccumulator
+	unpriced  map[string]bool
+}
+
+// costAccumulator is a CostBucket plus the sessions counted in it.
+type costAccumulator struct {
+	bucket   CostBucket
+	sessions map[string]bool
+}
+
+// NewCosts creates an empty accumulator. Days are computed in loc.
+func NewCosts(loc *time.Location) *Costs {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Costs{
+		loc:       loc,
+		total:     newCostAccumulator(""),
+		byDay:     make(map[string]*costAccumulator),
+		byProject: make(map[string]*costAccumulator),
+		byModel:   make(map[string]*costAccumulator),
+		unpriced:  make(map[string]bool),
+	}
+}
+
+func newCostAccumulator(key string) *costAccumulator {
+	return &costAccumulator{bucket: CostBucket{Key: key}, sessions: make(map[string]bool)}
+}
+
+// Add records the usage of a session's messages. Spend is attributed to the day each
+// response was made, falling back to the session start when a message has no timestamp.
+func (c *Costs) Add(session adapters.Session, messages []adapters.Message) {
+	sessionKey := session.Source + "/" + session.ID
+	for _, msg := range messages {
+		usage := MessageUsage(msg)
+		if usage.Total() == 0 && usage.Cost == 0 {
+			continue
+		}
+
+		model := MessageModel(msg)
+		if model == "" {
+			model = unknownModel
+		}
+		cost, estimated, priced := MessageCost(msg)
+		if !priced {
+			c.unpriced[model] = true
+		}
+		usage.Cost = cost
+
+		when := msg.Timestamp
+		if when.IsZero() {
+			when = session.Timestamp
+		}
+
+		accumulators := []*costAccumulator{
+			c.total,
+			costBucket(c.byProject, session.GroupPath()),
+			costBucket(c.byModel, model),
+		}
+		if !when.IsZero() {
+			accumulators = append(accumulators, costBucket(c.byDay, when.In(c.loc).Format("2006-01-02")))
+		}
+		for _, acc := range accumulators {
+			acc.add(sessionKey, usage, estimated)
+		}
+	}
+}
+
+// Report returns the accumulated spend. Days are ordered chronologically, projects and
+// models by cost (most expensive first).
+func (c *Costs) Report() CostReport {
+	report := CostReport{
+		Total:     c.total.bucket,
+		ByDay:     sortedCostBuckets(c.byDay),
+		ByProject: sortedCostBuckets(c.byProject),
+		ByModel:   sortedCostBuckets(c.byModel),
+	}
+	report.Total.Key = "total"
+	sort.SliceStable(report.ByProject, func(i, j int) bool {
+		return report.ByProject[i].Cost > report.ByProject[j].Cost
+	})
+	sort.SliceStable(report.ByModel, func(i, j int) bool {
+		return report.ByModel[i].Cost > report.ByModel[j].Cost
+	})
+	for model := range c.unpriced {
+		report.UnpricedModels = append(report.UnpricedModels, model)
+	}
+	sort.Strings(report.UnpricedModels)
+	return report
+}
+
+// add records one response's usage, whose Cost is already filled in.
+func (a *costAccumulator) add(sessionKey string, usage Usage, estimated bool) {
+	if !a.sessions[sessionKey] {
+		a.sessions[sessionKey] = true
+		a.bucket.Sessions++
+	}
+	a.bucket.Cost += usage.Cost
+	if estimated {
+		a.bucket.EstimatedCost += usage.Cost
+	}
+	usage.Cost = 0 // Reported once, as the bucket's Cost
+	a.bucket.Usage.Add(usage)
+}
+
+// costBucket returns the accumulator for key, creating it if needed.
+func costBucket(buckets map[string]*costAccumulator, key string) *costAccumulator {
+	acc, ok := buckets[key]
+	if !ok {
+		acc = newCostAccumulator(key)
+		buckets[key] = acc
+	}
+	return acc
+}
+
+// sortedCostBuckets returns the buckets ordered by key.
+func sortedCostBuckets(buckets map[string]*costAccumulator) []CostBucket {
+	result := make([]CostBucket, 0, len(buckets))
+	for _, acc := range buckets {
+		result = append(result, acc.bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
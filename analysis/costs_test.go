@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestPriceForModel(t *testing.T) {
+	cases := map[string]float64{
+		"claude-sonnet-4-5-20250929": 3,
+		"claude-opus-4-1-20250805":   15,
+		"claude-opus-4-5-20251101":   5,
+		"openai/gpt-5-codex":         1.25,
+		"gpt-5-mini":                 0.25,
+		"models/gemini-2.5-flash":    0.3,
+		"gemini-2.5-flash-lite":      0.1,
+		"o3-2025-04-16":              2,
+		"o3-mini":                    1.1,
+		"o3-pro-2025-06-10":          20,
+	}
+	for model, input := range cases {
+		price, ok := PriceForModel(model)
+		if !ok || price.Input != input {
+			t.Errorf("PriceForModel(%q) = %+v, %v; want input price %v", model, price, ok, input)
+		}
+	}
+	if _, ok := PriceForModel("llama-3-70b"); ok {
+		t.Error("unknown models should have no price")
+	}
+	if _, ok := PriceForModel(""); ok {
+		t.Error("empty model should have no price")
+	}
+}
+
+func TestMessageCost(t *testing.T) {
+	recorded := adapters.Message{Metadata: map[string]interface{}{"cost": 0.42, "model": "claude-sonnet-4"}}
+	if cost, estimated, priced := MessageCost(recorded); cost != 0.42 || estimated || !priced {
+		t.Fatalf("recorded cost should win: %v %v %v", cost, estimated, priced)
+	}
+
+	claude := adapters.Message{Metadata: map[string]interface{}{
+		"model": "claude-sonnet-4-5-20250929",
+		"usage": map[string]interface{}{
+			"input_tokens": 1000.0, "output_tokens": 1000.0,
+			"cache_read_input_tokens": 10000.0, "cache_creation_input_tokens": 1000.0,
+		},
+	}}
+	// 1000*3 + 1000*15 + 10000*0.3 + 1000*3.75 = 24750 per million
+	cost, estimated, priced := MessageCost(claude)
+	if math.Abs(cost-0.02475) > 1e-9 || !estimated || !priced {
+		t.Fatalf("unexpected Claude cost: %v %v %v", cost, estimated, priced)
+	}
+
+	unknown := adapters.Message{Metadata: map[string]interface{}{
+		"model": "mystery-model",
+		"usage": map[string]interface{}{"input_tokens": 10.0},
+	}}
+	if _, _, priced := MessageCost(unknown); priced {
+		t.Fatal("unknown model should be reported as unpriced")
+	}
+
+	if total := SessionCost([]adapters.Message{recorded, claude, {Role: "user"}}); math.Abs(total-0.44475) > 1e-9 {
+		t.Fatalf("unexpected session cost: %v", total)
+	}
+}
+
+func TestCostsReport(t *testing.T) {
+	day1 := time.Date(2025, 2, 3, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	priced := func(ts time.Time, model string, cost float64) adapters.Message {
+		return adapters.Message{Role: "assistant", Timestamp: ts, Metadata: map[string]interface{}{
+			"model": model, "cost": cost, "tokens": map[string]interface{}{"input": 100.0},
+		}}
+	}
+
+	costs := NewCosts(time.UTC)
+	costs.Add(adapters.Session{ID: "a", Source: "opencode", ProjectPath: "/a", Timestamp: day1}, []adapters.Message{
+		{Role: "user", Content: "hi"},
+		priced(day1, "gpt-5", 1),
+		priced(day2, "claude-sonnet-4", 2), // Session continued the next day
+	})
+	costs.Add(adapters.Session{ID: "b", Source: "opencode", ProjectPath: "/b", Timestamp: day2}, []adapters.Message{
+		priced(time.Time{}, "", 0.5),
+		{Role: "assistant", Metadata: map[string]interface{}{"model": "mystery", "usage": map[string]interface{}{"input_tokens": 5.0}}},
+	})
+
+	report := costs.Report()
+	if math.Abs(report.Total.Cost-3.5) > 1e-9 || report.Total.Sessions != 2 {
+		t.Fatalf("unexpected total: %+v", report.Total)
+	}
+	if len(report.ByDay) != 2 || report.ByDay[0].Cost != 1 || report.ByDay[1].Cost != 2.5 || report.ByDay[1].Sessions != 2 {
+		t.Fatalf("unexpected day buckets: %+v", report.ByDay)
+	}
+	if report.ByProject[0].Key != "/a" || report.ByProject[0].Cost != 3 {
+		t.Fatalf("projects should be ordered by cost: %+v", report.ByProject)
+	}
+	if report.ByModel[0].Key != "claude-sonnet-4" {
+		t.Fatalf("models should be ordered by cost: %+v", report.ByModel)
+	}
+	if len(report.UnpricedModels) != 1 || report.UnpricedModels[0] != "mystery" {
+		t.Fatalf("unexpected unpriced models: %v", report.UnpricedModels)
+	}
+}
@@ -0,0 +1,360 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+const (
+	// diffContextLines is the number of unchanged lines shown around each change
+	diffContextLines = 3
+
+	// maxDiffCells caps the size of the table used to diff an edit, in lines of the old
+	// text times lines of the new; larger edits are shown as replacing every line
+	maxDiffCells = 4_000_000
+)
+
+// FileDiff is the change a single tool call made to a file, as a unified diff. Tools
+// don't record where in a file an edit applies, so hunks number lines from the start
+// of the text they replace. Writes are shown as adding the whole file, since the content
+// they overwrote isn't recorded.
+type FileDiff struct {
+	Path      string    `json:"path"`
+	Operation string    `json:"operation"` // edit, write, create, or delete
+	Tool      string    `json:"tool"`
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	Added     int       `json:"added"`
+	Removed   int       `json:"removed"`
+	Diff      string    `json:"diff"`
+}
+
+// textEdit replaces old with new somewhere in a file
+type textEdit struct {
+	old, new string
+}
+
+// Diffs returns the changes the assistant made to files in a session, in order, from its
+// edit, write, and patch tool calls. Calls that failed are left out, since they changed
+// nothing.
+func Diffs(messages []adapters.Message) []FileDiff {
+	failed := make(map[string]bool)
+	for _, msg := range messages {
+		for _, result := range ToolResults(msg) {
+			if result.IsError && result.ToolCallID != "" {
+				failed[result.ToolCallID] = true
+			}
+		}
+	}
+
+	var diffs []FileDiff
+	for _, msg := range messages {
+		for _, call := range ToolCalls(msg) {
+			if call.ID != "" && failed[call.ID] {
+				continue
+			}
+			for _, diff := range toolCallDiffs(call) {
+				diff.Timestamp = msg.Timestamp
+				diffs = append(diffs, diff)
+			}
+		}
+	}
+	return diffs
+}
+
+// toolCallDiffs returns the changes made by a single tool call
+func toolCallDiffs(call adapters.ToolCall) []FileDiff {
+	name := strings.ToLower(call.Name)
+	if name == "apply_patch" {
+		return patchDiffs(stringInput(call.Input, "input", "patch"), call.Name)
+	}
+	if argv := commandArgs(call.Input); len(argv) >= 2 && argv[0] == "apply_patch" {
+		return patchDiffs(argv[1], "apply_patch")
+	}
+
+	path := stringInput(call.Input, "file_path", "filePath", "absolute_path", "path")
+	if path == "" {
+		return nil
+	}
+	switch toolFileOperations[name] {
+	case OpEdit:
+		var edits []textEdit
+		if items, ok := call.Input["edits"].([]interface{}); ok {
+			for _, item := range items {
+				if m, ok := item.(map[string]interface{}); ok {
+					edits = append(edits, textEdit{stringInput(m, "old_string", "oldString"), stringInput(m, "new_string", "newString")})
+				}
+			}
+		} else if edit := (textEdit{stringInput(call.Input, "old_string", "oldString"), stringInput(call.Input, "new_string", "newString")}); edit != (textEdit{}) {
+			edits = append(edits, edit)
+		}
+		if len(edits) == 0 {
+			return nil
+		}
+		return []FileDiff{newFileDiff(path, path, OpEdit, call.Name, edits)}
+	case OpWrite:
+		content, ok := call.Input["content"].(string)
+		if !ok {
+			return nil
+		}
+		return []FileDiff{newFileDiff("/dev/null", path, OpWrite, call.Name, []textEdit{{"", content}})}
+	}
+	return nil
+}
+
+// patchFile is a file section of an apply_patch style patch
+type patchFile struct {
+	path, movedTo, operation string
+	edits                    []textEdit
+}
+
+// patchDiffs converts an apply_patch style patch into a diff per file. Its hunks carry
+// context lines but no line numbers.
+func patchDiffs(patch, tool string) []FileDiff {
+	var diffs []FileDiff
+	var current *patchFile
+	var oldText, newText strings.Builder
+	inHunk := false
+
+	endHunk := func() {
+		if inHunk && current != nil {
+			current.edits = append(current.edits, textEdit{oldText.String(), newText.String()})
+		}
+		oldText.Reset()
+		newText.Reset()
+		inHunk = false
+	}
+	endFile := func() {
+		endHunk()
+		if current == nil {
+			return
+		}
+		oldPath, newPath := current.path, current.path
+		switch {
+		case current.operation == OpCreate:
+			oldPath = "/dev/null"
+		case current.operation == OpDelete:
+			newPath = "/dev/null"
+		case current.movedTo != "":
+			newPath = current.movedTo
+		}
+		diffs = append(diffs, newFileDiff(oldPath, newPath, current.operation, tool, current.edits))
+		current = nil
+	}
+	startFile := func(line, prefix, operation string) {
+		endFile()
+		current = &patchFile{path: strings.TrimSpace(strings.TrimPrefix(line, prefix)), operation: operation}
+	}
+
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		switch {
+		case strings.HasPrefix(line, "*** Add File: "):
+			startFile(line, "*** Add File: ", OpCreate)
+			inHunk = true // The file's lines follow, each added
+		case strings.HasPrefix(line, "*** Update File: "):
+			startFile(line, "*** Update File: ", OpEdit)
+		case strings.HasPrefix(line, "*** Delete File: "):
+			startFile(line, "*** Delete File: ", OpDelete)
+		case strings.HasPrefix(line, "*** Move to: ") && current != nil:
+			current.movedTo = strings.TrimSpace(strings.TrimPrefix(line, "*** Move to: "))
+		case strings.HasPrefix(line, "*** End of File"):
+		case strings.HasPrefix(line, "*** "):
+			// "*** Begin Patch" and "*** End Patch"
+			endFile()
+		case current == nil:
+			// Text outside a file section
+		case strings.HasPrefix(line, "@@"):
+			endHunk()
+			inHunk = true
+		case !inHunk:
+			// Lines of an update come after a hunk header
+		case strings.HasPrefix(line, "+"):
+			newText.WriteString(line[1:] + "\n")
+		case strings.HasPrefix(line, "-"):
+			oldText.WriteString(line[1:] + "\n")
+		default:
+			context := strings.TrimPrefix(line, " ")
+			oldText.WriteString(context + "\n")
+			newText.WriteString(context + "\n")
+		}
+	}
+	endFile()
+	return diffs
+}
+
+// newFileDiff renders the edits made to a file as a unified diff
+func newFileDiff(oldPath, newPath, operation, tool string, edits []textEdit) FileDiff {
+	diff := FileDiff{Path: newPath, Operation: operation, Tool: tool}
+	if newPath == "/dev/null" {
+		diff.Path = oldPath
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldPath, newPath)
+	for _, edit := range edits {
+		added, removed := writeHunks(&b, splitLines(edit.old), splitLines(edit.new))
+		diff.Added += added
+		diff.Removed += removed
+	}
+	diff.Diff = b.String()
+	return diff
+}
+
+// splitLines splits text into lines, without a final empty line for a trailing newline
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// diffLine is a line of a diff: ' ' for context, '-' for removed, '+' for added
+type diffLine struct {
+	kind byte
+	text string
+}
+
+// writeHunks writes the hunks turning old into new, returning the number of lines added
+// and removed
+func writeHunks(b *strings.Builder, old, new []string) (added, removed int) {
+	lines := diffLines(old, new)
+
+	// Positions of changed lines, grouped into hunks with their surrounding context
+	for start := 0; start < len(lines); {
+		first := start
+		for first < len(lines) && lines[first].kind == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines); i++ {
+			if lines[i].kind != ' ' {
+				last = i
+			} else if i-last > 2*diffContextLines {
+				break
+			}
+		}
+		from := max(first-diffContextLines, start)
+		to := min(last+diffContextLines+1, len(lines))
+
+		oldStart, newStart := 1, 1
+		for _, line := range lines[:from] {
+			if line.kind != '+' {
+				oldStart++
+			}
+			if line.kind != '-' {
+				newStart++
+			}
+		}
+		oldCount, newCount := 0, 0
+		for _, line := range lines[from:to] {
+			switch line.kind {
+			case ' ':
+				oldCount++
+				newCount++
+			case '-':
+				oldCount++
+				removed++
+			case '+':
+				newCount++
+				added++
+			}
+		}
+		// An empty side is numbered after the line it follows, as in diff(1)
+		if oldCount == 0 {
+			oldStart--
+		}
+		if newCount == 0 {
+			newStart--
+		}
+		fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+		for _, line := range lines[from:to] {
+			b.WriteByte(line.kind)
+			b.WriteString(line.text)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return added, removed
+}
+
+// diffLines returns the lines of old and new, marked as kept, removed, or added, from a
+// longest common subsequence of their lines
+func diffLines(old, new []string) []diffLine {
+	// Common leading and trailing lines need no table
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, line := range old[:prefix] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	lines = append(lines, diffMiddle(old[prefix:len(old)-suffix], new[prefix:len(new)-suffix])...)
+	for _, line := range old[len(old)-suffix:] {
+		lines = append(lines, diffLine{' ', line})
+	}
+	return lines
+}
+
+// diffMiddle diffs the lines between the common prefix and suffix of two texts
+func diffMiddle(old, new []string) []diffLine {
+	var lines []diffLine
+	if len(old)*len(new) > maxDiffCells || len(old) == 0 || len(new) == 0 {
+		for _, line := range old {
+			lines = append(lines, diffLine{'-', line})
+		}
+		for _, line := range new {
+			lines = append(lines, diffLine{'+', line})
+		}
+		return lines
+	}
+
+	// common[i][j] is the length of the longest common subsequence of old[i:] and new[j:]
+	common := make([][]int, len(old)+1)
+	for i := range common {
+		common[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(old) && j < len(new) {
+		switch {
+		case old[i] == new[j]:
+			lines = append(lines, diffLine{' ', old[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, diffLine{'-', old[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', new[j]})
+			j++
+		}
+	}
+	for ; i < len(old); i++ {
+		lines = append(lines, diffLine{'-', old[i]})
+	}
+	for ; j < len(new); j++ {
+		lines = append(lines, diffLine{'+', new[j]})
+	}
+	return lines
+}
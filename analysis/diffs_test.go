@@ -0,0 +1,111 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestDiffsFromEdits(t *testing.T) {
+	messages := []adapters.Message{
+		claudeToolUse("1", "Edit", map[string]interface{}{
+			"file_path":  "/repo/main.go",
+			"old_string": "func main() {\n\tfmt.Println(\"hi\")\n}",
+			"new_string": "func main() {\n\tfmt.Println(\"hello\")\n}",
+		}),
+		claudeToolUse("2", "Write", map[string]interface{}{"file_path": "/repo/README.md", "content": "# App\n\nUsage\n"}),
+		claudeToolUse("3", "Edit", map[string]interface{}{"file_path": "/repo/main.go", "old_string": "missing", "new_string": "x"}),
+		claudeToolResult("3", "String to replace not found in file", true),
+		claudeToolUse("4", "Read", map[string]interface{}{"file_path": "/repo/main.go"}),
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{ID: "e1", Name: "edit", Input: map[string]interface{}{
+			"filePath": "/repo/go.mod", "oldString": "go 1.22", "newString": "go 1.25",
+		}}}},
+	}
+
+	diffs := Diffs(messages)
+	if len(diffs) != 3 {
+		t.Fatalf("expected 3 diffs without the failed edit and the read, got %+v", diffs)
+	}
+
+	want := "--- /repo/main.go\n+++ /repo/main.go\n@@ -1,3 +1,3 @@\n func main() {\n-\tfmt.Println(\"hi\")\n+\tfmt.Println(\"hello\")\n }\n"
+	if diffs[0].Diff != want || diffs[0].Added != 1 || diffs[0].Removed != 1 || diffs[0].Operation != OpEdit {
+		t.Errorf("unexpected edit diff:\n%s%+v", diffs[0].Diff, diffs[0])
+	}
+	want = "--- /dev/null\n+++ /repo/README.md\n@@ -0,0 +1,3 @@\n+# App\n+\n+Usage\n"
+	if diffs[1].Diff != want || diffs[1].Path != "/repo/README.md" || diffs[1].Added != 3 {
+		t.Errorf("unexpected write diff:\n%s", diffs[1].Diff)
+	}
+	if diffs[2].Path != "/repo/go.mod" || !strings.Contains(diffs[2].Diff, "-go 1.22\n+go 1.25\n") {
+		t.Errorf("unexpected opencode edit diff:\n%s", diffs[2].Diff)
+	}
+}
+
+func TestDiffsSplitDistantChangesIntoHunks(t *testing.T) {
+	var old, new []string
+	for i := 1; i <= 20; i++ {
+		line := "line " + strings.Repeat("x", i)
+		old = append(old, line)
+		switch i {
+		case 2:
+			new = append(new, "changed")
+		case 18:
+		default:
+			new = append(new, line)
+		}
+	}
+	edit := claudeToolUse("1", "MultiEdit", map[string]interface{}{
+		"file_path": "/repo/a.txt",
+		"edits": []interface{}{
+			map[string]interface{}{"old_string": strings.Join(old, "\n"), "new_string": strings.Join(new, "\n")},
+		},
+	})
+
+	diffs := Diffs([]adapters.Message{edit})
+	if len(diffs) != 1 {
+		t.Fatalf("expected one diff, got %+v", diffs)
+	}
+	if hunks := strings.Count(diffs[0].Diff, "\n@@ "); hunks != 2 {
+		t.Fatalf("expected distant changes in separate hunks, got:\n%s", diffs[0].Diff)
+	}
+	if !strings.Contains(diffs[0].Diff, "@@ -1,5 +1,5 @@\n") || !strings.Contains(diffs[0].Diff, "@@ -15,6 +15,5 @@\n") {
+		t.Errorf("unexpected hunk headers:\n%s", diffs[0].Diff)
+	}
+	if diffs[0].Added != 1 || diffs[0].Removed != 2 {
+		t.Errorf("expected 1 line added and 2 removed, got %+v", diffs[0])
+	}
+}
+
+func TestDiffsFromPatches(t *testing.T) {
+	patch := strings.Join([]string{
+		"*** Begin Patch",
+		"*** Add File: docs/new.md",
+		"+# New",
+		"*** Update File: src/app.py",
+		"*** Move to: src/main.py",
+		"@@ def main():",
+		" print('a')",
+		"-print('b')",
+		"+print('c')",
+		"*** Delete File: old.txt",
+		"*** End Patch",
+	}, "\n")
+	messages := []adapters.Message{{Role: "assistant", ToolCalls: []adapters.ToolCall{{ID: "p1", Name: "shell", Input: map[string]interface{}{
+		"command": []interface{}{"apply_patch", patch},
+	}}}}}
+
+	diffs := Diffs(messages)
+	if len(diffs) != 3 {
+		t.Fatalf("expected a diff per file, got %+v", diffs)
+	}
+	if diffs[0].Operation != OpCreate || diffs[0].Diff != "--- /dev/null\n+++ docs/new.md\n@@ -0,0 +1,1 @@\n+# New\n" {
+		t.Errorf("unexpected added file:\n%s", diffs[0].Diff)
+	}
+	want := "--- src/app.py\n+++ src/main.py\n@@ -1,2 +1,2 @@\n print('a')\n-print('b')\n+print('c')\n"
+	if diffs[1].Path != "src/main.py" || diffs[1].Diff != want {
+		t.Errorf("unexpected updated file %q:\n%s", diffs[1].Path, diffs[1].Diff)
+	}
+	if diffs[2].Operation != OpDelete || diffs[2].Path != "old.txt" || diffs[2].Diff != "--- old.txt\n+++ /dev/null\n" {
+		t.Errorf("unexpected deleted file: %+v", diffs[2])
+	}
+}
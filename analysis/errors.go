@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// errorSignatures are substrings that mark a line as an error message.
+var errorSignatures = []string{
+	"error:",
+	"error[",
+	"panic:",
+	"fatal:",
+	"traceback (most recent call last)",
+	"exception:",
+	"--- fail",
+	"command not found",
+	"no such file or directory",
+	"permission denied",
+	"segmentation fault",
+}
+
+// maxErrorLineLength caps how much of an error line is kept.
+const maxErrorLineLength = 200
+
+// ExtractErrors returns the distinct error lines found in text, in order of appearance.
+func ExtractErrors(text string) []string {
+	var errors []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || !isErrorLine(trimmed) {
+			continue
+		}
+		trimmed = textutil.TruncateBytes(trimmed, maxErrorLineLength)
+		if !seen[trimmed] {
+			seen[trimmed] = true
+			errors = append(errors, trimmed)
+		}
+	}
+	return errors
+}
+
+// isErrorLine reports whether a line looks like an error message.
+func isErrorLine(line string) bool {
+	lower := strings.ToLower(line)
+	for _, sig := range errorSignatures {
+		if strings.Contains(lower, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// Kinds of errors found in a session
+const (
+	ErrorKindPanic     = "panic"     // A Go panic or fatal runtime error
+	ErrorKindTraceback = "traceback" // A Python exception, with its traceback
+	ErrorKindException = "exception" // An exception with a stack trace, as in JavaScript or Java
+	ErrorKindCompiler  = "compiler"  // A compiler or type checker diagnostic
+	ErrorKindError     = "error"     // Any other error line
+)
+
+// maxSessionErrors caps the number of distinct errors kept for a session
+const maxSessionErrors = 50
+
+var (
+	// compilerErrorPattern matches diagnostics such as "main.go:10:2: undefined: foo",
+	// "src/app.ts(3,7): error TS2322: ...", "error[E0308]: mismatched types", and
+	// "lib.c:4:5: error: ...". A column is required without the word error, so that grep
+	// output isn't mistaken for a diagnostic.
+	compilerErrorPattern = regexp.MustCompile(`^(?:\S+\.\w+:\d+:\d+: \S|\S+\.\w+(?::\d+|\(\d+,\d+\)):? ?(?:fatal )?error\b|error(?:\[E\d+\]| TS\d+):)`)
+
+	// exceptionPattern matches the first line of an exception, such as "TypeError: x is not
+	// a function" or "Exception in thread "main" java.lang.NullPointerException"
+	exceptionPattern = regexp.MustCompile(`^(?:Uncaught |Exception in thread "[^"]*" )?(?:[\w$]+\.)*[\w$]*(?:Error|Exception)\b`)
+
+	// pythonExceptionPattern matches the last line of a Python traceback, such as
+	// "ValueError: invalid literal" or "requests.exceptions.ConnectionError: ..."
+	pythonExceptionPattern = regexp.MustCompile(`^(?:[A-Za-z_]\w*\.)*[A-Za-z_]\w*(?::|$)`)
+)
+
+// SessionError is an error that showed up in the output of the tools run in a session
+type SessionError struct {
+	Kind      string    `json:"kind"`    // panic, traceback, exception, compiler, or error
+	Message   string    `json:"message"` // The line that identifies the error
+	Count     int       `json:"count"`   // How many times it showed up
+	Timestamp time.Time `json:"timestamp,omitempty"`
+}
+
+// Errors returns the distinct errors in the tool output of a session, in the order they
+// first showed up: panics, Python tracebacks, exceptions with stack traces, compiler
+// diagnostics, and other error lines.
+func Errors(messages []adapters.Message) []SessionError {
+	var errors []SessionError
+	index := make(map[string]int)
+	for _, msg := range messages {
+		var outputs []string
+		for _, result := range ToolResults(msg) {
+			outputs = append(outputs, result.Output)
+		}
+		if len(outputs) == 0 && IsToolOutput(msg) {
+			outputs = append(outputs, msg.Content)
+		}
+
+		for _, output := range outputs {
+			for _, found := range detectErrors(output) {
+				if i, ok := index[found.Message]; ok {
+					errors[i].Count++
+					continue
+				}
+				if len(errors) >= maxSessionErrors {
+					continue
+				}
+				found.Count, found.Timestamp = 1, msg.Timestamp
+				index[found.Message] = len(errors)
+				errors = append(errors, found)
+			}
+		}
+	}
+	return errors
+}
+
+// detectErrors returns the errors in a tool's output. The lines of a stack trace are
+// reported once, as the line naming its error.
+func detectErrors(text string) []SessionError {
+	lines := strings.Split(text, "\n")
+	var found []SessionError
+	add := func(kind, message string) {
+		found = append(found, SessionError{Kind: kind, Message: textutil.TruncateBytes(strings.TrimSpace(message), maxErrorLineLength)})
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t\r")
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "panic: ") || strings.HasPrefix(trimmed, "fatal error: "):
+			add(ErrorKindPanic, trimmed)
+			i = skipStackTrace(lines, i)
+		case strings.HasPrefix(trimmed, "Traceback (most recent call last)"):
+			// The error follows the frames, on the first line that isn't indented
+			j := i + 1
+			for j < len(lines) && (strings.HasPrefix(lines[j], " ") || strings.HasPrefix(lines[j], "\t")) {
+				j++
+			}
+			if j < len(lines) && pythonExceptionPattern.MatchString(lines[j]) {
+				add(ErrorKindTraceback, lines[j])
+				i = j
+			} else {
+				add(ErrorKindTraceback, trimmed)
+				i = j - 1
+			}
+		case compilerErrorPattern.MatchString(trimmed):
+			add(ErrorKindCompiler, trimmed)
+		case exceptionPattern.MatchString(trimmed) && i+1 < len(lines) && isStackFrame(lines[i+1]):
+			add(ErrorKindException, trimmed)
+			i = skipStackTrace(lines, i)
+		case isErrorLine(trimmed):
+			add(ErrorKindError, trimmed)
+		}
+	}
+	return found
+}
+
+// isStackFrame reports whether a line is a frame of a JavaScript or Java stack trace
+func isStackFrame(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "at ") && line != strings.TrimLeft(line, " \t")
+}
+
+// skipStackTrace returns the index of the last line of the stack trace or goroutine dump
+// following the error on line i: the lines up to the next blank line that aren't errors
+// themselves
+func skipStackTrace(lines []string, i int) int {
+	for i+1 < len(lines) {
+		next := strings.TrimSpace(lines[i+1])
+		if next == "" || compilerErrorPattern.MatchString(next) || strings.HasPrefix(next, "panic: ") {
+			break
+		}
+		i++
+	}
+	return i
+}
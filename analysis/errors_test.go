@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestErrors(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	goPanic := claudeToolResult("1", "panic: runtime error: index out of range [3] with length 3\n\ngoroutine 1 [running]:\nmain.main()\n\t/repo/main.go:5 +0x1d\nexit status 2", true)
+	goPanic.Timestamp = start
+	later := claudeToolResult("6", "./main.go:10:2: undefined: foo", true)
+	later.Timestamp = start.Add(time.Minute)
+	messages := []adapters.Message{
+		goPanic,
+		claudeToolResult("2", "Traceback (most recent call last):\n  File \"app.py\", line 3, in <module>\n    int(\"x\")\nValueError: invalid literal for int() with base 10: 'x'", true),
+		claudeToolResult("3", "/app/index.js:1\nTypeError: user.name is not a function\n    at Object.<anonymous> (/app/index.js:1:6)\n    at Module._compile (node:internal/modules/cjs/loader:1105:14)", true),
+		claudeToolResult("4", "# example.com/app\n./main.go:10:2: undefined: foo\nsrc/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.\nerror[E0308]: mismatched types", true),
+		claudeToolResult("5", "main.go:10:\tfoo()\ncat: missing.txt: No such file or directory", false),
+		later,
+		{Role: "user", Content: "why does it say panic: at the disco?"},
+	}
+
+	errors := Errors(messages)
+	want := []SessionError{
+		{Kind: ErrorKindPanic, Message: "panic: runtime error: index out of range [3] with length 3", Count: 1, Timestamp: start},
+		{Kind: ErrorKindTraceback, Message: "ValueError: invalid literal for int() with base 10: 'x'", Count: 1},
+		{Kind: ErrorKindException, Message: "TypeError: user.name is not a function", Count: 1},
+		{Kind: ErrorKindCompiler, Message: "./main.go:10:2: undefined: foo", Count: 2},
+		{Kind: ErrorKindCompiler, Message: "src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.", Count: 1},
+		{Kind: ErrorKindCompiler, Message: "error[E0308]: mismatched types", Count: 1},
+		{Kind: ErrorKindError, Message: "cat: missing.txt: No such file or directory", Count: 1},
+	}
+	if len(errors) != len(want) {
+		t.Fatalf("expected %d errors, got %+v", len(want), errors)
+	}
+	for i := range want {
+		if errors[i] != want[i] {
+			t.Errorf("error %d: expected %+v, got %+v", i, want[i], errors[i])
+		}
+	}
+}
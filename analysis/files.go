@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// File operation types reported by FileOperations.
+const (
+	OpRead   = "read"
+	OpEdit   = "edit"
+	OpWrite  = "write"
+	OpCreate = "create"
+	OpDelete = "delete"
+)
+
+// FileOperation is a single read or modification of a file by a tool call.
+type FileOperation struct {
+	Path      string `json:"path"`
+	Operation string `json:"operation"`
+	Tool      string `json:"tool"`
+}
+
+// FileActivity aggregates every operation performed on one file during a session.
+type FileActivity struct {
+	Path       string         `json:"path"`
+	Operations map[string]int `json:"operations"`
+	Total      int            `json:"total"`
+}
+
+// toolFileOperations maps lower-cased tool names to the operation they perform.
+// Names cover Claude Code, Gemini CLI, and opencode built-in tools.
+var toolFileOperations = map[string]string{
+	"read":            OpRead,
+	"read_file":       OpRead,
+	"read_many_files": OpRead,
+	"notebookread":    OpRead,
+	"view":            OpRead,
+	"edit":            OpEdit,
+	"multiedit":       OpEdit,
+	"notebookedit":    OpEdit,
+	"replace":         OpEdit,
+	"write":           OpWrite,
+	"write_file":      OpWrite,
+}
+
+// FileOperations extracts every file operation from a session's tool calls, in order.
+func FileOperations(messages []adapters.Message) []FileOperation {
+	var ops []FileOperation
+	for _, msg := range messages {
+		for _, call := range ToolCalls(msg) {
+			ops = append(ops, toolCallFileOperations(call)...)
+		}
+	}
+	return ops
+}
+
+// FileActivities aggregates file operations per path, sorted by path.
+func FileActivities(messages []adapters.Message) []FileActivity {
+	byPath := make(map[string]*FileActivity)
+	for _, op := range FileOperations(messages) {
+		activity, ok := byPath[op.Path]
+		if !ok {
+			activity = &FileActivity{Path: op.Path, Operations: make(map[string]int)}
+			byPath[op.Path] = activity
+		}
+		activity.Operations[op.Operation]++
+		activity.Total++
+	}
+
+	activities := make([]FileActivity, 0, len(byPath))
+	for _, activity := range byPath {
+		activities = append(activities, *activity)
+	}
+	sort.Slice(activities, func(i, j int) bool {
+		return activities[i].Path < activities[j].Path
+	})
+	return activities
+}
+
+// toolCallFileOperations returns the file operations performed by a single tool call.
+func toolCallFileOperations(call adapters.ToolCall) []FileOperation {
+	name := strings.ToLower(call.Name)
+
+	if name == "apply_patch" {
+		return patchFileOperations(stringInput(call.Input, "input", "patch"), call.Name)
+	}
+
+	// Codex may invoke apply_patch through its shell tool
+	if argv := commandArgs(call.Input); len(argv) >= 2 && argv[0] == "apply_patch" {
+		return patchFileOperations(argv[1], "apply_patch")
+	}
+
+	op, ok := toolFileOperations[name]
+	if !ok {
+		return nil
+	}
+
+	var ops []FileOperation
+	if path := stringInput(call.Input, "file_path", "notebook_path", "filePath", "absolute_path", "path"); path != "" {
+		ops = append(ops, FileOperation{Path: path, Operation: op, Tool: call.Name})
+	}
+	if paths, ok := call.Input["paths"].([]interface{}); ok {
+		for _, p := range paths {
+			if path, ok := p.(string); ok && path != "" {
+				ops = append(ops, FileOperation{Path: path, Operation: op, Tool: call.Name})
+			}
+		}
+	}
+	return ops
+}
+
+// patchFileOperations parses the file headers of an apply_patch style patch.
+func patchFileOperations(patch, tool string) []FileOperation {
+	headers := []struct {
+		prefix string
+		op     string
+	}{
+		{"*** Add File: ", OpCreate},
+		{"*** Update File: ", OpEdit},
+		{"*** Delete File: ", OpDelete},
+	}
+
+	var ops []FileOperation
+	for _, line := range strings.Split(patch, "\n") {
+		line = strings.TrimSpace(line)
+		for _, h := range headers {
+			if strings.HasPrefix(line, h.prefix) {
+				path := strings.TrimSpace(strings.TrimPrefix(line, h.prefix))
+				if path != "" {
+					ops = append(ops, FileOperation{Path: path, Operation: h.op, Tool: tool})
+				}
+			}
+		}
+	}
+	return ops
+}
+
+// commandArgs returns a tool call's command when it is given as an argument vector.
+func commandArgs(input map[string]interface{}) []string {
+	items, ok := input["command"].([]interface{})
+	if !ok {
+		return nil
+	}
+	args := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			args = append(args, s)
+		}
+	}
+	return args
+}
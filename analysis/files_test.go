@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestFileActivities(t *testing.T) {
+	patch := "*** Begin Patch\n*** Add File: new.go\n+package main\n*** Update File: main.go\n@@\n*** Delete File: old.go\n*** End Patch"
+	messages := []adapters.Message{
+		claudeToolUse("1", "Read", map[string]interface{}{"file_path": "/repo/main.go"}),
+		claudeToolUse("2", "Edit", map[string]interface{}{"file_path": "/repo/main.go"}),
+		claudeToolUse("3", "Edit", map[string]interface{}{"file_path": "/repo/main.go"}),
+		claudeToolUse("4", "Write", map[string]interface{}{"file_path": "/repo/README.md"}),
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{Name: "apply_patch", Input: map[string]interface{}{"input": patch}}}},
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{Name: "shell", Input: map[string]interface{}{
+			"command": []interface{}{"apply_patch", "*** Begin Patch\n*** Update File: lib.go\n*** End Patch"},
+		}}}},
+		{Role: "assistant", ToolCalls: []adapters.ToolCall{{Name: "read_many_files", Input: map[string]interface{}{
+			"paths": []interface{}{"a.txt", "b.txt"},
+		}}}},
+	}
+
+	activities := FileActivities(messages)
+	byPath := make(map[string]FileActivity)
+	for _, a := range activities {
+		byPath[a.Path] = a
+	}
+
+	main := byPath["/repo/main.go"]
+	if main.Operations[OpRead] != 1 || main.Operations[OpEdit] != 2 || main.Total != 3 {
+		t.Fatalf("unexpected activity for main.go: %+v", main)
+	}
+	if byPath["/repo/README.md"].Operations[OpWrite] != 1 {
+		t.Fatalf("expected README.md write, got %+v", byPath["/repo/README.md"])
+	}
+	if byPath["new.go"].Operations[OpCreate] != 1 || byPath["main.go"].Operations[OpEdit] != 1 || byPath["old.go"].Operations[OpDelete] != 1 {
+		t.Fatalf("apply_patch headers not parsed: %+v", activities)
+	}
+	if byPath["lib.go"].Operations[OpEdit] != 1 {
+		t.Fatalf("shell apply_patch not parsed: %+v", byPath["lib.go"])
+	}
+	if byPath["a.txt"].Operations[OpRead] != 1 || byPath["b.txt"].Operations[OpRead] != 1 {
+		t.Fatalf("read_many_files paths not parsed: %+v", activities)
+	}
+	if len(activities) != 8 {
+		t.Fatalf("expected 8 distinct files, got %d: %+v", len(activities), activities)
+	}
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import "strings"
+
+// ModelPrice is a model's list price in US dollars per million tokens.
+type ModelPrice struct {
+	Input      float64 `json:"input"`
+	Output     float64 `json:"output"`
+	CacheRead  float64 `json:"cache_read"`
+	CacheWrite float64 `json:"cache_write"`
+}
+
+// Cost returns the price of usage. Reasoning tokens are billed as output.
+func (p ModelPrice) Cost(u Usage) float64 {
+	return (float64(u.InputTokens)*p.Input +
+		float64(u.OutputTokens+u.ReasoningTokens)*p.Output +
+		float64(u.CacheReadTokens)*p.CacheRead +
+		float64(u.CacheWriteTokens)*p.CacheWrite) / 1_000_000
+}
+
+// modelPrices maps model name prefixes to list prices at the time of writing.
+// The longest matching prefix wins, so dated model versions match their family.
+var modelPrices = map[string]ModelPrice{
+	// Anthropic
+	"claude-opus-4-5":   {Input: 5, Output: 25, CacheRead: 0.5, CacheWrite: 6.25},
+	"claude-opus-4":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-3-opus":     {Input: 15, Output: 75, CacheRead: 1.5, CacheWrite: 18.75},
+	"claude-sonnet-4":   {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-7-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-3-5-sonnet": {Input: 3, Output: 15, CacheRead: 0.3, CacheWrite: 3.75},
+	"claude-haiku-4-5":  {Input: 1, Output: 5, CacheRead: 0.1, CacheWrite: 1.25},
+	"claude-3-5-haiku":  {Input: 0.8, Output: 4, CacheRead: 0.08, CacheWrite: 1},
+	"claude-3-haiku":    {Input: 0.25, Output: 1.25, CacheRead: 0.03, CacheWrite: 0.3},
+
+	// OpenAI
+	"gpt-5":        {Input: 1.25, Output: 10, CacheRead: 0.125},
+	"gpt-5-mini":   {Input: 0.25, Output: 2, CacheRead: 0.025},
+	"gpt-5-nano":   {Input: 0.05, Output: 0.4, CacheRead: 0.005},
+	"gpt-4.1":      {Input: 2, Output: 8, CacheRead: 0.5},
+	"gpt-4.1-mini": {Input: 0.4, Output: 1.6, CacheRead: 0.1},
+	"gpt-4.1-nano": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gpt-4o":       {Input: 2.5, Output: 10, CacheRead: 1.25},
+	"gpt-4o-mini":  {Input: 0.15, Output: 0.6, CacheRead: 0.075},
+	"o3":           {Input: 2, Output: 8, CacheRead: 0.5},
+	"o3-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.55},
+	"o3-pro":       {Input: 20, Output: 80},
+	"o4-mini":      {Input: 1.1, Output: 4.4, CacheRead: 0.275},
+	"codex-mini":   {Input: 1.5, Output: 6, CacheRead: 0.375},
+
+	// Google
+	"gemini-2.5-pro":        {Input: 1.25, Output: 10, CacheRead: 0.31},
+	"gemini-2.5-flash":      {Input: 0.3, Output: 2.5, CacheRead: 0.075},
+	"gemini-2.5-flash-lite": {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+	"gemini-2.0-flash":      {Input: 0.1, Output: 0.4, CacheRead: 0.025},
+}
+
+// PriceForModel returns the list price for a model name such as
+// "claude-sonnet-4-5-20250929" or "openai/gpt-5-codex".
+func PriceForModel(model string) (ModelPrice, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:] // Drop provider prefixes like "anthropic/" or "models/"
+	}
+	if model == "" {
+		return ModelPrice{}, false
+	}
+
+	best := ""
+	for prefix := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ModelPrice{}, false
+	}
+	return modelPrices[best], true
+}
@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// StatsBucket aggregates activity for one group of sessions (a source, project, day, or week).
+type StatsBucket struct {
+	Key               string    `json:"key"`
+	Sessions          int       `json:"sessions"`
+	Messages          int       `json:"messages"`
+	UserMessages      int       `json:"user_messages"`
+	AssistantMessages int       `json:"assistant_messages"`
+	ToolCalls         int       `json:"tool_calls"`
+	Usage             Usage     `json:"usage"`
+	LastActivity      time.Time `json:"last_activity,omitempty"`
+}
+
+// StatsReport is a usage report across many sessions.
+type StatsReport struct {
+	Totals    StatsBucket   `json:"totals"`
+	BySource  []StatsBucket `json:"by_source"`
+	ByProject []StatsBucket `json:"by_project"`
+	ByDay     []StatsBucket `json:"by_day"`
+	ByWeek    []StatsBucket `json:"by_week"`
+}
+
+// Stats accumulates sessions into a StatsReport.
+type Stats struct {
+	loc       *time.Location
+	totals    StatsBucket
+	bySource  map[string]*StatsBucket
+	byProject map[string]*StatsBucket
+	byDay     map[string]*StatsBucket
+	byWeek    map[string]*StatsBucket
+}
+
+// NewStats creates an empty accumulator. Days and weeks are computed in loc.
+func NewStats(loc *time.Location) *Stats {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &Stats{
+		loc:       loc,
+		bySource:  make(map[string]*StatsBucket),
+		byProject: make(map[string]*StatsBucket),
+		byDay:     make(map[string]*StatsBucket),
+		byWeek:    make(map[string]*StatsBucket),
+	}
+}
+
+// Add records a session and its messages. Messages may be nil when only
+// session-level counts are wanted.
+func (s *Stats) Add(session adapters.Session, messages []adapters.Message) {
+	entry := StatsBucket{Sessions: 1, LastActivity: session.Timestamp}
+	if messages == nil {
+		entry.UserMessages = session.UserMessageCount
+	}
+	for _, msg := range messages {
+		entry.Messages++
+		switch {
+		case IsHumanMessage(msg):
+			entry.UserMessages++
+		case msg.Role == "assistant":
+			entry.AssistantMessages++
+		}
+		entry.ToolCalls += len(ToolCalls(msg))
+		usage := MessageUsage(msg)
+		usage.Cost, _, _ = MessageCost(msg)
+		entry.Usage.Add(usage)
+		if msg.Timestamp.After(entry.LastActivity) {
+			entry.LastActivity = msg.Timestamp
+		}
+	}
+
+	s.totals.merge(entry)
+	bucket(s.bySource, session.Source).merge(entry)
+	bucket(s.byProject, session.GroupPath()).merge(entry)
+	if !session.Timestamp.IsZero() {
+		local := session.Timestamp.In(s.loc)
+		bucket(s.byDay, local.Format("2006-01-02")).merge(entry)
+		year, week := local.ISOWeek()
+		bucket(s.byWeek, fmt.Sprintf("%d-W%02d", year, week)).merge(entry)
+	}
+}
+
+// Report returns the accumulated statistics. Sources and projects are ordered by
+// session count (most active first); days and weeks chronologically.
+func (s *Stats) Report() StatsReport {
+	report := StatsReport{
+		Totals:    s.totals,
+		BySource:  sortedBuckets(s.bySource),
+		ByProject: sortedBuckets(s.byProject),
+		ByDay:     sortedBuckets(s.byDay),
+		ByWeek:    sortedBuckets(s.byWeek),
+	}
+	sort.SliceStable(report.BySource, func(i, j int) bool {
+		return report.BySource[i].Sessions > report.BySource[j].Sessions
+	})
+	sort.SliceStable(report.ByProject, func(i, j int) bool {
+		return report.ByProject[i].Sessions > report.ByProject[j].Sessions
+	})
+	return report
+}
+
+// merge adds other's counts into b.
+func (b *StatsBucket) merge(other StatsBucket) {
+	b.Sessions += other.Sessions
+	b.Messages += other.Messages
+	b.UserMessages += other.UserMessages
+	b.AssistantMessages += other.AssistantMessages
+	b.ToolCalls += other.ToolCalls
+	b.Usage.Add(other.Usage)
+	if other.LastActivity.After(b.LastActivity) {
+		b.LastActivity = other.LastActivity
+	}
+}
+
+// bucket returns the bucket for key, creating it if needed.
+func bucket(buckets map[string]*StatsBucket, key string) *StatsBucket {
+	b, ok := buckets[key]
+	if !ok {
+		b = &StatsBucket{Key: key}
+		buckets[key] = b
+	}
+	return b
+}
+
+// sortedBuckets returns the buckets ordered by key.
+func sortedBuckets(buckets map[string]*StatsBucket) []StatsBucket {
+	result := make([]StatsBucket, 0, len(buckets))
+	for _, b := range buckets {
+		result = append(result, *b)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
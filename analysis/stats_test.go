@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestMessageUsage(t *testing.T) {
+	opencode := adapters.Message{Metadata: map[string]interface{}{
+		"cost": 0.25,
+		"tokens": map[string]interface{}{
+			"input": 100.0, "output": 50.0, "reasoning": 10.0,
+			"cache": map[string]interface{}{"read": 400.0, "write": 20.0},
+		},
+	}}
+	usage := MessageUsage(opencode)
+	if usage.InputTokens != 100 || usage.OutputTokens != 50 || usage.CacheReadTokens != 400 || usage.CacheWriteTokens != 20 || usage.Cost != 0.25 {
+		t.Fatalf("unexpected opencode usage: %+v", usage)
+	}
+	if usage.Total() != 580 {
+		t.Fatalf("Total()=%d want 580", usage.Total())
+	}
+
+	codex := adapters.Message{Metadata: map[string]interface{}{
+		"token_usage": map[string]interface{}{
+			"input_tokens": 1000.0, "cached_input_tokens": 800.0, "output_tokens": 30.0, "reasoning_output_tokens": 5.0,
+		},
+	}}
+	usage = MessageUsage(codex)
+	if usage.InputTokens != 200 || usage.CacheReadTokens != 800 || usage.OutputTokens != 25 || usage.ReasoningTokens != 5 {
+		t.Fatalf("unexpected codex usage: %+v", usage)
+	}
+}
+
+func TestStatsReport(t *testing.T) {
+	day1 := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 8)
+	withCost := adapters.Message{Role: "assistant", Content: "done", Metadata: map[string]interface{}{"cost": 0.5}}
+
+	stats := NewStats(time.UTC)
+	stats.Add(adapters.Session{Source: "opencode", ProjectPath: "/a", Timestamp: day1},
+		[]adapters.Message{{Role: "user", Content: "hi"}, withCost})
+	stats.Add(adapters.Session{Source: "claude", ProjectPath: "/a", Timestamp: day1.Add(time.Hour)},
+		[]adapters.Message{{Role: "user", Content: "hello"}, claudeToolUse("1", "Bash", nil)})
+	stats.Add(adapters.Session{Source: "claude", ProjectPath: "/b", Timestamp: day2, UserMessageCount: 3}, nil)
+
+	report := stats.Report()
+	if report.Totals.Sessions != 3 || report.Totals.Messages != 4 || report.Totals.UserMessages != 5 || report.Totals.ToolCalls != 1 {
+		t.Fatalf("unexpected totals: %+v", report.Totals)
+	}
+	if report.Totals.Usage.Cost != 0.5 {
+		t.Fatalf("cost not aggregated: %+v", report.Totals.Usage)
+	}
+	if report.BySource[0].Key != "claude" || report.BySource[0].Sessions != 2 {
+		t.Fatalf("sources should be ordered by activity: %+v", report.BySource)
+	}
+	if report.ByProject[0].Key != "/a" || report.ByProject[0].Sessions != 2 {
+		t.Fatalf("unexpected project buckets: %+v", report.ByProject)
+	}
+	if len(report.ByDay) != 2 || report.ByDay[0].Key != "2025-01-06" || report.ByDay[0].Sessions != 2 {
+		t.Fatalf("unexpected day buckets: %+v", report.ByDay)
+	}
+	if len(report.ByWeek) != 2 || report.ByWeek[0].Key != "2025-W02" || report.ByWeek[1].Key != "2025-W03" {
+		t.Fatalf("unexpected week buckets: %+v", report.ByWeek)
+	}
+}
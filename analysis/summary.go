@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+const (
+	// maxSummaryMessageLength caps the first/last user messages included in a summary
+	maxSummaryMessageLength = 500
+
+	// maxSummaryCommands caps the number of commands listed in a summary
+	maxSummaryCommands = 50
+
+	// maxSummaryErrors caps the number of error lines listed in a summary
+	maxSummaryErrors = 20
+)
+
+// Summary is a condensed, LLM-free overview of a session.
+type Summary struct {
+	TotalMessages     int            `json:"total_messages"`
+	UserMessages      int            `json:"user_messages"`
+	AssistantMessages int            `json:"assistant_messages"`
+	ToolMessages      int            `json:"tool_messages"`
+	Turns             int            `json:"turns"`
+	ToolCalls         int            `json:"tool_calls"`
+	FirstUserMessage  string         `json:"first_user_message,omitempty"`
+	LastUserMessage   string         `json:"last_user_message,omitempty"`
+	FilesTouched      []FileActivity `json:"files_touched"`
+	Commands          []string       `json:"commands"`
+	Errors            []string       `json:"errors"`
+	StartTime         time.Time      `json:"start_time,omitempty"`
+	EndTime           time.Time      `json:"end_time,omitempty"`
+}
+
+// IsToolOutput reports whether a message carries tool output rather than conversation text.
+// Some agents record tool results under the user role, flagged with is_tool_result metadata.
+func IsToolOutput(msg adapters.Message) bool {
+	if msg.Role == "tool" {
+		return true
+	}
+	isResult, _ := msg.Metadata["is_tool_result"].(bool)
+	return isResult
+}
+
+// IsHumanMessage reports whether a message was typed by the user. The prompts of Claude
+// subagents, recorded as user messages of a sidechain, were written by the agent.
+func IsHumanMessage(msg adapters.Message) bool {
+	if sidechain, _ := msg.Metadata["is_sidechain"].(bool); sidechain {
+		return false
+	}
+	return msg.Role == "user" && !IsToolOutput(msg) && strings.TrimSpace(msg.Content) != ""
+}
+
+// Summarize builds a Summary from a session's messages.
+func Summarize(messages []adapters.Message) Summary {
+	summary := Summary{
+		TotalMessages: len(messages),
+		Commands:      []string{},
+		Errors:        []string{},
+	}
+
+	seenErrors := make(map[string]bool)
+	addErrors := func(text string) {
+		for _, line := range ExtractErrors(text) {
+			if !seenErrors[line] && len(summary.Errors) < maxSummaryErrors {
+				seenErrors[line] = true
+				summary.Errors = append(summary.Errors, line)
+			}
+		}
+	}
+
+	awaitingReply := false
+	for _, msg := range messages {
+		if !msg.Timestamp.IsZero() {
+			if summary.StartTime.IsZero() || msg.Timestamp.Before(summary.StartTime) {
+				summary.StartTime = msg.Timestamp
+			}
+			if msg.Timestamp.After(summary.EndTime) {
+				summary.EndTime = msg.Timestamp
+			}
+		}
+
+		switch {
+		case IsHumanMessage(msg):
+			summary.UserMessages++
+			text := textutil.TruncateBytes(strings.TrimSpace(msg.Content), maxSummaryMessageLength)
+			if summary.FirstUserMessage == "" {
+				summary.FirstUserMessage = text
+			}
+			summary.LastUserMessage = text
+			awaitingReply = true
+		case msg.Role == "assistant":
+			summary.AssistantMessages++
+			if awaitingReply {
+				summary.Turns++
+				awaitingReply = false
+			}
+		case IsToolOutput(msg):
+			summary.ToolMessages++
+			addErrors(msg.Content)
+		}
+
+		summary.ToolCalls += len(ToolCalls(msg))
+
+		for _, result := range ToolResults(msg) {
+			addErrors(result.Output)
+			if result.IsError && len(ExtractErrors(result.Output)) == 0 {
+				// Failed tool calls without a recognizable error line still deserve a mention
+				addErrors("error: " + firstLine(result.Output))
+			}
+		}
+	}
+
+	for _, run := range Commands(messages) {
+		if len(summary.Commands) >= maxSummaryCommands {
+			break
+		}
+		summary.Commands = append(summary.Commands, run.Command)
+	}
+
+	summary.FilesTouched = FileActivities(messages)
+
+	return summary
+}
+
+// firstLine returns the first non-empty line of text.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return textutil.TruncateBytes(trimmed, maxErrorLineLength)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func claudeToolUse(id, name string, input map[string]interface{}) adapters.Message {
+	return adapters.Message{
+		Role: "assistant",
+		Metadata: map[string]interface{}{
+			"raw_content": []interface{}{
+				map[string]interface{}{"type": "tool_use", "id": id, "name": name, "input": input},
+			},
+		},
+	}
+}
+
+func claudeToolResult(id, output string, isError bool) adapters.Message {
+	return adapters.Message{
+		Role: "user",
+		Metadata: map[string]interface{}{
+			"is_tool_result": true,
+			"raw_content": []interface{}{
+				map[string]interface{}{"type": "tool_result", "tool_use_id": id, "content": output, "is_error": isError},
+			},
+		},
+	}
+}
+
+// claudeSidechain marks a message as part of a Claude subagent's transcript, as the
+// Claude adapter does
+func claudeSidechain(msg adapters.Message) adapters.Message {
+	msg.Metadata = map[string]interface{}{"is_sidechain": true}
+	return msg
+}
+
+func TestSidechainPromptsAreNotHuman(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	messages := []adapters.Message{
+		{Role: "user", Content: "Review the parser", Timestamp: start},
+		claudeSidechain(adapters.Message{Role: "user", Content: "Read parser.go and list its bugs", Timestamp: start.Add(time.Second)}),
+		claudeSidechain(adapters.Message{Role: "assistant", Content: "Found two bugs", Timestamp: start.Add(2 * time.Second)}),
+		{Role: "assistant", Content: "The parser has two bugs", Timestamp: start.Add(3 * time.Second)},
+	}
+	if IsHumanMessage(messages[1]) {
+		t.Fatal("expected a subagent prompt not to be a human message")
+	}
+
+	summary := Summarize(messages)
+	if summary.UserMessages != 1 || summary.LastUserMessage != "Review the parser" {
+		t.Fatalf("expected only the user's prompt to count, got %d user messages, last %q", summary.UserMessages, summary.LastUserMessage)
+	}
+	if title := SessionTitle(adapters.Session{}, messages); title != "Review the parser" {
+		t.Fatalf("unexpected title %q", title)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	messages := []adapters.Message{
+		{Role: "user", Content: "Fix the failing build", Timestamp: start},
+		claudeToolUse("t1", "Read", map[string]interface{}{"file_path": "/repo/main.go"}),
+		claudeToolResult("t1", "package main", false),
+		claudeToolUse("t2", "Bash", map[string]interface{}{"command": "go build ./..."}),
+		claudeToolResult("t2", "./main.go:10:2: error: undefined: foo", true),
+		{Role: "assistant", Content: "Fixed it."},
+		{Role: "user", Content: "Thanks, now run the tests", Timestamp: start.Add(time.Hour)},
+		{Role: "assistant", Content: "All green."},
+	}
+
+	summary := Summarize(messages)
+
+	if summary.TotalMessages != 8 || summary.UserMessages != 2 || summary.ToolMessages != 2 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	if summary.Turns != 2 {
+		t.Fatalf("Turns=%d want 2", summary.Turns)
+	}
+	if summary.ToolCalls != 2 {
+		t.Fatalf("ToolCalls=%d want 2", summary.ToolCalls)
+	}
+	if summary.FirstUserMessage != "Fix the failing build" || summary.LastUserMessage != "Thanks, now run the tests" {
+		t.Fatalf("unexpected first/last messages: %q / %q", summary.FirstUserMessage, summary.LastUserMessage)
+	}
+	if len(summary.FilesTouched) != 1 || summary.FilesTouched[0].Path != "/repo/main.go" {
+		t.Fatalf("unexpected files: %v", summary.FilesTouched)
+	}
+	if len(summary.Commands) != 1 || summary.Commands[0] != "go build ./..." {
+		t.Fatalf("unexpected commands: %v", summary.Commands)
+	}
+	if len(summary.Errors) != 1 || summary.Errors[0] != "./main.go:10:2: error: undefined: foo" {
+		t.Fatalf("unexpected errors: %v", summary.Errors)
+	}
+	if !summary.StartTime.Equal(start) || !summary.EndTime.Equal(start.Add(time.Hour)) {
+		t.Fatalf("unexpected time range: %v - %v", summary.StartTime, summary.EndTime)
+	}
+}
+
+func TestExtractErrors(t *testing.T) {
+	text := "ok line\npanic: runtime error\nTraceback (most recent call last):\npanic: runtime error\n"
+	errors := ExtractErrors(text)
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 distinct errors, got %v", errors)
+	}
+}
@@ -0,0 +1,142 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// maxTimelineTextLength caps the text of each timeline event
+const maxTimelineTextLength = 200
+
+// Timeline event kinds.
+const (
+	EventPrompt  = "prompt"
+	EventCommand = "command"
+	EventFile    = "file"
+)
+
+// TimelineEvent is a notable step in a session: a user prompt, a shell command, or a file change.
+type TimelineEvent struct {
+	Timestamp time.Time `json:"timestamp,omitempty"`
+	Elapsed   float64   `json:"elapsed_seconds"` // Seconds since the session's first timestamped message
+	Kind      string    `json:"kind"`
+	Text      string    `json:"text"`
+}
+
+// Timeline extracts the notable steps of a session in order. File reads are omitted
+// since they rarely mark progress. maxEvents limits the result (0 = no limit).
+func Timeline(messages []adapters.Message, maxEvents int) []TimelineEvent {
+	var start time.Time
+	for _, msg := range messages {
+		if !msg.Timestamp.IsZero() {
+			start = msg.Timestamp
+			break
+		}
+	}
+
+	var events []TimelineEvent
+	add := func(msg adapters.Message, kind, text string) {
+		event := TimelineEvent{Timestamp: msg.Timestamp, Kind: kind, Text: textutil.TruncateBytes(text, maxTimelineTextLength)}
+		if !msg.Timestamp.IsZero() {
+			event.Elapsed = msg.Timestamp.Sub(start).Seconds()
+		} else if len(events) > 0 {
+			// Keep untimed events in place relative to their neighbours
+			event.Elapsed = events[len(events)-1].Elapsed
+		}
+		events = append(events, event)
+	}
+
+	for _, msg := range messages {
+		if IsHumanMessage(msg) {
+			add(msg, EventPrompt, firstLine(msg.Content))
+		}
+		for _, call := range ToolCalls(msg) {
+			if cmd := toolCallCommand(call); cmd != "" {
+				add(msg, EventCommand, firstLine(cmd))
+			}
+			for _, op := range toolCallFileOperations(call) {
+				if op.Operation != OpRead {
+					add(msg, EventFile, fmt.Sprintf("%s %s", op.Operation, op.Path))
+				}
+			}
+		}
+		if maxEvents > 0 && len(events) >= maxEvents {
+			return events[:maxEvents]
+		}
+	}
+	return events
+}
+
+// SideBySideEvent is a timeline event labelled with the session it came from.
+type SideBySideEvent struct {
+	Session string `json:"session"`
+	TimelineEvent
+}
+
+// SideBySide interleaves two timelines by elapsed time so parallel attempts at a task
+// can be read together. Events are labelled "a" and "b".
+func SideBySide(a, b []TimelineEvent) []SideBySideEvent {
+	merged := make([]SideBySideEvent, 0, len(a)+len(b))
+	for _, e := range a {
+		merged = append(merged, SideBySideEvent{Session: "a", TimelineEvent: e})
+	}
+	for _, e := range b {
+		merged = append(merged, SideBySideEvent{Session: "b", TimelineEvent: e})
+	}
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Elapsed < merged[j].Elapsed
+	})
+	return merged
+}
+
+// FileOverlap compares the files touched by two sessions.
+type FileOverlap struct {
+	Shared []string `json:"shared"`
+	OnlyA  []string `json:"only_a"`
+	OnlyB  []string `json:"only_b"`
+}
+
+// CompareFiles reports which files two sessions have in common. Paths match when they
+// are equal or when one is a path suffix of the other, so a relative path recorded by
+// one agent matches the absolute path recorded by another.
+func CompareFiles(a, b []FileActivity) FileOverlap {
+	overlap := FileOverlap{Shared: []string{}, OnlyA: []string{}, OnlyB: []string{}}
+	matchedB := make(map[int]bool)
+
+	for _, fa := range a {
+		found := false
+		for j, fb := range b {
+			if samePath(fa.Path, fb.Path) {
+				found = true
+				matchedB[j] = true
+			}
+		}
+		if found {
+			overlap.Shared = append(overlap.Shared, fa.Path)
+		} else {
+			overlap.OnlyA = append(overlap.OnlyA, fa.Path)
+		}
+	}
+	for j, fb := range b {
+		if !matchedB[j] {
+			overlap.OnlyB = append(overlap.OnlyB, fb.Path)
+		}
+	}
+	return overlap
+}
+
+// samePath reports whether two recorded paths likely refer to the same file.
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return strings.HasSuffix(a, "/"+strings.TrimPrefix(b, "./"))
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	at := func(msg adapters.Message, offset time.Duration) adapters.Message {
+		msg.Timestamp = start.Add(offset)
+		return msg
+	}
+
+	messages := []adapters.Message{
+		at(adapters.Message{Role: "user", Content: "fix the build\nmore details"}, 0),
+		at(claudeToolUse("1", "Read", map[string]interface{}{"file_path": "main.go"}), time.Second),
+		at(claudeToolUse("2", "Bash", map[string]interface{}{"command": "go build ./..."}), 2*time.Second),
+		at(claudeToolUse("3", "Edit", map[string]interface{}{"file_path": "main.go"}), 5*time.Second),
+	}
+
+	events := Timeline(messages, 0)
+	if len(events) != 3 {
+		t.Fatalf("expected prompt, command and edit events, got %+v", events)
+	}
+	if events[0].Kind != EventPrompt || events[0].Text != "fix the build" {
+		t.Fatalf("unexpected prompt event: %+v", events[0])
+	}
+	if events[1].Kind != EventCommand || events[1].Elapsed != 2 {
+		t.Fatalf("unexpected command event: %+v", events[1])
+	}
+	if events[2].Kind != EventFile || events[2].Text != "edit main.go" {
+		t.Fatalf("unexpected file event: %+v", events[2])
+	}
+
+	if limited := Timeline(messages, 2); len(limited) != 2 {
+		t.Fatalf("expected max events to apply, got %d", len(limited))
+	}
+
+	merged := SideBySide(events, []TimelineEvent{{Elapsed: 1, Kind: EventPrompt, Text: "other"}})
+	if len(merged) != 4 || merged[1].Session != "b" {
+		t.Fatalf("timelines not interleaved by elapsed time: %+v", merged)
+	}
+}
+
+func TestCompareFiles(t *testing.T) {
+	a := []FileActivity{{Path: "/repo/cmd/main.go"}, {Path: "/repo/README.md"}}
+	b := []FileActivity{{Path: "cmd/main.go"}, {Path: "go.mod"}}
+
+	overlap := CompareFiles(a, b)
+	if len(overlap.Shared) != 1 || overlap.Shared[0] != "/repo/cmd/main.go" {
+		t.Fatalf("relative and absolute paths should match: %+v", overlap)
+	}
+	if len(overlap.OnlyA) != 1 || overlap.OnlyA[0] != "/repo/README.md" {
+		t.Fatalf("unexpected only_a: %+v", overlap.OnlyA)
+	}
+	if len(overlap.OnlyB) != 1 || overlap.OnlyB[0] != "go.mod" {
+		t.Fatalf("unexpected only_b: %+v", overlap.OnlyB)
+	}
+	if samePath("/repo/xmain.go", "main.go") {
+		t.Fatal("suffix matches must fall on a path boundary")
+	}
+}
@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+const (
+	// maxTitleLength caps the length of a session title, in characters
+	maxTitleLength = 80
+
+	// minTitleWords is the number of words below which a request is too vague to
+	// name a session on its own ("fix it", "continue"), and the files it touched are
+	// added to the title
+	minTitleWords = 3
+
+	// maxTitleFiles caps the number of files named in a title
+	maxTitleFiles = 2
+)
+
+// politePrefixes open requests without saying anything about them
+var politePrefixes = []string{"please ", "can you ", "could you ", "would you ", "hey, ", "hi, ", "ok, ", "okay, "}
+
+// SessionTitle returns a short human-readable title for a session: the title or summary
+// the agent recorded, otherwise the user's first request. A request too vague to stand
+// on its own is followed by the files the session modified, and a session without one
+// is named after them, or after the command it ran most. It is empty when the session
+// has none of these.
+func SessionTitle(session adapters.Session, messages []adapters.Message) string {
+	for _, recorded := range []string{session.Title, session.Summary} {
+		if line := firstLine(recorded); line != "" {
+			return textutil.Truncate(line, maxTitleLength)
+		}
+	}
+
+	request := ""
+	if session.FirstMessage != adapters.EmptySessionMessage {
+		request = session.FirstMessage
+	}
+	if request == "" {
+		for _, msg := range messages {
+			if IsHumanMessage(msg) {
+				if request = adapters.FirstMessageLine(msg.Content); request != "" {
+					break
+				}
+			}
+		}
+	}
+	request = cleanRequest(request)
+
+	files := modifiedFileNames(messages)
+	switch {
+	case request != "" && (len(strings.Fields(request)) >= minTitleWords || len(files) == 0):
+		return textutil.Truncate(request, maxTitleLength)
+	case request != "":
+		return textutil.Truncate(request+" ("+strings.Join(files, ", ")+")", maxTitleLength)
+	case len(files) > 0:
+		return textutil.Truncate("Edit "+strings.Join(files, ", "), maxTitleLength)
+	}
+	if command := mostRunCommand(messages); command != "" {
+		return textutil.Truncate("Run "+command, maxTitleLength)
+	}
+	return ""
+}
+
+// cleanRequest strips the politeness and trailing punctuation off a request, and
+// capitalizes it
+func cleanRequest(request string) string {
+	request = strings.Join(strings.Fields(request), " ")
+	for {
+		lower := strings.ToLower(request)
+		stripped := false
+		for _, prefix := range politePrefixes {
+			if strings.HasPrefix(lower, prefix) && len(request) > len(prefix) {
+				request = request[len(prefix):]
+				stripped = true
+				break
+			}
+		}
+		if !stripped {
+			break
+		}
+	}
+	request = strings.TrimRight(request, ".!,;: ")
+	if r, size := utf8.DecodeRuneInString(request); unicode.IsLower(r) {
+		request = string(unicode.ToUpper(r)) + request[size:]
+	}
+	return request
+}
+
+// modifiedFileNames returns the base names of the files a session modified most,
+// most modified first
+func modifiedFileNames(messages []adapters.Message) []string {
+	type modified struct {
+		name  string
+		count int
+	}
+	var files []modified
+	seen := make(map[string]bool)
+	for _, activity := range FileActivities(messages) {
+		count := activity.Total - activity.Operations[OpRead]
+		name := filepath.Base(activity.Path)
+		if count == 0 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		files = append(files, modified{name, count})
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].count > files[j].count })
+
+	names := make([]string, 0, maxTitleFiles)
+	for i := 0; i < len(files) && i < maxTitleFiles; i++ {
+		names = append(names, files[i].name)
+	}
+	return names
+}
+
+// mostRunCommand returns the command a session ran most, reduced to its program and
+// subcommand ("go test", "npm run"), the earliest among equals
+func mostRunCommand(messages []adapters.Message) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, run := range Commands(messages) {
+		fields := strings.Fields(run.Command)
+		if len(fields) == 0 {
+			continue
+		}
+		command := fields[0]
+		if len(fields) > 1 && !strings.HasPrefix(fields[1], "-") {
+			command += " " + fields[1]
+		}
+		counts[command]++
+		if counts[command] > counts[best] {
+			best = command
+		}
+	}
+	return best
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSessionTitle(t *testing.T) {
+	edit := func(id, path string) adapters.Message {
+		return claudeToolUse(id, "Edit", map[string]interface{}{"file_path": path})
+	}
+	run := func(id, command string) adapters.Message {
+		return claudeToolUse(id, "Bash", map[string]interface{}{"command": command})
+	}
+
+	tests := []struct {
+		name     string
+		session  adapters.Session
+		messages []adapters.Message
+		want     string
+	}{
+		{
+			name:    "recorded title",
+			session: adapters.Session{Title: "Refactor the parser", Summary: "Parser work", FirstMessage: "hi"},
+			want:    "Refactor the parser",
+		},
+		{
+			name:    "summary",
+			session: adapters.Session{Summary: "Fix login redirect\nmore detail", FirstMessage: "hi"},
+			want:    "Fix login redirect",
+		},
+		{
+			name:    "polite request",
+			session: adapters.Session{FirstMessage: "Hey, can you please add retries to the HTTP client?"},
+			want:    "Add retries to the HTTP client?",
+		},
+		{
+			name:     "vague request with files",
+			session:  adapters.Session{FirstMessage: "fix it"},
+			messages: []adapters.Message{edit("t1", "/repo/auth.go"), edit("t2", "/repo/login.go"), edit("t3", "/repo/login.go"), edit("t4", "/repo/util.go")},
+			want:     "Fix it (login.go, auth.go)",
+		},
+		{
+			name:     "first human message",
+			session:  adapters.Session{FirstMessage: adapters.EmptySessionMessage},
+			messages: []adapters.Message{{Role: "user", Content: "<command-name>/clear</command-name>"}, {Role: "user", Content: "please update the changelog"}},
+			want:     "Update the changelog",
+		},
+		{
+			name:     "files only",
+			messages: []adapters.Message{edit("t1", "/repo/main.go")},
+			want:     "Edit main.go",
+		},
+		{
+			name:     "commands only",
+			messages: []adapters.Message{run("t1", "go test ./..."), run("t2", "git status"), run("t3", "go test -run TestX ./...")},
+			want:     "Run go test",
+		},
+		{
+			name: "nothing",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SessionTitle(tt.session, tt.messages); got != tt.want {
+				t.Fatalf("SessionTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionTitleIsShortened(t *testing.T) {
+	title := SessionTitle(adapters.Session{FirstMessage: strings.Repeat("refactor ", 30)}, nil)
+	if len([]rune(title)) > maxTitleLength || !strings.HasSuffix(title, "...") {
+		t.Fatalf("expected a shortened title, got %q", title)
+	}
+}
@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"encoding/json"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// messageOverheadTokens approximates the per-message framing tokens chat models add
+// (role markers and separators).
+const messageOverheadTokens = 4
+
+// pretokenizer splits text the way tiktoken's cl100k_base/o200k_base pre-tokenizers do:
+// contractions, words with an optional leading symbol, numbers of up to three digits,
+// punctuation runs, and whitespace. RE2 has no lookahead, so trailing whitespace is
+// grouped slightly differently, which only matters at the margin.
+var pretokenizer = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// EstimateTokens approximates the number of tokens a tiktoken-style BPE tokenizer
+// produces for text. It is typically within 10-15% of the real count for English
+// prose and code, which is enough to plan pagination and context budgets.
+func EstimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	tokens := 0
+	for _, piece := range pretokenizer.FindAllString(text, -1) {
+		tokens += pieceTokens(piece)
+	}
+	return tokens
+}
+
+// pieceTokens estimates the tokens in one pre-tokenized piece. Common words merge into a
+// single token, long words split roughly every eight characters, and CJK characters are
+// mostly one token each.
+func pieceTokens(piece string) int {
+	first, _ := utf8.DecodeRuneInString(piece)
+	if unicode.IsNumber(first) || isSpace(piece) {
+		return 1
+	}
+
+	letters, wide, other := 0, 0, 0
+	for _, r := range piece {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			wide++
+		case unicode.IsLetter(r):
+			letters++
+		case !unicode.IsSpace(r):
+			other++
+		}
+	}
+
+	tokens := wide
+	if letters > 0 {
+		tokens += 1 + (letters-1)/8
+	} else if other > 0 {
+		tokens += 1 + (other-1)/3
+	}
+	if tokens == 0 {
+		tokens = 1
+	}
+	return tokens
+}
+
+// isSpace reports whether s consists only of whitespace.
+func isSpace(s string) bool {
+	for _, r := range s {
+		if !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// MessageTokens estimates the tokens a message takes up in a model's context: its text,
+// thinking, tool calls with their arguments, and tool outputs.
+func MessageTokens(msg adapters.Message) int {
+	tokens := messageOverheadTokens + EstimateTokens(msg.Content) + EstimateTokens(msg.Thinking)
+	for _, call := range ToolCalls(msg) {
+		tokens += EstimateTokens(call.Name)
+		if len(call.Input) > 0 {
+			if input, err := json.Marshal(call.Input); err == nil {
+				tokens += EstimateTokens(string(input))
+			}
+		}
+	}
+	for _, result := range ToolResults(msg) {
+		// Some agents mirror the tool output in the message content
+		if result.Output != msg.Content {
+			tokens += EstimateTokens(result.Output)
+		}
+	}
+	return tokens
+}
+
+// SessionTokens estimates the total tokens of a session's messages.
+func SessionTokens(messages []adapters.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += MessageTokens(msg)
+	}
+	return total
+}
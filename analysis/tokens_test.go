@@ -0,0 +1,59 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	cases := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"Hello, world!", 4},
+		{"The quick brown fox jumps over the lazy dog.", 10},
+		{"12345678", 3},
+		{"你好世界", 4},
+	}
+	for _, tc := range cases {
+		if got := EstimateTokens(tc.text); got != tc.want {
+			t.Errorf("EstimateTokens(%q) = %d, want %d", tc.text, got, tc.want)
+		}
+	}
+
+	// Long inputs should land between three and eight characters per token
+	prose := strings.Repeat("Refactor the session loader so adapters can stream messages. ", 50)
+	if got := EstimateTokens(prose); got < len(prose)/8 || got > len(prose)/3 {
+		t.Errorf("estimate %d is implausible for %d characters", got, len(prose))
+	}
+}
+
+func TestMessageTokens(t *testing.T) {
+	plain := adapters.Message{Role: "user", Content: "Hello, world!"}
+	if got := MessageTokens(plain); got != messageOverheadTokens+4 {
+		t.Fatalf("unexpected plain message tokens: %d", got)
+	}
+
+	withTools := adapters.Message{
+		Role:      "assistant",
+		Content:   "Hello, world!",
+		Thinking:  "Hello, world!",
+		ToolCalls: []adapters.ToolCall{{Name: "Bash", Input: map[string]interface{}{"command": "ls"}}},
+	}
+	if got := MessageTokens(withTools); got <= MessageTokens(plain)+4 {
+		t.Fatalf("thinking and tool calls should add tokens, got %d", got)
+	}
+
+	// Tool outputs mirrored in the content are not counted twice
+	mirrored := adapters.Message{Role: "tool", Content: "ok", ToolResults: []adapters.ToolResult{{Output: "ok"}}}
+	if got := MessageTokens(mirrored); got != messageOverheadTokens+1 {
+		t.Fatalf("mirrored tool output counted twice: %d", got)
+	}
+
+	if got := SessionTokens([]adapters.Message{plain, mirrored}); got != MessageTokens(plain)+MessageTokens(mirrored) {
+		t.Fatalf("unexpected session tokens: %d", got)
+	}
+}
@@ -0,0 +1,92 @@
+// Package analysis derives structured information from session transcripts, such as
+// the tools an assistant invoked, the files it touched, and the errors it ran into.
+package analysis
+
+import (
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// ToolCalls returns the tool invocations recorded in a message. Messages without typed
+// tool calls fall back to tool_use blocks preserved in their raw content.
+func ToolCalls(msg adapters.Message) []adapters.ToolCall {
+	if len(msg.ToolCalls) > 0 {
+		return msg.ToolCalls
+	}
+	var calls []adapters.ToolCall
+	for _, block := range rawBlocks(msg) {
+		if block["type"] != "tool_use" {
+			continue
+		}
+		call := adapters.ToolCall{}
+		call.ID, _ = block["id"].(string)
+		call.Name, _ = block["name"].(string)
+		call.Input, _ = block["input"].(map[string]interface{})
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// ToolResults returns the tool outputs recorded in a message. Messages without typed
+// tool results fall back to tool_result blocks preserved in their raw content.
+func ToolResults(msg adapters.Message) []adapters.ToolResult {
+	if len(msg.ToolResults) > 0 {
+		return msg.ToolResults
+	}
+	var results []adapters.ToolResult
+	for _, block := range rawBlocks(msg) {
+		if block["type"] != "tool_result" {
+			continue
+		}
+		result := adapters.ToolResult{Output: blockText(block["content"])}
+		result.ToolCallID, _ = block["tool_use_id"].(string)
+		result.IsError, _ = block["is_error"].(bool)
+		results = append(results, result)
+	}
+	return results
+}
+
+// rawBlocks returns the structured content blocks preserved in a message's metadata.
+func rawBlocks(msg adapters.Message) []map[string]interface{} {
+	items, ok := msg.Metadata["raw_content"].([]interface{})
+	if !ok {
+		return nil
+	}
+	blocks := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			blocks = append(blocks, m)
+		}
+	}
+	return blocks
+}
+
+// blockText flattens tool result content, which may be a string or a list of text blocks.
+func blockText(content interface{}) string {
+	switch v := content.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var parts []string
+		for _, item := range v {
+			if m, ok := item.(map[string]interface{}); ok {
+				if text, ok := m["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// stringInput returns the first non-empty string value among the given input keys.
+func stringInput(input map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		if s, ok := input[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// Branch is one path through a branched conversation, from its root to a leaf message.
+type Branch struct {
+	LeafUUID        string    `json:"leaf_uuid"`
+	Messages        int       `json:"messages"`
+	ForkUUID        string    `json:"fork_uuid,omitempty"` // Last message this branch shares with a sibling branch
+	LastUserMessage string    `json:"last_user_message,omitempty"`
+	LastActivity    time.Time `json:"last_activity,omitempty"`
+	Latest          bool      `json:"latest"`
+}
+
+// ConversationTree describes how a session's messages branch. Only sources that link
+// messages with uuid/parent_uuid metadata (currently Claude Code) have branches.
+type ConversationTree struct {
+	Messages int      `json:"messages"`
+	Branched bool     `json:"branched"`
+	Branches []Branch `json:"branches"`
+}
+
+// BuildTree reconstructs the branch structure of a session from its message links.
+// Branches are listed in the order their leaves appear in the session.
+func BuildTree(messages []adapters.Message) ConversationTree {
+	tree := ConversationTree{Messages: len(messages), Branches: []Branch{}}
+
+	byUUID, children := messageLinks(messages)
+	if len(byUUID) == 0 {
+		return tree
+	}
+
+	latest := LatestLeaf(messages)
+	for _, msg := range messages {
+		uuid := messageUUID(msg)
+		if uuid == "" || len(children[uuid]) > 0 {
+			continue
+		}
+
+		branch := Branch{LeafUUID: uuid, Latest: uuid == latest}
+		for node := uuid; node != "" && branch.Messages < len(byUUID); node = messageParentUUID(byUUID[node]) {
+			current, ok := byUUID[node]
+			if !ok {
+				break
+			}
+			branch.Messages++
+			if branch.LastActivity.IsZero() {
+				branch.LastActivity = current.Timestamp
+			}
+			if branch.LastUserMessage == "" && IsHumanMessage(current) {
+				branch.LastUserMessage = textutil.TruncateBytes(firstLine(current.Content), maxTimelineTextLength)
+			}
+			if branch.ForkUUID == "" && node != uuid && len(children[node]) > 1 {
+				branch.ForkUUID = node
+			}
+		}
+		tree.Branches = append(tree.Branches, branch)
+	}
+
+	tree.Branched = len(tree.Branches) > 1
+	return tree
+}
+
+// LatestLeaf returns the UUID of the most recently active leaf message, or "" when the
+// messages carry no links. Ties (or missing timestamps) favour the leaf written last.
+func LatestLeaf(messages []adapters.Message) string {
+	_, children := messageLinks(messages)
+
+	var latest string
+	var latestTime time.Time
+	for _, msg := range messages {
+		uuid := messageUUID(msg)
+		if uuid == "" || len(children[uuid]) > 0 {
+			continue
+		}
+		if latest == "" || !msg.Timestamp.Before(latestTime) {
+			latest = uuid
+			latestTime = msg.Timestamp
+		}
+	}
+	return latest
+}
+
+// BranchMessages returns the messages on the path from the root to the given leaf, in
+// session order. The leaf may be "latest" to select the most recently active branch.
+func BranchMessages(messages []adapters.Message, leafUUID string) ([]adapters.Message, error) {
+	byUUID, _ := messageLinks(messages)
+	if len(byUUID) == 0 {
+		return nil, fmt.Errorf("session has no branch information")
+	}
+
+	if strings.EqualFold(leafUUID, "latest") {
+		leafUUID = LatestLeaf(messages)
+	}
+	if _, ok := byUUID[leafUUID]; !ok {
+		return nil, fmt.Errorf("message not found in session: %s", leafUUID)
+	}
+
+	onPath := make(map[string]bool)
+	for node := leafUUID; node != "" && !onPath[node]; node = messageParentUUID(byUUID[node]) {
+		onPath[node] = true
+	}
+
+	branch := make([]adapters.Message, 0, len(onPath))
+	for _, msg := range messages {
+		if onPath[messageUUID(msg)] {
+			branch = append(branch, msg)
+		}
+	}
+	return branch, nil
+}
+
+// messageLinks indexes messages by UUID and lists the children of each message.
+func messageLinks(messages []adapters.Message) (map[string]adapters.Message, map[string][]string) {
+	byUUID := make(map[string]adapters.Message)
+	children := make(map[string][]string)
+	for _, msg := range messages {
+		uuid := messageUUID(msg)
+		if uuid == "" {
+			continue
+		}
+		byUUID[uuid] = msg
+		if parent := messageParentUUID(msg); parent != "" {
+			children[parent] = append(children[parent], uuid)
+		}
+	}
+	return byUUID, children
+}
+
+// messageUUID returns the message's own link ID, if recorded.
+func messageUUID(msg adapters.Message) string {
+	uuid, _ := msg.Metadata["uuid"].(string)
+	return uuid
+}
+
+// messageParentUUID returns the link ID of the message's parent, if recorded.
+func messageParentUUID(msg adapters.Message) string {
+	parent, _ := msg.Metadata["parent_uuid"].(string)
+	return parent
+}
@@ -0,0 +1,64 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func linked(uuid, parent, role, content string, ts time.Time) adapters.Message {
+	metadata := map[string]interface{}{"uuid": uuid}
+	if parent != "" {
+		metadata["parent_uuid"] = parent
+	}
+	return adapters.Message{Role: role, Content: content, Timestamp: ts, Metadata: metadata}
+}
+
+func TestBuildTreeAndBranchMessages(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	// u1 -> a1 -> u2 -> a2          (first attempt)
+	//          \-> u3 -> a3         (prompt edited later)
+	messages := []adapters.Message{
+		linked("u1", "", "user", "set up the project", start),
+		linked("a1", "u1", "assistant", "done", start.Add(time.Minute)),
+		linked("u2", "a1", "user", "add tests", start.Add(2*time.Minute)),
+		linked("a2", "u2", "assistant", "added", start.Add(3*time.Minute)),
+		linked("u3", "a1", "user", "add integration tests instead", start.Add(4*time.Minute)),
+		linked("a3", "u3", "assistant", "added integration tests", start.Add(5*time.Minute)),
+	}
+
+	tree := BuildTree(messages)
+	if !tree.Branched || len(tree.Branches) != 2 {
+		t.Fatalf("expected two branches, got %+v", tree)
+	}
+	first, second := tree.Branches[0], tree.Branches[1]
+	if first.LeafUUID != "a2" || first.Messages != 4 || first.ForkUUID != "a1" || first.Latest {
+		t.Fatalf("unexpected first branch: %+v", first)
+	}
+	if second.LeafUUID != "a3" || !second.Latest || second.LastUserMessage != "add integration tests instead" {
+		t.Fatalf("unexpected second branch: %+v", second)
+	}
+
+	branch, err := BranchMessages(messages, "latest")
+	if err != nil {
+		t.Fatalf("BranchMessages failed: %v", err)
+	}
+	var ids []string
+	for _, msg := range branch {
+		ids = append(ids, messageUUID(msg))
+	}
+	if len(ids) != 4 || ids[0] != "u1" || ids[1] != "a1" || ids[2] != "u3" || ids[3] != "a3" {
+		t.Fatalf("unexpected latest branch: %v", ids)
+	}
+
+	if _, err := BranchMessages(messages, "missing"); err == nil {
+		t.Fatal("expected error for unknown leaf")
+	}
+	if _, err := BranchMessages([]adapters.Message{{Role: "user", Content: "hi"}}, "latest"); err == nil {
+		t.Fatal("expected error for sessions without links")
+	}
+	if tree := BuildTree([]adapters.Message{{Role: "user"}}); tree.Branched || len(tree.Branches) != 0 {
+		t.Fatalf("unlinked sessions have no branches: %+v", tree)
+	}
+}
@@ -0,0 +1,125 @@
+package analysis
+
+import "github.com/yoavf/ai-sessions-mcp/adapters"
+
+// Usage is token consumption and cost, normalized across sources.
+// InputTokens excludes cached input, which is counted separately in CacheReadTokens.
+type Usage struct {
+	InputTokens      int     `json:"input_tokens"`
+	OutputTokens     int     `json:"output_tokens"`
+	ReasoningTokens  int     `json:"reasoning_tokens,omitempty"`
+	CacheReadTokens  int     `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int     `json:"cache_write_tokens,omitempty"`
+	Cost             float64 `json:"cost,omitempty"`
+}
+
+// Total returns the total number of tokens processed.
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens + u.ReasoningTokens + u.CacheReadTokens + u.CacheWriteTokens
+}
+
+// Add accumulates other into u.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.CacheReadTokens += other.CacheReadTokens
+	u.CacheWriteTokens += other.CacheWriteTokens
+	u.Cost += other.Cost
+}
+
+// MessageUsage returns the token usage recorded on a message. opencode and Gemini
+// store "tokens" (opencode also stores "cost"), Codex stores "token_usage", and
+// Claude stores the API's "usage".
+func MessageUsage(msg adapters.Message) Usage {
+	var usage Usage
+
+	if tokens, ok := msg.Metadata["tokens"].(map[string]interface{}); ok {
+		usage.InputTokens = intValue(tokens["input"])
+		usage.OutputTokens = intValue(tokens["output"])
+		usage.ReasoningTokens = intValue(tokens["reasoning"])
+		if cache, ok := tokens["cache"].(map[string]interface{}); ok {
+			usage.CacheReadTokens = intValue(cache["read"])
+			usage.CacheWriteTokens = intValue(cache["write"])
+		}
+	}
+
+	if tokens, ok := msg.Metadata["token_usage"].(map[string]interface{}); ok {
+		cached := intValue(tokens["cached_input_tokens"])
+		usage.InputTokens = intValue(tokens["input_tokens"]) - cached
+		usage.CacheReadTokens = cached
+		// Reasoning is reported as part of the output tokens
+		usage.ReasoningTokens = intValue(tokens["reasoning_output_tokens"])
+		usage.OutputTokens = intValue(tokens["output_tokens"]) - usage.ReasoningTokens
+		if usage.OutputTokens < 0 {
+			usage.OutputTokens = 0
+		}
+	}
+
+	if tokens, ok := msg.Metadata["usage"].(map[string]interface{}); ok {
+		usage.InputTokens = intValue(tokens["input_tokens"])
+		usage.OutputTokens = intValue(tokens["output_tokens"])
+		usage.CacheReadTokens = intValue(tokens["cache_read_input_tokens"])
+		usage.CacheWriteTokens = intValue(tokens["cache_creation_input_tokens"])
+	}
+
+	if cost, ok := msg.Metadata["cost"].(float64); ok {
+		usage.Cost = cost
+	}
+
+	return usage
+}
+
+// MessageModel returns the model that produced a message, when the agent records it.
+func MessageModel(msg adapters.Message) string {
+	model, _ := msg.Metadata["model"].(string)
+	return model
+}
+
+// MessageCost returns what a message cost: the cost the agent recorded, or else an
+// estimate from the list price of the message's model. estimated reports whether list
+// prices were used, and priced is false when usage was recorded for an unknown model.
+func MessageCost(msg adapters.Message) (cost float64, estimated bool, priced bool) {
+	usage := MessageUsage(msg)
+	if usage.Cost > 0 {
+		return usage.Cost, false, true
+	}
+	if usage.Total() == 0 {
+		return 0, false, true
+	}
+	price, ok := PriceForModel(MessageModel(msg))
+	if !ok {
+		return 0, false, false
+	}
+	return price.Cost(usage), true, true
+}
+
+// SessionCost sums the cost of a session's messages, recorded or estimated.
+func SessionCost(messages []adapters.Message) float64 {
+	total := 0.0
+	for _, msg := range messages {
+		cost, _, _ := MessageCost(msg)
+		total += cost
+	}
+	return total
+}
+
+// SessionUsage sums the usage recorded across a session's messages.
+func SessionUsage(messages []adapters.Message) Usage {
+	var usage Usage
+	for _, msg := range messages {
+		usage.Add(MessageUsage(msg))
+	}
+	return usage
+}
+
+// intValue converts a decoded JSON number to an int.
+func intValue(v interface{}) int {
+	switch n := v.(type) {
+	case float64:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
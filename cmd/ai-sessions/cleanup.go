@@ -0,0 +1,292 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/manifoldco/promptui"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+var cleanupCommand = cliCommand{
+	name:    "cleanup",
+	summary: "Delete or archive the files of old sessions, and forget them in the search cache",
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts cleanupOptions
+		fs.StringVar(&opts.OlderThan, "older-than", "", "clean up sessions last active before a `window` like 90d or 12w, or a date")
+		fs.StringVar(&opts.Source, "source", "", "only clean up sessions of this `source`")
+		fs.StringVar(&opts.ProjectPath, "project", "", "only clean up sessions of a `project`: a path, a glob, or a directory name")
+		fs.StringVar(&opts.Archive, "archive", "", "move the session files into `dir` instead of deleting them")
+		fs.BoolVar(&opts.DryRun, "dry-run", false, "list the sessions that would be cleaned up, and their size, without touching them")
+		fs.BoolVar(&opts.Yes, "yes", false, "don't ask for confirmation")
+		fs.BoolVar(&opts.Yes, "y", false, "")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			var cache *search.Cache
+			if !opts.DryRun {
+				var err error
+				if cache, err = env.searchCache(); err != nil {
+					return err
+				}
+			}
+			return runCleanupCommand(env.sessionAdapters(), cache, opts, env.stdout, env.stderr)
+		}
+	},
+}
+
+// cleanupOptions are the options of `aisessions cleanup`
+type cleanupOptions struct {
+	OlderThan   string // A window or date (see parseSince)
+	Source      string
+	ProjectPath string
+	Archive     string // Directory to move the files into; empty deletes them
+	DryRun      bool
+	Yes         bool // Clean up without confirmation
+	JSON        bool
+}
+
+// cleanupSession is a session to clean up, with the files that hold it
+type cleanupSession struct {
+	adapters.Session
+	LastActivity time.Time `json:"last_activity"`
+	Files        []string  `json:"files"`
+	Size         int64     `json:"size"`
+}
+
+// runCleanupCommand deletes, or moves into the archive directory, the files of the local
+// sessions last active before the cutoff, then removes the sessions from the search
+// cache. Confirmation prompts go to stderr.
+func runCleanupCommand(adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, opts cleanupOptions, stdout, stderr io.Writer) error {
+	if strings.TrimSpace(opts.OlderThan) == "" || strings.EqualFold(strings.TrimSpace(opts.OlderThan), "all") {
+		return fmt.Errorf("usage: aisessions cleanup --older-than 90d [--source name] [--project path] [--archive dir] [--dry-run]")
+	}
+	cutoff, err := parseSince(opts.OlderThan, time.Now())
+	if err != nil {
+		return fmt.Errorf("invalid --older-than value %q: use a window like '90d', '12w', or a date like '2025-01-31'", opts.OlderThan)
+	}
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+	if !opts.DryRun && !opts.Yes && opts.JSON {
+		return fmt.Errorf("--json requires --yes or --dry-run, confirmation is interactive")
+	}
+
+	sessions, err := collectSessions(adaptersMap, opts.Source, opts.ProjectPath)
+	if err != nil {
+		return err
+	}
+	candidates, total := cleanupCandidates(sessions, cutoff)
+
+	action := "delete"
+	if opts.Archive != "" {
+		action = "archive"
+	}
+	if opts.JSON && (opts.DryRun || len(candidates) == 0) {
+		return printJSON(stdout, cleanupResult(candidates, total, action, opts))
+	}
+	if len(candidates) == 0 {
+		fmt.Fprintf(stdout, "No sessions last active before %s\n", cutoff.Format("2006-01-02"))
+		return nil
+	}
+
+	if !opts.JSON {
+		for _, session := range candidates {
+			fmt.Fprintf(stdout, "%-*s %-12s %-20s %-40s %s\n", cliTimes.columnWidth(), formatTime(session.LastActivity),
+				getAgentDisplayName(session.Source), truncateString(getProjectName(session.ProjectPath), 20), session.ID, formatFileSize(session.Size))
+		}
+	}
+	summary := fmt.Sprintf("%d sessions (%s)", len(candidates), formatFileSize(total))
+	if opts.DryRun {
+		verb := "delete"
+		if opts.Archive != "" {
+			verb = "move to " + opts.Archive
+		}
+		fmt.Fprintf(stdout, "Would %s %s\n", verb, summary)
+		return nil
+	}
+
+	if !opts.Yes {
+		label := "Delete " + summary
+		if opts.Archive != "" {
+			label = fmt.Sprintf("Move %s to %s", summary, opts.Archive)
+		}
+		prompt := promptui.Prompt{Label: label, IsConfirm: true, Stdout: nopWriteCloser{stderr}}
+		if _, err := prompt.Run(); err != nil {
+			return fmt.Errorf("cleanup cancelled")
+		}
+	}
+
+	// Sessions whose files can't all be removed stay in the cache, to be cleaned up again
+	var cleaned []cleanupSession
+	var cleanedSessions []adapters.Session
+	var failures []string
+	for _, session := range candidates {
+		if err := cleanUpSession(session, opts.Archive); err != nil {
+			failures = append(failures, fmt.Sprintf("%s %s: %v", session.Source, session.ID, err))
+			continue
+		}
+		cleaned = append(cleaned, session)
+		cleanedSessions = append(cleanedSessions, session.Session)
+	}
+	if cache != nil && len(cleanedSessions) > 0 {
+		if _, err := cache.RemoveSessions(cleanedSessions); err != nil {
+			failures = append(failures, fmt.Sprintf("search cache: %v", err))
+		}
+	}
+
+	var cleanedSize int64
+	for _, session := range cleaned {
+		cleanedSize += session.Size
+	}
+	if opts.JSON {
+		result := cleanupResult(cleaned, cleanedSize, action, opts)
+		if len(failures) > 0 {
+			result["failures"] = failures
+		}
+		return printJSON(stdout, result)
+	}
+	for _, failure := range failures {
+		fmt.Fprintf(stderr, "Warning: could not clean up %s\n", failure)
+	}
+	if opts.Archive != "" {
+		fmt.Fprintf(stdout, "Moved %d sessions (%s) to %s\n", len(cleaned), formatFileSize(cleanedSize), opts.Archive)
+	} else {
+		fmt.Fprintf(stdout, "Deleted %d sessions (%s)\n", len(cleaned), formatFileSize(cleanedSize))
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d sessions could not be cleaned up", len(failures))
+	}
+	return nil
+}
+
+// cleanupCandidates returns the local sessions last active before the cutoff, oldest
+// first, with their files and size, and their total size. Sessions from other machines
+// are mirrors, and are left alone.
+func cleanupCandidates(sessions []adapters.Session, cutoff time.Time) ([]cleanupSession, int64) {
+	candidates := []cleanupSession{}
+	var total int64
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		if session.Machine != "" {
+			continue
+		}
+		lastActivity := sessionModTime(session)
+		if !lastActivity.Before(cutoff) {
+			continue
+		}
+		files := adapters.SessionFiles(session)
+		if len(files) == 0 {
+			continue
+		}
+		candidate := cleanupSession{Session: session, LastActivity: lastActivity, Files: files}
+		for _, file := range files {
+			candidate.Size += pathSize(file)
+		}
+		total += candidate.Size
+		candidates = append(candidates, candidate)
+	}
+	return candidates, total
+}
+
+// cleanupResult is the JSON output of `aisessions cleanup`
+func cleanupResult(sessions []cleanupSession, size int64, action string, opts cleanupOptions) map[string]interface{} {
+	result := map[string]interface{}{
+		"action":   action,
+		"dry_run":  opts.DryRun,
+		"sessions": sessions,
+		"count":    len(sessions),
+		"bytes":    size,
+	}
+	if opts.Archive != "" {
+		result["archive"] = opts.Archive
+	}
+	return result
+}
+
+// cleanUpSession deletes the files of a session, or moves them into the archive
+// directory, under their path relative to the home directory so they can be restored
+func cleanUpSession(session cleanupSession, archive string) error {
+	for _, path := range session.Files {
+		if archive == "" {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := movePath(path, archivePath(archive, path)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archivePath returns where a session file is archived: under its path relative to the
+// home directory, or its absolute path outside it
+func archivePath(archive, path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return filepath.Join(archive, rel)
+		}
+	}
+	return filepath.Join(archive, strings.TrimPrefix(path, filepath.VolumeName(path)))
+}
+
+// movePath moves a file or directory, copying it when it can't be renamed across
+// file systems
+func movePath(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	err := os.Rename(from, to)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	err = filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+// pathSize returns the size of a file, or of the files in a directory
+func pathSize(path string) int64 {
+	var size int64
+	filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// writeCleanupSessions writes an old and a recent codex session file under home
+func writeCleanupSessions(t *testing.T, home string) []adapters.Session {
+	t.Helper()
+	dir := filepath.Join(home, ".codex", "sessions")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("failed to create sessions dir: %v", err)
+	}
+	sessions := []adapters.Session{
+		{ID: "recent", Source: "codex", FilePath: filepath.Join(dir, "recent.jsonl"), Timestamp: time.Now()},
+		{ID: "old", Source: "codex", FilePath: filepath.Join(dir, "old.jsonl"), Timestamp: time.Now().AddDate(0, 0, -200)},
+	}
+	for _, session := range sessions {
+		if err := os.WriteFile(session.FilePath, []byte(`{"type":"session_meta"}`+"\n"), 0o644); err != nil {
+			t.Fatalf("failed to write session file: %v", err)
+		}
+		if err := os.Chtimes(session.FilePath, session.Timestamp, session.Timestamp); err != nil {
+			t.Fatalf("failed to set session time: %v", err)
+		}
+	}
+	return sessions
+}
+
+func TestRunCleanupCommandDeletes(t *testing.T) {
+	home := t.TempDir()
+	sessions := writeCleanupSessions(t, home)
+	adaptersMap := map[string]adapters.SessionAdapter{"codex": newStubAdapter(sessions, nil)}
+	cache := newTestCache(t)
+	for _, session := range sessions {
+		if err := cache.IndexSession(session, "kubernetes rollout "+session.ID); err != nil {
+			t.Fatalf("failed to index session: %v", err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "--json", "cleanup", "--older-than", "90d"); err == nil {
+		t.Fatal("expected --json without --yes to fail")
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "--json", "cleanup", "--older-than", "90d", "--dry-run"); err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	var result struct {
+		DryRun   bool             `json:"dry_run"`
+		Count    int              `json:"count"`
+		Bytes    int64            `json:"bytes"`
+		Sessions []cleanupSession `json:"sessions"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if !result.DryRun || result.Count != 1 || result.Sessions[0].ID != "old" || result.Bytes == 0 {
+		t.Fatalf("unexpected dry run result: %s", out.String())
+	}
+	if _, err := os.Stat(sessions[1].FilePath); err != nil {
+		t.Fatalf("dry run removed the session file: %v", err)
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "cleanup", "--older-than", "90d", "--yes"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Deleted 1 sessions") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+	if _, err := os.Stat(sessions[1].FilePath); !os.IsNotExist(err) {
+		t.Fatalf("expected the old session file to be deleted, got %v", err)
+	}
+	if _, err := os.Stat(sessions[0].FilePath); err != nil {
+		t.Fatalf("expected the recent session file to be kept: %v", err)
+	}
+
+	results, err := cache.Search("kubernetes", "", "", 10)
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Session.ID != "recent" {
+		t.Fatalf("expected only the recent session in the cache, got %+v", results)
+	}
+}
+
+func TestRunCleanupCommandArchives(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	sessions := writeCleanupSessions(t, home)
+	adaptersMap := map[string]adapters.SessionAdapter{"codex": newStubAdapter(sessions, nil)}
+	archive := filepath.Join(t.TempDir(), "archive")
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "cleanup", "--older-than", "90d", "--archive", archive, "-y"); err != nil {
+		t.Fatalf("cleanup failed: %v", err)
+	}
+	if _, err := os.Stat(sessions[1].FilePath); !os.IsNotExist(err) {
+		t.Fatalf("expected the old session file to be moved, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archive, ".codex", "sessions", "old.jsonl")); err != nil {
+		t.Fatalf("expected the session file in the archive: %v", err)
+	}
+}
+
+func TestRunCleanupCommandRequiresAge(t *testing.T) {
+	for _, olderThan := range []string{"", "all", "soon"} {
+		var out bytes.Buffer
+		if err := runTestCLI(map[string]adapters.SessionAdapter{}, nil, &out, "cleanup", "--older-than", olderThan, "--dry-run"); err == nil {
+			t.Errorf("expected --older-than %q to fail", olderThan)
+		}
+	}
+}
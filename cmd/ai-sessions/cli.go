@@ -3,19 +3,22 @@ package main
 import (
 	"bufio"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
 	"time"
 
-	"github.com/manifoldco/promptui"
 	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
 	"golang.org/x/term"
 )
 
@@ -23,13 +26,74 @@ const (
 	defaultAPIURL = "https://aisessions.dev"
 	configDir     = ".aisessions"
 	configFile    = "config.json"
+	ignoreFile    = "ignore"
 )
 
 type Config struct {
-	Token string `json:"token"`
+	Token string `json:"token,omitempty"`
+
+	// Keychain is set when the token is stored in the system keychain instead of Token
+	Keychain bool `json:"keychain,omitempty"`
+
+	// Sources enables only these sources (empty = all), and DisabledSources turns
+	// sources off. The --sources flag of the MCP server takes precedence.
+	Sources         []string `json:"sources,omitempty"`
+	DisabledSources []string `json:"disabled_sources,omitempty"`
+
+	// Machines adds the sessions of other machines whose agent data is available locally
+	Machines []machineConfig `json:"machines,omitempty"`
+
+	// APIURL is the server logged in to, when not aisessions.dev, and TrustedHosts are
+	// the self-hosted servers the token may be sent to. `aisessions login --url` adds a
+	// host after asking for confirmation.
+	APIURL       string   `json:"api_url,omitempty"`
+	TrustedHosts []string `json:"trusted_hosts,omitempty"`
+
+	// Proxy sends requests to the API through this proxy URL instead of the one of
+	// HTTPS_PROXY, and CABundle is a PEM file of CA certificates to trust besides the
+	// system's, for networks that inspect TLS traffic
+	Proxy    string `json:"proxy,omitempty"`
+	CABundle string `json:"ca_bundle,omitempty"`
+
+	// AllowProjects and DenyProjects limit the projects whose sessions the MCP server
+	// exposes, and StripToolOutput hides the output of tool calls from it, for running
+	// it in shared or recorded environments. ReadOnly turns off the tools that write.
+	AllowProjects   []string `json:"allow_projects,omitempty"`
+	DenyProjects    []string `json:"deny_projects,omitempty"`
+	StripToolOutput bool     `json:"strip_tool_output,omitempty"`
+	ReadOnly        bool     `json:"read_only,omitempty"`
+
+	// EncryptCache encrypts the session text stored in the search cache, with a key kept
+	// in the system keychain
+	EncryptCache bool `json:"encrypt_cache,omitempty"`
+
+	// MetadataOnlyIndex keeps the text of sessions out of the search cache, which then
+	// only stores their metadata and term statistics
+	MetadataOnlyIndex bool `json:"metadata_only_index,omitempty"`
+
+	// InMemoryIndex keeps the search cache in memory instead of ~/.cache/ai-sessions,
+	// for containers and CI. It is rebuilt by every process, and its tags, notes, and
+	// saved searches are lost when the process exits.
+	InMemoryIndex bool `json:"in_memory_index,omitempty"`
+
+	// MCPUploads lets MCP clients upload sessions with the upload_session tool. Its
+	// uploads redact every secret found, since nobody is there to review them.
+	MCPUploads bool `json:"mcp_uploads,omitempty"`
+
+	// Limits cap the size of tool results, the sessions a request may index, and the
+	// rate of tool calls of the MCP server (see toolLimits)
+	Limits *toolLimits `json:"limits,omitempty"`
+
+	// LogFile also writes logs to ~/.cache/ai-sessions/aisessions.log
+	LogFile bool `json:"log_file,omitempty"`
+
+	// Profiles are named logins besides the default one above, such as a personal and
+	// a team server, chosen with --profile
+	Profiles map[string]profileConfig `json:"profiles,omitempty"`
 }
 
 type loginDeps struct {
+	config        Config // Saved settings: the server of the profile and the trusted hosts
 	stdin         io.Reader
 	stdout        io.Writer
 	stderr        io.Writer
@@ -38,29 +102,50 @@ type loginDeps struct {
 	saveConfig    func(Config) error
 }
 
-func defaultLoginDeps() loginDeps {
+func defaultLoginDeps(profile string) loginDeps {
+	config, err := readConfigFile()
+	if err != nil {
+		slog.Warn("Ignoring unreadable config file", "error", err)
+	}
+	p, _ := config.profile(profile)
+	config.APIURL = p.APIURL
 	return loginDeps{
+		config:        config,
 		stdin:         os.Stdin,
 		stdout:        os.Stdout,
 		stderr:        os.Stderr,
 		openBrowser:   openBrowser,
 		validateToken: validateTokenFormat,
-		saveConfig:    saveConfig,
+		saveConfig:    func(c Config) error { return saveLoginToken(profile, c) },
 	}
 }
 
 var exitFunc = os.Exit
 
+// UntrustedHostError is returned for an https URL of a host that could be a
+// self-hosted server, but hasn't been trusted
+type UntrustedHostError struct {
+	Host string
+}
+
+func (e *UntrustedHostError) Error() string {
+	return fmt.Sprintf("untrusted domain: %s (only aisessions.dev, localhost, 127.0.0.1, and trusted self-hosted servers are allowed)", e.Host)
+}
+
 // validateAPIURL validates that a URL is from a trusted domain
 // For security, we only allow:
 // - https://aisessions.dev (production)
 // - http://localhost:* (local development)
 // - http://127.0.0.1:* (local development)
-func validateAPIURL(apiURL string) error {
+// - https://<host> for the trustedHosts of self-hosted servers
+func validateAPIURL(apiURL string, trustedHosts []string) error {
 	parsedURL, err := url.Parse(apiURL)
 	if err != nil {
 		return fmt.Errorf("invalid URL format: %w", err)
 	}
+	if parsedURL.Hostname() == "" {
+		return fmt.Errorf("invalid URL format: %s has no host", apiURL)
+	}
 
 	// For localhost/127.0.0.1, only allow http (not https to avoid cert issues in dev)
 	if parsedURL.Hostname() == "localhost" || parsedURL.Hostname() == "127.0.0.1" {
@@ -78,78 +163,44 @@ func validateAPIURL(apiURL string) error {
 		return nil
 	}
 
-	return fmt.Errorf("untrusted domain: %s (only aisessions.dev, localhost, and 127.0.0.1 are allowed)", parsedURL.Hostname())
+	// Self-hosted servers must use https, and be trusted explicitly
+	if parsedURL.Scheme != "https" {
+		return fmt.Errorf("self-hosted servers must use https://")
+	}
+	for _, host := range trustedHosts {
+		if strings.EqualFold(host, parsedURL.Hostname()) {
+			return nil
+		}
+	}
+	return &UntrustedHostError{Host: parsedURL.Hostname()}
 }
 
-// handleCLI routes CLI commands to their handlers
-func handleCLI() {
-	if len(os.Args) < 2 {
-		printUsage()
-		os.Exit(1)
+// resolveAPIURL returns the server to send the token to: the --url flag, else the
+// server logged in to, else aisessions.dev. It must be trusted.
+func resolveAPIURL(flagURL string, config *Config) (string, error) {
+	apiURL := flagURL
+	if apiURL == "" {
+		apiURL = getAPIURL(config.APIURL)
 	}
-
-	command := os.Args[1]
-
-	switch command {
-	case "login", "config":
-		// Parse optional --url flag for login command
-		var apiURL string
-		for i := 2; i < len(os.Args); i++ {
-			if os.Args[i] == "--url" {
-				if i+1 >= len(os.Args) {
-					fmt.Fprintf(os.Stderr, "Error: --url requires a value\n")
-					os.Exit(1)
-				}
-				apiURL = os.Args[i+1]
-				break
-			}
+	if err := validateAPIURL(apiURL, config.TrustedHosts); err != nil {
+		var untrusted *UntrustedHostError
+		if errors.As(err, &untrusted) {
+			return "", fmt.Errorf("invalid API URL: %w; run 'aisessions login --url %s' to trust it", err, apiURL)
 		}
-		handleLogin(apiURL)
-	case "upload":
-		handleUploadCommand()
-	case "version", "-v", "--version":
-		fmt.Println("aisessions version 2.0.0")
-	case "help", "-h", "--help":
-		printUsage()
-	default:
-		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
-		printUsage()
-		os.Exit(1)
+		return "", fmt.Errorf("invalid API URL: %w", err)
 	}
+	return apiURL, nil
 }
 
-// printUsage displays CLI usage information
-func printUsage() {
-	fmt.Println(`AI Sessions CLI
-
-Usage:
-  aisessions <command> [options]
-
-Commands:
-  login              Configure authentication token
-  upload <file>      Upload a transcript file
-  version            Show version information
-  help               Show this help message
-
-Options:
-  --title <title>    Set the title for the uploaded transcript (upload only)
-  --url <url>        Override API URL (default: https://aisessions.dev)
-
-Examples:
-  aisessions login
-  aisessions upload session.jsonl
-  aisessions upload session.jsonl --title "Bug Fix Session"
-
-  # Development mode (use local server)
-  aisessions login --url http://localhost:3000
-  aisessions upload session.jsonl --url http://localhost:3000
-
-Authentication:
-  1. Visit https://aisessions.dev/my-transcripts
-  2. Click "Generate CLI Token"
-  3. Run 'aisessions login' and paste the token
-
-For more information, visit: https://github.com/yoavf/ai-sessions-mcp`)
+// confirmTrustedHost asks whether to trust a self-hosted server with the token
+func confirmTrustedHost(reader *bufio.Reader, w io.Writer, host string) bool {
+	fmt.Fprintf(w, "\033[33m⚠\033[0m  %s is not aisessions.dev. Your token will be sent to it with\n", host)
+	fmt.Fprintln(w, "every upload, so only trust it if it is your team's self-hosted transcript server.")
+	fmt.Fprintf(w, "Trust %s? [y/N]: ", host)
+	line, _ := reader.ReadString('\n')
+	answer := strings.ToLower(strings.TrimSpace(line))
+	fmt.Fprintln(w)
+	return answer == "y" || answer == "yes"
 }
 
 // openBrowser opens the default browser to the given URL
@@ -207,22 +258,37 @@ func validateTokenFormat(token string) error {
 	return nil
 }
 
-// handleLogin prompts for and saves authentication token
-func handleLogin(apiURL string) {
-	deps := defaultLoginDeps()
+// handleLogin prompts for and saves the authentication token of a profile, "" being
+// the default one
+func handleLogin(apiURL, profile string) {
+	deps := defaultLoginDeps(profile)
 	if err := runLogin(apiURL, deps); err != nil {
 		exitFunc(1)
 	}
 }
 
 func runLogin(apiURL string, deps loginDeps) error {
-	// Determine API URL with priority: command-line flag > default
+	// Determine API URL with priority: command-line flag > server logged in to > default
 	if apiURL == "" {
-		apiURL = getAPIURL("")
+		apiURL = getAPIURL(deps.config.APIURL)
 	}
 
-	// Validate the URL for security (prevent phishing via terminal hyperlinks)
-	if err := validateAPIURL(apiURL); err != nil {
+	// Create reader once and reuse it
+	reader := bufio.NewReader(deps.stdin)
+
+	// Validate the URL for security (prevent phishing via terminal hyperlinks). A
+	// self-hosted server is trusted only after confirmation.
+	var trustedHost string
+	err := validateAPIURL(apiURL, deps.config.TrustedHosts)
+	var untrusted *UntrustedHostError
+	if errors.As(err, &untrusted) {
+		if !confirmTrustedHost(reader, deps.stdout, untrusted.Host) {
+			fmt.Fprintf(deps.stderr, "\033[31m✗\033[0m Not trusting %s, login cancelled\n", untrusted.Host)
+			return err
+		}
+		trustedHost, err = untrusted.Host, nil
+	}
+	if err != nil {
 		fmt.Fprintf(deps.stderr, "Error: Invalid API URL: %v\n", err)
 		fmt.Fprintf(deps.stderr, "For local development, use: --url http://localhost:3000\n")
 		return err
@@ -233,9 +299,6 @@ func runLogin(apiURL string, deps loginDeps) error {
 	fmt.Fprintln(deps.stdout, "AI Sessions CLI - Login")
 	fmt.Fprintln(deps.stdout)
 
-	// Create reader once and reuse it
-	reader := bufio.NewReader(deps.stdin)
-
 	// Show clickable URL and wait for user input
 	clickableURL := makeClickableURL(tokenURL)
 	fmt.Fprintf(deps.stdout, "Press Enter to open %s in your browser, or paste your token: ", clickableURL)
@@ -284,6 +347,12 @@ func runLogin(apiURL string, deps loginDeps) error {
 	config := Config{
 		Token: token,
 	}
+	if apiURL != defaultAPIURL {
+		config.APIURL = apiURL
+	}
+	if trustedHost != "" {
+		config.TrustedHosts = []string{trustedHost}
+	}
 
 	if err := deps.saveConfig(config); err != nil {
 		fmt.Fprintf(deps.stderr, "\033[31m✗\033[0m Error saving configuration: %v\n", err)
@@ -369,13 +438,7 @@ func getAgentDisplayName(source string) string {
 
 // cleanFirstMessage trims and truncates the first message
 func cleanFirstMessage(msg string, maxLen int) string {
-	msg = strings.TrimSpace(msg)
-
-	// Truncate with ellipsis if needed
-	if len(msg) > maxLen {
-		return msg[:maxLen-3] + "..."
-	}
-	return msg
+	return textutil.Truncate(strings.TrimSpace(msg), maxLen)
 }
 
 // getTerminalWidth returns the terminal width, defaulting to 80 if unable to determine
@@ -387,39 +450,33 @@ func getTerminalWidth() int {
 	return width
 }
 
-// truncateString truncates a string to maxLen with ellipsis at the end
+// isTerminal reports whether w is an interactive terminal, where colors can be used
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
+// truncateString truncates a string to maxLen characters with ellipsis at the end
 func truncateString(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	if maxLen < 3 {
-		return s[:maxLen]
-	}
-	return s[:maxLen-3] + "..."
+	return textutil.Truncate(s, maxLen)
 }
 
-// truncateStringStart truncates a string to maxLen with ellipsis at the start
+// truncateStringStart truncates a string to maxLen characters with ellipsis at the start
 func truncateStringStart(s string, maxLen int) string {
-	if len(s) <= maxLen {
-		return s
-	}
-	if maxLen < 3 {
-		return s[len(s)-maxLen:]
-	}
-	return "..." + s[len(s)-(maxLen-3):]
+	return textutil.TruncateStart(s, maxLen)
 }
 
 // formatSessionRow formats a session as a table row
 func formatSessionRow(s adapters.Session, width int) string {
-	timeStr := formatRelativeTime(s.Timestamp)
+	timeStr := formatTime(s.Timestamp)
 	project := getProjectName(s.ProjectPath)
 	agent := getAgentDisplayName(s.Source)
 	userMsgCol := fmt.Sprintf("%d", s.UserMessageCount)
 
 	// Calculate available space for message
-	// prefix(2) + time(12) + agent(12) + userMsgs(5) + project(28) + spacing(10) = 69
-	const fixedWidth = 69
-	messageWidth := width - fixedWidth
+	// prefix(2) + time + agent(12) + userMsgs(5) + project(28) + spacing(10) = 57 + time
+	timeWidth := cliTimes.columnWidth()
+	messageWidth := width - 57 - timeWidth
 	if messageWidth < 20 {
 		messageWidth = 20 // Minimum message width
 	}
@@ -427,239 +484,268 @@ func formatSessionRow(s adapters.Session, width int) string {
 	message := cleanFirstMessage(s.FirstMessage, messageWidth)
 
 	// Use padding for alignment
-	timeCol := truncateString(timeStr, 12)
+	timeCol := truncateString(timeStr, timeWidth)
 	agentCol := truncateString(agent, 12)
 	// For project names, truncate from the start (show the end with ellipsis at the start)
 	projectCol := truncateStringStart(project, 28)
 
-	return fmt.Sprintf("  %-12s  %-12s  %5s  %-28s  %s", timeCol, agentCol, userMsgCol, projectCol, message)
+	return fmt.Sprintf("  %-*s  %-12s  %5s  %-28s  %s", timeWidth, timeCol, agentCol, userMsgCol, projectCol, message)
 }
 
 // formatTableHeader formats the table header row
 func formatTableHeader() string {
-	return fmt.Sprintf("  %-12s  %-12s  %5s  %-28s  %s", "TIME", "AGENT", "#USER", "PROJECT", "MESSAGE")
+	timeHeader := "TIME"
+	if cliTimes.utc {
+		timeHeader = "TIME (UTC)"
+	}
+	return fmt.Sprintf("  %-*s  %-12s  %5s  %-28s  %s", cliTimes.columnWidth(), timeHeader, "AGENT", "#USER", "PROJECT", "MESSAGE")
 }
 
-// selectSessionInteractively displays an interactive list of recent sessions
-// and returns the file path of the selected session
-func selectSessionInteractively() (string, error) {
-	// Initialize Claude adapter
-	claudeAdapter, err := adapters.NewClaudeAdapter()
-	if err != nil {
-		return "", fmt.Errorf("failed to initialize Claude adapter: %w", err)
-	}
+var loginCommand = cliCommand{
+	name:    "login",
+	aliases: []string{"config"},
+	summary: "Configure authentication token",
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var profile string
+		profileFlagVar(fs, &profile)
+		return func(env *cliEnv, args []string) error {
+			handleLogin(env.options.URL, profile)
+			return nil
+		}
+	},
+}
+
+var logoutCommand = cliCommand{
+	name:    "logout",
+	summary: "Remove the saved authentication token",
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var profile string
+		profileFlagVar(fs, &profile)
+		return func(env *cliEnv, args []string) error {
+			return runLogout(profile, env.options.JSON, env.stdout)
+		}
+	},
+}
 
-	// List recent sessions (limit to 50 per adapter)
-	sessions, err := claudeAdapter.ListSessions("", 50)
+// runLogout removes the token of a profile from the keychain and the config file,
+// keeping the other settings
+func runLogout(profile string, asJSON bool, stdout io.Writer) error {
+	config, err := readConfigFile()
 	if err != nil {
-		return "", fmt.Errorf("failed to list sessions: %w", err)
+		return err
 	}
-
-	// Try to load Codex sessions (ignore errors to keep Claude flow working)
-	if codexAdapter, codexErr := adapters.NewCodexAdapter(); codexErr == nil {
-		if codexSessions, listErr := codexAdapter.ListSessions("", 50); listErr == nil {
-			sessions = append(sessions, codexSessions...)
+	p, loggedIn := config.profile(profile)
+	if p.Keychain && tokenKeychain != nil {
+		if err := tokenKeychain.remove(keychainAccountFor(profile)); err != nil {
+			return fmt.Errorf("failed to remove token from the %s: %w", tokenKeychain.name(), err)
 		}
 	}
-
-	// Try to load Gemini sessions
-	if geminiAdapter, geminiErr := adapters.NewGeminiAdapter(); geminiErr == nil {
-		if geminiSessions, listErr := geminiAdapter.ListSessions("", 50); listErr == nil {
-			fmt.Printf("Found %d Gemini sessions\n", len(geminiSessions))
-			sessions = append(sessions, geminiSessions...)
+	if loggedIn {
+		config.removeProfile(profile)
+		if err := saveConfig(config); err != nil {
+			return err
 		}
 	}
 
-	if len(sessions) == 0 {
-		return "", fmt.Errorf("no sessions found")
+	if asJSON {
+		return printJSON(stdout, map[string]bool{"logged_out": loggedIn})
 	}
+	if !loggedIn {
+		fmt.Fprintln(stdout, "Not logged in")
+		return nil
+	}
+	fmt.Fprintln(stdout, "\033[32m✓\033[0m Logged out; the saved token was removed")
+	return nil
+}
 
-	// Sort sessions by timestamp (newest first), putting zero timestamps last
-	sort.SliceStable(sessions, func(i, j int) bool {
-		ti := sessions[i].Timestamp
-		tj := sessions[j].Timestamp
+var uploadCommand = cliCommand{
+	name:    "upload",
+	args:    "[file]",
+	summary: "Upload a transcript file or session, or pick recent sessions to upload",
+	maxArgs: 1,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		opts := defaultUploadOptions
+		source := fs.String("source", "", "with --id, upload the session of this `source`")
+		id := fs.String("id", "", "with --source, upload the session with this `id`")
+		from := fs.Int("from", -1, "with --source and --id, upload messages starting at `index` (from 0)")
+		to := fs.Int("to", -1, "with --source and --id, upload messages up to `index`, inclusive")
+		fs.StringVar(&opts.Title, "title", "", "set the `title` of the uploaded transcript")
+		profileFlagVar(fs, &opts.Profile)
+		fs.BoolVar(&opts.NoRedact, "no-redact", false, "upload without reviewing secrets and personal details to redact")
+		fs.BoolVar(&opts.Anonymize, "anonymize", false, "replace user names, host names, home directories, and email addresses with placeholders")
+		fs.DurationVar(&opts.Timeout, "timeout", defaultUploadTimeout, "give up on each request to the server after `duration`")
+		fs.IntVar(&opts.Retries, "retries", defaultUploadRetries, "retry requests that fail for a temporary reason up to `n` times")
+		return func(env *cliEnv, args []string) error {
+			if *from >= 0 || *to >= 0 {
+				if *source == "" {
+					return fmt.Errorf("--from and --to need a session given by --source and --id")
+				}
+				opts.Range = &messageRange{From: *from, To: *to}
+				if *from < 0 {
+					opts.Range.From = 0
+				}
+			}
 
-		if ti.IsZero() && tj.IsZero() {
-			return sessions[i].FirstMessage > sessions[j].FirstMessage
-		}
-		if ti.IsZero() {
-			return false
-		}
-		if tj.IsZero() {
-			return true
-		}
-		return ti.After(tj)
-	})
+			var target *uploadTarget
+			switch {
+			case (*source == "") != (*id == ""):
+				return fmt.Errorf("--source and --id must be given together")
+			case *source != "" && len(args) == 1:
+				return fmt.Errorf("give either a file or --source and --id, not both")
+			case *source != "":
+				t, err := sessionUploadTarget(env.sessionAdapters(), *source, *id)
+				if err != nil {
+					return err
+				}
+				target = &t
+			case len(args) == 1:
+				if err := checkIgnoredFile(args[0]); err != nil {
+					return err
+				}
+				t := fileUploadTarget(args[0])
+				target = &t
+			}
 
-	// Limit to 50 sessions overall to keep the list manageable
-	if len(sessions) > 50 {
-		sessions = sessions[:50]
-	}
+			if env.options.JSON {
+				return runJSONUpload(target, opts, env.options.URL, env.stdout, env.stderr)
+			}
+			if target == nil {
+				return runPick(env, opts)
+			}
+			handleUploadCommand(*target, opts, env.options.URL)
+			return nil
+		}
+	},
+}
 
-	// Get terminal width for formatting
-	termWidth := getTerminalWidth()
+// uploadTarget is what `aisessions upload` uploads: a file, or a session given by its
+// source and ID
+type uploadTarget struct {
+	args   string            // Command line arguments naming the target
+	title  string            // Title used when none is given
+	fields map[string]string // Identify the target in JSON output
+	send   func(apiURL, token string, opts uploadOptions, w io.Writer) (*UploadResponse, error)
+}
 
-	// Filter sessions in-place to remove those with no user messages
-	// This is more memory-efficient than creating a new slice
-	n := 0
-	for _, session := range sessions {
-		if session.UserMessageCount > 0 {
-			sessions[n] = session
-			n++
-		}
-	}
-	sessions = sessions[:n]
-
-	if len(sessions) == 0 {
-		return "", fmt.Errorf("no sessions with user messages found")
-	}
-
-	// Create display items from the filtered sessions
-	items := make([]string, len(sessions))
-	for i, session := range sessions {
-		items[i] = formatSessionRow(session, termWidth)
-	}
-
-	// Print title
-	fmt.Println()
-	fmt.Println("Select a session to upload")
-	fmt.Println("Use the arrow keys to navigate: ↓ ↑ → ←  and / toggles search")
-	fmt.Println()
-	fmt.Println("\033[2m" + formatTableHeader() + "\033[0m") // Dim color for header
-
-	// Create templates
-	templates := &promptui.SelectTemplates{
-		Label:    `{{ "" }}`,               // Empty label to hide the prompt line
-		Active:   "\033[36m{{ . }}\033[0m", // Cyan for active
-		Inactive: "{{ . }}",
-		Selected: "\033[32m{{ . }}\033[0m", // Green for selected
-	}
-
-	// Create the prompt
-	prompt := promptui.Select{
-		Label:     " ",
-		Items:     items,
-		Templates: templates,
-		Size:      15,
-		HideHelp:  true, // Hide default help since we show our own
-		Searcher: func(input string, index int) bool {
-			session := sessions[index]
-			input = strings.ToLower(input)
-			return strings.Contains(strings.ToLower(session.FirstMessage), input) ||
-				strings.Contains(strings.ToLower(session.ProjectPath), input) ||
-				strings.Contains(strings.ToLower(getAgentDisplayName(session.Source)), input)
+// fileUploadTarget uploads a transcript file as it is
+func fileUploadTarget(file string) uploadTarget {
+	return uploadTarget{
+		args:   file,
+		title:  getDefaultTitle(file),
+		fields: map[string]string{"file": file},
+		send: func(apiURL, token string, opts uploadOptions, w io.Writer) (*UploadResponse, error) {
+			return uploadFile(apiURL, token, file, opts, w)
 		},
 	}
+}
 
-	// Display and get selection
-	idx, _, err := prompt.Run()
+// sessionUploadTarget uploads a session found by source and ID, titled after its first
+// message, so scripts don't need to know where its file is
+func sessionUploadTarget(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string) (uploadTarget, error) {
+	if _, err := sessionAdapter(adaptersMap, source, sessionID); err != nil {
+		return uploadTarget{}, err
+	}
+	session := findListedSession(adaptersMap, source, sessionID)
+	messages, err := readSession(adaptersMap, session)
 	if err != nil {
-		return "", fmt.Errorf("selection cancelled: %w", err)
-	}
-
-	selectedSession := sessions[idx]
-
-	// Clear the screen and show what was selected
-	fmt.Print("\033[H\033[2J") // Clear screen
-	fmt.Println()
-	fmt.Println("Selected session:")
-	fmt.Printf("  %s\n", selectedSession.FirstMessage)
-	fmt.Printf("  Project: %s\n", getProjectName(selectedSession.ProjectPath))
-	fmt.Printf("  Agent: %s\n", getAgentDisplayName(selectedSession.Source))
-	fmt.Printf("  User messages: %d\n", selectedSession.UserMessageCount)
-	fmt.Printf("  Time: %s\n", formatRelativeTime(selectedSession.Timestamp))
-	fmt.Printf("  File: %s\n", filepath.Base(selectedSession.FilePath))
-	fmt.Println()
-
-	return selectedSession.FilePath, nil
-}
-
-// handleUploadCommand processes upload command arguments
-func handleUploadCommand() {
-	var filepath string
-	var title string
-	var apiURL string
-	var fileProvided bool
-
-	// Check if a file path is provided (not a flag)
-	if len(os.Args) >= 3 && !strings.HasPrefix(os.Args[2], "--") {
-		filepath = os.Args[2]
-		fileProvided = true
-	}
-
-	// Parse optional flags
-	startIdx := 3
-	if !fileProvided {
-		startIdx = 2 // Flags start at position 2 if no file provided
-	}
-	for i := startIdx; i < len(os.Args); i++ {
-		switch os.Args[i] {
-		case "--title":
-			if i+1 >= len(os.Args) {
-				fmt.Fprintf(os.Stderr, "Error: --title requires a value\n")
-				os.Exit(1)
-			}
-			title = os.Args[i+1]
-			i++
-		case "--url":
-			if i+1 >= len(os.Args) {
-				fmt.Fprintf(os.Stderr, "Error: --url requires a value\n")
-				os.Exit(1)
+		return uploadTarget{}, err
+	}
+	title := export.Title(session, messages)
+	return uploadTarget{
+		args:   fmt.Sprintf("--source %s --id %s", source, sessionID),
+		title:  title,
+		fields: map[string]string{"source": source, "session_id": sessionID},
+		send: func(apiURL, token string, opts uploadOptions, w io.Writer) (*UploadResponse, error) {
+			if opts.Title == "" {
+				opts.Title = title
 			}
-			apiURL = os.Args[i+1]
-			i++
-		default:
-			fmt.Fprintf(os.Stderr, "Unknown flag: %s\n", os.Args[i])
-			os.Exit(1)
-		}
+			return uploadSession(session, messages, apiURL, token, opts, w)
+		},
+	}, nil
+}
+
+// runJSONUpload uploads a file or session and prints the uploaded transcript as JSON.
+// Prompts and progress go to stderr, and a missing login is an error rather than
+// starting one.
+func runJSONUpload(target *uploadTarget, opts uploadOptions, apiURL string, stdout, stderr io.Writer) error {
+	if target == nil {
+		return fmt.Errorf("--json requires a file or --source and --id to upload, the session picker is interactive")
+	}
+	config, err := loadConfig(opts.Profile)
+	if err != nil {
+		return err
+	}
+	if apiURL, err = resolveAPIURL(apiURL, config); err != nil {
+		return err
+	}
+	if opts.Title == "" {
+		opts.Title = target.title
 	}
 
-	// If no file was provided, show interactive selector
-	if !fileProvided {
-		selectedPath, err := selectSessionInteractively()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		filepath = selectedPath
+	resp, err := target.send(apiURL, config.Token, opts, stderr)
+	if _, ok := err.(*AuthError); ok {
+		return fmt.Errorf("%w (run 'aisessions login' to re-authenticate)", err)
 	}
+	if err != nil {
+		return err
+	}
+	result := map[string]string{"id": resp.ID, "url": resp.URL, "title": opts.Title}
+	for key, value := range target.fields {
+		result[key] = value
+	}
+	return printJSON(stdout, result)
+}
 
+// handleUploadCommand uploads a transcript file or session
+func handleUploadCommand(target uploadTarget, opts uploadOptions, apiURL string) {
 	// Load configuration
-	config, err := loadConfig()
+	config, err := loadConfig(opts.Profile)
 	if err != nil {
 		// Not authenticated - start login flow automatically
 		fmt.Println("Not authenticated. Let's set up your CLI access.")
 		fmt.Println()
-		handleLogin("")
+		handleLogin("", opts.Profile)
 
 		// Try loading config again after login
-		config, err = loadConfig()
+		config, err = loadConfig(opts.Profile)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: Failed to load configuration after login: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
-	// Determine API URL (--url flag or default)
-	finalAPIURL := getAPIURL("")
-	if apiURL != "" {
-		finalAPIURL = apiURL
+	// Determine API URL (--url flag, server logged in to, or default)
+	finalAPIURL, err := resolveAPIURL(apiURL, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
 
 	// Perform upload
-	if err := uploadFile(finalAPIURL, config.Token, filepath, title); err != nil {
+	if _, err := target.send(finalAPIURL, config.Token, opts, os.Stdout); err != nil {
 		// Check if it's an authentication error (revoked/expired token)
 		if _, ok := err.(*AuthError); ok {
 			fmt.Println()
 			fmt.Println("Your token has expired or been revoked. Let's re-authenticate.")
 			fmt.Println()
-			handleLogin("")
+			handleLogin("", opts.Profile)
 
 			fmt.Println()
 			fmt.Println("Login successful! Please run your upload command again:")
-			fmt.Printf("  aisessions upload %s", filepath)
-			if title != "" {
-				fmt.Printf(" --title \"%s\"", title)
+			fmt.Printf("  aisessions upload %s%s", target.args, profileFlag(opts.Profile))
+			if opts.Title != "" {
+				fmt.Printf(" --title \"%s\"", opts.Title)
+			}
+			if opts.NoRedact {
+				fmt.Print(" --no-redact")
+			}
+			if opts.Anonymize {
+				fmt.Print(" --anonymize")
+			}
+			if opts.Range != nil {
+				fmt.Printf(" --from %d --to %d", opts.Range.From, opts.Range.To)
 			}
 			fmt.Println()
 			os.Exit(0)
@@ -692,8 +778,9 @@ func getAPIURL(configURL string) string {
 	return defaultAPIURL
 }
 
-// loadConfig loads the configuration from disk
-func loadConfig() (*Config, error) {
+// loadConfig loads the configuration from disk, with the token and server of a profile,
+// "" being the default one
+func loadConfig(profile string) (*Config, error) {
 	configPath, err := getConfigPath()
 	if err != nil {
 		return nil, err
@@ -709,9 +796,21 @@ func loadConfig() (*Config, error) {
 		return nil, fmt.Errorf("invalid config file: %w", err)
 	}
 
-	if config.Token == "" {
+	p, ok := config.profile(profile)
+	if !ok && profile != "" {
+		return nil, fmt.Errorf("not logged in to profile %s (run 'aisessions login --profile %s')", profile, profile)
+	}
+	if p.Token == "" && p.Keychain && tokenKeychain != nil {
+		token, err := tokenKeychain.get(keychainAccountFor(profile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read token from the %s (run 'aisessions login%s'): %w", tokenKeychain.name(), profileFlag(profile), err)
+		}
+		p.Token = token
+	}
+	if p.Token == "" {
 		return nil, fmt.Errorf("config file is missing token")
 	}
+	config.Token, config.APIURL = p.Token, p.APIURL
 
 	return &config, nil
 }
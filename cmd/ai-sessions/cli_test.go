@@ -20,7 +20,7 @@ func TestValidateAPIURL(t *testing.T) {
 		"http://127.0.0.1:8080",
 	}
 	for _, url := range allowed {
-		if err := validateAPIURL(url); err != nil {
+		if err := validateAPIURL(url, nil); err != nil {
 			t.Fatalf("validateAPIURL(%q) returned error: %v", url, err)
 		}
 	}
@@ -31,12 +31,85 @@ func TestValidateAPIURL(t *testing.T) {
 		"ftp://aisessions.dev",
 	}
 	for _, url := range disallowed {
-		if err := validateAPIURL(url); err == nil {
+		if err := validateAPIURL(url, nil); err == nil {
 			t.Fatalf("validateAPIURL(%q) expected error", url)
 		}
 	}
 }
 
+func TestValidateAPIURLTrustedHosts(t *testing.T) {
+	trusted := []string{"Sessions.Example.com"}
+	if err := validateAPIURL("https://sessions.example.com", trusted); err != nil {
+		t.Fatalf("expected a trusted host to be allowed: %v", err)
+	}
+	if err := validateAPIURL("http://sessions.example.com", trusted); err == nil {
+		t.Fatal("expected a trusted host over http to be rejected")
+	}
+	err := validateAPIURL("https://evil.example.com", trusted)
+	if untrusted, ok := err.(*UntrustedHostError); !ok || untrusted.Host != "evil.example.com" {
+		t.Fatalf("expected an untrusted host error, got %v", err)
+	}
+
+	config := &Config{APIURL: "https://sessions.example.com", TrustedHosts: trusted}
+	if got, err := resolveAPIURL("", config); err != nil || got != "https://sessions.example.com" {
+		t.Fatalf("expected the server logged in to, got %q (%v)", got, err)
+	}
+	if _, err := resolveAPIURL("https://evil.example.com", config); err == nil || !strings.Contains(err.Error(), "aisessions login --url https://evil.example.com") {
+		t.Fatalf("expected an untrusted --url to be rejected, got %v", err)
+	}
+	if got, err := resolveAPIURL("", &Config{}); err != nil || got != defaultAPIURL {
+		t.Fatalf("expected the default server, got %q (%v)", got, err)
+	}
+}
+
+func TestRunLoginSelfHosted(t *testing.T) {
+	for _, answer := range []string{"y", "n"} {
+		stdout := &bytes.Buffer{}
+		stderr := &bytes.Buffer{}
+		var saved *Config
+		deps := loginDeps{
+			stdin:         strings.NewReader(answer + "\nabc.def.ghi\n"),
+			stdout:        stdout,
+			stderr:        stderr,
+			openBrowser:   func(string) error { return nil },
+			validateToken: func(string) error { return nil },
+			saveConfig:    func(cfg Config) error { saved = &cfg; return nil },
+		}
+
+		err := runLogin("https://sessions.example.com", deps)
+		if !strings.Contains(stdout.String(), "Trust sessions.example.com?") {
+			t.Fatalf("expected a confirmation prompt, got %q", stdout.String())
+		}
+		if answer == "n" {
+			if err == nil || saved != nil {
+				t.Fatal("expected declining to trust the host to cancel the login")
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("runLogin returned error: %v", err)
+		}
+		if saved == nil || saved.APIURL != "https://sessions.example.com" || len(saved.TrustedHosts) != 1 || saved.TrustedHosts[0] != "sessions.example.com" {
+			t.Fatalf("expected the server and trusted host to be saved, got %+v", saved)
+		}
+	}
+
+	// A host already trusted isn't asked about again
+	stdout := &bytes.Buffer{}
+	deps := loginDeps{
+		config:        Config{APIURL: "https://sessions.example.com", TrustedHosts: []string{"sessions.example.com"}},
+		stdin:         strings.NewReader("abc.def.ghi\n"),
+		stdout:        stdout,
+		stderr:        &bytes.Buffer{},
+		openBrowser:   func(string) error { return nil },
+		validateToken: func(string) error { return nil },
+		saveConfig:    func(Config) error { return nil },
+	}
+	if err := runLogin("", deps); err != nil || strings.Contains(stdout.String(), "Trust") {
+		t.Fatalf("expected logging in to the trusted server without a prompt, got %q (%v)", stdout.String(), err)
+	}
+}
+
 func TestValidateTokenFormat(t *testing.T) {
 	if err := validateTokenFormat("abc.def.ghi"); err != nil {
 		t.Fatalf("validateTokenFormat valid token returned error: %v", err)
@@ -205,7 +278,7 @@ func TestConfigPersistence(t *testing.T) {
 		t.Fatalf("config token mismatch: %q", stored.Token)
 	}
 
-	loaded, err := loadConfig()
+	loaded, err := loadConfig("")
 	if err != nil {
 		t.Fatalf("loadConfig failed: %v", err)
 	}
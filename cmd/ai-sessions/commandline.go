@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// cliVersion is printed by `aisessions version`
+const cliVersion = "2.0.0"
+
+// globalOptions are the persistent flags, accepted before the command or among its flags
+type globalOptions struct {
+	JSON    bool   // Print machine-readable output
+	URL     string // API URL of the commands that talk to aisessions.dev
+	Verbose bool   // Log diagnostics to stderr
+
+	AbsoluteTimes bool // Print dates and times rather than "2 hours ago"
+	UTC           bool // Print times in UTC rather than the local zone
+}
+
+// globalFlagNames are the names of the persistent flags, listed apart in command help
+var globalFlagNames = map[string]bool{"json": true, "url": true, "verbose": true, "absolute": true, "utc": true}
+
+// cliEnv is what commands run against. The adapters and the search cache are opened
+// on first use, so commands that don't need them start quickly; tests set them up front.
+type cliEnv struct {
+	stdout  io.Writer
+	stderr  io.Writer
+	options globalOptions
+
+	adapters map[string]adapters.SessionAdapter
+	cache    *search.Cache
+
+	ctx           context.Context
+	stopInterrupt func()
+}
+
+// sessionAdapters returns the adapters of every available source
+func (env *cliEnv) sessionAdapters() map[string]adapters.SessionAdapter {
+	if env.adapters == nil {
+		env.adapters = newAdapters()
+	}
+	return env.adapters
+}
+
+// searchCache returns the search cache, opening it on first use
+func (env *cliEnv) searchCache() (*search.Cache, error) {
+	if env.cache == nil {
+		cache, err := openSearchCache()
+		if err != nil {
+			return nil, err
+		}
+		env.cache = cache
+	}
+	return env.cache, nil
+}
+
+// context returns the context of a command, done when the command is interrupted with
+// Ctrl+C so it can stop cleanly. Until a command asks for it, Ctrl+C exits at once.
+func (env *cliEnv) context() context.Context {
+	if env.ctx == nil {
+		env.ctx, env.stopInterrupt = interruptContext()
+	}
+	return env.ctx
+}
+
+// close releases what the commands opened
+func (env *cliEnv) close() {
+	if env.stopInterrupt != nil {
+		env.stopInterrupt()
+	}
+	if env.cache != nil {
+		env.cache.Close()
+	}
+}
+
+// cliRunFunc runs a command with its positional arguments, once its flags are parsed
+type cliRunFunc func(env *cliEnv, args []string) error
+
+// cliCommand is a subcommand of the aisessions CLI
+type cliCommand struct {
+	name    string
+	aliases []string
+	args    string // Positional arguments, as shown in the usage line
+	summary string
+	minArgs int
+	maxArgs int  // -1 for no limit
+	json    bool // Whether the command supports --json; interactive ones don't
+
+	// setup defines the command's flags and returns the function that runs it
+	setup func(fs *flag.FlagSet) cliRunFunc
+}
+
+// cliCommands lists the CLI commands in the order `aisessions help` shows them
+var cliCommands = []*cliCommand{
+	&loginCommand,
+	&logoutCommand,
+	&uploadCommand,
+	&uploadsCommand,
+	&pickCommand,
+	&listCommand,
+	&searchCommand,
+	&searchesCommand,
+	&showCommand,
+	&replayCommand,
+	&openCommand,
+	&tagCommand,
+	&untagCommand,
+	&bookmarkCommand,
+	&tagsCommand,
+	&statsCommand,
+	&costsCommand,
+	&exportCommand,
+	&convertCommand,
+	&scanCommand,
+	&cleanupCommand,
+	&doctorCommand,
+	&versionCommand,
+}
+
+// findCLICommand returns the command called name, or nil
+func findCLICommand(name string) *cliCommand {
+	for _, cmd := range cliCommands {
+		if cmd.name == name {
+			return cmd
+		}
+		for _, alias := range cmd.aliases {
+			if alias == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// usageError is returned for command lines that don't match a command's usage
+func (c *cliCommand) usageError() error {
+	return fmt.Errorf("usage: %s (see 'aisessions %s --help')", c.usageLine(), c.name)
+}
+
+func (c *cliCommand) usageLine() string {
+	line := "aisessions " + c.name
+	if c.args != "" {
+		line += " " + c.args
+	}
+	return line + " [options]"
+}
+
+// flagSet returns the command's flags, including the persistent ones, and its run function
+func (c *cliCommand) flagSet(options *globalOptions) (*flag.FlagSet, cliRunFunc) {
+	fs := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	run := c.setup(fs)
+	fs.BoolVar(&options.JSON, "json", options.JSON, "print machine-readable JSON output")
+	fs.StringVar(&options.URL, "url", options.URL, "override the API `url` (default: "+defaultAPIURL+")")
+	fs.BoolVar(&options.Verbose, "verbose", options.Verbose, "log diagnostics to stderr")
+	fs.BoolVar(&options.AbsoluteTimes, "absolute", options.AbsoluteTimes, "print dates and times rather than relative times")
+	fs.BoolVar(&options.UTC, "utc", options.UTC, "print times in UTC, in --json output too")
+	return fs, run
+}
+
+// printHelp prints the command's usage and flags
+func (c *cliCommand) printHelp(w io.Writer) {
+	var options globalOptions
+	fs, _ := c.flagSet(&options)
+	fmt.Fprintf(w, "Usage: %s\n\n%s\n", c.usageLine(), c.summary)
+	if len(c.aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(c.aliases, ", "))
+	}
+	fmt.Fprintln(w, "\nOptions:")
+	printFlags(w, fs, func(name string) bool { return !globalFlagNames[name] })
+	fmt.Fprintln(w, "\nGlobal options:")
+	printFlags(w, fs, func(name string) bool { return globalFlagNames[name] })
+}
+
+// printFlags prints the flags selected by include, one line per flag with its aliases:
+// flags bound to the same variable are listed together, longest name first
+func printFlags(w io.Writer, fs *flag.FlagSet, include func(name string) bool) {
+	type group struct {
+		names []string
+		flag  *flag.Flag
+	}
+	var groups []*group
+	fs.VisitAll(func(f *flag.Flag) {
+		if !include(f.Name) {
+			return
+		}
+		for _, g := range groups {
+			if g.flag.Value == f.Value {
+				g.names = append(g.names, f.Name)
+				if g.flag.Usage == "" {
+					g.flag = f // Aliases have no usage of their own
+				}
+				return
+			}
+		}
+		groups = append(groups, &group{names: []string{f.Name}, flag: f})
+	})
+
+	for _, g := range groups {
+		sort.SliceStable(g.names, func(i, j int) bool { return len(g.names[i]) > len(g.names[j]) })
+		names := make([]string, len(g.names))
+		for i, name := range g.names {
+			names[i] = "--" + name
+			if len(name) == 1 {
+				names[i] = "-" + name
+			}
+		}
+		placeholder, usage := flag.UnquoteUsage(g.flag)
+		spec := strings.TrimSpace(strings.Join(names, ", ") + " " + placeholder)
+		if def := g.flag.DefValue; def != "" && def != "false" && def != "0" && !strings.HasPrefix(def, "-") {
+			usage += fmt.Sprintf(" (default %s)", g.flag.DefValue)
+		}
+		fmt.Fprintf(w, "  %-24s %s\n", spec, usage)
+	}
+}
+
+// parseInterspersed parses flags that may appear before, between, or after the
+// positional arguments, which it returns. Arguments after "--" are never flags.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printJSON writes the machine-readable output of a command
+func printJSON(w io.Writer, v interface{}) error {
+	if cliTimes.utc {
+		v = timesIn(v, time.UTC)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// runCLI runs the command line args (without the program name)
+func runCLI(env *cliEnv, args []string) error {
+	global := flag.NewFlagSet("aisessions", flag.ContinueOnError)
+	global.SetOutput(io.Discard)
+	global.BoolVar(&env.options.JSON, "json", false, "")
+	global.StringVar(&env.options.URL, "url", "", "")
+	global.BoolVar(&env.options.Verbose, "verbose", false, "")
+	global.BoolVar(&env.options.AbsoluteTimes, "absolute", false, "")
+	global.BoolVar(&env.options.UTC, "utc", false, "")
+	showHelp := global.Bool("help", false, "")
+	global.BoolVar(showHelp, "h", false, "")
+	showVersion := global.Bool("version", false, "")
+	global.BoolVar(showVersion, "v", false, "")
+	if err := global.Parse(args); err != nil {
+		return fmt.Errorf("%v (see 'aisessions help')", err)
+	}
+	args = global.Args()
+
+	switch {
+	case *showVersion:
+		return runVersion(env)
+	case *showHelp || len(args) == 0:
+		printUsage(env.stdout)
+		if len(args) == 0 && !*showHelp {
+			return errors.New("no command given")
+		}
+		return nil
+	case args[0] == "help":
+		if len(args) > 1 {
+			cmd := findCLICommand(args[1])
+			if cmd == nil {
+				return fmt.Errorf("unknown command: %s (see 'aisessions help')", args[1])
+			}
+			cmd.printHelp(env.stdout)
+			return nil
+		}
+		printUsage(env.stdout)
+		return nil
+	}
+
+	cmd := findCLICommand(args[0])
+	if cmd == nil {
+		return fmt.Errorf("unknown command: %s (see 'aisessions help')", args[0])
+	}
+	fs, run := cmd.flagSet(&env.options)
+	positional, err := parseInterspersed(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		cmd.printHelp(env.stdout)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%v (see 'aisessions %s --help')", err, cmd.name)
+	}
+	if len(positional) < cmd.minArgs || (cmd.maxArgs >= 0 && len(positional) > cmd.maxArgs) {
+		return cmd.usageError()
+	}
+	if env.options.JSON && !cmd.json {
+		return fmt.Errorf("%s does not support --json", cmd.name)
+	}
+	cliTimes = timeStyle{absolute: env.options.AbsoluteTimes, utc: env.options.UTC}
+	defer func() { cliTimes = timeStyle{} }()
+
+	// A config file that can't be read is reported by the commands that need it
+	config, _ := readConfigFile()
+	closeLog, err := setupLogging(env.stderr, cliLogOptions(env.options.Verbose, config))
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	return run(env, positional)
+}
+
+// handleCLI runs the CLI command of the process arguments and exits on failure
+func handleCLI() {
+	env := &cliEnv{stdout: os.Stdout, stderr: os.Stderr}
+	err := runCLI(env, os.Args[1:])
+	env.close()
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Interrupted")
+		os.Exit(interruptedExitCode)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+var versionCommand = cliCommand{
+	name:    "version",
+	summary: "Show version information",
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		return func(env *cliEnv, args []string) error { return runVersion(env) }
+	},
+}
+
+func runVersion(env *cliEnv) error {
+	if env.options.JSON {
+		return printJSON(env.stdout, map[string]string{"version": cliVersion})
+	}
+	fmt.Fprintf(env.stdout, "aisessions version %s\n", cliVersion)
+	return nil
+}
+
+// printUsage displays CLI usage information
+func printUsage(w io.Writer) {
+	fmt.Fprint(w, `AI Sessions CLI
+
+Usage:
+  aisessions <command> [options]
+
+Commands:
+`)
+	for _, cmd := range cliCommands {
+		line := cmd.name
+		if cmd.args != "" {
+			line += " " + cmd.args
+		}
+		if len(line) >= 18 {
+			fmt.Fprintf(w, "  %s\n  %-18s %s\n", line, "", cmd.summary)
+		} else {
+			fmt.Fprintf(w, "  %-18s %s\n", line, cmd.summary)
+		}
+	}
+	fmt.Fprint(w, `  help [command]     Show this help message, or the options of a command
+
+Global options:
+  --json             Print machine-readable JSON output
+  --url <url>        Override API URL (default: https://aisessions.dev)
+  --verbose          Log diagnostics to stderr
+
+Run 'aisessions <command> --help' for the options of a command.
+
+Examples:
+  aisessions login
+  aisessions upload session.jsonl
+  aisessions upload session.jsonl --title "Bug Fix Session"
+  aisessions uploads list
+  aisessions uploads rename abc123 --title "Auth refactor"
+  aisessions list --source codex --limit 10
+  aisessions search "flaky login test"
+  aisessions show claude 4f9c2a --role user,assistant
+  aisessions open claude 4f9c2a --reveal
+  aisessions tag claude 4f9c2a postmortem
+  aisessions tags postmortem
+  aisessions stats --since 2w
+  aisessions costs --since 7d
+  aisessions export claude 4f9c2a --format md > session.md
+  aisessions export claude 4f9c2a --format html --output session.html
+  aisessions export --project ~/work/app --since 30d --out archive/
+  aisessions convert codex 0199a1b2 --to claude --install
+  aisessions doctor
+
+  # Development mode (use local server)
+  aisessions login --url http://localhost:3000
+  aisessions upload session.jsonl --url http://localhost:3000
+
+Authentication:
+  1. Visit https://aisessions.dev/my-transcripts
+  2. Click "Generate CLI Token"
+  3. Run 'aisessions login' and paste the token
+
+For more information, visit: https://github.com/yoavf/ai-sessions-mcp
+`)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// runTestCLI runs a command line against the given adapters and search cache
+func runTestCLI(adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, stdout io.Writer, args ...string) error {
+	env := &cliEnv{stdout: stdout, stderr: io.Discard, adapters: adaptersMap, cache: cache}
+	return runCLI(env, args)
+}
+
+func TestRunCLIParsesFlags(t *testing.T) {
+	stub := newStubAdapter([]adapters.Session{{ID: "s1", Source: "claude"}, {ID: "s2", Source: "claude"}}, nil)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	// Persistent flags are accepted before the command, and flags after positional arguments
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "--json", "list", "--limit=1"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(out.String()), "[") || strings.Contains(out.String(), "s2") {
+		t.Fatalf("expected one session as JSON, got:\n%s", out.String())
+	}
+
+	for _, args := range [][]string{
+		{"list", "--limt", "1"},    // Unknown flag
+		{"list", "--limit"},        // Missing value
+		{"list", "--limit", "ten"}, // Invalid value
+		{"list", "extra"},          // Unexpected argument
+		{"show", "claude"},         // Missing argument
+		{"login", "--json"},        // Interactive
+		{"frobnicate"},
+	} {
+		if err := runTestCLI(adaptersMap, nil, &out, args...); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	var opts globalOptions
+	fs, _ := exportCommand.flagSet(&opts)
+	args, err := parseInterspersed(fs, []string{"claude", "--format", "html", "s1", "--json", "--", "--not-a-flag"})
+	if err != nil {
+		t.Fatalf("parseInterspersed failed: %v", err)
+	}
+	if strings.Join(args, " ") != "claude s1 --not-a-flag" || fs.Lookup("format").Value.String() != "html" || !opts.JSON {
+		t.Fatalf("unexpected parse: args %v, format %s, json %v", args, fs.Lookup("format").Value, opts.JSON)
+	}
+}
+
+func TestRunCLIHelp(t *testing.T) {
+	var out bytes.Buffer
+	if err := runTestCLI(nil, nil, &out, "export", "--help"); err != nil {
+		t.Fatalf("export --help failed: %v", err)
+	}
+	help := out.String()
+	for _, want := range []string{"Usage: aisessions export [<source> <id>] [options]", "--output, --out, -o file", "--format format", "(default md)", "Global options:", "--verbose"} {
+		if !strings.Contains(help, want) {
+			t.Errorf("expected %q in help:\n%s", want, help)
+		}
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, nil, &out, "help"); err != nil {
+		t.Fatalf("help failed: %v", err)
+	}
+	for _, cmd := range cliCommands {
+		if !strings.Contains(out.String(), "  "+cmd.name) {
+			t.Errorf("expected %s in usage:\n%s", cmd.name, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, nil, &out, "help", "config"); err != nil || !strings.Contains(out.String(), "aisessions login") {
+		t.Fatalf("expected help of the login command for its alias, got %q (%v)", out.String(), err)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Tool 7: get_session_commands
+type getSessionCommandsArgs struct {
+	SessionID  string `json:"session_id" jsonschema:"The session ID to inspect"`
+	Source     string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	FailedOnly bool   `json:"failed_only,omitempty" jsonschema:"Only return commands that exited with an error"`
+}
+
+func addGetSessionCommandsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_session_commands",
+		Description: "List the shell commands executed during a session, in order, with their exit status and a short excerpt of their output",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionCommandsArgs) (*mcp.CallToolResult, any, error) {
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		commands := []analysis.CommandRun{}
+		failed := 0
+		for _, run := range analysis.Commands(messages) {
+			if run.IsError {
+				failed++
+			} else if args.FailedOnly {
+				continue
+			}
+			commands = append(commands, run)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"commands":   commands,
+			"count":      len(commands),
+			"failed":     failed,
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Tool 23: find_sessions_by_commit
+type findSessionsByCommitArgs struct {
+	Commit      string `json:"commit,omitempty" jsonschema:"Commit hash to look up, full or abbreviated (at least 4 characters)"`
+	Branch      string `json:"branch,omitempty" jsonschema:"Optional: find the sessions that worked on or committed to this branch instead, or also match it"`
+	Source      string `json:"source,omitempty" jsonschema:"Optional: filter by source (claude, gemini, codex, opencode)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Optional: filter by project: a path, a glob like '~/work/*', or a directory name"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return (default: 10)"`
+}
+
+func addFindSessionsByCommitTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_sessions_by_commit",
+		Description: "Find the sessions that created a git commit, read from the output of the git commands they ran, or the sessions that worked on a branch, newest first",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args findSessionsByCommitArgs) (*mcp.CallToolResult, any, error) {
+		if args.Commit == "" && args.Branch == "" {
+			return nil, nil, fmt.Errorf("commit or branch is required")
+		}
+		if args.Limit == 0 {
+			args.Limit = 10
+		}
+
+		// Commits are indexed alongside the search index
+		if _, err := indexSessions(ctx, adaptersMap, searchCache, args.Source, args.ProjectPath); err != nil {
+			slog.Warn("Failed to index sessions", "error", err)
+		}
+
+		matches, err := searchCache.FindSessionsByCommit(search.CommitSearchOptions{
+			Hash:        args.Commit,
+			Branch:      args.Branch,
+			Source:      args.Source,
+			ProjectPath: args.ProjectPath,
+			Limit:       args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("commit lookup failed: %w", err)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"commit":   args.Commit,
+			"branch":   args.Branch,
+			"sessions": matches,
+			"count":    len(matches),
+		})
+	})
+}
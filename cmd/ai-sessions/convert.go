@@ -0,0 +1,124 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+)
+
+var convertCommand = cliCommand{
+	name:    "convert",
+	args:    "<source> <id>",
+	summary: "Convert a session to Claude Code or Codex's format",
+	minArgs: 2,
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts convertOptions
+		fs.StringVar(&opts.Target, "to", "", "the `agent` whose format to convert to: claude or codex")
+		fs.StringVar(&opts.Output, "output", "", "write to `file` instead of stdout")
+		fs.StringVar(&opts.Output, "o", "", "")
+		fs.BoolVar(&opts.Install, "install", false, "write the session where the agent finds it, so it can be resumed")
+		fs.BoolVar(&opts.Force, "force", false, "with --install, replace a session converted earlier")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			return runConvertCommand(env.sessionAdapters(), args[0], args[1], opts, env.stdout)
+		}
+	},
+}
+
+// convertOptions are the options of `aisessions convert`
+type convertOptions struct {
+	Target  string
+	Output  string
+	Install bool
+	Force   bool // Replace an installed session, which may have been resumed since
+	JSON    bool
+}
+
+func runConvertCommand(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string, opts convertOptions, stdout io.Writer) error {
+	if (opts.Target != "claude" && opts.Target != "codex") || (opts.Install && opts.Output != "") || (opts.Force && !opts.Install) {
+		return fmt.Errorf("usage: aisessions convert <source> <id> --to claude|codex [--output file | --install [--force]]")
+	}
+
+	messages, err := loadSessionMessages(adaptersMap, source, sessionID)
+	if err != nil {
+		return err
+	}
+	session := findListedSession(adaptersMap, source, sessionID)
+	content, err := export.Render(opts.Target, session, messages)
+	if err != nil {
+		return err
+	}
+
+	output := opts.Output
+	if opts.Install {
+		// Place the session where the target agent looks for it, so it can be resumed
+		started := session.Timestamp
+		if len(messages) > 0 && started.IsZero() {
+			started = messages[0].Timestamp
+		}
+		output, err = adapters.SessionFilePath(opts.Target, session.ProjectPath, export.ConvertedSessionID(session), started)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(output), 0o700); err != nil {
+			return fmt.Errorf("failed to create session directory: %w", err)
+		}
+	}
+	result := map[string]interface{}{
+		"session_id":           sessionID,
+		"source":               source,
+		"target":               opts.Target,
+		"converted_session_id": export.ConvertedSessionID(session),
+	}
+	if output == "" {
+		if opts.JSON {
+			result["content"] = content
+			return printJSON(stdout, result)
+		}
+		_, err = io.WriteString(stdout, content)
+		return err
+	}
+	if err := writeConvertedSession(output, content, opts.Install && !opts.Force); err != nil {
+		return err
+	}
+	if opts.JSON {
+		result["output"] = output
+		return printJSON(stdout, result)
+	}
+	fmt.Fprintf(stdout, "Converted %s session %s to %s session %s: %s\n", source, sessionID, opts.Target, export.ConvertedSessionID(session), output)
+	return nil
+}
+
+// writeConvertedSession writes a converted session to path. With exclusive set, an
+// existing file is left alone: an installed session keeps its ID when converted again,
+// and the copy there may have been resumed and extended in the target agent.
+func writeConvertedSession(path, content string, exclusive bool) error {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if exclusive {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o600)
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("converted session already exists: %s (use --force to replace it)", path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write converted session: %w", err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write converted session: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write converted session: %w", err)
+	}
+	return nil
+}
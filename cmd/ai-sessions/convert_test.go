@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+)
+
+// installedClaudeSession returns the only session file installed under ~/.claude/projects
+func installedClaudeSession(t *testing.T, home string) string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(home, ".claude", "projects", "*", "*.jsonl"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected one installed session, got %v (%v)", files, err)
+	}
+	return files[0]
+}
+
+func TestRunConvertCommandInstallsSession(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+
+	projectPath := t.TempDir()
+	session := adapters.Session{ID: "rollout-1", Source: "codex", ProjectPath: projectPath, Timestamp: time.Now()}
+	stub := newStubAdapter(
+		[]adapters.Session{session},
+		map[string][]adapters.Message{"rollout-1": {{Role: "user", Content: "Fix the build"}, {Role: "assistant", Content: "Fixed."}}},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"codex": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "convert", "codex", "rollout-1", "--to", "claude", "--install"); err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+
+	claude, err := adapters.NewAdapterAt("claude", filepath.Join(tempHome, ".claude"))
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	messages, err := claude.GetSession(export.ConvertedSessionID(session), 0, 10)
+	if err != nil || len(messages) != 2 || messages[0].Content != "Fix the build" {
+		t.Fatalf("expected Claude to read the installed session, got %+v (%v)\n%s", messages, err, out.String())
+	}
+
+	// Installing again doesn't replace the copy, which may have been resumed, without --force
+	installed, err := os.ReadFile(installedClaudeSession(t, tempHome))
+	if err != nil {
+		t.Fatalf("read installed session: %v", err)
+	}
+	resumed := append(installed, []byte(`{"type":"user","message":{"role":"user","content":"continued"}}`+"\n")...)
+	if err := os.WriteFile(installedClaudeSession(t, tempHome), resumed, 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	err = runTestCLI(adaptersMap, nil, &out, "convert", "codex", "rollout-1", "--to", "claude", "--install")
+	if err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("expected installing over an existing session to fail, got %v", err)
+	}
+	if data, _ := os.ReadFile(installedClaudeSession(t, tempHome)); !bytes.Equal(data, resumed) {
+		t.Fatal("the installed session was modified")
+	}
+	if err := runTestCLI(adaptersMap, nil, &out, "convert", "codex", "rollout-1", "--to", "claude", "--install", "--force"); err != nil {
+		t.Fatalf("convert --force failed: %v", err)
+	}
+	if data, _ := os.ReadFile(installedClaudeSession(t, tempHome)); !bytes.Equal(data, installed) {
+		t.Fatal("expected --force to replace the installed session")
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "convert", "codex", "rollout-1", "--to", "claude"); err != nil {
+		t.Fatalf("convert failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"sessionId":"`+export.ConvertedSessionID(session)+`"`) {
+		t.Fatalf("expected the converted session on stdout, got %s", out.String())
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "convert", "codex", "rollout-1", "--to", "gemini"); err == nil {
+		t.Fatal("expected an error for an unsupported target")
+	}
+	if _, err := os.Stat(filepath.Join(tempHome, ".gemini")); err == nil {
+		t.Fatal("nothing should be written for an unsupported target")
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Tool 18: session_costs
+type sessionCostsArgs struct {
+	Source      string `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Filter by project: a path (absolute or relative), a glob like '~/work/*', or a directory name like 'ai-sessions-mcp'. Leave empty for all projects."`
+	Since       string `json:"since,omitempty" jsonschema:"Only include sessions started after this point: a relative window like '7d', '2w', '12h', a date like '2025-01-31', or 'all' (default: 30d)"`
+}
+
+func addSessionCostsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "session_costs",
+		Description: "Summarize AI spend by day, project, and model. Uses the cost recorded by the agent (opencode) or estimates it from token usage and model list prices (Claude, Codex, Gemini).",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args sessionCostsArgs) (*mcp.CallToolResult, any, error) {
+		if args.Since == "" {
+			args.Since = defaultStatsWindow
+		}
+		since, err := parseSince(args.Since, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		report, err := buildCostReport(adaptersMap, args.Source, args.ProjectPath, since)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := map[string]interface{}{
+			"since":    args.Since,
+			"costs":    report,
+			"currency": "USD",
+		}
+		if !since.IsZero() {
+			result["since_time"] = since
+		}
+		return jsonToolResult(result)
+	})
+}
+
+// buildCostReport aggregates the spend of every matching session started at or after since.
+func buildCostReport(adaptersMap map[string]adapters.SessionAdapter, source, projectPath string, since time.Time) (analysis.CostReport, error) {
+	sessions, err := collectSessions(adaptersMap, source, projectPath)
+	if err != nil {
+		return analysis.CostReport{}, err
+	}
+
+	costs := analysis.NewCosts(cliTimes.location())
+	for _, session := range sessions {
+		if session.Timestamp.Before(since) {
+			continue
+		}
+		messages, err := readSession(adaptersMap, session)
+		if err != nil {
+			slog.Warn("Failed to read session", "session", session.ID, "error", err)
+			continue
+		}
+		costs.Add(session, messages)
+	}
+	return costs.Report(), nil
+}
+
+var costsCommand = cliCommand{
+	name:    "costs",
+	json:    true,
+	summary: "Summarize spend by model, project, and day",
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts reportOptions
+		fs.StringVar(&opts.Since, "since", defaultStatsWindow, "only include sessions since a `window` like 30d, 2w, or 12h, a date, or 'all'")
+		fs.StringVar(&opts.Source, "source", "", "only include sessions of this `source`")
+		fs.StringVar(&opts.ProjectPath, "project", "", "only include sessions of a `project`: a path, a glob, or a directory name")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			return runCostsCommand(env.sessionAdapters(), opts, env.stdout)
+		}
+	},
+}
+
+func runCostsCommand(adaptersMap map[string]adapters.SessionAdapter, opts reportOptions, stdout io.Writer) error {
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+	report, err := buildCostReport(adaptersMap, opts.Source, opts.ProjectPath, since)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		output := map[string]interface{}{
+			"since":    opts.Since,
+			"costs":    report,
+			"currency": "USD",
+		}
+		if !since.IsZero() {
+			output["since_time"] = since
+		}
+		return printJSON(stdout, output)
+	}
+	printCostReport(stdout, opts.Since, report)
+	return nil
+}
+
+// printCostReport writes a cost report as plain text tables.
+func printCostReport(w io.Writer, since string, report analysis.CostReport) {
+	total := report.Total
+	fmt.Fprintf(w, "Spend (since %s): $%.2f across %d sessions, %d tokens\n", since, total.Cost, total.Sessions, total.Usage.Total())
+	if total.EstimatedCost > 0 {
+		fmt.Fprintf(w, "  $%.2f of this is estimated from model list prices\n", total.EstimatedCost)
+	}
+
+	sections := []struct {
+		title   string
+		buckets []analysis.CostBucket
+	}{
+		{"By model", report.ByModel},
+		{"By project", report.ByProject},
+		{"By day", report.ByDay},
+	}
+	for _, section := range sections {
+		if len(section.buckets) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for _, b := range section.buckets {
+			fmt.Fprintf(w, "  %-50s $%9.2f  %4d sessions  %12d tokens\n", b.Key, b.Cost, b.Sessions, b.Usage.Total())
+		}
+	}
+
+	if len(report.UnpricedModels) > 0 {
+		fmt.Fprintf(w, "\nNo price known for: %v (their usage is counted at $0)\n", report.UnpricedModels)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunCostsCommand(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	stub := newStubAdapter(
+		[]adapters.Session{
+			{ID: "s1", Source: "opencode", ProjectPath: "/work/app", Timestamp: recent},
+			{ID: "old", Source: "opencode", ProjectPath: "/work/app", Timestamp: recent.AddDate(0, -3, 0)},
+		},
+		map[string][]adapters.Message{
+			"s1": {{Role: "assistant", Timestamp: recent, Metadata: map[string]interface{}{
+				"model": "claude-sonnet-4", "cost": 1.5,
+				"tokens": map[string]interface{}{"input": 1000.0, "output": 200.0},
+			}}},
+			"old": {{Role: "assistant", Metadata: map[string]interface{}{"cost": 9.0}}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"opencode": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "costs", "--since", "7d"); err != nil {
+		t.Fatalf("costs failed: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "$1.50 across 1 sessions") || !strings.Contains(text, "claude-sonnet-4") || !strings.Contains(text, "/work/app") {
+		t.Fatalf("unexpected report:\n%s", text)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "costs", "--since"); err == nil {
+		t.Fatal("expected error for missing option value")
+	}
+	if err := runTestCLI(adaptersMap, nil, &out, "costs", "--source", "nope"); err == nil {
+		t.Fatal("expected error for unknown source")
+	}
+}
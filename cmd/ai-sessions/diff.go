@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// defaultDiffTimelineEvents is the number of timeline events kept per session by default
+const defaultDiffTimelineEvents = 40
+
+// Tool 11: diff_sessions
+type diffSessionsArgs struct {
+	SessionA  string `json:"session_a" jsonschema:"The first session ID"`
+	SourceA   string `json:"source_a" jsonschema:"The source of the first session (claude, gemini, codex, opencode)"`
+	SessionB  string `json:"session_b" jsonschema:"The second session ID"`
+	SourceB   string `json:"source_b" jsonschema:"The source of the second session (claude, gemini, codex, opencode)"`
+	MaxEvents int    `json:"max_events,omitempty" jsonschema:"Maximum timeline events per session (default: 40)"`
+}
+
+func addDiffSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "diff_sessions",
+		Description: "Compare two sessions (from the same or different sources): overlapping files, shared and distinct topics, and a side-by-side timeline of prompts, commands, and file changes",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args diffSessionsArgs) (*mcp.CallToolResult, any, error) {
+		messagesA, err := loadSessionMessages(adaptersMap, args.SourceA, args.SessionA)
+		if err != nil {
+			return nil, nil, fmt.Errorf("session_a: %w", err)
+		}
+		messagesB, err := loadSessionMessages(adaptersMap, args.SourceB, args.SessionB)
+		if err != nil {
+			return nil, nil, fmt.Errorf("session_b: %w", err)
+		}
+
+		if args.MaxEvents == 0 {
+			args.MaxEvents = defaultDiffTimelineEvents
+		}
+
+		// Topic comparison uses the search index
+		for _, source := range []string{args.SourceA, args.SourceB} {
+			if _, err := indexSessions(ctx, adaptersMap, searchCache, source, ""); err != nil {
+				slog.Warn("Failed to index sessions", "error", err)
+			}
+		}
+		topics, err := searchCache.CompareSessions(args.SessionA, args.SessionB, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to compare topics: %w", err)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_a": map[string]interface{}{"session_id": args.SessionA, "source": args.SourceA, "messages": len(messagesA)},
+			"session_b": map[string]interface{}{"session_id": args.SessionB, "source": args.SourceB, "messages": len(messagesB)},
+			"files":     analysis.CompareFiles(analysis.FileActivities(messagesA), analysis.FileActivities(messagesB)),
+			"topics":    topics,
+			"timeline": analysis.SideBySide(
+				analysis.Timeline(messagesA, args.MaxEvents),
+				analysis.Timeline(messagesB, args.MaxEvents),
+			),
+		})
+	})
+}
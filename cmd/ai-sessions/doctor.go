@@ -0,0 +1,354 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Results of a doctor check
+const (
+	checkOK      = "ok"
+	checkSkipped = "skipped" // Not applicable, such as a disabled source
+	checkWarning = "warning" // Likely fine, but worth a look
+	checkFailed  = "error"
+)
+
+// doctorTimeout bounds the request checking the login token
+const doctorTimeout = 10 * time.Second
+
+// doctorCheck is the result of one check of `aisessions doctor`
+type doctorCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Message  string `json:"message"`
+	Fix      string `json:"fix,omitempty"`      // What to do about a failed check
+	Sessions *int   `json:"sessions,omitempty"` // Sessions found, for source checks
+}
+
+// doctorReport is the output of `aisessions doctor`
+type doctorReport struct {
+	Checks   []doctorCheck `json:"checks"`
+	Problems int           `json:"problems"` // Failed checks
+}
+
+var doctorCommand = cliCommand{
+	name:    "doctor",
+	summary: "Check sources, the search cache, and the login for problems",
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts doctorOptions
+		fs.BoolVar(&opts.Offline, "offline", false, "don't contact the server to check the login token")
+		profileFlagVar(fs, &opts.Profile)
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			opts.URL = env.options.URL
+			return runDoctorCommand(opts, env.stdout)
+		}
+	},
+}
+
+// doctorOptions are the options of `aisessions doctor`
+type doctorOptions struct {
+	Offline bool   // Check the token's format only
+	Profile string // Login profile to check; empty for the default one
+	URL     string // API URL overriding the profile's
+	JSON    bool
+}
+
+// runDoctorCommand checks the setup and prints what is wrong and how to fix it. It
+// fails when a check does, so scripts can rely on its exit status.
+func runDoctorCommand(opts doctorOptions, stdout io.Writer) error {
+	var checks []doctorCheck
+	config, err := readConfigFile()
+	if err != nil {
+		checks = append(checks, doctorCheck{
+			Name:    "config",
+			Status:  checkFailed,
+			Message: err.Error(),
+			Fix:     "fix or delete ~/.aisessions/config.json",
+		})
+	} else {
+		checks = append(checks, checkConfigFile())
+		checks = append(checks, checkSources()...)
+		checks = append(checks, checkSearchCache(config))
+		checks = append(checks, checkLogin(opts))
+	}
+
+	report := doctorReport{Checks: checks}
+	for _, check := range checks {
+		if check.Status == checkFailed {
+			report.Problems++
+		}
+	}
+
+	if opts.JSON {
+		if err := printJSON(stdout, report); err != nil {
+			return err
+		}
+	} else {
+		printDoctorReport(stdout, report)
+	}
+	if report.Problems > 0 {
+		return fmt.Errorf("doctor found %d problem(s)", report.Problems)
+	}
+	return nil
+}
+
+// checkConfigFile reports the config file in use, once it has been read successfully
+func checkConfigFile() doctorCheck {
+	check := doctorCheck{Name: "config", Status: checkOK}
+	path, err := getConfigPath()
+	if err != nil {
+		check.Status, check.Message = checkFailed, err.Error()
+		return check
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		check.Message = "no config file; using the defaults"
+	} else {
+		check.Message = path + " is valid"
+	}
+	return check
+}
+
+func printDoctorReport(w io.Writer, report doctorReport) {
+	marks := map[string]string{
+		checkOK:      "\033[32m✓\033[0m",
+		checkSkipped: "-",
+		checkWarning: "\033[33m⚠\033[0m",
+		checkFailed:  "\033[31m✗\033[0m",
+	}
+	for _, check := range report.Checks {
+		fmt.Fprintf(w, "%s %-16s %s\n", marks[check.Status], check.Name, check.Message)
+		if check.Fix != "" {
+			fmt.Fprintf(w, "  %-16s fix: %s\n", "", check.Fix)
+		}
+	}
+	if report.Problems == 0 {
+		fmt.Fprintln(w, "\nNo problems found")
+	}
+}
+
+// sourceDirHints tell where to point a source whose data isn't in its default directory
+var sourceDirHints = map[string]string{
+	"claude":   "set CLAUDE_CONFIG_DIR",
+	"codex":    "set CODEX_HOME",
+	"opencode": "set XDG_DATA_HOME",
+}
+
+// checkSources checks that every enabled source has a session directory, and counts the
+// sessions it finds
+func checkSources() []doctorCheck {
+	selection, err := configuredSources()
+	if err != nil {
+		return []doctorCheck{{Name: "sources", Status: checkFailed, Message: err.Error(), Fix: "fix sources and disabled_sources in ~/.aisessions/config.json"}}
+	}
+	adaptersMap, statuses := openAdapters(selection, configuredMachines())
+
+	checks := make([]doctorCheck, 0, len(statuses))
+	for _, status := range statuses {
+		check := doctorCheck{Name: "source " + status.Source}
+		switch status.Status {
+		case sourceDisabled:
+			check.Status, check.Message = checkSkipped, "disabled in ~/.aisessions/config.json"
+		case sourceUnavailable:
+			check.Status, check.Message = checkFailed, status.Error
+			check.Fix = fmt.Sprintf("disable %s with \"disabled_sources\" in ~/.aisessions/config.json if you don't use it", status.Source)
+		default:
+			check = checkSource(status.Source, adaptersMap[status.Source])
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+func checkSource(source string, adapter adapters.SessionAdapter) doctorCheck {
+	check := doctorCheck{Name: "source " + source}
+	dirs, err := adapters.SessionDirs(source)
+	if err != nil {
+		check.Status, check.Message = checkFailed, err.Error()
+		return check
+	}
+	var found []string
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && info.IsDir():
+			found = append(found, dir)
+		case err == nil:
+			check.Status, check.Message = checkFailed, dir+" is not a directory"
+			return check
+		case !os.IsNotExist(err):
+			check.Status, check.Message = checkFailed, fmt.Sprintf("can't read %s: %v", dir, err)
+			check.Fix = "check the permissions of " + dir
+			return check
+		}
+	}
+
+	sessions, err := adapter.ListSessions("", 0)
+	if err != nil {
+		check.Status, check.Message = checkFailed, fmt.Sprintf("failed to list sessions: %v", err)
+		check.Fix = "run 'aisessions --verbose list --source " + source + "' for details"
+		return check
+	}
+	count := len(sessions)
+	check.Sessions = &count
+
+	switch {
+	case len(found) == 0 && count == 0:
+		check.Status = checkWarning
+		check.Message = "no session directory found (looked in " + strings.Join(dirs, ", ") + ")"
+		check.Fix = fmt.Sprintf("run %s once to create it", getAgentDisplayName(source))
+		if hint := sourceDirHints[source]; hint != "" {
+			check.Fix += ", or " + hint + " if it keeps its data elsewhere"
+		}
+		check.Fix += fmt.Sprintf("; if you don't use it, add %q to \"disabled_sources\" in ~/.aisessions/config.json", source)
+	case count == 0:
+		check.Status = checkWarning
+		check.Message = "no sessions in " + strings.Join(found, ", ")
+		check.Fix = fmt.Sprintf("start a session with %s, and check that its project isn't listed in ~/.aisessions/ignore or a %s file", getAgentDisplayName(source), adapters.IgnoreFileName)
+	default:
+		check.Status = checkOK
+		check.Message = fmt.Sprintf("%d sessions", count)
+		if len(found) > 0 {
+			check.Message += " in " + strings.Join(found, ", ")
+		}
+	}
+	return check
+}
+
+// checkSearchCache checks that the search cache opens and isn't corrupt. A missing cache
+// is fine: it is built by the first search.
+func checkSearchCache(config Config) doctorCheck {
+	check := doctorCheck{Name: "search cache"}
+	if config.InMemoryIndex {
+		check.Status, check.Message = checkOK, "kept in memory (in_memory_index); each process builds its own"
+		return check
+	}
+	path, err := cacheFilePath("search.db")
+	if err != nil {
+		check.Status, check.Message = checkFailed, err.Error()
+		return check
+	}
+	if config.EncryptCache {
+		path = encryptedCachePath(path)
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		check.Status, check.Message = checkOK, "not built yet; the first search builds it"
+		return check
+	}
+	deleteFix := fmt.Sprintf("delete %s; it is rebuilt by the next search, but its tags and notes are lost", path)
+	if err != nil {
+		check.Status, check.Message, check.Fix = checkFailed, fmt.Sprintf("can't read %s: %v", path, err), "check the permissions of "+path
+		return check
+	}
+
+	var cache *search.Cache
+	if config.EncryptCache {
+		var key []byte
+		if key, err = searchCacheKey(); err == nil {
+			cache, err = search.NewEncryptedCache(path, key)
+		}
+	} else {
+		cache, err = search.NewCache(path)
+	}
+	if err != nil {
+		check.Status, check.Message, check.Fix = checkFailed, fmt.Sprintf("can't open %s: %v", path, err), deleteFix
+		switch {
+		case errors.Is(err, search.ErrWrongKey):
+			check.Fix = "restore the search cache key in the system keychain, or " + deleteFix
+		case errors.Is(err, search.ErrSchemaTooNew):
+			check.Fix = "upgrade aisessions, or " + deleteFix
+		}
+		return check
+	}
+	defer cache.Close()
+
+	health, err := cache.Health()
+	if err != nil {
+		check.Status, check.Message, check.Fix = checkFailed, err.Error(), deleteFix
+		return check
+	}
+	if len(health.Problems) > 0 {
+		check.Status, check.Fix = checkFailed, deleteFix
+		check.Message = fmt.Sprintf("%s is corrupt: %s", path, strings.Join(health.Problems, "; "))
+		return check
+	}
+	check.Status = checkOK
+	check.Message = fmt.Sprintf("%d sessions indexed in %s (%s, schema version %d)", health.Sessions, path, formatFileSize(info.Size()), health.SchemaVersion)
+	return check
+}
+
+// checkLogin checks that the token used for uploads is well formed and accepted by the
+// server. Not being logged in is fine: only uploads need it.
+func checkLogin(opts doctorOptions) doctorCheck {
+	check := doctorCheck{Name: "login"}
+	login := "aisessions login" + profileFlag(opts.Profile)
+	config, err := readConfigFile()
+	if err != nil {
+		check.Status, check.Message = checkFailed, err.Error()
+		return check
+	}
+	if _, ok := config.profile(opts.Profile); !ok {
+		check.Status, check.Message = checkSkipped, "not logged in; only needed to upload sessions"
+		check.Fix = fmt.Sprintf("run '%s' to upload sessions", login)
+		return check
+	}
+
+	loaded, err := loadConfig(opts.Profile)
+	if err == nil {
+		err = validateTokenFormat(loaded.Token)
+	}
+	if err != nil {
+		check.Status, check.Message, check.Fix = checkFailed, err.Error(), fmt.Sprintf("run '%s' again", login)
+		return check
+	}
+	apiURL, err := resolveAPIURL(opts.URL, loaded)
+	if err != nil {
+		check.Status, check.Message = checkFailed, err.Error()
+		return check
+	}
+	if opts.Offline {
+		check.Status, check.Message = checkOK, "token is well formed (not checked with "+apiURL+")"
+		return check
+	}
+
+	client, err := newUploadClient(apiURL, loaded.Token, doctorTimeout, 0)
+	if err == nil {
+		_, err = client.listTranscripts()
+	}
+	var authErr *AuthError
+	switch {
+	case errors.As(err, &authErr):
+		check.Status, check.Message = checkFailed, fmt.Sprintf("%s rejected the token: %v", apiURL, err)
+		check.Fix = fmt.Sprintf("run '%s' again to get a new token", login)
+	case err != nil:
+		check.Status, check.Message = checkWarning, fmt.Sprintf("couldn't reach %s: %v", apiURL, err)
+		check.Fix = "check your network connection, and the proxy and ca_bundle settings in ~/.aisessions/config.json"
+	default:
+		check.Status, check.Message = checkOK, "token accepted by "+apiURL
+	}
+	return check
+}
+
+// formatFileSize formats a size in bytes for display
+func formatFileSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", size)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunDoctorCommand(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	t.Setenv("CLAUDE_CONFIG_DIR", "")
+	t.Setenv("CODEX_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+
+	projectDir := filepath.Join(tempHome, ".claude", "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	line := `{"type":"user","uuid":"u1","cwd":"/work/app","timestamp":"2025-01-01T10:00:00Z","message":{"role":"user","content":"fix the login bug"}}`
+	if err := os.WriteFile(filepath.Join(projectDir, "s1.jsonl"), []byte(line+"\n"), 0o644); err != nil {
+		t.Fatalf("write session: %v", err)
+	}
+
+	validToken := "abc.def.ghi"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unauthorized"})
+			return
+		}
+		json.NewEncoder(w).Encode(transcriptList{})
+	}))
+	defer server.Close()
+	if err := saveConfig(Config{Token: validToken, APIURL: server.URL, DisabledSources: []string{"gemini"}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	run := func() (doctorReport, error) {
+		var out strings.Builder
+		err := runDoctorCommand(doctorOptions{JSON: true}, &out)
+		var report doctorReport
+		if jsonErr := json.Unmarshal([]byte(out.String()), &report); jsonErr != nil {
+			t.Fatalf("invalid JSON output %q: %v", out.String(), jsonErr)
+		}
+		return report, err
+	}
+	statuses := func(report doctorReport) map[string]doctorCheck {
+		checks := make(map[string]doctorCheck)
+		for _, check := range report.Checks {
+			checks[check.Name] = check
+		}
+		return checks
+	}
+
+	report, err := run()
+	if err != nil || report.Problems != 0 {
+		t.Fatalf("expected no problems, got %+v (%v)", report, err)
+	}
+	checks := statuses(report)
+	if claude := checks["source claude"]; claude.Status != checkOK || claude.Sessions == nil || *claude.Sessions != 1 {
+		t.Errorf("expected one claude session, got %+v", claude)
+	}
+	if checks["source gemini"].Status != checkSkipped {
+		t.Errorf("expected gemini to be skipped, got %+v", checks["source gemini"])
+	}
+	if codex := checks["source codex"]; codex.Status != checkWarning || !strings.Contains(codex.Fix, "CODEX_HOME") {
+		t.Errorf("expected a missing codex directory to be a warning with a fix, got %+v", codex)
+	}
+	if checks["search cache"].Status != checkOK || checks["login"].Status != checkOK {
+		t.Errorf("expected the cache and login to be fine, got %+v and %+v", checks["search cache"], checks["login"])
+	}
+
+	validToken = "other.token.value"
+	report, err = run()
+	if err == nil || report.Problems != 1 {
+		t.Fatalf("expected a rejected token to be a problem, got %+v (%v)", report, err)
+	}
+	if login := statuses(report)["login"]; login.Status != checkFailed || !strings.Contains(login.Fix, "aisessions login") {
+		t.Errorf("expected the fix to be logging in again, got %+v", login)
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// collapseDuplicates collapses copies of a session into one entry (see
+// adapters.CollapseDuplicates). Copies with different IDs are found by the content
+// hashes of the sessions that are indexed; without a cache only IDs are compared.
+func collapseDuplicates(searchCache *search.Cache, sessions []adapters.Session) []adapters.Session {
+	if searchCache != nil {
+		ids := make([]string, len(sessions))
+		for i, session := range sessions {
+			ids[i] = session.ID
+		}
+		hashes, err := searchCache.ContentHashes(ids)
+		if err != nil {
+			slog.Warn("Failed to load content hashes", "error", err)
+		}
+		for i := range sessions {
+			sessions[i].ContentHash = hashes[sessions[i].ID]
+		}
+	}
+	return adapters.CollapseDuplicates(sessions)
+}
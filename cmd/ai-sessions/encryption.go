@@ -0,0 +1,136 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// cacheKeyAccount is the keychain account the search cache's encryption key is kept in
+const cacheKeyAccount = "cache-key"
+
+// openCacheFile opens the search cache called name in ~/.cache/ai-sessions. When the
+// config file sets encrypt_cache, the cache is encrypted with a key kept in the system
+// keychain and replaces the plaintext one, keeping its tags and notes. With
+// metadata_only_index, it doesn't store the text of sessions. With in_memory_index, a
+// new cache is created in memory instead, and nothing is written to disk.
+func openCacheFile(name string) (*search.Cache, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	var cache *search.Cache
+	if config.InMemoryIndex {
+		cache, err = search.NewMemoryCache()
+	} else {
+		var path string
+		if path, err = cacheFilePath(name); err == nil {
+			cache, err = openConfiguredCache(path, config)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.SetMetadataOnly(config.MetadataOnlyIndex); err != nil {
+		cache.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+// cacheFilePath returns the path of the cache file called name in ~/.cache/ai-sessions
+func cacheFilePath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "ai-sessions", name), nil
+}
+
+// encryptedCachePath returns the path of the encrypted cache replacing the cache at path
+func encryptedCachePath(path string) string {
+	return strings.TrimSuffix(path, ".db") + "-encrypted.db"
+}
+
+// openConfiguredCache opens the cache at path, or the encrypted cache replacing it when
+// the config file sets encrypt_cache
+func openConfiguredCache(path string, config Config) (*search.Cache, error) {
+	if !config.EncryptCache {
+		return search.NewCache(path)
+	}
+
+	key, err := searchCacheKey()
+	if err != nil {
+		return nil, err
+	}
+	encryptedPath := encryptedCachePath(path)
+	_, statErr := os.Stat(encryptedPath)
+	cache, err := search.NewEncryptedCache(encryptedPath, key)
+	if err != nil {
+		return nil, err
+	}
+	if os.IsNotExist(statErr) {
+		if err := replacePlaintextCache(path, cache); err != nil {
+			slog.Warn("Failed to move tags and notes to the encrypted search cache", "error", err)
+		}
+	}
+	return cache, nil
+}
+
+// replacePlaintextCache moves the tags and notes of the plaintext cache at path, if
+// there is one, to the encrypted cache, and deletes the plaintext cache
+func replacePlaintextCache(path string, encrypted *search.Cache) error {
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	plain, err := search.NewCache(path)
+	if err != nil {
+		return err
+	}
+	err = plain.CopyAnnotations(encrypted)
+	plain.Close()
+	if err != nil {
+		return err
+	}
+	for _, file := range []string{path, path + "-journal", path + "-wal", path + "-shm"} {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to delete the plaintext search cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// searchCacheKey returns the key the search cache is encrypted with, creating one in
+// the system keychain the first time
+func searchCacheKey() ([]byte, error) {
+	if tokenKeychain == nil {
+		return nil, fmt.Errorf("encrypt_cache needs a system keychain to keep the key in (the macOS Keychain, secret-tool on Linux, or PowerShell on Windows)")
+	}
+	encoded, err := tokenKeychain.get(cacheKeyAccount)
+	if err == nil {
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != search.KeySize {
+			return nil, fmt.Errorf("invalid search cache key in the %s", tokenKeychain.name())
+		}
+		return key, nil
+	}
+	if !errors.Is(err, errNoKeychainToken) {
+		return nil, fmt.Errorf("failed to read the search cache key from the %s: %w", tokenKeychain.name(), err)
+	}
+
+	key := make([]byte, search.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate the search cache key: %w", err)
+	}
+	if err := tokenKeychain.set(cacheKeyAccount, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to store the search cache key in the %s: %w", tokenKeychain.name(), err)
+	}
+	return key, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOpenCacheFileEncrypted(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	defer func(k keychain) { tokenKeychain = k }(tokenKeychain)
+	tokenKeychain = fakeKeychain{tokens: map[string]string{}}
+
+	plain, err := openCacheFile("search.db")
+	if err != nil {
+		t.Fatalf("openCacheFile failed: %v", err)
+	}
+	if err := plain.TagSession("claude", "s1", []string{"keep"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+	if _, err := plain.AddNote("claude", "s1", "remember this"); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	plain.Close()
+
+	if err := saveConfig(Config{EncryptCache: true}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	cache, err := openCacheFile("search.db")
+	if err != nil {
+		t.Fatalf("openCacheFile (encrypted) failed: %v", err)
+	}
+	defer cache.Close()
+
+	cacheDir := filepath.Join(tempHome, ".cache", "ai-sessions")
+	if _, err := os.Stat(filepath.Join(cacheDir, "search.db")); !os.IsNotExist(err) {
+		t.Fatalf("expected the plaintext cache to be deleted, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, "search-encrypted.db")); err != nil {
+		t.Fatalf("expected an encrypted cache: %v", err)
+	}
+	if tags, err := cache.SessionTags("claude", "s1"); err != nil || len(tags) != 1 || tags[0] != "keep" {
+		t.Fatalf("expected the tags to be kept, got %v (%v)", tags, err)
+	}
+	if notes, err := cache.SessionNotes("claude", "s1"); err != nil || len(notes) != 1 || notes[0].Text != "remember this" {
+		t.Fatalf("expected the notes to be kept, got %+v (%v)", notes, err)
+	}
+
+	key, err := searchCacheKey()
+	if err != nil || len(key) == 0 {
+		t.Fatalf("expected the key to be kept in the keychain, got %v", err)
+	}
+
+	tokenKeychain = nil
+	if _, err := openCacheFile("search.db"); err == nil {
+		t.Fatal("expected an error without a keychain for the key")
+	}
+}
+
+func TestOpenCacheFileInMemory(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	if err := saveConfig(Config{InMemoryIndex: true, EncryptCache: true}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	cache, err := openCacheFile("search.db")
+	if err != nil {
+		t.Fatalf("openCacheFile failed: %v", err)
+	}
+	defer cache.Close()
+	if !cache.InMemory() {
+		t.Fatal("expected an in-memory cache")
+	}
+	if err := cache.TagSession("claude", "s1", []string{"keep"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tempHome, ".cache")); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing written to ~/.cache, got %v", err)
+	}
+	if check := checkSearchCache(Config{InMemoryIndex: true}); check.Status != checkOK {
+		t.Fatalf("unexpected doctor check %+v", check)
+	}
+
+	// Every process starts from an empty cache
+	other, err := openCacheFile("search.db")
+	if err != nil {
+		t.Fatalf("openCacheFile failed: %v", err)
+	}
+	defer other.Close()
+	if tags, err := other.SessionTags("claude", "s1"); err != nil || len(tags) != 0 {
+		t.Fatalf("expected a new cache, got tags %v (%v)", tags, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// errorKinds are the kinds of errors search_errors can filter on
+var errorKinds = []string{
+	analysis.ErrorKindPanic,
+	analysis.ErrorKindTraceback,
+	analysis.ErrorKindException,
+	analysis.ErrorKindCompiler,
+	analysis.ErrorKindError,
+}
+
+// Tool 25: search_errors
+type searchErrorsArgs struct {
+	Query       string `json:"query,omitempty" jsonschema:"Text of the error to find, such as 'nil pointer dereference' or 'ModuleNotFoundError'. Every word must appear in the error, in any case."`
+	Kind        string `json:"kind,omitempty" jsonschema:"Optional: only errors of this kind: panic, traceback (Python), exception (with a stack trace, as in JavaScript or Java), compiler, or error (any other error line)"`
+	Source      string `json:"source,omitempty" jsonschema:"Optional: filter by source (claude, gemini, codex, opencode)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Optional: filter by project: a path, a glob like '~/work/*', or a directory name"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return (default: 10)"`
+}
+
+func addSearchErrorsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "search_errors",
+		Description: "Find every session whose tool output showed an error, such as a panic, a Python traceback, an exception, or a compiler error, newest first, with the matching errors and how often each showed up",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args searchErrorsArgs) (*mcp.CallToolResult, any, error) {
+		if strings.TrimSpace(args.Query) == "" && args.Kind == "" {
+			return nil, nil, fmt.Errorf("query or kind is required")
+		}
+		if args.Kind != "" && !slices.Contains(errorKinds, args.Kind) {
+			return nil, nil, fmt.Errorf("unknown error kind: %s (use %s)", args.Kind, strings.Join(errorKinds, ", "))
+		}
+		if args.Limit == 0 {
+			args.Limit = 10
+		}
+
+		// Errors are indexed alongside the search index
+		failedSources, err := indexSessions(ctx, adaptersMap, searchCache, args.Source, args.ProjectPath)
+		if err != nil {
+			slog.Warn("Failed to index sessions", "error", err)
+		}
+
+		matches, err := searchCache.FindSessionsByError(args.Query, search.ErrorSearchOptions{
+			Kind:        args.Kind,
+			Source:      args.Source,
+			ProjectPath: args.ProjectPath,
+			Limit:       args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("error search failed: %w", err)
+		}
+
+		result := map[string]interface{}{
+			"query":    args.Query,
+			"sessions": matches,
+			"count":    len(matches),
+		}
+		if len(failedSources) > 0 {
+			result["failed_sources"] = failedSources
+		}
+		return jsonToolResult(result)
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// sessionExclusions are the sources and projects whose sessions list_sessions and
+// search_sessions leave out
+type sessionExclusions struct {
+	Sources  []string
+	Projects []string // Project filters (see adapters.ProjectFilter)
+}
+
+// adapters returns adaptersMap without the excluded sources, which must be known
+func (e sessionExclusions) adapters(adaptersMap map[string]adapters.SessionAdapter) (map[string]adapters.SessionAdapter, error) {
+	if len(e.Sources) == 0 {
+		return adaptersMap, nil
+	}
+	excluded := make(map[string]bool, len(e.Sources))
+	for _, source := range e.Sources {
+		if _, ok := adaptersMap[source]; !ok {
+			return nil, fmt.Errorf("unknown source: %s", source)
+		}
+		excluded[source] = true
+	}
+	kept := make(map[string]adapters.SessionAdapter, len(adaptersMap))
+	for name, adapter := range adaptersMap {
+		if !excluded[name] {
+			kept[name] = adapter
+		}
+	}
+	return kept, nil
+}
+
+// apply returns the sessions not in an excluded project
+func (e sessionExclusions) apply(sessions []adapters.Session) []adapters.Session {
+	if len(e.Projects) == 0 {
+		return sessions
+	}
+	filters := make([]adapters.ProjectFilter, len(e.Projects))
+	for i, project := range e.Projects {
+		filters[i] = adapters.NewProjectFilter(project)
+	}
+	kept := sessions[:0:0]
+	for _, session := range sessions {
+		excluded := false
+		for _, filter := range filters {
+			if !filter.IsEmpty() && filter.Matches(session.ProjectPath) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			kept = append(kept, session)
+		}
+	}
+	return kept
+}
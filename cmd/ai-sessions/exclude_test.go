@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSessionExclusions(t *testing.T) {
+	adaptersMap := map[string]adapters.SessionAdapter{
+		"claude": newStubAdapter(nil, nil),
+		"codex":  newStubAdapter(nil, nil),
+	}
+	exclusions := sessionExclusions{Sources: []string{"codex"}, Projects: []string{"web", ""}}
+	kept, err := exclusions.adapters(adaptersMap)
+	if err != nil || len(kept) != 1 || kept["claude"] == nil {
+		t.Fatalf("expected only claude to be kept, got %v (%v)", kept, err)
+	}
+	if _, err := (sessionExclusions{Sources: []string{"cursor"}}).adapters(adaptersMap); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+
+	sessions := exclusions.apply([]adapters.Session{
+		{ID: "api", ProjectPath: "/work/api"},
+		{ID: "web", ProjectPath: "/work/web"},
+	})
+	if len(sessions) != 1 || sessions[0].ID != "api" {
+		t.Fatalf("expected the web project to be left out, got %+v", sessions)
+	}
+}
+
+func TestSearchSessionsExclusions(t *testing.T) {
+	cache := newTestCache(t)
+	dir := t.TempDir()
+	session := func(id, source, project string) adapters.Session {
+		path := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(path, []byte("dummy"), 0o644); err != nil {
+			t.Fatalf("failed to create session file: %v", err)
+		}
+		return adapters.Session{ID: id, Source: source, ProjectPath: project, Timestamp: time.Now(), FilePath: path}
+	}
+	adaptersMap := map[string]adapters.SessionAdapter{
+		"claude": newStubAdapter([]adapters.Session{session("claude-api", "claude", "/work/api"), session("claude-web", "claude", "/work/web")}, map[string][]adapters.Message{
+			"claude-api": {{Role: "user", Content: "the deploy is stuck"}},
+			"claude-web": {{Role: "user", Content: "the deploy is stuck again"}},
+		}),
+		"codex": newStubAdapter([]adapters.Session{session("codex-api", "codex", "/work/api")}, map[string][]adapters.Message{
+			"codex-api": {{Role: "user", Content: "the deploy is stuck"}},
+		}),
+	}
+
+	ids := func(args searchSessionsArgs) []string {
+		t.Helper()
+		result, err := searchSessions(context.Background(), adaptersMap, cache, args)
+		if err != nil {
+			t.Fatalf("searchSessions failed: %v", err)
+		}
+		var ids []string
+		for _, match := range result["matches"].([]map[string]interface{}) {
+			ids = append(ids, match["session"].(adapters.Session).ID)
+		}
+		return ids
+	}
+	if got := ids(searchSessionsArgs{Query: "deploy", ShowDuplicates: true}); len(got) != 3 {
+		t.Fatalf("expected every session, got %v", got)
+	}
+	if got := ids(searchSessionsArgs{Query: "deploy", ShowDuplicates: true, ExcludeSource: []string{"codex"}}); len(got) != 2 || got[0] == "codex-api" || got[1] == "codex-api" {
+		t.Fatalf("expected the codex session to be left out, got %v", got)
+	}
+	if got := ids(searchSessionsArgs{Query: "deploy", ShowDuplicates: true, ExcludeSource: []string{"codex"}, ExcludeProject: []string{"web"}}); len(got) != 1 || got[0] != "claude-api" {
+		t.Fatalf("expected only the claude api session, got %v", got)
+	}
+	if _, err := searchSessions(context.Background(), adaptersMap, cache, searchSessionsArgs{Query: "deploy", ExcludeSource: []string{"cursor"}}); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+}
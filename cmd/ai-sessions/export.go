@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+	"github.com/yoavf/ai-sessions-mcp/redact"
+)
+
+// Tool 19: export_session
+type exportSessionArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to export"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Format    string `json:"format,omitempty" jsonschema:"Export format: 'md' (default), 'html', 'json', 'jsonl', 'diff', or a native session format: 'claude' or 'codex'"`
+	Anonymize bool   `json:"anonymize,omitempty" jsonschema:"Replace user names, host names, home directories, and email addresses with placeholders"`
+}
+
+func addExportSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "export_session",
+		Description: "Render a session as a shareable document. 'md' produces clean Markdown with user/assistant headings, fenced code blocks, and collapsed tool calls and output, suitable for pasting into PRs or docs. 'html' produces a single self-contained page with syntax highlighting, collapsible tool calls, and a message navigator, for sharing with people who don't use the CLI. 'json' and 'jsonl' produce the unified transcript format, which is the same for every source. 'claude' and 'codex' convert the session to that agent's session file format. 'diff' produces a unified diff of every change the assistant made to files.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args exportSessionArgs) (*mcp.CallToolResult, any, error) {
+		if args.Format == "" {
+			args.Format = "md"
+		}
+		content, err := exportSession(adaptersMap, args.Source, args.SessionID, args.Format)
+		if err != nil {
+			return nil, nil, err
+		}
+		if args.Anonymize {
+			content = redact.NewAnonymizer().Anonymize(content)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"format":     args.Format,
+			"content":    content,
+		})
+	})
+}
+
+// exportSession renders a session in the given format. The session's listing supplies
+// the project and start time when the session can be found in it.
+func exportSession(adaptersMap map[string]adapters.SessionAdapter, source, sessionID, format string) (string, error) {
+	messages, err := loadSessionMessages(adaptersMap, source, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return export.Render(format, findListedSession(adaptersMap, source, sessionID), messages)
+}
+
+// findListedSession returns the listing of a session, or just its ID and source when
+// it isn't listed
+func findListedSession(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string) adapters.Session {
+	sessions, err := collectSessions(adaptersMap, source, "")
+	if err == nil {
+		for _, session := range sessions {
+			if session.ID == sessionID {
+				return session
+			}
+		}
+	}
+	return adapters.Session{ID: sessionID, Source: source}
+}
+
+var exportCommand = cliCommand{
+	name:    "export",
+	args:    "[<source> <id>]",
+	summary: "Export a session, or many sessions, as Markdown, HTML, or JSON transcripts",
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts exportOptions
+		fs.StringVar(&opts.Format, "format", "md", "export `format`: md, html, json, jsonl, claude, codex, or diff")
+		fs.BoolVar(&opts.Diffs, "diffs", false, "export the changes the session made to files as a unified diff (--format diff)")
+		fs.StringVar(&opts.Output, "output", "", "write to `file` instead of stdout; with no session, the directory to export into")
+		fs.StringVar(&opts.Output, "out", "", "")
+		fs.StringVar(&opts.Output, "o", "", "")
+		fs.StringVar(&opts.ProjectPath, "project", "", "bulk export: only sessions of a `project`: a path, a glob, or a directory name")
+		fs.StringVar(&opts.Since, "since", "", "bulk export: only sessions since a `window` like 30d, or a date")
+		fs.StringVar(&opts.Source, "source", "", "bulk export: only sessions of this `source`")
+		fs.BoolVar(&opts.Anonymize, "anonymize", false, "replace user names, host names, home directories, and email addresses with placeholders")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			if opts.Diffs {
+				opts.Format = "diff"
+			}
+			return runExportCommand(env.sessionAdapters(), args, opts, env.stdout)
+		}
+	},
+}
+
+// runExportCommand exports the session given by args (source and ID) to a file or
+// stdout, or with no args, every session matching opts into opts.Output
+func runExportCommand(adaptersMap map[string]adapters.SessionAdapter, args []string, opts exportOptions, stdout io.Writer) error {
+	const usage = "usage: aisessions export <source> <id> [--format md|html|json|jsonl|diff] [--diffs] [--output file]\n" +
+		"   or: aisessions export --out dir [--project path] [--since 30d] [--source name] [--format md|html|json|jsonl|diff]"
+	if len(args) == 0 && opts.Output != "" {
+		return runBulkExport(adaptersMap, opts, stdout)
+	}
+	if len(args) != 2 || opts.ProjectPath != "" || opts.Since != "" || opts.Source != "" {
+		return fmt.Errorf(usage)
+	}
+
+	content, err := exportSession(adaptersMap, args[0], args[1], opts.Format)
+	if err != nil {
+		return err
+	}
+	if opts.Anonymize {
+		content = redact.NewAnonymizer().Anonymize(content)
+	}
+	result := map[string]interface{}{"session_id": args[1], "source": args[0], "format": opts.Format}
+	if opts.Output == "" {
+		if opts.JSON {
+			result["content"] = content
+			return printJSON(stdout, result)
+		}
+		_, err = io.WriteString(stdout, content)
+		return err
+	}
+	if err := os.WriteFile(opts.Output, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	if opts.JSON {
+		result["output"] = opts.Output
+		return printJSON(stdout, result)
+	}
+	fmt.Fprintf(stdout, "Exported %s session %s to %s\n", args[0], args[1], opts.Output)
+	return nil
+}
+
+// exportOptions are the options of `aisessions export`. The filters only apply to bulk exports.
+type exportOptions struct {
+	Output      string // File to write a session to, or the directory of a bulk export
+	Format      string
+	ProjectPath string
+	Since       string // Relative window or date, see parseSince; empty exports every session
+	Source      string
+	Anonymize   bool // Replace identifying names and addresses with placeholders
+	Diffs       bool // Export only the changes made to files, as --format diff
+	JSON        bool // Report the result as JSON, with the content when it isn't written to a file
+}
+
+// runBulkExport exports every matching session into opts.Output, one file per session,
+// laid out as <project>/<date>/<source>-<id>.<ext> so an archive stays browsable.
+// Sessions that fail to export are reported and skipped.
+func runBulkExport(adaptersMap map[string]adapters.SessionAdapter, opts exportOptions, stdout io.Writer) error {
+	ext, err := export.Extension(opts.Format)
+	if err != nil {
+		return err
+	}
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+
+	sessions, err := collectSessions(adaptersMap, opts.Source, opts.ProjectPath)
+	if err != nil {
+		return err
+	}
+
+	// One anonymizer for the whole export keeps placeholders consistent across files
+	var anonymizer *redact.Anonymizer
+	if opts.Anonymize {
+		anonymizer = redact.NewAnonymizer()
+	}
+	exported, failed := 0, 0
+	for _, session := range sessions {
+		if session.Timestamp.Before(since) {
+			continue
+		}
+		pathSession := session
+		if anonymizer != nil {
+			pathSession.ProjectPath = anonymizer.Anonymize(session.ProjectPath)
+		}
+		path := filepath.Join(opts.Output, bulkExportPath(pathSession, ext))
+		if err := exportSessionFile(adaptersMap, session, opts.Format, path, anonymizer); err != nil {
+			slog.Warn("Failed to export session", "source", session.Source, "session", session.ID, "error", err)
+			failed++
+			continue
+		}
+		exported++
+	}
+
+	if opts.JSON {
+		err = printJSON(stdout, map[string]interface{}{"output": opts.Output, "format": opts.Format, "exported": exported, "failed": failed})
+	} else {
+		fmt.Fprintf(stdout, "Exported %d sessions to %s\n", exported, opts.Output)
+	}
+	if err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d sessions failed to export (run with --verbose for details)", failed)
+	}
+	return nil
+}
+
+// exportSessionFile renders a listed session and writes it to path, anonymized when
+// anonymizer is set
+func exportSessionFile(adaptersMap map[string]adapters.SessionAdapter, session adapters.Session, format, path string, anonymizer *redact.Anonymizer) error {
+	messages, err := readSession(adaptersMap, session)
+	if err != nil {
+		return err
+	}
+	content, err := export.Render(format, session, messages)
+	if err != nil {
+		return err
+	}
+	if anonymizer != nil {
+		content = anonymizer.Anonymize(content)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create export directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// unsafePathChars matches characters replaced when turning names into path segments
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// bulkExportPath returns where a session is written within a bulk export directory:
+// a folder per project, holding a folder per day
+func bulkExportPath(session adapters.Session, ext string) string {
+	project := strings.Trim(unsafePathChars.ReplaceAllString(session.ProjectPath, "-"), "-.")
+	if project == "" {
+		project = "unknown-project"
+	}
+	if session.Machine != "" {
+		project = safePathSegment(session.Machine) + "-" + project
+	}
+	day := "undated"
+	if !session.Timestamp.IsZero() {
+		day = session.Timestamp.In(cliTimes.location()).Format("2006-01-02")
+	}
+	return filepath.Join(project, day, session.Source+"-"+safePathSegment(session.ID)+ext)
+}
+
+func safePathSegment(name string) string {
+	return strings.Trim(unsafePathChars.ReplaceAllString(name, "_"), ".")
+}
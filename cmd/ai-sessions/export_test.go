@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunExportCommand(t *testing.T) {
+	stub := newStubAdapter(
+		[]adapters.Session{{ID: "s1", Source: "claude", ProjectPath: "/work/app"}},
+		map[string][]adapters.Message{
+			"s1": {{Role: "user", Content: "Add a login page"}, {Role: "assistant", Content: "Done."}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude", "s1", "--format", "md"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if text := out.String(); !strings.HasPrefix(text, "# Add a login page\n") || !strings.Contains(text, "`/work/app`") || !strings.Contains(text, "## Assistant\n\nDone.") {
+		t.Fatalf("unexpected export:\n%s", text)
+	}
+
+	outputPath := filepath.Join(t.TempDir(), "session.md")
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude", "s1", "--output", outputPath); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if data, err := os.ReadFile(outputPath); err != nil || !strings.Contains(string(data), "Add a login page") {
+		t.Fatalf("expected the export to be written to the output file: %v", err)
+	}
+
+	out.Reset()
+	stub.messages["s2"] = []adapters.Message{{Role: "user", Content: "ask ops@corp.dev, then ops@corp.dev about /home/asmith/app"}}
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude", "s2", "--anonymize"); err != nil {
+		t.Fatalf("export --anonymize failed: %v", err)
+	}
+	if text := out.String(); !strings.Contains(text, "ask user1@example.com, then user1@example.com about /home/user") || strings.Contains(text, "asmith") {
+		t.Fatalf("expected an anonymized export:\n%s", text)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude"); err == nil {
+		t.Fatal("expected an error without a session ID")
+	}
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude", "s1", "--format", "pdf"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+}
+
+func TestRunBulkExport(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	stub := newStubAdapter(
+		[]adapters.Session{
+			{ID: "s1", Source: "claude", ProjectPath: "/work/app", Timestamp: recent},
+			{ID: "s2", Source: "claude", ProjectPath: "/work/app", Timestamp: recent.AddDate(0, -2, 0)},
+			{ID: "s3", Source: "claude", Timestamp: recent},
+		},
+		map[string][]adapters.Message{
+			"s1": {{Role: "user", Content: "recent"}},
+			"s2": {{Role: "user", Content: "old"}},
+			"s3": {{Role: "user", Content: "no project"}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+	dir := t.TempDir()
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "--out", dir, "--since", "30d", "--format", "json"); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Exported 2 sessions") {
+		t.Fatalf("unexpected output: %s", out.String())
+	}
+
+	day := recent.Local().Format("2006-01-02")
+	for _, path := range []string{
+		filepath.Join(dir, "work-app", day, "claude-s1.json"),
+		filepath.Join(dir, "unknown-project", day, "claude-s3.json"),
+	} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("expected export at %s: %v", path, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "work-app", recent.AddDate(0, -2, 0).Local().Format("2006-01-02"))); err == nil {
+		t.Error("sessions older than --since should not be exported")
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "--out", dir, "--format", "pdf"); err == nil {
+		t.Fatal("expected an error for an unsupported format")
+	}
+	if err := runTestCLI(adaptersMap, nil, &out, "export", "claude", "s1", "--since", "7d"); err == nil {
+		t.Fatal("expected an error for bulk filters on a single export")
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+const (
+	// listTimeout bounds how long a single source may take to list its sessions
+	listTimeout = 30 * time.Second
+
+	// indexTimeout bounds how long a single source may spend (re)indexing per request.
+	// Sessions indexed before the deadline are kept, so the rest is picked up next time.
+	indexTimeout = 2 * time.Minute
+)
+
+// sourceError records a source that failed or timed out while others succeeded
+type sourceError struct {
+	Source string `json:"source"`
+	Error  string `json:"error"`
+}
+
+// selectAdapters returns the adapter for source, or every adapter when source is empty
+func selectAdapters(adaptersMap map[string]adapters.SessionAdapter, source string) (map[string]adapters.SessionAdapter, error) {
+	if source == "" {
+		return adaptersMap, nil
+	}
+	adapter, ok := adaptersMap[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source: %s", source)
+	}
+	return map[string]adapters.SessionAdapter{source: adapter}, nil
+}
+
+// fanOut runs fn for every adapter concurrently, each under its own timeout, which fn
+// passes on to the adapter. A source that fails does not affect the others; its error is
+// returned in the failures, ordered by source name.
+func fanOut(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, timeout time.Duration, fn func(ctx context.Context, name string, adapter adapters.SessionAdapter) error) []sourceError {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		failures []sourceError
+	)
+	for name, adapter := range adaptersMap {
+		wg.Go(func() {
+			sourceCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			if err := fn(sourceCtx, name, adapter); err != nil {
+				if errors.Is(err, context.DeadlineExceeded) {
+					err = fmt.Errorf("timed out after %s", timeout)
+				}
+				mu.Lock()
+				failures = append(failures, sourceError{Source: name, Error: err.Error()})
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Source < failures[j].Source
+	})
+	return failures
+}
+
+// listSources lists sessions from the selected sources concurrently, newest first.
+// Sources that fail or time out are skipped and reported. projectPath is a project filter
+// (see adapters.ProjectFilter); a pattern or directory name lists every session, so
+// callers apply limit to the sessions returned.
+func listSources(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, source, projectPath string, limit int) ([]adapters.Session, []sourceError, error) {
+	selected, err := selectAdapters(adaptersMap, source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	projects := adapters.NewProjectFilter(projectPath)
+	if projects.Path() == "" && !projects.IsEmpty() {
+		limit = 0
+	}
+
+	var (
+		mu          sync.Mutex
+		allSessions []adapters.Session
+	)
+	failures := fanOut(ctx, selected, listTimeout, func(ctx context.Context, name string, adapter adapters.SessionAdapter) error {
+		sessions, err := adapters.ListSessionsContext(ctx, adapter, projects.Path(), limit)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		for _, session := range sessions {
+			if projects.Matches(session.ProjectPath) {
+				allSessions = append(allSessions, session)
+			}
+		}
+		mu.Unlock()
+		return nil
+	})
+
+	sort.Slice(allSessions, func(i, j int) bool {
+		return allSessions[i].Timestamp.After(allSessions[j].Timestamp)
+	})
+	return allSessions, failures, nil
+}
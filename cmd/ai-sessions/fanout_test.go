@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestListSourcesReportsFailedSources(t *testing.T) {
+	now := time.Now()
+	older := newStubAdapter([]adapters.Session{{ID: "old", Source: "a", Timestamp: now.Add(-time.Hour)}}, nil)
+	newer := newStubAdapter([]adapters.Session{{ID: "new", Source: "b", Timestamp: now}}, nil)
+	broken := newStubAdapter(nil, nil)
+	broken.listErr = errors.New("permission denied")
+
+	adaptersMap := map[string]adapters.SessionAdapter{"a": older, "b": newer, "c": broken}
+	sessions, failures, err := listSources(context.Background(), adaptersMap, "", "", 10)
+	if err != nil {
+		t.Fatalf("listSources returned error: %v", err)
+	}
+
+	if len(sessions) != 2 || sessions[0].ID != "new" || sessions[1].ID != "old" {
+		t.Fatalf("expected sessions from healthy sources newest first, got %+v", sessions)
+	}
+	if len(failures) != 1 || failures[0].Source != "c" || failures[0].Error != "permission denied" {
+		t.Fatalf("expected source c to be reported as failed, got %+v", failures)
+	}
+
+	if _, _, err := listSources(context.Background(), adaptersMap, "missing", "", 10); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+}
+
+func TestListSourcesMatchesProjectNames(t *testing.T) {
+	now := time.Now()
+	stub := newStubAdapter([]adapters.Session{
+		{ID: "app", Source: "claude", ProjectPath: "/work/ai-sessions-mcp", Timestamp: now},
+		{ID: "app-cmd", Source: "claude", ProjectPath: "/work/ai-sessions-mcp/cmd", Timestamp: now.Add(-time.Hour)},
+		{ID: "api", Source: "claude", ProjectPath: "/work/api", Timestamp: now},
+	}, nil)
+	sessions, _, err := listSources(context.Background(), map[string]adapters.SessionAdapter{"claude": stub}, "", "ai-sessions-mcp", 10)
+	if err != nil {
+		t.Fatalf("listSources returned error: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "app" || sessions[1].ID != "app-cmd" {
+		t.Fatalf("expected the sessions of the ai-sessions-mcp project, got %+v", sessions)
+	}
+}
+
+func TestFanOutTimesOutSlowSources(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	adaptersMap := map[string]adapters.SessionAdapter{
+		"fast": newStubAdapter(nil, nil),
+		"slow": newStubAdapter(nil, nil),
+	}
+
+	start := time.Now()
+	var fastDone bool
+	failures := fanOut(context.Background(), adaptersMap, 50*time.Millisecond, func(ctx context.Context, name string, adapter adapters.SessionAdapter) error {
+		if name == "fast" {
+			fastDone = true
+			return nil
+		}
+		// Adapters are given the source's context and stop when it is done
+		select {
+		case <-release:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("fanOut waited %s for a slow source", elapsed)
+	}
+	if !fastDone {
+		t.Fatal("expected the fast source to complete")
+	}
+	if len(failures) != 1 || failures[0].Source != "slow" || !strings.Contains(failures[0].Error, "timed out") {
+		t.Fatalf("expected slow source to time out, got %+v", failures)
+	}
+}
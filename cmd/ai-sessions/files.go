@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Tool 6: get_session_files
+type getSessionFilesArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to inspect"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+}
+
+func addGetSessionFilesTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_session_files",
+		Description: "List the files read, edited, created, or deleted during a session, with per-operation counts",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionFilesArgs) (*mcp.CallToolResult, any, error) {
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		files := analysis.FileActivities(messages)
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"files":      files,
+			"count":      len(files),
+		})
+	})
+}
+
+// Tool 24: get_session_diffs
+type getSessionDiffsArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to inspect"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Path      string `json:"path,omitempty" jsonschema:"Optional: only the changes to files whose path ends with this (e.g., 'cmd/main.go')"`
+}
+
+func addGetSessionDiffsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_session_diffs",
+		Description: "Return every change the assistant made to files during a session as unified diffs, in order, from its edit, write, and patch tool calls, to review all the code it changed in one place",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionDiffsArgs) (*mcp.CallToolResult, any, error) {
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		diffs := []analysis.FileDiff{}
+		files := make(map[string]bool)
+		added, removed := 0, 0
+		for _, diff := range analysis.Diffs(messages) {
+			if args.Path != "" && !strings.HasSuffix(diff.Path, args.Path) {
+				continue
+			}
+			diffs = append(diffs, diff)
+			files[diff.Path] = true
+			added += diff.Added
+			removed += diff.Removed
+		}
+		return jsonToolResult(map[string]interface{}{
+			"session_id":    args.SessionID,
+			"source":        args.Source,
+			"diffs":         diffs,
+			"count":         len(diffs),
+			"files_changed": len(files),
+			"lines_added":   added,
+			"lines_removed": removed,
+		})
+	})
+}
+
+// Tool 8: find_sessions_by_file
+type findSessionsByFileArgs struct {
+	Path        string `json:"path" jsonschema:"File path or glob to look up (e.g., 'cmd/main.go', '/repo/src/app.ts', '*.sql'). Relative paths match by suffix."`
+	Source      string `json:"source,omitempty" jsonschema:"Optional: filter by source (claude, gemini, codex, opencode)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Optional: filter by project: a path, a glob like '~/work/*', or a directory name"`
+	Operation   string `json:"operation,omitempty" jsonschema:"Optional: only match one operation (read, edit, write, create, delete)"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return (default: 10)"`
+}
+
+func addFindSessionsByFileTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_sessions_by_file",
+		Description: "Find sessions across all sources that read or modified a file, newest first",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args findSessionsByFileArgs) (*mcp.CallToolResult, any, error) {
+		if args.Path == "" {
+			return nil, nil, fmt.Errorf("path is required")
+		}
+
+		switch args.Operation {
+		case "", analysis.OpRead, analysis.OpEdit, analysis.OpWrite, analysis.OpCreate, analysis.OpDelete:
+		default:
+			return nil, nil, fmt.Errorf("invalid operation %q: must be read, edit, write, create, or delete", args.Operation)
+		}
+
+		if args.Limit == 0 {
+			args.Limit = 10
+		}
+
+		// The file index is populated alongside the search index
+		if _, err := indexSessions(ctx, adaptersMap, searchCache, args.Source, args.ProjectPath); err != nil {
+			slog.Warn("Failed to index sessions", "error", err)
+		}
+
+		matches, err := searchCache.FindSessionsByFile(args.Path, search.FileSearchOptions{
+			Source:      args.Source,
+			ProjectPath: args.ProjectPath,
+			Operation:   args.Operation,
+			Limit:       args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("file lookup failed: %w", err)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"path":     args.Path,
+			"sessions": matches,
+			"count":    len(matches),
+		})
+	})
+}
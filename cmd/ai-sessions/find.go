@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/search"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// contextPreviewLength is how much of a neighboring message a match shows
+const contextPreviewLength = 200
+
+// sessionMatch is a message of a session matching a query
+type sessionMatch struct {
+	MessageIndex int              `json:"message_index"`
+	Role         string           `json:"role"`
+	Timestamp    time.Time        `json:"timestamp,omitempty"`
+	Fields       []string         `json:"fields"` // Where the terms were found: content, thinking, tool_input or tool_output
+	Snippets     []string         `json:"snippets"`
+	Before       []messagePreview `json:"before,omitempty"`
+	After        []messagePreview `json:"after,omitempty"`
+}
+
+// messagePreview is the start of a message next to a match
+type messagePreview struct {
+	MessageIndex int    `json:"message_index"`
+	Role         string `json:"role"`
+	Content      string `json:"content"`
+}
+
+// findOptions control which messages findInMessages matches and what it returns of them
+type findOptions struct {
+	Filter   messageFilter // Selects the messages searched
+	Context  int           // Neighboring messages previewed on each side of a match
+	Snippets search.SnippetOptions
+}
+
+// messageFields returns the searchable text of a message by field, like scan_session
+func messageFields(msg adapters.Message) [][2]string {
+	fields := [][2]string{{"content", msg.Content}, {"thinking", msg.Thinking}}
+	var inputs []string
+	for _, call := range analysis.ToolCalls(msg) {
+		if input, err := json.Marshal(call.Input); err == nil {
+			inputs = append(inputs, call.Name+" "+string(input))
+		}
+	}
+	var outputs []string
+	for _, result := range analysis.ToolResults(msg) {
+		outputs = append(outputs, result.Output)
+	}
+	return append(fields, [2]string{"tool_input", strings.Join(inputs, "\n")}, [2]string{"tool_output", strings.Join(outputs, "\n")})
+}
+
+// queryTerms returns the terms of a query, or the whole query if it has no terms (such
+// as a single character)
+func queryTerms(query string) []string {
+	if terms := search.Tokenize(query); len(terms) > 0 {
+		return terms
+	}
+	return []string{strings.ToLower(strings.TrimSpace(query))}
+}
+
+// findInMessages returns the messages containing every term of the query, in order. Each
+// match carries its index among all the messages, which is its position in get_session
+// without filters, snippets of the fields it was found in, and previews of the messages
+// around it.
+func findInMessages(messages []adapters.Message, query string, opts findOptions) []sessionMatch {
+	terms := dedupeTerms(queryTerms(query))
+	matches := []sessionMatch{}
+	for i, msg := range messages {
+		if len(filterMessages(messages[i:i+1], opts.Filter)) == 0 {
+			continue
+		}
+
+		// Every term must appear in the message, in any of its fields
+		found := make(map[string]bool)
+		var fields, texts []string
+		for _, field := range messageFields(msg) {
+			lower := strings.ToLower(field[1])
+			hit := false
+			for _, term := range terms {
+				if strings.Contains(lower, term) {
+					found[term] = true
+					hit = true
+				}
+			}
+			if hit {
+				fields = append(fields, field[0])
+				texts = append(texts, field[1])
+			}
+		}
+		if len(found) < len(terms) {
+			continue
+		}
+
+		match := sessionMatch{MessageIndex: i, Role: msg.Role, Timestamp: msg.Timestamp, Fields: fields}
+		for _, text := range texts {
+			match.Snippets = append(match.Snippets, search.GetSnippets(text, terms, opts.Snippets)...)
+		}
+		for j := max(i-opts.Context, 0); j < i; j++ {
+			match.Before = append(match.Before, previewMessage(messages, j))
+		}
+		for j := i + 1; j <= min(i+opts.Context, len(messages)-1); j++ {
+			match.After = append(match.After, previewMessage(messages, j))
+		}
+		matches = append(matches, match)
+	}
+	return matches
+}
+
+// dedupeTerms returns terms without repeats
+func dedupeTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	unique := terms[:0:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			unique = append(unique, term)
+		}
+	}
+	return unique
+}
+
+func previewMessage(messages []adapters.Message, index int) messagePreview {
+	msg := messages[index]
+	content := msg.Content
+	if strings.TrimSpace(content) == "" {
+		// Tool calls and results carry their text in other fields
+		for _, field := range messageFields(msg)[1:] {
+			if strings.TrimSpace(field[1]) != "" {
+				content = field[1]
+				break
+			}
+		}
+	}
+	return messagePreview{
+		MessageIndex: index,
+		Role:         msg.Role,
+		Content:      textutil.Truncate(strings.Join(strings.Fields(content), " "), contextPreviewLength),
+	}
+}
+
+// Tool 28: find_in_session
+type findInSessionArgs struct {
+	SessionID       string   `json:"session_id" jsonschema:"The session ID to search"`
+	Source          string   `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Query           string   `json:"query" jsonschema:"Words to find. A message matches when it contains every word, in its text, thinking, tool calls or tool output."`
+	Roles           []string `json:"roles,omitempty" jsonschema:"Only search messages with these roles (user, assistant, tool, system). Leave empty for all roles."`
+	ContextMessages int      `json:"context_messages,omitempty" jsonschema:"Number of messages to preview before and after each match (default: none)"`
+	Limit           int      `json:"limit,omitempty" jsonschema:"Maximum number of matches to return (default: 20)"`
+	Offset          int      `json:"offset,omitempty" jsonschema:"Number of matches to skip, to page through many matches"`
+	Highlight       string   `json:"highlight,omitempty" jsonschema:"Highlight matched words in snippets: 'em' wraps them in <em></em> tags, 'marker' wraps them in ** markers. Leave empty for no highlighting."`
+}
+
+func addFindInSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_in_session",
+		Description: "Find the messages of one session that mention a query, to locate the relevant part of a long session without paging through it. Returns each match's message index with snippets and previews of the messages around it; read more around a match with get_session, whose page with the default order and no filters is message_index / page_size.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args findInSessionArgs) (*mcp.CallToolResult, any, error) {
+		if strings.TrimSpace(args.Query) == "" {
+			return nil, nil, fmt.Errorf("query is required")
+		}
+		if args.Limit == 0 {
+			args.Limit = 20
+		}
+		if args.Limit < 0 || args.Offset < 0 {
+			return nil, nil, fmt.Errorf("limit and offset must not be negative")
+		}
+		if args.ContextMessages < 0 {
+			return nil, nil, fmt.Errorf("context_messages must not be negative")
+		}
+		pre, post, err := highlightMarkers(args.Highlight)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		matches := findInMessages(messages, args.Query, findOptions{
+			Filter:   messageFilter{Roles: args.Roles},
+			Context:  args.ContextMessages,
+			Snippets: search.SnippetOptions{Length: 200, HighlightPre: pre, HighlightPost: post},
+		})
+
+		total := len(matches)
+		matches = matches[min(args.Offset, total):]
+		hasMore := len(matches) > args.Limit
+		if hasMore {
+			matches = matches[:args.Limit]
+		}
+		return jsonToolResult(map[string]interface{}{
+			"session_id":     args.SessionID,
+			"source":         args.Source,
+			"query":          args.Query,
+			"matches":        matches,
+			"count":          len(matches),
+			"total_matches":  total,
+			"has_more":       hasMore,
+			"total_messages": len(messages),
+		})
+	})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+func TestFindInMessages(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "The webhook retry loop never ends"},
+		{Role: "assistant", Content: "Let me look.", ToolCalls: []adapters.ToolCall{{Name: "Bash", Input: map[string]interface{}{"command": "grep -r retry webhook/"}}}},
+		{Role: "user", ToolResults: []adapters.ToolResult{{Output: "webhook/send.go: maxRetries := 0"}}, Metadata: map[string]interface{}{"is_tool_result": true}},
+		{Role: "assistant", Content: "The webhook sender never stops retrying.", Thinking: "maxRetries is zero"},
+		{Role: "user", Content: "Thanks"},
+	}
+
+	matches := findInMessages(messages, "webhook retry", findOptions{})
+	var indexes []int
+	for _, match := range matches {
+		indexes = append(indexes, match.MessageIndex)
+	}
+	if len(matches) != 3 || indexes[0] != 0 || indexes[1] != 1 || indexes[2] != 3 {
+		t.Fatalf("expected the messages having both words, got %v", indexes)
+	}
+	if fields := strings.Join(matches[1].Fields, ","); fields != "tool_input" {
+		t.Fatalf("expected the match in the tool call, got %s", fields)
+	}
+
+	matches = findInMessages(messages, "MAXRETRIES", findOptions{
+		Context:  1,
+		Snippets: search.SnippetOptions{HighlightPre: "**", HighlightPost: "**"},
+	})
+	if len(matches) != 2 || matches[0].MessageIndex != 2 || matches[1].MessageIndex != 3 {
+		t.Fatalf("expected the tool output and the thinking to match, got %+v", matches)
+	}
+	if len(matches[0].Snippets) != 1 || !strings.Contains(matches[0].Snippets[0], "**maxRetries**") {
+		t.Fatalf("expected a highlighted snippet, got %v", matches[0].Snippets)
+	}
+	before, after := matches[0].Before, matches[0].After
+	if len(before) != 1 || before[0].MessageIndex != 1 || !strings.Contains(before[0].Content, "Let me look.") {
+		t.Fatalf("unexpected previous message: %+v", before)
+	}
+	if len(after) != 1 || after[0].MessageIndex != 3 {
+		t.Fatalf("unexpected next message: %+v", after)
+	}
+	if matches[1].After[0].Content != "Thanks" {
+		t.Fatalf("unexpected preview: %+v", matches[1].After)
+	}
+
+	matches = findInMessages(messages, "webhook", findOptions{Filter: messageFilter{Roles: []string{"user"}}})
+	if len(matches) != 1 || matches[0].MessageIndex != 0 {
+		t.Fatalf("expected only the user's message, got %+v", matches)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// ignoreRules are the projects whose sessions are never listed, indexed, searched, or
+// uploaded, from ~/.aisessions/ignore and the .aisessionsignore files of projects
+var ignoreRules = newIgnoreRules()
+
+func newIgnoreRules() *adapters.IgnoreRules {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return adapters.NewIgnoreRules("")
+	}
+	return adapters.NewIgnoreRules(filepath.Join(homeDir, configDir, ignoreFile))
+}
+
+// withIgnoreRules hides the sessions of ignored projects from an adapter. Until an ignore
+// file is found, the adapter is listed with the caller's limit and sessions are read
+// without listing their source, as if it weren't wrapped. It stays wrapped since the
+// .aisessionsignore files of projects are only found as their sessions are listed.
+func withIgnoreRules(adapter adapters.SessionAdapter) adapters.SessionAdapter {
+	if ignoreRules == nil {
+		return adapter
+	}
+	return adapters.NewGuardedAdapter(adapter, adapters.Guard{Ignore: ignoreRules})
+}
+
+// pruneIgnoredSessions removes the sessions of projects ignored since they were indexed
+// from the search cache
+func pruneIgnoredSessions(cache *search.Cache) {
+	if ignoreRules == nil {
+		return
+	}
+	if _, err := cache.RemoveProjects(ignoreRules.Ignored); err != nil {
+		slog.Warn("Failed to remove ignored sessions from the search cache", "error", err)
+	}
+}
+
+// checkIgnoredFile refuses to upload a transcript file that lies in an ignored project
+// or records that it was made in one. Claude Code and Codex record the directory a
+// session ran in as "cwd" near the start of the file.
+func checkIgnoredFile(file string) error {
+	if ignoreRules == nil {
+		return nil
+	}
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	projects := []string{filepath.Dir(path)}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for i := 0; i < 20 && scanner.Scan(); i++ {
+		var line struct {
+			CWD     string `json:"cwd"`
+			Payload struct {
+				CWD string `json:"cwd"`
+			} `json:"payload"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &line) != nil {
+			continue
+		}
+		projects = append(projects, line.CWD, line.Payload.CWD)
+	}
+
+	for _, project := range projects {
+		if ignoreRules.Ignored(project) {
+			return fmt.Errorf("%s is a session of the ignored project %s (see ~/.aisessions/ignore and .aisessionsignore files)", file, project)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestCheckIgnoredFile(t *testing.T) {
+	root := t.TempDir()
+	globalPath := filepath.Join(root, "ignore")
+	if err := os.WriteFile(globalPath, []byte(filepath.Join(root, "secret")+"\n"), 0o644); err != nil {
+		t.Fatalf("write ignore file: %v", err)
+	}
+	defer func(rules *adapters.IgnoreRules) { ignoreRules = rules }(ignoreRules)
+	ignoreRules = adapters.NewIgnoreRules(globalPath)
+
+	writeSession := func(name, cwd string) string {
+		path := filepath.Join(root, name)
+		line := `{"type":"user","cwd":"` + cwd + `","message":{"role":"user","content":"hi"}}` + "\n"
+		if err := os.WriteFile(path, []byte(line), 0o644); err != nil {
+			t.Fatalf("write session: %v", err)
+		}
+		return path
+	}
+
+	if err := checkIgnoredFile(writeSession("ok.jsonl", filepath.Join(root, "app"))); err != nil {
+		t.Fatalf("expected a session of another project to be uploadable: %v", err)
+	}
+	if err := checkIgnoredFile(writeSession("secret.jsonl", filepath.Join(root, "secret", "api"))); err == nil {
+		t.Fatal("expected a session recorded in an ignored project to be refused")
+	}
+	if err := os.MkdirAll(filepath.Join(root, "secret"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := checkIgnoredFile(writeSession(filepath.Join("secret", "notes.txt"), "")); err == nil {
+		t.Fatal("expected a file inside an ignored project to be refused")
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	// keychainService and keychainAccount identify the token in the system keychain.
+	// Tokens of named profiles have accounts of their own.
+	keychainService = "aisessions"
+	keychainAccount = "token"
+)
+
+// errNoKeychainToken is returned when the keychain has no token
+var errNoKeychainToken = errors.New("no token in the keychain")
+
+// keychain is a system credential store the CLI tokens are kept in, by account
+type keychain interface {
+	name() string
+	get(account string) (string, error)
+	set(account, token string) error
+	remove(account string) error
+}
+
+// tokenKeychain is the system keychain, or nil when there is none, in which case the
+// token is stored in the config file
+var tokenKeychain = systemKeychain()
+
+// systemKeychain returns the keychain of the OS: the macOS Keychain, the Secret
+// Service on Linux (through secret-tool), or a DPAPI-encrypted file on Windows
+func systemKeychain() keychain {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return macKeychain{}
+		}
+	case "windows":
+		if _, err := exec.LookPath("powershell"); err == nil {
+			return dpapiKeychain{}
+		}
+	default:
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return secretServiceKeychain{}
+		}
+	}
+	return nil
+}
+
+// runKeychainCommand runs a credential tool with input on stdin, so the token never
+// appears in a process listing, and returns its trimmed output
+func runKeychainCommand(input, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(input)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s failed: %s", name, message)
+		}
+		return "", fmt.Errorf("%s failed: %w", name, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// macKeychain stores the token as a generic password in the login keychain
+type macKeychain struct{}
+
+func (macKeychain) name() string { return "macOS Keychain" }
+
+func (macKeychain) get(account string) (string, error) {
+	token, err := runKeychainCommand("", "security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	if err != nil {
+		return "", errNoKeychainToken
+	}
+	return token, nil
+}
+
+func (macKeychain) set(account, token string) error {
+	// Interactive mode reads the command from stdin, keeping the token out of the
+	// arguments. Tokens are base64url, so quoting them is enough.
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w '%s'\n", keychainService, account, token)
+	_, err := runKeychainCommand(command, "security", "-i")
+	return err
+}
+
+func (macKeychain) remove(account string) error {
+	_, err := runKeychainCommand("", "security", "delete-generic-password", "-s", keychainService, "-a", account)
+	return err
+}
+
+// secretServiceKeychain stores the token with the Secret Service (GNOME Keyring, KWallet)
+type secretServiceKeychain struct{}
+
+func (secretServiceKeychain) name() string { return "Secret Service" }
+
+func (secretServiceKeychain) get(account string) (string, error) {
+	token, err := runKeychainCommand("", "secret-tool", "lookup", "service", keychainService, "account", account)
+	if err != nil || token == "" {
+		return "", errNoKeychainToken
+	}
+	return token, nil
+}
+
+func (secretServiceKeychain) set(account, token string) error {
+	_, err := runKeychainCommand(token, "secret-tool", "store", "--label=AI Sessions CLI token", "service", keychainService, "account", account)
+	return err
+}
+
+func (secretServiceKeychain) remove(account string) error {
+	_, err := runKeychainCommand("", "secret-tool", "clear", "service", keychainService, "account", account)
+	return err
+}
+
+// dpapiKeychain stores the token in a file encrypted with DPAPI, which only the
+// current Windows user can decrypt
+type dpapiKeychain struct{}
+
+func (dpapiKeychain) name() string { return "Windows DPAPI" }
+
+// path returns the encrypted token file of an account, beside the config file
+func (dpapiKeychain) path(account string) (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), account+".dpapi"), nil
+}
+
+func (k dpapiKeychain) get(account string) (string, error) {
+	path, err := k.path(account)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := os.ReadFile(path)
+	if err != nil {
+		return "", errNoKeychainToken
+	}
+	script := `$s = [Console]::In.ReadToEnd().Trim() | ConvertTo-SecureString; ` +
+		`[Runtime.InteropServices.Marshal]::PtrToStringBSTR([Runtime.InteropServices.Marshal]::SecureStringToBSTR($s))`
+	return runKeychainCommand(string(encrypted), "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+}
+
+func (k dpapiKeychain) set(account, token string) error {
+	path, err := k.path(account)
+	if err != nil {
+		return err
+	}
+	script := `[Console]::In.ReadToEnd().Trim() | ConvertTo-SecureString -AsPlainText -Force | ConvertFrom-SecureString`
+	encrypted, err := runKeychainCommand(token, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(encrypted), 0600); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	return nil
+}
+
+func (k dpapiKeychain) remove(account string) error {
+	path, err := k.path(account)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove token file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// fakeKeychain is an in-memory keychain, failing to store when broken
+type fakeKeychain struct {
+	tokens map[string]string
+	broken bool
+}
+
+func (fakeKeychain) name() string { return "fake keychain" }
+
+func (k fakeKeychain) get(account string) (string, error) {
+	if k.tokens[account] == "" {
+		return "", errNoKeychainToken
+	}
+	return k.tokens[account], nil
+}
+
+func (k fakeKeychain) set(account, token string) error {
+	if k.broken {
+		return errors.New("locked")
+	}
+	k.tokens[account] = token
+	return nil
+}
+
+func (k fakeKeychain) remove(account string) error {
+	delete(k.tokens, account)
+	return nil
+}
+
+func TestTokenKeychain(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	defer func(k keychain) { tokenKeychain = k }(tokenKeychain)
+	stored := make(map[string]string)
+	tokenKeychain = fakeKeychain{tokens: stored}
+
+	if err := saveLoginToken("", Config{Token: "abc.def.ghi"}); err != nil {
+		t.Fatalf("saveLoginToken failed: %v", err)
+	}
+	file, err := readConfigFile()
+	if err != nil || file.Token != "" || !file.Keychain || stored["token"] != "abc.def.ghi" {
+		t.Fatalf("expected the token in the keychain only, got %+v and %q (%v)", file, stored, err)
+	}
+	config, err := loadConfig("")
+	if err != nil || config.Token != "abc.def.ghi" {
+		t.Fatalf("expected loadConfig to read the keychain, got %+v (%v)", config, err)
+	}
+
+	var out strings.Builder
+	if err := runLogout("", false, &out); err != nil || !strings.Contains(out.String(), "Logged out") || len(stored) != 0 {
+		t.Fatalf("expected logout to remove the token, got %q (%v)", out.String(), err)
+	}
+	if _, err := loadConfig(""); err == nil {
+		t.Fatal("expected no token after logout")
+	}
+	out.Reset()
+	if err := runLogout("", true, &out); err != nil || !strings.Contains(out.String(), `"logged_out": false`) {
+		t.Fatalf("expected logging out twice to report not being logged in, got %q (%v)", out.String(), err)
+	}
+
+	// Without a working keychain the token is kept in the config file
+	tokenKeychain = fakeKeychain{tokens: stored, broken: true}
+	if err := saveLoginToken("", Config{Token: "abc.def.ghi"}); err != nil {
+		t.Fatalf("saveLoginToken failed: %v", err)
+	}
+	if file, _ := readConfigFile(); file.Token != "abc.def.ghi" || file.Keychain {
+		t.Fatalf("expected the token in the config file, got %+v", file)
+	}
+	if err := runLogout("", false, &out); err != nil {
+		t.Fatalf("runLogout failed: %v", err)
+	}
+	if file, _ := readConfigFile(); file.Token != "" {
+		t.Fatalf("expected logout to clear the config file token, got %+v", file)
+	}
+
+	// A config file that can't be read isn't replaced, losing its settings
+	path, err := getConfigPath()
+	if err != nil {
+		t.Fatalf("getConfigPath failed: %v", err)
+	}
+	invalid := []byte(`{"machines": [{"name": "laptop",}]}`)
+	if err := os.WriteFile(path, invalid, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	if err := saveLoginToken("", Config{Token: "abc.def.ghi"}); err == nil || !strings.Contains(err.Error(), "invalid config file") {
+		t.Fatalf("expected an error for the unreadable config file, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != string(invalid) {
+		t.Fatalf("expected the config file to be left alone, got %s", data)
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	defer func(k keychain) { tokenKeychain = k }(tokenKeychain)
+	stored := make(map[string]string)
+	tokenKeychain = fakeKeychain{tokens: stored}
+
+	if err := saveLoginToken("", Config{Token: "personal.token.x"}); err != nil {
+		t.Fatalf("saveLoginToken failed: %v", err)
+	}
+	if err := saveLoginToken("work", Config{Token: "work.token.x", APIURL: "https://sessions.example.com"}); err != nil {
+		t.Fatalf("saveLoginToken failed: %v", err)
+	}
+	if stored["token"] != "personal.token.x" || stored["token-work"] != "work.token.x" {
+		t.Fatalf("expected each profile's token under its own account, got %v", stored)
+	}
+
+	personal, err := loadConfig("")
+	if err != nil || personal.Token != "personal.token.x" || personal.APIURL != "" {
+		t.Fatalf("unexpected default profile %+v (%v)", personal, err)
+	}
+	work, err := loadConfig("work")
+	if err != nil || work.Token != "work.token.x" || work.APIURL != "https://sessions.example.com" {
+		t.Fatalf("unexpected work profile %+v (%v)", work, err)
+	}
+	if _, err := loadConfig("team"); err == nil || !strings.Contains(err.Error(), "login --profile team") {
+		t.Fatalf("expected an unknown profile to fail, got %v", err)
+	}
+
+	var out strings.Builder
+	if err := runLogout("work", false, &out); err != nil {
+		t.Fatalf("runLogout failed: %v", err)
+	}
+	if _, err := loadConfig("work"); err == nil {
+		t.Fatal("expected the work profile to be logged out")
+	}
+	if _, err := loadConfig(""); err != nil || stored["token"] == "" {
+		t.Fatalf("expected the default profile to stay logged in: %v", err)
+	}
+
+	var profile string
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	profileFlagVar(fs, &profile)
+	if err := fs.Parse([]string{"--profile", "default"}); err != nil || profile != "" {
+		t.Fatalf("expected --profile default to be the default profile, got %q (%v)", profile, err)
+	}
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse([]string{"--profile", "../work"}); err == nil {
+		t.Fatal("expected an invalid profile name to be rejected")
+	}
+}
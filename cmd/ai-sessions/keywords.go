@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// withKeywords sets the keywords of the sessions that are indexed, logging rather than
+// failing on errors since keywords are supplementary to the sessions they describe
+func withKeywords(searchCache *search.Cache, sessions []adapters.Session) []adapters.Session {
+	ids := make([]string, len(sessions))
+	for i, session := range sessions {
+		ids[i] = session.ID
+	}
+	keywords, err := searchCache.SessionKeywords(ids)
+	if err != nil {
+		slog.Warn("Failed to load keywords", "error", err)
+		return sessions
+	}
+	for i := range sessions {
+		sessions[i].Keywords = keywords[sessions[i].ID]
+	}
+	return sessions
+}
+
+// sessionsWithKeyword returns the sessions having a keyword, in order
+func sessionsWithKeyword(sessions []adapters.Session, keyword string) []adapters.Session {
+	keyword = strings.ToLower(strings.TrimSpace(keyword))
+	var matching []adapters.Session
+	for _, session := range sessions {
+		for _, k := range session.Keywords {
+			if k == keyword {
+				matching = append(matching, session)
+				break
+			}
+		}
+	}
+	return matching
+}
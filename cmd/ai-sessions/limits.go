@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// toolLimits guard the MCP server against pathological requests, such as a page of huge
+// tool outputs or a search indexing every session at once. A zero limit is no limit.
+type toolLimits struct {
+	MaxResultBytes     int `json:"max_result_bytes,omitempty"`      // Size of a tool result past which it is truncated
+	MaxSessionsScanned int `json:"max_sessions_scanned,omitempty"`  // Sessions a request may read to index them
+	ToolCallsPerMinute int `json:"tool_calls_per_minute,omitempty"` // Calls of each tool per minute
+}
+
+// defaultLimits are the limits of the MCP server for the settings left out of the config
+// file
+var defaultLimits = toolLimits{
+	MaxResultBytes:     1 << 20,
+	MaxSessionsScanned: 5000,
+	ToolCallsPerMinute: 120,
+}
+
+// limits are the limits in effect. The CLI has none; the MCP server sets them as it starts.
+var limits toolLimits
+
+// serverLimits returns the limits of the config file's limits setting: a limit left out
+// takes its default, and a negative one turns the limit off
+func serverLimits(config Config) toolLimits {
+	var l toolLimits
+	if config.Limits != nil {
+		l = *config.Limits
+	}
+	resolve := func(value, fallback int) int {
+		switch {
+		case value < 0:
+			return 0
+		case value == 0:
+			return fallback
+		default:
+			return value
+		}
+	}
+	return toolLimits{
+		MaxResultBytes:     resolve(l.MaxResultBytes, defaultLimits.MaxResultBytes),
+		MaxSessionsScanned: resolve(l.MaxSessionsScanned, defaultLimits.MaxSessionsScanned),
+		ToolCallsPerMinute: resolve(l.ToolCallsPerMinute, defaultLimits.ToolCallsPerMinute),
+	}
+}
+
+// toolLimiter enforces the limits on tool calls: it rejects the calls of a tool past its
+// rate, and truncates results past the size limit
+type toolLimiter struct {
+	limits toolLimits
+	now    func() time.Time
+
+	mu    sync.Mutex
+	calls map[string][]time.Time // Recent calls by tool, oldest first
+}
+
+func newToolLimiter(limits toolLimits) *toolLimiter {
+	return &toolLimiter{limits: limits, now: time.Now, calls: make(map[string][]time.Time)}
+}
+
+// middleware applies the limits to the tools/call requests
+func (l *toolLimiter) middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		call, ok := req.(*mcp.CallToolRequest)
+		if method != "tools/call" || !ok || call.Params == nil {
+			return next(ctx, method, req)
+		}
+		if wait := l.reserve(call.Params.Name); wait > 0 {
+			slog.Warn("Tool call rate limited", "tool", call.Params.Name)
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: fmt.Sprintf(
+					"rate limit exceeded: %s may be called %d times per minute; retry in %s",
+					call.Params.Name, l.limits.ToolCallsPerMinute, wait.Round(time.Second))}},
+			}, nil
+		}
+
+		result, err := next(ctx, method, req)
+		if toolResult, ok := result.(*mcp.CallToolResult); ok && err == nil {
+			truncateToolResult(toolResult, l.limits.MaxResultBytes)
+		}
+		return result, err
+	}
+}
+
+// reserve records a call of a tool, or returns how long to wait before it is allowed
+func (l *toolLimiter) reserve(tool string) time.Duration {
+	if l.limits.ToolCallsPerMinute <= 0 {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	recent := l.calls[tool]
+	for len(recent) > 0 && now.Sub(recent[0]) >= time.Minute {
+		recent = recent[1:]
+	}
+	if len(recent) >= l.limits.ToolCallsPerMinute {
+		l.calls[tool] = recent
+		return time.Minute - now.Sub(recent[0])
+	}
+	l.calls[tool] = append(recent, now)
+	return 0
+}
+
+// truncationNotice follows the text of a truncated tool result
+type truncationNotice struct {
+	Truncated      bool   `json:"truncated"`
+	ResultBytes    int    `json:"result_bytes"`
+	ReturnedBytes  int    `json:"returned_bytes"`
+	MaxResultBytes int    `json:"max_result_bytes"`
+	Hint           string `json:"hint"`
+}
+
+// truncateToolResult cuts the text of a result down to maxBytes, and adds a notice saying
+// so. The text is cut whole characters at a time, so a truncated JSON result is no longer
+// valid JSON; the notice is.
+func truncateToolResult(result *mcp.CallToolResult, maxBytes int) {
+	if maxBytes <= 0 {
+		return
+	}
+	total := 0
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			total += len(text.Text)
+		}
+	}
+	if total <= maxBytes {
+		return
+	}
+
+	returned, cut := 0, false
+	kept := result.Content[:0]
+	for _, content := range result.Content {
+		text, ok := content.(*mcp.TextContent)
+		if !ok {
+			kept = append(kept, content)
+			continue
+		}
+		if cut {
+			continue
+		}
+		if returned+len(text.Text) > maxBytes {
+			text.Text = text.Text[:textutil.ClusterStart(text.Text, maxBytes-returned)]
+			cut = true
+		}
+		returned += len(text.Text)
+		kept = append(kept, text)
+	}
+
+	notice, _ := json.Marshal(truncationNotice{
+		Truncated:      true,
+		ResultBytes:    total,
+		ReturnedBytes:  returned,
+		MaxResultBytes: maxBytes,
+		Hint:           "The result was cut off. Ask for less at a time: a smaller page_size or limit, filters, or the next page.",
+	})
+	result.Content = append(kept, &mcp.TextContent{Text: string(notice)})
+	slog.Warn("Tool result truncated", "bytes", total, "max_bytes", maxBytes)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestServerLimits(t *testing.T) {
+	if got := serverLimits(Config{}); got != defaultLimits {
+		t.Fatalf("expected the defaults without a setting, got %+v", got)
+	}
+	got := serverLimits(Config{Limits: &toolLimits{MaxResultBytes: 4096, ToolCallsPerMinute: -1}})
+	want := toolLimits{MaxResultBytes: 4096, MaxSessionsScanned: defaultLimits.MaxSessionsScanned}
+	if got != want {
+		t.Fatalf("serverLimits = %+v, want %+v", got, want)
+	}
+}
+
+func TestToolLimiterRateLimitsEachTool(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newToolLimiter(toolLimits{ToolCallsPerMinute: 2})
+	limiter.now = func() time.Time { return now }
+	handler := limiter.middleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+	})
+	call := func(tool string) *mcp.CallToolResult {
+		t.Helper()
+		result, err := handler(context.Background(), "tools/call", &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: tool}})
+		if err != nil {
+			t.Fatalf("call failed: %v", err)
+		}
+		return result.(*mcp.CallToolResult)
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := call("search_sessions"); result.IsError {
+			t.Fatalf("expected call %d to be allowed", i+1)
+		}
+		now = now.Add(10 * time.Second)
+	}
+	result := call("search_sessions")
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "retry in 40s") {
+		t.Fatalf("expected the third call to be rate limited, got %+v", result.Content[0])
+	}
+	if result := call("get_session"); result.IsError {
+		t.Fatal("expected other tools to have their own limit")
+	}
+
+	now = now.Add(41 * time.Second)
+	if result := call("search_sessions"); result.IsError {
+		t.Fatal("expected the call to be allowed once the first call is a minute old")
+	}
+}
+
+func TestTruncateToolResult(t *testing.T) {
+	result := &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: strings.Repeat("é", 10)}}}
+	truncateToolResult(result, 25)
+	if len(result.Content) != 1 {
+		t.Fatalf("expected a result within the limit to be left alone, got %d contents", len(result.Content))
+	}
+
+	truncateToolResult(result, 7)
+	if len(result.Content) != 2 {
+		t.Fatalf("expected the text and a notice, got %d contents", len(result.Content))
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; text != "ééé" {
+		t.Fatalf("expected the text cut between characters, got %q", text)
+	}
+	var notice truncationNotice
+	if err := json.Unmarshal([]byte(result.Content[1].(*mcp.TextContent).Text), &notice); err != nil {
+		t.Fatalf("expected a JSON notice: %v", err)
+	}
+	if !notice.Truncated || notice.ResultBytes != 20 || notice.ReturnedBytes != 6 || notice.MaxResultBytes != 7 {
+		t.Fatalf("unexpected notice: %+v", notice)
+	}
+}
+
+func TestIndexSessionsScanLimit(t *testing.T) {
+	defer func(saved toolLimits) { limits = saved }(limits)
+	limits = toolLimits{MaxSessionsScanned: 2}
+
+	cache := newTestCache(t)
+	dir := t.TempDir()
+	var sessions []adapters.Session
+	messages := make(map[string][]adapters.Message)
+	for _, id := range []string{"a", "b", "c"} {
+		path := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(path, []byte("dummy"), 0o644); err != nil {
+			t.Fatalf("failed to create session file: %v", err)
+		}
+		sessions = append(sessions, adapters.Session{ID: id, Source: "claude", Timestamp: time.Now(), FilePath: path})
+		messages[id] = []adapters.Message{{Role: "user", Content: "deploy"}}
+	}
+	stub := newStubAdapter(sessions, messages)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	warnings := &warningCollector{}
+	if _, err := indexSessionsWithWarnings(context.Background(), adaptersMap, cache, "", "", warnings); err != nil {
+		t.Fatalf("indexSessions failed: %v", err)
+	}
+	list, _ := warnings.list()
+	if len(list) != 1 || list[0].Source != "claude" || !strings.Contains(list[0].Reason, "1 sessions are left to index") {
+		t.Fatalf("expected a scan limit warning, got %+v", list)
+	}
+	if len(stub.getCalls) != 2 {
+		t.Fatalf("expected 2 sessions to be read, got %d", len(stub.getCalls))
+	}
+
+	// The next request indexes the rest
+	warnings = &warningCollector{}
+	if _, err := indexSessionsWithWarnings(context.Background(), adaptersMap, cache, "", "", warnings); err != nil {
+		t.Fatalf("indexSessions failed: %v", err)
+	}
+	if list, _ := warnings.list(); len(list) != 0 || len(stub.getCalls) != 3 {
+		t.Fatalf("expected the last session to be indexed, got %+v and %d reads", list, len(stub.getCalls))
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// defaultListLimit is how many sessions `aisessions list` prints by default
+const defaultListLimit = 50
+
+var listCommand = cliCommand{
+	name:    "list",
+	summary: "List recent sessions",
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts listOptions
+		fs.StringVar(&opts.Source, "source", "", "only list sessions of this `source`")
+		fs.StringVar(&opts.ProjectPath, "project", "", "only list sessions of a `project`: a path, a glob, or a directory name")
+		fs.IntVar(&opts.Limit, "limit", defaultListLimit, "list at most `n` sessions, 0 for all")
+		fs.StringVar(&opts.Filter.Since, "since", "", "only list sessions since a `window` like 7d, 2w, or 12h, or a date")
+		fs.DurationVar(&opts.Filter.MinDuration, "min-duration", 0, "only list sessions lasting at least `duration`, like 30m")
+		fs.IntVar(&opts.Filter.MinUserMessages, "min-messages", 0, "only list sessions in which the user wrote at least `n` messages")
+		fs.StringVar(&opts.Filter.SortBy, "sort", "", "sort by `order`: "+strings.Join(sessionOrderNames(), ", ")+" (default: recent)")
+		fs.BoolVar(&opts.ShowDuplicates, "duplicates", false, "list every copy of a duplicated session")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			// Copies with different IDs are found by the content hashes of the cache
+			cache, err := env.searchCache()
+			if err != nil {
+				slog.Warn("Failed to open the search cache", "error", err)
+			}
+			return runListCommand(env.sessionAdapters(), cache, opts, env.stdout)
+		}
+	},
+}
+
+// listOptions are the options of `aisessions list`
+type listOptions struct {
+	Source         string
+	ProjectPath    string
+	Limit          int // 0 lists every session
+	Filter         sessionFilterOptions
+	ShowDuplicates bool // Lists every copy of a session rather than collapsing them
+	JSON           bool
+}
+
+func runListCommand(adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, opts listOptions, stdout io.Writer) error {
+	if opts.Limit < 0 {
+		return fmt.Errorf("invalid --limit value %d: use a number (0 = no limit)", opts.Limit)
+	}
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+
+	filter, err := newSessionFilter(opts.Filter)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := collectSessions(adaptersMap, opts.Source, opts.ProjectPath)
+	if err != nil {
+		return err
+	}
+	if !opts.ShowDuplicates {
+		sessions = collapseDuplicates(cache, sessions)
+	}
+	sessions = filter.apply(sessions)
+	if opts.Limit > 0 && len(sessions) > opts.Limit {
+		sessions = sessions[:opts.Limit]
+	}
+
+	if opts.JSON {
+		if sessions == nil {
+			sessions = []adapters.Session{}
+		}
+		return printJSON(stdout, sessions)
+	}
+
+	if len(sessions) == 0 {
+		fmt.Fprintln(stdout, "No sessions found")
+		return nil
+	}
+	width := getTerminalWidth()
+	fmt.Fprintln(stdout, formatTableHeader())
+	for _, session := range sessions {
+		fmt.Fprintln(stdout, formatSessionRow(session, width))
+	}
+	return nil
+}
+
+// sessionOrders are the orders sessions can be listed in besides the default, most
+// recent first. Sessions that compare equal stay most recent first.
+var sessionOrders = map[string]func(a, b adapters.Session) bool{
+	"duration":   func(a, b adapters.Session) bool { return a.DurationSeconds > b.DurationSeconds },
+	"turns":      func(a, b adapters.Session) bool { return a.AssistantTurns > b.AssistantTurns },
+	"tool_calls": func(a, b adapters.Session) bool { return a.ToolCallCount > b.ToolCallCount },
+}
+
+// sessionOrderNames returns the names of the orders sessions can be listed in
+func sessionOrderNames() []string {
+	names := []string{"recent"}
+	for name := range sessionOrders {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// sessionFilterOptions select and order the sessions of a listing. The zero value lists
+// every session, most recent first.
+type sessionFilterOptions struct {
+	Since           string // A window or date (see parseSince)
+	MinDuration     time.Duration
+	MinUserMessages int
+	SortBy          string // A key of sessionOrders, or "recent"
+}
+
+// sessionFilter applies sessionFilterOptions to a listing
+type sessionFilter struct {
+	since           time.Time
+	minDuration     time.Duration
+	minUserMessages int
+	less            func(a, b adapters.Session) bool // nil keeps the most recent first
+}
+
+// newSessionFilter validates opts and builds their filter
+func newSessionFilter(opts sessionFilterOptions) (sessionFilter, error) {
+	var filter sessionFilter
+	var err error
+	if filter.since, err = parseSince(opts.Since, time.Now()); err != nil {
+		return filter, err
+	}
+	if opts.MinDuration < 0 {
+		return filter, fmt.Errorf("invalid minimum duration %s: use a positive duration", opts.MinDuration)
+	}
+	if opts.MinUserMessages < 0 {
+		return filter, fmt.Errorf("invalid minimum user messages %d: use a positive number", opts.MinUserMessages)
+	}
+	filter.minDuration = opts.MinDuration
+	filter.minUserMessages = opts.MinUserMessages
+	if opts.SortBy != "" && opts.SortBy != "recent" {
+		less, ok := sessionOrders[opts.SortBy]
+		if !ok {
+			return filter, fmt.Errorf("unknown sort order %q: use one of %s", opts.SortBy, strings.Join(sessionOrderNames(), ", "))
+		}
+		filter.less = less
+	}
+	return filter, nil
+}
+
+// active reports whether the filter changes a listing, which then has to consider every
+// session rather than only the most recent ones
+func (f sessionFilter) active() bool {
+	return !f.since.IsZero() || f.minDuration > 0 || f.minUserMessages > 0 || f.less != nil
+}
+
+// apply returns the sessions the filter selects, in its order. sessions must be sorted
+// most recent first.
+func (f sessionFilter) apply(sessions []adapters.Session) []adapters.Session {
+	if !f.active() {
+		return sessions
+	}
+	var selected []adapters.Session
+	for _, session := range sessions {
+		if session.Timestamp.Before(f.since) ||
+			time.Duration(session.DurationSeconds)*time.Second < f.minDuration ||
+			session.UserMessageCount < f.minUserMessages {
+			continue
+		}
+		selected = append(selected, session)
+	}
+	if f.less != nil {
+		sort.SliceStable(selected, func(i, j int) bool { return f.less(selected[i], selected[j]) })
+	}
+	return selected
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunListCommand(t *testing.T) {
+	now := time.Now()
+	stub := newStubAdapter([]adapters.Session{
+		{ID: "s1", Source: "claude", ProjectPath: "/work/app", FirstMessage: "Add dark mode", Timestamp: now, UserMessageCount: 3},
+		{ID: "s2", Source: "claude", ProjectPath: "/work/api", FirstMessage: "Fix the flaky test", Timestamp: now.Add(-time.Hour), UserMessageCount: 1},
+	}, nil)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--limit", "1"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "PROJECT") || !strings.Contains(lines[1], "Add dark mode") {
+		t.Fatalf("expected a header and the newest session, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--json", "--source", "claude"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var sessions []adapters.Session
+	if err := json.Unmarshal(out.Bytes(), &sessions); err != nil || len(sessions) != 2 || sessions[1].ID != "s2" {
+		t.Fatalf("expected both sessions as JSON, got %s (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--json", "--min-messages", "2"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &sessions); err != nil || len(sessions) != 1 || sessions[0].ID != "s1" {
+		t.Fatalf("expected only the session with 3 user messages, got %s (%v)", out.String(), err)
+	}
+
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--source", "cursor"); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--limit", "many"); err == nil {
+		t.Fatal("expected an error for an invalid limit")
+	}
+}
+
+func TestRunListCommandFiltersAndSortsByActivity(t *testing.T) {
+	now := time.Now()
+	stub := newStubAdapter([]adapters.Session{
+		{ID: "quick", Source: "claude", FirstMessage: "Rename a variable", Timestamp: now, DurationSeconds: 120, AssistantTurns: 1},
+		{ID: "long", Source: "claude", FirstMessage: "Migrate the database", Timestamp: now.Add(-time.Hour), DurationSeconds: 3 * 3600, AssistantTurns: 4},
+		{ID: "longer", Source: "claude", FirstMessage: "Rewrite the parser", Timestamp: now.Add(-2 * time.Hour), DurationSeconds: 5 * 3600, AssistantTurns: 9},
+		{ID: "old", Source: "claude", FirstMessage: "Set up CI", Timestamp: now.AddDate(0, 0, -30), DurationSeconds: 8 * 3600, AssistantTurns: 20},
+	}, nil)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	list := func(args ...string) []string {
+		t.Helper()
+		var out bytes.Buffer
+		if err := runTestCLI(adaptersMap, newTestCache(t), &out, append([]string{"list", "--json"}, args...)...); err != nil {
+			t.Fatalf("list %v failed: %v", args, err)
+		}
+		var sessions []adapters.Session
+		if err := json.Unmarshal(out.Bytes(), &sessions); err != nil {
+			t.Fatalf("decode sessions: %v", err)
+		}
+		var ids []string
+		for _, session := range sessions {
+			ids = append(ids, session.ID)
+		}
+		return ids
+	}
+
+	if got := strings.Join(list("--since", "1w", "--min-duration", "1h", "--sort", "duration"), " "); got != "longer long" {
+		t.Fatalf("expected the long sessions of the last week, longest first, got %q", got)
+	}
+	if got := strings.Join(list("--sort", "turns", "--limit", "2"), " "); got != "old longer" {
+		t.Fatalf("expected the sessions with the most turns, got %q", got)
+	}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--sort", "size"); err == nil {
+		t.Fatal("expected an error for an unknown sort order")
+	}
+}
+
+func TestRunListCommandCollapsesDuplicates(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	sessions := []adapters.Session{
+		{ID: "resumed", Source: "claude", FilePath: filepath.Join(dir, "resumed.jsonl"), FirstMessage: "Fix the webhook", Timestamp: now},
+		{ID: "moved", Source: "claude", FilePath: filepath.Join(dir, "new", "moved.jsonl"), FirstMessage: "Add retries", Timestamp: now.Add(-time.Hour)},
+		{ID: "copy", Source: "claude", FilePath: filepath.Join(dir, "copy.jsonl"), FirstMessage: "Fix the webhook", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "moved", Source: "claude", FilePath: filepath.Join(dir, "old", "moved.jsonl"), FirstMessage: "Add retries", Timestamp: now.Add(-3 * time.Hour)},
+	}
+	cache := newTestCache(t)
+	for _, session := range []adapters.Session{sessions[0], sessions[2]} {
+		if err := os.WriteFile(session.FilePath, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("failed to write session file: %v", err)
+		}
+		if err := cache.IndexSession(session, "fix the webhook signature check"); err != nil {
+			t.Fatalf("failed to index session: %v", err)
+		}
+	}
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": newStubAdapter(sessions, nil)}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "--json", "list"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	var listed []adapters.Session
+	if err := json.Unmarshal(out.Bytes(), &listed); err != nil || len(listed) != 2 {
+		t.Fatalf("expected copies to be collapsed, got %s (%v)", out.String(), err)
+	}
+	if listed[0].ID != "resumed" || len(listed[0].Duplicates) != 1 || listed[0].Duplicates[0].ID != "copy" {
+		t.Fatalf("expected the copy with the same content under the newest session, got %+v", listed[0])
+	}
+	if listed[1].ID != "moved" || len(listed[1].Duplicates) != 1 || listed[1].Duplicates[0].FilePath != sessions[3].FilePath {
+		t.Fatalf("expected the copy with the same ID under the newest session, got %+v", listed[1])
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "--json", "list", "--duplicates"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &listed); err != nil || len(listed) != 4 {
+		t.Fatalf("expected every copy with --duplicates, got %s (%v)", out.String(), err)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// logFileName is the log file kept in ~/.cache/ai-sessions when logging to a file
+	logFileName = "aisessions.log"
+
+	// maxLogFileSize is the size past which the log file is moved to <name>.1 and a new
+	// one started, keeping at most two files
+	maxLogFileSize = 5 << 20
+)
+
+// logOptions choose where log records go and from which level
+type logOptions struct {
+	Level  slog.Level
+	Stderr bool // Log to stderr
+	File   bool // Log to ~/.cache/ai-sessions/aisessions.log
+}
+
+// setupLogging sends log records (those of the log package included) where opts say.
+// Nothing is ever logged to stdout, which carries the MCP protocol of the server. The
+// returned function closes the log file.
+func setupLogging(stderr io.Writer, opts logOptions) (func(), error) {
+	var writers []io.Writer
+	if opts.Stderr {
+		writers = append(writers, stderr)
+	}
+	closeFile := func() {}
+	if opts.File {
+		file, err := openLogFile()
+		if err != nil {
+			return closeFile, err
+		}
+		writers = append(writers, file)
+		closeFile = func() { file.Close() }
+	}
+
+	w := io.Discard
+	if len(writers) > 0 {
+		w = io.MultiWriter(writers...)
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: opts.Level})))
+	return closeFile, nil
+}
+
+// openLogFile opens the log file for appending, rotating it when it has grown too large
+func openLogFile() (*os.File, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
+	}
+	dir := filepath.Join(homeDir, ".cache", "ai-sessions")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	path := filepath.Join(dir, logFileName)
+	if info, err := os.Stat(path); err == nil && info.Size() > maxLogFileSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return file, nil
+}
+
+// serverLogOptions returns where the MCP server logs: warnings to stderr, which MCP
+// clients show in their logs, everything down to debug with --verbose, and only errors
+// with --quiet. The log file is written with --log-file or the config file's log_file.
+func serverLogOptions(opts serverOptions, config Config) logOptions {
+	level := slog.LevelWarn
+	switch {
+	case opts.Verbose:
+		level = slog.LevelDebug
+	case opts.Quiet:
+		level = slog.LevelError
+	}
+	return logOptions{Level: level, Stderr: true, File: opts.LogFile || config.LogFile}
+}
+
+// cliLogOptions returns where CLI commands log: warnings to stderr, so problems such as
+// sessions that could not be read aren't hidden, and everything down to debug with
+// --verbose. The log file is written when the config file sets log_file.
+func cliLogOptions(verbose bool, config Config) logOptions {
+	if verbose {
+		return logOptions{Level: slog.LevelDebug, Stderr: true, File: config.LogFile}
+	}
+	return logOptions{Level: slog.LevelWarn, Stderr: true, File: config.LogFile}
+}
+
+// fatal logs an error that stops the MCP server and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
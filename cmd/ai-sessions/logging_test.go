@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupLogging(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	defer func(l *slog.Logger) { slog.SetDefault(l) }(slog.Default())
+
+	var stderr bytes.Buffer
+	closeLog, err := setupLogging(&stderr, serverLogOptions(serverOptions{Quiet: true}, Config{LogFile: true}))
+	if err != nil {
+		t.Fatalf("setupLogging failed: %v", err)
+	}
+	slog.Warn("skipped warning")
+	slog.Error("reported error", "source", "claude")
+	closeLog()
+
+	if strings.Contains(stderr.String(), "skipped warning") || !strings.Contains(stderr.String(), "source=claude") {
+		t.Fatalf("expected only the error on stderr, got %q", stderr.String())
+	}
+	logged, err := os.ReadFile(filepath.Join(tempHome, ".cache", "ai-sessions", logFileName))
+	if err != nil || !strings.Contains(string(logged), "reported error") {
+		t.Fatalf("expected the error in the log file, got %q (%v)", logged, err)
+	}
+
+	stderr.Reset()
+	closeLog, err = setupLogging(&stderr, cliLogOptions(false, Config{}))
+	if err != nil {
+		t.Fatalf("setupLogging failed: %v", err)
+	}
+	slog.Debug("hidden diagnostic")
+	slog.Warn("reported warning")
+	closeLog()
+	if strings.Contains(stderr.String(), "hidden diagnostic") || !strings.Contains(stderr.String(), "reported warning") {
+		t.Fatalf("expected CLI commands to log only warnings without --verbose, got %q", stderr.String())
+	}
+}
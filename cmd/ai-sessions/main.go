@@ -8,94 +8,254 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
 	"os"
 	"path/filepath"
-	"sort"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
 	"github.com/yoavf/ai-sessions-mcp/search"
 )
 
 func main() {
 	// Check if running in CLI mode (has command arguments)
-	if len(os.Args) > 1 {
+	if len(os.Args) > 1 && !isServerFlag(os.Args[1]) {
 		handleCLI()
 		return
 	}
+	serverOpts, err := parseServerOptions(os.Args[1:], os.Stderr)
+	if err != nil {
+		fatal("Invalid server options", err)
+	}
+	selection := serverOpts.Selection
 
 	// Otherwise, run as MCP server
 	// Create the MCP server with metadata
+	// The session watcher is created once the sources are open, before the server runs
+	var watcher *sessionWatcher
 	opts := &mcp.ServerOptions{
 		Instructions: "This server provides access to AI assistant CLI sessions from Claude Code, Gemini CLI, OpenAI Codex, and opencode. Use the tools to search, list, and read previous coding sessions.",
+		SubscribeHandler: func(ctx context.Context, req *mcp.SubscribeRequest) error {
+			return watcher.subscribe(ctx, req)
+		},
+		UnsubscribeHandler: func(context.Context, *mcp.UnsubscribeRequest) error { return nil },
 	}
 
 	server := mcp.NewServer(&mcp.Implementation{
 		Name:    "ai-sessions",
-		Version: "1.0.0",
+		Version: cliVersion,
 	}, opts)
 
-	// Initialize adapters
-	adaptersMap := make(map[string]adapters.SessionAdapter)
-	if claudeAdapter, err := adapters.NewClaudeAdapter(); err == nil {
-		adaptersMap["claude"] = claudeAdapter
+	// The privacy settings fail closed: without them the server could expose more than
+	// the user allowed
+	config, err := readConfigFile()
+	if err != nil {
+		fatal("Failed to read privacy settings", err)
 	}
-	if geminiAdapter, err := adapters.NewGeminiAdapter(); err == nil {
-		adaptersMap["gemini"] = geminiAdapter
+	// Logs go to stderr (and the log file), never to stdout, which carries the protocol
+	logOpts := serverLogOptions(serverOpts, config)
+	config.LogFile = logOpts.File
+	closeLog, err := setupLogging(os.Stderr, logOpts)
+	if err != nil {
+		fatal("Failed to open the log file", err)
 	}
-	if codexAdapter, err := adapters.NewCodexAdapter(); err == nil {
-		adaptersMap["codex"] = codexAdapter
+	defer closeLog()
+	if serverOpts.SelectionErr != nil {
+		slog.Warn("Ignoring source settings", "error", serverOpts.SelectionErr)
 	}
-	if opencodeAdapter, err := adapters.NewOpencodeAdapter(); err == nil {
-		adaptersMap["opencode"] = opencodeAdapter
+	guard := serverGuard(config)
+	limits = serverLimits(config)
+	if serverOpts.Pprof != "" {
+		_, stopProfiling, err := startProfiling(serverOpts.Pprof)
+		if err != nil {
+			fatal("Failed to start profiling", err)
+		}
+		defer stopProfiling()
 	}
 
+	// SIGINT and SIGTERM cancel the requests in flight, then close the search cache
+	ctx, stopInterrupt := interruptContext()
+	defer stopInterrupt()
+
+	// Initialize adapters
+	machines := configuredMachines()
+	adaptersMap, sourceStatuses := openAdapters(selection, machines)
+	keepRemoteMachinesSynced(ctx, machines, selection)
+	guardAdapters(adaptersMap, guard)
+
 	// Initialize search cache
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		log.Fatalf("Failed to get home directory: %v", err)
-	}
-	cachePath := filepath.Join(homeDir, ".cache", "ai-sessions", "search.db")
-	searchCache, err := search.NewCache(cachePath)
+	searchCache, err := openServerSearchCache(guard)
 	if err != nil {
-		log.Fatalf("Failed to initialize search cache: %v", err)
+		fatal("Failed to initialize search cache", err)
 	}
 	defer searchCache.Close()
+	useMetadataCache(adaptersMap, searchCache)
+	shareProjectPaths(adaptersMap)
+	watcher = newSessionWatcher(ctx, adaptersMap, notifySessionsUpdated(server))
 
 	// Add tools with strongly-typed argument structures
-	addListAvailableSourcesTool(server, adaptersMap)
-	addListSessionsTool(server, adaptersMap)
+	addListAvailableSourcesTool(server, adaptersMap, sourceStatuses)
+	addListSessionsTool(server, adaptersMap, searchCache)
 	addSearchSessionsTool(server, adaptersMap, searchCache)
-	addGetSessionTool(server, adaptersMap)
+	addGetSessionTool(server, adaptersMap, searchCache)
+	addFindInSessionTool(server, adaptersMap)
+	addGetSessionSummaryTool(server, adaptersMap)
+	addGetSessionFilesTool(server, adaptersMap)
+	addGetSessionDiffsTool(server, adaptersMap)
+	addGetSessionCommandsTool(server, adaptersMap)
+	addFindSessionsByFileTool(server, adaptersMap, searchCache)
+	addFindSessionsByCommitTool(server, adaptersMap, searchCache)
+	addSearchErrorsTool(server, adaptersMap, searchCache)
+	addSessionStatsTool(server, adaptersMap)
+	addFindSimilarSessionsTool(server, adaptersMap, searchCache)
+	addDiffSessionsTool(server, adaptersMap, searchCache)
+	if !config.ReadOnly {
+		addTagSessionTool(server, adaptersMap, searchCache)
+	}
+	addListTagsTool(server, searchCache)
+	addFindSessionsByTagTool(server, searchCache)
+	if !config.ReadOnly {
+		addSaveSearchTool(server, adaptersMap, searchCache)
+	}
+	addListSavedSearchesTool(server, searchCache)
+	addRunSavedSearchTool(server, adaptersMap, searchCache)
+	if !config.ReadOnly {
+		addAddSessionNoteTool(server, adaptersMap, searchCache)
+	}
+	addGroupSessionsByProjectTool(server, adaptersMap, searchCache)
+	addGetSessionTreeTool(server, adaptersMap)
+	addSessionCostsTool(server, adaptersMap)
+	addExportSessionTool(server, adaptersMap)
+	addScanSessionTool(server, adaptersMap)
+	if config.MCPUploads && !config.ReadOnly {
+		addUploadSessionTool(server, adaptersMap)
+	}
+	addServerInfoTool(server, adaptersMap, sourceStatuses, searchCache, config)
+	addWatchSessionsTool(server, watcher)
+	addGetActiveSessionsTool(server, adaptersMap)
+	addRecentSessionsResource(server, watcher)
+
+	requests := &requestTracker{shutdown: ctx}
+	server.AddReceivingMiddleware(requests.middleware, newToolLimiter(limits).middleware)
+
+	// Run the server over stdio. Once it stops, wait for the requests in flight so the
+	// search cache isn't closed under them; the deferred calls close it cleanly.
+	err = server.Run(ctx, &mcp.StdioTransport{})
+	if !requests.wait(shutdownGrace) {
+		slog.Warn("Requests still running at shutdown")
+	}
+	if err != nil && !errors.Is(err, context.Canceled) {
+		fatal("Server error", err)
+	}
+	slog.Info("Server stopped")
+}
+
+// newAdapters creates an adapter for every source enabled in the config file that can
+// be initialized. Remote machines are synced when their sessions are first read, so
+// commands that only read local sessions don't wait on SSH.
+func newAdapters() map[string]adapters.SessionAdapter {
+	selection, err := configuredSources()
+	if err != nil {
+		slog.Warn("Ignoring source settings", "error", err)
+	}
+	machines := configuredMachines()
+	for i := range machines {
+		if machine := machines[i]; machine.SSH != "" {
+			machines[i].sync = sync.OnceFunc(func() {
+				syncRemoteMachines([]machineConfig{machine}, selection, remoteSyncInterval)
+			})
+		}
+	}
+	adaptersMap, _ := openAdapters(selection, machines)
+	return adaptersMap
+}
+
+// useMetadataCache lets adapters reuse the listing metadata of unchanged session files
+// stored in the search cache instead of parsing every file on each listing
+func useMetadataCache(adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache) {
+	for _, adapter := range adaptersMap {
+		if user, ok := adapter.(adapters.MetadataCacheUser); ok {
+			user.SetMetadataCache(cache)
+		}
+	}
+}
+
+// projectHintsTTL is how long the projects of the other sources are reused to recover
+// the projects of Gemini sessions before the sources are listed again
+const projectHintsTTL = time.Minute
 
-	// Run the server over stdio
-	if err := server.Run(context.Background(), &mcp.StdioTransport{}); err != nil {
-		log.Fatalf("Server error: %v", err)
+// shareProjectPaths lets adapters that don't always know a session's project (Gemini
+// only records a hash of its path) recover it from the projects of the other sources.
+// The other sources are listed at most once per projectHintsTTL, since sessions whose
+// project can't be recovered would otherwise have every listing list them all again.
+func shareProjectPaths(adaptersMap map[string]adapters.SessionAdapter) {
+	for name, adapter := range adaptersMap {
+		user, ok := adapter.(adapters.ProjectHintUser)
+		if !ok {
+			continue
+		}
+		others := make(map[string]adapters.SessionAdapter, len(adaptersMap))
+		for otherName, other := range adaptersMap {
+			if otherName != name {
+				others[otherName] = other
+			}
+		}
+		var (
+			mu       sync.Mutex
+			paths    []string
+			listedAt time.Time
+		)
+		user.SetProjectHints(func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			if !listedAt.IsZero() && time.Since(listedAt) < projectHintsTTL {
+				return paths
+			}
+			sessions, _, _ := listSources(context.Background(), others, "", "", 0)
+			seen := make(map[string]bool)
+			paths = nil
+			for _, session := range sessions {
+				if session.ProjectPath != "" && filepath.IsAbs(session.ProjectPath) && !seen[session.ProjectPath] {
+					seen[session.ProjectPath] = true
+					paths = append(paths, session.ProjectPath)
+				}
+			}
+			listedAt = time.Now()
+			return paths
+		})
 	}
 }
 
 // Tool 1: list_available_sources
 type listAvailableSourcesArgs struct{}
 
-func addListAvailableSourcesTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+func addListAvailableSourcesTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, statuses []sourceStatus) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list_available_sources",
-		Description: "List which AI CLI sources have sessions available (e.g., claude, gemini, codex, opencode)",
+		Description: "List which AI CLI sources have sessions available (e.g., claude, gemini, codex, opencode), and which are disabled or unavailable",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args listAvailableSourcesArgs) (*mcp.CallToolResult, any, error) {
 		available := make([]map[string]interface{}, 0, len(adaptersMap))
-		for name, adapter := range adaptersMap {
-			available = append(available, map[string]interface{}{
-				"source":    name,
-				"full_name": adapter.Name(),
-			})
+		for _, status := range statuses {
+			if adapter, ok := adaptersMap[status.Source]; ok {
+				available = append(available, map[string]interface{}{
+					"source":    status.Source,
+					"full_name": adapter.Name(),
+				})
+			}
 		}
 
 		result := map[string]interface{}{
 			"available_sources": available,
 			"count":             len(available),
+			"sources":           statuses,
 		}
 
 		resultJSON, err := json.MarshalIndent(result, "", "  ")
@@ -113,59 +273,106 @@ func addListAvailableSourcesTool(server *mcp.Server, adaptersMap map[string]adap
 
 // Tool 2: list_sessions
 type listSessionsArgs struct {
-	Source      string `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
-	ProjectPath string `json:"project_path,omitempty" jsonschema:"Filter by project directory path. Leave empty for current directory."`
-	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return"`
+	Source          string   `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
+	ProjectPath     string   `json:"project_path,omitempty" jsonschema:"Filter by project: a path (absolute or relative), a glob like '~/work/*', or a directory name like 'ai-sessions-mcp'. Leave empty for all projects."`
+	Limit           int      `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return"`
+	ExpandChains    bool     `json:"expand_chains,omitempty" jsonschema:"List resumed sessions separately instead of collapsing each chain into its latest session"`
+	Since           string   `json:"since,omitempty" jsonschema:"Only list sessions started after this point: a relative window like '7d', '2w', '12h', or a date like '2025-01-31'"`
+	MinDuration     int      `json:"min_duration_minutes,omitempty" jsonschema:"Only list sessions lasting at least this many minutes, from their first to their last message"`
+	MinUserMessages int      `json:"min_user_messages,omitempty" jsonschema:"Only list sessions in which the user wrote at least this many messages. 1 skips the empty sessions agents create on startup."`
+	SortBy          string   `json:"sort_by,omitempty" jsonschema:"Order of the sessions: 'recent' (default), 'duration', 'turns' (assistant replies to the user), or 'tool_calls'"`
+	Keyword         string   `json:"keyword,omitempty" jsonschema:"Only list sessions having this keyword, one of the distinctive terms computed for each session when it is indexed"`
+	ShowDuplicates  bool     `json:"show_duplicates,omitempty" jsonschema:"List every copy of a duplicated session instead of collapsing the copies into one entry"`
+	ExcludeSource   []string `json:"exclude_source,omitempty" jsonschema:"Leave out sessions of these sources, e.g. ['gemini', 'opencode']"`
+	ExcludeProject  []string `json:"exclude_project,omitempty" jsonschema:"Leave out sessions of these projects, each a path, a glob, or a directory name as for project_path"`
 }
 
-func addListSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+func addListSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list_sessions",
-		Description: "List recent AI assistant sessions with optional filtering by source and project",
+		Description: "List recent AI assistant sessions with optional filtering by source, project, and keyword, or leaving out sources and projects. Indexed sessions list their keywords: their most distinctive terms. Copies of a session (the same ID, or the same indexed text) are listed once, with the other copies in its duplicates.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args listSessionsArgs) (*mcp.CallToolResult, any, error) {
 		if args.Limit == 0 {
 			args.Limit = 10
 		}
+		filter, err := newSessionFilter(sessionFilterOptions{
+			Since:           args.Since,
+			MinDuration:     time.Duration(args.MinDuration) * time.Minute,
+			MinUserMessages: args.MinUserMessages,
+			SortBy:          args.SortBy,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		exclusions := sessionExclusions{Sources: args.ExcludeSource, Projects: args.ExcludeProject}
+		listAdapters, err := exclusions.adapters(adaptersMap)
+		if err != nil {
+			return nil, nil, err
+		}
 
-		var allSessions []adapters.Session
+		// Filtering and sorting look at every session, not just the latest ones
+		listLimit := args.Limit
+		if filter.active() || args.Keyword != "" || len(exclusions.Projects) > 0 {
+			listLimit = 0
+		}
 
-		// Determine which adapters to query
-		adaptersToQuery := make(map[string]adapters.SessionAdapter)
-		if args.Source != "" {
-			if adapter, ok := adaptersMap[args.Source]; ok {
-				adaptersToQuery[args.Source] = adapter
-			} else {
-				return nil, nil, fmt.Errorf("unknown source: %s", args.Source)
+		// Keywords are computed as sessions are indexed
+		warnings := &warningCollector{}
+		if args.Keyword != "" {
+			if _, err := indexSessionsWithWarnings(ctx, listAdapters, searchCache, args.Source, args.ProjectPath, warnings); err != nil {
+				slog.Warn("Failed to index sessions", "error", err)
 			}
-		} else {
-			adaptersToQuery = adaptersMap
 		}
 
-		// Query each adapter
-		for _, adapter := range adaptersToQuery {
-			sessions, err := adapter.ListSessions(args.ProjectPath, args.Limit)
-			if err != nil {
-				// Log error but continue with other adapters
-				log.Printf("Error listing sessions for %s: %v", adapter.Name(), err)
-				continue
-			}
-			allSessions = append(allSessions, sessions...)
+		// Query every source concurrently so a slow one doesn't hold up the rest
+		allSessions, failedSources, err := listSources(ctx, listAdapters, args.Source, args.ProjectPath, listLimit)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, failure := range failedSources {
+			slog.Warn("Failed to list sessions", "source", failure.Source, "error", failure.Error)
 		}
+		warnings.addSourceErrors("list", failedSources)
+		allSessions = exclusions.apply(allSessions)
 
-		// Sort by timestamp (newest first)
-		sort.Slice(allSessions, func(i, j int) bool {
-			return allSessions[i].Timestamp.After(allSessions[j].Timestamp)
-		})
+		// Show a resumed conversation once, under its latest session
+		if !args.ExpandChains {
+			allSessions = adapters.LinkChains(allSessions)
+		}
+		if !args.ShowDuplicates {
+			allSessions = collapseDuplicates(searchCache, allSessions)
+		}
+		allSessions = withKeywords(searchCache, filter.apply(allSessions))
+		if args.Keyword != "" {
+			allSessions = sessionsWithKeyword(allSessions, args.Keyword)
+		}
 
 		// Apply limit
 		if args.Limit > 0 && len(allSessions) > args.Limit {
 			allSessions = allSessions[:args.Limit]
 		}
 
+		annotated := annotateSessions(searchCache, allSessions)
+		for i := range annotated {
+			stats, err := sessionListingStats(adaptersMap, searchCache, annotated[i].Session)
+			if err == nil {
+				annotated[i].MessageCount = stats.MessageCount
+				annotated[i].EstimatedTokens = stats.EstimatedTokens
+				annotated[i].Cost = stats.Cost
+			} else {
+				warnings.add(resultWarning{Source: annotated[i].Source, SessionID: annotated[i].ID, Operation: "read", Reason: err.Error()})
+			}
+			annotated[i].Title = stats.Title
+		}
+
 		result := map[string]interface{}{
-			"sessions": allSessions,
+			"sessions": annotated,
 			"count":    len(allSessions),
 		}
+		if len(failedSources) > 0 {
+			result["failed_sources"] = failedSources
+		}
+		warnings.addTo(result)
 
 		resultJSON, err := json.MarshalIndent(result, "", "  ")
 		if err != nil {
@@ -182,10 +389,32 @@ func addListSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.Ses
 
 // Tool 3: search_sessions
 type searchSessionsArgs struct {
-	Query       string `json:"query" jsonschema:"Search query to find in session content"`
-	Source      string `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
-	ProjectPath string `json:"project_path,omitempty" jsonschema:"Filter by project directory path. Leave empty for current directory."`
-	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of matching sessions to return"`
+	Query          string   `json:"query" jsonschema:"Search query to find in session content. Field filters narrow it down: role:user (or assistant, tool, system) only counts words in messages with that role, file:main.go only matches sessions that touched the file (a path or glob), source:codex and project:myrepo only match sessions of that source or project. Quote values with spaces, e.g. project:\"my repo\"."`
+	Source         string   `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
+	ProjectPath    string   `json:"project_path,omitempty" jsonschema:"Filter by project: a path (absolute or relative), a glob like '~/work/*', or a directory name like 'ai-sessions-mcp'. Leave empty for all projects."`
+	Limit          int      `json:"limit,omitempty" jsonschema:"Maximum number of matching sessions to return"`
+	Snippets       int      `json:"snippets,omitempty" jsonschema:"Maximum number of snippets to return per session (default: 1)"`
+	SnippetLength  int      `json:"snippet_length,omitempty" jsonschema:"Approximate length of each snippet in characters (default: 300)"`
+	Highlight      string   `json:"highlight,omitempty" jsonschema:"Highlight matched terms in snippets: 'em' wraps them in <em></em> tags, 'marker' wraps them in ** markers. Leave empty for no highlighting."`
+	Tag            string   `json:"tag,omitempty" jsonschema:"Only return sessions carrying this tag"`
+	Keyword        string   `json:"keyword,omitempty" jsonschema:"Only return sessions having this keyword, one of the distinctive terms computed for each session when it is indexed"`
+	ShowDuplicates bool     `json:"show_duplicates,omitempty" jsonschema:"Return every copy of a duplicated session instead of collapsing the copies into the best match"`
+	ExcludeSource  []string `json:"exclude_source,omitempty" jsonschema:"Leave out sessions of these sources, e.g. ['gemini', 'opencode']"`
+	ExcludeProject []string `json:"exclude_project,omitempty" jsonschema:"Leave out sessions of these projects, each a path, a glob, or a directory name as for project_path"`
+}
+
+// highlightMarkers returns the opening and closing markers for a highlight style
+func highlightMarkers(style string) (string, string, error) {
+	switch style {
+	case "", "none":
+		return "", "", nil
+	case "em":
+		return "<em>", "</em>", nil
+	case "marker":
+		return "**", "**", nil
+	default:
+		return "", "", fmt.Errorf("unknown highlight style: %s (use 'em' or 'marker')", style)
+	}
 }
 
 func addSearchSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
@@ -193,161 +422,607 @@ func addSearchSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.S
 		Name:        "search_sessions",
 		Description: "Search through session content using BM25 ranking for relevance",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args searchSessionsArgs) (*mcp.CallToolResult, any, error) {
-		if args.Query == "" {
-			return nil, nil, fmt.Errorf("query is required")
+		result, err := searchSessions(ctx, adaptersMap, searchCache, args)
+		if err != nil {
+			return nil, nil, err
 		}
+		return jsonToolResult(result)
+	})
+}
 
-		if args.Limit == 0 {
-			args.Limit = 10
-		}
+// searchSessions runs a search_sessions query, indexing new and changed sessions first
+func searchSessions(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache, args searchSessionsArgs) (map[string]interface{}, error) {
+	if args.Query == "" {
+		return nil, fmt.Errorf("query is required")
+	}
 
-		// Lazy indexing: index sessions that need it
-		if err := indexSessions(adaptersMap, searchCache, args.Source, args.ProjectPath); err != nil {
-			log.Printf("Warning: indexing error: %v", err)
-			// Continue with search anyway - we may have some indexed data
-		}
+	if args.Limit == 0 {
+		args.Limit = 10
+	}
 
-		// Perform BM25 search (snippets are extracted from cached content)
-		results, err := searchCache.Search(args.Query, args.Source, args.ProjectPath, args.Limit)
-		if err != nil {
-			return nil, nil, fmt.Errorf("search failed: %w", err)
-		}
+	pre, post, err := highlightMarkers(args.Highlight)
+	if err != nil {
+		return nil, err
+	}
+	exclusions := sessionExclusions{Sources: args.ExcludeSource, Projects: args.ExcludeProject}
+	indexAdapters, err := exclusions.adapters(adaptersMap)
+	if err != nil {
+		return nil, err
+	}
 
-		// Convert to session list with scores and snippets
-		matches := make([]map[string]interface{}, len(results))
-		for i, result := range results {
-			matches[i] = map[string]interface{}{
-				"session": result.Session,
-				"score":   result.Score,
-				"snippet": result.Snippet,
-			}
-		}
+	// Lazy indexing: index sessions that need it
+	warnings := &warningCollector{}
+	failedSources, err := indexSessionsWithWarnings(ctx, indexAdapters, searchCache, args.Source, args.ProjectPath, warnings)
+	if err != nil {
+		slog.Warn("Failed to index sessions", "error", err)
+		// Continue with search anyway - we may have some indexed data
+	}
 
-		result := map[string]interface{}{
-			"query":   args.Query,
-			"matches": matches,
-			"count":   len(matches),
-		}
+	// Perform BM25 search (snippets are extracted from cached content)
+	results, err := searchCache.SearchWithOptions(args.Query, search.SearchOptions{
+		Source:             args.Source,
+		ProjectPath:        args.ProjectPath,
+		Limit:              args.Limit,
+		Tag:                args.Tag,
+		Keyword:            args.Keyword,
+		LoadContent:        contentLoader(adaptersMap),
+		ExcludeSources:     exclusions.Sources,
+		ExcludeProjects:    exclusions.Projects,
+		CollapseDuplicates: !args.ShowDuplicates,
+		Snippets: search.SnippetOptions{
+			MaxSnippets:   args.Snippets,
+			Length:        args.SnippetLength,
+			HighlightPre:  pre,
+			HighlightPost: post,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("search failed: %w", err)
+	}
 
-		resultJSON, err := json.MarshalIndent(result, "", "  ")
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	// Convert to session list with scores and snippets
+	matches := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		matches[i] = map[string]interface{}{
+			"session":  result.Session,
+			"score":    result.Score,
+			"snippet":  result.Snippet,
+			"snippets": result.Snippets,
+		}
+		if notes := sessionNotes(searchCache, result.Session); len(notes) > 0 {
+			matches[i]["notes"] = notes
 		}
+	}
 
-		return &mcp.CallToolResult{
-			Content: []mcp.Content{
-				&mcp.TextContent{Text: string(resultJSON)},
-			},
-		}, nil, nil
-	})
+	result := map[string]interface{}{
+		"query":   args.Query,
+		"matches": matches,
+		"count":   len(matches),
+	}
+	if suggestions, corrected := suggestCorrections(searchCache, args.Query); len(suggestions) > 0 {
+		result["suggestions"] = suggestions
+		result["did_you_mean"] = corrected
+	}
+	if len(failedSources) > 0 {
+		result["failed_sources"] = failedSources
+	}
+	warnings.addTo(result)
+	return result, nil
 }
 
-// indexSessions lazily indexes sessions that need updating
-func indexSessions(adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, source string, projectPath string) error {
-	// Determine which adapters to index
-	adaptersToQuery := make(map[string]adapters.SessionAdapter)
-	if source != "" {
-		if adapter, ok := adaptersMap[source]; ok {
-			adaptersToQuery[source] = adapter
-		}
-	} else {
-		adaptersToQuery = adaptersMap
+// suggestCorrections returns the indexed terms closest to the query's terms that match
+// no session, and the query corrected with the best of them. A typo otherwise silently
+// returns nothing, or only the sessions matching the other terms.
+func suggestCorrections(searchCache *search.Cache, query string) ([]search.TermSuggestion, string) {
+	suggestions, err := searchCache.Suggest(query)
+	if err != nil {
+		slog.Warn("Failed to suggest corrections", "error", err)
+		return nil, ""
 	}
+	return suggestions, search.CorrectQuery(query, suggestions)
+}
+
+// indexSessions lazily indexes sessions that need updating. Sources are indexed
+// concurrently, each within indexTimeout; sources that fail or run out of time are
+// returned so callers can report them, and their remaining sessions are indexed next time.
+// An unknown source is an error, as it is for listing, rather than silently indexing nothing.
+func indexSessions(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, source string, projectPath string) ([]sourceError, error) {
+	return indexSessionsWithWarnings(ctx, adaptersMap, cache, source, projectPath, nil)
+}
+
+// indexSessionsWithWarnings indexes sessions like indexSessions, adding a warning for
+// each source that failed and each session that could not be read or indexed
+func indexSessionsWithWarnings(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, source string, projectPath string, warnings *warningCollector) ([]sourceError, error) {
+	adaptersToQuery, err := selectAdapters(adaptersMap, source)
+	if err != nil {
+		return nil, err
+	}
+
+	pruneIgnoredSessions(cache)
+	projects := adapters.NewProjectFilter(projectPath)
+
+	// The cache is shared by every source, so its reads and writes are serialized
+	var cacheMu sync.Mutex
 
-	// Index sessions from each adapter
-	for _, adapter := range adaptersToQuery {
-		sessions, err := adapter.ListSessions(projectPath, 0) // Get all sessions
+	// Reading sessions is what makes indexing slow, so a request reads at most
+	// limits.MaxSessionsScanned of them; the rest are indexed by later requests. Both
+	// counts are guarded by cacheMu.
+	scanned := 0
+	unscanned := make(map[string]int)
+
+	failures := fanOut(ctx, adaptersToQuery, indexTimeout, func(ctx context.Context, name string, adapter adapters.SessionAdapter) error {
+		sessions, err := adapters.ListSessionsContext(ctx, adapter, projects.Path(), 0) // Get all sessions
 		if err != nil {
-			log.Printf("Error listing sessions for %s: %v", adapter.Name(), err)
-			continue
+			return err
 		}
 
 		for _, session := range sessions {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !projects.Matches(session.ProjectPath) {
+				continue
+			}
+
 			// Check if session needs reindexing
+			cacheMu.Lock()
 			needsReindex, err := cache.NeedsReindex(session.ID, session.FilePath)
+			overLimit := false
+			if err == nil && needsReindex {
+				if overLimit = limits.MaxSessionsScanned > 0 && scanned >= limits.MaxSessionsScanned; overLimit {
+					unscanned[name]++
+				} else {
+					scanned++
+				}
+			}
+			cacheMu.Unlock()
 			if err != nil {
-				log.Printf("Error checking if session needs reindex: %v", err)
+				slog.Warn("Failed to check if session needs reindex", "session", session.ID, "error", err)
+				warnings.add(resultWarning{Source: name, SessionID: session.ID, Operation: "index", Reason: err.Error()})
 				continue
 			}
 
-			if !needsReindex {
+			if !needsReindex || overLimit {
 				continue
 			}
 
-			// Get full session content for indexing
-			messages, err := adapter.GetSession(session.ID, 0, 100000) // Get all messages
+			// Get full session content for indexing. Reading one session can't be
+			// interrupted, so the deadline is checked between sessions.
+			messages, err := readSession(adaptersMap, session)
 			if err != nil {
-				log.Printf("Error getting session %s: %v", session.ID, err)
+				slog.Warn("Failed to read session", "session", session.ID, "error", err)
+				warnings.add(resultWarning{Source: name, SessionID: session.ID, Operation: "read", Reason: err.Error()})
 				continue
 			}
 
-			// Combine all message content
-			contentParts := make([]string, 0, len(messages)+2)
-			if session.FirstMessage != "" {
-				contentParts = append(contentParts, session.FirstMessage)
-			}
-			if session.Summary != "" {
-				contentParts = append(contentParts, session.Summary)
-			}
-			for _, msg := range messages {
-				if msg.Content != "" {
-					contentParts = append(contentParts, msg.Content)
-				}
+			content := sessionContent(session, messages)
+			session.Title = analysis.SessionTitle(session, messages)
+			session.MessageCount = len(messages) // Counting subagent transcripts too
+			details := search.SessionDetails{
+				Files:       analysis.FileActivities(messages),
+				Commits:     analysis.Commits(messages),
+				Errors:      analysis.Errors(messages),
+				RoleContent: roleContent(messages),
 			}
-			content := strings.Join(contentParts, " ")
+			session.Branch = analysis.SessionBranch(session, details.Commits)
 
-			// Index the session
-			if err := cache.IndexSession(session, content); err != nil {
-				log.Printf("Error indexing session %s: %v", session.ID, err)
+			// Index the session along with the files it touched, the commits it created,
+			// the errors it ran into, and its text by role
+			cacheMu.Lock()
+			err = cache.IndexSessionWithDetails(ctx, session, content, details)
+			cacheMu.Unlock()
+			if err != nil {
+				slog.Warn("Failed to index session", "session", session.ID, "error", err)
+				warnings.add(resultWarning{Source: name, SessionID: session.ID, Operation: "index", Reason: err.Error()})
 				continue
 			}
 		}
+		return nil
+	})
+
+	// Indexing was aborted, such as by a shutdown: what was indexed is kept
+	if err := ctx.Err(); err != nil {
+		return failures, err
+	}
+	for _, failure := range failures {
+		slog.Warn("Failed to index sessions", "source", failure.Source, "error", failure.Error)
+	}
+	warnings.addSourceErrors("index", failures)
+	for _, name := range slices.Sorted(maps.Keys(unscanned)) {
+		slog.Info("Scan limit reached, leaving sessions to index later", "source", name, "sessions", unscanned[name])
+		warnings.add(resultWarning{Source: name, Operation: "index", Reason: fmt.Sprintf(
+			"scan limit of %d sessions per request reached; %d sessions are left to index on later requests", limits.MaxSessionsScanned, unscanned[name])})
+	}
+	return failures, nil
+}
+
+// sessionContent combines the text of a session that is indexed and searched
+func sessionContent(session adapters.Session, messages []adapters.Message) string {
+	contentParts := make([]string, 0, len(messages)+2)
+	if session.FirstMessage != "" {
+		contentParts = append(contentParts, session.FirstMessage)
+	}
+	if session.Summary != "" {
+		contentParts = append(contentParts, session.Summary)
+	}
+	for _, msg := range messages {
+		if msg.Content != "" {
+			contentParts = append(contentParts, msg.Content)
+		}
+	}
+	return strings.Join(contentParts, " ")
+}
+
+// roleContent combines the indexed text of a session's messages by role, for role:
+// searches
+func roleContent(messages []adapters.Message) map[string]string {
+	parts := make(map[string][]string)
+	for _, msg := range messages {
+		if msg.Content != "" {
+			role := messageRole(msg)
+			parts[role] = append(parts[role], msg.Content)
+		}
+	}
+	content := make(map[string]string, len(parts))
+	for role, texts := range parts {
+		content[role] = strings.Join(texts, " ")
+	}
+	return content
+}
+
+// contentLoader reads the text of sessions whose text the search cache doesn't store,
+// for their snippets
+func contentLoader(adaptersMap map[string]adapters.SessionAdapter) func(adapters.Session) (string, error) {
+	return func(session adapters.Session) (string, error) {
+		messages, err := readSession(adaptersMap, session)
+		if err != nil {
+			return "", err
+		}
+		return sessionContent(session, messages), nil
+	}
+}
+
+// allMessagesPageSize is a page size large enough to fetch every message of a session in one call
+const allMessagesPageSize = 100000
+
+// loadSessionMessages validates the source/session arguments shared by session tools
+// and returns every message of the session.
+func loadSessionMessages(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string) ([]adapters.Message, error) {
+	adapter, err := sessionAdapter(adaptersMap, source, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := adapter.GetSession(sessionID, 0, allMessagesPageSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return messages, nil
+}
+
+// sessionAdapter validates the source/session arguments shared by session tools and
+// returns the adapter of the source.
+func sessionAdapter(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string) (adapters.SessionAdapter, error) {
+	if sessionID == "" {
+		return nil, fmt.Errorf("session_id is required")
+	}
+	if source == "" {
+		return nil, fmt.Errorf("source is required")
+	}
+
+	adapter, ok := adaptersMap[source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source: %s", source)
+	}
+	return adapter, nil
+}
+
+// readSession loads every message of a listed session, reading its file directly when
+// the adapter supports it instead of looking the session up by ID
+func readSession(adaptersMap map[string]adapters.SessionAdapter, session adapters.Session) ([]adapters.Message, error) {
+	adapter, ok := adaptersMap[session.Source]
+	if !ok {
+		return nil, fmt.Errorf("unknown source: %s", session.Source)
+	}
+	if reader, ok := adapter.(adapters.SessionReader); ok && session.FilePath != "" {
+		messages, err := reader.ReadSession(session)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read session: %w", err)
+		}
+		return messages, nil
+	}
+	return loadSessionMessages(adaptersMap, session.Source, session.ID)
+}
+
+// listingStats are the figures list_sessions shows for a session that take reading its
+// messages
+type listingStats struct {
+	MessageCount    int     `json:"message_count"`
+	EstimatedTokens int     `json:"estimated_tokens"`
+	Cost            float64 `json:"cost"`
+	Title           string  `json:"title"`
+}
+
+// listingStatsVersion is bumped when the figures are computed differently, so stale
+// cached ones are not reused
+const listingStatsVersion = 1
+
+// sessionListingStats returns the listing figures of a session, cached in the session
+// metadata of the search cache while its file keeps the same modification time and size.
+// Only the title is set when the session can't be read.
+func sessionListingStats(adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache, session adapters.Session) (listingStats, error) {
+	var stat os.FileInfo
+	key := fmt.Sprintf("listing-v%d:%s:%s:%s:%s", listingStatsVersion, session.Source, session.Machine, session.ID, session.FilePath)
+	if session.FilePath != "" {
+		stat, _ = os.Stat(session.FilePath)
+	}
+	if stat != nil {
+		if data, ok := searchCache.GetMetadata(key, stat.ModTime(), session.FileSize); ok {
+			var stats listingStats
+			if json.Unmarshal(data, &stats) == nil {
+				return stats, nil
+			}
+		}
 	}
 
-	return nil
+	messages, err := readSession(adaptersMap, session)
+	if err != nil {
+		return listingStats{Title: analysis.SessionTitle(session, nil)}, err
+	}
+	stats := listingStats{
+		MessageCount:    len(messages),
+		EstimatedTokens: analysis.SessionTokens(messages),
+		Cost:            analysis.SessionCost(messages),
+		Title:           analysis.SessionTitle(session, messages),
+	}
+	if data, err := json.Marshal(stats); err == nil && stat != nil {
+		_ = searchCache.PutMetadata(key, session.FilePath, stat.ModTime(), session.FileSize, data) // Caching is best effort
+	}
+	return stats, nil
+}
+
+// cachedSessionTotals stores the totals get_session reports for a filtered session in the
+// session metadata of the search cache, while the session's file keeps the same
+// modification time and size. Sessions whose file isn't recorded aren't cached.
+type cachedSessionTotals struct {
+	cache *search.Cache
+	key   string
+	path  string
+	stat  os.FileInfo
+}
+
+func newCachedSessionTotals(searchCache *search.Cache, source, sessionID string, filter messageFilter) cachedSessionTotals {
+	filterKey, _ := json.Marshal(filter)
+	cached := cachedSessionTotals{cache: searchCache, key: fmt.Sprintf("totals-v1:%s:%s:%s", source, sessionID, filterKey)}
+	if searchCache == nil {
+		return cached
+	}
+	if path, ok := searchCache.GetSessionFile(source, sessionID); ok {
+		cached.path = path
+		cached.stat, _ = os.Stat(path)
+	}
+	return cached
+}
+
+// get returns the stored totals, or nil if there are none for the file's current state
+func (c cachedSessionTotals) get() *sessionTotals {
+	if c.stat == nil {
+		return nil
+	}
+	data, ok := c.cache.GetMetadata(c.key, c.stat.ModTime(), c.stat.Size())
+	if !ok {
+		return nil
+	}
+	var totals sessionTotals
+	if json.Unmarshal(data, &totals) != nil {
+		return nil
+	}
+	return &totals
+}
+
+// put stores the totals read from the file's current state
+func (c cachedSessionTotals) put(totals sessionTotals) {
+	if c.stat == nil {
+		return
+	}
+	if data, err := json.Marshal(totals); err == nil {
+		_ = c.cache.PutMetadata(c.key, c.path, c.stat.ModTime(), c.stat.Size(), data) // Caching is best effort
+	}
+}
+
+// openSearchCache opens the search cache at ~/.cache/ai-sessions/search.db
+func openSearchCache() (*search.Cache, error) {
+	cache, err := openCacheFile("search.db")
+	if err != nil {
+		return nil, err
+	}
+	pruneIgnoredSessions(cache)
+	pruneStaleMetadata(cache)
+	return cache, nil
+}
+
+// pruneStaleMetadata removes the cached metadata of session files that were deleted
+func pruneStaleMetadata(cache *search.Cache) {
+	if _, err := cache.PruneMetadata(); err != nil {
+		slog.Warn("Failed to prune session metadata from the search cache", "error", err)
+	}
+}
+
+// collectSessions lists every session from the given source (or all sources when empty),
+// newest first. Sources are queried concurrently; those that fail are logged and skipped.
+func collectSessions(adaptersMap map[string]adapters.SessionAdapter, source, projectPath string) ([]adapters.Session, error) {
+	sessions, failures, err := listSources(context.Background(), adaptersMap, source, projectPath, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, failure := range failures {
+		slog.Warn("Failed to list sessions", "source", failure.Source, "error", failure.Error)
+	}
+	return sessions, nil
+}
+
+// jsonToolResult marshals a tool result as indented JSON text content
+func jsonToolResult(result interface{}) (*mcp.CallToolResult, any, error) {
+	resultJSON, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal result: %w", err)
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: string(resultJSON)},
+		},
+	}, nil, nil
 }
 
 // Tool 4: get_session
 type getSessionArgs struct {
-	SessionID string `json:"session_id" jsonschema:"The session ID to retrieve"`
-	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
-	Page      int    `json:"page,omitempty" jsonschema:"Page number for pagination (0-indexed)"`
-	PageSize  int    `json:"page_size,omitempty" jsonschema:"Number of messages per page"`
+	SessionID          string   `json:"session_id" jsonschema:"The session ID to retrieve"`
+	Source             string   `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Page               int      `json:"page,omitempty" jsonschema:"Page number for pagination (0-indexed)"`
+	PageSize           int      `json:"page_size,omitempty" jsonschema:"Number of messages per page"`
+	Order              string   `json:"order,omitempty" jsonschema:"Message order: 'asc' (oldest first, default) or 'desc' (most recent first)"`
+	Roles              []string `json:"roles,omitempty" jsonschema:"Only return messages with these roles (user, assistant, tool, system). Leave empty for all roles."`
+	ExcludeToolOutputs bool     `json:"exclude_tool_outputs,omitempty" jsonschema:"Drop tool output messages and tool call blocks, keeping just the conversation"`
+	ExcludeThinking    bool     `json:"exclude_thinking,omitempty" jsonschema:"Drop thinking/reasoning blocks from message content"`
+	ExcludeSidechains  bool     `json:"exclude_sidechains,omitempty" jsonschema:"Drop subagent (sidechain) messages, keeping only the main conversation"`
+	MaxChars           int      `json:"max_chars,omitempty" jsonschema:"Maximum characters to return for this page. Long tool outputs are truncated first, then other messages."`
+	MaxTokens          int      `json:"max_tokens,omitempty" jsonschema:"Maximum estimated tokens to return for this page (approximately 4 characters per token). Ignored if max_chars is set."`
+	Branch             string   `json:"branch,omitempty" jsonschema:"Only return one branch of a branched conversation: a leaf_uuid from get_session_tree, or 'latest'. Leave empty for every message."`
+	Tail               bool     `json:"tail,omitempty" jsonschema:"Tail mode, to follow an ongoing session: return up to page_size messages from the cursor on, oldest first, and the cursor to pass next time. page and order are ignored."`
+	Cursor             int      `json:"cursor,omitempty" jsonschema:"In tail mode, the cursor returned by the previous call or by get_active_sessions. Leave empty to start from the first message."`
+	Around             string   `json:"around,omitempty" jsonschema:"Return the page holding the message closest to this time instead of page: an RFC 3339 timestamp like '2025-01-31T15:00:00Z', a local time like '2025-01-31 15:00', or a time ago like '20h'"`
 }
 
-func addGetSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+func addGetSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_session",
-		Description: "Get the full content of a session with pagination support",
+		Description: "Get the full content of a session with pagination support. Use order 'desc' to read the most recent messages first, or around to jump to the messages from a point in time.",
 	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionArgs) (*mcp.CallToolResult, any, error) {
-		if args.SessionID == "" {
-			return nil, nil, fmt.Errorf("session_id is required")
+		if args.PageSize == 0 {
+			args.PageSize = 20
 		}
-		if args.Source == "" {
-			return nil, nil, fmt.Errorf("source is required")
+		if args.Order == "" {
+			args.Order = "asc"
 		}
 
-		adapter, ok := adaptersMap[args.Source]
-		if !ok {
-			return nil, nil, fmt.Errorf("unknown source: %s", args.Source)
+		adapter, err := sessionAdapter(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter := messageFilter{
+			Roles:              args.Roles,
+			ExcludeToolOutputs: args.ExcludeToolOutputs,
+			ExcludeThinking:    args.ExcludeThinking,
+			ExcludeSidechains:  args.ExcludeSidechains,
 		}
 
-		if args.PageSize == 0 {
-			args.PageSize = 20
+		// Tail mode returns the messages added since a cursor, counted before filtering
+		// so that it stays valid as the session grows
+		if args.Tail {
+			if args.Branch != "" || args.Around != "" {
+				return nil, nil, fmt.Errorf("branch and around can't be combined with tail")
+			}
+			messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+			if err != nil {
+				return nil, nil, err
+			}
+			tail, cursor, hasMore := tailMessages(messages, args.Cursor, args.PageSize, filter)
+			pageMessages, pageTokens := withTokenEstimates(tail)
+			return jsonToolResult(map[string]interface{}{
+				"session_id":     args.SessionID,
+				"source":         args.Source,
+				"messages":       pageMessages,
+				"count":          len(tail),
+				"cursor":         cursor,
+				"has_more":       hasMore,
+				"total_messages": len(messages),
+				"page_tokens":    pageTokens,
+			})
 		}
 
-		messages, err := adapter.GetSession(args.SessionID, args.Page, args.PageSize)
-		if err != nil {
-			return nil, nil, fmt.Errorf("failed to get session: %w", err)
+		var page messagePage
+		var totalTokens int
+		aroundIndex := -1
+		if streamer, ok := adapter.(adapters.SessionStreamer); ok && args.Branch == "" && args.Around == "" {
+			// Stream the session so only the requested page is held in memory, and once
+			// its totals are known, only read it up to the page
+			cached := newCachedSessionTotals(searchCache, args.Source, args.SessionID, filter)
+			known := cached.get()
+			var totals sessionTotals
+			page, totals, err = streamMessagePage(func(fn func(adapters.Message) bool) error {
+				if err := streamer.StreamSession(args.SessionID, fn); err != nil {
+					return fmt.Errorf("failed to get session: %w", err)
+				}
+				return nil
+			}, filter, args.Page, args.PageSize, args.Order, known)
+			if err != nil {
+				return nil, nil, err
+			}
+			if known == nil {
+				cached.put(totals)
+			}
+			totalTokens = totals.Tokens
+		} else {
+			// Load every message so we can report totals and paginate from either end
+			messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if args.Branch != "" {
+				messages, err = analysis.BranchMessages(messages, args.Branch)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			messages = filterMessages(messages, filter)
+			if args.Around != "" {
+				around, err := parseAround(args.Around, time.Now())
+				if err != nil {
+					return nil, nil, err
+				}
+				if args.Page, aroundIndex, err = pageAround(messages, around, args.PageSize, args.Order); err != nil {
+					return nil, nil, err
+				}
+			}
+			page, err = paginateMessages(messages, args.Page, args.PageSize, args.Order)
+			if err != nil {
+				return nil, nil, err
+			}
+			totalTokens = analysis.SessionTokens(messages)
 		}
 
+		maxChars := args.MaxChars
+		if maxChars == 0 && args.MaxTokens > 0 {
+			maxChars = args.MaxTokens * charsPerToken
+		}
+		budgeted := applyCharBudget(page.Messages, maxChars)
+		pageMessages, pageTokens := withTokenEstimates(budgeted.Messages)
+
 		result := map[string]interface{}{
-			"session_id": args.SessionID,
-			"source":     args.Source,
-			"page":       args.Page,
-			"page_size":  args.PageSize,
-			"messages":   messages,
-			"count":      len(messages),
+			"session_id":     args.SessionID,
+			"source":         args.Source,
+			"page":           args.Page,
+			"page_size":      args.PageSize,
+			"order":          args.Order,
+			"messages":       pageMessages,
+			"count":          len(budgeted.Messages),
+			"total_messages": page.TotalMessages,
+			"total_pages":    page.TotalPages,
+			"has_more":       page.HasMore,
+			"page_tokens":    pageTokens,
+			"total_tokens":   totalTokens,
+		}
+		if aroundIndex >= 0 {
+			// Its position among the (filtered) messages, oldest first
+			result["around_index"] = aroundIndex
+		}
+		if maxChars > 0 {
+			result["max_chars"] = maxChars
+			result["truncated_messages"] = budgeted.Truncated
+			result["omitted_messages"] = budgeted.Omitted
 		}
 
 		resultJSON, err := json.MarshalIndent(result, "", "  ")
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -96,7 +98,7 @@ func TestIndexSessionsIndexesAndSkipsUpToDateSessions(t *testing.T) {
 
 	adaptersMap := map[string]adapters.SessionAdapter{"stub": adapter}
 
-	if err := indexSessions(adaptersMap, cache, "", ""); err != nil {
+	if _, err := indexSessions(context.Background(), adaptersMap, cache, "", ""); err != nil {
 		t.Fatalf("indexSessions returned error: %v", err)
 	}
 
@@ -115,7 +117,7 @@ func TestIndexSessionsIndexesAndSkipsUpToDateSessions(t *testing.T) {
 		t.Fatalf("expected search result for sess-1, got %s", results[0].Session.ID)
 	}
 
-	if err := indexSessions(adaptersMap, cache, "", ""); err != nil {
+	if _, err := indexSessions(context.Background(), adaptersMap, cache, "", ""); err != nil {
 		t.Fatalf("indexSessions (second run) returned error: %v", err)
 	}
 	if got := adapter.getCalls["sess-1"]; got != 1 {
@@ -127,7 +129,7 @@ func TestIndexSessionsIndexesAndSkipsUpToDateSessions(t *testing.T) {
 		t.Fatalf("failed to update file mtime: %v", err)
 	}
 
-	if err := indexSessions(adaptersMap, cache, "", ""); err != nil {
+	if _, err := indexSessions(context.Background(), adaptersMap, cache, "", ""); err != nil {
 		t.Fatalf("indexSessions (after mtime change) returned error: %v", err)
 	}
 	if got := adapter.getCalls["sess-1"]; got != 2 {
@@ -135,14 +137,14 @@ func TestIndexSessionsIndexesAndSkipsUpToDateSessions(t *testing.T) {
 	}
 }
 
-func TestIndexSessionsSkipsUnknownSource(t *testing.T) {
+func TestIndexSessionsRejectsUnknownSource(t *testing.T) {
 	cache := newTestCache(t)
 
 	adapter := newStubAdapter(nil, nil)
 	adaptersMap := map[string]adapters.SessionAdapter{"stub": adapter}
 
-	if err := indexSessions(adaptersMap, cache, "other", ""); err != nil {
-		t.Fatalf("indexSessions returned error: %v", err)
+	if _, err := indexSessions(context.Background(), adaptersMap, cache, "other", ""); err == nil || !strings.Contains(err.Error(), "unknown source") {
+		t.Fatalf("expected an unknown source error, got %v", err)
 	}
 
 	if adapter.listCalls != 0 {
@@ -152,3 +154,93 @@ func TestIndexSessionsSkipsUnknownSource(t *testing.T) {
 		t.Fatalf("expected GetSession not to be called, got %d calls", len(adapter.getCalls))
 	}
 }
+
+func TestSessionListingStatsAreCachedWhileTheFileIsUnchanged(t *testing.T) {
+	cache := newTestCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("{}\n"), 0o600); err != nil {
+		t.Fatalf("write session: %v", err)
+	}
+	session := adapters.Session{ID: "s1", Source: "stub", FilePath: filePath, FileSize: 3}
+	adapter := newStubAdapter([]adapters.Session{session}, map[string][]adapters.Message{
+		"s1": {{Role: "user", Content: "fix the flaky test"}, {Role: "assistant", Content: "Done."}},
+	})
+	adaptersMap := map[string]adapters.SessionAdapter{"stub": adapter}
+
+	for i := 0; i < 2; i++ {
+		stats, err := sessionListingStats(adaptersMap, cache, session)
+		if err != nil {
+			t.Fatalf("sessionListingStats failed: %v", err)
+		}
+		if stats.MessageCount != 2 || stats.EstimatedTokens == 0 || stats.Title == "" {
+			t.Fatalf("unexpected stats: %+v", stats)
+		}
+	}
+	if adapter.getCalls["s1"] != 1 {
+		t.Fatalf("expected the session to be read once, got %d reads", adapter.getCalls["s1"])
+	}
+
+	// A session that grew is read again
+	adapter.messages["s1"] = append(adapter.messages["s1"], adapters.Message{Role: "user", Content: "thanks"})
+	session.FileSize = 6
+	stats, err := sessionListingStats(adaptersMap, cache, session)
+	if err != nil || stats.MessageCount != 3 {
+		t.Fatalf("expected the grown session to be read again, got %+v (%v)", stats, err)
+	}
+}
+
+// hintedAdapter records the project hints it is given
+type hintedAdapter struct {
+	*stubAdapter
+	hints func() []string
+}
+
+func (h *hintedAdapter) SetProjectHints(hints func() []string) {
+	h.hints = hints
+}
+
+func TestShareProjectPathsReusesRecentListings(t *testing.T) {
+	other := newStubAdapter([]adapters.Session{{ID: "s1", Source: "claude", ProjectPath: "/work/app"}}, nil)
+	hinted := &hintedAdapter{stubAdapter: newStubAdapter(nil, nil)}
+	shareProjectPaths(map[string]adapters.SessionAdapter{"claude": other, "gemini": hinted})
+
+	for i := 0; i < 3; i++ {
+		if paths := hinted.hints(); len(paths) != 1 || paths[0] != "/work/app" {
+			t.Fatalf("unexpected project hints: %v", paths)
+		}
+	}
+	if other.listCalls != 1 {
+		t.Fatalf("expected the other sources to be listed once, got %d listings", other.listCalls)
+	}
+}
+
+func TestCachedSessionTotalsFollowTheSessionFile(t *testing.T) {
+	cache := newTestCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("{}\n"), 0o600); err != nil {
+		t.Fatalf("write session: %v", err)
+	}
+	filter := messageFilter{Roles: []string{"user"}}
+	if cached := newCachedSessionTotals(cache, "claude", "s1", filter); cached.get() != nil {
+		t.Fatal("expected no totals for a session whose file isn't recorded")
+	}
+	if err := cache.PutSessionFile("claude", "s1", filePath); err != nil {
+		t.Fatalf("PutSessionFile failed: %v", err)
+	}
+
+	newCachedSessionTotals(cache, "claude", "s1", filter).put(sessionTotals{Messages: 3, Tokens: 40})
+	if totals := newCachedSessionTotals(cache, "claude", "s1", filter).get(); totals == nil || totals.Messages != 3 {
+		t.Fatalf("expected the stored totals, got %+v", totals)
+	}
+	if totals := newCachedSessionTotals(cache, "claude", "s1", messageFilter{}).get(); totals != nil {
+		t.Fatalf("expected totals to depend on the filter, got %+v", totals)
+	}
+
+	// A session that grew is read again
+	if err := os.WriteFile(filePath, []byte("{}\n{}\n"), 0o600); err != nil {
+		t.Fatalf("write session: %v", err)
+	}
+	if totals := newCachedSessionTotals(cache, "claude", "s1", filter).get(); totals != nil {
+		t.Fatalf("expected no totals once the file changed, got %+v", totals)
+	}
+}
@@ -0,0 +1,507 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// messagePage is a single page of session messages along with pagination metadata
+type messagePage struct {
+	Messages      []adapters.Message
+	TotalMessages int
+	TotalPages    int
+	HasMore       bool
+}
+
+// paginateMessages slices messages into the requested page.
+// With order "desc" the messages are reversed first, so page 0 holds the most recent messages.
+func paginateMessages(messages []adapters.Message, page, pageSize int, order string) (messagePage, error) {
+	if pageSize <= 0 {
+		return messagePage{}, fmt.Errorf("page_size must be positive")
+	}
+	if page < 0 {
+		return messagePage{}, fmt.Errorf("page must not be negative")
+	}
+
+	switch order {
+	case "", "asc":
+	case "desc":
+		reversed := make([]adapters.Message, len(messages))
+		for i, msg := range messages {
+			reversed[len(messages)-1-i] = msg
+		}
+		messages = reversed
+	default:
+		return messagePage{}, fmt.Errorf("unknown order: %s (use 'asc' or 'desc')", order)
+	}
+
+	result := messagePage{
+		Messages:      []adapters.Message{},
+		TotalMessages: len(messages),
+		TotalPages:    (len(messages) + pageSize - 1) / pageSize,
+	}
+
+	start := page * pageSize
+	if start >= len(messages) {
+		return result, nil
+	}
+
+	end := start + pageSize
+	if end > len(messages) {
+		end = len(messages)
+	}
+
+	result.Messages = messages[start:end]
+	result.HasMore = end < len(messages)
+	return result, nil
+}
+
+// aroundLayouts are the local date and time formats accepted by parseAround, besides the
+// RFC 3339 timestamps, dates and windows of parseSince
+var aroundLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// parseAround converts a timestamp, a local date and time like "2025-01-31 15:00", or a
+// window like "12h" (that long ago) into the time to jump to in a session
+func parseAround(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range aroundLayouts {
+		if t, err := time.ParseInLocation(layout, value, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := parseSince(value, now); err == nil && !t.IsZero() {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: use a timestamp like '2025-01-31T15:00:00Z', a local time like '2025-01-31 15:00', or a time ago like '12h'", value)
+}
+
+// pageAround returns the page of messages holding the message closest in time to t, and
+// that message's index. Messages without a timestamp are skipped.
+func pageAround(messages []adapters.Message, t time.Time, pageSize int, order string) (int, int, error) {
+	if pageSize <= 0 {
+		return 0, 0, fmt.Errorf("page_size must be positive")
+	}
+	closest := -1
+	var best time.Duration
+	for i, msg := range messages {
+		if msg.Timestamp.IsZero() {
+			continue
+		}
+		diff := msg.Timestamp.Sub(t)
+		if diff < 0 {
+			diff = -diff
+		}
+		if closest < 0 || diff < best {
+			closest, best = i, diff
+		}
+	}
+	if closest < 0 {
+		return 0, 0, fmt.Errorf("the session's messages have no timestamps")
+	}
+	if order == "desc" {
+		return (len(messages) - 1 - closest) / pageSize, closest, nil
+	}
+	return closest / pageSize, closest, nil
+}
+
+// sessionTotals are the number and estimated tokens of the messages of a session that
+// pass a filter
+type sessionTotals struct {
+	Messages int `json:"messages"`
+	Tokens   int `json:"tokens"`
+}
+
+// streamMessagePage pages through a streamed session like filterMessages followed by
+// paginateMessages, without holding the whole session in memory: only the requested page
+// is kept (for "desc", the messages from the page to the end of the session). It also
+// returns the totals of the filtered session. When they are already known, pages in
+// "asc" order stop reading the session at the end of the page.
+func streamMessagePage(stream func(fn func(adapters.Message) bool) error, filter messageFilter, page, pageSize int, order string, known *sessionTotals) (messagePage, sessionTotals, error) {
+	if pageSize <= 0 {
+		return messagePage{}, sessionTotals{}, fmt.Errorf("page_size must be positive")
+	}
+	if page < 0 {
+		return messagePage{}, sessionTotals{}, fmt.Errorf("page must not be negative")
+	}
+	if order != "" && order != "asc" && order != "desc" {
+		return messagePage{}, sessionTotals{}, fmt.Errorf("unknown order: %s (use 'asc' or 'desc')", order)
+	}
+
+	start := page * pageSize
+	keep := start + pageSize // Messages to keep from the end of the session in desc order
+	stopEarly := known != nil && order != "desc"
+	var window []adapters.Message
+	total, tokens := 0, 0
+
+	// Past the known end of the session, there is nothing to read
+	var err error
+	if !stopEarly || start < known.Messages {
+		err = stream(func(msg adapters.Message) bool {
+			filtered := filterMessages([]adapters.Message{msg}, filter)
+			if len(filtered) == 0 {
+				return true
+			}
+			msg = filtered[0]
+			tokens += analysis.MessageTokens(msg)
+
+			if order == "desc" {
+				window = append(window, msg)
+				if len(window) >= 2*keep {
+					window = append([]adapters.Message(nil), window[len(window)-keep:]...)
+				}
+			} else if total >= start && total < start+pageSize {
+				window = append(window, msg)
+			}
+			total++
+			return !stopEarly || total < start+pageSize
+		})
+	}
+	if err != nil {
+		return messagePage{}, sessionTotals{}, err
+	}
+
+	totals := sessionTotals{Messages: total, Tokens: tokens}
+	if stopEarly {
+		totals = *known
+		total = totals.Messages
+	}
+	result := messagePage{
+		Messages:      []adapters.Message{},
+		TotalMessages: total,
+		TotalPages:    (total + pageSize - 1) / pageSize,
+	}
+	if start >= total {
+		return result, totals, nil
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+
+	if order == "desc" {
+		// window holds the last len(window) messages; page i of the reversed session
+		// is the original messages [total-end, total-start)
+		offset := total - len(window)
+		for i := total - start - 1; i >= total-end; i-- {
+			result.Messages = append(result.Messages, window[i-offset])
+		}
+	} else {
+		result.Messages = window
+	}
+	result.HasMore = end < total
+	return result, totals, nil
+}
+
+// messageFilter describes which messages and content blocks get_session should return
+type messageFilter struct {
+	Roles              []string // Only keep messages with these roles (empty = all roles)
+	ExcludeToolOutputs bool     // Drop tool output messages, tool calls, and tool blocks from raw content
+	ExcludeThinking    bool     // Drop thinking text and thinking blocks from raw content
+	ExcludeSidechains  bool     // Drop messages from subagent sidechains
+}
+
+// filterMessages applies a messageFilter, returning new messages without mutating the input
+func filterMessages(messages []adapters.Message, filter messageFilter) []adapters.Message {
+	roles := make(map[string]bool, len(filter.Roles))
+	for _, role := range filter.Roles {
+		roles[strings.ToLower(strings.TrimSpace(role))] = true
+	}
+
+	filtered := make([]adapters.Message, 0, len(messages))
+	for _, msg := range messages {
+		if filter.ExcludeToolOutputs && (analysis.IsToolOutput(msg) || isToolCallOnly(msg)) {
+			continue
+		}
+		if filter.ExcludeSidechains && msg.Metadata["is_sidechain"] == true {
+			continue
+		}
+		if len(roles) > 0 && !roles[messageRole(msg)] {
+			continue
+		}
+
+		if filter.ExcludeToolOutputs || filter.ExcludeThinking {
+			msg = stripContentBlocks(msg, filter)
+		}
+		filtered = append(filtered, msg)
+	}
+	return filtered
+}
+
+// messageRole returns the role of a message, with tool outputs recorded under the user
+// role as "tool"
+func messageRole(msg adapters.Message) string {
+	if analysis.IsToolOutput(msg) {
+		return "tool"
+	}
+	return msg.Role
+}
+
+// isToolCallOnly reports whether a message carries tool calls but no text
+func isToolCallOnly(msg adapters.Message) bool {
+	return len(msg.ToolCalls) > 0 && strings.TrimSpace(msg.Content) == ""
+}
+
+// stripContentBlocks removes excluded tool calls, thinking, and raw content blocks from a message
+func stripContentBlocks(msg adapters.Message, filter messageFilter) adapters.Message {
+	if filter.ExcludeToolOutputs {
+		msg.ToolCalls = nil
+		msg.ToolResults = nil
+	}
+	if filter.ExcludeThinking {
+		msg.Thinking = ""
+	}
+
+	blocks, ok := msg.Metadata["raw_content"].([]interface{})
+	if !ok {
+		return msg
+	}
+
+	kept := make([]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		if m, ok := block.(map[string]interface{}); ok {
+			blockType, _ := m["type"].(string)
+			switch {
+			case filter.ExcludeThinking && (blockType == "thinking" || blockType == "redacted_thinking"):
+				continue
+			case filter.ExcludeToolOutputs && (blockType == "tool_use" || blockType == "tool_result"):
+				continue
+			}
+		}
+		kept = append(kept, block)
+	}
+
+	// Copy metadata so the caller's messages are left untouched
+	metadata := cloneMetadata(msg.Metadata)
+	metadata["raw_content"] = kept
+	msg.Metadata = metadata
+	return msg
+}
+
+// tokenizedMessage is a message returned together with its estimated token count
+type tokenizedMessage struct {
+	adapters.Message
+	EstimatedTokens int `json:"estimated_tokens"`
+}
+
+// withTokenEstimates pairs each message with its estimated token count and returns the total
+func withTokenEstimates(messages []adapters.Message) ([]tokenizedMessage, int) {
+	tokenized := make([]tokenizedMessage, len(messages))
+	total := 0
+	for i, msg := range messages {
+		tokens := analysis.MessageTokens(msg)
+		tokenized[i] = tokenizedMessage{Message: msg, EstimatedTokens: tokens}
+		total += tokens
+	}
+	return tokenized, total
+}
+
+const (
+	// charsPerToken approximates how many characters make up one model token
+	charsPerToken = 4
+
+	// truncatedToolOutputChars is how much of each tool output is kept when a page exceeds its budget
+	truncatedToolOutputChars = 500
+
+	// minTruncatedChars is the smallest slice of a message worth returning instead of omitting it
+	minTruncatedChars = 200
+)
+
+// budgetedPage is the result of fitting a page of messages into a character budget
+type budgetedPage struct {
+	Messages  []adapters.Message
+	Truncated int // Messages whose content was shortened
+	Omitted   int // Messages dropped from the end of the page
+}
+
+// applyCharBudget shrinks a page of messages so its serialized size stays within maxChars.
+// Tool outputs are truncated first, then raw structured content is dropped, and finally the
+// remaining messages are truncated or omitted from the end of the page.
+func applyCharBudget(messages []adapters.Message, maxChars int) budgetedPage {
+	if maxChars <= 0 || pageSize(messages) <= maxChars {
+		return budgetedPage{Messages: messages}
+	}
+
+	out := make([]adapters.Message, len(messages))
+	copy(out, messages)
+
+	// Pass 1: truncate long tool outputs
+	for i, msg := range out {
+		if !analysis.IsToolOutput(msg) {
+			continue
+		}
+		if len(msg.Content) > truncatedToolOutputChars {
+			out[i] = truncateMessage(msg, truncatedToolOutputChars)
+		}
+	}
+
+	// Pass 2: drop raw structured content, keeping the flattened text
+	if pageSize(out) > maxChars {
+		for i, msg := range out {
+			if _, hasRaw := msg.Metadata["raw_content"]; hasRaw {
+				metadata := cloneMetadata(msg.Metadata)
+				delete(metadata, "raw_content")
+				metadata["raw_content_omitted"] = true
+				out[i].Metadata = metadata
+			}
+		}
+	}
+
+	// Pass 3: fill the budget in order, truncating the message that crosses it
+	result := budgetedPage{Messages: make([]adapters.Message, 0, len(out))}
+	remaining := maxChars
+	for i, msg := range out {
+		size := messageSize(msg)
+		if size <= remaining {
+			result.Messages = append(result.Messages, msg)
+			remaining -= size
+			continue
+		}
+
+		result.Omitted = len(out) - i
+		if truncated, ok := fitMessage(msg, remaining, len(result.Messages) == 0); ok {
+			result.Messages = append(result.Messages, truncated)
+			result.Omitted--
+		}
+		break
+	}
+
+	for _, msg := range result.Messages {
+		if truncated, _ := msg.Metadata["truncated"].(bool); truncated {
+			result.Truncated++
+		}
+	}
+
+	return result
+}
+
+// fitMessage truncates a message until its serialized size, with the truncation notice,
+// metadata and JSON escaping, is within maxSize. It fails when less than
+// minTruncatedChars of each text would be left, unless first is set: the first message
+// of a page is always returned, cut as short as it takes.
+func fitMessage(msg adapters.Message, maxSize int, first bool) (adapters.Message, bool) {
+	// Escaping can make the texts serialize to several times their length, so search
+	// for the longest cut that fits
+	var best adapters.Message
+	found := false
+	low, high := minTruncatedChars, min(messageSize(msg), maxSize)
+	if first {
+		low = 0
+	}
+	for low <= high {
+		mid := low + (high-low)/2
+		truncated := truncateMessage(msg, mid)
+		if messageSize(truncated) <= maxSize {
+			best, found = truncated, true
+			low = mid + 1
+		} else {
+			high = mid - 1
+		}
+	}
+	if !found && first {
+		return truncateMessage(msg, 0), true
+	}
+	return best, found
+}
+
+// truncateMessage shortens each text of a message to roughly maxChars, marking it as
+// truncated: its content, thinking, tool outputs, and the strings in its tool inputs
+func truncateMessage(msg adapters.Message, maxChars int) adapters.Message {
+	metadata := cloneMetadata(msg.Metadata)
+	delete(metadata, "raw_content")
+
+	originalLength := len(msg.Content)
+	msg.Content = truncateText(msg.Content, maxChars)
+	msg.Thinking = truncateText(msg.Thinking, maxChars)
+
+	// Inputs such as the files written by a tool can be as long as any output
+	if len(msg.ToolCalls) > 0 {
+		calls := make([]adapters.ToolCall, len(msg.ToolCalls))
+		for i, call := range msg.ToolCalls {
+			if call.Input != nil {
+				call.Input = truncateValue(call.Input, maxChars).(map[string]interface{})
+			}
+			calls[i] = call
+		}
+		msg.ToolCalls = calls
+	}
+
+	// Tool outputs are also carried in the typed results; copy before shortening them
+	if len(msg.ToolResults) > 0 {
+		results := make([]adapters.ToolResult, len(msg.ToolResults))
+		for i, result := range msg.ToolResults {
+			result.Output = truncateText(result.Output, maxChars)
+			results[i] = result
+		}
+		msg.ToolResults = results
+	}
+
+	metadata["truncated"] = true
+	metadata["original_length"] = originalLength
+	msg.Metadata = metadata
+	return msg
+}
+
+// truncateText shortens text to roughly maxChars, noting how much was cut
+func truncateText(text string, maxChars int) string {
+	if len(text) <= maxChars {
+		return text
+	}
+	cut := textutil.ClusterStart(text, maxChars) // Don't split a character
+	return text[:cut] + fmt.Sprintf("\n... [truncated %d characters]", len(text)-cut)
+}
+
+// truncateValue returns a copy of a decoded JSON value with each string in it shortened
+// by truncateText
+func truncateValue(value interface{}, maxChars int) interface{} {
+	switch v := value.(type) {
+	case string:
+		return truncateText(v, maxChars)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = truncateValue(item, maxChars)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = truncateValue(item, maxChars)
+		}
+		return out
+	default:
+		return value
+	}
+}
+
+// cloneMetadata returns a shallow copy of a message's metadata map
+func cloneMetadata(metadata map[string]interface{}) map[string]interface{} {
+	clone := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		clone[k] = v
+	}
+	return clone
+}
+
+// messageSize returns the serialized size of a message in characters
+func messageSize(msg adapters.Message) int {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return len(msg.Content)
+	}
+	return len(data)
+}
+
+// pageSize returns the combined serialized size of messages
+func pageSize(messages []adapters.Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += messageSize(msg)
+	}
+	return total
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestPaginateMessages(t *testing.T) {
+	messages := make([]adapters.Message, 5)
+	for i := range messages {
+		messages[i] = adapters.Message{Role: "user", Content: fmt.Sprintf("msg-%d", i)}
+	}
+
+	page, err := paginateMessages(messages, 0, 2, "asc")
+	if err != nil {
+		t.Fatalf("paginateMessages returned error: %v", err)
+	}
+	if page.TotalMessages != 5 || page.TotalPages != 3 || !page.HasMore {
+		t.Fatalf("unexpected pagination metadata: %+v", page)
+	}
+	if page.Messages[0].Content != "msg-0" || page.Messages[1].Content != "msg-1" {
+		t.Fatalf("unexpected asc page contents: %+v", page.Messages)
+	}
+
+	page, err = paginateMessages(messages, 0, 2, "desc")
+	if err != nil {
+		t.Fatalf("paginateMessages desc returned error: %v", err)
+	}
+	if page.Messages[0].Content != "msg-4" || page.Messages[1].Content != "msg-3" {
+		t.Fatalf("desc page should start with most recent messages, got %+v", page.Messages)
+	}
+
+	page, err = paginateMessages(messages, 2, 2, "desc")
+	if err != nil {
+		t.Fatalf("paginateMessages last page returned error: %v", err)
+	}
+	if len(page.Messages) != 1 || page.Messages[0].Content != "msg-0" || page.HasMore {
+		t.Fatalf("unexpected last desc page: %+v", page)
+	}
+
+	page, err = paginateMessages(messages, 5, 2, "asc")
+	if err != nil {
+		t.Fatalf("paginateMessages out of range returned error: %v", err)
+	}
+	if len(page.Messages) != 0 || page.HasMore {
+		t.Fatalf("out of range page should be empty, got %+v", page)
+	}
+
+	if _, err := paginateMessages(messages, 0, 2, "sideways"); err == nil {
+		t.Fatal("expected error for unknown order")
+	}
+}
+
+func TestFilterMessages(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "question"},
+		{Role: "assistant", Content: "answer", Thinking: "hmm", ToolCalls: []adapters.ToolCall{{Name: "Bash"}}, Metadata: map[string]interface{}{
+			"raw_content": []interface{}{
+				map[string]interface{}{"type": "thinking", "thinking": "hmm"},
+				map[string]interface{}{"type": "text", "text": "answer"},
+				map[string]interface{}{"type": "tool_use", "name": "Bash"},
+			},
+		}},
+		{Role: "user", Metadata: map[string]interface{}{"is_tool_result": true}},
+		{Role: "tool", Content: "tool output"},
+	}
+
+	userOnly := filterMessages(messages, messageFilter{Roles: []string{"user"}})
+	if len(userOnly) != 1 || userOnly[0].Content != "question" {
+		t.Fatalf("role filter should keep only the human user message, got %+v", userOnly)
+	}
+
+	toolOnly := filterMessages(messages, messageFilter{Roles: []string{"tool"}})
+	if len(toolOnly) != 2 {
+		t.Fatalf("tool role filter should match tool results and tool messages, got %d", len(toolOnly))
+	}
+
+	conversation := filterMessages(messages, messageFilter{ExcludeToolOutputs: true, ExcludeThinking: true})
+	if len(conversation) != 2 {
+		t.Fatalf("expected tool outputs to be dropped, got %d messages", len(conversation))
+	}
+	blocks := conversation[1].Metadata["raw_content"].([]interface{})
+	if len(blocks) != 1 || blocks[0].(map[string]interface{})["type"] != "text" {
+		t.Fatalf("expected only the text block to remain, got %+v", blocks)
+	}
+	if conversation[1].Thinking != "" || conversation[1].ToolCalls != nil {
+		t.Fatalf("expected thinking and tool calls to be dropped, got %+v", conversation[1])
+	}
+
+	original := messages[1].Metadata["raw_content"].([]interface{})
+	if len(original) != 3 {
+		t.Fatalf("filterMessages must not mutate input, raw_content now has %d blocks", len(original))
+	}
+	if messages[1].Thinking != "hmm" || len(messages[1].ToolCalls) != 1 {
+		t.Fatal("filterMessages must not mutate input messages")
+	}
+
+	withSidechain := append(messages, adapters.Message{Role: "assistant", Content: "subagent", Metadata: map[string]interface{}{"is_sidechain": true}})
+	mainOnly := filterMessages(withSidechain, messageFilter{ExcludeSidechains: true})
+	if len(mainOnly) != len(messages) {
+		t.Fatalf("expected sidechain messages to be dropped, got %d messages", len(mainOnly))
+	}
+}
+
+func TestApplyCharBudget(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+	messages := []adapters.Message{
+		{Role: "user", Content: "short question"},
+		{Role: "tool", Content: long},
+		{Role: "assistant", Content: "short answer"},
+	}
+
+	unlimited := applyCharBudget(messages, 0)
+	if len(unlimited.Messages) != 3 || unlimited.Truncated != 0 {
+		t.Fatalf("zero budget should leave messages untouched, got %+v", unlimited)
+	}
+
+	budgeted := applyCharBudget(messages, 1500)
+	if len(budgeted.Messages) != 3 || budgeted.Omitted != 0 {
+		t.Fatalf("expected all messages to fit once the tool output is truncated, got %d (omitted %d)", len(budgeted.Messages), budgeted.Omitted)
+	}
+	if budgeted.Messages[0].Content != "short question" || budgeted.Messages[2].Content != "short answer" {
+		t.Fatal("conversation messages should not be truncated when tool output truncation suffices")
+	}
+	toolMsg := budgeted.Messages[1]
+	if truncated, _ := toolMsg.Metadata["truncated"].(bool); !truncated {
+		t.Fatal("tool output should be marked as truncated")
+	}
+	if toolMsg.Metadata["original_length"] != 5000 {
+		t.Fatalf("original_length=%v want 5000", toolMsg.Metadata["original_length"])
+	}
+	if pageSize(budgeted.Messages) > 1500 {
+		t.Fatalf("budgeted page exceeds budget: %d", pageSize(budgeted.Messages))
+	}
+	if len(messages[1].Content) != 5000 {
+		t.Fatal("applyCharBudget must not mutate input messages")
+	}
+
+	tight := applyCharBudget([]adapters.Message{
+		{Role: "user", Content: long},
+		{Role: "assistant", Content: long},
+	}, 1000)
+	if len(tight.Messages) != 1 || tight.Omitted != 1 || tight.Truncated != 1 {
+		t.Fatalf("expected one truncated message and one omitted, got %d kept, %d truncated, %d omitted",
+			len(tight.Messages), tight.Truncated, tight.Omitted)
+	}
+
+	// Short tool outputs keep their structured content when a long one is truncated
+	mixed := applyCharBudget([]adapters.Message{
+		{Role: "tool", Content: "ok", ToolResults: []adapters.ToolResult{{Output: "ok"}}, Metadata: map[string]interface{}{"raw_content": "ok"}},
+		{Role: "tool", Content: long},
+	}, 1500)
+	if short := mixed.Messages[0]; short.Metadata["truncated"] != nil || short.Metadata["raw_content"] != "ok" || len(short.ToolResults) != 1 {
+		t.Fatalf("expected the short tool output to be left alone, got %+v", short)
+	}
+	if mixed.Truncated != 1 {
+		t.Fatalf("expected only the long tool output to be truncated, got %d", mixed.Truncated)
+	}
+
+	// The message crossing the budget is cut to fit with its truncation notice, its
+	// metadata, and the escaping of its content
+	escaped := strings.Repeat(`<"\`+"\n", 2000)
+	for _, maxChars := range []int{1000, 2500, 6000} {
+		crossing := applyCharBudget([]adapters.Message{
+			{Role: "user", Content: "short question"},
+			{Role: "assistant", Content: escaped},
+		}, maxChars)
+		if len(crossing.Messages) != 2 || crossing.Truncated != 1 {
+			t.Fatalf("expected the answer to be truncated to fit %d characters, got %d kept, %d truncated", maxChars, len(crossing.Messages), crossing.Truncated)
+		}
+		if size := pageSize(crossing.Messages); size > maxChars {
+			t.Fatalf("page of %d characters exceeds the budget of %d", size, maxChars)
+		}
+	}
+
+	// A first message whose size is in its tool input or its thinking is cut to fit too
+	body := strings.Repeat("func main() {}\n", 3500)
+	for name, msg := range map[string]adapters.Message{
+		"tool input": {Role: "assistant", Content: "Writing the file", ToolCalls: []adapters.ToolCall{
+			{ID: "call_1", Name: "Write", Input: map[string]interface{}{"file_path": "/work/app/main.go", "content": body}},
+			{ID: "call_2", Name: "MultiEdit", Input: map[string]interface{}{"edits": []interface{}{map[string]interface{}{"new_string": body, "replace_all": true}}}},
+		}},
+		"thinking": {Role: "assistant", Content: "Done", Thinking: body},
+	} {
+		for _, maxChars := range []int{500, 2000} {
+			page := applyCharBudget([]adapters.Message{msg, {Role: "user", Content: "thanks"}}, maxChars)
+			if len(page.Messages) != 1 || page.Truncated != 1 {
+				t.Fatalf("%s: expected the first message to be truncated, got %d kept, %d truncated", name, len(page.Messages), page.Truncated)
+			}
+			if size := pageSize(page.Messages); size > maxChars {
+				t.Fatalf("%s: page of %d characters exceeds the budget of %d", name, size, maxChars)
+			}
+		}
+		if len(msg.Thinking) != 0 && len(msg.Thinking) != len(body) || len(msg.ToolCalls) > 0 && msg.ToolCalls[0].Input["content"] != body {
+			t.Fatalf("%s: applyCharBudget must not mutate input messages", name)
+		}
+	}
+}
+
+func TestWithTokenEstimates(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "Hello, world!"},
+		{Role: "assistant", Content: "The quick brown fox jumps over the lazy dog."},
+	}
+	tokenized, total := withTokenEstimates(messages)
+	if len(tokenized) != 2 || tokenized[0].Content != "Hello, world!" {
+		t.Fatalf("unexpected messages: %+v", tokenized)
+	}
+	if tokenized[0].EstimatedTokens == 0 || total != tokenized[0].EstimatedTokens+tokenized[1].EstimatedTokens {
+		t.Fatalf("page total %d does not match message estimates %+v", total, tokenized)
+	}
+}
+
+func TestStreamMessagePageMatchesPaginate(t *testing.T) {
+	var messages []adapters.Message
+	for i := 0; i < 23; i++ {
+		role := "user"
+		if i%3 != 0 {
+			role = "assistant"
+		}
+		messages = append(messages, adapters.Message{Role: role, Content: fmt.Sprintf("message %d", i)})
+	}
+	stream := func(fn func(adapters.Message) bool) error {
+		for _, msg := range messages {
+			if !fn(msg) {
+				break
+			}
+		}
+		return nil
+	}
+
+	filters := []messageFilter{{}, {Roles: []string{"assistant"}}}
+	for _, filter := range filters {
+		filtered := filterMessages(messages, filter)
+		for _, order := range []string{"asc", "desc"} {
+			for _, pageSize := range []int{1, 4, 50} {
+				for page := 0; page <= len(filtered)/pageSize+1; page++ {
+					want, _ := paginateMessages(filtered, page, pageSize, order)
+					got, totals, err := streamMessagePage(stream, filter, page, pageSize, order, nil)
+					if err != nil {
+						t.Fatalf("streamMessagePage failed: %v", err)
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Fatalf("roles=%v order=%s page=%d size=%d:\ngot  %+v\nwant %+v", filter.Roles, order, page, pageSize, got, want)
+					}
+					if totals.Tokens != analysis.SessionTokens(filtered) || totals.Messages != len(filtered) {
+						t.Fatalf("totals=%+v, want %d tokens", totals, analysis.SessionTokens(filtered))
+					}
+				}
+			}
+		}
+	}
+
+	if _, _, err := streamMessagePage(stream, messageFilter{}, 0, 10, "sideways", nil); err == nil {
+		t.Fatal("expected an error for an unknown order")
+	}
+
+	// With the totals known, pages stop reading the session at their end
+	read := 0
+	counted := func(fn func(adapters.Message) bool) error {
+		return stream(func(msg adapters.Message) bool {
+			read++
+			return fn(msg)
+		})
+	}
+	known := &sessionTotals{Messages: len(messages), Tokens: analysis.SessionTokens(messages)}
+	want, _ := paginateMessages(messages, 1, 4, "asc")
+	got, totals, err := streamMessagePage(counted, messageFilter{}, 1, 4, "asc", known)
+	if err != nil || !reflect.DeepEqual(got, want) || totals != *known {
+		t.Fatalf("got %+v, %+v (%v), want %+v", got, totals, err, want)
+	}
+	if read != 8 {
+		t.Fatalf("expected the stream to stop after 8 messages, read %d", read)
+	}
+	if _, _, err := streamMessagePage(counted, messageFilter{}, 10, 4, "asc", known); err != nil || read != 8 {
+		t.Fatalf("expected a page past the end not to read the session, read %d (%v)", read, err)
+	}
+}
+
+func TestPageAround(t *testing.T) {
+	start := time.Date(2025, 3, 1, 14, 0, 0, 0, time.UTC)
+	var messages []adapters.Message
+	for i := 0; i < 10; i++ {
+		messages = append(messages, adapters.Message{Role: "user", Content: fmt.Sprint(i), Timestamp: start.Add(time.Duration(i) * 10 * time.Minute)})
+	}
+	messages[5].Timestamp = time.Time{}
+
+	page, index, err := pageAround(messages, start.Add(62*time.Minute), 4, "asc")
+	if err != nil || index != 6 || page != 1 {
+		t.Fatalf("expected message 6 on page 1, got %d on page %d (%v)", index, page, err)
+	}
+	// The closest message with a timestamp is picked
+	if _, index, _ = pageAround(messages, start.Add(50*time.Minute), 4, "asc"); index != 4 {
+		t.Fatalf("expected message 4, got %d", index)
+	}
+	if page, index, _ = pageAround(messages, start.Add(-time.Hour), 4, "desc"); index != 0 || page != 2 {
+		t.Fatalf("expected the first message on the last page in desc order, got %d on page %d", index, page)
+	}
+	if _, _, err := pageAround([]adapters.Message{{Role: "user"}}, start, 4, "asc"); err == nil {
+		t.Fatal("expected an error for messages without timestamps")
+	}
+}
+
+func TestParseAround(t *testing.T) {
+	now := time.Date(2025, 3, 2, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Time{
+		"2025-03-01T15:00:00Z": time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC),
+		"2025-03-01 15:00":     time.Date(2025, 3, 1, 15, 0, 0, 0, time.UTC),
+		"2025-03-01T15:04:05":  time.Date(2025, 3, 1, 15, 4, 5, 0, time.UTC),
+		"20h":                  time.Date(2025, 3, 1, 16, 0, 0, 0, time.UTC),
+	}
+	for value, want := range cases {
+		if got, err := parseAround(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("parseAround(%q) = %v (%v), want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "all", "3pm yesterday"} {
+		if _, err := parseAround(value, now); err == nil {
+			t.Errorf("expected an error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPTransportProxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		w.Write([]byte(`{"transcripts":[]}`))
+	}))
+	defer proxy.Close()
+
+	transport, err := newHTTPTransport(Config{Proxy: proxy.URL})
+	if err != nil {
+		t.Fatalf("newHTTPTransport failed: %v", err)
+	}
+	client := &uploadClient{client: &http.Client{Transport: transport}, apiURL: "http://sessions.example.com"}
+	if _, err := client.listTranscripts(); err != nil {
+		t.Fatalf("request through the proxy failed: %v", err)
+	}
+	if proxied != "http://sessions.example.com/api/cli/transcripts" {
+		t.Fatalf("expected the request to go through the proxy, got %q", proxied)
+	}
+
+	// An unreachable proxy is reported as such
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedProxy := "http://" + listener.Addr().String()
+	listener.Close()
+	transport, _ = newHTTPTransport(Config{Proxy: closedProxy})
+	client = &uploadClient{client: &http.Client{Transport: transport}, apiURL: "https://sessions.example.com"}
+	if _, err := client.listTranscripts(); err == nil || !strings.Contains(err.Error(), "failed to connect to the proxy") {
+		t.Fatalf("expected a proxy connection error, got %v", err)
+	}
+
+	if _, err := newHTTPTransport(Config{Proxy: "proxy:8080"}); err == nil {
+		t.Fatal("expected a proxy without a scheme to be rejected")
+	}
+}
+
+func TestNewHTTPTransportCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"transcripts":[]}`))
+	}))
+	defer server.Close()
+
+	// The test server's certificate isn't trusted by default, and that isn't retried
+	transport, err := newHTTPTransport(Config{})
+	if err != nil {
+		t.Fatalf("newHTTPTransport failed: %v", err)
+	}
+	attempts := 0
+	client := &uploadClient{client: &http.Client{Transport: transport}, apiURL: server.URL, retries: 3, sleep: func(time.Duration) { attempts++ }}
+	if _, err := client.listTranscripts(); err == nil || !strings.Contains(err.Error(), "ca_bundle") || attempts != 0 {
+		t.Fatalf("expected an untrusted certificate error without retries, got %v after %d retries", err, attempts)
+	}
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(bundle, cert, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	transport, err = newHTTPTransport(Config{CABundle: bundle})
+	if err != nil {
+		t.Fatalf("newHTTPTransport failed: %v", err)
+	}
+	client.client = &http.Client{Transport: transport}
+	if _, err := client.listTranscripts(); err != nil {
+		t.Fatalf("expected the CA bundle to be trusted: %v", err)
+	}
+
+	os.WriteFile(bundle, []byte("not a certificate"), 0o600)
+	if _, err := newHTTPTransport(Config{CABundle: bundle}); err == nil {
+		t.Fatal("expected a CA bundle without certificates to be rejected")
+	}
+}
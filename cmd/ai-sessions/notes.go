@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Tool 15: add_session_note
+type addSessionNoteArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to annotate"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Note      string `json:"note,omitempty" jsonschema:"Free-text note to attach (e.g., 'this fix shipped in v1.2')"`
+	DeleteID  int64  `json:"delete_id,omitempty" jsonschema:"Optional: ID of an existing note to delete instead of adding one"`
+}
+
+func addAddSessionNoteTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "add_session_note",
+		Description: "Attach a free-text note to a session, such as its outcome. Notes are stored locally, never modify session files, and are returned with list and search results.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args addSessionNoteArgs) (*mcp.CallToolResult, any, error) {
+		if args.SessionID == "" {
+			return nil, nil, fmt.Errorf("session_id is required")
+		}
+		if _, ok := adaptersMap[args.Source]; !ok {
+			return nil, nil, fmt.Errorf("unknown source: %s", args.Source)
+		}
+
+		if args.DeleteID != 0 {
+			if err := searchCache.DeleteNote(args.DeleteID); err != nil {
+				return nil, nil, err
+			}
+		} else if _, err := searchCache.AddNote(args.Source, args.SessionID, args.Note); err != nil {
+			return nil, nil, err
+		}
+
+		notes, err := searchCache.SessionNotes(args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"notes":      notes,
+		})
+	})
+}
+
+// annotatedSession is a session listed together with its user notes, size, and cost
+type annotatedSession struct {
+	adapters.Session
+	Notes           []search.Note `json:"notes,omitempty"`
+	EstimatedTokens int           `json:"estimated_tokens,omitempty"`
+	Cost            float64       `json:"cost,omitempty"` // Recorded by the agent or estimated from model list prices
+}
+
+// annotateSessions attaches each session's notes for listing
+func annotateSessions(searchCache *search.Cache, sessions []adapters.Session) []annotatedSession {
+	annotated := make([]annotatedSession, len(sessions))
+	for i, session := range sessions {
+		annotated[i] = annotatedSession{Session: session, Notes: sessionNotes(searchCache, session)}
+	}
+	return annotated
+}
+
+// sessionNotes returns a session's notes, logging rather than failing on errors
+// since notes are supplementary to the results they decorate.
+func sessionNotes(searchCache *search.Cache, session adapters.Session) []search.Note {
+	notes, err := searchCache.SessionNotes(session.Source, session.ID)
+	if err != nil {
+		slog.Warn("Failed to load notes", "session", session.ID, "error", err)
+		return nil
+	}
+	return notes
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+func TestAnnotateSessions(t *testing.T) {
+	cache, err := search.NewCache(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	if _, err := cache.AddNote("claude", "a", "shipped"); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+
+	annotated := annotateSessions(cache, []adapters.Session{{ID: "a", Source: "claude"}, {ID: "b", Source: "claude"}})
+	if len(annotated[0].Notes) != 1 || annotated[0].Notes[0].Text != "shipped" || annotated[1].Notes != nil {
+		t.Fatalf("unexpected annotations: %+v", annotated)
+	}
+
+	data, err := json.Marshal(annotated[0])
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	if !strings.Contains(string(data), `"id":"a"`) || !strings.Contains(string(data), `"notes":[`) {
+		t.Fatalf("session fields should be flattened alongside notes: %s", data)
+	}
+}
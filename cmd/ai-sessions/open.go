@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// openInEditor opens a file in an editor command, which may include arguments (e.g.
+// "code --wait"), attached to the terminal. Replaced in tests.
+var openInEditor = func(editor, path string) error {
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], path)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// revealInFileManager shows a file in the OS file manager. Replaced in tests.
+var revealInFileManager = func(path string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", "-R", path).Start()
+	case "windows":
+		return exec.Command("explorer", "/select,"+path).Start()
+	default:
+		// Most Linux file managers can't select a file, so open its directory
+		return exec.Command("xdg-open", filepath.Dir(path)).Start()
+	}
+}
+
+var openCommand = cliCommand{
+	name:    "open",
+	args:    "<source> <id>",
+	summary: "Open a session's file in $EDITOR, or show it in the file manager",
+	minArgs: 2,
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts openOptions
+		fs.BoolVar(&opts.Reveal, "reveal", false, "show the file in the file manager instead")
+		fs.BoolVar(&opts.PrintPath, "path", false, "only print the file's path (with --json, as JSON)")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			return runOpenCommand(env.sessionAdapters(), args[0], args[1], opts, env.stdout)
+		}
+	},
+}
+
+// openOptions are the options of `aisessions open`
+type openOptions struct {
+	Reveal    bool
+	PrintPath bool
+	JSON      bool // Print the path as JSON, implying PrintPath
+}
+
+func runOpenCommand(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string, opts openOptions, stdout io.Writer) error {
+	opts.PrintPath = opts.PrintPath || opts.JSON
+	if opts.Reveal && opts.PrintPath {
+		return fmt.Errorf("--reveal can't be combined with --path or --json")
+	}
+
+	if _, err := sessionAdapter(adaptersMap, source, sessionID); err != nil {
+		return err
+	}
+	session := findListedSession(adaptersMap, source, sessionID)
+	if session.FilePath == "" {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	switch {
+	case opts.JSON:
+		return printJSON(stdout, map[string]string{"session_id": sessionID, "source": source, "path": session.FilePath})
+	case opts.PrintPath:
+		fmt.Fprintln(stdout, session.FilePath)
+		return nil
+	case opts.Reveal:
+		if err := revealInFileManager(session.FilePath); err != nil {
+			return fmt.Errorf("failed to reveal %s: %w", session.FilePath, err)
+		}
+		return nil
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if strings.TrimSpace(editor) == "" {
+		// Without an editor, hand the file to the OS, like a double click would
+		if err := openBrowser(session.FilePath); err != nil {
+			return fmt.Errorf("set $EDITOR to open %s: %w", session.FilePath, err)
+		}
+		return nil
+	}
+	if err := openInEditor(editor, session.FilePath); err != nil {
+		return fmt.Errorf("failed to open %s with %s: %w", session.FilePath, editor, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunOpenCommand(t *testing.T) {
+	stub := newStubAdapter([]adapters.Session{{ID: "s1", Source: "claude", FilePath: "/home/me/.claude/projects/-work-app/s1.jsonl"}}, nil)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var opened, revealed []string
+	origEditor, origReveal := openInEditor, revealInFileManager
+	openInEditor = func(editor, path string) error {
+		opened = append(opened, editor+" "+path)
+		return nil
+	}
+	revealInFileManager = func(path string) error {
+		revealed = append(revealed, path)
+		return nil
+	}
+	t.Cleanup(func() { openInEditor, revealInFileManager = origEditor, origReveal })
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "vim -R")
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "open", "claude", "s1"); err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+	if len(opened) != 1 || opened[0] != "vim -R /home/me/.claude/projects/-work-app/s1.jsonl" {
+		t.Fatalf("expected the session file to open in $EDITOR, got %v", opened)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "open", "claude", "s1", "--reveal"); err != nil || len(revealed) != 1 {
+		t.Fatalf("expected the session file to be revealed, got %v (%v)", revealed, err)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "open", "claude", "s1", "--path"); err != nil || strings.TrimSpace(out.String()) != "/home/me/.claude/projects/-work-app/s1.jsonl" {
+		t.Fatalf("expected the path to be printed, got %q (%v)", out.String(), err)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "open", "claude", "missing"); err == nil {
+		t.Fatal("expected an error for an unknown session")
+	}
+}
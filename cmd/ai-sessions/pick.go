@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/manifoldco/promptui"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+)
+
+// maxPickerSessions caps the sessions offered by the picker, newest first
+const maxPickerSessions = 50
+
+// Actions offered for the sessions selected in the picker
+const (
+	pickUpload = "Upload"
+	pickExport = "Export"
+	pickTag    = "Tag"
+	pickCancel = "Cancel"
+)
+
+var pickCommand = cliCommand{
+	name:    "pick",
+	summary: "Pick sessions from a list, then upload, export, or tag them",
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		opts := defaultUploadOptions
+		profileFlagVar(fs, &opts.Profile)
+		return func(env *cliEnv, args []string) error {
+			return runPick(env, opts)
+		}
+	},
+}
+
+// runPick lets the user select sessions and an action to apply to them. opts are the
+// upload options given to `aisessions upload`.
+func runPick(env *cliEnv, opts uploadOptions) error {
+	adaptersMap := env.sessionAdapters()
+	sessions, err := pickableSessions(adaptersMap)
+	if err != nil {
+		return err
+	}
+	selected, err := pickSessions(sessions)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(env.stdout, "Selected sessions:")
+	for _, session := range selected {
+		fmt.Fprintf(env.stdout, "  %s  %s  %s\n", getAgentDisplayName(session.Source), getProjectName(session.ProjectPath),
+			cleanFirstMessage(session.FirstMessage, 60))
+	}
+	fmt.Fprintln(env.stdout)
+
+	actions := promptui.Select{
+		Label: fmt.Sprintf("What should be done with %d selected sessions", len(selected)),
+		Items: []string{pickUpload, pickExport, pickTag, pickCancel},
+	}
+	_, action, err := actions.Run()
+	if err != nil || action == pickCancel {
+		return fmt.Errorf("selection cancelled")
+	}
+
+	switch action {
+	case pickUpload:
+		return uploadSessions(adaptersMap, selected, opts, env.options.URL, env.stdout)
+	case pickExport:
+		return exportSessions(adaptersMap, selected, env.stdout)
+	default:
+		return tagSessions(env, selected)
+	}
+}
+
+// convertedUploadSources are the sources whose session files don't hold the transcript
+// itself (opencode keeps messages in separate files), so they are uploaded converted to
+// the Claude Code format
+var convertedUploadSources = map[string]bool{"opencode": true}
+
+// pickableSessions returns the most recent sessions with user messages of every source,
+// newest first
+func pickableSessions(adaptersMap map[string]adapters.SessionAdapter) ([]adapters.Session, error) {
+	var sessions []adapters.Session
+	for _, source := range supportedSources {
+		adapter, ok := adaptersMap[source]
+		if !ok {
+			continue
+		}
+		listed, err := adapter.ListSessions("", maxPickerSessions)
+		if err != nil {
+			continue // Keep the other sources working
+		}
+		for _, session := range listed {
+			if session.UserMessageCount > 0 {
+				sessions = append(sessions, session)
+			}
+		}
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no sessions with user messages found")
+	}
+
+	// Newest first, putting sessions without a timestamp last
+	sort.SliceStable(sessions, func(i, j int) bool {
+		ti, tj := sessions[i].Timestamp, sessions[j].Timestamp
+		if ti.IsZero() || tj.IsZero() {
+			return !ti.IsZero() && tj.IsZero()
+		}
+		return ti.After(tj)
+	})
+	if len(sessions) > maxPickerSessions {
+		sessions = sessions[:maxPickerSessions]
+	}
+	return sessions, nil
+}
+
+// uploadSessions uploads the selected sessions one by one after previewing each,
+// starting the login flow first if needed
+func uploadSessions(adaptersMap map[string]adapters.SessionAdapter, sessions []adapters.Session, opts uploadOptions, apiURL string, stdout io.Writer) error {
+	config, err := loadConfig(opts.Profile)
+	if err != nil {
+		fmt.Fprintln(stdout, "Not authenticated. Let's set up your CLI access.")
+		fmt.Fprintln(stdout)
+		handleLogin("", opts.Profile)
+		if config, err = loadConfig(opts.Profile); err != nil {
+			return fmt.Errorf("failed to load configuration after login: %w", err)
+		}
+	}
+	if apiURL, err = resolveAPIURL(apiURL, config); err != nil {
+		return err
+	}
+	if len(sessions) > 1 {
+		opts.Title = "" // A title names a single transcript
+	}
+
+	uploaded := 0
+	for _, session := range sessions {
+		messages, err := readSession(adaptersMap, session)
+		if err != nil {
+			fmt.Fprintf(stdout, "Could not read %s session %s: %v\n", session.Source, session.ID, err)
+			continue
+		}
+		if ok, err := previewSession(session, messages); err != nil {
+			return err
+		} else if !ok {
+			fmt.Fprintf(stdout, "Skipped %s session %s\n", session.Source, session.ID)
+			continue
+		}
+		_, err = uploadSession(session, messages, apiURL, config.Token, opts, stdout)
+		if _, ok := err.(*AuthError); ok {
+			return fmt.Errorf("%w (run 'aisessions login' to re-authenticate)", err)
+		}
+		if err == nil {
+			uploaded++
+		} else {
+			fmt.Fprintf(stdout, "Could not upload %s session %s: %v\n", session.Source, session.ID, err)
+		}
+	}
+	if len(sessions) > 1 {
+		fmt.Fprintf(stdout, "Uploaded %d of %d sessions\n", uploaded, len(sessions))
+	}
+	if uploaded == 0 {
+		return fmt.Errorf("no sessions were uploaded")
+	}
+	return nil
+}
+
+// uploadSession uploads a session's file, or its transcript in the Claude Code format
+// when there is no file, it doesn't hold the transcript, or only a range of messages
+// is uploaded
+func uploadSession(session adapters.Session, messages []adapters.Message, apiURL, token string, opts uploadOptions, stdout io.Writer) (*UploadResponse, error) {
+	if opts.Range != nil {
+		trimmed, err := opts.Range.apply(messages)
+		if err != nil {
+			return nil, err
+		}
+		messages = trimmed
+	} else if !convertedUploadSources[session.Source] && session.FilePath != "" {
+		return uploadFile(apiURL, token, session.FilePath, opts, stdout)
+	}
+	content, err := export.Render("claude", session, messages)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Title == "" {
+		opts.Title = export.Title(session, messages)
+	}
+	return uploadTranscript(apiURL, token, session.ID+".jsonl", []byte(content), opts, stdout)
+}
+
+// exportSessions asks for a format and directory, and exports each session there
+func exportSessions(adaptersMap map[string]adapters.SessionAdapter, sessions []adapters.Session, stdout io.Writer) error {
+	formats := promptui.Select{Label: "Export format", Items: []string{"md", "html", "json", "jsonl"}}
+	_, format, err := formats.Run()
+	if err != nil {
+		return fmt.Errorf("export cancelled")
+	}
+	dirPrompt := promptui.Prompt{Label: "Directory", Default: "."}
+	dir, err := dirPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("export cancelled")
+	}
+	ext, err := export.Extension(format)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		path := filepath.Join(dir, session.Source+"-"+safePathSegment(session.ID)+ext)
+		if err := exportSessionFile(adaptersMap, session, format, path, nil); err != nil {
+			return fmt.Errorf("failed to export %s session %s: %w", session.Source, session.ID, err)
+		}
+		fmt.Fprintf(stdout, "Exported %s\n", path)
+	}
+	return nil
+}
+
+// tagSessions asks for tags and adds them to each session
+func tagSessions(env *cliEnv, sessions []adapters.Session) error {
+	tagPrompt := promptui.Prompt{Label: "Tags (separated by spaces)"}
+	input, err := tagPrompt.Run()
+	if err != nil {
+		return fmt.Errorf("tagging cancelled")
+	}
+	tags := strings.Fields(strings.ReplaceAll(input, ",", " "))
+	if len(tags) == 0 {
+		return fmt.Errorf("no tags given")
+	}
+
+	cache, err := env.searchCache()
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err := cache.TagSession(session.Source, session.ID, tags); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(env.stdout, "Tagged %d sessions: %s\n", len(sessions), strings.Join(tags, ", "))
+	return nil
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"golang.org/x/term"
+)
+
+// Keys the session picker responds to, besides printable runes
+type pickerKey int
+
+const (
+	keyRune pickerKey = iota
+	keyUp
+	keyDown
+	keyPageUp
+	keyPageDown
+	keyEnter
+	keyBackspace
+	keyEscape
+	keyInterrupt
+)
+
+// keyPress is a key read from the terminal; r is set for keyRune
+type keyPress struct {
+	key pickerKey
+	r   rune
+}
+
+// readKey reads one key press from a terminal in raw mode, decoding the escape
+// sequences of the arrow and page keys
+func readKey(r *bufio.Reader) (keyPress, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return keyPress{}, err
+	}
+	switch c {
+	case '\r', '\n':
+		return keyPress{key: keyEnter}, nil
+	case 0x7f, 0x08:
+		return keyPress{key: keyBackspace}, nil
+	case 0x03, 0x04:
+		return keyPress{key: keyInterrupt}, nil
+	case 0x1b:
+		if r.Buffered() == 0 {
+			return keyPress{key: keyEscape}, nil
+		}
+		next, _, _ := r.ReadRune()
+		if next != '[' && next != 'O' {
+			return keyPress{key: keyEscape}, nil
+		}
+		code, _, _ := r.ReadRune()
+		switch code {
+		case 'A':
+			return keyPress{key: keyUp}, nil
+		case 'B':
+			return keyPress{key: keyDown}, nil
+		case '5', '6':
+			r.ReadRune() // Trailing '~'
+			if code == '5' {
+				return keyPress{key: keyPageUp}, nil
+			}
+			return keyPress{key: keyPageDown}, nil
+		}
+		return keyPress{key: keyEscape}, nil
+	}
+	return keyPress{key: keyRune, r: c}, nil
+}
+
+// sessionPicker is the state of the interactive session picker: a filterable list of
+// sessions with a cursor, of which any number can be selected
+type sessionPicker struct {
+	sessions  []adapters.Session
+	visible   []int // Indexes of the sessions matching the query
+	cursor    int   // Position of the highlighted session in visible
+	offset    int   // Position in visible of the first row on screen
+	selected  map[int]bool
+	query     string
+	searching bool
+	height    int             // Rows of sessions shown at once
+	sources   []string        // Sources of the sessions, toggled by the number keys
+	hidden    map[string]bool // Sources toggled off
+}
+
+func newSessionPicker(sessions []adapters.Session, height int) *sessionPicker {
+	p := &sessionPicker{sessions: sessions, selected: make(map[int]bool), height: height, hidden: make(map[string]bool)}
+	if p.height < 1 {
+		p.height = 1
+	}
+	present := make(map[string]bool)
+	for _, session := range sessions {
+		present[session.Source] = true
+	}
+	for _, source := range supportedSources {
+		if present[source] {
+			p.sources = append(p.sources, source)
+		}
+	}
+	p.filter()
+	return p
+}
+
+// filter recomputes the sessions matching the query, keeping the cursor in range
+func (p *sessionPicker) filter() {
+	query := strings.ToLower(p.query)
+	p.visible = p.visible[:0]
+	for i, session := range p.sessions {
+		if p.hidden[session.Source] {
+			continue
+		}
+		if query == "" ||
+			strings.Contains(strings.ToLower(session.FirstMessage), query) ||
+			strings.Contains(strings.ToLower(session.ProjectPath), query) ||
+			strings.Contains(strings.ToLower(getAgentDisplayName(session.Source)), query) {
+			p.visible = append(p.visible, i)
+		}
+	}
+	p.move(0)
+}
+
+// move moves the cursor by delta rows, scrolling to keep it on screen
+func (p *sessionPicker) move(delta int) {
+	p.cursor += delta
+	if p.cursor >= len(p.visible) {
+		p.cursor = len(p.visible) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	if p.cursor < p.offset {
+		p.offset = p.cursor
+	}
+	if p.cursor >= p.offset+p.height {
+		p.offset = p.cursor - p.height + 1
+	}
+}
+
+// current returns the index of the highlighted session, or -1 when none matches
+func (p *sessionPicker) current() int {
+	if len(p.visible) == 0 {
+		return -1
+	}
+	return p.visible[p.cursor]
+}
+
+// handle applies a key press. It reports done once the user confirms or cancels
+// the selection, and whether they confirmed it.
+func (p *sessionPicker) handle(k keyPress) (done, confirmed bool) {
+	if p.searching {
+		switch k.key {
+		case keyRune:
+			p.query += string(k.r)
+			p.filter()
+		case keyBackspace:
+			if runes := []rune(p.query); len(runes) > 0 {
+				p.query = string(runes[:len(runes)-1])
+				p.filter()
+			}
+		case keyEnter:
+			p.searching = false
+		case keyEscape:
+			p.searching, p.query = false, ""
+			p.filter()
+		case keyInterrupt:
+			return true, false
+		case keyUp, keyDown, keyPageUp, keyPageDown:
+			p.searching = false
+			return p.handle(k)
+		}
+		return false, false
+	}
+
+	switch k.key {
+	case keyUp:
+		p.move(-1)
+	case keyDown:
+		p.move(1)
+	case keyPageUp:
+		p.move(-p.height)
+	case keyPageDown:
+		p.move(p.height)
+	case keyEnter:
+		if len(p.selected) == 0 && p.current() >= 0 {
+			p.selected[p.current()] = true // Enter alone picks the highlighted session
+		}
+		return len(p.selected) > 0, len(p.selected) > 0
+	case keyEscape, keyInterrupt:
+		return true, false
+	case keyRune:
+		switch k.r {
+		case 'k':
+			p.move(-1)
+		case 'j':
+			p.move(1)
+		case ' ':
+			if i := p.current(); i >= 0 {
+				if p.selected[i] {
+					delete(p.selected, i)
+				} else {
+					p.selected[i] = true
+				}
+				p.move(1)
+			}
+		case 'a':
+			p.toggleAll()
+		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			p.toggleSource(int(k.r - '1'))
+		case '/':
+			p.searching = true
+		case 'q':
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// toggleAll selects every matching session, or clears them if all are selected
+func (p *sessionPicker) toggleAll() {
+	all := true
+	for _, i := range p.visible {
+		all = all && p.selected[i]
+	}
+	for _, i := range p.visible {
+		if all {
+			delete(p.selected, i)
+		} else {
+			p.selected[i] = true
+		}
+	}
+}
+
+// toggleSource hides or shows the sessions of the n-th source. Hiding a source also
+// deselects its sessions, so nothing is acted on without being seen.
+func (p *sessionPicker) toggleSource(n int) {
+	if n >= len(p.sources) {
+		return
+	}
+	source := p.sources[n]
+	p.hidden[source] = !p.hidden[source]
+	if p.hidden[source] {
+		for i := range p.selected {
+			if p.sessions[i].Source == source {
+				delete(p.selected, i)
+			}
+		}
+	}
+	p.filter()
+}
+
+// selection returns the selected sessions, in list order
+func (p *sessionPicker) selection() []adapters.Session {
+	var sessions []adapters.Session
+	for i, session := range p.sessions {
+		if p.selected[i] {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// render draws the picker, width columns wide
+func (p *sessionPicker) render(w io.Writer, width int) {
+	fmt.Fprintln(w, "Select sessions")
+	fmt.Fprintln(w, "\033[2m↑/↓ move · space select · a select all · / search · 1-9 show/hide a source · enter confirm · q quit\033[0m")
+	var toggles []string
+	for n, source := range p.sources {
+		mark := "✓"
+		if p.hidden[source] {
+			mark = "✗"
+		}
+		toggles = append(toggles, fmt.Sprintf("%d %s %s", n+1, mark, getAgentDisplayName(source)))
+	}
+	fmt.Fprintln(w, "Sources: "+strings.Join(toggles, "  "))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\033[2m    "+formatTableHeader()+"\033[0m")
+
+	for row := p.offset; row < p.offset+p.height && row < len(p.visible); row++ {
+		i := p.visible[row]
+		mark := "[ ]"
+		if p.selected[i] {
+			mark = "[x]"
+		}
+		line := mark + " " + formatSessionRow(p.sessions[i], width-4)
+		if row == p.cursor {
+			line = "\033[36m" + line + "\033[0m"
+		}
+		fmt.Fprintln(w, line)
+	}
+	if len(p.visible) == 0 {
+		fmt.Fprintln(w, "  No sessions match")
+	}
+
+	fmt.Fprintln(w)
+	status := fmt.Sprintf("%d of %d sessions, %d selected", len(p.visible), len(p.sessions), len(p.selected))
+	if p.searching || p.query != "" {
+		status = "Search: " + p.query + "  (" + status + ")"
+	}
+	fmt.Fprint(w, status)
+}
+
+// pickSessions runs the picker on the terminal and returns the selected sessions
+func pickSessions(sessions []adapters.Session) ([]adapters.Session, error) {
+	var picker *sessionPicker
+	confirmed, err := runTerminalScreen(func(width, height int) screen {
+		picker = newSessionPicker(sessions, height-8)
+		return picker
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, fmt.Errorf("selection cancelled")
+	}
+	return picker.selection(), nil
+}
+
+// screen is a full-screen view of the terminal driven by key presses
+type screen interface {
+	render(w io.Writer, width int)
+	handle(k keyPress) (done, confirmed bool)
+}
+
+// runTerminalScreen puts the terminal in raw mode and redraws the screen created by
+// newScreen after each key press, until it is done. It reports whether the user
+// confirmed the screen.
+func runTerminalScreen(newScreen func(width, height int) screen) (bool, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return false, fmt.Errorf("interactive mode needs a terminal")
+	}
+	_, height, err := term.GetSize(fd)
+	if err != nil || height < 12 {
+		height = 24
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return false, fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	width := getTerminalWidth()
+	view := newScreen(width, height)
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		var frame bytes.Buffer
+		view.render(&frame, width)
+		// Raw mode doesn't translate newlines, so return the cursor explicitly
+		fmt.Fprint(os.Stdout, "\033[H\033[2J"+strings.ReplaceAll(frame.String(), "\n", "\r\n"))
+
+		key, err := readKey(reader)
+		if err != nil {
+			return false, fmt.Errorf("failed to read key: %w", err)
+		}
+		if done, confirmed := view.handle(key); done {
+			fmt.Fprint(os.Stdout, "\033[H\033[2J")
+			return confirmed, nil
+		}
+	}
+}
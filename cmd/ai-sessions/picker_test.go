@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestReadKey(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("\x1b[A\x1b[B\x1b[6~ x\r\x7f"))
+	want := []keyPress{{key: keyUp}, {key: keyDown}, {key: keyPageDown}, {key: keyRune, r: ' '}, {key: keyRune, r: 'x'}, {key: keyEnter}, {key: keyBackspace}}
+	for _, w := range want {
+		got, err := readKey(reader)
+		if err != nil || got != w {
+			t.Fatalf("readKey = %+v (%v), want %+v", got, err, w)
+		}
+	}
+}
+
+func TestSessionPickerMultiSelect(t *testing.T) {
+	sessions := []adapters.Session{
+		{ID: "a", Source: "claude", FirstMessage: "fix the login bug"},
+		{ID: "b", Source: "codex", FirstMessage: "add dark mode"},
+		{ID: "c", Source: "claude", FirstMessage: "login page styles"},
+	}
+	picker := newSessionPicker(sessions, 2)
+
+	// Space toggles the highlighted session and moves down
+	for _, k := range []keyPress{{key: keyRune, r: ' '}, {key: keyRune, r: ' '}, {key: keyUp}, {key: keyUp}, {key: keyRune, r: ' '}} {
+		if done, _ := picker.handle(k); done {
+			t.Fatalf("picker finished early on %+v", k)
+		}
+	}
+	if picker.offset != 0 || len(picker.selected) != 1 || !picker.selected[1] {
+		t.Fatalf("expected only session b selected, got %v", picker.selected)
+	}
+
+	// Searching narrows the list; toggling all selects only the matches
+	for _, k := range []keyPress{{key: keyRune, r: '/'}, {key: keyRune, r: 'l'}, {key: keyRune, r: 'o'}, {key: keyRune, r: 'g'}, {key: keyEnter}, {key: keyRune, r: 'a'}} {
+		picker.handle(k)
+	}
+	if len(picker.visible) != 2 {
+		t.Fatalf("expected 2 sessions matching 'log', got %d", len(picker.visible))
+	}
+
+	var frame bytes.Buffer
+	picker.render(&frame, 120)
+	if !strings.Contains(frame.String(), "[x]") || !strings.Contains(frame.String(), "Search: log  (2 of 3 sessions, 3 selected)") {
+		t.Fatalf("unexpected frame:\n%s", frame.String())
+	}
+
+	done, confirmed := picker.handle(keyPress{key: keyEnter})
+	if !done || !confirmed || len(picker.selection()) != 3 {
+		t.Fatalf("expected 3 sessions confirmed, got done=%v confirmed=%v %v", done, confirmed, picker.selection())
+	}
+}
+
+func TestSessionPickerEnterPicksHighlighted(t *testing.T) {
+	picker := newSessionPicker([]adapters.Session{{ID: "a"}, {ID: "b"}}, 10)
+	picker.handle(keyPress{key: keyDown})
+	if done, confirmed := picker.handle(keyPress{key: keyEnter}); !done || !confirmed {
+		t.Fatal("expected enter to confirm the highlighted session")
+	}
+	if selection := picker.selection(); len(selection) != 1 || selection[0].ID != "b" {
+		t.Fatalf("expected session b, got %v", selection)
+	}
+
+	picker = newSessionPicker([]adapters.Session{{ID: "a"}}, 10)
+	if done, confirmed := picker.handle(keyPress{key: keyRune, r: 'q'}); !done || confirmed {
+		t.Fatal("expected q to cancel")
+	}
+}
+
+func TestSessionPickerSourceToggles(t *testing.T) {
+	picker := newSessionPicker([]adapters.Session{
+		{ID: "a", Source: "opencode"},
+		{ID: "b", Source: "gemini"},
+		{ID: "c", Source: "opencode"},
+	}, 10)
+	if strings.Join(picker.sources, ",") != "gemini,opencode" {
+		t.Fatalf("expected sources in display order, got %v", picker.sources)
+	}
+
+	picker.handle(keyPress{key: keyRune, r: 'a'})
+	picker.handle(keyPress{key: keyRune, r: '2'})
+	if len(picker.visible) != 1 || len(picker.selection()) != 1 || picker.selection()[0].ID != "b" {
+		t.Fatalf("expected hiding opencode to leave only session b, got %v", picker.selection())
+	}
+	picker.handle(keyPress{key: keyRune, r: '2'})
+	if len(picker.visible) != 3 {
+		t.Fatalf("expected opencode sessions back, got %d visible", len(picker.visible))
+	}
+}
+
+func TestPickableSessions(t *testing.T) {
+	now := time.Now()
+	adaptersMap := map[string]adapters.SessionAdapter{
+		"claude":   newStubAdapter([]adapters.Session{{ID: "old", Source: "claude", Timestamp: now.Add(-time.Hour), UserMessageCount: 1}}, nil),
+		"opencode": newStubAdapter([]adapters.Session{{ID: "new", Source: "opencode", Timestamp: now, UserMessageCount: 2}, {ID: "empty", Source: "opencode", Timestamp: now}}, nil),
+	}
+	sessions, err := pickableSessions(adaptersMap)
+	if err != nil {
+		t.Fatalf("pickableSessions failed: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "new" || sessions[1].ID != "old" {
+		t.Fatalf("expected sessions with user messages of every source, newest first, got %v", sessions)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// previewMessages is how many messages the upload preview shows from each end of a session
+const previewMessages = 5
+
+// sessionPreview is the state of the scrollable preview shown before uploading a session
+type sessionPreview struct {
+	title  string
+	lines  []string
+	offset int // First line on screen
+	height int // Lines shown at once
+}
+
+// newSessionPreview renders the first and last n messages of a session into lines width
+// columns wide
+func newSessionPreview(session adapters.Session, messages []adapters.Message, n, width, height int) *sessionPreview {
+	p := &sessionPreview{
+		title:  fmt.Sprintf("%s · %s · %d messages", getAgentDisplayName(session.Source), getProjectName(session.ProjectPath), len(messages)),
+		height: height,
+	}
+	if p.height < 1 {
+		p.height = 1
+	}
+
+	var body bytes.Buffer
+	if len(messages) <= 2*n {
+		for _, msg := range messages {
+			printShownMessage(&body, msg, width, true)
+		}
+	} else {
+		for _, msg := range messages[:n] {
+			printShownMessage(&body, msg, width, true)
+		}
+		fmt.Fprintln(&body, styled(fmt.Sprintf("… %d messages not shown …", len(messages)-2*n), "\033[2m", true))
+		fmt.Fprintln(&body)
+		for _, msg := range messages[len(messages)-n:] {
+			printShownMessage(&body, msg, width, true)
+		}
+	}
+	p.lines = strings.Split(strings.TrimRight(body.String(), "\n"), "\n")
+	return p
+}
+
+// scroll moves the preview by delta lines, keeping the last screen full
+func (p *sessionPreview) scroll(delta int) {
+	p.offset += delta
+	if p.offset > len(p.lines)-p.height {
+		p.offset = len(p.lines) - p.height
+	}
+	if p.offset < 0 {
+		p.offset = 0
+	}
+}
+
+// handle applies a key press. It reports done once the user accepts or skips the
+// session, and whether they accepted it.
+func (p *sessionPreview) handle(k keyPress) (done, confirmed bool) {
+	switch k.key {
+	case keyUp:
+		p.scroll(-1)
+	case keyDown:
+		p.scroll(1)
+	case keyPageUp:
+		p.scroll(-p.height)
+	case keyPageDown:
+		p.scroll(p.height)
+	case keyEnter:
+		return true, true
+	case keyEscape, keyInterrupt:
+		return true, false
+	case keyRune:
+		switch k.r {
+		case 'k':
+			p.scroll(-1)
+		case 'j':
+			p.scroll(1)
+		case ' ':
+			p.scroll(p.height)
+		case 'y':
+			return true, true
+		case 'n', 'q':
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// render draws the visible lines of the preview
+func (p *sessionPreview) render(w io.Writer, width int) {
+	fmt.Fprintln(w, "Preview: "+truncateString(p.title, width-9))
+	fmt.Fprintln(w, "\033[2m↑/↓ scroll · space page down · enter/y upload · n/q skip\033[0m")
+	fmt.Fprintln(w)
+	end := p.offset + p.height
+	if end > len(p.lines) {
+		end = len(p.lines)
+	}
+	for _, line := range p.lines[p.offset:end] {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "Lines %d-%d of %d", p.offset+1, end, len(p.lines))
+}
+
+// previewSession shows the preview of a session on the terminal and reports whether
+// the user chose to upload it
+func previewSession(session adapters.Session, messages []adapters.Message) (bool, error) {
+	return runTerminalScreen(func(width, height int) screen {
+		return newSessionPreview(session, messages, previewMessages, width, height-5)
+	})
+}
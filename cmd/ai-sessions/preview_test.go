@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSessionPreview(t *testing.T) {
+	var messages []adapters.Message
+	for i := 1; i <= 12; i++ {
+		messages = append(messages, adapters.Message{Role: "user", Content: fmt.Sprintf("message %d", i)})
+	}
+	preview := newSessionPreview(adapters.Session{Source: "claude", ProjectPath: "/src/app"}, messages, 2, 80, 4)
+	text := strings.Join(preview.lines, "\n")
+	for _, want := range []string{"message 1\n", "message 2\n", "8 messages not shown", "message 11\n", "message 12"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in preview:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "message 3\n") {
+		t.Errorf("expected middle messages to be left out:\n%s", text)
+	}
+
+	// Scrolling stops with the last screen full
+	preview.handle(keyPress{key: keyPageDown})
+	preview.handle(keyPress{key: keyPageDown})
+	preview.handle(keyPress{key: keyPageDown})
+	preview.handle(keyPress{key: keyPageDown})
+	if preview.offset != len(preview.lines)-4 {
+		t.Fatalf("expected offset %d, got %d", len(preview.lines)-4, preview.offset)
+	}
+	var frame bytes.Buffer
+	preview.render(&frame, 80)
+	if !strings.Contains(frame.String(), "Preview: Claude Code · app · 12 messages") || !strings.Contains(frame.String(), fmt.Sprintf("of %d", len(preview.lines))) {
+		t.Fatalf("unexpected frame:\n%s", frame.String())
+	}
+
+	if done, confirmed := preview.handle(keyPress{key: keyEnter}); !done || !confirmed {
+		t.Fatal("expected enter to accept the upload")
+	}
+	if done, confirmed := preview.handle(keyPress{key: keyRune, r: 'n'}); !done || confirmed {
+		t.Fatal("expected n to skip the upload")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// serverGuard returns the privacy guard of the config file's allow_projects,
+// deny_projects, and strip_tool_output settings
+func serverGuard(config Config) adapters.Guard {
+	return adapters.Guard{
+		AllowProjects:   guardPaths(config.AllowProjects),
+		DenyProjects:    guardPaths(config.DenyProjects),
+		StripToolOutput: config.StripToolOutput,
+	}
+}
+
+// guardPaths expands "~/" in project paths and makes them absolute
+func guardPaths(paths []string) []string {
+	var expanded []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if path == "~" || strings.HasPrefix(path, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				path = filepath.Join(home, strings.TrimPrefix(path[1:], "/"))
+			}
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		expanded = append(expanded, path)
+	}
+	return expanded
+}
+
+// guardAdapters wraps every adapter so it only exposes what guard allows
+func guardAdapters(adaptersMap map[string]adapters.SessionAdapter, guard adapters.Guard) {
+	if !guard.Active() {
+		return
+	}
+	for name, adapter := range adaptersMap {
+		adaptersMap[name] = adapters.NewGuardedAdapter(adapter, guard)
+	}
+}
+
+// openServerSearchCache opens the search cache of the MCP server. A guarded server uses
+// a cache of its own for each guard, so sessions and tool output indexed without the
+// guard (by the CLI or an unguarded server) aren't found through it.
+func openServerSearchCache(guard adapters.Guard) (*search.Cache, error) {
+	if !guard.Active() {
+		return openSearchCache()
+	}
+	settings, err := json.Marshal(guard)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(settings)
+	name := "search-guarded-" + hex.EncodeToString(sum[:4]) + ".db"
+	cache, err := openCacheFile(name)
+	if err != nil {
+		return nil, err
+	}
+	pruneIgnoredSessions(cache)
+	pruneStaleMetadata(cache)
+	return cache, nil
+}
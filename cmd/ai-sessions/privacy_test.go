@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServerGuard(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+	guard := serverGuard(Config{
+		AllowProjects:   []string{"~/work", "/srv/app", ""},
+		DenyProjects:    []string{"~"},
+		StripToolOutput: true,
+	})
+	if len(guard.AllowProjects) != 2 || guard.AllowProjects[0] != filepath.Join(home, "work") || guard.AllowProjects[1] != "/srv/app" {
+		t.Errorf("expected expanded allowed projects, got %v", guard.AllowProjects)
+	}
+	if len(guard.DenyProjects) != 1 || guard.DenyProjects[0] != home {
+		t.Errorf("expected the home directory to be denied, got %v", guard.DenyProjects)
+	}
+	if !guard.StripToolOutput || !guard.Active() {
+		t.Error("expected the guard to strip tool output")
+	}
+	if serverGuard(Config{}).Active() {
+		t.Error("expected no guard without privacy settings")
+	}
+}
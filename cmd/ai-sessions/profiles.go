@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+)
+
+// profileNamePattern is what a profile may be called
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// profileConfig is the login of a named profile: its token and the server it is for
+type profileConfig struct {
+	Token    string `json:"token,omitempty"`
+	Keychain bool   `json:"keychain,omitempty"`
+	APIURL   string `json:"api_url,omitempty"`
+}
+
+// normalizeProfile validates a --profile value, returning "" for the default profile
+func normalizeProfile(name string) (string, error) {
+	if name == "" || name == "default" {
+		return "", nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, '-', and '_'", name)
+	}
+	return name, nil
+}
+
+// profileValue is a --profile flag, validated as it is parsed
+type profileValue string
+
+func (p *profileValue) String() string { return string(*p) }
+
+func (p *profileValue) Set(value string) error {
+	name, err := normalizeProfile(value)
+	*p = profileValue(name)
+	return err
+}
+
+// profileFlagVar defines the --profile flag of the commands that talk to a server
+func profileFlagVar(fs *flag.FlagSet, profile *string) {
+	fs.Var((*profileValue)(profile), "profile", "use the login of the profile called `name`")
+}
+
+// profileFlag formats the --profile option of a profile for the commands suggested to
+// the user
+func profileFlag(profile string) string {
+	if profile == "" {
+		return ""
+	}
+	return " --profile " + profile
+}
+
+// profile returns the login of a profile, "" being the default one kept at the top level
+// of the config file, and whether it was logged in to
+func (c *Config) profile(name string) (profileConfig, bool) {
+	if name == "" {
+		p := profileConfig{Token: c.Token, Keychain: c.Keychain, APIURL: c.APIURL}
+		return p, p.Token != "" || p.Keychain
+	}
+	p, ok := c.Profiles[name]
+	return p, ok
+}
+
+// setProfile replaces the login of a profile
+func (c *Config) setProfile(name string, p profileConfig) {
+	if name == "" {
+		c.Token, c.Keychain, c.APIURL = p.Token, p.Keychain, p.APIURL
+		return
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]profileConfig)
+	}
+	c.Profiles[name] = p
+}
+
+// removeProfile forgets the login of a profile, keeping the server of the default one
+// so logging in again goes to the same place
+func (c *Config) removeProfile(name string) {
+	if name == "" {
+		c.Token, c.Keychain = "", false
+		return
+	}
+	delete(c.Profiles, name)
+}
+
+// keychainAccountFor returns the keychain account the token of a profile is stored under
+func keychainAccountFor(profile string) string {
+	if profile == "" {
+		return keychainAccount
+	}
+	return keychainAccount + "-" + profile
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestStartProfilingServesProfiles(t *testing.T) {
+	addr, stop, err := startProfiling("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("startProfiling failed: %v", err)
+	}
+	defer stop()
+
+	resp, err := http.Get("http://" + addr.String() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatalf("failed to fetch the goroutine profile: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine profile") {
+		t.Fatalf("unexpected goroutine profile (%d): %.200s", resp.StatusCode, body)
+	}
+}
+
+func TestStartProfilingRequiresLoopback(t *testing.T) {
+	for _, addr := range []string{":6060", "0.0.0.0:6060", "192.168.1.10:6060", "example.com:6060", "localhost"} {
+		if _, stop, err := startProfiling(addr); err == nil {
+			stop()
+			t.Errorf("expected startProfiling(%q) to fail", addr)
+		}
+	}
+}
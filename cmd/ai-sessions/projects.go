@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// projectGroup is a per-project rollup of sessions across sources
+type projectGroup struct {
+	ProjectPath   string         `json:"project_path"`
+	Sessions      int            `json:"sessions"`
+	BySource      map[string]int `json:"by_source"`
+	FirstActivity time.Time      `json:"first_activity"`
+	LastActivity  time.Time      `json:"last_activity"`
+	TopTopics     []string       `json:"top_topics"`
+
+	sessionIDs []string
+}
+
+// Tool 16: group_sessions_by_project
+type groupSessionsByProjectArgs struct {
+	Source string `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
+	Since  string `json:"since,omitempty" jsonschema:"Only include sessions started after this point: a relative window like '7d', '2w', a date like '2025-01-31', or 'all' (default: all)"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Maximum number of projects to return, most recently active first (default: 20)"`
+	Topics int    `json:"topics,omitempty" jsonschema:"Number of top topics to report per project (default: 5)"`
+}
+
+func addGroupSessionsByProjectTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "group_sessions_by_project",
+		Description: "Group sessions from all sources by project (sessions in subdirectories of a git repository are grouped under its root) and return per-project rollups: session counts per source, first and last activity, and top topics",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args groupSessionsByProjectArgs) (*mcp.CallToolResult, any, error) {
+		if args.Limit == 0 {
+			args.Limit = 20
+		}
+		if args.Topics == 0 {
+			args.Topics = 5
+		}
+		since, err := parseSince(args.Since, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		sessions, err := collectSessions(adaptersMap, args.Source, "")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		groups := groupByProject(sessions, since)
+		if len(groups) > args.Limit {
+			groups = groups[:args.Limit]
+		}
+
+		// Topics come from the search index
+		if _, err := indexSessions(ctx, adaptersMap, searchCache, args.Source, ""); err != nil {
+			slog.Warn("Failed to index sessions", "error", err)
+		}
+		for i := range groups {
+			topics, err := searchCache.TopTerms(groups[i].sessionIDs, args.Topics)
+			if err != nil {
+				slog.Warn("Failed to compute topics", "project", groups[i].ProjectPath, "error", err)
+				topics = []string{}
+			}
+			groups[i].TopTopics = topics
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"projects": groups,
+			"count":    len(groups),
+		})
+	})
+}
+
+// groupByProject groups sessions started at or after since by repository, or by canonical
+// project path outside one, most recently active project first
+func groupByProject(sessions []adapters.Session, since time.Time) []projectGroup {
+	byPath := make(map[string]*projectGroup)
+	for _, session := range sessions {
+		if session.Timestamp.Before(since) {
+			continue
+		}
+		// Placeholders such as Gemini's unknown-project-<hash> aren't paths to resolve
+		path := strings.TrimSpace(session.GroupPath())
+		if path == "" {
+			path = "(unknown)"
+		} else if filepath.IsAbs(path) {
+			path = adapters.CanonicalProjectPath(path)
+		}
+		group, ok := byPath[path]
+		if !ok {
+			group = &projectGroup{ProjectPath: path, BySource: make(map[string]int), FirstActivity: session.Timestamp}
+			byPath[path] = group
+		}
+		group.Sessions++
+		group.BySource[session.Source]++
+		group.sessionIDs = append(group.sessionIDs, session.ID)
+		if session.Timestamp.Before(group.FirstActivity) {
+			group.FirstActivity = session.Timestamp
+		}
+		if session.Timestamp.After(group.LastActivity) {
+			group.LastActivity = session.Timestamp
+		}
+	}
+
+	groups := make([]projectGroup, 0, len(byPath))
+	for _, group := range byPath {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if !groups[i].LastActivity.Equal(groups[j].LastActivity) {
+			return groups[i].LastActivity.After(groups[j].LastActivity)
+		}
+		return groups[i].ProjectPath < groups[j].ProjectPath
+	})
+	return groups
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestGroupByProject(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []adapters.Session{
+		{ID: "1", Source: "claude", ProjectPath: "/work/api", Timestamp: now.Add(-2 * time.Hour)},
+		{ID: "2", Source: "codex", ProjectPath: "/work/api/", Timestamp: now},
+		{ID: "3", Source: "claude", ProjectPath: "/work/web", Timestamp: now.Add(-time.Hour)},
+		{ID: "4", Source: "gemini", ProjectPath: "", Timestamp: now.Add(-3 * time.Hour)},
+		{ID: "5", Source: "claude", ProjectPath: "/work/old", Timestamp: now.AddDate(0, -1, 0)},
+	}
+
+	groups := groupByProject(sessions, now.AddDate(0, 0, -7))
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 projects after the since filter, got %+v", groups)
+	}
+
+	api := groups[0]
+	if api.ProjectPath != "/work/api" || api.Sessions != 2 || api.BySource["claude"] != 1 || api.BySource["codex"] != 1 {
+		t.Fatalf("trailing slashes should group together: %+v", api)
+	}
+	if !api.FirstActivity.Equal(now.Add(-2*time.Hour)) || !api.LastActivity.Equal(now) {
+		t.Fatalf("unexpected activity range: %+v", api)
+	}
+	if groups[1].ProjectPath != "/work/web" || groups[2].ProjectPath != "(unknown)" {
+		t.Fatalf("projects should be ordered by last activity: %+v", groups)
+	}
+}
+
+func TestGroupByProjectUsesRepositories(t *testing.T) {
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	sessions := []adapters.Session{
+		{ID: "1", Source: "claude", ProjectPath: "/work/app", Repo: "/work/app", Timestamp: now},
+		{ID: "2", Source: "codex", ProjectPath: "/work/app/web", Repo: "/work/app", Timestamp: now.Add(-time.Hour)},
+		{ID: "3", Source: "claude", ProjectPath: "/work/scratch", Timestamp: now.Add(-2 * time.Hour)},
+	}
+
+	groups := groupByProject(sessions, time.Time{})
+	if len(groups) != 2 || groups[0].ProjectPath != "/work/app" || groups[0].Sessions != 2 {
+		t.Fatalf("expected sessions in a repository's subdirectories to group under its root, got %+v", groups)
+	}
+	if groups[1].ProjectPath != "/work/scratch" {
+		t.Fatalf("expected sessions outside a repository to group by project path, got %+v", groups)
+	}
+}
+
+func TestGroupByProjectResolvesSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "app")
+	link := filepath.Join(dir, "link")
+	if err := os.Mkdir(target, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	now := time.Now()
+	sessions := []adapters.Session{
+		{ID: "1", Source: "claude", ProjectPath: target, Timestamp: now},
+		{ID: "2", Source: "codex", ProjectPath: link, Timestamp: now},
+	}
+	if groups := groupByProject(sessions, time.Time{}); len(groups) != 1 || groups[0].Sessions != 2 {
+		t.Fatalf("expected a symlinked project directory to group with its target, got %+v", groups)
+	}
+}
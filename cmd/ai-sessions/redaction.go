@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/manifoldco/promptui"
+	"github.com/yoavf/ai-sessions-mcp/redact"
+)
+
+// Choices offered when reviewing a possible secret before upload
+const (
+	redactValue     = "Redact"
+	keepValue       = "Keep"
+	redactRemaining = "Redact this and all remaining"
+	keepRemaining   = "Keep this and all remaining"
+)
+
+// redactionGroup is a sensitive value and each place it appears in a transcript
+type redactionGroup struct {
+	kind     string
+	value    string
+	findings []redact.Finding
+}
+
+// redactionReviewer asks whether to redact a value, returning one of the choices above
+type redactionReviewer func(text string, group redactionGroup, n, total int, w io.Writer) (string, error)
+
+// groupFindings groups findings by value, in order of first appearance
+func groupFindings(findings []redact.Finding) []redactionGroup {
+	var groups []redactionGroup
+	index := make(map[string]int)
+	for _, f := range findings {
+		i, ok := index[f.Value]
+		if !ok {
+			i = len(groups)
+			index[f.Value] = i
+			groups = append(groups, redactionGroup{kind: f.Kind, value: f.Value})
+		}
+		groups[i].findings = append(groups[i].findings, f)
+	}
+	return groups
+}
+
+// redactTranscript finds secrets and personal details in a transcript, asks review about
+// each distinct value, and replaces the ones to redact. It returns the redacted
+// transcript and the number of values redacted.
+func redactTranscript(text string, w io.Writer, review redactionReviewer) (string, int, error) {
+	groups := groupFindings(redact.Find(text))
+	if len(groups) == 0 {
+		return text, 0, nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\033[33m⚠ Found %d possible secrets or personal details\033[0m\n", len(groups))
+	var redacted []redact.Finding
+	count := 0
+	rest := ""
+	for n, group := range groups {
+		choice := rest
+		if choice == "" {
+			var err error
+			if choice, err = review(text, group, n+1, len(groups), w); err != nil {
+				return "", 0, err
+			}
+			if choice == redactRemaining || choice == keepRemaining {
+				rest = choice
+			}
+		}
+		if choice == redactValue || choice == redactRemaining {
+			redacted = append(redacted, group.findings...)
+			count++
+		}
+	}
+
+	sort.Slice(redacted, func(i, j int) bool { return redacted[i].Start < redacted[j].Start })
+	return redact.Apply(text, redacted), count, nil
+}
+
+// promptRedaction shows a value in context and asks whether to redact it
+func promptRedaction(text string, group redactionGroup, n, total int, w io.Writer) (string, error) {
+	before, after := redact.Context(text, group.findings[0], 40)
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "[%d/%d] %s", n, total, group.kind)
+	if len(group.findings) > 1 {
+		fmt.Fprintf(w, " (%d occurrences)", len(group.findings))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\033[2m…%s\033[0m\033[31m%s\033[0m\033[2m%s…\033[0m\n", before, group.value, after)
+
+	prompt := promptui.Select{
+		Label:  "Redact this value",
+		Items:  []string{redactValue, keepValue, redactRemaining, keepRemaining},
+		Stdout: nopWriteCloser{w},
+	}
+	_, choice, err := prompt.Run()
+	if err != nil {
+		return "", fmt.Errorf("upload cancelled")
+	}
+	return choice, nil
+}
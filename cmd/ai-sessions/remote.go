@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// remoteSyncInterval is how long the local mirror of a remote machine is used before
+// it is synced again
+const remoteSyncInterval = 5 * time.Minute
+
+// remoteDataPaths are the session directories mirrored from a remote machine's home
+// directory, by source
+var remoteDataPaths = map[string][]string{
+	"claude":   {".claude/projects"},
+	"codex":    {".codex/sessions", ".codex/archived_sessions"},
+	"gemini":   {".gemini/tmp"},
+	"opencode": {".local/share/opencode/storage"},
+}
+
+// runCommand runs an external command, replaced in tests
+var runCommand = func(name string, args ...string) error {
+	output, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w: %s", name, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// remoteMirrorDir returns the local directory a remote machine's sessions are mirrored to
+func remoteMirrorDir(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid machine name: %q", name)
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", "ai-sessions", "machines", name), nil
+}
+
+// syncedMarker is touched in a mirror after each sync, recording when it last synced
+const syncedMarker = ".last-sync"
+
+// syncRemoteMachine mirrors the session directories of the enabled sources from a remote
+// machine over SSH with rsync, unless the mirror was synced within maxAge. Only changed
+// files are transferred, so syncing an up to date mirror is cheap.
+func syncRemoteMachine(machine machineConfig, selection sourceSelection, maxAge time.Duration) error {
+	if machine.Home == "" {
+		return fmt.Errorf("machine %s has no mirror directory", machine.Name)
+	}
+	marker := filepath.Join(machine.Home, syncedMarker)
+	if info, err := os.Stat(marker); err == nil && time.Since(info.ModTime()) < maxAge {
+		return nil
+	}
+
+	var failures []string
+	synced := 0
+	for _, source := range supportedSources {
+		if !selection.allows(source) {
+			continue
+		}
+		for _, path := range remoteDataPaths[source] {
+			local := filepath.Join(machine.Home, filepath.FromSlash(path))
+			if err := os.MkdirAll(local, 0o700); err != nil {
+				return fmt.Errorf("failed to create mirror directory: %w", err)
+			}
+			// Paths without a leading slash are relative to the remote home directory
+			err := runCommand("rsync", "-az", "--delete", "-e", "ssh -o BatchMode=yes",
+				machine.SSH+":"+path+"/", local+string(filepath.Separator))
+			switch {
+			case err == nil:
+				synced++
+			case strings.Contains(err.Error(), "No such file or directory"):
+				// The agent isn't installed remotely, so there is nothing to sync
+			default:
+				failures = append(failures, fmt.Sprintf("%s: %v", path, err))
+			}
+		}
+	}
+
+	// The mirror only counts as synced if something was synced, or nothing failed but
+	// for missing directories; otherwise an unreachable machine is tried again next time
+	if len(failures) > 0 {
+		if synced == 0 {
+			return fmt.Errorf("failed to sync %s: %s", machine.Name, strings.Join(failures, "; "))
+		}
+		for _, failure := range failures {
+			slog.Warn("Incomplete sync", "machine", machine.Name, "error", failure)
+		}
+	}
+	if err := os.WriteFile(marker, []byte(time.Now().Format(time.RFC3339)), 0o600); err != nil {
+		return fmt.Errorf("failed to record sync: %w", err)
+	}
+	return nil
+}
+
+// syncRemoteMachines syncs every remote machine whose mirror is older than maxAge
+func syncRemoteMachines(machines []machineConfig, selection sourceSelection, maxAge time.Duration) {
+	for _, machine := range machines {
+		if machine.SSH == "" {
+			continue
+		}
+		if err := syncRemoteMachine(machine, selection, maxAge); err != nil {
+			slog.Warn("Failed to sync machine", "machine", machine.Name, "error", err)
+		}
+	}
+}
+
+// keepRemoteMachinesSynced syncs remote machines in the background until ctx is done,
+// so the server starts immediately and searches always read the local mirror
+func keepRemoteMachinesSynced(ctx context.Context, machines []machineConfig, selection sourceSelection) {
+	hasRemote := false
+	for _, machine := range machines {
+		hasRemote = hasRemote || machine.SSH != ""
+	}
+	if !hasRemote {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(remoteSyncInterval)
+		defer ticker.Stop()
+		for {
+			syncRemoteMachines(machines, selection, remoteSyncInterval)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncRemoteMachineMirrorsEnabledSources(t *testing.T) {
+	mirror := t.TempDir()
+	var calls [][]string
+	original := runCommand
+	runCommand = func(name string, args ...string) error {
+		calls = append(calls, append([]string{name}, args...))
+		return nil
+	}
+	defer func() { runCommand = original }()
+
+	machine := machineConfig{Name: "devbox", SSH: "me@devbox", Home: mirror}
+	selection := sourceSelection{Enabled: []string{"claude", "codex"}}
+	if err := syncRemoteMachine(machine, selection, time.Minute); err != nil {
+		t.Fatalf("syncRemoteMachine failed: %v", err)
+	}
+
+	if len(calls) != 3 {
+		t.Fatalf("expected rsync for the claude and codex directories, got %v", calls)
+	}
+	first := strings.Join(calls[0], " ")
+	if calls[0][0] != "rsync" || !strings.Contains(first, "me@devbox:.claude/projects/") ||
+		!strings.HasSuffix(first, filepath.Join(mirror, ".claude", "projects")+string(filepath.Separator)) {
+		t.Fatalf("unexpected rsync command: %s", first)
+	}
+	if _, err := os.Stat(filepath.Join(mirror, syncedMarker)); err != nil {
+		t.Fatalf("expected the sync to be recorded: %v", err)
+	}
+
+	// A fresh mirror isn't synced again
+	if err := syncRemoteMachine(machine, selection, time.Minute); err != nil {
+		t.Fatalf("syncRemoteMachine failed: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("expected a fresh mirror to be reused, got %d commands", len(calls))
+	}
+
+	// The adapters read the mirror even before the first sync created it
+	if dir := (machineConfig{Name: "new", SSH: "me@new", Home: filepath.Join(mirror, "missing")}).dataDir("claude"); dir == "" {
+		t.Fatal("expected a data directory for a machine that hasn't synced yet")
+	}
+}
+
+func TestSyncRemoteMachineFailures(t *testing.T) {
+	mirror := t.TempDir()
+	var fail func(args []string) error
+	original := runCommand
+	runCommand = func(name string, args ...string) error { return fail(args) }
+	defer func() { runCommand = original }()
+
+	machine := machineConfig{Name: "devbox", SSH: "me@devbox", Home: mirror}
+	selection := sourceSelection{Enabled: []string{"claude", "codex"}}
+	marker := filepath.Join(mirror, syncedMarker)
+
+	// An unreachable machine isn't recorded as synced, so it is tried again
+	fail = func([]string) error {
+		return errors.New("rsync failed: ssh: connect to host devbox: Connection refused")
+	}
+	if err := syncRemoteMachine(machine, selection, time.Minute); err == nil {
+		t.Fatal("expected an error when nothing could be synced")
+	}
+	if _, err := os.Stat(marker); !os.IsNotExist(err) {
+		t.Fatalf("expected no sync to be recorded, got %v", err)
+	}
+
+	// Directories of agents that aren't installed remotely don't count as failures
+	fail = func([]string) error {
+		return errors.New("rsync failed: change_dir \"/home/me/.codex/sessions\" failed: No such file or directory (2)")
+	}
+	if err := syncRemoteMachine(machine, selection, time.Minute); err != nil {
+		t.Fatalf("syncRemoteMachine failed: %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Fatalf("expected the sync to be recorded: %v", err)
+	}
+}
+
+func TestConfiguredMachinesRejectsOptionDestinations(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+	config := Config{Machines: []machineConfig{
+		{Name: "evil", SSH: "-oProxyCommand=touch /tmp/pwned"},
+		{Name: "devbox", SSH: "me@devbox"},
+	}}
+	if err := saveConfig(config); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+	machines := configuredMachines()
+	if len(machines) != 1 || machines[0].Name != "devbox" {
+		t.Fatalf("expected only the devbox machine, got %+v", machines)
+	}
+}
+
+func TestRemoteMirrorDirRejectsPaths(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if _, err := remoteMirrorDir("../escape"); err == nil {
+		t.Fatal("expected an error for a machine name containing a path")
+	}
+	if dir, err := remoteMirrorDir("devbox"); err != nil || filepath.Base(dir) != "devbox" {
+		t.Fatalf("unexpected mirror directory %q (%v)", dir, err)
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"golang.org/x/term"
+)
+
+const (
+	// defaultReplayMaxDelay caps the pause between two messages of a replay, so the
+	// time a session sat idle doesn't stall it
+	defaultReplayMaxDelay = 3 * time.Second
+
+	// untimedReplayDelay is the pause before messages without a timestamp, at speed 1
+	untimedReplayDelay = time.Second
+)
+
+// replayPause waits for d, returning early with ctx.Err() when ctx is done. Tests
+// replace it to record the pauses instead.
+var replayPause = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+var replayCommand = cliCommand{
+	name:    "replay",
+	args:    "<source> <id>",
+	summary: "Play a session back in the terminal, with its timing or one message per key press",
+	minArgs: 2,
+	maxArgs: 2,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		opts := replayOptions{}
+		fs.Float64Var(&opts.Speed, "speed", 1, "play back `n` times faster than the session happened")
+		fs.DurationVar(&opts.MaxDelay, "max-delay", defaultReplayMaxDelay, "longest `pause` between two messages")
+		fs.BoolVar(&opts.Step, "step", false, "show the next message on each key press instead (space or enter: next, q: quit)")
+		roles := fs.String("role", "", "only replay messages of these comma-separated `roles` (user, assistant, tool, system)")
+		fs.StringVar(roles, "roles", "", "")
+		return func(env *cliEnv, args []string) error {
+			if opts.Speed <= 0 {
+				return fmt.Errorf("--speed must be positive")
+			}
+			if *roles != "" {
+				opts.Roles = strings.Split(*roles, ",")
+			}
+			messages, err := loadSessionMessages(env.sessionAdapters(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			messages = filterMessages(messages, messageFilter{Roles: opts.Roles})
+			if opts.Step {
+				return replayOnKeyPress(messages)
+			}
+			return replayTimed(env.context(), messages, opts, env.stdout)
+		}
+	},
+}
+
+// replayOptions are the options of `aisessions replay`
+type replayOptions struct {
+	Speed    float64       // How many times faster than the session to play it back
+	MaxDelay time.Duration // Longest pause between two messages
+	Step     bool          // Wait for a key press before each message instead
+	Roles    []string      // Empty replays every role
+}
+
+// replayTimed prints the messages of a session as `aisessions show` does, pausing
+// between them as long as the session did, divided by the speed and capped at the
+// maximum delay. Interrupting the replay ends it.
+func replayTimed(ctx context.Context, messages []adapters.Message, opts replayOptions, w io.Writer) error {
+	color := isTerminal(w)
+	width := getTerminalWidth()
+	for i, msg := range messages {
+		if i > 0 {
+			if err := replayPause(ctx, replayDelay(messages[i-1].Timestamp, msg.Timestamp, opts)); err != nil {
+				return nil
+			}
+		}
+		printShownMessage(w, msg, width, color)
+	}
+	return nil
+}
+
+// replayDelay returns the pause before a message sent at next, after one sent at prev
+func replayDelay(prev, next time.Time, opts replayOptions) time.Duration {
+	gap := untimedReplayDelay
+	if !prev.IsZero() && !next.IsZero() {
+		gap = max(next.Sub(prev), 0)
+	}
+	return min(time.Duration(float64(gap)/opts.Speed), opts.MaxDelay)
+}
+
+// replaySteps prints the messages of a session one at a time, calling next before each
+// message but the first. It stops when next returns false.
+func replaySteps(messages []adapters.Message, w io.Writer, color bool, next func() (bool, error)) error {
+	width := getTerminalWidth()
+	for i, msg := range messages {
+		if i > 0 {
+			prompt := fmt.Sprintf("[%d/%d] space: next · q: quit", i, len(messages))
+			fmt.Fprint(w, styled(prompt, "\033[2m", color))
+			more, err := next()
+			// Replace the prompt with the message
+			fmt.Fprint(w, "\r\033[K")
+			if err != nil || !more {
+				return err
+			}
+		}
+		printShownMessage(w, msg, width, color)
+	}
+	return nil
+}
+
+// replayOnKeyPress replays a session on the terminal, showing the next message on each
+// key press
+func replayOnKeyPress(messages []adapters.Message) error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("--step needs a terminal")
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return fmt.Errorf("failed to set up the terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	reader := bufio.NewReader(os.Stdin)
+	next := func() (bool, error) {
+		for {
+			key, err := readKey(reader)
+			if err != nil {
+				return false, fmt.Errorf("failed to read key: %w", err)
+			}
+			switch {
+			case key.key == keyEnter, key.key == keyDown, key.key == keyRune && (key.r == ' ' || key.r == 'j' || key.r == 'n'):
+				return true, nil
+			case key.key == keyEscape, key.key == keyInterrupt, key.key == keyRune && key.r == 'q':
+				return false, nil
+			}
+		}
+	}
+	return replaySteps(messages, crlfWriter{os.Stdout}, true, next)
+}
+
+// crlfWriter returns the cursor at the end of each line, which a terminal in raw mode
+// doesn't do
+type crlfWriter struct {
+	w io.Writer
+}
+
+func (c crlfWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(c.w, strings.ReplaceAll(string(p), "\n", "\r\n")); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
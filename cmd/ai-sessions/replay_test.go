@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunReplayCommand(t *testing.T) {
+	start := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	stub := newStubAdapter(nil, map[string][]adapters.Message{
+		"s1": {
+			{Role: "user", Content: "Fix the build", Timestamp: start},
+			{Role: "assistant", Content: "Looking.", Timestamp: start.Add(2 * time.Second)},
+			{Role: "assistant", Content: "Done, after a long wait.", Timestamp: start.Add(time.Hour)},
+			{Role: "user", Content: "Thanks"},
+		},
+	})
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var pauses []time.Duration
+	defer func(pause func(context.Context, time.Duration) error) { replayPause = pause }(replayPause)
+	replayPause = func(ctx context.Context, d time.Duration) error {
+		pauses = append(pauses, d)
+		return nil
+	}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "replay", "claude", "s1", "--speed", "2"); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	want := []time.Duration{time.Second, defaultReplayMaxDelay, untimedReplayDelay / 2}
+	if len(pauses) != len(want) {
+		t.Fatalf("expected pauses %v, got %v", want, pauses)
+	}
+	for i := range want {
+		if pauses[i] != want[i] {
+			t.Errorf("pause %d: expected %v, got %v", i, want[i], pauses[i])
+		}
+	}
+	text := out.String()
+	if !strings.Contains(text, "── User") || strings.Index(text, "Looking.") > strings.Index(text, "Thanks") {
+		t.Fatalf("expected the messages in order, got:\n%s", text)
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "replay", "claude", "s1", "--speed", "0"); err == nil {
+		t.Fatal("expected an error for a speed of 0")
+	}
+}
+
+func TestReplayStepsStopsOnQuit(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "first"},
+		{Role: "assistant", Content: "second"},
+		{Role: "assistant", Content: "third"},
+	}
+	presses := []bool{true, false}
+	var out bytes.Buffer
+	err := replaySteps(messages, &out, false, func() (bool, error) {
+		more := presses[0]
+		presses = presses[1:]
+		return more, nil
+	})
+	if err != nil {
+		t.Fatalf("replaySteps failed: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "[1/3] space: next") || !strings.Contains(text, "second") || strings.Contains(text, "third") {
+		t.Fatalf("expected the replay to stop before the third message, got:\n%s", text)
+	}
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Tool 29: save_search
+type saveSearchArgs struct {
+	Name        string `json:"name" jsonschema:"Name of the saved search, e.g. 'flaky-ci'. Names are lower-cased and spaces become dashes. Saving under an existing name replaces that search."`
+	Query       string `json:"query,omitempty" jsonschema:"Search query, as for search_sessions"`
+	Source      string `json:"source,omitempty" jsonschema:"Optional: only search sessions of this source (claude, gemini, codex, opencode)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Optional: only search sessions of a project: a path, a glob like '~/work/*', or a directory name"`
+	Tag         string `json:"tag,omitempty" jsonschema:"Optional: only search sessions carrying this tag"`
+	Keyword     string `json:"keyword,omitempty" jsonschema:"Optional: only search sessions having this keyword"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of matching sessions to return (default: 10)"`
+	Delete      bool   `json:"delete,omitempty" jsonschema:"Delete the saved search instead of saving it"`
+}
+
+func addSaveSearchTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "save_search",
+		Description: "Save a search_sessions query and its filters under a name, so a recurring lookup like 'flaky CI investigations in repo X' runs in one call with run_saved_search. Saved searches are stored locally.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args saveSearchArgs) (*mcp.CallToolResult, any, error) {
+		if args.Delete {
+			if err := searchCache.DeleteSavedSearch(args.Name); err != nil {
+				return nil, nil, err
+			}
+			return jsonToolResult(map[string]interface{}{"name": args.Name, "deleted": true})
+		}
+		saved, err := saveSearch(adaptersMap, searchCache, search.SavedSearch{
+			Name:        args.Name,
+			Query:       args.Query,
+			Source:      args.Source,
+			ProjectPath: args.ProjectPath,
+			Tag:         args.Tag,
+			Keyword:     args.Keyword,
+			Limit:       args.Limit,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonToolResult(saved)
+	})
+}
+
+// saveSearch checks a saved search's source and stores it
+func saveSearch(adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache, saved search.SavedSearch) (search.SavedSearch, error) {
+	if saved.Source != "" {
+		if _, ok := adaptersMap[saved.Source]; !ok {
+			return search.SavedSearch{}, fmt.Errorf("unknown source: %s", saved.Source)
+		}
+	}
+	return searchCache.SaveSearch(saved)
+}
+
+// Tool 30: list_saved_searches
+type listSavedSearchesArgs struct{}
+
+func addListSavedSearchesTool(server *mcp.Server, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_saved_searches",
+		Description: "List the saved searches with their queries and filters, to run one with run_saved_search",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args listSavedSearchesArgs) (*mcp.CallToolResult, any, error) {
+		searches, err := searchCache.SavedSearches()
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonToolResult(map[string]interface{}{
+			"searches": searches,
+			"count":    len(searches),
+		})
+	})
+}
+
+// Tool 31: run_saved_search
+type runSavedSearchArgs struct {
+	Name           string `json:"name" jsonschema:"Name of the saved search to run"`
+	Limit          int    `json:"limit,omitempty" jsonschema:"Maximum number of matching sessions to return (default: the saved search's limit)"`
+	Snippets       int    `json:"snippets,omitempty" jsonschema:"Maximum number of snippets to return per session (default: 1)"`
+	SnippetLength  int    `json:"snippet_length,omitempty" jsonschema:"Approximate length of each snippet in characters (default: 300)"`
+	Highlight      string `json:"highlight,omitempty" jsonschema:"Highlight matched terms in snippets: 'em' wraps them in <em></em> tags, 'marker' wraps them in ** markers. Leave empty for no highlighting."`
+	ShowDuplicates bool   `json:"show_duplicates,omitempty" jsonschema:"Return every copy of a duplicated session instead of collapsing the copies into the best match"`
+}
+
+func addRunSavedSearchTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "run_saved_search",
+		Description: "Run a saved search by name. Returns the same matches as search_sessions, with the saved search.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args runSavedSearchArgs) (*mcp.CallToolResult, any, error) {
+		saved, err := searchCache.SavedSearch(args.Name)
+		if err != nil {
+			return nil, nil, err
+		}
+		limit := saved.Limit
+		if args.Limit > 0 {
+			limit = args.Limit
+		}
+		result, err := searchSessions(ctx, adaptersMap, searchCache, searchSessionsArgs{
+			Query:          saved.Query,
+			Source:         saved.Source,
+			ProjectPath:    saved.ProjectPath,
+			Tag:            saved.Tag,
+			Keyword:        saved.Keyword,
+			Limit:          limit,
+			Snippets:       args.Snippets,
+			SnippetLength:  args.SnippetLength,
+			Highlight:      args.Highlight,
+			ShowDuplicates: args.ShowDuplicates,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		result["saved_search"] = saved
+		return jsonToolResult(result)
+	})
+}
+
+var searchesCommand = cliCommand{
+	name:    "searches",
+	args:    "<list|save|run|delete> [name] [query...]",
+	summary: "List, save, run, or delete saved searches",
+	minArgs: 1,
+	maxArgs: -1,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var saved search.SavedSearch
+		fs.StringVar(&saved.Source, "source", "", "with save, only search sessions of this `source`")
+		fs.StringVar(&saved.ProjectPath, "project", "", "with save, only search sessions of a `project`: a path, a glob, or a directory name")
+		fs.StringVar(&saved.Tag, "tag", "", "with save, only search sessions carrying this `tag`")
+		fs.StringVar(&saved.Keyword, "keyword", "", "with save, only search sessions having this `keyword`")
+		fs.IntVar(&saved.Limit, "limit", 0, "show at most `n` matches (default: the saved search's limit, or 10)")
+		showDuplicates := fs.Bool("duplicates", false, "with run, show every copy of a duplicated session")
+		return func(env *cliEnv, args []string) error {
+			cache, err := env.searchCache()
+			if err != nil {
+				return err
+			}
+			adaptersMap := env.sessionAdapters()
+			useMetadataCache(adaptersMap, cache)
+			return runSearchesCommand(env.context(), adaptersMap, cache, args, saved, *showDuplicates, env.options.JSON, env.stdout)
+		}
+	},
+}
+
+// runSearchesCommand runs the list, save, run, or delete action of `aisessions searches`.
+// flags holds the filters given on the command line.
+func runSearchesCommand(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, args []string, flags search.SavedSearch, showDuplicates, asJSON bool, stdout io.Writer) error {
+	action := args[0]
+	switch {
+	case action == "list" && len(args) != 1:
+		return fmt.Errorf("usage: aisessions searches list")
+	case action == "save" && len(args) < 3:
+		return fmt.Errorf("usage: aisessions searches save <name> <query>")
+	case (action == "run" || action == "delete") && len(args) != 2:
+		return fmt.Errorf("usage: aisessions searches %s <name>", action)
+	}
+
+	switch action {
+	case "list":
+		searches, err := cache.SavedSearches()
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(stdout, map[string]interface{}{"searches": searches, "count": len(searches)})
+		}
+		if len(searches) == 0 {
+			fmt.Fprintln(stdout, "No saved searches. Save one with: aisessions searches save <name> <query>")
+			return nil
+		}
+		for _, saved := range searches {
+			fmt.Fprintf(stdout, "%-24s %s\n", saved.Name, describeSavedSearch(saved))
+		}
+		return nil
+
+	case "save":
+		flags.Name, flags.Query = args[1], strings.Join(args[2:], " ")
+		saved, err := saveSearch(adaptersMap, cache, flags)
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(stdout, saved)
+		}
+		fmt.Fprintf(stdout, "Saved %s: %s\n", saved.Name, describeSavedSearch(saved))
+		return nil
+
+	case "run":
+		saved, err := cache.SavedSearch(args[1])
+		if err != nil {
+			return err
+		}
+		limit := saved.Limit
+		if flags.Limit > 0 {
+			limit = flags.Limit
+		}
+		if limit == 0 {
+			limit = 10
+		}
+		return runSearchCommand(ctx, adaptersMap, cache, searchCommandOptions{
+			Query:          saved.Query,
+			Source:         saved.Source,
+			ProjectPath:    saved.ProjectPath,
+			Limit:          limit,
+			Keyword:        saved.Keyword,
+			Tag:            saved.Tag,
+			ShowDuplicates: showDuplicates,
+			JSON:           asJSON,
+		}, stdout)
+
+	case "delete":
+		if err := cache.DeleteSavedSearch(args[1]); err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(stdout, map[string]interface{}{"name": args[1], "deleted": true})
+		}
+		fmt.Fprintf(stdout, "Deleted saved search %s\n", args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown action: %s (use list, save, run, or delete)", action)
+}
+
+// describeSavedSearch returns a saved search's query and filters on one line
+func describeSavedSearch(saved search.SavedSearch) string {
+	parts := []string{fmt.Sprintf("%q", saved.Query)}
+	for _, filter := range []struct{ name, value string }{
+		{"source", saved.Source}, {"project", saved.ProjectPath}, {"tag", saved.Tag}, {"keyword", saved.Keyword},
+	} {
+		if filter.value != "" {
+			parts = append(parts, filter.name+"="+filter.value)
+		}
+	}
+	if saved.Limit > 0 {
+		parts = append(parts, fmt.Sprintf("limit=%d", saved.Limit))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSearchesCommand(t *testing.T) {
+	cache := newTestCache(t)
+	dir := t.TempDir()
+	now := time.Now()
+	session := func(id, project string) adapters.Session {
+		path := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(path, []byte("dummy"), 0o644); err != nil {
+			t.Fatalf("failed to create session file: %v", err)
+		}
+		return adapters.Session{ID: id, Source: "stub", ProjectPath: project, FirstMessage: "CI is red", Timestamp: now, FilePath: path}
+	}
+	stub := newStubAdapter(
+		[]adapters.Session{session("api-1", "/work/api"), session("web-1", "/work/web")},
+		map[string][]adapters.Message{
+			"api-1": {{Role: "user", Content: "The flaky test times out on CI"}},
+			"web-1": {{Role: "user", Content: "Another flaky test on CI"}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"stub": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "save", "Flaky CI", "flaky", "test", "--project", "/work/api"); err != nil {
+		t.Fatalf("searches save failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `Saved flaky-ci: "flaky test" project=/work/api`) {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "save", "broken", "ci", "--source", "cursor"); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "list"); err != nil {
+		t.Fatalf("searches list failed: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(lines) != 1 || !strings.HasPrefix(lines[0], "flaky-ci") {
+		t.Fatalf("expected the saved search, got:\n%s", out.String())
+	}
+
+	// Running the search applies its project filter
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "--json", "searches", "run", "flaky-ci"); err != nil {
+		t.Fatalf("searches run failed: %v", err)
+	}
+	var result struct {
+		Query   string `json:"query"`
+		Matches []struct {
+			Session adapters.Session `json:"session"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Query != "flaky test" || len(result.Matches) != 1 || result.Matches[0].Session.ID != "api-1" {
+		t.Fatalf("expected only the api session, got %s (%v)", out.String(), err)
+	}
+
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "delete", "flaky-ci"); err != nil {
+		t.Fatalf("searches delete failed: %v", err)
+	}
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "run", "flaky-ci"); err == nil {
+		t.Fatal("expected an error running a deleted search")
+	}
+	if err := runTestCLI(adaptersMap, cache, &out, "searches", "run"); err == nil {
+		t.Fatal("expected a usage error without a name")
+	}
+}
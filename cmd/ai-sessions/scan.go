@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/redact"
+)
+
+// Tool 20: scan_session
+type scanSessionArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to scan"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+}
+
+func addScanSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "scan_session",
+		Description: "Audit a stored session for likely secrets and personal details: API keys, AWS credentials, JWTs, private keys, passwords, and email addresses. Returns where each was found (message index, role, and whether in the text, thinking, tool input, or tool output) with the value masked.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args scanSessionArgs) (*mcp.CallToolResult, any, error) {
+		findings, err := scanSession(adaptersMap, args.Source, args.SessionID, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonToolResult(scanResult(args.Source, args.SessionID, findings))
+	})
+}
+
+// scanSession scans a session's messages for secrets, masking the values found unless
+// reveal is set
+func scanSession(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string, reveal bool) ([]redact.MessageFinding, error) {
+	messages, err := loadSessionMessages(adaptersMap, source, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	findings := redact.ScanMessages(messages)
+	if findings == nil {
+		findings = []redact.MessageFinding{}
+	}
+	if !reveal {
+		for i := range findings {
+			findings[i].Value = redact.Mask(findings[i].Value)
+		}
+	}
+	return findings, nil
+}
+
+// scanResult is the JSON report of a scan, shared by the MCP tool and the CLI
+func scanResult(source, sessionID string, findings []redact.MessageFinding) map[string]interface{} {
+	byKind := make(map[string]int)
+	for _, f := range findings {
+		byKind[f.Kind]++
+	}
+	return map[string]interface{}{
+		"session_id": sessionID,
+		"source":     source,
+		"findings":   findings,
+		"count":      len(findings),
+		"by_kind":    byKind,
+	}
+}
+
+var scanCommand = cliCommand{
+	name:    "scan",
+	args:    "<source> <id>",
+	summary: "Report likely secrets and personal details stored in a session",
+	minArgs: 2,
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		reveal := fs.Bool("reveal", false, "show the values found instead of masking them")
+		return func(env *cliEnv, args []string) error {
+			return runScanCommand(env.sessionAdapters(), args[0], args[1], *reveal, env.options.JSON, env.stdout)
+		}
+	},
+}
+
+// runScanCommand prints the secrets found in a session, one per line
+func runScanCommand(adaptersMap map[string]adapters.SessionAdapter, source, sessionID string, reveal, asJSON bool, stdout io.Writer) error {
+	findings, err := scanSession(adaptersMap, source, sessionID, reveal)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(stdout, scanResult(source, sessionID, findings))
+	}
+
+	if len(findings) == 0 {
+		fmt.Fprintf(stdout, "No likely secrets found in %s session %s\n", source, sessionID)
+		return nil
+	}
+	fmt.Fprintf(stdout, "Found %d likely secrets in %s session %s:\n\n", len(findings), source, sessionID)
+	fmt.Fprintf(stdout, "%-9s %-10s %-12s %-18s %s\n", "MESSAGE", "ROLE", "FIELD", "KIND", "VALUE")
+	for _, f := range findings {
+		fmt.Fprintf(stdout, "%-9d %-10s %-12s %-18s %s\n", f.MessageIndex, f.Role, f.Field, f.Kind, f.Value)
+	}
+	if !reveal {
+		fmt.Fprintln(stdout, "\nValues are masked; run with --reveal to show them.")
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+var searchCommand = cliCommand{
+	name:    "search",
+	args:    "<query>",
+	summary: "Search session content, best matches first",
+	minArgs: 1,
+	maxArgs: -1,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts searchCommandOptions
+		fs.StringVar(&opts.Source, "source", "", "only search sessions of this `source`")
+		fs.StringVar(&opts.ProjectPath, "project", "", "only search sessions of a `project`: a path, a glob, or a directory name")
+		fs.IntVar(&opts.Limit, "limit", 10, "show at most `n` matches")
+		fs.StringVar(&opts.Keyword, "keyword", "", "only search sessions having this `keyword`")
+		fs.StringVar(&opts.Tag, "tag", "", "only search sessions carrying this `tag`")
+		fs.BoolVar(&opts.ShowDuplicates, "duplicates", false, "show every copy of a duplicated session")
+		return func(env *cliEnv, args []string) error {
+			cache, err := env.searchCache()
+			if err != nil {
+				return err
+			}
+			adaptersMap := env.sessionAdapters()
+			useMetadataCache(adaptersMap, cache)
+			opts.Query, opts.JSON = strings.Join(args, " "), env.options.JSON
+			return runSearchCommand(env.context(), adaptersMap, cache, opts, env.stdout)
+		}
+	},
+}
+
+// searchCommandOptions are the options of `aisessions search`
+type searchCommandOptions struct {
+	Query          string
+	Source         string
+	ProjectPath    string
+	Limit          int
+	Keyword        string
+	Tag            string
+	ShowDuplicates bool // Shows every copy of a session rather than collapsing them
+	JSON           bool
+}
+
+func runSearchCommand(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, cache *search.Cache, opts searchCommandOptions, stdout io.Writer) error {
+	query := strings.TrimSpace(opts.Query)
+	if query == "" {
+		return fmt.Errorf("usage: aisessions search <query> [options]")
+	}
+	if opts.Limit <= 0 {
+		return fmt.Errorf("invalid --limit value %d: use a positive number", opts.Limit)
+	}
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+
+	warnings := &warningCollector{}
+	failures, err := indexSessionsWithWarnings(ctx, adaptersMap, cache, opts.Source, opts.ProjectPath, warnings)
+	if err != nil {
+		return fmt.Errorf("failed to index sessions: %w", err)
+	}
+
+	// Matched terms are shown in bold on a terminal
+	pre, post := "", ""
+	if !opts.JSON && isTerminal(stdout) {
+		pre, post = "\033[1m", "\033[0m"
+	}
+	results, err := cache.SearchWithOptions(query, search.SearchOptions{
+		Source:             opts.Source,
+		ProjectPath:        opts.ProjectPath,
+		Limit:              opts.Limit,
+		Keyword:            opts.Keyword,
+		Tag:                opts.Tag,
+		CollapseDuplicates: !opts.ShowDuplicates,
+		Snippets:           search.SnippetOptions{HighlightPre: pre, HighlightPost: post},
+		LoadContent:        contentLoader(adaptersMap),
+	})
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+	suggestions, corrected := suggestCorrections(cache, query)
+
+	if opts.JSON {
+		matches := make([]map[string]interface{}, len(results))
+		for i, result := range results {
+			matches[i] = map[string]interface{}{
+				"session":  result.Session,
+				"score":    result.Score,
+				"snippet":  result.Snippet,
+				"snippets": result.Snippets,
+			}
+		}
+		output := map[string]interface{}{"query": query, "matches": matches, "count": len(matches)}
+		if len(suggestions) > 0 {
+			output["suggestions"] = suggestions
+			output["did_you_mean"] = corrected
+		}
+		if len(failures) > 0 {
+			output["failed_sources"] = failures
+		}
+		warnings.addTo(output)
+		return printJSON(stdout, output)
+	}
+
+	shown, omitted := warnings.list()
+	for _, warning := range shown {
+		fmt.Fprintf(stdout, "Warning: %s\n", warning)
+	}
+	if omitted > 0 {
+		fmt.Fprintf(stdout, "Warning: %d more sessions could not be searched\n", omitted)
+	}
+	if len(results) == 0 {
+		fmt.Fprintf(stdout, "No sessions match %q\n", query)
+	}
+	if len(suggestions) > 0 {
+		fmt.Fprintf(stdout, "Did you mean %q?\n", corrected)
+		if len(results) > 0 {
+			fmt.Fprintln(stdout)
+		}
+	}
+	width := getTerminalWidth()
+	for i, result := range results {
+		s := result.Session
+		fmt.Fprintf(stdout, "%2d. %s  %s  %s  %s  (score %.2f)\n", i+1, formatTime(s.Timestamp),
+			getAgentDisplayName(s.Source), getProjectName(s.ProjectPath), s.ID, result.Score)
+		if message := cleanFirstMessage(s.FirstMessage, width-6); message != "" {
+			fmt.Fprintf(stdout, "    %s\n", message)
+		}
+		if snippet := strings.Join(strings.Fields(result.Snippet), " "); snippet != "" {
+			fmt.Fprintf(stdout, "    ...%s...\n", snippet)
+		}
+		if len(s.Keywords) > 0 {
+			fmt.Fprintf(stdout, "    keywords: %s\n", strings.Join(s.Keywords, ", "))
+		}
+		if len(s.Duplicates) > 0 {
+			ids := make([]string, len(s.Duplicates))
+			for i, duplicate := range s.Duplicates {
+				ids[i] = duplicate.ID
+			}
+			fmt.Fprintf(stdout, "    copies: %s\n", strings.Join(ids, ", "))
+		}
+		fmt.Fprintln(stdout)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunSearchCommand(t *testing.T) {
+	cache := newTestCache(t)
+	sessionFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(sessionFile, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to create session file: %v", err)
+	}
+	stub := newStubAdapter(
+		[]adapters.Session{{ID: "sess-1", Source: "stub", ProjectPath: "/work/app", FirstMessage: "Debug the login flow", Timestamp: time.Now(), FilePath: sessionFile}},
+		map[string][]adapters.Message{
+			"sess-1": {{Role: "user", Content: "The oauth callback returns a redirect loop"}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"stub": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "redirect", "loop"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, " 1. ") || !strings.Contains(text, "sess-1") || !strings.Contains(text, "Debug the login flow") || !strings.Contains(text, "redirect loop") {
+		t.Fatalf("unexpected results:\n%s", text)
+	}
+	if strings.Contains(text, "\033[") {
+		t.Fatalf("expected no colors when not writing to a terminal:\n%q", text)
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "redirect", "--json"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	var result struct {
+		Count   int `json:"count"`
+		Matches []struct {
+			Session adapters.Session `json:"session"`
+		} `json:"matches"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &result); err != nil || result.Count != 1 || result.Matches[0].Session.ID != "sess-1" {
+		t.Fatalf("unexpected JSON results %s (%v)", out.String(), err)
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "kubernetes"); err != nil || !strings.Contains(out.String(), "No sessions match") {
+		t.Fatalf("expected no matches, got %q (%v)", out.String(), err)
+	}
+
+	// Field filters in the query narrow the search down
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "redirect", "role:user"); err != nil || !strings.Contains(out.String(), "sess-1") {
+		t.Fatalf("expected a match in the user's message, got %q (%v)", out.String(), err)
+	}
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "redirect", "role:assistant"); err != nil || !strings.Contains(out.String(), "No sessions match") {
+		t.Fatalf("expected no match in assistant messages, got %q (%v)", out.String(), err)
+	}
+
+	// A misspelled term suggests the closest indexed one
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "redirct", "loop"); err != nil || !strings.Contains(out.String(), `Did you mean "redirect loop"?`) {
+		t.Fatalf("expected a suggestion, got %q (%v)", out.String(), err)
+	}
+	out.Reset()
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "oauht", "--json"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	var suggested struct {
+		Count      int    `json:"count"`
+		DidYouMean string `json:"did_you_mean"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &suggested); err != nil || suggested.Count != 0 || suggested.DidYouMean != "oauth" {
+		t.Fatalf("unexpected JSON suggestion %s (%v)", out.String(), err)
+	}
+	if err := runTestCLI(adaptersMap, cache, &out, "search"); err == nil {
+		t.Fatal("expected an error without a query")
+	}
+}
+
+func TestRunSearchCommandKeyword(t *testing.T) {
+	cache := newTestCache(t)
+	sessionFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(sessionFile, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to create session file: %v", err)
+	}
+	now := time.Now()
+	stub := newStubAdapter(
+		[]adapters.Session{
+			{ID: "sess-1", Source: "stub", ProjectPath: "/work/app", Timestamp: now, FilePath: sessionFile},
+			{ID: "sess-2", Source: "stub", ProjectPath: "/work/app", Timestamp: now.Add(-time.Hour), FilePath: sessionFile},
+		},
+		map[string][]adapters.Message{
+			"sess-1": {{Role: "user", Content: "The webpack build fails. Fix the webpack config and the build"}},
+			"sess-2": {{Role: "user", Content: "The terraform plan fails. Fix the terraform module and the build"}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"stub": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "build", "--keyword", "Webpack"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	text := out.String()
+	if !strings.Contains(text, "sess-1") || strings.Contains(text, "sess-2") {
+		t.Fatalf("expected only the webpack session:\n%s", text)
+	}
+	if _, keywords, _ := strings.Cut(text, "keywords: "); !strings.Contains(keywords, "webpack") {
+		t.Fatalf("expected the keywords of the session:\n%s", text)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// serverSourceInfo describes a source in the server_info report
+type serverSourceInfo struct {
+	sourceStatus
+	Sessions        *int       `json:"sessions,omitempty"`     // Sessions the source lists, when enabled
+	IndexedSessions int        `json:"indexed_sessions"`       // Sessions of the source in the search cache
+	LastIndexed     *time.Time `json:"last_indexed,omitempty"` // When a session of the source was last indexed
+	ListError       string     `json:"list_error,omitempty"`   // Why the sessions couldn't be counted
+}
+
+// serverCacheInfo describes the search cache in the server_info report
+type serverCacheInfo struct {
+	Sessions      int    `json:"sessions"`
+	SizeBytes     int64  `json:"size_bytes"`
+	Size          string `json:"size"`
+	SchemaVersion int    `json:"schema_version"`
+	Encrypted     bool   `json:"encrypted"`
+	MetadataOnly  bool   `json:"metadata_only"`
+	InMemory      bool   `json:"in_memory"`
+}
+
+// serverConfigInfo is the configuration the server runs with, in the server_info report.
+// Project rules are reported as a flag, so the report doesn't reveal the projects hidden.
+type serverConfigInfo struct {
+	ReadOnly        bool       `json:"read_only"`
+	StripToolOutput bool       `json:"strip_tool_output"`
+	ProjectRules    bool       `json:"project_rules"` // allow_projects or deny_projects is set
+	MCPUploads      bool       `json:"mcp_uploads"`
+	LogFile         bool       `json:"log_file"`
+	Machines        []string   `json:"machines"` // Names of the other machines whose sessions are read
+	Limits          toolLimits `json:"limits"`   // Limits turned off are left out
+}
+
+// Tool 22: server_info
+type serverInfoArgs struct{}
+
+func addServerInfoTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, statuses []sourceStatus, searchCache *search.Cache, config Config) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "server_info",
+		Description: "Report the server's state: its version, every source with its status, session count, and when its sessions were last indexed, the search cache's size, and the configuration in effect",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args serverInfoArgs) (*mcp.CallToolResult, any, error) {
+		return jsonToolResult(buildServerInfo(adaptersMap, statuses, searchCache, config))
+	})
+}
+
+// buildServerInfo gathers the server_info report
+func buildServerInfo(adaptersMap map[string]adapters.SessionAdapter, statuses []sourceStatus, searchCache *search.Cache, config Config) map[string]interface{} {
+	indexed := make(map[string]search.SourceIndex)
+	cacheInfo := serverCacheInfo{
+		Encrypted:    config.EncryptCache && !searchCache.InMemory(),
+		MetadataOnly: config.MetadataOnlyIndex,
+		InMemory:     searchCache.InMemory(),
+	}
+	if sources, err := searchCache.IndexedSources(); err == nil {
+		for _, index := range sources {
+			indexed[index.Source] = index
+			cacheInfo.Sessions += index.Sessions
+		}
+	} else {
+		slog.Warn("Failed to read the search cache's sources", "error", err)
+	}
+	if size, err := searchCache.Size(); err == nil {
+		cacheInfo.SizeBytes, cacheInfo.Size = size, formatFileSize(size)
+	} else {
+		slog.Warn("Failed to read the search cache's size", "error", err)
+	}
+	if version, err := searchCache.SchemaVersion(); err == nil {
+		cacheInfo.SchemaVersion = version
+	} else {
+		slog.Warn("Failed to read the search cache's schema version", "error", err)
+	}
+
+	sources := make([]serverSourceInfo, 0, len(statuses))
+	for _, status := range statuses {
+		info := serverSourceInfo{sourceStatus: status}
+		if index, ok := indexed[status.Source]; ok {
+			info.IndexedSessions = index.Sessions
+			info.LastIndexed = &index.LastIndexed
+		}
+		if adapter, ok := adaptersMap[status.Source]; ok {
+			if sessions, err := adapter.ListSessions("", 0); err != nil {
+				info.ListError = err.Error()
+			} else {
+				count := len(sessions)
+				info.Sessions = &count
+			}
+		}
+		sources = append(sources, info)
+	}
+
+	configInfo := serverConfigInfo{
+		ReadOnly:        config.ReadOnly,
+		StripToolOutput: config.StripToolOutput,
+		ProjectRules:    len(config.AllowProjects) > 0 || len(config.DenyProjects) > 0,
+		MCPUploads:      config.MCPUploads && !config.ReadOnly,
+		LogFile:         config.LogFile,
+		Machines:        []string{},
+		Limits:          serverLimits(config),
+	}
+	for _, machine := range config.Machines {
+		configInfo.Machines = append(configInfo.Machines, machine.Name)
+	}
+
+	return map[string]interface{}{
+		"name":    "ai-sessions",
+		"version": cliVersion,
+		"sources": sources,
+		"cache":   cacheInfo,
+		"config":  configInfo,
+	}
+}
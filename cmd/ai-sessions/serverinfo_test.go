@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestBuildServerInfo(t *testing.T) {
+	cache := newTestCache(t)
+	sessionFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(sessionFile, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to create session file: %v", err)
+	}
+	sessions := []adapters.Session{
+		{ID: "s1", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: sessionFile},
+		{ID: "s2", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: sessionFile},
+	}
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": newStubAdapter(sessions, map[string][]adapters.Message{
+		"s1": {{Role: "user", Content: "fix the login bug"}},
+		"s2": {{Role: "user", Content: "add a logout button"}},
+	})}
+	if _, err := indexSessions(context.Background(), adaptersMap, cache, "", ""); err != nil {
+		t.Fatalf("indexSessions failed: %v", err)
+	}
+
+	statuses := []sourceStatus{
+		{Source: "claude", Status: sourceEnabled},
+		{Source: "gemini", Status: sourceDisabled},
+	}
+	config := Config{ReadOnly: true, MCPUploads: true, DenyProjects: []string{"/work/secret"}, Machines: []machineConfig{{Name: "laptop"}}}
+	info := buildServerInfo(adaptersMap, statuses, cache, config)
+
+	sourceInfos := info["sources"].([]serverSourceInfo)
+	claude := sourceInfos[0]
+	if claude.Sessions == nil || *claude.Sessions != 2 || claude.IndexedSessions != 2 || claude.LastIndexed == nil {
+		t.Errorf("expected two listed and indexed claude sessions, got %+v", claude)
+	}
+	if gemini := sourceInfos[1]; gemini.Status != sourceDisabled || gemini.Sessions != nil {
+		t.Errorf("expected gemini to be disabled without a count, got %+v", gemini)
+	}
+
+	cacheInfo := info["cache"].(serverCacheInfo)
+	if cacheInfo.Sessions != 2 || cacheInfo.SizeBytes <= 0 {
+		t.Errorf("unexpected cache info %+v", cacheInfo)
+	}
+	configInfo := info["config"].(serverConfigInfo)
+	if !configInfo.ReadOnly || configInfo.MCPUploads || !configInfo.ProjectRules || len(configInfo.Machines) != 1 {
+		t.Errorf("unexpected config info %+v", configInfo)
+	}
+	if info["version"] != cliVersion {
+		t.Errorf("expected version %s, got %v", cliVersion, info["version"])
+	}
+}
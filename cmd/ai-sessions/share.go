@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/export"
+)
+
+// Tool 21: upload_session
+type uploadSessionArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to upload"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Title     string `json:"title,omitempty" jsonschema:"Title of the uploaded transcript (default: the session's first message)"`
+	Anonymize bool   `json:"anonymize,omitempty" jsonschema:"Replace user names, host names, home directories, and email addresses with placeholders"`
+	Profile   string `json:"profile,omitempty" jsonschema:"Login profile to upload with (default: the default login)"`
+}
+
+// addUploadSessionTool adds the upload_session tool. It is only added when the config
+// file opts in with mcp_uploads.
+func addUploadSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "upload_session",
+		Description: "Share a session by uploading it to aisessions.dev (or the user's self-hosted server) with the user's CLI login, and return the link to the uploaded transcript. Likely secrets and personal details are redacted before upload. Only upload sessions the user asked to share.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args uploadSessionArgs) (*mcp.CallToolResult, any, error) {
+		result, err := shareSession(adaptersMap, args)
+		if err != nil {
+			return nil, nil, err
+		}
+		return jsonToolResult(result)
+	})
+}
+
+// shareSession uploads a session without prompts, redacting every secret found, if the
+// config file allows MCP uploads
+func shareSession(adaptersMap map[string]adapters.SessionAdapter, args uploadSessionArgs) (map[string]interface{}, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	if !config.MCPUploads {
+		return nil, fmt.Errorf("uploading from MCP clients is disabled: set \"mcp_uploads\": true in ~/.aisessions/config.json to allow it")
+	}
+	profile, err := normalizeProfile(args.Profile)
+	if err != nil {
+		return nil, err
+	}
+	login, err := loadConfig(profile)
+	if err != nil {
+		return nil, fmt.Errorf("not logged in: %w", err)
+	}
+	apiURL, err := resolveAPIURL("", login)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := sessionAdapter(adaptersMap, args.Source, args.SessionID); err != nil {
+		return nil, err
+	}
+	session := findListedSession(adaptersMap, args.Source, args.SessionID)
+	messages, err := readSession(adaptersMap, session)
+	if err != nil {
+		return nil, err
+	}
+	opts := defaultUploadOptions
+	opts.Title, opts.Anonymize, opts.Profile, opts.Unattended = args.Title, args.Anonymize, profile, true
+	if opts.Title == "" {
+		opts.Title = export.Title(session, messages)
+	}
+
+	resp, err := uploadSession(session, messages, apiURL, login.Token, opts, io.Discard)
+	if _, ok := err.(*AuthError); ok {
+		return nil, fmt.Errorf("%w (run 'aisessions login%s' to re-authenticate)", err, profileFlag(profile))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"session_id": args.SessionID,
+		"source":     args.Source,
+		"id":         resp.ID,
+		"url":        resp.URL,
+	}, nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/export"
+)
+
+const (
+	// defaultShowPageSize is the page size of `aisessions show --page`
+	defaultShowPageSize = 50
+
+	// maxShownOutputLines caps the lines of tool output shown per tool call
+	maxShownOutputLines = 5
+)
+
+// ANSI styles of the message headings printed by `aisessions show`
+var roleStyles = map[string]string{
+	"user":      "\033[1;36m",
+	"assistant": "\033[1;32m",
+	"tool":      "\033[33m",
+	"system":    "\033[35m",
+}
+
+var showCommand = cliCommand{
+	name:    "show",
+	args:    "<source> <id>",
+	summary: "Read a session in the terminal",
+	minArgs: 2,
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		opts := showOptions{Page: -1}
+		roles := fs.String("role", "", "only show messages of these comma-separated `roles` (user, assistant, tool, system)")
+		fs.StringVar(roles, "roles", "", "")
+		fs.IntVar(&opts.Page, "page", -1, "show only page `n` (0-indexed)")
+		fs.IntVar(&opts.PageSize, "page-size", defaultShowPageSize, "`n` messages per page")
+		fs.StringVar(&opts.At, "at", "", "show the page of messages around a `time`, like '2025-01-31 15:00' or '20h' (ago)")
+		fs.BoolVar(&opts.Raw, "raw", false, "print the messages as JSON lines, as the adapter returns them")
+		return func(env *cliEnv, args []string) error {
+			opts.Source, opts.SessionID = args[0], args[1]
+			if *roles != "" {
+				opts.Roles = strings.Split(*roles, ",")
+			}
+			opts.JSON = env.options.JSON
+			return runShowCommand(env.sessionAdapters(), opts, env.stdout)
+		}
+	},
+}
+
+// showOptions are the options of `aisessions show`
+type showOptions struct {
+	Source    string
+	SessionID string
+	Roles     []string // Empty shows every role
+	Page      int      // Negative shows every message
+	At        string   // Shows the page around this time (see parseAround) instead of Page
+	PageSize  int
+	Raw       bool
+	JSON      bool
+}
+
+func runShowCommand(adaptersMap map[string]adapters.SessionAdapter, opts showOptions, stdout io.Writer) error {
+	messages, err := loadSessionMessages(adaptersMap, opts.Source, opts.SessionID)
+	if err != nil {
+		return err
+	}
+	messages = filterMessages(messages, messageFilter{Roles: opts.Roles})
+
+	output := map[string]interface{}{
+		"session_id":     opts.SessionID,
+		"source":         opts.Source,
+		"total_messages": len(messages),
+	}
+	footer := ""
+	if opts.At != "" {
+		at, err := parseAround(opts.At, time.Now())
+		if err != nil {
+			return err
+		}
+		if opts.Page, _, err = pageAround(messages, at, opts.PageSize, "asc"); err != nil {
+			return err
+		}
+	}
+	if opts.Page >= 0 {
+		result, err := paginateMessages(messages, opts.Page, opts.PageSize, "asc")
+		if err != nil {
+			return err
+		}
+		messages = result.Messages
+		footer = fmt.Sprintf("Page %d of %d (0-indexed), %d messages in total", opts.Page, result.TotalPages, result.TotalMessages)
+		output["page"], output["page_size"], output["total_pages"] = opts.Page, opts.PageSize, result.TotalPages
+	}
+
+	if opts.JSON {
+		if messages == nil {
+			messages = []adapters.Message{}
+		}
+		output["messages"], output["count"] = messages, len(messages)
+		return printJSON(stdout, output)
+	}
+
+	if opts.Raw {
+		// One JSON message per line, as the adapter returns it
+		encoder := json.NewEncoder(stdout)
+		encoder.SetEscapeHTML(false)
+		for _, msg := range messages {
+			if err := encoder.Encode(msg); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	color := isTerminal(stdout)
+	width := getTerminalWidth()
+	for _, msg := range messages {
+		printShownMessage(stdout, msg, width, color)
+	}
+	if footer != "" {
+		fmt.Fprintln(stdout, styled(footer, "\033[2m", color))
+	}
+	return nil
+}
+
+// printShownMessage prints a message under a heading with its role and time, with
+// its text wrapped to width. Tool calls and output are summarized on dimmed lines.
+func printShownMessage(w io.Writer, msg adapters.Message, width int, color bool) {
+	role := msg.Role
+	if analysis.IsToolOutput(msg) {
+		role = "tool"
+	}
+	heading := role
+	if role != "" {
+		heading = strings.ToUpper(role[:1]) + role[1:]
+	}
+	if name, _ := msg.Metadata["agent_name"].(string); name != "" {
+		heading += " (" + name + ")"
+	}
+	if !msg.Timestamp.IsZero() {
+		heading += " · " + msg.Timestamp.In(cliTimes.location()).Format("2006-01-02 15:04:05 MST")
+	}
+	fmt.Fprintln(w, styled("── "+heading, roleStyles[role], color))
+
+	const indent = "  "
+	if thinking := strings.TrimSpace(msg.Thinking); thinking != "" {
+		for _, line := range wrapText(thinking, width-len(indent)) {
+			fmt.Fprintln(w, styled(indent+line, "\033[2;3m", color))
+		}
+	}
+	if content := strings.TrimSpace(msg.Content); content != "" && !(role == "tool" && len(analysis.ToolResults(msg)) > 0) {
+		for _, line := range wrapText(content, width-len(indent)) {
+			fmt.Fprintln(w, indent+line)
+		}
+	}
+	for _, call := range analysis.ToolCalls(msg) {
+		fmt.Fprintln(w, styled(indent+"▸ "+truncateString(export.CallSummary(call), width-len(indent)-2), "\033[2m", color))
+	}
+	for _, result := range analysis.ToolResults(msg) {
+		lines := strings.Split(strings.TrimSpace(result.Output), "\n")
+		shown := lines
+		if len(lines) > maxShownOutputLines {
+			shown = lines[:maxShownOutputLines]
+		}
+		marker := "◂ "
+		if result.IsError {
+			marker = "✗ "
+		}
+		for _, line := range shown {
+			fmt.Fprintln(w, styled(indent+marker+truncateString(line, width-len(indent)-2), "\033[2m", color))
+			marker = "  "
+		}
+		if hidden := len(lines) - len(shown); hidden > 0 {
+			fmt.Fprintln(w, styled(fmt.Sprintf("%s  … %d more lines", indent, hidden), "\033[2m", color))
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// styled wraps text in an ANSI style when color is enabled
+func styled(text, style string, color bool) string {
+	if !color || style == "" {
+		return text
+	}
+	return style + text + "\033[0m"
+}
+
+// wrapText wraps each line of text at word boundaries to at most width characters.
+// Indented lines, which are usually code, are left as they are.
+func wrapText(text string, width int) []string {
+	if width < 20 {
+		width = 20
+	}
+	var wrapped []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") || len([]rune(line)) <= width {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		current := ""
+		for _, word := range strings.Fields(line) {
+			if current != "" && len([]rune(current))+1+len([]rune(word)) > width {
+				wrapped = append(wrapped, current)
+				current = ""
+			}
+			if current != "" {
+				current += " "
+			}
+			current += word
+		}
+		wrapped = append(wrapped, current)
+	}
+	return wrapped
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestRunShowCommand(t *testing.T) {
+	longOutput := strings.Repeat("line\n", 20)
+	stub := newStubAdapter(nil, map[string][]adapters.Message{
+		"s1": {
+			{Role: "user", Content: "Please run the tests"},
+			{Role: "assistant", Content: "Running.", ToolCalls: []adapters.ToolCall{{ID: "t1", Name: "Bash", Input: map[string]interface{}{"command": "go test ./..."}}}},
+			{Role: "user", Metadata: map[string]interface{}{"is_tool_result": true}, ToolResults: []adapters.ToolResult{{ToolCallID: "t1", Output: longOutput}}},
+			{Role: "assistant", Content: strings.Repeat("word ", 40)},
+		},
+	})
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "show", "claude", "s1"); err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{"── User\n  Please run the tests", "▸ Bash: go test ./...", "── Tool\n", "… 15 more lines"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in:\n%s", want, text)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) > 80 {
+			t.Errorf("expected lines wrapped to the terminal width, got %d characters: %q", len(line), line)
+		}
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "show", "claude", "s1", "--role", "user", "--page", "0", "--page-size", "1"); err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	if text := out.String(); !strings.Contains(text, "Please run the tests") || strings.Contains(text, "Running.") || !strings.Contains(text, "Page 0 of 1") {
+		t.Fatalf("expected only the first user message, got:\n%s", text)
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "show", "claude", "s1", "--raw", "--role", "assistant"); err != nil {
+		t.Fatalf("show failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	var first adapters.Message
+	if len(lines) != 2 || json.Unmarshal([]byte(lines[0]), &first) != nil || first.Content != "Running." {
+		t.Fatalf("expected raw assistant messages as JSON lines, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "show", "claude", "s1", "--json", "--page", "0", "--page-size", "1"); err != nil {
+		t.Fatalf("show --json failed: %v", err)
+	}
+	var shown struct {
+		Messages      []adapters.Message `json:"messages"`
+		TotalMessages int                `json:"total_messages"`
+		TotalPages    int                `json:"total_pages"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &shown); err != nil || len(shown.Messages) != 1 || shown.TotalPages != shown.TotalMessages {
+		t.Fatalf("expected one message per page as JSON, got:\n%s", out.String())
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "show", "claude"); err == nil {
+		t.Fatal("expected an error without a session ID")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const (
+	// shutdownGrace is how long an interrupted command or server may take to stop
+	// cleanly before it exits anyway
+	shutdownGrace = 5 * time.Second
+
+	// interruptedExitCode is the exit status of a process stopped by Ctrl+C, as shells
+	// report it
+	interruptedExitCode = 130
+)
+
+// interruptContext returns a context done on SIGINT or SIGTERM, and a function to stop
+// listening for them. Once the context is done, a second signal exits at once, and so
+// does taking longer than shutdownGrace to stop.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case sig := <-signals:
+			slog.Info("Shutting down", "signal", sig.String())
+			signal.Stop(signals)
+			cancel()
+			time.Sleep(shutdownGrace)
+			slog.Warn("Did not stop in time, exiting")
+			os.Exit(interruptedExitCode)
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// requestTracker cancels the MCP requests in flight when the server shuts down, so
+// indexing stops between sessions, and waits for them to return before the search cache
+// is closed
+type requestTracker struct {
+	shutdown context.Context
+	wg       sync.WaitGroup
+}
+
+// middleware derives the context of every request from the shutdown context
+func (t *requestTracker) middleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		t.wg.Add(1)
+		defer t.wg.Done()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(t.shutdown, cancel)
+		defer stop()
+		return next(ctx, method, req)
+	}
+}
+
+// wait waits up to timeout for the requests in flight to return, and reports whether
+// they did
+func (t *requestTracker) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
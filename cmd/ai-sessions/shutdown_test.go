@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestIndexSessionsCancelled(t *testing.T) {
+	cache := newTestCache(t)
+	sessionFile := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(sessionFile, []byte("dummy"), 0o644); err != nil {
+		t.Fatalf("failed to create session file: %v", err)
+	}
+	sessions := []adapters.Session{{ID: "s1", Source: "claude", Timestamp: time.Now(), FilePath: sessionFile}}
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": newStubAdapter(sessions, map[string][]adapters.Message{
+		"s1": {{Role: "user", Content: "fix the login bug"}},
+	})}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := indexSessions(ctx, adaptersMap, cache, "", ""); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if health, err := cache.Health(); err != nil || health.Sessions != 0 {
+		t.Fatalf("expected nothing indexed, got %+v (%v)", health, err)
+	}
+}
+
+func TestRequestTrackerCancelsOnShutdown(t *testing.T) {
+	shutdown, stop := context.WithCancel(context.Background())
+	tracker := &requestTracker{shutdown: shutdown}
+
+	started := make(chan struct{})
+	handler := tracker.middleware(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	result := make(chan error, 1)
+	go func() {
+		_, err := handler(context.Background(), "tools/call", nil)
+		result <- err
+	}()
+
+	<-started
+	if tracker.wait(10 * time.Millisecond) {
+		t.Fatal("expected the request to still be running")
+	}
+	stop()
+	if !tracker.wait(time.Second) {
+		t.Fatal("expected the request to return once the server shuts down")
+	}
+	if err := <-result; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the request to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// Tool 10: find_similar_sessions
+type findSimilarSessionsArgs struct {
+	SessionID   string `json:"session_id" jsonschema:"The session to find similar sessions for"`
+	Source      string `json:"source,omitempty" jsonschema:"Optional: only return similar sessions from this source (claude, gemini, codex, opencode)"`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Optional: only return similar sessions from this project: a path, a glob, or a directory name"`
+	Limit       int    `json:"limit,omitempty" jsonschema:"Maximum number of similar sessions to return (default: 10)"`
+}
+
+func addFindSimilarSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_similar_sessions",
+		Description: "Find sessions whose content is most similar to a given session (TF-IDF cosine similarity), with the distinctive terms they share",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args findSimilarSessionsArgs) (*mcp.CallToolResult, any, error) {
+		if args.SessionID == "" {
+			return nil, nil, fmt.Errorf("session_id is required")
+		}
+		if args.Limit == 0 {
+			args.Limit = 10
+		}
+
+		// Similarity is computed over the whole index, not just the filtered sources
+		if _, err := indexSessions(ctx, adaptersMap, searchCache, "", ""); err != nil {
+			slog.Warn("Failed to index sessions", "error", err)
+		}
+
+		results, err := searchCache.FindSimilarSessions(args.SessionID, search.SimilarOptions{
+			Source:      args.Source,
+			ProjectPath: args.ProjectPath,
+			Limit:       args.Limit,
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("similarity search failed: %w", err)
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"similar":    results,
+			"count":      len(results),
+		})
+	})
+}
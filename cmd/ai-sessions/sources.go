@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// supportedSources lists every source the server knows how to read, in display order
+var supportedSources = []string{"claude", "gemini", "codex", "opencode"}
+
+// adapterConstructors creates the adapter of each supported source
+var adapterConstructors = map[string]func() (adapters.SessionAdapter, error){
+	"claude":   func() (adapters.SessionAdapter, error) { return adapters.NewClaudeAdapter() },
+	"gemini":   func() (adapters.SessionAdapter, error) { return adapters.NewGeminiAdapter() },
+	"codex":    func() (adapters.SessionAdapter, error) { return adapters.NewCodexAdapter() },
+	"opencode": func() (adapters.SessionAdapter, error) { return adapters.NewOpencodeAdapter() },
+}
+
+// Source statuses reported by list_available_sources
+const (
+	sourceEnabled     = "enabled"
+	sourceDisabled    = "disabled"    // Turned off by the config file or --sources
+	sourceUnavailable = "unavailable" // Enabled, but its adapter could not be initialized
+)
+
+// sourceStatus describes whether a supported source is in use, and why not
+type sourceStatus struct {
+	Source string `json:"source"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// sourceSelection says which sources to load. An empty Enabled list enables every
+// source; Disabled sources are never loaded.
+type sourceSelection struct {
+	Enabled  []string
+	Disabled []string
+}
+
+// allows reports whether the selection enables source
+func (s sourceSelection) allows(source string) bool {
+	for _, disabled := range s.Disabled {
+		if disabled == source {
+			return false
+		}
+	}
+	if len(s.Enabled) == 0 {
+		return true
+	}
+	for _, enabled := range s.Enabled {
+		if enabled == source {
+			return true
+		}
+	}
+	return false
+}
+
+// validate rejects sources that aren't supported, so typos don't silently disable everything
+func (s sourceSelection) validate() error {
+	for _, name := range append(append([]string{}, s.Enabled...), s.Disabled...) {
+		if _, ok := adapterConstructors[name]; !ok {
+			return fmt.Errorf("unknown source: %s (supported: %s)", name, strings.Join(supportedSources, ", "))
+		}
+	}
+	return nil
+}
+
+// parseSourceList parses a --sources value: a comma-separated list of sources to enable.
+// Names prefixed with "-" are disabled instead, so "claude,codex" enables only Claude
+// and Codex while "-gemini" enables everything but Gemini.
+func parseSourceList(value string) (sourceSelection, error) {
+	var selection sourceSelection
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch {
+		case name == "":
+		case strings.HasPrefix(name, "-"):
+			selection.Disabled = append(selection.Disabled, strings.TrimPrefix(name, "-"))
+		default:
+			selection.Enabled = append(selection.Enabled, name)
+		}
+	}
+	return selection, selection.validate()
+}
+
+// configuredSources returns the source selection of the config file, which enables
+// every source when the file doesn't exist or doesn't set one
+func configuredSources() (sourceSelection, error) {
+	config, err := readConfigFile()
+	if err != nil {
+		return sourceSelection{}, err
+	}
+	selection := sourceSelection{Enabled: config.Sources, Disabled: config.DisabledSources}
+	if err := selection.validate(); err != nil {
+		return sourceSelection{}, fmt.Errorf("invalid config file: %w", err)
+	}
+	return selection, nil
+}
+
+// serverOptions are the command line options of the MCP server
+type serverOptions struct {
+	Selection sourceSelection
+	// SelectionErr is why an invalid source selection was ignored for every source
+	SelectionErr error
+	Verbose      bool   // Log everything, down to debug records
+	Quiet        bool   // Log only errors
+	LogFile      bool   // Also log to ~/.cache/ai-sessions/aisessions.log
+	Pprof        string // Serve net/http/pprof profiles on this loopback address
+}
+
+// parseServerOptions parses the MCP server's options. The sources it loads are those of
+// the --sources flag, or of the config file without it. An invalid selection loads every
+// source, like the CLI does, and is reported in SelectionErr.
+func parseServerOptions(args []string, stderr io.Writer) (serverOptions, error) {
+	fs := flag.NewFlagSet("aisessions", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	sources := fs.String("sources", "", `sources to enable, comma-separated; prefix a source with "-" to disable it (e.g. "claude,codex" or "-gemini")`)
+	var opts serverOptions
+	fs.BoolVar(&opts.Verbose, "verbose", false, "log debug diagnostics to stderr")
+	fs.BoolVar(&opts.Quiet, "quiet", false, "log only errors to stderr")
+	fs.BoolVar(&opts.LogFile, "log-file", false, "also log to ~/.cache/ai-sessions/"+logFileName)
+	fs.StringVar(&opts.Pprof, "pprof", "", `serve pprof profiles on a localhost address (e.g. "localhost:6060")`)
+	if err := fs.Parse(args); err != nil {
+		return serverOptions{}, err
+	}
+	if fs.NArg() > 0 {
+		return serverOptions{}, fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if opts.Verbose && opts.Quiet {
+		return serverOptions{}, fmt.Errorf("--verbose and --quiet can't be used together")
+	}
+
+	sourcesSet := false
+	fs.Visit(func(f *flag.Flag) {
+		sourcesSet = sourcesSet || f.Name == "sources"
+	})
+	if sourcesSet {
+		opts.Selection, opts.SelectionErr = parseSourceList(*sources)
+	} else {
+		opts.Selection, opts.SelectionErr = configuredSources()
+	}
+	if opts.SelectionErr != nil {
+		opts.Selection = sourceSelection{}
+	}
+	return opts, nil
+}
+
+// isServerFlag reports whether a command line argument is an MCP server option rather
+// than a CLI command
+func isServerFlag(arg string) bool {
+	name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+	if !strings.HasPrefix(arg, "-") {
+		return false
+	}
+	switch name {
+	case "sources", "verbose", "quiet", "log-file", "pprof":
+		return true
+	}
+	return false
+}
+
+// openAdapters creates an adapter for every source the selection enables, merged with
+// the sessions of other machines, and reports the status of every supported source
+func openAdapters(selection sourceSelection, machines []machineConfig) (map[string]adapters.SessionAdapter, []sourceStatus) {
+	adaptersMap := make(map[string]adapters.SessionAdapter)
+	statuses := make([]sourceStatus, 0, len(supportedSources))
+	for _, name := range supportedSources {
+		if !selection.allows(name) {
+			statuses = append(statuses, sourceStatus{Source: name, Status: sourceDisabled})
+			continue
+		}
+		adapter, err := adapterConstructors[name]()
+		if err != nil {
+			statuses = append(statuses, sourceStatus{Source: name, Status: sourceUnavailable, Error: err.Error()})
+			continue
+		}
+		adaptersMap[name] = withIgnoreRules(withMachines(name, adapter, machines))
+		statuses = append(statuses, sourceStatus{Source: name, Status: sourceEnabled})
+	}
+	return adaptersMap, statuses
+}
+
+// machineConfig describes another machine whose agent data is available locally, such
+// as an rsync'd backup of its home directory or a mounted drive, or over SSH
+type machineConfig struct {
+	Name string            `json:"name"`           // Label attached to the machine's sessions
+	Home string            `json:"home,omitempty"` // Copy of the machine's home directory
+	Dirs map[string]string `json:"dirs,omitempty"` // Agent data directories by source, overriding those under Home
+	SSH  string            `json:"ssh,omitempty"`  // SSH destination (e.g. "me@devbox") to mirror sessions from
+
+	// sync, if set, syncs the mirror of a remote machine before its sessions are first read
+	sync func()
+}
+
+// defaultDataDirs are the agent data directories relative to a home directory
+var defaultDataDirs = map[string][]string{
+	"claude":   {".claude"},
+	"codex":    {".codex"},
+	"gemini":   {".gemini"},
+	"opencode": {".local", "share", "opencode"},
+}
+
+// dataDir returns the machine's data directory of a source, or "" if it has none
+func (m machineConfig) dataDir(source string) string {
+	dir := m.Dirs[source]
+	if dir == "" && m.Home != "" {
+		dir = filepath.Join(append([]string{m.Home}, defaultDataDirs[source]...)...)
+	}
+	if dir == "" {
+		return ""
+	}
+	if m.SSH != "" {
+		return dir // Mirrors are filled in the background, so they may not exist yet
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// configuredMachines returns the other machines listed in the config file
+func configuredMachines() []machineConfig {
+	config, err := readConfigFile()
+	if err != nil {
+		slog.Warn("Ignoring machines", "error", err)
+		return nil
+	}
+	machines := make([]machineConfig, 0, len(config.Machines))
+	for _, machine := range config.Machines {
+		if machine.Name == "" {
+			slog.Warn("Ignoring a machine without a name in the config file")
+			continue
+		}
+		if strings.HasPrefix(machine.SSH, "-") {
+			// It would be passed to rsync and ssh as an option
+			slog.Warn("Ignoring machine with an invalid SSH destination", "machine", machine.Name, "ssh", machine.SSH)
+			continue
+		}
+		if machine.SSH != "" {
+			// Remote sessions are read from a local mirror, see syncRemoteMachine
+			mirror, err := remoteMirrorDir(machine.Name)
+			if err != nil {
+				slog.Warn("Ignoring machine", "machine", machine.Name, "error", err)
+				continue
+			}
+			machine.Home, machine.Dirs = mirror, nil
+		}
+		machines = append(machines, machine)
+	}
+	return machines
+}
+
+// withMachines merges the local adapter of a source with the adapters of the other
+// machines that have data for it
+func withMachines(source string, local adapters.SessionAdapter, machines []machineConfig) adapters.SessionAdapter {
+	merged := []adapters.MachineAdapter{{Adapter: local}}
+	for _, machine := range machines {
+		dataDir := machine.dataDir(source)
+		if dataDir == "" {
+			continue
+		}
+		adapter, err := adapters.NewAdapterAt(source, dataDir)
+		if err != nil {
+			slog.Warn("Ignoring sessions of machine", "source", source, "machine", machine.Name, "error", err)
+			continue
+		}
+		merged = append(merged, adapters.MachineAdapter{Machine: machine.Name, Adapter: adapter, Prepare: machine.sync})
+	}
+	if len(merged) == 1 {
+		return local
+	}
+	return adapters.NewMultiMachineAdapter(merged...)
+}
+
+// readConfigFile reads the config file without requiring a token. A missing file is
+// an empty configuration.
+func readConfigFile() (Config, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return Config{}, err
+	}
+
+	data, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid config file: %w", err)
+	}
+	return config, nil
+}
+
+// saveLoginToken stores the new token of a profile and the server it is for, adding the
+// hosts newly trusted and keeping the other settings of the config file. The token goes
+// in the system keychain when there is one, and in the config file otherwise. A config
+// file that can't be read is left alone rather than replaced, losing its settings.
+func saveLoginToken(profile string, config Config) error {
+	existing, err := readConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to update config file (fix or delete it to log in): %w", err)
+	}
+	p := profileConfig{Token: config.Token, APIURL: config.APIURL}
+	if tokenKeychain != nil {
+		if err := tokenKeychain.set(keychainAccountFor(profile), config.Token); err != nil {
+			slog.Warn("Saving token in the config file, the keychain is unavailable", "keychain", tokenKeychain.name(), "error", err)
+		} else {
+			p.Token, p.Keychain = "", true
+		}
+	}
+	existing.setProfile(profile, p)
+	for _, host := range config.TrustedHosts {
+		trusted := false
+		for _, known := range existing.TrustedHosts {
+			trusted = trusted || strings.EqualFold(known, host)
+		}
+		if !trusted {
+			existing.TrustedHosts = append(existing.TrustedHosts, host)
+		}
+	}
+	return saveConfig(existing)
+}
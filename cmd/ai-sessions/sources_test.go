@@ -0,0 +1,100 @@
+package main
+
+import (
+	"io"
+	"testing"
+)
+
+func TestParseSourceList(t *testing.T) {
+	only, err := parseSourceList("claude, Codex")
+	if err != nil {
+		t.Fatalf("parseSourceList failed: %v", err)
+	}
+	if !only.allows("claude") || !only.allows("codex") || only.allows("gemini") {
+		t.Fatalf("expected only claude and codex to be enabled: %+v", only)
+	}
+
+	without, err := parseSourceList("-gemini")
+	if err != nil {
+		t.Fatalf("parseSourceList failed: %v", err)
+	}
+	if without.allows("gemini") || !without.allows("claude") || !without.allows("opencode") {
+		t.Fatalf("expected everything but gemini to be enabled: %+v", without)
+	}
+
+	if _, err := parseSourceList("claude,cursr"); err == nil {
+		t.Fatal("expected an error for an unknown source")
+	}
+}
+
+func TestServerOptionsPreferFlagOverConfig(t *testing.T) {
+	tempHome := t.TempDir()
+	t.Setenv("HOME", tempHome)
+	t.Setenv("USERPROFILE", tempHome)
+
+	if err := saveConfig(Config{Token: "abc.def.ghi", DisabledSources: []string{"gemini"}}); err != nil {
+		t.Fatalf("saveConfig failed: %v", err)
+	}
+
+	fromConfig, err := parseServerOptions(nil, io.Discard)
+	if err != nil {
+		t.Fatalf("parseServerOptions failed: %v", err)
+	}
+	if fromConfig.Selection.allows("gemini") || !fromConfig.Selection.allows("claude") {
+		t.Fatalf("expected the config file to disable gemini: %+v", fromConfig)
+	}
+
+	fromFlag, err := parseServerOptions([]string{"--sources", "gemini", "--quiet"}, io.Discard)
+	if err != nil {
+		t.Fatalf("parseServerOptions failed: %v", err)
+	}
+	if !fromFlag.Selection.allows("gemini") || fromFlag.Selection.allows("claude") || !fromFlag.Quiet {
+		t.Fatalf("expected --sources to replace the config file: %+v", fromFlag)
+	}
+
+	invalid, err := parseServerOptions([]string{"--sources", "claude,bogus"}, io.Discard)
+	if err != nil || invalid.SelectionErr == nil || !invalid.Selection.allows("gemini") {
+		t.Fatalf("expected an invalid selection to be reported and load every source: %+v (%v)", invalid, err)
+	}
+
+	if _, err := parseServerOptions([]string{"--verbose", "--quiet"}, io.Discard); err == nil {
+		t.Fatal("expected --verbose and --quiet to conflict")
+	}
+
+	if !isServerFlag("--sources=-gemini") || !isServerFlag("--log-file") || !isServerFlag("--pprof=localhost:6060") || isServerFlag("costs") || isServerFlag("-x") {
+		t.Fatal("isServerFlag misclassified an argument")
+	}
+
+	// Logging in again keeps the source settings
+	defer func(k keychain) { tokenKeychain = k }(tokenKeychain)
+	tokenKeychain = nil
+	if err := saveLoginToken("", Config{Token: "new.token.value"}); err != nil {
+		t.Fatalf("saveLoginToken failed: %v", err)
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		t.Fatalf("readConfigFile failed: %v", err)
+	}
+	if config.Token != "new.token.value" || len(config.DisabledSources) != 1 {
+		t.Fatalf("expected the token to change and the sources to be kept: %+v", config)
+	}
+}
+
+func TestOpenAdaptersReportsDisabledSources(t *testing.T) {
+	adaptersMap, statuses := openAdapters(sourceSelection{Disabled: []string{"gemini"}}, nil)
+	if _, ok := adaptersMap["gemini"]; ok {
+		t.Fatal("disabled source should not be loaded")
+	}
+	if len(statuses) != len(supportedSources) {
+		t.Fatalf("expected a status for every supported source, got %+v", statuses)
+	}
+	for _, status := range statuses {
+		want := sourceEnabled
+		if status.Source == "gemini" {
+			want = sourceDisabled
+		}
+		if status.Status != want {
+			t.Fatalf("source %s: expected %s, got %s", status.Source, want, status.Status)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// defaultStatsWindow is the reporting window used when no start date is given
+const defaultStatsWindow = "30d"
+
+// Tool 9: session_stats
+type sessionStatsArgs struct {
+	Source      string `json:"source,omitempty" jsonschema:"Filter by source name (claude, gemini, codex, opencode). Leave empty for all sources."`
+	ProjectPath string `json:"project_path,omitempty" jsonschema:"Filter by project: a path (absolute or relative), a glob like '~/work/*', or a directory name like 'ai-sessions-mcp'. Leave empty for all projects."`
+	Since       string `json:"since,omitempty" jsonschema:"Only include sessions started after this point: a relative window like '7d', '2w', '12h', a date like '2025-01-31', or 'all' (default: 30d)"`
+}
+
+func addSessionStatsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "session_stats",
+		Description: "Aggregate usage statistics across sessions: counts per source, project, day, and week, message counts, and token usage and cost where the source records them",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args sessionStatsArgs) (*mcp.CallToolResult, any, error) {
+		if args.Since == "" {
+			args.Since = defaultStatsWindow
+		}
+		since, err := parseSince(args.Since, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		report, err := buildStatsReport(adaptersMap, args.Source, args.ProjectPath, since)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		result := map[string]interface{}{
+			"since": args.Since,
+			"stats": report,
+		}
+		if !since.IsZero() {
+			result["since_time"] = since
+		}
+		return jsonToolResult(result)
+	})
+}
+
+// buildStatsReport aggregates every matching session started at or after since.
+func buildStatsReport(adaptersMap map[string]adapters.SessionAdapter, source, projectPath string, since time.Time) (analysis.StatsReport, error) {
+	sessions, err := collectSessions(adaptersMap, source, projectPath)
+	if err != nil {
+		return analysis.StatsReport{}, err
+	}
+
+	stats := analysis.NewStats(cliTimes.location())
+	for _, session := range sessions {
+		if session.Timestamp.Before(since) {
+			continue
+		}
+		messages, err := readSession(adaptersMap, session)
+		if err != nil {
+			slog.Warn("Failed to read session", "session", session.ID, "error", err)
+			messages = nil
+		}
+		stats.Add(session, messages)
+	}
+	return stats.Report(), nil
+}
+
+// parseSince converts a relative window ("30d", "2w", "12h"), a date, or an RFC 3339
+// timestamp into the earliest time to include. "all" returns the zero time.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || strings.EqualFold(value, "all") {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return t, nil
+	}
+
+	units := map[byte]time.Duration{
+		'h': time.Hour,
+		'd': 24 * time.Hour,
+		'w': 7 * 24 * time.Hour,
+	}
+	if unit, ok := units[strings.ToLower(value)[len(value)-1]]; ok {
+		if n, err := strconv.Atoi(value[:len(value)-1]); err == nil && n > 0 {
+			return now.Add(-time.Duration(n) * unit), nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid since value %q: use a window like '30d', '2w', '12h', a date like '2025-01-31', or 'all'", value)
+}
+
+// maxStatsRows caps the projects and days listed by `aisessions stats`
+const maxStatsRows = 10
+
+// reportOptions select the sessions summarized by `aisessions stats` and `aisessions costs`
+type reportOptions struct {
+	Since       string // Relative window or date, see parseSince
+	Source      string
+	ProjectPath string
+	JSON        bool
+}
+
+var statsCommand = cliCommand{
+	name:    "stats",
+	json:    true,
+	summary: "Summarize sessions per source and project, and the busiest days",
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts reportOptions
+		fs.StringVar(&opts.Since, "since", defaultStatsWindow, "only include sessions since a `window` like 30d, 2w, or 12h, a date, or 'all'")
+		fs.StringVar(&opts.Source, "source", "", "only include sessions of this `source`")
+		fs.StringVar(&opts.ProjectPath, "project", "", "only include sessions of a `project`: a path, a glob, or a directory name")
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			return runStatsCommand(env.sessionAdapters(), opts, env.stdout)
+		}
+	},
+}
+
+func runStatsCommand(adaptersMap map[string]adapters.SessionAdapter, opts reportOptions, stdout io.Writer) error {
+	if opts.Source != "" {
+		if _, ok := adaptersMap[opts.Source]; !ok {
+			return fmt.Errorf("unknown source: %s", opts.Source)
+		}
+	}
+
+	since, err := parseSince(opts.Since, time.Now())
+	if err != nil {
+		return err
+	}
+	report, err := buildStatsReport(adaptersMap, opts.Source, opts.ProjectPath, since)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		output := map[string]interface{}{
+			"since": opts.Since,
+			"stats": report,
+		}
+		if !since.IsZero() {
+			output["since_time"] = since
+		}
+		return printJSON(stdout, output)
+	}
+	printStatsReport(stdout, opts.Since, report)
+	return nil
+}
+
+// printStatsReport writes a stats report as plain text tables: sessions per source,
+// the most active projects, and the busiest days.
+func printStatsReport(w io.Writer, since string, report analysis.StatsReport) {
+	total := report.Totals
+	fmt.Fprintf(w, "Activity (since %s): %d sessions, %d messages (%d from you), %d tool calls\n",
+		since, total.Sessions, total.Messages, total.UserMessages, total.ToolCalls)
+	fmt.Fprintf(w, "Tokens: %d  Estimated cost: $%.2f\n", total.Usage.Total(), total.Usage.Cost)
+
+	busiest := append([]analysis.StatsBucket(nil), report.ByDay...)
+	sort.SliceStable(busiest, func(i, j int) bool {
+		return busiest[i].Sessions > busiest[j].Sessions
+	})
+
+	sections := []struct {
+		title   string
+		buckets []analysis.StatsBucket
+	}{
+		{"By source", report.BySource},
+		{"Top projects", report.ByProject},
+		{"Busiest days", busiest},
+	}
+	for _, section := range sections {
+		if len(section.buckets) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", section.title)
+		for i, b := range section.buckets {
+			if i == maxStatsRows {
+				fmt.Fprintf(w, "  ... and %d more\n", len(section.buckets)-maxStatsRows)
+				break
+			}
+			key := b.Key
+			if key == "" {
+				key = "(unknown)"
+			}
+			fmt.Fprintf(w, "  %-50s %4d sessions  %6d messages  %12d tokens  $%9.2f\n", key, b.Sessions, b.Messages, b.Usage.Total(), b.Usage.Cost)
+		}
+	}
+}
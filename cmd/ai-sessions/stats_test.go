@@ -0,0 +1,82 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	cases := map[string]time.Time{
+		"7d":                   now.AddDate(0, 0, -7),
+		"2W":                   now.AddDate(0, 0, -14),
+		"12h":                  now.Add(-12 * time.Hour),
+		"2025-01-31":           time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC),
+		"2025-02-01T08:00:00Z": time.Date(2025, 2, 1, 8, 0, 0, 0, time.UTC),
+		"all":                  {},
+	}
+	for input, want := range cases {
+		got, err := parseSince(input, now)
+		if err != nil {
+			t.Fatalf("parseSince(%q) failed: %v", input, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("parseSince(%q)=%v want %v", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"soon", "0d", "d", "-3d"} {
+		if _, err := parseSince(input, now); err == nil {
+			t.Fatalf("parseSince(%q) should fail", input)
+		}
+	}
+}
+
+func TestRunStatsCommand(t *testing.T) {
+	recent := time.Now().Add(-time.Hour)
+	stub := newStubAdapter(
+		[]adapters.Session{
+			{ID: "s1", Source: "opencode", ProjectPath: "/work/app", Timestamp: recent},
+			{ID: "s2", Source: "opencode", ProjectPath: "/work/api", Timestamp: recent},
+		},
+		map[string][]adapters.Message{
+			"s1": {{Role: "user", Content: "hi"}, {Role: "assistant", Metadata: map[string]interface{}{"cost": 0.5}}},
+			"s2": {{Role: "user", Content: "hello"}},
+		},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"opencode": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, nil, &out, "stats", "--since", "7d"); err != nil {
+		t.Fatalf("stats failed: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{"2 sessions, 3 messages (2 from you)", "Estimated cost: $0.50", "By source:", "/work/api", "Busiest days:\n  " + recent.Local().Format("2006-01-02")} {
+		if !strings.Contains(text, want) {
+			t.Errorf("expected %q in report:\n%s", want, text)
+		}
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, nil, &out, "stats", "--since", "7d", "--json"); err != nil {
+		t.Fatalf("stats --json failed: %v", err)
+	}
+	var report struct {
+		Since string               `json:"since"`
+		Stats analysis.StatsReport `json:"stats"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil || report.Since != "7d" || report.Stats.Totals.Sessions != 2 {
+		t.Fatalf("expected the report as JSON, got:\n%s", out.String())
+	}
+
+	if err := runTestCLI(adaptersMap, nil, &out, "stats", "--weekly"); err == nil {
+		t.Fatal("expected an error for an unknown option")
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Tool 5: get_session_summary
+type getSessionSummaryArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to summarize"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+}
+
+func addGetSessionSummaryTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_session_summary",
+		Description: "Get a condensed overview of a session (first/last user messages, files touched, commands run, errors seen, message and turn counts) to decide whether to fetch the full transcript",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionSummaryArgs) (*mcp.CallToolResult, any, error) {
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"summary":    analysis.Summarize(messages),
+		})
+	})
+}
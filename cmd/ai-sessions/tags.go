@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+// bookmarkTag is the tag applied by the bookmark command
+const bookmarkTag = "bookmark"
+
+// Tool 12: tag_session
+type tagSessionArgs struct {
+	SessionID string   `json:"session_id" jsonschema:"The session ID to tag"`
+	Source    string   `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+	Tags      []string `json:"tags" jsonschema:"Tags to add (or remove). Tags are lower-cased and spaces become dashes. Use 'bookmark' to bookmark a session."`
+	Remove    bool     `json:"remove,omitempty" jsonschema:"Remove the given tags instead of adding them"`
+}
+
+func addTagSessionTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "tag_session",
+		Description: "Add or remove tags on a session (e.g., 'good-example', 'postmortem', 'bookmark'). Tags are stored locally and never modify session files.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args tagSessionArgs) (*mcp.CallToolResult, any, error) {
+		if args.SessionID == "" {
+			return nil, nil, fmt.Errorf("session_id is required")
+		}
+		if _, ok := adaptersMap[args.Source]; !ok {
+			return nil, nil, fmt.Errorf("unknown source: %s", args.Source)
+		}
+
+		var err error
+		if args.Remove {
+			err = searchCache.UntagSession(args.Source, args.SessionID, args.Tags)
+		} else {
+			err = searchCache.TagSession(args.Source, args.SessionID, args.Tags)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		tags, err := searchCache.SessionTags(args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"tags":       tags,
+		})
+	})
+}
+
+// Tool 13: list_tags
+type listTagsArgs struct{}
+
+func addListTagsTool(server *mcp.Server, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_tags",
+		Description: "List every tag in use with the number of sessions carrying it",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args listTagsArgs) (*mcp.CallToolResult, any, error) {
+		tags, err := searchCache.ListTags()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"tags":  tags,
+			"count": len(tags),
+		})
+	})
+}
+
+// Tool 14: find_sessions_by_tag
+type findSessionsByTagArgs struct {
+	Tag    string `json:"tag" jsonschema:"The tag to look up"`
+	Source string `json:"source,omitempty" jsonschema:"Optional: filter by source (claude, gemini, codex, opencode)"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return (default: 50)"`
+}
+
+func addFindSessionsByTagTool(server *mcp.Server, searchCache *search.Cache) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "find_sessions_by_tag",
+		Description: "List the sessions carrying a tag, most recently tagged first",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args findSessionsByTagArgs) (*mcp.CallToolResult, any, error) {
+		if args.Limit == 0 {
+			args.Limit = 50
+		}
+
+		sessions, err := searchCache.SessionsByTag(args.Tag, args.Source, args.Limit)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"tag":      args.Tag,
+			"sessions": sessions,
+			"count":    len(sessions),
+		})
+	})
+}
+
+var tagCommand = cliCommand{
+	name:    "tag",
+	args:    "<source> <id> <tag>...",
+	summary: "Add tags to a session",
+	minArgs: 3,
+	maxArgs: -1,
+	json:    true,
+	setup:   tagCommandSetup("tag"),
+}
+
+var untagCommand = cliCommand{
+	name:    "untag",
+	args:    "<source> <id> <tag>...",
+	summary: "Remove tags from a session",
+	minArgs: 3,
+	maxArgs: -1,
+	json:    true,
+	setup:   tagCommandSetup("untag"),
+}
+
+var bookmarkCommand = cliCommand{
+	name:    "bookmark",
+	args:    "<source> <id>",
+	summary: `Bookmark a session (same as tagging it "bookmark")`,
+	minArgs: 2,
+	maxArgs: 2,
+	json:    true,
+	setup:   tagCommandSetup("bookmark"),
+}
+
+func tagCommandSetup(command string) func(fs *flag.FlagSet) cliRunFunc {
+	return func(fs *flag.FlagSet) cliRunFunc {
+		return func(env *cliEnv, args []string) error {
+			cache, err := env.searchCache()
+			if err != nil {
+				return err
+			}
+			return runTagCommand(cache, command, args, env.options.JSON, env.stdout)
+		}
+	}
+}
+
+func runTagCommand(cache *search.Cache, command string, args []string, asJSON bool, stdout io.Writer) error {
+	if command == "bookmark" {
+		args = append(args, bookmarkTag)
+	}
+	if len(args) < 3 {
+		return fmt.Errorf("usage: aisessions %s <source> <session-id> <tag>...", command)
+	}
+	source, sessionID, tags := args[0], args[1], args[2:]
+
+	var err error
+	if command == "untag" {
+		err = cache.UntagSession(source, sessionID, tags)
+	} else {
+		err = cache.TagSession(source, sessionID, tags)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := cache.SessionTags(source, sessionID)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(stdout, map[string]interface{}{"session_id": sessionID, "source": source, "tags": current})
+	}
+	fmt.Fprintf(stdout, "%s/%s tags: %v\n", source, sessionID, current)
+	return nil
+}
+
+var tagsCommand = cliCommand{
+	name:    "tags",
+	args:    "[tag]",
+	summary: "List tags, or the sessions carrying a tag",
+	maxArgs: 1,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		return func(env *cliEnv, args []string) error {
+			cache, err := env.searchCache()
+			if err != nil {
+				return err
+			}
+			return runTagsCommand(cache, args, env.options.JSON, env.stdout)
+		}
+	},
+}
+
+func runTagsCommand(cache *search.Cache, args []string, asJSON bool, stdout io.Writer) error {
+	if len(args) == 0 {
+		tags, err := cache.ListTags()
+		if err != nil {
+			return err
+		}
+		if asJSON {
+			return printJSON(stdout, map[string]interface{}{"tags": tags, "count": len(tags)})
+		}
+		if len(tags) == 0 {
+			fmt.Fprintln(stdout, "No tags yet. Tag a session with: aisessions tag <source> <session-id> <tag>")
+			return nil
+		}
+		for _, tc := range tags {
+			fmt.Fprintf(stdout, "%-30s %d\n", tc.Tag, tc.Count)
+		}
+		return nil
+	}
+
+	sessions, err := cache.SessionsByTag(args[0], "", 0)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return printJSON(stdout, map[string]interface{}{"tag": args[0], "sessions": sessions, "count": len(sessions)})
+	}
+	if len(sessions) == 0 {
+		fmt.Fprintf(stdout, "No sessions tagged %q\n", args[0])
+		return nil
+	}
+	for _, ts := range sessions {
+		line := fmt.Sprintf("[%s] %s", ts.Source, ts.SessionID)
+		if ts.Session != nil && ts.Session.FirstMessage != "" {
+			line += "  " + ts.Session.FirstMessage
+		}
+		fmt.Fprintln(stdout, line)
+	}
+	return nil
+}
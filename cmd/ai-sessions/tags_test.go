@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/search"
+)
+
+func TestTagCommands(t *testing.T) {
+	cache, err := search.NewCache(filepath.Join(t.TempDir(), "cache.db"))
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	defer cache.Close()
+
+	var out bytes.Buffer
+	if err := runTestCLI(nil, cache, &out, "tag", "claude", "abc", "postmortem"); err != nil {
+		t.Fatalf("tag failed: %v", err)
+	}
+	if err := runTestCLI(nil, cache, &out, "bookmark", "claude", "abc"); err != nil {
+		t.Fatalf("bookmark failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "[bookmark postmortem]") {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	if err := runTestCLI(nil, cache, &out, "tag", "claude", "abc"); err == nil {
+		t.Fatal("expected usage error without tags")
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, cache, &out, "tags"); err != nil {
+		t.Fatalf("tags failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "postmortem") || !strings.Contains(out.String(), "bookmark") {
+		t.Fatalf("tag list missing entries: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, cache, &out, "tags", "postmortem"); err != nil {
+		t.Fatalf("tags <tag> failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "[claude] abc") {
+		t.Fatalf("tagged session not listed: %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, cache, &out, "--json", "tags", "postmortem"); err != nil {
+		t.Fatalf("tags --json failed: %v", err)
+	}
+	var tagged struct {
+		Count int `json:"count"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &tagged); err != nil || tagged.Count != 1 {
+		t.Fatalf("expected one tagged session as JSON, got %q", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(nil, cache, &out, "untag", "claude", "abc", "postmortem"); err != nil {
+		t.Fatalf("untag failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "[bookmark]") {
+		t.Fatalf("unexpected output after untag: %q", out.String())
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// defaultActiveWindow is how recently a session must have been written to to be active
+const defaultActiveWindow = 5 * time.Minute
+
+// sessionModTime returns when a session's file was last written to, or when the session
+// started if its file can't be read
+func sessionModTime(session adapters.Session) time.Time {
+	if info, err := os.Stat(session.FilePath); err == nil {
+		return info.ModTime()
+	}
+	return session.Timestamp
+}
+
+// activeSession is a session whose file was written to recently
+type activeSession struct {
+	adapters.Session
+	LastActivity time.Time `json:"last_activity"`
+	IdleSeconds  int64     `json:"idle_seconds"`
+	Cursor       int       `json:"cursor"` // Its number of messages, to tail it from with get_session
+}
+
+// activeSessions returns the sessions written to within window of now, most recently
+// active first
+func activeSessions(sessions []adapters.Session, window time.Duration, now time.Time) []activeSession {
+	active := []activeSession{}
+	for _, session := range sessions {
+		modTime := sessionModTime(session)
+		if now.Sub(modTime) > window {
+			continue
+		}
+		active = append(active, activeSession{Session: session, LastActivity: modTime, IdleSeconds: int64(max(now.Sub(modTime), 0) / time.Second)})
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].LastActivity.After(active[j].LastActivity)
+	})
+	return active
+}
+
+// tailMessages returns up to limit messages kept by filter, starting at the message with
+// index cursor, and the cursor to continue from: the index after the last message read.
+// A cursor past the end returns nothing.
+func tailMessages(messages []adapters.Message, cursor, limit int, filter messageFilter) ([]adapters.Message, int, bool) {
+	tail := []adapters.Message{}
+	next := min(max(cursor, 0), len(messages))
+	for next < len(messages) && len(tail) < limit {
+		tail = append(tail, filterMessages(messages[next:next+1], filter)...)
+		next++
+	}
+	return tail, next, next < len(messages)
+}
+
+// Tool 27: get_active_sessions
+type getActiveSessionsArgs struct {
+	WithinMinutes int    `json:"within_minutes,omitempty" jsonschema:"How recently a session must have been written to, in minutes (default: 5)"`
+	Source        string `json:"source,omitempty" jsonschema:"Optional: filter by source (claude, gemini, codex, opencode)"`
+	ProjectPath   string `json:"project_path,omitempty" jsonschema:"Optional: filter by project: a path, a glob like '~/work/*', or a directory name"`
+	Limit         int    `json:"limit,omitempty" jsonschema:"Maximum number of sessions to return (default: 10)"`
+}
+
+func addGetActiveSessionsTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_active_sessions",
+		Description: "List the sessions written to in the last few minutes, most recently active first, to monitor another agent's ongoing session. Each has a cursor: pass it to get_session with tail set to read only the messages added since.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getActiveSessionsArgs) (*mcp.CallToolResult, any, error) {
+		if args.WithinMinutes < 0 {
+			return nil, nil, fmt.Errorf("within_minutes must not be negative")
+		}
+		window := defaultActiveWindow
+		if args.WithinMinutes > 0 {
+			window = time.Duration(args.WithinMinutes) * time.Minute
+		}
+		if args.Limit == 0 {
+			args.Limit = 10
+		}
+
+		// Sessions are listed by start, so a long-running one can be anywhere in the list
+		sessions, failedSources, err := listSources(ctx, adaptersMap, args.Source, args.ProjectPath, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+		active := activeSessions(sessions, window, time.Now())
+		if len(active) > args.Limit {
+			active = active[:args.Limit]
+		}
+		for i := range active {
+			if messages, err := readSession(adaptersMap, active[i].Session); err == nil {
+				active[i].Cursor = len(messages)
+			}
+		}
+
+		result := map[string]interface{}{
+			"sessions":       active,
+			"count":          len(active),
+			"within_minutes": int(window / time.Minute),
+		}
+		if len(failedSources) > 0 {
+			result["failed_sources"] = failedSources
+		}
+		return jsonToolResult(result)
+	})
+}
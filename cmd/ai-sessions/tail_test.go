@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestActiveSessions(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	session := func(id string, modTime time.Time) adapters.Session {
+		t.Helper()
+		path := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("write session file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("set modification time: %v", err)
+		}
+		return adapters.Session{ID: id, Source: "stub", Timestamp: now.Add(-time.Hour), FilePath: path}
+	}
+	sessions := []adapters.Session{
+		session("idle", now.Add(-time.Hour)),
+		session("busy", now.Add(-10*time.Second)),
+		session("recent", now.Add(-2*time.Minute)),
+	}
+
+	active := activeSessions(sessions, defaultActiveWindow, now)
+	if len(active) != 2 || active[0].ID != "busy" || active[1].ID != "recent" {
+		t.Fatalf("expected the active sessions, most recently active first, got %+v", active)
+	}
+	if active[1].IdleSeconds != 120 {
+		t.Fatalf("expected 120 idle seconds, got %d", active[1].IdleSeconds)
+	}
+}
+
+func TestTailMessages(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "Fix the build"},
+		{Role: "assistant", Content: "Running the tests"},
+		{Role: "user", Content: "", Metadata: map[string]interface{}{"is_tool_result": true}},
+		{Role: "assistant", Content: "Fixed"},
+	}
+
+	tail, cursor, hasMore := tailMessages(messages, 0, 2, messageFilter{})
+	if len(tail) != 2 || tail[1].Content != "Running the tests" || cursor != 2 || !hasMore {
+		t.Fatalf("unexpected first tail: %+v (cursor %d, more %v)", tail, cursor, hasMore)
+	}
+
+	// The cursor counts the messages left out by the filter
+	tail, cursor, hasMore = tailMessages(messages, cursor, 2, messageFilter{Roles: []string{"assistant"}})
+	if len(tail) != 1 || tail[0].Content != "Fixed" || cursor != 4 || hasMore {
+		t.Fatalf("unexpected filtered tail: %+v (cursor %d, more %v)", tail, cursor, hasMore)
+	}
+
+	tail, cursor, hasMore = tailMessages(messages, 10, 2, messageFilter{})
+	if len(tail) != 0 || cursor != 4 || hasMore {
+		t.Fatalf("expected nothing past the end, got %+v (cursor %d, more %v)", tail, cursor, hasMore)
+	}
+}
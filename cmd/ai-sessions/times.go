@@ -0,0 +1,118 @@
+package main
+
+import (
+	"reflect"
+	"time"
+)
+
+// timeStyle is how the CLI prints times: relative ("2 hours ago") or absolute, in the
+// local zone or in UTC
+type timeStyle struct {
+	absolute bool
+	utc      bool
+}
+
+// cliTimes is the time style of the running command, set from --absolute and --utc
+var cliTimes timeStyle
+
+// absoluteTimeLayout names the zone so that absolute times are unambiguous
+const absoluteTimeLayout = "2006-01-02 15:04 MST"
+
+// location returns the zone times are printed in
+func (s timeStyle) location() *time.Location {
+	if s.utc {
+		return time.UTC
+	}
+	return time.Local
+}
+
+// format prints t in the style
+func (s timeStyle) format(t time.Time) string {
+	t = t.In(s.location())
+	if s.absolute {
+		return t.Format(absoluteTimeLayout)
+	}
+	return formatRelativeTime(t)
+}
+
+// columnWidth returns the width of a table column of times in the style
+func (s timeStyle) columnWidth() int {
+	if s.absolute {
+		return len(absoluteTimeLayout) + 2 // Zones without an abbreviation print as -0700
+	}
+	return 12
+}
+
+// formatTime prints t in the style of the running command
+func formatTime(t time.Time) string {
+	return cliTimes.format(t)
+}
+
+// timesIn returns a copy of v with every time.Time it holds in loc, so that JSON output
+// prints them in one zone. Unexported fields, which JSON leaves out, are copied as they
+// are.
+func timesIn(v interface{}, loc *time.Location) interface{} {
+	if v == nil {
+		return nil
+	}
+	return convertTimes(reflect.ValueOf(v), loc).Interface()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func convertTimes(v reflect.Value, loc *time.Location) reflect.Value {
+	if v.Type() == timeType {
+		return reflect.ValueOf(v.Interface().(time.Time).In(loc))
+	}
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type().Elem())
+		out.Elem().Set(convertTimes(v.Elem(), loc))
+		return out
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.New(v.Type()).Elem()
+		out.Set(convertTimes(v.Elem(), loc))
+		return out
+	case reflect.Struct:
+		out := reflect.New(v.Type()).Elem()
+		out.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if field := out.Field(i); field.CanSet() {
+				field.Set(convertTimes(v.Field(i), loc))
+			}
+		}
+		return out
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(convertTimes(v.Index(i), loc))
+		}
+		return out
+	case reflect.Array:
+		out := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			out.Index(i).Set(convertTimes(v.Index(i), loc))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		out := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out.SetMapIndex(iter.Key(), convertTimes(iter.Value(), loc))
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestListPrintsAbsoluteAndUTCTimes(t *testing.T) {
+	started := time.Date(2025, 3, 1, 11, 30, 0, 0, time.FixedZone("CET", 2*60*60))
+	stub := newStubAdapter([]adapters.Session{
+		{ID: "s1", Source: "claude", ProjectPath: "/work/app", FirstMessage: "Add dark mode", Timestamp: started},
+	}, nil)
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": stub}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--absolute", "--utc"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "TIME (UTC)") || !strings.Contains(out.String(), "2025-03-01 09:30 UTC") {
+		t.Fatalf("expected absolute times in UTC, got:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--json", "--utc"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"timestamp": "2025-03-01T09:30:00Z"`) {
+		t.Fatalf("expected an ISO timestamp in UTC, got %s", out.String())
+	}
+
+	// Without --utc, JSON keeps each time's zone
+	out.Reset()
+	if err := runTestCLI(adaptersMap, newTestCache(t), &out, "list", "--json"); err != nil {
+		t.Fatalf("list failed: %v", err)
+	}
+	if !strings.Contains(out.String(), `"timestamp": "2025-03-01T11:30:00+02:00"`) {
+		t.Fatalf("expected the session's own zone, got %s", out.String())
+	}
+}
+
+func TestTimesIn(t *testing.T) {
+	local := time.Date(2025, 3, 1, 11, 30, 0, 0, time.FixedZone("CET", 2*60*60))
+	type row struct {
+		When  time.Time
+		Later *time.Time
+		note  time.Time
+	}
+	value := map[string]interface{}{
+		"rows":  []row{{When: local, Later: &local, note: local}},
+		"count": 1,
+	}
+
+	converted := timesIn(value, time.UTC).(map[string]interface{})
+	got := converted["rows"].([]row)[0]
+	if got.When.Location() != time.UTC || got.Later.Location() != time.UTC || !got.When.Equal(local) {
+		t.Fatalf("expected the times in UTC, got %+v", got)
+	}
+	if got.note.Location() == time.UTC {
+		t.Fatal("expected unexported fields to be copied as they are")
+	}
+	if value["rows"].([]row)[0].When.Location() == time.UTC || local.Location() == time.UTC {
+		t.Fatal("expected the original value to be left unchanged")
+	}
+	if converted["count"] != 1 || timesIn(nil, time.UTC) != nil {
+		t.Fatalf("expected other values to be kept, got %v", converted)
+	}
+}
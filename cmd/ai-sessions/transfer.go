@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxSingleUploadSize is the largest transcript sent in a single request, and the
+	// largest that can be uploaded to a server without chunked uploads
+	maxSingleUploadSize = 5 * 1024 * 1024
+
+	// maxUploadSize is the largest transcript that can be uploaded in chunks
+	maxUploadSize = 100 * 1024 * 1024
+
+	// uploadChunkSize is the size of each chunk of a chunked upload, unless the server
+	// asks for another
+	uploadChunkSize = 1024 * 1024
+
+	// uploadStateFile remembers unfinished chunked uploads so they can be resumed
+	uploadStateFile = "uploads.json"
+
+	// Defaults of how long each upload request may take, and how many times a request
+	// that failed for a temporary reason is retried
+	defaultUploadTimeout = 60 * time.Second
+	defaultUploadRetries = 3
+
+	// Waits between retries start at uploadRetryDelay and double each time, up to
+	// maxUploadRetryDelay
+	uploadRetryDelay    = time.Second
+	maxUploadRetryDelay = 30 * time.Second
+)
+
+// UploadError is a failed upload request: rejected by the server, or with a
+// StatusCode of 0, not answered at all
+type UploadError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // How long the server asked to wait before retrying
+}
+
+func (e *UploadError) Error() string {
+	return e.Message
+}
+
+// Retryable reports whether the request may succeed if sent again: the server was
+// unreachable, timed out, overloaded, or failed internally. Other errors, such as an
+// invalid transcript, fail the same way every time.
+func (e *UploadError) Retryable() bool {
+	return e.StatusCode == 0 || e.StatusCode == http.StatusRequestTimeout ||
+		e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// serverCapabilities are the upload features a server advertises at
+// /api/cli/capabilities. Servers that don't are sent uncompressed transcripts of up to
+// maxSingleUploadSize, in a single request.
+type serverCapabilities struct {
+	GzipUploads    bool `json:"gzipUploads"`             // Accepts gzip-compressed request bodies
+	ChunkedUploads bool `json:"chunkedUploads"`          // Accepts chunked uploads (see sendChunked)
+	MaxUploadSize  int  `json:"maxUploadSize,omitempty"` // Largest transcript it accepts, if below maxUploadSize
+}
+
+// chunkedUpload is the server's state of a chunked upload: the bytes received so far,
+// and the size of the chunks it accepts
+type chunkedUpload struct {
+	ID        string `json:"uploadId"`
+	Offset    int    `json:"offset"`
+	ChunkSize int    `json:"chunkSize,omitempty"`
+}
+
+// chunkedUploadRequest starts a chunked upload of a gzip-compressed transcript
+type chunkedUploadRequest struct {
+	Title           string `json:"title,omitempty"`
+	Size            int    `json:"size"`
+	SHA256          string `json:"sha256"`
+	ContentEncoding string `json:"contentEncoding"`
+}
+
+// uploadClient sends transcripts to the AI Sessions API
+type uploadClient struct {
+	client    *http.Client
+	apiURL    string
+	token     string
+	statePath string                // Where unfinished chunked uploads are remembered; empty to not resume
+	retries   int                   // Times a request is retried after a retryable error
+	progress  func(sent, total int) // Called as the chunks of a chunked upload are sent
+
+	// retrying is called before waiting to retry a request, and sleep waits
+	retrying func(attempt int, wait time.Duration, err error)
+	sleep    func(time.Duration)
+
+	capabilities *serverCapabilities // Fetched with the first upload
+}
+
+// newUploadClient returns a client whose requests time out after timeout (the default
+// when 0), and are retried up to retries times after a retryable error. Requests use
+// the proxy and CA settings of the config file.
+func newUploadClient(apiURL, token string, timeout time.Duration, retries int) (*uploadClient, error) {
+	if timeout <= 0 {
+		timeout = defaultUploadTimeout
+	}
+	config, err := readConfigFile()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newHTTPTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	c := &uploadClient{client: &http.Client{Timeout: timeout, Transport: transport}, apiURL: apiURL, token: token, retries: retries, sleep: time.Sleep}
+	if path, err := getConfigPath(); err == nil {
+		c.statePath = filepath.Join(filepath.Dir(path), uploadStateFile)
+	}
+	return c, nil
+}
+
+// serverCapabilities returns the upload features of the server. A server that doesn't
+// advertise them, or fails to, has none.
+func (c *uploadClient) serverCapabilities() serverCapabilities {
+	if c.capabilities == nil {
+		var capabilities serverCapabilities
+		if err := c.attempt("GET", "/api/cli/capabilities", nil, nil, &capabilities); err != nil {
+			capabilities = serverCapabilities{}
+		}
+		c.capabilities = &capabilities
+	}
+	return *c.capabilities
+}
+
+// maxUploadSize returns the largest transcript the server accepts
+func (c *uploadClient) maxUploadSize() int {
+	capabilities := c.serverCapabilities()
+	if !capabilities.ChunkedUploads {
+		return maxSingleUploadSize
+	}
+	if capabilities.MaxUploadSize > 0 && capabilities.MaxUploadSize < maxUploadSize {
+		return capabilities.MaxUploadSize
+	}
+	return maxUploadSize
+}
+
+// send uploads a transcript: in a single request when it is small enough, and
+// otherwise in chunks, resuming an earlier attempt to upload it. Transcripts are
+// compressed with gzip for servers that advertise accepting it.
+func (c *uploadClient) send(data []byte, title string) (*UploadResponse, error) {
+	if limit := c.maxUploadSize(); len(data) > limit {
+		return nil, fmt.Errorf("file too large: %.2f MB exceeds the %d MB limit", float64(len(data))/1024/1024, limit/1024/1024)
+	}
+	if len(data) <= maxSingleUploadSize {
+		return c.sendSingle(data, title, c.serverCapabilities().GzipUploads)
+	}
+	return c.sendChunked(data, title)
+}
+
+// sendSingle uploads a transcript in one request, compressed with gzip if asked to. A
+// server that turns out not to accept gzip request bodies gets it uncompressed.
+func (c *uploadClient) sendSingle(data []byte, title string, compress bool) (*UploadResponse, error) {
+	body, err := json.Marshal(UploadRequest{FileData: string(data), Title: title})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	var resp UploadResponse
+	headers := map[string]string{"Content-Type": "application/json"}
+	if compress {
+		compressed, err := gzipBytes(body)
+		if err != nil {
+			return nil, err
+		}
+		err = c.do("POST", "/api/cli/upload", compressed, map[string]string{"Content-Type": "application/json", "Content-Encoding": "gzip"}, &resp)
+		if uploadErr, ok := err.(*UploadError); !ok || uploadErr.StatusCode != http.StatusUnsupportedMediaType {
+			if err != nil {
+				return nil, err
+			}
+			return &resp, nil
+		}
+	}
+	if err := c.do("POST", "/api/cli/upload", body, headers, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// sendChunked uploads a transcript in chunks of its gzip-compressed data:
+//
+//	POST /api/cli/upload/chunked             starts an upload, returning its ID
+//	GET  /api/cli/upload/chunked/<id>        returns how much of an upload was received
+//	PUT  /api/cli/upload/chunked/<id>        sends the chunk given by Content-Range
+//	POST /api/cli/upload/chunked/<id>/complete  assembles the transcript
+//
+// An upload that was interrupted is resumed from the last chunk the server received.
+func (c *uploadClient) sendChunked(data []byte, title string) (*UploadResponse, error) {
+	compressed, err := gzipBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(compressed)
+	key := c.apiURL + " " + hex.EncodeToString(sum[:])
+
+	var upload chunkedUpload
+	if id := c.loadState()[key]; id != "" {
+		if err := c.do("GET", "/api/cli/upload/chunked/"+id, nil, nil, &upload); err != nil {
+			upload = chunkedUpload{} // Expired or unknown; start over
+		}
+	}
+	if upload.ID == "" {
+		start, err := json.Marshal(chunkedUploadRequest{Title: title, Size: len(compressed), SHA256: hex.EncodeToString(sum[:]), ContentEncoding: "gzip"})
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		if err := c.do("POST", "/api/cli/upload/chunked", start, map[string]string{"Content-Type": "application/json"}, &upload); err != nil {
+			return nil, err
+		}
+		if upload.ID == "" {
+			return nil, fmt.Errorf("failed to start upload: no upload ID returned")
+		}
+		c.saveState(key, upload.ID)
+	}
+
+	chunkSize := upload.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = uploadChunkSize
+	}
+	for upload.Offset < len(compressed) {
+		if c.progress != nil {
+			c.progress(upload.Offset, len(compressed))
+		}
+		end := upload.Offset + chunkSize
+		if end > len(compressed) {
+			end = len(compressed)
+		}
+		headers := map[string]string{
+			"Content-Type":  "application/octet-stream",
+			"Content-Range": fmt.Sprintf("bytes %d-%d/%d", upload.Offset, end-1, len(compressed)),
+		}
+		previous := upload.Offset
+		if err := c.do("PUT", "/api/cli/upload/chunked/"+upload.ID, compressed[upload.Offset:end], headers, &upload); err != nil {
+			return nil, err
+		}
+		if upload.Offset <= previous {
+			return nil, fmt.Errorf("upload stalled at byte %d of %d", previous, len(compressed))
+		}
+	}
+	if c.progress != nil {
+		c.progress(len(compressed), len(compressed))
+	}
+
+	var resp UploadResponse
+	if err := c.do("POST", "/api/cli/upload/chunked/"+upload.ID+"/complete", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	c.saveState(key, "")
+	return &resp, nil
+}
+
+// do sends an authenticated request and decodes the JSON response into out. Requests
+// that fail for a temporary reason are retried with exponential backoff.
+func (c *uploadClient) do(method, path string, body []byte, headers map[string]string, out interface{}) error {
+	delay := uploadRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := c.attempt(method, path, body, headers, out)
+		uploadErr, ok := err.(*UploadError)
+		if !ok || !uploadErr.Retryable() {
+			return err
+		}
+		if attempt > c.retries {
+			if attempt > 1 {
+				uploadErr.Message += fmt.Sprintf(" (gave up after %d attempts)", attempt)
+			}
+			return uploadErr
+		}
+
+		wait := delay
+		if uploadErr.RetryAfter > wait {
+			wait = uploadErr.RetryAfter
+		}
+		if wait > maxUploadRetryDelay {
+			wait = maxUploadRetryDelay
+		}
+		if c.retrying != nil {
+			c.retrying(attempt, wait, err)
+		}
+		if c.sleep != nil {
+			c.sleep(wait)
+		}
+		delay *= 2
+	}
+}
+
+// attempt sends a request once
+func (c *uploadClient) attempt(method, path string, body []byte, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequest(method, c.apiURL+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return requestError(err)
+	}
+	defer resp.Body.Close()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := responseError(resp.StatusCode, responseBody)
+		if uploadErr, ok := err.(*UploadError); ok {
+			if seconds, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil {
+				uploadErr.RetryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+		return err
+	}
+	if out != nil {
+		if err := json.Unmarshal(responseBody, out); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+// responseError turns an error response into an *AuthError for a rejected token, or
+// an *UploadError
+func responseError(statusCode int, body []byte) error {
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != "" {
+		if statusCode == http.StatusUnauthorized {
+			return &AuthError{Message: fmt.Sprintf("%s: %s", errResp.Error, errResp.Message)}
+		}
+		message := errResp.Error
+		if errResp.Message != "" {
+			message += ": " + errResp.Message
+		}
+		return &UploadError{StatusCode: statusCode, Message: message}
+	}
+	message := fmt.Sprintf("Status %d: %s", statusCode, strings.TrimSpace(string(body)))
+	if statusCode == http.StatusUnauthorized {
+		return &AuthError{Message: message}
+	}
+	return &UploadError{StatusCode: statusCode, Message: message}
+}
+
+// loadState returns the IDs of unfinished chunked uploads by API URL and checksum
+func (c *uploadClient) loadState() map[string]string {
+	state := make(map[string]string)
+	if c.statePath == "" {
+		return state
+	}
+	if data, err := os.ReadFile(c.statePath); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+// saveState remembers the ID of an unfinished chunked upload, or forgets it when id
+// is empty. Failing to save only means the upload can't be resumed.
+func (c *uploadClient) saveState(key, id string) {
+	if c.statePath == "" {
+		return
+	}
+	state := c.loadState()
+	if id == "" {
+		delete(state, key)
+	} else {
+		state[key] = id
+	}
+	if data, err := json.MarshalIndent(state, "", "  "); err == nil {
+		os.MkdirAll(filepath.Dir(c.statePath), 0o700)
+		os.WriteFile(c.statePath, data, 0o600)
+	}
+}
+
+// gzipBytes compresses data with gzip
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to compress transcript: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress transcript: %w", err)
+	}
+	return buf.Bytes(), nil
+}
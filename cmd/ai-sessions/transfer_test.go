@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadClientSendSingle(t *testing.T) {
+	tests := []struct {
+		name         string
+		capabilities string // Response to /api/cli/capabilities; empty for none
+		acceptsGzip  bool
+		wantGzip     bool
+		wantRequests int
+	}{
+		{name: "gzip", capabilities: `{"gzipUploads":true}`, acceptsGzip: true, wantGzip: true, wantRequests: 1},
+		{name: "gzip rejected", capabilities: `{"gzipUploads":true}`, wantRequests: 2},
+		{name: "no capabilities", wantRequests: 1},
+	}
+	for _, tc := range tests {
+		var received UploadRequest
+		var gzipped bool
+		requests := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/cli/capabilities" {
+				if tc.capabilities == "" {
+					http.NotFound(w, r)
+					return
+				}
+				io.WriteString(w, tc.capabilities)
+				return
+			}
+			requests++
+			body := io.Reader(r.Body)
+			gzipped = r.Header.Get("Content-Encoding") == "gzip"
+			if gzipped {
+				if !tc.acceptsGzip {
+					w.WriteHeader(http.StatusUnsupportedMediaType)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "UnsupportedMediaType"})
+					return
+				}
+				zr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					t.Fatalf("invalid gzip body: %v", err)
+				}
+				body = zr
+			}
+			if err := json.NewDecoder(body).Decode(&received); err != nil {
+				t.Fatalf("invalid request body: %v", err)
+			}
+			json.NewEncoder(w).Encode(UploadResponse{ID: "t1", URL: "https://aisessions.dev/t/t1"})
+		}))
+
+		client := &uploadClient{client: server.Client(), apiURL: server.URL, token: "tok"}
+		resp, err := client.send([]byte(`{"type":"user"}`), "My session")
+		if err != nil {
+			t.Fatalf("send failed (%s): %v", tc.name, err)
+		}
+		if resp.ID != "t1" || received.FileData != `{"type":"user"}` || received.Title != "My session" {
+			t.Fatalf("unexpected upload (%s): %+v, %+v", tc.name, resp, received)
+		}
+		if requests != tc.wantRequests || gzipped != tc.wantGzip {
+			t.Fatalf("expected %d requests, gzip %v (%s), got %d, gzip %v", tc.wantRequests, tc.wantGzip, tc.name, requests, gzipped)
+		}
+
+		// Servers without chunked uploads keep the single request limit
+		if tc.capabilities == "" {
+			requests = 0
+			_, err := client.send(bytes.Repeat([]byte("x"), maxSingleUploadSize+1), "big")
+			if err == nil || !strings.Contains(err.Error(), "exceeds the 5 MB limit") || requests != 0 {
+				t.Fatalf("expected a size error without uploading, got %v after %d requests", err, requests)
+			}
+		}
+		server.Close()
+	}
+}
+
+func TestUploadClientSendChunkedResumes(t *testing.T) {
+	var assembled []byte
+	failNextPut := true
+	var gets, starts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/api/cli/upload/chunked":
+			starts++
+			json.NewEncoder(w).Encode(chunkedUpload{ID: "u1", ChunkSize: 64})
+		case r.Method == "GET" && r.URL.Path == "/api/cli/upload/chunked/u1":
+			gets++
+			json.NewEncoder(w).Encode(chunkedUpload{ID: "u1", Offset: len(assembled), ChunkSize: 64})
+		case r.Method == "PUT" && r.URL.Path == "/api/cli/upload/chunked/u1":
+			if len(assembled) > 0 && failNextPut {
+				failNextPut = false
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			var start, end, total int
+			fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total)
+			chunk, _ := io.ReadAll(r.Body)
+			if start != len(assembled) || end-start+1 != len(chunk) {
+				t.Fatalf("unexpected chunk %s of %d bytes", r.Header.Get("Content-Range"), len(chunk))
+			}
+			assembled = append(assembled, chunk...)
+			json.NewEncoder(w).Encode(chunkedUpload{ID: "u1", Offset: len(assembled)})
+		case r.Method == "POST" && r.URL.Path == "/api/cli/upload/chunked/u1/complete":
+			json.NewEncoder(w).Encode(UploadResponse{ID: "t1", URL: "https://aisessions.dev/t/t1"})
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	var data bytes.Buffer
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&data, `{"type":"user","n":%d,"text":"%s"}`+"\n", i, strings.Repeat(string(rune('a'+i%26)), i))
+	}
+	client := &uploadClient{client: server.Client(), apiURL: server.URL, token: "tok", statePath: filepath.Join(t.TempDir(), uploadStateFile)}
+
+	if _, err := client.sendChunked(data.Bytes(), "big"); err == nil {
+		t.Fatal("expected the interrupted upload to fail")
+	}
+	resp, err := client.sendChunked(data.Bytes(), "big")
+	if err != nil {
+		t.Fatalf("resumed upload failed: %v", err)
+	}
+	if resp.ID != "t1" || starts != 1 || gets != 1 {
+		t.Fatalf("expected the upload to be resumed, got %+v after %d starts and %d lookups", resp, starts, gets)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(assembled))
+	if err != nil {
+		t.Fatalf("assembled upload isn't gzip: %v", err)
+	}
+	if got, _ := io.ReadAll(zr); !bytes.Equal(got, data.Bytes()) {
+		t.Fatal("assembled upload differs from the transcript")
+	}
+	if state := client.loadState(); len(state) != 0 {
+		t.Fatalf("expected the finished upload to be forgotten, got %v", state)
+	}
+}
+
+func TestResponseError(t *testing.T) {
+	if err, ok := responseError(http.StatusUnauthorized, []byte(`{"error":"Unauthorized","message":"Token expired"}`)).(*AuthError); !ok || err.Message != "Unauthorized: Token expired" {
+		t.Fatalf("expected an auth error, got %#v", err)
+	}
+	err, ok := responseError(http.StatusInternalServerError, []byte("oops")).(*UploadError)
+	if !ok || err.StatusCode != 500 || err.Message != "Status 500: oops" {
+		t.Fatalf("expected an upload error, got %#v", err)
+	}
+}
+
+func TestUploadClientRetries(t *testing.T) {
+	failures := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if len(failures) > 0 {
+			status := failures[0]
+			failures = failures[1:]
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "5")
+			}
+			w.WriteHeader(status)
+			return
+		}
+		if r.Header.Get("Content-Encoding") == "" {
+			t.Error("expected the retried request to be sent again in full")
+		}
+		json.NewEncoder(w).Encode(UploadResponse{ID: "t1"})
+	}))
+	defer server.Close()
+
+	var waits []time.Duration
+	client := &uploadClient{client: server.Client(), apiURL: server.URL, retries: 3, sleep: func(d time.Duration) { waits = append(waits, d) },
+		capabilities: &serverCapabilities{GzipUploads: true}}
+	if _, err := client.send([]byte("{}"), ""); err != nil {
+		t.Fatalf("expected the upload to succeed after retries: %v", err)
+	}
+	if requests != 3 || len(waits) != 2 || waits[0] != time.Second || waits[1] != 5*time.Second {
+		t.Fatalf("expected 2 retries waiting 1s then the 5s asked for, got %d requests and waits %v", requests, waits)
+	}
+
+	// Errors that would fail again aren't retried
+	failures = []int{http.StatusBadRequest}
+	requests, waits = 0, nil
+	err := func() error { _, err := client.send([]byte("{}"), ""); return err }()
+	if uploadErr, ok := err.(*UploadError); !ok || uploadErr.Retryable() || requests != 1 || len(waits) != 0 {
+		t.Fatalf("expected a single fatal attempt, got %v after %d requests", err, requests)
+	}
+
+	// Unreachable servers are retried until giving up
+	server.Close()
+	waits = nil
+	client.retries = 2
+	_, err = client.send([]byte("{}"), "")
+	if uploadErr, ok := err.(*UploadError); !ok || uploadErr.StatusCode != 0 || !strings.Contains(err.Error(), "gave up after 3 attempts") || len(waits) != 2 || waits[1] != 2*time.Second {
+		t.Fatalf("expected to give up after 3 attempts with backoff, got %v and waits %v", err, waits)
+	}
+}
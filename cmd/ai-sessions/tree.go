@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Tool 17: get_session_tree
+type getSessionTreeArgs struct {
+	SessionID string `json:"session_id" jsonschema:"The session ID to inspect"`
+	Source    string `json:"source" jsonschema:"The source that created this session (claude, gemini, codex, opencode)"`
+}
+
+func addGetSessionTreeTool(server *mcp.Server, adaptersMap map[string]adapters.SessionAdapter) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "get_session_tree",
+		Description: "Show the branch structure of a conversation (e.g., Claude Code sessions where earlier messages were edited or rewound). Pass a branch's leaf_uuid to get_session to read just that branch.",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args getSessionTreeArgs) (*mcp.CallToolResult, any, error) {
+		messages, err := loadSessionMessages(adaptersMap, args.Source, args.SessionID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return jsonToolResult(map[string]interface{}{
+			"session_id": args.SessionID,
+			"source":     args.Source,
+			"tree":       analysis.BuildTree(messages),
+		})
+	})
+}
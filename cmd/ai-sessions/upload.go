@@ -1,17 +1,16 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/briandowns/spinner"
 	"github.com/manifoldco/promptui"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/redact"
 )
 
 // UploadRequest represents the request body for CLI upload
@@ -42,140 +41,182 @@ func (e *AuthError) Error() string {
 	return e.Message
 }
 
-// uploadFile uploads a transcript file to the AI Sessions API
-func uploadFile(apiURL, token, filePath, title string) error {
+// uploadOptions are the choices of how a transcript is uploaded
+type uploadOptions struct {
+	Title     string // Defaults to the file name
+	NoRedact  bool   // Skip looking for secrets and personal details to redact
+	Anonymize bool   // Replace identifying names and addresses with placeholders
+
+	Timeout time.Duration // Limit on each request to the API
+	Retries int           // Times a request that failed for a temporary reason is retried
+
+	Range *messageRange // Messages of a session to upload; nil for all of them
+
+	Profile string // Login profile to upload with; empty for the default one
+
+	// Unattended redacts every secret found and skips the confirmation, for uploads
+	// without anyone at a terminal, such as from the MCP server
+	Unattended bool
+}
+
+// messageRange is a range of message indexes, counted from 0 and inclusive. A negative
+// To is the last message.
+type messageRange struct {
+	From, To int
+}
+
+// apply returns the messages in the range
+func (r messageRange) apply(messages []adapters.Message) ([]adapters.Message, error) {
+	to := r.To
+	if to < 0 {
+		to = len(messages) - 1
+	}
+	if r.From < 0 || r.From > to || to >= len(messages) {
+		return nil, fmt.Errorf("invalid message range %d-%d: the session has messages 0-%d", r.From, to, len(messages)-1)
+	}
+	return messages[r.From : to+1], nil
+}
+
+// defaultUploadOptions are the options of an upload without flags
+var defaultUploadOptions = uploadOptions{Timeout: defaultUploadTimeout, Retries: defaultUploadRetries}
+
+// uploadFile uploads a transcript file to the AI Sessions API, writing its progress and
+// prompts to w
+func uploadFile(apiURL, token, filePath string, opts uploadOptions, w io.Writer) (*UploadResponse, error) {
 	// Read the file
 	fileData, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return nil, fmt.Errorf("failed to read file: %w", err)
 	}
+	return uploadTranscript(apiURL, token, filePath, fileData, opts, w)
+}
 
-	// Check file size (5MB limit)
-	const maxSize = 5 * 1024 * 1024 // 5MB
-	if len(fileData) > maxSize {
-		fmt.Println()
-		fmt.Printf("\033[31m✗ Error:\033[0m File size (%.2f MB) exceeds the 5MB limit\n", float64(len(fileData))/1024/1024)
-		fmt.Println()
-		return fmt.Errorf("file too large")
+// uploadTranscript uploads transcript data to the AI Sessions API, after anonymizing it
+// if asked to and reviewing the secrets and personal details to redact from it.
+// filePath names the transcript in progress messages and gives its default title.
+func uploadTranscript(apiURL, token, filePath string, fileData []byte, opts uploadOptions, w io.Writer) (*UploadResponse, error) {
+	client, err := newUploadClient(apiURL, token, opts.Timeout, opts.Retries)
+	if err != nil {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "\033[31m✗ Upload Failed:\033[0m %v\n", err)
+		fmt.Fprintln(w)
+		return nil, err
+	}
+	// Servers without chunked uploads take transcripts of up to maxSingleUploadSize
+	if limit := client.maxUploadSize(); len(fileData) > limit {
+		fmt.Fprintln(w)
+		fmt.Fprintf(w, "\033[31m✗ Error:\033[0m File size (%.2f MB) exceeds the %d MB limit\n", float64(len(fileData))/1024/1024, limit/1024/1024)
+		fmt.Fprintln(w)
+		return nil, fmt.Errorf("file too large")
 	}
 
-	// Show data responsibility notice
-	fmt.Println()
-	fmt.Println("\033[33m⚠ Data Responsibility Notice\033[0m")
-	fmt.Println("\033[2mYou are responsible for ensuring that the transcript does not contain")
-	fmt.Println("private or sensitive information before uploading. While we scan for")
-	fmt.Println("common patterns, you should review the content yourself.\033[0m")
-	fmt.Println()
-
-	// Confirm with promptui
-	prompt := promptui.Prompt{
-		Label:     "Continue with upload",
-		IsConfirm: true,
+	var anonymizer *redact.Anonymizer
+	if opts.Anonymize {
+		anonymizer = redact.NewAnonymizer()
+		fileData = []byte(anonymizer.Anonymize(string(fileData)))
+	}
+	if !opts.NoRedact {
+		review := promptRedaction
+		if opts.Unattended {
+			review = func(string, redactionGroup, int, int, io.Writer) (string, error) { return redactRemaining, nil }
+		}
+		redacted, count, err := redactTranscript(string(fileData), w, review)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			fmt.Fprintf(w, "\033[32m✓\033[0m Redacted %d values\n", count)
+			fileData = []byte(redacted)
+		}
 	}
 
-	_, err = prompt.Run()
-	if err != nil {
-		// This handles 'n', 'N', Ctrl+C, etc.
-		return fmt.Errorf("upload cancelled")
+	if !opts.Unattended {
+		// Show data responsibility notice
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "\033[33m⚠ Data Responsibility Notice\033[0m")
+		fmt.Fprintln(w, "\033[2mYou are responsible for ensuring that the transcript does not contain")
+		fmt.Fprintln(w, "private or sensitive information before uploading. While we scan for")
+		fmt.Fprintln(w, "common patterns, you should review the content yourself.\033[0m")
+		fmt.Fprintln(w)
+
+		// Confirm with promptui
+		prompt := promptui.Prompt{
+			Label:     "Continue with upload",
+			IsConfirm: true,
+			Stdout:    nopWriteCloser{w},
+		}
+
+		if _, err := prompt.Run(); err != nil {
+			// This handles 'n', 'N', Ctrl+C, etc.
+			return nil, fmt.Errorf("upload cancelled")
+		}
 	}
 
 	// If no title provided, use filename without extension
+	title := opts.Title
 	if title == "" {
 		title = getDefaultTitle(filePath)
 	}
-
-	// Prepare request body
-	uploadReq := UploadRequest{
-		FileData: string(fileData),
-		Title:    title,
+	// Titles taken from a first message can hold the same details as the transcript
+	if anonymizer != nil {
+		title = anonymizer.Anonymize(title)
 	}
-
-	requestBody, err := json.Marshal(uploadReq)
-	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+	if !opts.NoRedact {
+		title = redact.Apply(title, redact.Find(title))
 	}
 
-	// Create HTTP request
-	uploadURL := apiURL + "/api/cli/upload"
-	req, err := http.NewRequest("POST", uploadURL, bytes.NewReader(requestBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
-	}
-
-	// Set headers
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+token)
-
 	// Create and start spinner
-	fmt.Println()
+	fmt.Fprintln(w)
 	s := spinner.New(spinner.CharSets[14], 100*time.Millisecond)
-	s.Suffix = fmt.Sprintf("  Uploading \033[36m%s\033[0m (%.2f KB)", filepath.Base(filePath), float64(len(fileData))/1024)
+	s.Writer = w
+	suffix := fmt.Sprintf("  Uploading \033[36m%s\033[0m (%.2f KB)", filepath.Base(filePath), float64(len(fileData))/1024)
+	s.Suffix = suffix
 	s.Start()
 
-	// Send request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	client.progress = func(sent, total int) {
+		s.Lock()
+		s.Suffix = fmt.Sprintf("%s %d%%", suffix, sent*100/total)
+		s.Unlock()
+	}
+	client.retrying = func(attempt int, wait time.Duration, err error) {
+		s.Lock()
+		s.Suffix = fmt.Sprintf("%s \033[33m(%v; retry %d of %d in %v)\033[0m", suffix, err, attempt, opts.Retries, wait)
+		s.Unlock()
+	}
+	uploadResp, err := client.send(fileData, title)
 
 	// Stop spinner
 	s.Stop()
 
 	if err != nil {
-		fmt.Println()
-		fmt.Printf("\033[31m✗ Upload Failed:\033[0m %v\n", err)
-		fmt.Println()
-		return fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	// Read response body
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
-	}
-
-	// Handle error responses
-	if resp.StatusCode != http.StatusOK {
-		var errResp ErrorResponse
-		if err := json.Unmarshal(responseBody, &errResp); err == nil {
-			// Special handling for authentication errors (401)
-			if resp.StatusCode == http.StatusUnauthorized {
-				fmt.Println()
-				fmt.Printf("\033[31m✗ Authentication Error:\033[0m %s\n", errResp.Message)
-				fmt.Println()
-				return &AuthError{Message: fmt.Sprintf("%s: %s", errResp.Error, errResp.Message)}
-			}
-			fmt.Println()
-			if errResp.Message != "" {
-				fmt.Printf("\033[31m✗ Upload Failed:\033[0m %s: %s\n", errResp.Error, errResp.Message)
-			} else {
-				fmt.Printf("\033[31m✗ Upload Failed:\033[0m %s\n", errResp.Error)
-			}
-			fmt.Println()
-			return fmt.Errorf("upload failed")
+		fmt.Fprintln(w)
+		if _, ok := err.(*AuthError); ok {
+			fmt.Fprintf(w, "\033[31m✗ Authentication Error:\033[0m %v\n", err)
+		} else {
+			fmt.Fprintf(w, "\033[31m✗ Upload Failed:\033[0m %v\n", err)
 		}
-		fmt.Println()
-		fmt.Printf("\033[31m✗ Upload Failed:\033[0m Status %d: %s\n", resp.StatusCode, string(responseBody))
-		fmt.Println()
-		return fmt.Errorf("upload failed")
-	}
-
-	// Parse success response
-	var uploadResp UploadResponse
-	if err := json.Unmarshal(responseBody, &uploadResp); err != nil {
-		return fmt.Errorf("failed to parse response: %w", err)
+		fmt.Fprintln(w)
+		return nil, err
 	}
 
 	// Display success message
-	fmt.Println()
-	fmt.Println("\033[32m✓ Upload successful!\033[0m")
-	fmt.Println()
-	fmt.Printf("\033[2mView your transcript at:\033[0m\n")
-	fmt.Printf("\033[36m%s\033[0m\n", uploadResp.URL)
-	fmt.Println()
-
-	return nil
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "\033[32m✓ Upload successful!\033[0m")
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "\033[2mView your transcript at:\033[0m\n")
+	fmt.Fprintf(w, "\033[36m%s\033[0m\n", uploadResp.URL)
+	fmt.Fprintln(w)
+
+	return uploadResp, nil
 }
 
+// nopWriteCloser adds a no-op Close to a writer, for promptui's output
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
 // getDefaultTitle generates a default title from the file path
 func getDefaultTitle(filePath string) string {
 	filename := filepath.Base(filePath)
@@ -9,6 +9,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
 )
 
 func TestGetDefaultTitle(t *testing.T) {
@@ -341,38 +343,17 @@ func TestHandleUploadCommandArgParsing(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			// Test the argument parsing logic
-			var filepath string
-			var title string
-			var apiURL string
-			var fileProvided bool
-
-			args := tc.args
-			if len(args) >= 2 && !strings.HasPrefix(args[1], "--") {
-				filepath = args[1]
-				fileProvided = true
+			var options globalOptions
+			fs, _ := uploadCommand.flagSet(&options)
+			args, err := parseInterspersed(fs, tc.args[1:])
+			if err != nil {
+				t.Fatalf("parseInterspersed failed: %v", err)
 			}
-
-			startIdx := 2
-			if !fileProvided {
-				startIdx = 1
-			}
-			for i := startIdx; i < len(args); i++ {
-				switch args[i] {
-				case "--title":
-					if i+1 >= len(args) {
-						t.Fatal("--title requires a value")
-					}
-					title = args[i+1]
-					i++
-				case "--url":
-					if i+1 >= len(args) {
-						t.Fatal("--url requires a value")
-					}
-					apiURL = args[i+1]
-					i++
-				}
+			filepath := ""
+			if len(args) > 0 {
+				filepath = args[0]
 			}
+			title, apiURL := fs.Lookup("title").Value.String(), options.URL
 
 			if filepath != tc.wantFile {
 				t.Errorf("filepath = %q, want %q", filepath, tc.wantFile)
@@ -503,3 +484,65 @@ func TestUploadFileSizeValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestUploadSessionTarget(t *testing.T) {
+	stub := newStubAdapter(
+		[]adapters.Session{{ID: "s1", Source: "codex", FilePath: "/tmp/rollout-s1.jsonl"}},
+		map[string][]adapters.Message{"s1": {{Role: "user", Content: "Fix the flaky login test"}}},
+	)
+	adaptersMap := map[string]adapters.SessionAdapter{"codex": stub}
+
+	target, err := sessionUploadTarget(adaptersMap, "codex", "s1")
+	if err != nil {
+		t.Fatalf("sessionUploadTarget failed: %v", err)
+	}
+	if target.title != "Fix the flaky login test" || target.args != "--source codex --id s1" || target.fields["session_id"] != "s1" {
+		t.Fatalf("unexpected target: %+v", target)
+	}
+
+	var out strings.Builder
+	for _, args := range [][]string{
+		{"upload", "--source", "codex"},
+		{"upload", "--id", "s1"},
+		{"upload", "session.jsonl", "--source", "codex", "--id", "s1"},
+		{"upload", "--source", "cursor", "--id", "s1"},
+	} {
+		if err := runTestCLI(adaptersMap, nil, &out, args...); err == nil {
+			t.Errorf("expected an error for %v", args)
+		}
+	}
+}
+
+func TestMessageRange(t *testing.T) {
+	messages := []adapters.Message{{Content: "0"}, {Content: "1"}, {Content: "2"}, {Content: "3"}}
+	tests := []struct {
+		r       messageRange
+		want    string
+		wantErr bool
+	}{
+		{messageRange{From: 1, To: 2}, "12", false},
+		{messageRange{From: 2, To: -1}, "23", false},
+		{messageRange{From: 3, To: 3}, "3", false},
+		{messageRange{From: 3, To: 1}, "", true},
+		{messageRange{From: 0, To: 4}, "", true},
+	}
+	for _, tc := range tests {
+		got, err := tc.r.apply(messages)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("apply(%+v) error = %v, want error %v", tc.r, err, tc.wantErr)
+			continue
+		}
+		var contents string
+		for _, msg := range got {
+			contents += msg.Content
+		}
+		if contents != tc.want {
+			t.Errorf("apply(%+v) = %q, want %q", tc.r, contents, tc.want)
+		}
+	}
+
+	var out strings.Builder
+	if err := runTestCLI(nil, nil, &out, "upload", "session.jsonl", "--from", "2"); err == nil || !strings.Contains(err.Error(), "--source and --id") {
+		t.Fatalf("expected --from to require a session, got %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/manifoldco/promptui"
+)
+
+// remoteTranscript is a transcript uploaded to aisessions.dev
+type remoteTranscript struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// transcriptList is the response listing the user's transcripts
+type transcriptList struct {
+	Transcripts []remoteTranscript `json:"transcripts"`
+}
+
+// renameRequest changes the title of an uploaded transcript
+type renameRequest struct {
+	Title string `json:"title"`
+}
+
+// listTranscripts returns the transcripts the user uploaded, newest first
+func (c *uploadClient) listTranscripts() ([]remoteTranscript, error) {
+	var list transcriptList
+	if err := c.do("GET", "/api/cli/transcripts", nil, nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Transcripts, nil
+}
+
+// deleteTranscript deletes an uploaded transcript
+func (c *uploadClient) deleteTranscript(id string) error {
+	return c.do("DELETE", "/api/cli/transcripts/"+url.PathEscape(id), nil, nil, nil)
+}
+
+// renameTranscript changes the title of an uploaded transcript and returns it
+func (c *uploadClient) renameTranscript(id, title string) (*remoteTranscript, error) {
+	body, err := json.Marshal(renameRequest{Title: title})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	var transcript remoteTranscript
+	if err := c.do("PATCH", "/api/cli/transcripts/"+url.PathEscape(id), body, map[string]string{"Content-Type": "application/json"}, &transcript); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+var uploadsCommand = cliCommand{
+	name:    "uploads",
+	args:    "<list|delete|rename> [id]",
+	summary: "List, delete, or rename the transcripts you uploaded",
+	minArgs: 1,
+	maxArgs: 2,
+	json:    true,
+	setup: func(fs *flag.FlagSet) cliRunFunc {
+		var opts uploadsOptions
+		fs.StringVar(&opts.Title, "title", "", "with rename, the new `title`")
+		fs.BoolVar(&opts.Yes, "yes", false, "with delete, don't ask for confirmation")
+		fs.BoolVar(&opts.Yes, "y", false, "")
+		profileFlagVar(fs, &opts.Profile)
+		return func(env *cliEnv, args []string) error {
+			opts.JSON = env.options.JSON
+			config, err := loadConfig(opts.Profile)
+			if err != nil {
+				return err
+			}
+			apiURL, err := resolveAPIURL(env.options.URL, config)
+			if err != nil {
+				return err
+			}
+			client, err := newUploadClient(apiURL, config.Token, defaultUploadTimeout, defaultUploadRetries)
+			if err != nil {
+				return err
+			}
+			err = runUploadsCommand(client, args, opts, env.stdout, env.stderr)
+			if _, ok := err.(*AuthError); ok {
+				return fmt.Errorf("%w (run 'aisessions login' to re-authenticate)", err)
+			}
+			return err
+		}
+	},
+}
+
+// uploadsOptions are the options of `aisessions uploads`
+type uploadsOptions struct {
+	Title string
+	Yes   bool // Delete without confirmation
+	JSON  bool
+
+	Profile string // Login profile whose uploads to manage; empty for the default one
+}
+
+// runUploadsCommand runs the list, delete, or rename action of `aisessions uploads`.
+// Confirmation prompts go to stderr.
+func runUploadsCommand(client *uploadClient, args []string, opts uploadsOptions, stdout, stderr io.Writer) error {
+	action := args[0]
+	var id string
+	if len(args) > 1 {
+		id = args[1]
+	}
+	switch {
+	case action == "list" && id != "":
+		return fmt.Errorf("usage: aisessions uploads list")
+	case (action == "delete" || action == "rename") && id == "":
+		return fmt.Errorf("usage: aisessions uploads %s <id>", action)
+	}
+
+	switch action {
+	case "list":
+		transcripts, err := client.listTranscripts()
+		if err != nil {
+			return err
+		}
+		if opts.JSON {
+			if transcripts == nil {
+				transcripts = []remoteTranscript{}
+			}
+			return printJSON(stdout, transcripts)
+		}
+		if len(transcripts) == 0 {
+			fmt.Fprintln(stdout, "No uploaded transcripts")
+			return nil
+		}
+		timeWidth := cliTimes.columnWidth()
+		fmt.Fprintf(stdout, "%-14s %-*s %-40s %s\n", "ID", timeWidth, "UPLOADED", "TITLE", "URL")
+		for _, t := range transcripts {
+			fmt.Fprintf(stdout, "%-14s %-*s %-40s %s\n", t.ID, timeWidth, formatTime(t.CreatedAt), truncateString(t.Title, 40), t.URL)
+		}
+		return nil
+
+	case "delete":
+		if !opts.Yes {
+			if opts.JSON {
+				return fmt.Errorf("--json requires --yes to delete, confirmation is interactive")
+			}
+			prompt := promptui.Prompt{
+				Label:     fmt.Sprintf("Delete transcript %s", id),
+				IsConfirm: true,
+				Stdout:    nopWriteCloser{stderr},
+			}
+			if _, err := prompt.Run(); err != nil {
+				return fmt.Errorf("delete cancelled")
+			}
+		}
+		if err := client.deleteTranscript(id); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(stdout, map[string]interface{}{"id": id, "deleted": true})
+		}
+		fmt.Fprintf(stdout, "Deleted transcript %s\n", id)
+		return nil
+
+	case "rename":
+		if opts.Title == "" {
+			return fmt.Errorf("rename needs the new title: aisessions uploads rename %s --title <title>", id)
+		}
+		transcript, err := client.renameTranscript(id, opts.Title)
+		if err != nil {
+			return err
+		}
+		if transcript.ID == "" {
+			transcript.ID = id
+		}
+		if transcript.Title == "" {
+			transcript.Title = opts.Title
+		}
+		if opts.JSON {
+			return printJSON(stdout, transcript)
+		}
+		fmt.Fprintf(stdout, "Renamed transcript %s to %q\n", transcript.ID, transcript.Title)
+		return nil
+	}
+	return fmt.Errorf("unknown action: %s (use list, delete, or rename)", action)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunUploadsCommand(t *testing.T) {
+	transcripts := map[string]*remoteTranscript{
+		"t1": {ID: "t1", Title: "Fix login", URL: "https://aisessions.dev/t/t1", CreatedAt: time.Now().Add(-time.Hour)},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unauthorized"})
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, "/api/cli/transcripts/")
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/api/cli/transcripts":
+			var list transcriptList
+			for _, t := range transcripts {
+				list.Transcripts = append(list.Transcripts, *t)
+			}
+			json.NewEncoder(w).Encode(list)
+		case transcripts[id] == nil:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "NotFound", Message: "No such transcript"})
+		case r.Method == "DELETE":
+			delete(transcripts, id)
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == "PATCH":
+			var req renameRequest
+			json.NewDecoder(r.Body).Decode(&req)
+			transcripts[id].Title = req.Title
+			json.NewEncoder(w).Encode(transcripts[id])
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+	client := &uploadClient{client: server.Client(), apiURL: server.URL, token: "tok"}
+
+	run := func(opts uploadsOptions, args ...string) (string, error) {
+		var out strings.Builder
+		err := runUploadsCommand(client, args, opts, &out, &out)
+		return out.String(), err
+	}
+
+	out, err := run(uploadsOptions{}, "list")
+	if err != nil || !strings.Contains(out, "t1") || !strings.Contains(out, "Fix login") || !strings.Contains(out, "1 hour ago") {
+		t.Fatalf("unexpected list output %q (%v)", out, err)
+	}
+
+	out, err = run(uploadsOptions{Title: "Fix OAuth login", JSON: true}, "rename", "t1")
+	var renamed remoteTranscript
+	if err != nil || json.Unmarshal([]byte(out), &renamed) != nil || renamed.Title != "Fix OAuth login" {
+		t.Fatalf("unexpected rename output %q (%v)", out, err)
+	}
+	if _, err := run(uploadsOptions{}, "rename", "t1"); err == nil {
+		t.Fatal("expected rename without --title to fail")
+	}
+
+	if _, err := run(uploadsOptions{JSON: true}, "delete", "t1"); err == nil {
+		t.Fatal("expected delete with --json to need --yes")
+	}
+	if out, err := run(uploadsOptions{Yes: true}, "delete", "t1"); err != nil || !strings.Contains(out, "Deleted transcript t1") || len(transcripts) != 0 {
+		t.Fatalf("unexpected delete output %q (%v)", out, err)
+	}
+	if _, err := run(uploadsOptions{Yes: true}, "delete", "t1"); err == nil || !strings.Contains(err.Error(), "No such transcript") {
+		t.Fatalf("expected deleting a missing transcript to fail, got %v", err)
+	}
+
+	out, err = run(uploadsOptions{JSON: true}, "list")
+	if err != nil || strings.TrimSpace(out) != "[]" {
+		t.Fatalf("expected an empty JSON list, got %q (%v)", out, err)
+	}
+
+	for _, args := range [][]string{{"delete"}, {"list", "t1"}, {"share", "t1"}} {
+		if _, err := run(uploadsOptions{Yes: true}, args...); err == nil {
+			t.Errorf("expected %v to fail", args)
+		}
+	}
+
+	client.token = "revoked"
+	if _, err := run(uploadsOptions{}, "list"); err == nil {
+		t.Fatal("expected a rejected token to fail")
+	} else if _, ok := err.(*AuthError); !ok {
+		t.Fatalf("expected an auth error, got %#v", err)
+	}
+}
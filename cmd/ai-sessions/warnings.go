@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// maxWarnings caps the warnings of a response, so a source whose every session fails to
+// parse doesn't bury the results
+const maxWarnings = 20
+
+// resultWarning is a problem that left sessions out of a response, such as a source that
+// failed to list its sessions or a session that could not be read. Warnings are returned
+// to the client instead of only being logged, so missing sessions are noticed.
+type resultWarning struct {
+	Source    string `json:"source"`
+	SessionID string `json:"session_id,omitempty"` // Set when one session failed rather than its whole source
+	Operation string `json:"operation"`            // list, index, or read
+	Reason    string `json:"reason"`
+}
+
+func (w resultWarning) String() string {
+	if w.SessionID != "" {
+		return fmt.Sprintf("could not %s %s session %s: %s", w.Operation, w.Source, w.SessionID, w.Reason)
+	}
+	return fmt.Sprintf("could not %s %s sessions: %s", w.Operation, w.Source, w.Reason)
+}
+
+// warningCollector gathers the warnings of a response from sources handled concurrently.
+// A nil collector drops them.
+type warningCollector struct {
+	mu       sync.Mutex
+	warnings []resultWarning
+	omitted  int // Warnings past maxWarnings
+}
+
+func (c *warningCollector) add(w resultWarning) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Failed sources are few, and always reported
+	if w.SessionID != "" && len(c.warnings) >= maxWarnings {
+		c.omitted++
+		return
+	}
+	c.warnings = append(c.warnings, w)
+}
+
+// addSourceErrors adds a warning for each source that failed an operation
+func (c *warningCollector) addSourceErrors(operation string, failures []sourceError) {
+	for _, failure := range failures {
+		c.add(resultWarning{Source: failure.Source, Operation: operation, Reason: failure.Error})
+	}
+}
+
+// list returns the warnings by source, the failures of whole sources first, and how
+// many were left out past maxWarnings
+func (c *warningCollector) list() ([]resultWarning, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	warnings := append([]resultWarning(nil), c.warnings...)
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Source != warnings[j].Source {
+			return warnings[i].Source < warnings[j].Source
+		}
+		return warnings[i].SessionID == "" && warnings[j].SessionID != ""
+	})
+	return warnings, c.omitted
+}
+
+// addTo adds the warnings to a tool result, if there are any
+func (c *warningCollector) addTo(result map[string]interface{}) {
+	warnings, omitted := c.list()
+	if len(warnings) == 0 {
+		return
+	}
+	result["warnings"] = warnings
+	if omitted > 0 {
+		result["warnings_omitted"] = omitted
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSearchSessionsWarnings(t *testing.T) {
+	cache := newTestCache(t)
+	dir := t.TempDir()
+	session := func(id string) adapters.Session {
+		path := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(path, []byte("dummy"), 0o644); err != nil {
+			t.Fatalf("failed to create session file: %v", err)
+		}
+		return adapters.Session{ID: id, Source: "claude", Timestamp: time.Now(), FilePath: path}
+	}
+	// The second session has no messages, so reading it fails
+	claude := newStubAdapter([]adapters.Session{session("good"), session("unreadable")}, map[string][]adapters.Message{
+		"good": {{Role: "user", Content: "the deploy is stuck"}},
+	})
+	codex := newStubAdapter(nil, nil)
+	codex.listErr = errors.New("permission denied")
+	adaptersMap := map[string]adapters.SessionAdapter{"claude": claude, "codex": codex}
+
+	result, err := searchSessions(context.Background(), adaptersMap, cache, searchSessionsArgs{Query: "deploy"})
+	if err != nil {
+		t.Fatalf("searchSessions failed: %v", err)
+	}
+	if result["count"] != 1 {
+		t.Fatalf("expected the readable session to be found, got %v", result["count"])
+	}
+	warnings, _ := result["warnings"].([]resultWarning)
+	if len(warnings) != 2 {
+		t.Fatalf("expected two warnings, got %+v", result["warnings"])
+	}
+	if w := warnings[0]; w.Source != "claude" || w.SessionID != "unreadable" || w.Operation != "read" || !strings.Contains(w.Reason, "unknown session") {
+		t.Fatalf("unexpected session warning: %+v", w)
+	}
+	if w := warnings[1]; w.Source != "codex" || w.SessionID != "" || w.Operation != "index" || w.Reason != "permission denied" {
+		t.Fatalf("unexpected source warning: %+v", w)
+	}
+
+	var out bytes.Buffer
+	if err := runTestCLI(adaptersMap, cache, &out, "search", "deploy"); err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if !strings.Contains(out.String(), "Warning: could not index codex sessions: permission denied") {
+		t.Fatalf("expected the failed source to be reported:\n%s", out.String())
+	}
+}
+
+func TestWarningCollectorCapsSessionWarnings(t *testing.T) {
+	warnings := &warningCollector{}
+	for i := 0; i < maxWarnings+5; i++ {
+		warnings.add(resultWarning{Source: "claude", SessionID: "s", Operation: "read", Reason: "bad"})
+	}
+	warnings.addSourceErrors("list", []sourceError{{Source: "codex", Error: "timeout"}})
+
+	result := map[string]interface{}{}
+	warnings.addTo(result)
+	list := result["warnings"].([]resultWarning)
+	if len(list) != maxWarnings+1 || result["warnings_omitted"] != 5 {
+		t.Fatalf("expected %d warnings and 5 omitted, got %d and %v", maxWarnings+1, len(list), result["warnings_omitted"])
+	}
+	if list[len(list)-1].Source != "codex" {
+		t.Fatalf("expected the failed source to be kept, got %+v", list[len(list)-1])
+	}
+
+	var none *warningCollector
+	none.add(resultWarning{Source: "claude"})
+	empty := map[string]interface{}{}
+	(&warningCollector{}).addTo(empty)
+	if len(empty) != 0 {
+		t.Fatalf("expected no warnings, got %v", empty)
+	}
+}
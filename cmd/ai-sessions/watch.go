@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+const (
+	// recentSessionsURI is the resource listing recent sessions, which clients can
+	// subscribe to to hear about new and updated sessions
+	recentSessionsURI = "sessions://recent"
+
+	// sessionWatchInterval is how often new and updated sessions are looked for, once a
+	// client watches them
+	sessionWatchInterval = 10 * time.Second
+
+	// watchedSessions is the number of recent sessions of each source that are watched
+	watchedSessions = 20
+
+	// maxSessionChanges caps the changes kept for watch_sessions
+	maxSessionChanges = 200
+
+	// maxWatchWait caps how long watch_sessions waits for a change
+	maxWatchWait = time.Minute
+)
+
+// Kinds of session changes
+const (
+	sessionCreated = "created"
+	sessionUpdated = "updated"
+)
+
+// sessionChange is a session that appeared or was written to while the server watched
+type sessionChange struct {
+	Cursor     int64            `json:"cursor"`
+	Change     string           `json:"change"` // created or updated
+	Session    adapters.Session `json:"session"`
+	DetectedAt time.Time        `json:"detected_at"`
+}
+
+// sessionWatcher polls the recent sessions of every source for new sessions and
+// sessions whose files changed. It starts on first use, so servers whose clients never
+// watch sessions don't list them in the background.
+type sessionWatcher struct {
+	ctx         context.Context // Stops polling
+	adaptersMap map[string]adapters.SessionAdapter
+	notify      func(ctx context.Context) // Called after changes are found
+	start       sync.Once
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time // By source and ID; nil until the first poll
+	changes  []sessionChange      // Oldest first
+	cursor   int64                // Cursor of the last change
+	changed  chan struct{}        // Closed, and replaced, when changes are found
+}
+
+func newSessionWatcher(ctx context.Context, adaptersMap map[string]adapters.SessionAdapter, notify func(ctx context.Context)) *sessionWatcher {
+	return &sessionWatcher{ctx: ctx, adaptersMap: adaptersMap, notify: notify, changed: make(chan struct{})}
+}
+
+// ensureRunning starts polling, unless it already started
+func (w *sessionWatcher) ensureRunning() {
+	w.start.Do(func() {
+		go func() {
+			ticker := time.NewTicker(sessionWatchInterval)
+			defer ticker.Stop()
+			for {
+				if err := w.poll(w.ctx); err != nil {
+					slog.Warn("Failed to watch sessions", "error", err)
+				}
+				select {
+				case <-w.ctx.Done():
+					return
+				case <-ticker.C:
+				}
+			}
+		}()
+	})
+}
+
+// poll lists recent sessions and records the ones that are new or whose files were
+// modified since the last poll. The first poll only records what exists.
+func (w *sessionWatcher) poll(ctx context.Context) error {
+	sessions, failures, err := listSources(ctx, w.adaptersMap, "", "", watchedSessions)
+	if err != nil {
+		return err
+	}
+	for _, failure := range failures {
+		slog.Debug("Failed to list sessions to watch", "source", failure.Source, "error", failure.Error)
+	}
+
+	now := time.Now()
+	w.mu.Lock()
+	baseline := w.modTimes == nil
+	if baseline {
+		w.modTimes = make(map[string]time.Time, len(sessions))
+	}
+	found := 0
+	// Sessions are listed newest first; changes are recorded oldest first
+	for i := len(sessions) - 1; i >= 0; i-- {
+		session := sessions[i]
+		key := session.Source + "/" + session.ID
+		modTime := sessionModTime(session)
+		previous, seen := w.modTimes[key]
+		w.modTimes[key] = modTime
+		if baseline || (seen && !modTime.After(previous)) {
+			continue
+		}
+		change := sessionCreated
+		if seen {
+			change = sessionUpdated
+		}
+		w.cursor++
+		w.changes = append(w.changes, sessionChange{Cursor: w.cursor, Change: change, Session: session, DetectedAt: now})
+		found++
+	}
+	if len(w.changes) > maxSessionChanges {
+		w.changes = append([]sessionChange(nil), w.changes[len(w.changes)-maxSessionChanges:]...)
+	}
+	if found > 0 {
+		close(w.changed)
+		w.changed = make(chan struct{})
+	}
+	w.mu.Unlock()
+
+	if found > 0 && w.notify != nil {
+		w.notify(ctx)
+	}
+	return nil
+}
+
+// changesSince returns the changes after a cursor, the cursor of the last change, and a
+// channel closed when more changes are found
+func (w *sessionWatcher) changesSince(cursor int64) ([]sessionChange, int64, <-chan struct{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changes := []sessionChange{}
+	for _, change := range w.changes {
+		if change.Cursor > cursor {
+			changes = append(changes, change)
+		}
+	}
+	return changes, w.cursor, w.changed
+}
+
+// wait returns the changes after a cursor, waiting up to timeout for one when there are
+// none yet, and the cursor to pass next time
+func (w *sessionWatcher) wait(ctx context.Context, cursor int64, timeout time.Duration) ([]sessionChange, int64) {
+	changes, latest, changed := w.changesSince(cursor)
+	if len(changes) > 0 || timeout <= 0 {
+		return changes, latest
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-changed:
+		changes, latest, _ = w.changesSince(cursor)
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return changes, latest
+}
+
+// subscribe lets a client subscribe to the recent sessions resource
+func (w *sessionWatcher) subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	if req.Params.URI != recentSessionsURI {
+		return fmt.Errorf("unknown resource: %s (only %s can be subscribed to)", req.Params.URI, recentSessionsURI)
+	}
+	w.ensureRunning()
+	return nil
+}
+
+// Tool 26: watch_sessions
+type watchSessionsArgs struct {
+	Cursor      int64 `json:"cursor,omitempty" jsonschema:"The cursor returned by the previous call, to get only the changes since. Leave empty the first time."`
+	WaitSeconds int   `json:"wait_seconds,omitempty" jsonschema:"When nothing changed yet, wait up to this many seconds for a change (at most 60). Leave empty to return at once."`
+}
+
+func addWatchSessionsTool(server *mcp.Server, watcher *sessionWatcher) {
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "watch_sessions",
+		Description: "Report the sessions that started or were updated since the previous call, for workflows like 'summarize what I just did'. Pass the returned cursor to the next call. Sessions are checked every 10 seconds once watched; clients that support resource subscriptions can instead subscribe to " + recentSessionsURI + ".",
+	}, func(ctx context.Context, req *mcp.CallToolRequest, args watchSessionsArgs) (*mcp.CallToolResult, any, error) {
+		if args.WaitSeconds < 0 {
+			return nil, nil, fmt.Errorf("wait_seconds must not be negative")
+		}
+		watcher.ensureRunning()
+		wait := min(time.Duration(args.WaitSeconds)*time.Second, maxWatchWait)
+		changes, cursor := watcher.wait(ctx, args.Cursor, wait)
+		return jsonToolResult(map[string]interface{}{
+			"changes": changes,
+			"count":   len(changes),
+			"cursor":  cursor,
+		})
+	})
+}
+
+// addRecentSessionsResource adds the resource listing recent sessions. Subscribers are
+// notified when sessions start or are updated.
+func addRecentSessionsResource(server *mcp.Server, watcher *sessionWatcher) {
+	server.AddResource(&mcp.Resource{
+		URI:         recentSessionsURI,
+		Name:        "recent-sessions",
+		Description: "The most recent sessions of every source, newest first. Subscribe to be notified when a session starts or is updated.",
+		MIMEType:    "application/json",
+	}, func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		watcher.ensureRunning()
+		sessions, _, err := listSources(ctx, watcher.adaptersMap, "", "", watchedSessions)
+		if err != nil {
+			return nil, err
+		}
+		if sessions == nil {
+			sessions = []adapters.Session{}
+		}
+		data, err := json.MarshalIndent(map[string]interface{}{"sessions": sessions, "count": len(sessions)}, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal sessions: %w", err)
+		}
+		return &mcp.ReadResourceResult{
+			Contents: []*mcp.ResourceContents{{URI: recentSessionsURI, MIMEType: "application/json", Text: string(data)}},
+		}, nil
+	})
+}
+
+// notifySessionsUpdated tells the clients subscribed to the recent sessions resource that
+// it changed
+func notifySessionsUpdated(server *mcp.Server) func(ctx context.Context) {
+	return func(ctx context.Context) {
+		if err := server.ResourceUpdated(ctx, &mcp.ResourceUpdatedNotificationParams{URI: recentSessionsURI}); err != nil {
+			slog.Warn("Failed to notify clients of session changes", "error", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSessionWatcher(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name string, modTime time.Time) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatalf("write session file: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("set modification time: %v", err)
+		}
+		return path
+	}
+	start := time.Now().Add(-time.Hour)
+	stub := newStubAdapter([]adapters.Session{
+		{ID: "old", Source: "stub", Timestamp: start, FilePath: writeFile("old.jsonl", start)},
+	}, nil)
+	notified := 0
+	ctx := context.Background()
+	watcher := newSessionWatcher(ctx, map[string]adapters.SessionAdapter{"stub": stub}, func(context.Context) { notified++ })
+
+	// The first poll only records the existing sessions
+	if err := watcher.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	if changes, cursor := watcher.wait(ctx, 0, 0); len(changes) != 0 || cursor != 0 || notified != 0 {
+		t.Fatalf("expected no changes after the first poll, got %+v (cursor %d, %d notifications)", changes, cursor, notified)
+	}
+
+	// A new session, and an update to an existing one
+	stub.sessions = append(stub.sessions, adapters.Session{ID: "new", Source: "stub", Timestamp: start.Add(time.Minute), FilePath: writeFile("new.jsonl", start)})
+	writeFile("old.jsonl", start.Add(time.Minute))
+	if err := watcher.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	changes, cursor := watcher.wait(ctx, 0, 0)
+	if len(changes) != 2 || cursor != 2 || notified != 1 {
+		t.Fatalf("expected 2 changes and a notification, got %+v (cursor %d, %d notifications)", changes, cursor, notified)
+	}
+	kinds := map[string]string{changes[0].Session.ID: changes[0].Change, changes[1].Session.ID: changes[1].Change}
+	if kinds["new"] != sessionCreated || kinds["old"] != sessionUpdated {
+		t.Fatalf("unexpected changes: %+v", changes)
+	}
+
+	// Nothing changed since the cursor: waiting ends with the next change
+	done := make(chan []sessionChange)
+	go func() {
+		changes, _ := watcher.wait(ctx, cursor, time.Minute)
+		done <- changes
+	}()
+	time.Sleep(10 * time.Millisecond)
+	writeFile("new.jsonl", start.Add(2*time.Minute))
+	if err := watcher.poll(ctx); err != nil {
+		t.Fatalf("poll failed: %v", err)
+	}
+	select {
+	case changes := <-done:
+		if len(changes) != 1 || changes[0].Session.ID != "new" || changes[0].Change != sessionUpdated || changes[0].Cursor != 3 {
+			t.Fatalf("unexpected changes after waiting: %+v", changes)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiting didn't end with the change")
+	}
+
+	if changes, cursor := watcher.wait(ctx, 3, 10*time.Millisecond); len(changes) != 0 || cursor != 3 {
+		t.Fatalf("expected no changes after the last cursor, got %+v (cursor %d)", changes, cursor)
+	}
+}
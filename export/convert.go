@@ -0,0 +1,272 @@
+package export
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// ConvertedSessionID returns the ID a session gets when converted to another agent's
+// format: a UUID derived from its source and ID, so converting the same session again
+// replaces the earlier copy instead of duplicating it.
+func ConvertedSessionID(session adapters.Session) string {
+	return nameUUID(session.Source + ":" + session.ID)
+}
+
+// nameUUID returns a version 5 style UUID derived from name
+func nameUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// toolCallIDs assigns IDs to tool calls and results for formats that require them.
+// Calls without an ID get a generated one, and results without an ID are matched to
+// the oldest call still waiting for its result.
+type toolCallIDs struct {
+	prefix  string
+	next    int
+	pending []string
+}
+
+func (ids *toolCallIDs) call(id string) string {
+	if id == "" {
+		ids.next++
+		id = fmt.Sprintf("%s%d", ids.prefix, ids.next)
+	}
+	ids.pending = append(ids.pending, id)
+	return id
+}
+
+func (ids *toolCallIDs) result(id string) string {
+	if id == "" && len(ids.pending) > 0 {
+		id = ids.pending[0]
+	}
+	for i, pending := range ids.pending {
+		if pending == id {
+			ids.pending = append(ids.pending[:i], ids.pending[i+1:]...)
+			break
+		}
+	}
+	return id
+}
+
+// carriedResults returns the tool results a message carries alongside its own tool
+// calls, as Gemini and opencode record them, rather than as a separate tool message.
+func carriedResults(msg adapters.Message) []adapters.ToolResult {
+	if analysis.IsToolOutput(msg) {
+		return nil
+	}
+	return analysis.ToolResults(msg)
+}
+
+// encodeLines writes each value as a line of JSON
+func encodeLines(values []interface{}) (string, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	for _, value := range values {
+		if err := encoder.Encode(value); err != nil {
+			return "", fmt.Errorf("failed to encode session: %w", err)
+		}
+	}
+	return b.String(), nil
+}
+
+func formatTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// claudeEntry is a line of a Claude Code session file
+type claudeEntry struct {
+	Type        string         `json:"type"`
+	ParentUUID  *string        `json:"parentUuid"`
+	IsSidechain bool           `json:"isSidechain"`
+	UserType    string         `json:"userType"`
+	CWD         string         `json:"cwd,omitempty"`
+	SessionID   string         `json:"sessionId"`
+	Message     *claudeContent `json:"message,omitempty"`
+	Content     string         `json:"content,omitempty"` // System entries
+	UUID        string         `json:"uuid"`
+	Timestamp   string         `json:"timestamp,omitempty"`
+}
+
+type claudeContent struct {
+	Role    string      `json:"role"`
+	Model   string      `json:"model,omitempty"`
+	Content interface{} `json:"content"`
+}
+
+// ClaudeJSONL converts a session from any source to a Claude Code session file, so it
+// can be reviewed or resumed in tools that only read Claude Code transcripts. The
+// session is given the ID ConvertedSessionID returns.
+func ClaudeJSONL(session adapters.Session, messages []adapters.Message) (string, error) {
+	sessionID := ConvertedSessionID(session)
+	ids := &toolCallIDs{prefix: "toolu_converted_"}
+	var (
+		entries []interface{}
+		parent  *string
+	)
+	add := func(msg adapters.Message, entryType string, content *claudeContent, text string) {
+		uuid := nameUUID(fmt.Sprintf("%s:%d", sessionID, len(entries)))
+		sidechain, _ := msg.Metadata["is_sidechain"].(bool)
+		entries = append(entries, claudeEntry{
+			Type:        entryType,
+			ParentUUID:  parent,
+			IsSidechain: sidechain,
+			UserType:    "external",
+			CWD:         session.ProjectPath,
+			SessionID:   sessionID,
+			Message:     content,
+			Content:     text,
+			UUID:        uuid,
+			Timestamp:   formatTimestamp(msg.Timestamp),
+		})
+		parent = &uuid
+	}
+	addResults := func(msg adapters.Message, results []adapters.ToolResult) {
+		if len(results) == 0 {
+			return
+		}
+		blocks := make([]interface{}, 0, len(results))
+		for _, result := range results {
+			blocks = append(blocks, map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": ids.result(result.ToolCallID),
+				"content":     result.Output,
+				"is_error":    result.IsError,
+			})
+		}
+		add(msg, "user", &claudeContent{Role: "user", Content: blocks}, "")
+	}
+
+	for _, msg := range messages {
+		switch {
+		case analysis.IsToolOutput(msg):
+			results := analysis.ToolResults(msg)
+			if len(results) == 0 && msg.Content != "" {
+				results = []adapters.ToolResult{{Output: msg.Content}}
+			}
+			addResults(msg, results)
+		case msg.Role == "user":
+			if strings.TrimSpace(msg.Content) != "" {
+				add(msg, "user", &claudeContent{Role: "user", Content: msg.Content}, "")
+			}
+		case msg.Role == "assistant":
+			var blocks []interface{}
+			if msg.Thinking != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "thinking", "thinking": msg.Thinking})
+			}
+			if msg.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": msg.Content})
+			}
+			for _, call := range analysis.ToolCalls(msg) {
+				input := call.Input
+				if input == nil {
+					input = map[string]interface{}{}
+				}
+				blocks = append(blocks, map[string]interface{}{"type": "tool_use", "id": ids.call(call.ID), "name": call.Name, "input": input})
+			}
+			if len(blocks) > 0 {
+				add(msg, "assistant", &claudeContent{Role: "assistant", Model: analysis.MessageModel(msg), Content: blocks}, "")
+			}
+			addResults(msg, carriedResults(msg))
+		default:
+			if strings.TrimSpace(msg.Content) != "" {
+				add(msg, "system", nil, msg.Content)
+			}
+		}
+	}
+	return encodeLines(entries)
+}
+
+// codexLine is a line of a Codex rollout file
+type codexLine struct {
+	Timestamp string                 `json:"timestamp"`
+	Type      string                 `json:"type"`
+	Payload   map[string]interface{} `json:"payload"`
+}
+
+// CodexJSONL converts a session from any source to a Codex rollout file. The session
+// is given the ID ConvertedSessionID returns.
+func CodexJSONL(session adapters.Session, messages []adapters.Message) (string, error) {
+	sessionID := ConvertedSessionID(session)
+	started := startTime(session, messages)
+	if started.IsZero() {
+		started = time.Now()
+	}
+	ids := &toolCallIDs{prefix: "call_converted_"}
+	lines := []interface{}{codexLine{
+		Timestamp: formatTimestamp(started),
+		Type:      "session_meta",
+		Payload: map[string]interface{}{
+			"id":         sessionID,
+			"timestamp":  formatTimestamp(started),
+			"cwd":        session.ProjectPath,
+			"originator": "ai-sessions",
+		},
+	}}
+	last := started
+	add := func(msg adapters.Message, payload map[string]interface{}) {
+		if !msg.Timestamp.IsZero() {
+			last = msg.Timestamp
+		}
+		lines = append(lines, codexLine{Timestamp: formatTimestamp(last), Type: "response_item", Payload: payload})
+	}
+	addResults := func(msg adapters.Message, results []adapters.ToolResult) {
+		for _, result := range results {
+			add(msg, map[string]interface{}{"type": "function_call_output", "call_id": ids.result(result.ToolCallID), "output": result.Output})
+		}
+	}
+	textMessage := func(role, blockType, text string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":    "message",
+			"role":    role,
+			"content": []interface{}{map[string]interface{}{"type": blockType, "text": text}},
+		}
+	}
+
+	for _, msg := range messages {
+		switch {
+		case analysis.IsToolOutput(msg):
+			results := analysis.ToolResults(msg)
+			if len(results) == 0 && msg.Content != "" {
+				results = []adapters.ToolResult{{Output: msg.Content}}
+			}
+			addResults(msg, results)
+		case msg.Role == "user":
+			if strings.TrimSpace(msg.Content) != "" {
+				add(msg, textMessage("user", "input_text", msg.Content))
+			}
+		case msg.Role == "assistant":
+			if msg.Thinking != "" {
+				add(msg, map[string]interface{}{
+					"type":    "reasoning",
+					"summary": []interface{}{map[string]interface{}{"type": "summary_text", "text": msg.Thinking}},
+				})
+			}
+			if msg.Content != "" {
+				add(msg, textMessage("assistant", "output_text", msg.Content))
+			}
+			for _, call := range analysis.ToolCalls(msg) {
+				arguments, err := json.Marshal(call.Input)
+				if err != nil || call.Input == nil {
+					arguments = []byte("{}")
+				}
+				add(msg, map[string]interface{}{"type": "function_call", "name": call.Name, "arguments": string(arguments), "call_id": ids.call(call.ID)})
+			}
+			addResults(msg, carriedResults(msg))
+		}
+	}
+	return encodeLines(lines)
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// convertTestMessages is a session in the shape Gemini records it, with tool results
+// carried on the assistant message and a call without an ID
+func convertTestMessages(start time.Time) []adapters.Message {
+	return []adapters.Message{
+		{Role: "user", Content: "Run the tests", Timestamp: start},
+		{Role: "assistant", Content: "Running them.", Thinking: "Tests first.", Timestamp: start.Add(time.Second),
+			ToolCalls:   []adapters.ToolCall{{Name: "run_shell_command", Input: map[string]interface{}{"command": "go test ./..."}}},
+			ToolResults: []adapters.ToolResult{{Output: "ok"}},
+		},
+		{Role: "assistant", Content: "All tests pass.", Timestamp: start.Add(2 * time.Second)},
+	}
+}
+
+func TestClaudeJSONLIsReadableByTheClaudeAdapter(t *testing.T) {
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	session := adapters.Session{ID: "g-1", Source: "gemini", ProjectPath: "/work/app"}
+	content, err := Render("claude", session, convertTestMessages(start))
+	if err != nil {
+		t.Fatalf("ClaudeJSONL failed: %v", err)
+	}
+
+	dataDir := t.TempDir()
+	projectDir := filepath.Join(dataDir, "projects", "-work-app")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	id := ConvertedSessionID(session)
+	if err := os.WriteFile(filepath.Join(projectDir, id+".jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	claude, err := adapters.NewAdapterAt("claude", dataDir)
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	sessions, err := claude.ListSessions("", 0)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != id || sessions[0].FirstMessage != "Run the tests" {
+		t.Fatalf("expected the converted session to be listed, got %+v (%v)", sessions, err)
+	}
+	messages, err := claude.GetSession(id, 0, 100)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	if len(messages) != 4 {
+		t.Fatalf("expected user, assistant, tool result, and assistant messages, got %+v", messages)
+	}
+	call, result := messages[1].ToolCalls, messages[2].ToolResults
+	if len(call) != 1 || len(result) != 1 || call[0].ID == "" || result[0].ToolCallID != call[0].ID || result[0].Output != "ok" {
+		t.Fatalf("expected the tool call linked to its result, got %+v and %+v", call, result)
+	}
+	if messages[1].Thinking != "Tests first." || !messages[0].Timestamp.Equal(start) {
+		t.Fatalf("expected thinking and timestamps to be kept, got %+v", messages[:2])
+	}
+}
+
+func TestCodexJSONLIsReadableByTheCodexAdapter(t *testing.T) {
+	start := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+	session := adapters.Session{ID: "c-1", Source: "claude", ProjectPath: "/work/app"}
+	content, err := Render("codex", session, convertTestMessages(start))
+	if err != nil {
+		t.Fatalf("CodexJSONL failed: %v", err)
+	}
+
+	dataDir := t.TempDir()
+	dayDir := filepath.Join(dataDir, "sessions", "2025", "04", "01")
+	if err := os.MkdirAll(dayDir, 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	id := ConvertedSessionID(session)
+	if err := os.WriteFile(filepath.Join(dayDir, "rollout-2025-04-01T12-00-00-"+id+".jsonl"), []byte(content), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	codex, err := adapters.NewAdapterAt("codex", dataDir)
+	if err != nil {
+		t.Fatalf("NewAdapterAt failed: %v", err)
+	}
+	messages, err := codex.GetSession(id, 0, 100)
+	if err != nil {
+		t.Fatalf("GetSession failed: %v", err)
+	}
+	var calls, results int
+	for _, msg := range messages {
+		calls += len(msg.ToolCalls)
+		for _, result := range msg.ToolResults {
+			if result.Output == "ok" && result.ToolCallID != "" {
+				results++
+			}
+		}
+	}
+	if messages[0].Content != "Run the tests" || calls != 1 || results != 1 {
+		t.Fatalf("unexpected converted session: %+v", messages)
+	}
+}
+
+func TestConvertedSessionIDIsStable(t *testing.T) {
+	a := ConvertedSessionID(adapters.Session{ID: "x", Source: "codex"})
+	if a != ConvertedSessionID(adapters.Session{ID: "x", Source: "codex"}) || a == ConvertedSessionID(adapters.Session{ID: "x", Source: "gemini"}) {
+		t.Fatal("expected IDs to depend only on source and ID")
+	}
+	if len(a) != 36 || a[14] != '5' {
+		t.Fatalf("expected a version 5 UUID, got %s", a)
+	}
+}
@@ -0,0 +1,30 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Diff renders the changes the assistant made to files in a session as one unified diff,
+// in the order they were made (see analysis.Diffs). Each file diff is preceded by a line
+// naming the tool that made it, which patch tools skip.
+func Diff(session adapters.Session, messages []adapters.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", Title(session, messages))
+	diffs := analysis.Diffs(messages)
+	if len(diffs) == 0 {
+		b.WriteString("# No file changes\n")
+		return b.String()
+	}
+	for _, diff := range diffs {
+		b.WriteString("\n# " + diff.Tool)
+		if !diff.Timestamp.IsZero() {
+			b.WriteString(" at " + formatTimestamp(diff.Timestamp))
+		}
+		b.WriteString("\n" + diff.Diff)
+	}
+	return b.String()
+}
@@ -0,0 +1,31 @@
+package export
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestDiffRendersFileChanges(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "Rename the greeting"},
+		{Role: "assistant", Timestamp: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC), ToolCalls: []adapters.ToolCall{
+			{ID: "t1", Name: "Edit", Input: map[string]interface{}{"file_path": "/work/app/main.go", "old_string": "hi", "new_string": "hello"}},
+		}},
+	}
+
+	out, err := Render("diff", adapters.Session{ID: "s1"}, messages)
+	want := "# Rename the greeting\n\n# Edit at 2025-01-02T10:00:00.000Z\n--- /work/app/main.go\n+++ /work/app/main.go\n@@ -1,1 +1,1 @@\n-hi\n+hello\n"
+	if err != nil || out != want {
+		t.Fatalf("unexpected diff export (%v):\n%s", err, out)
+	}
+
+	out, err = Render("diff", adapters.Session{ID: "s1"}, messages[:1])
+	if err != nil || out != "# Rename the greeting\n# No file changes\n" {
+		t.Fatalf("unexpected export of a session without changes (%v):\n%s", err, out)
+	}
+	if ext, err := Extension("diff"); err != nil || ext != ".diff" {
+		t.Fatalf("expected the .diff extension, got %q (%v)", ext, err)
+	}
+}
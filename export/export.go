@@ -0,0 +1,317 @@
+// Package export renders session transcripts as standalone documents that can be
+// shared outside the agent that recorded them, such as in pull requests or docs.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+// Formats lists the supported export formats. "claude" and "codex" convert the session
+// to that agent's own session file format, and "diff" only has the changes it made to files.
+var Formats = []string{"md", "html", "json", "jsonl", "claude", "codex", "diff"}
+
+const (
+	// maxTitleLength caps the length of a title derived from the first user message
+	maxTitleLength = 80
+
+	// maxToolOutputLength caps the tool output included in an export, so a single
+	// large file read doesn't dominate the document
+	maxToolOutputLength = 4000
+)
+
+// Render renders a session in the given format.
+func Render(format string, session adapters.Session, messages []adapters.Message) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "md", "markdown":
+		return Markdown(session, messages), nil
+	case "html":
+		return HTML(session, messages)
+	case "json":
+		return JSON(session, messages)
+	case "jsonl":
+		return JSONL(session, messages)
+	case "claude":
+		return ClaudeJSONL(session, messages)
+	case "codex":
+		return CodexJSONL(session, messages)
+	case "diff", "patch":
+		return Diff(session, messages), nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Extension returns the file extension of an export format, including the dot.
+func Extension(format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "", "md", "markdown":
+		return ".md", nil
+	case "html", "json", "jsonl":
+		return "." + strings.ToLower(format), nil
+	case "claude", "codex":
+		return ".jsonl", nil
+	case "diff", "patch":
+		return ".diff", nil
+	default:
+		return "", fmt.Errorf("unsupported export format: %s (supported: %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// Title returns a title for the session: its summary when the agent recorded one,
+// otherwise the first line of the first user message.
+func Title(session adapters.Session, messages []adapters.Message) string {
+	if title := firstLine(session.Summary); title != "" {
+		return textutil.TruncateBytes(title, maxTitleLength)
+	}
+	for _, msg := range messages {
+		if analysis.IsHumanMessage(msg) {
+			return textutil.TruncateBytes(firstLine(msg.Content), maxTitleLength)
+		}
+	}
+	if title := firstLine(session.FirstMessage); title != "" {
+		return textutil.TruncateBytes(title, maxTitleLength)
+	}
+	return "Session " + session.ID
+}
+
+// partKind says what a part of a turn holds
+type partKind int
+
+const (
+	textPart partKind = iota
+	thinkingPart
+	toolPart
+	attachmentPart
+)
+
+// part is one piece of a turn: text, reasoning, a tool call with its output, or an attachment
+type part struct {
+	kind       partKind
+	text       string
+	call       *adapters.ToolCall   // nil for tool output whose call is unknown
+	result     *adapters.ToolResult // nil for calls without recorded output
+	attachment adapters.Attachment
+}
+
+// turn is a run of consecutive messages from one speaker
+type turn struct {
+	label     string
+	role      string
+	timestamp time.Time
+	parts     []part
+}
+
+// buildTurns groups a session's messages into turns for rendering. Agents often split
+// one reply into several messages, so consecutive messages from the same speaker are
+// merged. Tool output is attached to the call that produced it; output without a known
+// call is kept in the turn it appears in.
+func buildTurns(messages []adapters.Message) []turn {
+	results := resultsByCall(messages)
+	attached := make(map[string]bool)
+	var turns []turn
+	appendParts := func(msg adapters.Message, parts []part) {
+		if len(parts) == 0 {
+			return
+		}
+		label := roleLabel(msg)
+		if analysis.IsToolOutput(msg) && len(turns) > 0 {
+			label = turns[len(turns)-1].label
+		}
+		if len(turns) == 0 || turns[len(turns)-1].label != label {
+			turns = append(turns, turn{label: label, role: msg.Role, timestamp: msg.Timestamp})
+		}
+		last := &turns[len(turns)-1]
+		last.parts = append(last.parts, parts...)
+	}
+
+	for _, msg := range messages {
+		var parts []part
+		if analysis.IsToolOutput(msg) {
+			toolResults := analysis.ToolResults(msg)
+			if len(toolResults) == 0 && strings.TrimSpace(msg.Content) != "" {
+				toolResults = []adapters.ToolResult{{Output: msg.Content}}
+			}
+			for i := range toolResults {
+				if id := toolResults[i].ToolCallID; id == "" || !attached[id] {
+					parts = append(parts, part{kind: toolPart, result: &toolResults[i]})
+				}
+			}
+			appendParts(msg, parts)
+			continue
+		}
+
+		if thinking := strings.TrimSpace(msg.Thinking); thinking != "" {
+			parts = append(parts, part{kind: thinkingPart, text: thinking})
+		}
+		if content := strings.TrimSpace(msg.Content); content != "" {
+			parts = append(parts, part{kind: textPart, text: content})
+		}
+		for _, attachment := range msg.Attachments {
+			parts = append(parts, part{kind: attachmentPart, attachment: attachment})
+		}
+		calls := analysis.ToolCalls(msg)
+		for i := range calls {
+			p := part{kind: toolPart, call: &calls[i]}
+			if result, ok := results[calls[i].ID]; ok && calls[i].ID != "" {
+				p.result = &result
+				attached[calls[i].ID] = true
+			}
+			parts = append(parts, p)
+		}
+		appendParts(msg, parts)
+	}
+	return turns
+}
+
+// startTime returns when the session started: its listed time, or else the time of its
+// first timestamped message.
+func startTime(session adapters.Session, messages []adapters.Message) time.Time {
+	if !session.Timestamp.IsZero() {
+		return session.Timestamp
+	}
+	for _, msg := range messages {
+		if !msg.Timestamp.IsZero() {
+			return msg.Timestamp
+		}
+	}
+	return time.Time{}
+}
+
+// sessionDetails returns the labelled details shown in an export's header, skipping
+// those the session doesn't have.
+func sessionDetails(session adapters.Session, messages []adapters.Message) [][2]string {
+	details := [][2]string{
+		{"Source", session.Source},
+		{"Session", session.ID},
+		{"Project", session.ProjectPath},
+		{"Machine", session.Machine},
+	}
+	if started := startTime(session, messages); !started.IsZero() {
+		details = append(details, [2]string{"Started", started.UTC().Format(time.RFC3339)})
+	}
+	present := details[:0]
+	for _, detail := range details {
+		if detail[1] != "" {
+			present = append(present, detail)
+		}
+	}
+	return present
+}
+
+// attachmentName returns the name shown for an attachment.
+func attachmentName(attachment adapters.Attachment) string {
+	if attachment.Name != "" {
+		return attachment.Name
+	}
+	return attachment.MediaType
+}
+
+// toolSummary describes a tool part in one line, marking failed calls.
+func toolSummary(p part) string {
+	summary := "Tool output"
+	if p.call != nil {
+		summary = CallSummary(*p.call)
+	}
+	if p.result != nil && p.result.IsError {
+		summary += " (failed)"
+	}
+	return summary
+}
+
+// toolInput formats a tool call's arguments as indented JSON, or "" without arguments.
+func toolInput(p part) string {
+	if p.call == nil || len(p.call.Input) == 0 {
+		return ""
+	}
+	data, err := json.MarshalIndent(p.call.Input, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// truncateOutput shortens long tool output, noting how much was left out.
+func truncateOutput(output string) string {
+	if len(output) <= maxToolOutputLength {
+		return output
+	}
+	omitted := len(output) - maxToolOutputLength
+	return textutil.TruncateBytes(output, maxToolOutputLength) + fmt.Sprintf("\n[%d more bytes]", omitted)
+}
+
+// roleLabel returns the heading of a message's speaker. Subagent messages are labelled
+// with the agent that wrote them.
+func roleLabel(msg adapters.Message) string {
+	if sidechain, _ := msg.Metadata["is_sidechain"].(bool); sidechain {
+		if name, _ := msg.Metadata["agent_name"].(string); name != "" {
+			return "Subagent (" + name + ")"
+		}
+		return "Subagent"
+	}
+	switch msg.Role {
+	case "user":
+		return "User"
+	case "assistant":
+		return "Assistant"
+	case "system":
+		return "System"
+	case "":
+		return "Message"
+	default:
+		return strings.ToUpper(msg.Role[:1]) + msg.Role[1:]
+	}
+}
+
+// resultsByCall indexes every tool result of a session by the ID of its tool call, so
+// calls can be rendered together with their output.
+func resultsByCall(messages []adapters.Message) map[string]adapters.ToolResult {
+	results := make(map[string]adapters.ToolResult)
+	for _, msg := range messages {
+		for _, result := range analysis.ToolResults(msg) {
+			if result.ToolCallID != "" {
+				results[result.ToolCallID] = result
+			}
+		}
+	}
+	return results
+}
+
+// CallSummary describes a tool call in one line, such as "Bash: go test ./...".
+func CallSummary(call adapters.ToolCall) string {
+	for _, key := range []string{"command", "cmd", "file_path", "path", "pattern", "query", "url", "description"} {
+		switch v := call.Input[key].(type) {
+		case string:
+			if line := firstLine(v); line != "" {
+				return call.Name + ": " + textutil.TruncateBytes(line, maxTitleLength)
+			}
+		case []interface{}:
+			var parts []string
+			for _, part := range v {
+				if s, ok := part.(string); ok {
+					parts = append(parts, s)
+				}
+			}
+			if len(parts) > 0 {
+				return call.Name + ": " + textutil.TruncateBytes(strings.Join(parts, " "), maxTitleLength)
+			}
+		}
+	}
+	return call.Name
+}
+
+// firstLine returns the first non-empty line of text.
+func firstLine(text string) string {
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package export
+
+import (
+	_ "embed"
+	"fmt"
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
+)
+
+//go:embed viewer.html
+var viewerTemplate string
+
+var viewer = template.Must(template.New("viewer").Parse(viewerTemplate))
+
+// maxPreviewLength caps the message previews listed in the HTML navigator
+const maxPreviewLength = 60
+
+// htmlView is the data rendered by the viewer template
+type htmlView struct {
+	Title   string
+	Details [][2]string
+	Turns   []htmlTurn
+}
+
+type htmlTurn struct {
+	Anchor  string
+	Label   string
+	Role    string
+	Time    string
+	Preview string
+	Parts   []htmlPart
+}
+
+type htmlPart struct {
+	Kind        string        // "text", "thinking", "attachment", or "tool"
+	Body        template.HTML // Rendered text and thinking
+	Summary     string
+	Input       string
+	Output      string
+	OutputLabel string
+	Failed      bool
+}
+
+// HTML renders a session as a single self-contained HTML page for people who don't use
+// the CLI. The page needs no network access: styles and a small syntax highlighter are
+// inlined. A navigator lists every turn, and tool calls are collapsible.
+func HTML(session adapters.Session, messages []adapters.Message) (string, error) {
+	view := htmlView{
+		Title:   Title(session, messages),
+		Details: sessionDetails(session, messages),
+	}
+	for i, t := range buildTurns(messages) {
+		ht := htmlTurn{
+			Anchor: fmt.Sprintf("turn-%d", i+1),
+			Label:  t.label,
+			Role:   t.role,
+		}
+		if !t.timestamp.IsZero() {
+			ht.Time = t.timestamp.UTC().Format("2006-01-02 15:04:05 UTC")
+		}
+		for _, p := range t.parts {
+			ht.Parts = append(ht.Parts, htmlPartOf(p))
+			if ht.Preview == "" && p.kind == textPart {
+				ht.Preview = textutil.TruncateBytes(firstLine(p.text), maxPreviewLength)
+			}
+		}
+		if ht.Preview == "" && len(t.parts) > 0 && t.parts[0].kind == toolPart {
+			ht.Preview = textutil.TruncateBytes(toolSummary(t.parts[0]), maxPreviewLength)
+		}
+		view.Turns = append(view.Turns, ht)
+	}
+
+	var b strings.Builder
+	if err := viewer.Execute(&b, view); err != nil {
+		return "", fmt.Errorf("failed to render HTML: %w", err)
+	}
+	return b.String(), nil
+}
+
+func htmlPartOf(p part) htmlPart {
+	switch p.kind {
+	case textPart:
+		return htmlPart{Kind: "text", Body: renderHTMLText(p.text)}
+	case thinkingPart:
+		return htmlPart{Kind: "thinking", Body: renderHTMLText(p.text)}
+	case attachmentPart:
+		return htmlPart{Kind: "attachment", Summary: fmt.Sprintf("Attached %s: %s", p.attachment.Type, attachmentName(p.attachment))}
+	}
+
+	hp := htmlPart{Kind: "tool", Summary: toolSummary(p), Input: toolInput(p)}
+	if p.result != nil {
+		hp.OutputLabel, hp.Failed = "Output", p.result.IsError
+		if p.result.IsError {
+			hp.OutputLabel = "Error"
+		}
+		hp.Output = truncateOutput(strings.TrimSpace(p.result.Output))
+		if hp.Output == "" {
+			hp.Output = "(empty)"
+		}
+	}
+	return hp
+}
+
+var (
+	// fenceLine matches the opening or closing line of a fenced code block
+	fenceLine = regexp.MustCompile("^\\s*(`{3,}|~{3,})\\s*([\\w+#.-]*)")
+
+	// inlineCode matches `code` spans within a line
+	inlineCode = regexp.MustCompile("`([^`\\n]+)`")
+)
+
+// renderHTMLText renders message text as HTML. Fenced code blocks become highlighted
+// <pre> blocks and `code` spans become <code>; everything else is kept as escaped
+// text with its line breaks.
+func renderHTMLText(text string) template.HTML {
+	var b, prose, code strings.Builder
+	flushProse := func() {
+		if s := strings.Trim(prose.String(), "\n"); s != "" {
+			escaped := html.EscapeString(s)
+			escaped = inlineCode.ReplaceAllString(escaped, "<code>$1</code>")
+			fmt.Fprintf(&b, "<div class=\"text\">%s</div>\n", escaped)
+		}
+		prose.Reset()
+	}
+
+	fence, language := "", ""
+	for _, line := range strings.Split(text, "\n") {
+		if fence == "" {
+			if m := fenceLine.FindStringSubmatch(line); m != nil {
+				flushProse()
+				fence, language = m[1], m[2]
+				continue
+			}
+			prose.WriteString(line)
+			prose.WriteString("\n")
+			continue
+		}
+		if closesFence(line, fence) {
+			writeHTMLCode(&b, code.String(), language)
+			code.Reset()
+			fence = ""
+			continue
+		}
+		code.WriteString(line)
+		code.WriteString("\n")
+	}
+	if fence != "" {
+		// An unclosed fence runs to the end of the message, as in Markdown
+		writeHTMLCode(&b, code.String(), language)
+	}
+	flushProse()
+	return template.HTML(b.String())
+}
+
+// closesFence reports whether a line closes a code block opened with fence: a run of
+// at least as many of the same fence characters, and nothing else
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
+
+func writeHTMLCode(b *strings.Builder, code, language string) {
+	class := ""
+	if language != "" {
+		class = fmt.Sprintf(" class=\"language-%s\"", html.EscapeString(strings.ToLower(language)))
+	}
+	fmt.Fprintf(b, "<pre><code%s>%s</code></pre>\n", class, html.EscapeString(strings.TrimSuffix(code, "\n")))
+}
@@ -0,0 +1,45 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestHTMLIsSelfContained(t *testing.T) {
+	messages := []adapters.Message{
+		{Role: "user", Content: "Why does <Login> crash?"},
+		{Role: "assistant", Content: "Use `useState`:\n\n```tsx\nconst [a, b] = useState(0)\n```\nDone.", ToolCalls: []adapters.ToolCall{
+			{ID: "t1", Name: "Read", Input: map[string]interface{}{"file_path": "src/Login.tsx"}},
+		}},
+		{Role: "tool", ToolResults: []adapters.ToolResult{{ToolCallID: "t1", Output: "export function Login() {}"}}},
+	}
+
+	page, err := Render("html", adapters.Session{ID: "s1", Source: "claude"}, messages)
+	if err != nil {
+		t.Fatalf("HTML failed: %v", err)
+	}
+	for _, want := range []string{
+		"<title>Why does &lt;Login&gt; crash?</title>",
+		`<a href="#turn-1" class="role-user">User <span>Why does &lt;Login&gt; crash?</span></a>`,
+		`<section class="turn role-assistant" id="turn-2">`,
+		"<code>useState</code>",
+		`<pre><code class="language-tsx">const [a, b] = useState(0)</code></pre>`,
+		`<div class="text">Done.</div>`,
+		"<summary>Read: src/Login.tsx</summary>",
+		"export function Login() {}",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expected %q in page:\n%s", want, page)
+		}
+	}
+	if strings.Contains(page, "<Login>") {
+		t.Error("message text must be escaped")
+	}
+	for _, external := range []string{"<link", "src=\"http", "@import"} {
+		if strings.Contains(page, external) {
+			t.Errorf("page should not load external resources, found %q", external)
+		}
+	}
+}
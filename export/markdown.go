@@ -0,0 +1,82 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// Markdown renders a session as Markdown: a header with the session's details, then
+// each turn under a speaker heading. Tool calls and their output are collapsed into
+// <details> blocks, which GitHub and most Markdown viewers render as expandable
+// sections.
+func Markdown(session adapters.Session, messages []adapters.Message) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", Title(session, messages))
+	if details := sessionDetails(session, messages); len(details) > 0 {
+		for _, detail := range details {
+			fmt.Fprintf(&b, "- **%s:** `%s`\n", detail[0], detail[1])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("---\n\n")
+
+	for _, t := range buildTurns(messages) {
+		fmt.Fprintf(&b, "## %s\n\n", t.label)
+		for _, p := range t.parts {
+			switch p.kind {
+			case textPart:
+				b.WriteString(p.text)
+				b.WriteString("\n\n")
+			case thinkingPart:
+				writeMarkdownCollapsed(&b, "Thinking", p.text+"\n\n")
+			case attachmentPart:
+				fmt.Fprintf(&b, "_Attached %s: %s_\n\n", p.attachment.Type, attachmentName(p.attachment))
+			case toolPart:
+				writeMarkdownToolBlock(&b, p)
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n"
+}
+
+// writeMarkdownToolBlock writes a collapsed tool call with its input and output.
+func writeMarkdownToolBlock(b *strings.Builder, p part) {
+	var body strings.Builder
+	if input := toolInput(p); input != "" {
+		writeMarkdownFence(&body, input, "json")
+	}
+	if p.result != nil {
+		label := "Output"
+		if p.result.IsError {
+			label = "Error"
+		}
+		if output := strings.TrimSpace(p.result.Output); output != "" {
+			fmt.Fprintf(&body, "%s:\n\n", label)
+			writeMarkdownFence(&body, truncateOutput(output), "")
+		} else {
+			fmt.Fprintf(&body, "_%s: (empty)_\n\n", label)
+		}
+	}
+	writeMarkdownCollapsed(b, toolSummary(p), body.String())
+}
+
+// writeMarkdownCollapsed writes a <details> block around Markdown that ends with a
+// blank line.
+func writeMarkdownCollapsed(b *strings.Builder, summary, body string) {
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n", html.EscapeString(summary))
+	b.WriteString(body)
+	b.WriteString("</details>\n\n")
+}
+
+// writeMarkdownFence writes text as a fenced code block, using a fence longer than any
+// run of backticks in the text so embedded code blocks can't close it early.
+func writeMarkdownFence(b *strings.Builder, text, language string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n\n", fence, language, text, fence)
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestMarkdownRendersTurnsAndCollapsesTools(t *testing.T) {
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)}
+	messages := []adapters.Message{
+		{Role: "user", Content: "Fix the failing test\n\nIt fails on CI"},
+		{Role: "assistant", Content: "Running the tests:", ToolCalls: []adapters.ToolCall{
+			{ID: "t1", Name: "Bash", Input: map[string]interface{}{"command": "go test ./..."}},
+		}},
+		{Role: "user", ToolResults: []adapters.ToolResult{{ToolCallID: "t1", Output: "FAIL ```weird``` output", IsError: true}},
+			Metadata: map[string]interface{}{"is_tool_result": true}},
+		{Role: "assistant", Content: "Fixed:\n\n```go\nx := 1\n```"},
+	}
+
+	md := Markdown(session, messages)
+	for _, want := range []string{
+		"# Fix the failing test\n",
+		"- **Project:** `/work/app`",
+		"- **Started:** `2025-01-02T10:00:00Z`",
+		"## User\n\nFix the failing test\n\nIt fails on CI",
+		"<summary>Bash: go test ./... (failed)</summary>",
+		"Error:\n\n````\nFAIL ```weird``` output\n````",
+		"```go\nx := 1\n```",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("expected %q in export:\n%s", want, md)
+		}
+	}
+	// Consecutive assistant messages and tool output stay under one heading
+	if n := strings.Count(md, "## Assistant"); n != 1 {
+		t.Errorf("expected one assistant heading, got %d:\n%s", n, md)
+	}
+	if strings.Count(md, "FAIL") != 1 {
+		t.Errorf("expected tool output to be rendered once:\n%s", md)
+	}
+}
+
+func TestRenderRejectsUnknownFormat(t *testing.T) {
+	if _, err := Render("docx", adapters.Session{ID: "s1"}, nil); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+	out, err := Render("markdown", adapters.Session{ID: "s1"}, nil)
+	if err != nil || !strings.HasPrefix(out, "# Session s1\n") {
+		t.Fatalf("expected an empty session to export with a default title, got %q (%v)", out, err)
+	}
+}
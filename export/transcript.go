@@ -0,0 +1,172 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// Unified transcripts identify themselves with TranscriptSchema and TranscriptVersion.
+// The version changes only when a field is removed or changes meaning; new optional
+// fields may be added within a version. transcript.schema.json describes the format
+// as a JSON Schema.
+const (
+	TranscriptSchema  = "ai-sessions/transcript"
+	TranscriptVersion = 1
+)
+
+// Transcript is a session converted to the unified transcript format, which is the
+// same for every source, so tools can read sessions without understanding each
+// agent's native format.
+type Transcript struct {
+	Schema   string              `json:"schema"`
+	Version  int                 `json:"version"`
+	Session  TranscriptSession   `json:"session"`
+	Messages []TranscriptMessage `json:"messages"`
+}
+
+// TranscriptSession describes the session a transcript was converted from.
+type TranscriptSession struct {
+	ID            string          `json:"id"`
+	Source        string          `json:"source"`
+	Title         string          `json:"title"`
+	ProjectPath   string          `json:"project_path,omitempty"`
+	Machine       string          `json:"machine,omitempty"`
+	ContinuedFrom string          `json:"continued_from,omitempty"`
+	StartedAt     *time.Time      `json:"started_at,omitempty"`
+	EndedAt       *time.Time      `json:"ended_at,omitempty"`
+	Usage         *analysis.Usage `json:"usage,omitempty"`
+}
+
+// TranscriptMessage is one message of a transcript. Role is "user" for text the user
+// typed, "assistant", "system", or "tool" for tool output, whichever role the source
+// recorded it under.
+type TranscriptMessage struct {
+	Index       int                   `json:"index"`
+	Role        string                `json:"role"`
+	Timestamp   *time.Time            `json:"timestamp,omitempty"`
+	Text        string                `json:"text,omitempty"`
+	Thinking    string                `json:"thinking,omitempty"`
+	ToolCalls   []adapters.ToolCall   `json:"tool_calls,omitempty"`
+	ToolResults []adapters.ToolResult `json:"tool_results,omitempty"`
+	Attachments []adapters.Attachment `json:"attachments,omitempty"`
+	Model       string                `json:"model,omitempty"`
+	Usage       *analysis.Usage       `json:"usage,omitempty"`
+	Agent       *TranscriptAgent      `json:"agent,omitempty"`
+}
+
+// TranscriptAgent identifies the subagent that wrote a message, for sources that run
+// subagents alongside the main conversation.
+type TranscriptAgent struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// NewTranscript converts a session to the unified transcript format.
+func NewTranscript(session adapters.Session, messages []adapters.Message) Transcript {
+	transcript := Transcript{
+		Schema:  TranscriptSchema,
+		Version: TranscriptVersion,
+		Session: TranscriptSession{
+			ID:            session.ID,
+			Source:        session.Source,
+			Title:         Title(session, messages),
+			ProjectPath:   session.ProjectPath,
+			Machine:       session.Machine,
+			ContinuedFrom: session.ContinuedFrom,
+		},
+		Messages: make([]TranscriptMessage, 0, len(messages)),
+	}
+
+	var total analysis.Usage
+	var ended time.Time
+	for i, msg := range messages {
+		tm := TranscriptMessage{
+			Index:       i,
+			Role:        msg.Role,
+			Timestamp:   timePtr(msg.Timestamp),
+			Text:        msg.Content,
+			Thinking:    msg.Thinking,
+			ToolCalls:   analysis.ToolCalls(msg),
+			ToolResults: analysis.ToolResults(msg),
+			Attachments: msg.Attachments,
+			Model:       analysis.MessageModel(msg),
+		}
+		if analysis.IsToolOutput(msg) {
+			tm.Role = "tool"
+		}
+		if usage := analysis.MessageUsage(msg); usage.Total() > 0 || usage.Cost > 0 {
+			tm.Usage = &usage
+			total.Add(usage)
+		}
+		if sidechain, _ := msg.Metadata["is_sidechain"].(bool); sidechain {
+			agent := &TranscriptAgent{}
+			agent.ID, _ = msg.Metadata["agent_id"].(string)
+			agent.Name, _ = msg.Metadata["agent_name"].(string)
+			tm.Agent = agent
+		}
+		if msg.Timestamp.After(ended) {
+			ended = msg.Timestamp
+		}
+		transcript.Messages = append(transcript.Messages, tm)
+	}
+
+	transcript.Session.StartedAt = timePtr(startTime(session, messages))
+	transcript.Session.EndedAt = timePtr(ended)
+	if total.Total() > 0 || total.Cost > 0 {
+		transcript.Session.Usage = &total
+	}
+	return transcript
+}
+
+// JSON renders a session as an indented unified transcript document.
+func JSON(session adapters.Session, messages []adapters.Message) (string, error) {
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(NewTranscript(session, messages)); err != nil {
+		return "", fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	return b.String(), nil
+}
+
+// jsonlRecord is a line of a JSONL transcript: the header, then one line per message
+type jsonlRecord struct {
+	Type    string             `json:"type"` // "session" or "message"
+	Schema  string             `json:"schema,omitempty"`
+	Version int                `json:"version,omitempty"`
+	Session *TranscriptSession `json:"session,omitempty"`
+	*TranscriptMessage
+}
+
+// JSONL renders a session as a unified transcript with one JSON object per line, for
+// streaming large sessions. The first line holds the schema, version, and session;
+// each following line is a message with "type": "message".
+func JSONL(session adapters.Session, messages []adapters.Message) (string, error) {
+	transcript := NewTranscript(session, messages)
+	var b bytes.Buffer
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	header := jsonlRecord{Type: "session", Schema: transcript.Schema, Version: transcript.Version, Session: &transcript.Session}
+	if err := encoder.Encode(header); err != nil {
+		return "", fmt.Errorf("failed to encode transcript: %w", err)
+	}
+	for i := range transcript.Messages {
+		if err := encoder.Encode(jsonlRecord{Type: "message", TranscriptMessage: &transcript.Messages[i]}); err != nil {
+			return "", fmt.Errorf("failed to encode transcript: %w", err)
+		}
+	}
+	return b.String(), nil
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,104 @@
+package export
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestNewTranscriptNormalizesMessages(t *testing.T) {
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	messages := []adapters.Message{
+		{Role: "user", Content: "List the files", Timestamp: start},
+		{Role: "assistant", Timestamp: start.Add(time.Second), Metadata: map[string]interface{}{
+			"model": "claude-sonnet-4",
+			"usage": map[string]interface{}{"input_tokens": 100.0, "output_tokens": 20.0},
+			"raw_content": []interface{}{
+				map[string]interface{}{"type": "tool_use", "id": "t1", "name": "Bash", "input": map[string]interface{}{"command": "ls"}},
+			},
+		}},
+		{Role: "user", Timestamp: start.Add(2 * time.Second), Metadata: map[string]interface{}{
+			"is_tool_result": true, "is_sidechain": true, "agent_name": "explorer",
+		}, ToolResults: []adapters.ToolResult{{ToolCallID: "t1", Output: "go.mod"}}},
+	}
+
+	transcript := NewTranscript(adapters.Session{ID: "s1", Source: "claude"}, messages)
+	if transcript.Schema != TranscriptSchema || transcript.Version != TranscriptVersion {
+		t.Fatalf("expected schema header, got %s v%d", transcript.Schema, transcript.Version)
+	}
+	if transcript.Session.Title != "List the files" || !transcript.Session.StartedAt.Equal(start) || !transcript.Session.EndedAt.Equal(start.Add(2*time.Second)) {
+		t.Fatalf("unexpected session: %+v", transcript.Session)
+	}
+	if transcript.Session.Usage == nil || transcript.Session.Usage.InputTokens != 100 {
+		t.Fatalf("expected session usage totals, got %+v", transcript.Session.Usage)
+	}
+
+	assistant, tool := transcript.Messages[1], transcript.Messages[2]
+	if len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Name != "Bash" || assistant.Model != "claude-sonnet-4" {
+		t.Fatalf("expected tool calls from raw content and the model, got %+v", assistant)
+	}
+	if tool.Role != "tool" || tool.Index != 2 || tool.Agent == nil || tool.Agent.Name != "explorer" {
+		t.Fatalf("expected tool output with the tool role and its subagent, got %+v", tool)
+	}
+}
+
+func TestJSONLWritesHeaderThenMessages(t *testing.T) {
+	out, err := Render("jsonl", adapters.Session{ID: "s1", Source: "codex"}, []adapters.Message{
+		{Role: "user", Content: "a <b> c"},
+		{Role: "assistant", Content: "done"},
+	})
+	if err != nil {
+		t.Fatalf("JSONL failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected a header and two messages, got:\n%s", out)
+	}
+	var header, first map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &header); err != nil || header["type"] != "session" || header["schema"] != TranscriptSchema {
+		t.Fatalf("unexpected header %s (%v)", lines[0], err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &first); err != nil || first["type"] != "message" || first["text"] != "a <b> c" || first["index"] != 0.0 {
+		t.Fatalf("unexpected message line %s (%v)", lines[1], err)
+	}
+}
+
+func TestTranscriptSchemaDocumentsEveryField(t *testing.T) {
+	data, err := os.ReadFile("transcript.schema.json")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	var schema struct {
+		Properties map[string]interface{} `json:"properties"`
+		Defs       map[string]struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+
+	checks := map[string]interface{}{
+		"":        Transcript{},
+		"session": TranscriptSession{},
+		"message": TranscriptMessage{},
+	}
+	for def, value := range checks {
+		properties := schema.Properties
+		if def != "" {
+			properties = schema.Defs[def].Properties
+		}
+		typ := reflect.TypeOf(value)
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if _, ok := properties[name]; !ok {
+				t.Errorf("schema %q does not document field %q", def, name)
+			}
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package redact
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// homePattern matches home directories on macOS, Linux, and Windows (with the
+	// backslashes escaped or not, as in JSON), capturing the user name
+	homePattern = regexp.MustCompile(`(/Users/|/home/|[A-Za-z]:(?:\\\\|\\)Users(?:\\\\|\\))([A-Za-z0-9._-]+)`)
+
+	// placeholderName matches the names the anonymizer uses, so they aren't renamed again
+	placeholderName = regexp.MustCompile(`^(user|host)\d*$`)
+)
+
+// sharedNames are names that appear in home-like paths or as user names without
+// identifying anyone, and are too common to replace wherever they appear
+var sharedNames = map[string]bool{
+	"shared": true, "public": true, "default": true,
+	"root": true, "admin": true, "runner": true, "ubuntu": true, "ec2-user": true, "vscode": true, "node": true,
+}
+
+// Anonymizer replaces user names, host names, home directories, and email addresses
+// with placeholders such as "user" and "user2@example.com". The same value always gets
+// the same placeholder, so a transcript still reads consistently.
+type Anonymizer struct {
+	users  map[string]string // User name → placeholder
+	hosts  map[string]string // Host name → placeholder
+	emails map[string]string // Email address → placeholder
+}
+
+// NewAnonymizer returns an anonymizer that knows the current user's name, home
+// directory, and host name, besides those it finds in transcripts
+func NewAnonymizer() *Anonymizer {
+	a := &Anonymizer{users: make(map[string]string), hosts: make(map[string]string), emails: make(map[string]string)}
+	if u, err := user.Current(); err == nil {
+		name := u.Username
+		if i := strings.LastIndexAny(name, `\`); i >= 0 {
+			name = name[i+1:] // Windows user names include the domain
+		}
+		a.addUser(name)
+		a.addUser(filepath.Base(u.HomeDir))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		a.addUser(filepath.Base(home))
+	}
+	if host, err := os.Hostname(); err == nil {
+		a.addHost(host)
+		a.addHost(strings.TrimSuffix(host, ".local"))
+	}
+	return a
+}
+
+// addUser gives a user name a placeholder, unless it is shared by many people, and
+// returns the name to use in its place
+func (a *Anonymizer) addUser(name string) string {
+	if name == "" || name == "." || name == string(filepath.Separator) || sharedNames[strings.ToLower(name)] || placeholderName.MatchString(name) {
+		return name
+	}
+	if _, ok := a.users[name]; !ok {
+		// Spellings that differ only in case, such as a home directory and a login
+		// name, share a placeholder
+		a.users[name] = nextPlaceholder("user", a.users, func(known string) bool { return strings.EqualFold(known, name) })
+	}
+	return a.users[name]
+}
+
+// addHost gives a host name a placeholder
+func (a *Anonymizer) addHost(name string) {
+	if name == "" || name == "localhost" || placeholderName.MatchString(name) {
+		return
+	}
+	if _, ok := a.hosts[name]; !ok {
+		// The same host with and without its domain shares a placeholder
+		a.hosts[name] = nextPlaceholder("host", a.hosts, func(known string) bool {
+			return strings.HasPrefix(known, name+".") || strings.HasPrefix(name, known+".")
+		})
+	}
+}
+
+// nextPlaceholder returns the placeholder of a known value that same matches, or else
+// the next unused one: prefix, then prefix2, prefix3, and so on
+func nextPlaceholder(prefix string, known map[string]string, same func(string) bool) string {
+	used := make(map[string]bool)
+	for value, placeholder := range known {
+		if same(value) {
+			return placeholder
+		}
+		used[placeholder] = true
+	}
+	placeholder := prefix
+	for n := 2; used[placeholder]; n++ {
+		placeholder = fmt.Sprintf("%s%d", prefix, n)
+	}
+	return placeholder
+}
+
+// Anonymize replaces the identifying values in text with their placeholders
+func (a *Anonymizer) Anonymize(text string) string {
+	text = emailPattern.ReplaceAllStringFunc(text, func(email string) string {
+		if strings.HasSuffix(strings.ToLower(email), "@example.com") {
+			return email
+		}
+		if placeholder, ok := a.emails[email]; ok {
+			return placeholder
+		}
+		placeholder := fmt.Sprintf("user%d@example.com", len(a.emails)+1)
+		a.emails[email] = placeholder
+		return placeholder
+	})
+
+	text = replaceWords(text, a.hosts)
+
+	text = homePattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := homePattern.FindStringSubmatch(match)
+		return parts[1] + a.addUser(parts[2])
+	})
+
+	// Names short enough to be ordinary words are only replaced in paths
+	words := make(map[string]string)
+	for name, placeholder := range a.users {
+		if len(name) >= 4 {
+			words[name] = placeholder
+		}
+	}
+	return replaceWords(text, words)
+}
+
+// replaceWords replaces each whole-word occurrence of the keys of replacements with
+// its value, longest key first so a name isn't replaced within a longer one
+func replaceWords(text string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return text
+	}
+	var alternatives []string
+	for word := range replacements {
+		alternatives = append(alternatives, regexp.QuoteMeta(word))
+	}
+	sort.Slice(alternatives, func(i, j int) bool { return len(alternatives[i]) > len(alternatives[j]) })
+	pattern := regexp.MustCompile(`\b(?:` + strings.Join(alternatives, "|") + `)\b`)
+	return pattern.ReplaceAllStringFunc(text, func(word string) string {
+		return replacements[word]
+	})
+}
@@ -0,0 +1,28 @@
+package redact
+
+import "testing"
+
+func TestAnonymize(t *testing.T) {
+	a := &Anonymizer{users: make(map[string]string), hosts: make(map[string]string), emails: make(map[string]string)}
+	a.addUser("jdoe")
+	a.addHost("jdoe-laptop.local")
+	a.addHost("jdoe-laptop")
+
+	text := `cd /Users/jdoe/src/app && ssh jdoe-laptop.local
+{"cwd":"C:\\Users\\asmith\\work","by":"jane@corp.dev","cc":"jane@corp.dev, ops@corp.dev"}
+asmith pushed to /home/root/x; prompt: jdoe@jdoe-laptop:~$`
+	want := `cd /Users/user/src/app && ssh host
+{"cwd":"C:\\Users\\user2\\work","by":"user1@example.com","cc":"user1@example.com, user2@example.com"}
+user2 pushed to /home/root/x; prompt: user@host:~$`
+	if got := a.Anonymize(text); got != want {
+		t.Fatalf("Anonymize =\n%s\nwant\n%s", got, want)
+	}
+
+	// Placeholders are kept across calls, and left alone when anonymizing again
+	if got := a.Anonymize("asmith and jane@corp.dev"); got != "user2 and user1@example.com" {
+		t.Fatalf("expected consistent placeholders, got %q", got)
+	}
+	if got := a.Anonymize(want); got != want {
+		t.Fatalf("expected anonymized text to be unchanged, got\n%s", got)
+	}
+}
@@ -0,0 +1,144 @@
+// Package redact finds secrets and personal details in session transcripts, such as
+// API keys and email addresses, and replaces them before a transcript is shared.
+package redact
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule detects one kind of sensitive value. When Group is set, only that capture group
+// of the pattern is the value; the rest of the match is context such as a key name.
+type Rule struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	Group   int
+}
+
+// Value characters stop at quotes and backslashes, so a value found in a JSON string is
+// never cut in the middle of an escape sequence
+const valueChars = `[^\s"'\\,;]`
+
+// Rules are the patterns Find looks for, most specific first: when two matches overlap,
+// the one from the earlier rule wins.
+var Rules = []Rule{
+	{Kind: "private key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{Kind: "AWS access key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Kind: "AWS secret key", Pattern: regexp.MustCompile(`(?i)aws.{0,20}?secret.{0,20}?[=:]\s*\\?["']?([A-Za-z0-9/+]{40})\b`), Group: 1},
+	{Kind: "Anthropic API key", Pattern: regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{Kind: "OpenAI API key", Pattern: regexp.MustCompile(`\bsk-(?:proj-|svcacct-)?[A-Za-z0-9_-]{20,}`)},
+	{Kind: "GitHub token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{Kind: "Slack token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{Kind: "Google API key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	{Kind: "Stripe key", Pattern: regexp.MustCompile(`\b[rs]k_(?:live|test)_[A-Za-z0-9]{16,}`)},
+	{Kind: "JWT", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	{Kind: "credential", Pattern: regexp.MustCompile(`(?i)\b(?:api[_-]?key|secret[_-]?key|access[_-]?token|auth[_-]?token|client[_-]?secret|password|passwd)\\?["']?\s*[=:]\s*\\?["']?(` + valueChars + `{8,})`), Group: 1},
+	{Kind: "email", Pattern: emailPattern},
+}
+
+// emailPattern matches email addresses
+var emailPattern = regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}\b`)
+
+// Finding is a sensitive value found in a text, at Start:End
+type Finding struct {
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Find returns the sensitive values in text, in order and without overlaps
+func Find(text string) []Finding {
+	var findings []Finding
+	for _, rule := range Rules {
+		for _, loc := range rule.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			start, end := loc[2*rule.Group], loc[2*rule.Group+1]
+			if start < 0 || isPlaceholder(text[start:end]) {
+				continue
+			}
+			findings = append(findings, Finding{Kind: rule.Kind, Value: text[start:end], Start: start, End: end})
+		}
+	}
+
+	// Keep the earliest-ranked finding among overlapping ones. Findings are appended in
+	// rule order, so a stable sort by position keeps that order for equal starts.
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Start < findings[j].Start })
+	var kept []Finding
+	for _, f := range findings {
+		overlaps := false
+		for i, k := range kept {
+			if f.Start < k.End && k.Start < f.End {
+				overlaps = true
+				if ruleRank(f.Kind) < ruleRank(k.Kind) {
+					kept[i] = f
+				}
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, f)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].Start < kept[j].Start })
+	return kept
+}
+
+// ruleRank returns the position of a kind in Rules
+func ruleRank(kind string) int {
+	for i, rule := range Rules {
+		if rule.Kind == kind {
+			return i
+		}
+	}
+	return len(Rules)
+}
+
+// isPlaceholder reports whether a value is an obvious stand-in rather than a real
+// secret, such as an environment variable reference or an earlier redaction
+func isPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	return strings.HasPrefix(value, "$") || strings.HasPrefix(value, "[REDACTED") ||
+		strings.Contains(lower, "xxxx") || strings.Contains(lower, "example") || strings.Contains(lower, "your_") ||
+		strings.Contains(lower, "<") || strings.HasPrefix(value, "process.env") || strings.HasPrefix(value, "os.")
+}
+
+// Placeholder is the text that replaces a redacted value of the given kind
+func Placeholder(kind string) string {
+	return "[REDACTED " + strings.ToUpper(strings.ReplaceAll(kind, " ", "_")) + "]"
+}
+
+// Apply replaces the given findings of text with their placeholders. Findings must be
+// in order and not overlap, as returned by Find.
+func Apply(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString(Placeholder(f.Kind))
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Context returns the text around a finding on the same line, up to width characters
+// on each side, for reviewing it
+func Context(text string, f Finding, width int) (before, after string) {
+	start := f.Start - width
+	if start < 0 {
+		start = 0
+	}
+	end := f.End + width
+	if end > len(text) {
+		end = len(text)
+	}
+	before, after = text[start:f.Start], text[f.End:end]
+	if i := strings.LastIndexAny(before, "\n"); i >= 0 {
+		before = before[i+1:]
+	}
+	if i := strings.IndexAny(after, "\n"); i >= 0 {
+		after = after[:i]
+	}
+	return strings.ToValidUTF8(before, ""), strings.ToValidUTF8(after, "")
+}
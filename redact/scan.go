@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// MessageFinding is a sensitive value found in a session message. Field is the part of
+// the message it was found in: "content", "thinking", "tool_input", or "tool_output".
+type MessageFinding struct {
+	MessageIndex int    `json:"message_index"`
+	Role         string `json:"role"`
+	Field        string `json:"field"`
+	Kind         string `json:"kind"`
+	Value        string `json:"value"`
+}
+
+// ScanMessages returns the sensitive values found in a session's messages, in order.
+// A value repeated within a field is reported once.
+func ScanMessages(messages []adapters.Message) []MessageFinding {
+	var findings []MessageFinding
+	for i, msg := range messages {
+		scan := func(field, text string) {
+			seen := make(map[string]bool)
+			for _, f := range Find(text) {
+				if seen[f.Value] {
+					continue
+				}
+				seen[f.Value] = true
+				findings = append(findings, MessageFinding{MessageIndex: i, Role: msg.Role, Field: field, Kind: f.Kind, Value: f.Value})
+			}
+		}
+		scan("content", msg.Content)
+		scan("thinking", msg.Thinking)
+		var inputs []string
+		for _, call := range analysis.ToolCalls(msg) {
+			if input, err := json.Marshal(call.Input); err == nil {
+				inputs = append(inputs, string(input))
+			}
+		}
+		scan("tool_input", strings.Join(inputs, "\n"))
+		var outputs []string
+		for _, result := range analysis.ToolResults(msg) {
+			outputs = append(outputs, result.Output)
+		}
+		scan("tool_output", strings.Join(outputs, "\n"))
+	}
+	return findings
+}
+
+// Mask hides most of a value, keeping a few characters at each end so it can still be
+// recognized
+func Mask(value string) string {
+	runes := []rune(value)
+	keep := len(runes) / 6
+	if keep > 4 {
+		keep = 4
+	}
+	if len(runes) < 8 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:keep]) + strings.Repeat("*", len(runes)-2*keep) + string(runes[len(runes)-keep:])
+}
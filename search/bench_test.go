@@ -0,0 +1,246 @@
+package search
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// The benchmarks index synthetic sessions, 10,000 by default: about the history of a
+// busy user. Run them with:
+//
+//	go test ./search -run '^$' -bench . -benchmem -timeout 0
+//
+// and compare runs with benchstat to catch regressions in tokenization, BM25 scoring
+// and SQLite access. -cpuprofile and -memprofile profile them, and -bench-sessions
+// changes the number of sessions for a quicker run.
+var benchSessionCount = flag.Int("bench-sessions", 10000, "number of synthetic sessions the benchmarks index")
+
+// benchVocabulary is the words of the synthetic sessions. Words early in it are picked
+// more often, so terms range from common to rare as in real sessions.
+var benchVocabulary = strings.Fields(`
+	the function error test file build run go code fix add update change return value
+	package import module config server client request response handler cache index
+	search query token session project source message user assistant tool output
+	struct interface method field type string int slice map channel goroutine mutex
+	context deadline timeout retry backoff logger debug warning panic recover defer
+	database sqlite migration schema table column row transaction commit rollback
+	http json yaml parse encode decode marshal unmarshal stream buffer reader writer
+	refactor rename extract inline deprecate benchmark profile allocate latency
+	kubernetes terraform webpack typescript react postgres redis grpc protobuf docker
+	bm25 tokenizer stemming levenshtein trigram bloom hyperloglog quantile histogram
+`)
+
+// benchContent returns the text of a synthetic session of about words words
+func benchContent(rng *rand.Rand, words int) string {
+	var text strings.Builder
+	for i := 0; i < words; i++ {
+		// Squaring skews the picks toward the start of the vocabulary
+		f := rng.Float64()
+		text.WriteString(benchVocabulary[int(f*f*float64(len(benchVocabulary)))])
+		if i%12 == 11 {
+			text.WriteString(".\n")
+		} else {
+			text.WriteByte(' ')
+		}
+	}
+	return text.String()
+}
+
+// benchSessions returns count synthetic sessions with their text, all from one file in
+// dir
+func benchSessions(b *testing.B, dir string, count int) ([]adapters.Session, []string) {
+	b.Helper()
+	filePath := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("{}\n"), 0o600); err != nil {
+		b.Fatalf("failed to write session file: %v", err)
+	}
+	rng := rand.New(rand.NewSource(1))
+	sources := []string{"claude", "codex", "gemini", "opencode"}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	sessions := make([]adapters.Session, count)
+	contents := make([]string, count)
+	for i := range sessions {
+		contents[i] = benchContent(rng, 100+rng.Intn(400))
+		sessions[i] = adapters.Session{
+			ID:           fmt.Sprintf("bench-%05d", i),
+			Source:       sources[i%len(sources)],
+			ProjectPath:  fmt.Sprintf("/home/user/project-%d", i%50),
+			FilePath:     filePath,
+			FirstMessage: contents[i][:60],
+			Timestamp:    start.Add(time.Duration(i) * time.Minute),
+			MessageCount: 10 + i%40,
+		}
+	}
+	return sessions, contents
+}
+
+// newBenchCache returns a cache in a temporary directory, closed after the benchmark
+func newBenchCache(b *testing.B) *Cache {
+	b.Helper()
+	cache, err := NewCache(filepath.Join(b.TempDir(), "cache.db"))
+	if err != nil {
+		b.Fatalf("NewCache failed: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = cache.Close()
+	})
+	return cache
+}
+
+// benchIndex is a cache of benchSessionCount sessions shared by the benchmarks that
+// only need one to exist, since indexing it takes a while. Its directory is removed by
+// TestMain.
+var benchIndex struct {
+	once     sync.Once
+	dir      string
+	cache    *Cache
+	sessions []adapters.Session
+	contents []string
+	err      error
+}
+
+// sharedBenchCache returns the shared cache and the sessions indexed in it, indexing
+// them the first time
+func sharedBenchCache(b *testing.B) (*Cache, []adapters.Session, []string) {
+	b.Helper()
+	benchIndex.once.Do(func() {
+		benchIndex.dir, benchIndex.err = os.MkdirTemp("", "ai-sessions-bench-")
+		if benchIndex.err != nil {
+			return
+		}
+		benchIndex.sessions, benchIndex.contents = benchSessions(b, benchIndex.dir, *benchSessionCount)
+		benchIndex.cache, benchIndex.err = NewCache(filepath.Join(benchIndex.dir, "cache.db"))
+		if benchIndex.err != nil {
+			return
+		}
+		for i, session := range benchIndex.sessions {
+			if benchIndex.err = benchIndex.cache.IndexSession(session, benchIndex.contents[i]); benchIndex.err != nil {
+				return
+			}
+		}
+	})
+	if benchIndex.err != nil {
+		b.Fatalf("failed to build the benchmark index: %v", benchIndex.err)
+	}
+	return benchIndex.cache, benchIndex.sessions, benchIndex.contents
+}
+
+func TestMain(m *testing.M) {
+	code := m.Run()
+	if benchIndex.cache != nil {
+		_ = benchIndex.cache.Close()
+	}
+	if benchIndex.dir != "" {
+		_ = os.RemoveAll(benchIndex.dir)
+	}
+	os.Exit(code)
+}
+
+func indexBenchSessions(b *testing.B, cache *Cache, sessions []adapters.Session, contents []string) {
+	b.Helper()
+	for i, session := range sessions {
+		if err := cache.IndexSession(session, contents[i]); err != nil {
+			b.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	content := benchContent(rand.New(rand.NewSource(1)), 500)
+	b.SetBytes(int64(len(content)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TermFrequency(Tokenize(content))
+	}
+}
+
+func BenchmarkBM25Score(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	tokens := Tokenize(benchContent(rng, 300))
+	termFreqs := TermFrequency(tokens)
+	docFreqs := make(map[string]int, len(benchVocabulary))
+	for _, term := range benchVocabulary {
+		docFreqs[term] = 1 + rng.Intn(*benchSessionCount)
+	}
+	queryTerms := Tokenize("sqlite transaction timeout retry")
+	scorer := NewBM25Scorer(300, *benchSessionCount)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scorer.Score(queryTerms, termFreqs, len(tokens), docFreqs)
+	}
+}
+
+// BenchmarkIndexSessions indexes benchSessionCount sessions into an empty cache per
+// iteration
+func BenchmarkIndexSessions(b *testing.B) {
+	sessions, contents := benchSessions(b, b.TempDir(), *benchSessionCount)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		cache := newBenchCache(b)
+		b.StartTimer()
+		indexBenchSessions(b, cache, sessions, contents)
+	}
+	b.ReportMetric(float64(b.Elapsed().Microseconds())/float64(b.N*(*benchSessionCount)), "µs/session")
+}
+
+// BenchmarkReindexSession indexes one session again into a cache of benchSessionCount
+// sessions, as when a session in progress grows
+func BenchmarkReindexSession(b *testing.B) {
+	cache, sessions, contents := sharedBenchCache(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := i % len(sessions)
+		if err := cache.IndexSession(sessions[n], contents[n]); err != nil {
+			b.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+}
+
+// BenchmarkSearch measures query latency over a cache of benchSessionCount sessions
+func BenchmarkSearch(b *testing.B) {
+	cache, _, _ := sharedBenchCache(b)
+
+	queries := []struct {
+		name  string
+		query string
+		opts  SearchOptions
+	}{
+		{name: "common term", query: "function"},
+		{name: "rare term", query: "hyperloglog"},
+		{name: "several terms", query: "sqlite transaction rollback timeout"},
+		{name: "source filter", query: "cache index", opts: SearchOptions{Source: "codex"}},
+		{name: "project filter", query: "cache index", opts: SearchOptions{ProjectPath: "project-7"}},
+		{name: "field filters", query: "source:claude project:project-3 migration schema"},
+		{name: "no match", query: "xylophone"},
+	}
+	for _, q := range queries {
+		b.Run(q.name, func(b *testing.B) {
+			opts := q.opts
+			opts.Limit = 10
+			for i := 0; i < b.N; i++ {
+				if _, err := cache.SearchWithOptions(q.query, opts); err != nil {
+					b.Fatalf("SearchWithOptions(%q) failed: %v", q.query, err)
+				}
+			}
+		})
+	}
+
+	b.Run("suggest", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := cache.Suggest("sqlte transacton"); err != nil {
+				b.Fatalf("Suggest failed: %v", err)
+			}
+		}
+	})
+}
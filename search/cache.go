@@ -1,6 +1,7 @@
 package search
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"fmt"
@@ -8,59 +9,200 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	_ "github.com/mattn/go-sqlite3"
 	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+	"github.com/yoavf/ai-sessions-mcp/textutil"
 )
 
 //go:embed schema.sql
 var schemaSQL string
 
+// busyTimeout is how long a connection waits for another one, possibly of another
+// server sharing the cache, to finish writing before failing with SQLITE_BUSY
+const busyTimeout = 10 * time.Second
+
 // Cache manages the search index and session cache
 type Cache struct {
-	db *sql.DB
+	db           *sql.DB
+	cipher       *cacheCipher   // Encrypts session text, nil for a plaintext cache
+	metadataOnly bool           // Leaves the text of sessions out of the cache
+	dictionary   termDictionary // Indexed terms, for suggesting corrections
+
+	// memoryConn keeps an in-memory cache alive: SQLite frees it when its last
+	// connection closes, which the connection pool may otherwise do when idle
+	memoryConn *sql.Conn
 }
 
 // NewCache creates a new search cache with SQLite backend
 func NewCache(dbPath string) (*Cache, error) {
+	return openCache(dbPath, nil)
+}
+
+// NewEncryptedCache opens a search cache whose session text is encrypted with key, which
+// must be KeySize bytes. A cache is created encrypted or not, and must always be opened
+// the same way, with the same key.
+func NewEncryptedCache(dbPath string, key []byte) (*Cache, error) {
+	cipher, err := newCacheCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return openCache(dbPath, cipher)
+}
+
+func openCache(dbPath string, cipher *cacheCipher) (*Cache, error) {
 	// Ensure directory exists
 	if err := os.MkdirAll(filepath.Dir(dbPath), 0755); err != nil {
 		return nil, fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", cacheDSN(dbPath))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	return newCache(db, cipher)
+}
 
-	// Initialize schema
-	if _, err := db.Exec(schemaSQL); err != nil {
+// memoryCaches counts the in-memory caches opened, to name each one
+var memoryCaches atomic.Int64
+
+// NewMemoryCache creates a search cache held in memory, for containers and CI where
+// writing to disk is undesirable. It supports everything a cache on disk does, and is
+// gone once closed.
+func NewMemoryCache() (*Cache, error) {
+	// SQLite's memdb VFS shares the database between the connections of the pool, and
+	// locks it like a file, so writers wait for each other as with a cache on disk
+	name := fmt.Sprintf("/ai-sessions-%d", memoryCaches.Add(1))
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?vfs=memdb&_busy_timeout=%d&_txlock=immediate", name, busyTimeout.Milliseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
 		db.Close()
-		return nil, fmt.Errorf("failed to initialize schema: %w", err)
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	cache, err := newCache(db, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
 	}
+	cache.memoryConn = conn
+	return cache, nil
+}
 
-	return &Cache{db: db}, nil
+// newCache sets up a cache on an open database, closing it on failure
+func newCache(db *sql.DB, cipher *cacheCipher) (*Cache, error) {
+	// Upgrade the schema of caches created by earlier versions in place
+	if err := migrate(db, migrations); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	cache := &Cache{db: db, cipher: cipher}
+	if err := cache.checkKey(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return cache, nil
+}
+
+// cacheDSN returns the connection string of the cache at dbPath. Several servers, one
+// per editor window, may share a cache:
+//   - WAL mode lets them read while another one writes
+//   - the busy timeout makes a writer wait for the others instead of failing
+//   - transactions take the write lock when they begin, so those that read before
+//     writing (such as updating the search statistics) run one at a time rather than
+//     failing when another one wrote in between
+func cacheDSN(dbPath string) string {
+	return fmt.Sprintf("%s?_journal_mode=WAL&_busy_timeout=%d&_txlock=immediate", dbPath, busyTimeout.Milliseconds())
+}
+
+// SetMetadataOnly sets whether the text of indexed sessions is left out of the cache,
+// keeping only their metadata and term statistics. Snippets are then extracted from the
+// text given by SearchOptions.LoadContent. Turning it on deletes the text already
+// stored; turning it off reindexes the sessions indexed without their text.
+func (c *Cache) SetMetadataOnly(on bool) error {
+	c.metadataOnly = on
+	if !on {
+		if _, err := c.db.Exec("UPDATE sessions SET file_mtime = 0 WHERE content = '' AND doc_length > 0"); err != nil {
+			return fmt.Errorf("failed to update cache: %w", err)
+		}
+		return nil
+	}
+
+	result, err := c.db.Exec("UPDATE sessions SET content = '' WHERE content != ''")
+	if err != nil {
+		return fmt.Errorf("failed to update cache: %w", err)
+	}
+	// Deleted text stays in the database file's free pages until it is rebuilt, and in
+	// the write-ahead log until it is checkpointed
+	if n, _ := result.RowsAffected(); n > 0 {
+		if _, err := c.db.Exec("VACUUM"); err != nil {
+			return fmt.Errorf("failed to compact cache: %w", err)
+		}
+		if _, err := c.db.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+			return fmt.Errorf("failed to compact cache: %w", err)
+		}
+	}
+	return nil
 }
 
 // Close closes the database connection
 func (c *Cache) Close() error {
+	if c.memoryConn != nil {
+		c.memoryConn.Close()
+	}
 	return c.db.Close()
 }
 
+// InMemory reports whether the cache is held in memory (see NewMemoryCache)
+func (c *Cache) InMemory() bool {
+	return c.memoryConn != nil
+}
+
+// SessionDetails is what a session did, indexed with it for lookups beyond its text
+type SessionDetails struct {
+	Files   []analysis.FileActivity // Files it read or modified
+	Commits []analysis.Commit       // Git commits it created
+	Errors  []analysis.SessionError // Errors in the output of the tools it ran
+
+	// RoleContent is the text of its messages by role (user, assistant, tool, system),
+	// for searches scoped to a role
+	RoleContent map[string]string
+}
+
 // IndexSession indexes a session for searching
 func (c *Cache) IndexSession(session adapters.Session, content string) error {
-	tx, err := c.db.Begin()
-	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
-	}
-	defer tx.Rollback()
+	return c.IndexSessionWithDetails(context.Background(), session, content, SessionDetails{})
+}
 
+// IndexSessionWithDetails indexes a session for searching along with the files it touched,
+// the commits it created, the errors it ran into, and its keywords. The session is indexed in a transaction, rolled back if ctx
+// is done before it commits.
+func (c *Cache) IndexSessionWithDetails(ctx context.Context, session adapters.Session, content string, details SessionDetails) error {
 	// Tokenize content
 	tokens := Tokenize(content)
 	termFreqs := TermFrequency(tokens)
 	docLength := len(tokens)
 
+	// Keywords are weighed against the other sessions, read before the transaction starts
+	keywords, err := c.sessionKeywords(session.ID, termFreqs)
+	if err != nil {
+		return fmt.Errorf("failed to compute keywords: %w", err)
+	}
+
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Get file modification time
 	fileInfo, err := os.Stat(session.FilePath)
 	if err != nil {
@@ -68,13 +210,19 @@ func (c *Cache) IndexSession(session adapters.Session, content string) error {
 	}
 
 	// Insert or update session metadata with content
+	storedContent := c.sealText(content)
+	if c.metadataOnly {
+		storedContent = ""
+	}
 	_, err = tx.Exec(`
 		INSERT OR REPLACE INTO sessions
-		(id, source, project_path, file_path, first_message, summary, timestamp, last_indexed, file_mtime, doc_length, content)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, session.ID, session.Source, session.ProjectPath, session.FilePath,
-		session.FirstMessage, session.Summary, session.Timestamp.Unix(),
-		time.Now().Unix(), fileInfo.ModTime().Unix(), docLength, content)
+		(id, source, project_path, repo, branch, file_path, first_message, summary, title, timestamp, message_count, file_size,
+		 last_indexed, file_mtime, doc_length, content, content_hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, session.ID, session.Source, session.ProjectPath, session.Repo, session.Branch, session.FilePath,
+		c.sealText(session.FirstMessage), c.sealText(session.Summary), c.sealText(session.Title), session.Timestamp.Unix(),
+		session.MessageCount, session.FileSize, time.Now().Unix(), fileInfo.ModTime().Unix(), docLength, storedContent,
+		c.sealContentHash(contentHash(content)))
 
 	if err != nil {
 		return fmt.Errorf("failed to insert session: %w", err)
@@ -93,11 +241,81 @@ func (c *Cache) IndexSession(session adapters.Session, content string) error {
 	defer stmt.Close()
 
 	for term, freq := range termFreqs {
-		if _, err = stmt.Exec(term, session.ID, freq); err != nil {
+		if _, err = stmt.Exec(c.sealTerm(term), session.ID, freq); err != nil {
 			return fmt.Errorf("failed to insert term: %w", err)
 		}
 	}
 
+	// Replace the terms of this session by role
+	if _, err = tx.Exec("DELETE FROM role_term_index WHERE session_id = ?", session.ID); err != nil {
+		return fmt.Errorf("failed to delete old role terms: %w", err)
+	}
+	roleStmt, err := tx.Prepare("INSERT INTO role_term_index (term, session_id, role, term_frequency) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer roleStmt.Close()
+
+	for role, text := range details.RoleContent {
+		for term, freq := range TermFrequency(Tokenize(text)) {
+			if _, err = roleStmt.Exec(c.sealTerm(term), session.ID, role, freq); err != nil {
+				return fmt.Errorf("failed to insert role term: %w", err)
+			}
+		}
+	}
+
+	// Replace file activity for this session
+	if _, err = tx.Exec("DELETE FROM session_files WHERE session_id = ?", session.ID); err != nil {
+		return fmt.Errorf("failed to delete old file index: %w", err)
+	}
+
+	fileStmt, err := tx.Prepare("INSERT INTO session_files (session_id, path, operation, count) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer fileStmt.Close()
+
+	for _, file := range details.Files {
+		for op, count := range file.Operations {
+			if _, err = fileStmt.Exec(session.ID, file.Path, op, count); err != nil {
+				return fmt.Errorf("failed to insert file: %w", err)
+			}
+		}
+	}
+
+	// Replace the commits of this session
+	if _, err = tx.Exec("DELETE FROM session_commits WHERE session_id = ?", session.ID); err != nil {
+		return fmt.Errorf("failed to delete old commits: %w", err)
+	}
+	for _, commit := range details.Commits {
+		if _, err = tx.Exec("INSERT OR REPLACE INTO session_commits (session_id, hash, branch, subject, timestamp) VALUES (?, ?, ?, ?, ?)",
+			session.ID, commit.Hash, commit.Branch, c.sealText(commit.Subject), commit.Timestamp.Unix()); err != nil {
+			return fmt.Errorf("failed to insert commit: %w", err)
+		}
+	}
+
+	// Replace the errors of this session
+	if _, err = tx.Exec("DELETE FROM session_errors WHERE session_id = ?", session.ID); err != nil {
+		return fmt.Errorf("failed to delete old errors: %w", err)
+	}
+	for position, sessionErr := range details.Errors {
+		if _, err = tx.Exec("INSERT INTO session_errors (session_id, position, kind, message, count, timestamp) VALUES (?, ?, ?, ?, ?, ?)",
+			session.ID, position, sessionErr.Kind, c.sealText(sessionErr.Message), sessionErr.Count, sessionErr.Timestamp.Unix()); err != nil {
+			return fmt.Errorf("failed to insert error: %w", err)
+		}
+	}
+
+	// Replace the keywords of this session
+	if _, err = tx.Exec("DELETE FROM session_keywords WHERE session_id = ?", session.ID); err != nil {
+		return fmt.Errorf("failed to delete old keywords: %w", err)
+	}
+	for rank, keyword := range keywords {
+		if _, err = tx.Exec("INSERT INTO session_keywords (session_id, keyword, rank) VALUES (?, ?, ?)",
+			session.ID, c.sealTerm(keyword), rank); err != nil {
+			return fmt.Errorf("failed to insert keyword: %w", err)
+		}
+	}
+
 	// Update global stats
 	if err := c.updateStats(tx); err != nil {
 		return fmt.Errorf("failed to update stats: %w", err)
@@ -126,16 +344,181 @@ func (c *Cache) NeedsReindex(sessionID string, filePath string) (bool, error) {
 	return fileInfo.ModTime().Unix() > cachedMtime, nil
 }
 
+// sessionTables are the tables holding what is indexed for each session, besides the
+// sessions table
+var sessionTables = []string{"term_index", "role_term_index", "session_files", "session_commits", "session_keywords", "session_errors"}
+
+// RemoveProjects removes the indexed sessions of the projects for which remove returns
+// true, returning how many were removed. Tags and notes are kept.
+func (c *Cache) RemoveProjects(remove func(projectPath string) bool) (int, error) {
+	projects, err := c.projectPaths(remove)
+	if err != nil {
+		return 0, err
+	}
+	if len(projects) == 0 {
+		return 0, nil
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed := 0
+	for _, projectPath := range projects {
+		for _, table := range sessionTables {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE session_id IN (SELECT id FROM sessions WHERE project_path = ?)", projectPath); err != nil {
+				return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM session_paths WHERE (source, session_id) IN (SELECT source, id FROM sessions WHERE project_path = ?)", projectPath); err != nil {
+			return 0, fmt.Errorf("failed to delete session paths: %w", err)
+		}
+		if _, err := tx.Exec("DELETE FROM session_metadata WHERE file_path IN (SELECT file_path FROM sessions WHERE project_path = ?)", projectPath); err != nil {
+			return 0, fmt.Errorf("failed to delete session metadata: %w", err)
+		}
+		result, err := tx.Exec("DELETE FROM sessions WHERE project_path = ?", projectPath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete sessions: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += int(n)
+	}
+	if err := c.updateStats(tx); err != nil {
+		return 0, fmt.Errorf("failed to update stats: %w", err)
+	}
+	return removed, tx.Commit()
+}
+
+// RemoveSessions removes indexed sessions, such as sessions whose files were deleted,
+// returning how many were removed. Tags and notes are kept.
+func (c *Cache) RemoveSessions(sessions []adapters.Session) (int, error) {
+	tx, err := c.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	removed := 0
+	for _, session := range sessions {
+		for _, table := range sessionTables {
+			if _, err := tx.Exec("DELETE FROM "+table+" WHERE session_id = ?", session.ID); err != nil {
+				return 0, fmt.Errorf("failed to delete from %s: %w", table, err)
+			}
+		}
+		if _, err := tx.Exec("DELETE FROM session_paths WHERE source = ? AND session_id = ?", session.Source, session.ID); err != nil {
+			return 0, fmt.Errorf("failed to delete session path: %w", err)
+		}
+		if session.FilePath != "" {
+			if _, err := tx.Exec("DELETE FROM session_metadata WHERE file_path = ?", session.FilePath); err != nil {
+				return 0, fmt.Errorf("failed to delete session metadata: %w", err)
+			}
+		}
+		result, err := tx.Exec("DELETE FROM sessions WHERE id = ?", session.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to delete session: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += int(n)
+	}
+	if err := c.updateStats(tx); err != nil {
+		return 0, fmt.Errorf("failed to update stats: %w", err)
+	}
+	return removed, tx.Commit()
+}
+
+// projectPaths returns the project paths of indexed sessions for which match returns true
+func (c *Cache) projectPaths(match func(projectPath string) bool) ([]string, error) {
+	rows, err := c.db.Query("SELECT DISTINCT project_path FROM sessions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []string
+	for rows.Next() {
+		var projectPath string
+		if err := rows.Scan(&projectPath); err != nil {
+			return nil, fmt.Errorf("failed to scan project: %w", err)
+		}
+		if match(projectPath) {
+			projects = append(projects, projectPath)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list projects: %w", err)
+	}
+	return projects, nil
+}
+
+// projectCondition returns an SQL condition selecting the sessions whose project_path
+// column matches a project filter (see adapters.ProjectFilter), and its arguments
+func (c *Cache) projectCondition(column, filter string) (string, []interface{}, error) {
+	projects, err := c.projectPaths(adapters.NewProjectFilter(filter).Matches)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(projects) == 0 {
+		return "0", nil, nil
+	}
+	args := make([]interface{}, len(projects))
+	for i, projectPath := range projects {
+		args[i] = projectPath
+	}
+	return column + " IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(projects)), ", ") + ")", args, nil
+}
+
 // SearchResult represents a search result with score and matching snippet
 type SearchResult struct {
-	Session adapters.Session
-	Score   float64
-	Snippet string // Contextual snippet showing where the match occurred
+	Session  adapters.Session
+	Score    float64
+	Snippet  string   // Contextual snippet showing where the match occurred
+	Snippets []string // All extracted snippets (Snippet is the first of these)
+}
+
+// SearchOptions holds filters and presentation settings for a search
+type SearchOptions struct {
+	Source      string
+	ProjectPath string // A project filter (see adapters.ProjectFilter)
+	Limit       int
+	Tag         string // Only match sessions carrying this tag
+	Keyword     string // Only match sessions having this keyword
+	Snippets    SnippetOptions
+
+	ExcludeSources  []string // Leave out sessions of these sources
+	ExcludeProjects []string // Leave out sessions of these projects (see adapters.ProjectFilter)
+
+	// CollapseDuplicates returns one result for sessions with the same text, the best
+	// ranked, with the others in its Duplicates
+	CollapseDuplicates bool
+
+	// LoadContent returns the text of a session whose text isn't stored in the cache
+	// (see SetMetadataOnly), to extract snippets from. Without it, such sessions get
+	// snippets from their first message.
+	LoadContent func(session adapters.Session) (string, error)
 }
 
 // Search performs BM25-ranked search across indexed sessions
 func (c *Cache) Search(query string, source string, projectPath string, limit int) ([]SearchResult, error) {
-	queryTerms := Tokenize(query)
+	return c.SearchWithOptions(query, SearchOptions{
+		Source:      source,
+		ProjectPath: projectPath,
+		Limit:       limit,
+	})
+}
+
+// SearchWithOptions performs BM25-ranked search using the given filters and snippet settings
+func (c *Cache) SearchWithOptions(query string, opts SearchOptions) ([]SearchResult, error) {
+	source := opts.Source
+	projectPath := opts.ProjectPath
+	limit := opts.Limit
+
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	queryTerms := Tokenize(parsed.Text)
 	if len(queryTerms) == 0 {
 		return nil, fmt.Errorf("no valid search terms")
 	}
@@ -154,23 +537,32 @@ func (c *Cache) Search(query string, source string, projectPath string, limit in
 		return nil, err
 	}
 
-	// Build SQL query with filters - include content for snippet extraction
-	sqlQuery := `
-		SELECT DISTINCT s.id, s.source, s.project_path, s.file_path,
-		       s.first_message, s.summary, s.timestamp, s.doc_length, s.content
-		FROM sessions s
-		JOIN term_index ti ON s.id = ti.session_id
-		WHERE ti.term IN (`
-
-	args := make([]interface{}, 0)
+	// Read the frequencies of the query terms in every matching session in one query
+	sealedTerms := c.sealTerms(queryTerms)
+	termsBySealed := make(map[string]string, len(queryTerms))
 	for i, term := range queryTerms {
-		if i > 0 {
-			sqlQuery += ", "
+		termsBySealed[sealedTerms[i].(string)] = term
+	}
+	termPlaceholders := strings.TrimSuffix(strings.Repeat("?, ", len(queryTerms)), ", ")
+	sqlQuery := `
+		SELECT s.id, s.source, s.content_hash, s.timestamp, s.doc_length, ti.term, ti.term_frequency
+		FROM term_index ti
+		JOIN sessions s ON s.id = ti.session_id
+		WHERE ti.term IN (` + termPlaceholders + ")"
+	args := sealedTerms
+	if len(parsed.Roles) > 0 {
+		// Only the occurrences of the terms in messages of the roles count
+		sqlQuery = `
+		SELECT s.id, s.source, s.content_hash, s.timestamp, s.doc_length, ti.term, ti.term_frequency
+		FROM (SELECT term, session_id, SUM(term_frequency) AS term_frequency FROM role_term_index
+		      WHERE term IN (` + termPlaceholders + ") AND role IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(parsed.Roles)), ", ") + `)
+		      GROUP BY term, session_id) ti
+		JOIN sessions s ON s.id = ti.session_id
+		WHERE 1`
+		for _, role := range parsed.Roles {
+			args = append(args, role)
 		}
-		sqlQuery += "?"
-		args = append(args, term)
 	}
-	sqlQuery += ")"
 
 	// Add filters
 	if source != "" {
@@ -178,97 +570,299 @@ func (c *Cache) Search(query string, source string, projectPath string, limit in
 		args = append(args, source)
 	}
 	if projectPath != "" {
-		sqlQuery += " AND s.project_path = ?"
-		args = append(args, projectPath)
+		condition, projectArgs, err := c.projectCondition("s.project_path", projectPath)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += " AND " + condition
+		args = append(args, projectArgs...)
 	}
-
-	rows, err := c.db.Query(sqlQuery, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search: %w", err)
+	if opts.Tag != "" {
+		tag, err := NormalizeTag(opts.Tag)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += " AND EXISTS (SELECT 1 FROM session_tags t WHERE t.source = s.source AND t.session_id = s.id AND t.tag = ?)"
+		args = append(args, tag)
 	}
-	defer rows.Close()
-
-	var results []SearchResult
-
-	for rows.Next() {
-		var session adapters.Session
-		var timestampUnix int64
-		var docLength int
-		var content string
 
-		err := rows.Scan(&session.ID, &session.Source, &session.ProjectPath,
-			&session.FilePath, &session.FirstMessage, &session.Summary,
-			&timestampUnix, &docLength, &content)
+	if opts.Keyword != "" {
+		condition, arg := c.keywordCondition("s.id", opts.Keyword)
+		sqlQuery += " AND " + condition
+		args = append(args, arg)
+	}
+	if len(opts.ExcludeSources) > 0 || len(opts.ExcludeProjects) > 0 {
+		condition, excludeArgs, err := c.exclusionConditions(opts.ExcludeSources, opts.ExcludeProjects)
 		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
+			return nil, err
 		}
-
-		session.Timestamp = time.Unix(timestampUnix, 0)
-
-		// Get term frequencies for this document
-		termFreqs, err := c.getTermFrequencies(session.ID, queryTerms)
+		sqlQuery += " AND " + condition
+		args = append(args, excludeArgs...)
+	}
+	if !parsed.Empty() {
+		condition, filterArgs, err := c.filterConditions(parsed)
 		if err != nil {
 			return nil, err
 		}
+		if condition != "" {
+			sqlQuery += " AND " + condition
+			args = append(args, filterArgs...)
+		}
+	}
 
-		// Calculate BM25 score
-		score := scorer.Score(queryTerms, termFreqs, docLength, docFreqs)
-
-		// Extract snippet from cached content
-		snippet := GetSnippet(content, queryTerms, 300)
-
-		results = append(results, SearchResult{
-			Session: session,
-			Score:   score,
-			Snippet: snippet,
-		})
+	matches, err := c.scanTermMatches(sqlQuery, args, termsBySealed)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort by score (descending)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
+	// Score each session once, then rank them, newest first among equal scores
+	for _, match := range matches {
+		match.score = scorer.Score(queryTerms, match.termFreqs, match.docLength, docFreqs)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		if matches[i].timestamp != matches[j].timestamp {
+			return matches[i].timestamp > matches[j].timestamp
+		}
+		return matches[i].id < matches[j].id
 	})
 
+	var copies map[string][]string
+	if opts.CollapseDuplicates {
+		matches, copies = collapseDuplicateMatches(matches)
+	}
+
 	// Apply limit
-	if limit > 0 && len(results) > limit {
-		results = results[:limit]
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	// Only the sessions returned are read, with their text
+	ids := make([]string, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.id)
+		ids = append(ids, copies[match.id]...)
+	}
+	sessions, contents, err := c.loadResultSessions(ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]SearchResult, 0, len(matches))
+	for _, match := range matches {
+		session, ok := sessions[match.id]
+		if !ok {
+			continue
+		}
+		for _, id := range copies[match.id] {
+			if duplicate, ok := sessions[id]; ok {
+				session.Duplicates = append(session.Duplicates, adapters.SessionCopy{ID: duplicate.ID, FilePath: duplicate.FilePath})
+			}
+		}
+		results = append(results, SearchResult{Session: session, Score: match.score})
+	}
+
+	// Extract snippets from the cached content, or from the session itself when the
+	// cache doesn't store it
+	for i := range results {
+		content := contents[results[i].Session.ID]
+		if content == "" && opts.LoadContent != nil {
+			if loaded, err := opts.LoadContent(results[i].Session); err == nil {
+				content = loaded
+			}
+		}
+		if content == "" {
+			content = results[i].Session.FirstMessage
+		}
+		results[i].Snippets = GetSnippets(content, queryTerms, opts.Snippets)
+		results[i].Snippet = results[i].Snippets[0]
 	}
 
 	return results, nil
 }
 
+// SnippetOptions controls how snippets are extracted and highlighted
+type SnippetOptions struct {
+	MaxSnippets   int    // Maximum number of snippets to return (default: 1)
+	Length        int    // Approximate length of each snippet in bytes (default: 300)
+	HighlightPre  string // Inserted before each matched term (empty disables highlighting)
+	HighlightPost string // Inserted after each matched term
+}
+
 // GetSnippet extracts a contextual snippet from content around the first occurrence of query terms
 func GetSnippet(content string, queryTerms []string, maxLength int) string {
 	if maxLength == 0 {
 		maxLength = 300
 	}
 
-	contentLower := strings.ToLower(content)
+	// Find the earliest occurrence of any query term
+	matches := findTermMatches(newLowerText(content), queryTerms)
 
-	// Find the earliest position of any query term
-	firstPos := len(content)
-	matchedTerm := ""
+	// If no match found (shouldn't happen), return start of content
+	if len(matches) == 0 {
+		if len(content) <= maxLength {
+			return content
+		}
+		return content[:textutil.ClusterStart(content, maxLength)] + "..."
+	}
+
+	start, end := snippetWindow(content, matches[0].pos, matches[0].end-matches[0].pos, maxLength)
+	return renderSnippet(content, start, end, nil, "", "")
+}
+
+// GetSnippets extracts up to opts.MaxSnippets non-overlapping snippets from content.
+// Windows covering different query terms are preferred over repeated hits of the same
+// term, and snippets are returned in the order they appear in the content.
+func GetSnippets(content string, queryTerms []string, opts SnippetOptions) []string {
+	if opts.MaxSnippets <= 0 {
+		opts.MaxSnippets = 1
+	}
+	if opts.Length <= 0 {
+		opts.Length = 300
+	}
+
+	matches := findTermMatches(newLowerText(content), queryTerms)
+	if len(matches) == 0 {
+		return []string{GetSnippet(content, queryTerms, opts.Length)}
+	}
+
+	type window struct{ start, end int }
+	var windows []window
+
+	overlaps := func(start, end int) bool {
+		for _, w := range windows {
+			if start < w.end && w.start < end {
+				return true
+			}
+		}
+		return false
+	}
 
+	tryAdd := func(m termMatch) {
+		if len(windows) >= opts.MaxSnippets {
+			return
+		}
+		start, end := snippetWindow(content, m.pos, m.end-m.pos, opts.Length)
+		if !overlaps(start, end) {
+			windows = append(windows, window{start, end})
+		}
+	}
+
+	// First pass: one window per distinct term, in query order
+	covered := make(map[string]bool)
 	for _, term := range queryTerms {
-		pos := strings.Index(contentLower, term)
-		if pos != -1 && pos < firstPos {
-			firstPos = pos
-			matchedTerm = term
+		if covered[term] {
+			continue
+		}
+		for _, m := range matches {
+			if m.term == term {
+				covered[term] = true
+				tryAdd(m)
+				break
+			}
 		}
 	}
 
-	// If no match found (shouldn't happen), return start of content
-	if firstPos == len(content) {
-		if len(content) <= maxLength {
-			return content
+	// Second pass: fill remaining slots with further hits in document order
+	for _, m := range matches {
+		tryAdd(m)
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].start < windows[j].start
+	})
+
+	snippets := make([]string, len(windows))
+	for i, w := range windows {
+		snippets[i] = renderSnippet(content, w.start, w.end, queryTerms, opts.HighlightPre, opts.HighlightPost)
+	}
+	return snippets
+}
+
+// termMatch records a single occurrence of a query term in content, as the bytes
+// content[pos:end]
+type termMatch struct {
+	pos  int
+	end  int
+	term string
+}
+
+// lowerText is the lowercase form of a text for case-insensitive matching, with where
+// each of its bytes comes from in the original. Lowercasing can change the length of a
+// character (Ⱥ grows from 2 bytes to 3, İ shrinks to 1), so offsets in the lowercase
+// form can't index the original directly.
+type lowerText struct {
+	text    string
+	offsets []int // Where the character of each byte of text starts in the original, then its length
+}
+
+func newLowerText(s string) lowerText {
+	var sb strings.Builder
+	sb.Grow(len(s))
+	offsets := make([]int, 0, len(s)+1)
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteByte(s[i]) // Keep invalid bytes as they are
+		} else {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+		for len(offsets) < sb.Len() {
+			offsets = append(offsets, i)
 		}
-		return content[:maxLength] + "..."
+		i += size
+	}
+	offsets = append(offsets, len(s))
+	return lowerText{text: sb.String(), offsets: offsets}
+}
+
+// original returns the bytes of the original text that text[start:end] was lowercased
+// from, widened to whole characters
+func (l lowerText) original(start, end int) (int, int) {
+	for end > start && end < len(l.text) && l.offsets[end] == l.offsets[end-1] {
+		end++
 	}
+	return l.offsets[start], l.offsets[end]
+}
 
-	// Calculate snippet boundaries
+// findTermMatches returns every occurrence of the query terms in the original of lower,
+// ordered by position
+func findTermMatches(lower lowerText, queryTerms []string) []termMatch {
+	var matches []termMatch
+	seen := make(map[string]bool)
+	for _, term := range queryTerms {
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+
+		offset := 0
+		for {
+			idx := strings.Index(lower.text[offset:], term)
+			if idx == -1 {
+				break
+			}
+			pos, end := lower.original(offset+idx, offset+idx+len(term))
+			matches = append(matches, termMatch{pos: pos, end: end, term: term})
+			offset += idx + len(term)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].pos == matches[j].pos {
+			return matches[i].end > matches[j].end
+		}
+		return matches[i].pos < matches[j].pos
+	})
+	return matches
+}
+
+// snippetWindow calculates snippet boundaries around a match, snapped to nearby word boundaries
+func snippetWindow(content string, pos, termLen, maxLength int) (int, int) {
 	halfLength := maxLength / 2
-	start := firstPos - halfLength
-	end := firstPos + len(matchedTerm) + halfLength
+	start := pos - halfLength
+	end := pos + termLen + halfLength
 
 	// Adjust boundaries
 	if start < 0 {
@@ -299,7 +893,16 @@ func GetSnippet(content string, queryTerms []string, maxLength int) string {
 		}
 	}
 
+	// Without a space nearby, don't split a character
+	return textutil.ClusterStart(content, start), textutil.ClusterEnd(content, end)
+}
+
+// renderSnippet slices content to the window, highlights query terms and adds ellipses where truncated
+func renderSnippet(content string, start, end int, queryTerms []string, pre, post string) string {
 	snippet := content[start:end]
+	if pre != "" || post != "" {
+		snippet = highlightTerms(snippet, queryTerms, pre, post)
+	}
 
 	// Add ellipsis if truncated
 	if start > 0 {
@@ -312,6 +915,49 @@ func GetSnippet(content string, queryTerms []string, maxLength int) string {
 	return snippet
 }
 
+// highlightTerms wraps every case-insensitive occurrence of the query terms in pre/post markers
+func highlightTerms(text string, queryTerms []string, pre, post string) string {
+	matches := findTermMatches(newLowerText(text), queryTerms)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.pos < last {
+			continue // Overlaps a term we already highlighted
+		}
+		if !isWordBoundary(text, m.pos, m.end) {
+			continue // Only highlight whole tokens, not substrings of longer words
+		}
+		sb.WriteString(text[last:m.pos])
+		sb.WriteString(pre)
+		sb.WriteString(text[m.pos:m.end])
+		sb.WriteString(post)
+		last = m.end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// isWordBoundary reports whether text[start:end] is not directly adjacent to letters or digits
+func isWordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // getStats retrieves global search statistics
 type searchStats struct {
 	totalDocs    int
@@ -364,24 +1010,37 @@ func (c *Cache) updateStats(tx *sql.Tx) error {
 	return nil
 }
 
+// maxQueryTerms bounds the number of terms bound into a single IN (...) clause
+const maxQueryTerms = 500
+
 // getDocumentFrequencies returns the number of documents containing each term
 func (c *Cache) getDocumentFrequencies(terms []string) (map[string]int, error) {
 	freqs := make(map[string]int)
 
-	query := "SELECT term, COUNT(DISTINCT session_id) FROM term_index WHERE term IN ("
-	args := make([]interface{}, len(terms))
-	for i, term := range terms {
-		if i > 0 {
-			query += ", "
+	for start := 0; start < len(terms); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(terms) {
+			end = len(terms)
+		}
+		batch := terms[start:end]
+
+		query := "SELECT term, COUNT(DISTINCT session_id) FROM term_index WHERE term IN ("
+		args := c.sealTerms(batch)
+		query += strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ") GROUP BY term"
+
+		if err := c.scanDocumentFrequencies(freqs, query, args); err != nil {
+			return nil, err
 		}
-		query += "?"
-		args[i] = term
 	}
-	query += ") GROUP BY term"
 
+	return freqs, nil
+}
+
+// scanDocumentFrequencies runs a document frequency query and adds its rows to freqs
+func (c *Cache) scanDocumentFrequencies(freqs map[string]int, query string, args []interface{}) error {
 	rows, err := c.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get document frequencies: %w", err)
+		return fmt.Errorf("failed to get document frequencies: %w", err)
 	}
 	defer rows.Close()
 
@@ -389,43 +1048,122 @@ func (c *Cache) getDocumentFrequencies(terms []string) (map[string]int, error) {
 		var term string
 		var count int
 		if err := rows.Scan(&term, &count); err != nil {
-			return nil, err
+			return err
+		}
+		term, err := c.openTerm(term)
+		if err != nil {
+			return err
 		}
 		freqs[term] = count
 	}
 
-	return freqs, nil
+	return rows.Err()
 }
 
-// getTermFrequencies returns term frequencies for a specific document
-func (c *Cache) getTermFrequencies(sessionID string, terms []string) (map[string]int, error) {
-	freqs := make(map[string]int)
+// termMatches holds the frequencies of the query terms in a session matching a search
+type termMatches struct {
+	id          string
+	source      string
+	contentHash string // Sealed
+	timestamp   int64
+	docLength   int
+	termFreqs   map[string]int
+	score       float64
+}
+
+// scanTermMatches runs a query returning a row per session and query term, and groups
+// the rows by session. termsBySealed maps the terms as stored to the query terms.
+func (c *Cache) scanTermMatches(query string, args []interface{}, termsBySealed map[string]string) ([]*termMatches, error) {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []*termMatches
+	byID := make(map[string]*termMatches)
+	for rows.Next() {
+		var id, source, hash, term string
+		var timestamp int64
+		var docLength, freq int
+		if err := rows.Scan(&id, &source, &hash, &timestamp, &docLength, &term, &freq); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		match, ok := byID[id]
+		if !ok {
+			match = &termMatches{id: id, source: source, contentHash: hash, timestamp: timestamp, docLength: docLength, termFreqs: make(map[string]int)}
+			byID[id] = match
+			matches = append(matches, match)
+		}
+		match.termFreqs[termsBySealed[term]] = freq
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return matches, nil
+}
 
-	query := "SELECT term, term_frequency FROM term_index WHERE session_id = ? AND term IN ("
-	args := []interface{}{sessionID}
-	for i, term := range terms {
-		if i > 0 {
-			query += ", "
+// loadResultSessions reads the sessions with the given IDs, with their keywords, and
+// their stored text, by ID
+func (c *Cache) loadResultSessions(ids []string) (map[string]adapters.Session, map[string]string, error) {
+	sessions := make(map[string]adapters.Session, len(ids))
+	contents := make(map[string]string, len(ids))
+	for start := 0; start < len(ids); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
 		}
-		query += "?"
-		args = append(args, term)
+		query := `
+			SELECT id, source, project_path, repo, branch, file_path, first_message, summary, title, timestamp, message_count, file_size, content, content_hash
+			FROM sessions WHERE id IN (` + strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ")"
+		if err := c.scanResultSessions(query, args, sessions, contents); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	keywords, err := c.SessionKeywords(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	for id, session := range sessions {
+		session.Keywords = keywords[id]
+		sessions[id] = session
 	}
-	query += ")"
+	return sessions, contents, nil
+}
 
+func (c *Cache) scanResultSessions(query string, args []interface{}, sessions map[string]adapters.Session, contents map[string]string) error {
 	rows, err := c.db.Query(query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get term frequencies: %w", err)
+		return fmt.Errorf("failed to load results: %w", err)
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var term string
-		var freq int
-		if err := rows.Scan(&term, &freq); err != nil {
-			return nil, err
+		var session adapters.Session
+		var timestampUnix int64
+		var content string
+		if err := rows.Scan(&session.ID, &session.Source, &session.ProjectPath, &session.Repo, &session.Branch, &session.FilePath,
+			&session.FirstMessage, &session.Summary, &session.Title, &timestampUnix, &session.MessageCount, &session.FileSize, &content, &session.ContentHash); err != nil {
+			return fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := c.openSessionText(&session.FirstMessage, &session.Summary, &session.Title); err != nil {
+			return err
 		}
-		freqs[term] = freq
+		if content, err = c.openText(content); err != nil {
+			return err
+		}
+		if session.ContentHash, err = c.openTerm(session.ContentHash); err != nil {
+			return err
+		}
+		session.Timestamp = time.Unix(timestampUnix, 0)
+		sessions[session.ID] = session
+		contents[session.ID] = content
 	}
-
-	return freqs, nil
+	return rows.Err()
 }
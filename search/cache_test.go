@@ -1,12 +1,16 @@
 package search
 
 import (
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/yoavf/ai-sessions-mcp/adapters"
 )
@@ -75,6 +79,40 @@ func TestGetSnippet(t *testing.T) {
 	}
 }
 
+func TestGetSnippetsCoversDistinctTerms(t *testing.T) {
+	filler := strings.Repeat("filler words here ", 30)
+	content := "Alpha appears first. " + filler + "Alpha again. " + filler + "Then beta shows up at the end."
+	snippets := GetSnippets(content, []string{"alpha", "beta"}, SnippetOptions{MaxSnippets: 2, Length: 60})
+	if len(snippets) != 2 {
+		t.Fatalf("expected 2 snippets, got %d: %q", len(snippets), snippets)
+	}
+	if !strings.Contains(snippets[0], "Alpha appears first") {
+		t.Fatalf("first snippet should cover the first alpha hit, got %q", snippets[0])
+	}
+	if !strings.Contains(snippets[1], "beta") {
+		t.Fatalf("second snippet should cover beta rather than a repeated alpha, got %q", snippets[1])
+	}
+}
+
+func TestGetSnippetsHighlighting(t *testing.T) {
+	content := "Fixing the Flaky test in CI (special case)"
+	snippets := GetSnippets(content, []string{"flaky", "ci"}, SnippetOptions{HighlightPre: "<em>", HighlightPost: "</em>"})
+	if len(snippets) != 1 {
+		t.Fatalf("expected a single snippet by default, got %d", len(snippets))
+	}
+	want := "Fixing the <em>Flaky</em> test in <em>CI</em> (special case)"
+	if snippets[0] != want {
+		t.Fatalf("highlighted snippet=%q want %q", snippets[0], want)
+	}
+}
+
+func TestGetSnippetsNoMatchFallsBackToStart(t *testing.T) {
+	snippets := GetSnippets("nothing relevant", []string{"absent"}, SnippetOptions{MaxSnippets: 3})
+	if len(snippets) != 1 || snippets[0] != "nothing relevant" {
+		t.Fatalf("unexpected fallback snippets: %q", snippets)
+	}
+}
+
 func TestCacheIndexSearchAndNeedsReindex(t *testing.T) {
 	cache := newTempCache(t)
 	tempDir := t.TempDir()
@@ -91,6 +129,8 @@ func TestCacheIndexSearchAndNeedsReindex(t *testing.T) {
 		Summary:      "Summary info",
 		Timestamp:    time.Now(),
 		FilePath:     filePath,
+		MessageCount: 12,
+		FileSize:     4096,
 	}
 
 	content := "Initial intro explains context. Keyword appears in the detailed content block to verify search."
@@ -116,6 +156,9 @@ func TestCacheIndexSearchAndNeedsReindex(t *testing.T) {
 	if !strings.Contains(strings.ToLower(results[0].Snippet), "keyword") {
 		t.Fatalf("snippet missing keyword: %q", results[0].Snippet)
 	}
+	if results[0].Session.MessageCount != 12 || results[0].Session.FileSize != 4096 {
+		t.Fatalf("expected the session's size, got %d messages and %d bytes", results[0].Session.MessageCount, results[0].Session.FileSize)
+	}
 
 	// Ensure source/project filters apply
 	results, err = cache.Search("keyword", "other", "/workspace", 5)
@@ -140,3 +183,426 @@ func TestCacheIndexSearchAndNeedsReindex(t *testing.T) {
 		t.Fatal("expected NeedsReindex to return true after file mtime change")
 	}
 }
+
+func TestCacheRemoveProjects(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	for _, session := range []adapters.Session{
+		{ID: "keep", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: filePath},
+		{ID: "drop", Source: "claude", ProjectPath: "/work/secret", Timestamp: time.Now(), FilePath: filePath},
+	} {
+		if err := cache.IndexSession(session, "shared keyword"); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	secretPath := filepath.Join(t.TempDir(), "secret.jsonl")
+	if err := os.WriteFile(secretPath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	if err := cache.IndexSession(adapters.Session{ID: "secret", Source: "codex", ProjectPath: "/work/secret", Timestamp: time.Now(), FilePath: secretPath}, "other"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+	if err := cache.PutSessionFile("codex", "secret", secretPath); err != nil {
+		t.Fatalf("PutSessionFile failed: %v", err)
+	}
+	if err := cache.PutMetadata("v1:"+secretPath, secretPath, time.Unix(1, 0), 1, []byte("{}")); err != nil {
+		t.Fatalf("PutMetadata failed: %v", err)
+	}
+
+	removed, err := cache.RemoveProjects(func(projectPath string) bool { return projectPath == "/work/secret" })
+	if err != nil || removed != 2 {
+		t.Fatalf("RemoveProjects removed %d (%v), want 2", removed, err)
+	}
+	if _, ok := cache.GetSessionFile("codex", "secret"); ok {
+		t.Fatal("expected the file of a removed session to be forgotten")
+	}
+	if _, ok := cache.GetMetadata("v1:"+secretPath, time.Unix(1, 0), 1); ok {
+		t.Fatal("expected the metadata of a removed session to be dropped")
+	}
+	results, err := cache.Search("keyword", "", "", 10)
+	if err != nil || len(results) != 1 || results[0].Session.ID != "keep" {
+		t.Fatalf("expected only the kept session to be found, got %+v (%v)", results, err)
+	}
+	if needs, _ := cache.NeedsReindex("drop", filePath); !needs {
+		t.Fatal("expected a removed session to need indexing again")
+	}
+}
+
+func TestCacheRemoveSessions(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	keep := adapters.Session{ID: "keep", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: filePath}
+	drop := adapters.Session{ID: "drop", Source: "codex", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: filePath}
+	for _, session := range []adapters.Session{keep, drop} {
+		if err := cache.IndexSession(session, "shared keyword"); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+	if err := cache.PutSessionFile("codex", "drop", filePath); err != nil {
+		t.Fatalf("PutSessionFile failed: %v", err)
+	}
+	if err := cache.PutMetadata("v1:"+filePath, filePath, time.Unix(1, 0), 1, []byte("{}")); err != nil {
+		t.Fatalf("PutMetadata failed: %v", err)
+	}
+
+	removed, err := cache.RemoveSessions([]adapters.Session{drop, {ID: "missing", Source: "codex"}})
+	if err != nil || removed != 1 {
+		t.Fatalf("RemoveSessions removed %d (%v), want 1", removed, err)
+	}
+	results, err := cache.Search("keyword", "", "", 10)
+	if err != nil || len(results) != 1 || results[0].Session.ID != "keep" {
+		t.Fatalf("expected only the kept session to be found, got %+v (%v)", results, err)
+	}
+	if _, ok := cache.GetSessionFile("codex", "drop"); ok {
+		t.Fatal("expected the file of a removed session to be forgotten")
+	}
+	if _, ok := cache.GetMetadata("v1:"+filePath, time.Unix(1, 0), 1); ok {
+		t.Fatal("expected the metadata of a removed session to be dropped")
+	}
+}
+
+func TestCacheMetadataOnly(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "cache.db")
+	cache, err := NewCache(dbPath)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	defer cache.Close()
+	filePath := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/work", FirstMessage: "hello",
+		Timestamp: time.Now(), FilePath: filePath}
+	content := "hello, the quokka migration is done"
+	if err := cache.IndexSession(session, content); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+
+	if err := cache.SetMetadataOnly(true); err != nil {
+		t.Fatalf("SetMetadataOnly failed: %v", err)
+	}
+	if err := cache.IndexSession(session, content); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+	var stored string
+	if err := cache.db.QueryRow("SELECT content FROM sessions WHERE id = 's1'").Scan(&stored); err != nil || stored != "" {
+		t.Fatalf("expected no stored content, got %q (%v)", stored, err)
+	}
+
+	results, err := cache.SearchWithOptions("quokka", SearchOptions{})
+	if err != nil || len(results) != 1 || results[0].Snippet != "hello" {
+		t.Fatalf("expected a match with a first message snippet, got %+v (%v)", results, err)
+	}
+	loaded := 0
+	results, err = cache.SearchWithOptions("quokka", SearchOptions{LoadContent: func(adapters.Session) (string, error) {
+		loaded++
+		return content, nil
+	}})
+	if err != nil || len(results) != 1 || !strings.Contains(results[0].Snippet, "quokka") || loaded != 1 {
+		t.Fatalf("expected a snippet from the loaded content, got %+v (%v)", results, err)
+	}
+
+	if err := cache.SetMetadataOnly(false); err != nil {
+		t.Fatalf("SetMetadataOnly failed: %v", err)
+	}
+	if needs, _ := cache.NeedsReindex("s1", filePath); !needs {
+		t.Fatal("expected sessions indexed without content to be reindexed")
+	}
+}
+
+func TestCacheConcurrentWriters(t *testing.T) {
+	// Two servers sharing a cache, each indexing its own sessions
+	cachePath := filepath.Join(t.TempDir(), "cache.db")
+	caches := make([]*Cache, 2)
+	for i := range caches {
+		cache, err := NewCache(cachePath)
+		if err != nil {
+			t.Fatalf("NewCache failed: %v", err)
+		}
+		defer cache.Close()
+		caches[i] = cache
+	}
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	const perCache = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, len(caches)*perCache)
+	for i, cache := range caches {
+		wg.Add(1)
+		go func(i int, cache *Cache) {
+			defer wg.Done()
+			for j := 0; j < perCache; j++ {
+				session := adapters.Session{ID: fmt.Sprintf("s%d-%d", i, j), Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: time.Now()}
+				errs <- cache.IndexSession(session, "refactor the parser and fix the tests")
+			}
+		}(i, cache)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	var totalDocs float64
+	if err := caches[0].db.QueryRow("SELECT value FROM search_stats WHERE key = 'total_docs'").Scan(&totalDocs); err != nil {
+		t.Fatalf("read stats: %v", err)
+	}
+	if int(totalDocs) != len(caches)*perCache {
+		t.Fatalf("expected total_docs %d, got %v", len(caches)*perCache, totalDocs)
+	}
+	var journalMode string
+	if err := caches[1].db.QueryRow("PRAGMA journal_mode").Scan(&journalMode); err != nil || journalMode != "wal" {
+		t.Fatalf("expected WAL mode, got %q (%v)", journalMode, err)
+	}
+}
+
+func TestSearchRanksAndLimitsResults(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	base := time.Now().Add(-time.Hour)
+	for i := 0; i < 30; i++ {
+		// Session i mentions "parser" i%5+1 times; sessions with the same count differ
+		// only by their timestamp
+		content := strings.Repeat("parser ", i%5+1) + "and some other words about the build"
+		session := adapters.Session{ID: fmt.Sprintf("s%02d", i), Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: base.Add(time.Duration(i) * time.Minute)}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	results, err := cache.Search("parser build", "", "", 4)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.Session.ID)
+		if result.Snippet == "" {
+			t.Errorf("expected a snippet for %s", result.Session.ID)
+		}
+	}
+	// BM25 favors the shortest sessions (one mention), newest first among equal scores
+	if want := "s25 s20 s15 s10"; strings.Join(ids, " ") != want {
+		t.Fatalf("expected %s, got %v", want, ids)
+	}
+	if results[0].Score != results[3].Score {
+		t.Fatalf("expected equal scores, got %v and %v", results[0].Score, results[3].Score)
+	}
+
+	all, err := cache.Search("parser", "", "", 0)
+	if err != nil || len(all) != 30 {
+		t.Fatalf("expected all 30 sessions without a limit, got %d (%v)", len(all), err)
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].Score > all[i-1].Score {
+			t.Fatalf("results not sorted by score at %d", i)
+		}
+	}
+}
+
+func TestGetSnippetsDoNotSplitCharacters(t *testing.T) {
+	// No spaces to snap to, and emoji of several code points around the match
+	content := strings.Repeat("日本語\U0001F44B\U0001F3FD", 60) + "parser" + strings.Repeat("\U0001F468\u200d\U0001F469\u200d\U0001F467テキスト", 60)
+	for _, length := range []int{40, 41, 42, 43, 100, 301} {
+		snippets := GetSnippets(content, []string{"parser"}, SnippetOptions{Length: length})
+		snippet := strings.TrimSuffix(strings.TrimPrefix(snippets[0], "..."), "...")
+		if !utf8.ValidString(snippet) || !strings.Contains(snippet, "parser") {
+			t.Fatalf("length %d: invalid snippet %q", length, snippets[0])
+		}
+		if strings.HasPrefix(snippet, "\U0001F3FD") || strings.HasPrefix(snippet, "\u200d") || strings.HasSuffix(snippet, "\u200d") {
+			t.Fatalf("length %d: snippet splits an emoji: %q", length, snippet)
+		}
+	}
+	if got := GetSnippet(strings.Repeat("é", 200), []string{"missing"}, 51); !utf8.ValidString(got) {
+		t.Fatalf("invalid fallback snippet %q", got)
+	}
+
+	// Characters whose lowercase form has another length shift where matches are found
+	mapped := strings.Repeat("ȺİK-", 80) + "parser" + strings.Repeat("-KİȺ", 80)
+	for _, length := range []int{20, 41, 42, 100, 301} {
+		snippets := append(GetSnippets(mapped, []string{"parser"}, SnippetOptions{Length: length, HighlightPre: "<", HighlightPost: ">"}),
+			GetSnippet(mapped, []string{"parser"}, length))
+		for _, snippet := range snippets {
+			if !utf8.ValidString(snippet) || !strings.Contains(snippet, "parser") {
+				t.Fatalf("length %d: invalid snippet %q", length, snippet)
+			}
+		}
+		if !strings.Contains(snippets[0], "<parser>") {
+			t.Fatalf("length %d: match not highlighted in %q", length, snippets[0])
+		}
+	}
+}
+
+func TestGetSnippetsWithCaseMappingChangingLength(t *testing.T) {
+	opts := SnippetOptions{MaxSnippets: 2, HighlightPre: "[", HighlightPost: "]"}
+
+	// Ⱥ lowercases to a longer character, so offsets in the lowercase text run past the original
+	grown := strings.Repeat("Ⱥ", 10) + " error"
+	if got := GetSnippets(grown, []string{"error"}, opts); len(got) != 1 || got[0] != strings.Repeat("Ⱥ", 10)+" [error]" {
+		t.Fatalf("GetSnippets=%q", got)
+	}
+
+	// İ lowercases to a shorter one, so they fall short and would split characters
+	shrunk := strings.Repeat("İ", 10) + " İstanbul error"
+	got := GetSnippets(shrunk, []string{"istanbul", "error"}, opts)
+	if len(got) != 1 || got[0] != strings.Repeat("İ", 10)+" [İstanbul] [error]" {
+		t.Fatalf("GetSnippets=%q", got)
+	}
+}
+
+func TestSearchFiltersByProject(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	projects := map[string]string{
+		"app":     "/work/ai-sessions-mcp",
+		"app-cmd": "/work/ai-sessions-mcp/cmd",
+		"clone":   "/tmp/review/ai-sessions-mcp",
+		"api":     "/work/api",
+	}
+	for id, projectPath := range projects {
+		session := adapters.Session{ID: id, Source: "claude", ProjectPath: projectPath, FilePath: filePath, Timestamp: time.Now()}
+		if err := cache.IndexSession(session, "deploy the service"); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	cases := map[string]string{
+		"ai-sessions-mcp": "app app-cmd clone",
+		"/work/*":         "api app app-cmd",
+		"/work/api":       "api",
+		"/work/api/":      "api",
+		"billing":         "",
+	}
+	for filter, want := range cases {
+		results, err := cache.Search("deploy", "", filter, 0)
+		if err != nil {
+			t.Fatalf("Search with project %q failed: %v", filter, err)
+		}
+		var ids []string
+		for _, result := range results {
+			ids = append(ids, result.Session.ID)
+		}
+		sort.Strings(ids)
+		if got := strings.Join(ids, " "); got != want {
+			t.Errorf("project %q: expected %q, got %q", filter, want, got)
+		}
+	}
+}
+
+func TestSearchFiltersByRepository(t *testing.T) {
+	cache := newTempCache(t)
+	repo := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	sessions := []adapters.Session{
+		{ID: "root", ProjectPath: repo, Repo: repo},
+		{ID: "web", ProjectPath: filepath.Join(repo, "web"), Repo: repo},
+		{ID: "other", ProjectPath: "/work/other"},
+	}
+	for _, session := range sessions {
+		session.Source, session.FilePath, session.Timestamp = "claude", filePath, time.Now()
+		if err := cache.IndexSession(session, "deploy the service"); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	results, err := cache.Search("deploy", "", repo, 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	var ids []string
+	for _, result := range results {
+		if result.Session.Repo != repo {
+			t.Errorf("expected %s to be in repository %q, got %q", result.Session.ID, repo, result.Session.Repo)
+		}
+		ids = append(ids, result.Session.ID)
+	}
+	sort.Strings(ids)
+	if got := strings.Join(ids, " "); got != "root web" {
+		t.Errorf("expected the sessions of the repository, got %q", got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	cache, err := NewMemoryCache()
+	if err != nil {
+		t.Fatalf("NewMemoryCache failed: %v", err)
+	}
+	defer cache.Close()
+	if !cache.InMemory() || newTempCache(t).InMemory() {
+		t.Fatal("expected only the memory cache to be in memory")
+	}
+	// The database outlives the connections the pool closes when idle
+	cache.db.SetMaxIdleConns(0)
+
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			session := adapters.Session{ID: fmt.Sprintf("s%d", i), Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: time.Now()}
+			errs <- cache.IndexSession(session, "refactor the parser and fix the tests")
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+	if err := cache.TagSession("claude", "s3", []string{"parser"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+
+	results, err := cache.SearchWithOptions("parser", SearchOptions{Limit: 50})
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if len(results) != 20 {
+		t.Fatalf("expected 20 results, got %d", len(results))
+	}
+	if tagged, err := cache.SessionsByTag("parser", "", 10); err != nil || len(tagged) != 1 {
+		t.Fatalf("expected the tagged session, got %v (%v)", tagged, err)
+	}
+
+	// Each memory cache is a database of its own
+	other, err := NewMemoryCache()
+	if err != nil {
+		t.Fatalf("NewMemoryCache failed: %v", err)
+	}
+	defer other.Close()
+	if results, err := other.Search("parser", "", "", 10); err != nil || len(results) != 0 {
+		t.Fatalf("expected an empty cache, got %d results (%v)", len(results), err)
+	}
+}
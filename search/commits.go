@@ -0,0 +1,131 @@
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// commitHashPattern matches the commit hashes that can be looked up: at least as long as
+// the shortest abbreviation git prints
+var commitHashPattern = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// CommitMatch is a session that created commits matching a commit query
+type CommitMatch struct {
+	Session adapters.Session  `json:"session"`
+	Commits []analysis.Commit `json:"commits"`
+}
+
+// CommitSearchOptions holds filters for a commit lookup
+type CommitSearchOptions struct {
+	Hash        string // A full or abbreviated commit hash
+	Branch      string // Sessions that worked on, or committed to, this branch
+	Source      string
+	ProjectPath string // A project filter (see adapters.ProjectFilter)
+	Limit       int
+}
+
+// FindSessionsByCommit returns the indexed sessions that created a commit, or that worked
+// on a branch. Hashes match when one is a prefix of the other, so the full hash of a
+// commit finds the session that printed its abbreviation. Results are ordered newest first.
+func (c *Cache) FindSessionsByCommit(opts CommitSearchOptions) ([]CommitMatch, error) {
+	hash := strings.ToLower(strings.TrimSpace(opts.Hash))
+	branch := strings.TrimSpace(opts.Branch)
+	if hash == "" && branch == "" {
+		return nil, fmt.Errorf("a commit hash or branch is required")
+	}
+	if hash != "" && !commitHashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("invalid commit hash %q: expected 4 to 40 hexadecimal characters", opts.Hash)
+	}
+
+	sqlQuery := `
+		SELECT s.id, s.source, s.project_path, s.repo, s.branch, s.file_path, s.first_message, s.summary, s.title, s.timestamp, s.message_count, s.file_size,
+		       c.hash, c.branch, c.subject, c.timestamp
+		FROM sessions s
+		LEFT JOIN session_commits c ON c.session_id = s.id
+		WHERE `
+
+	var conditions []string
+	var args []interface{}
+	if hash != "" {
+		conditions = append(conditions, "(c.hash LIKE ? OR ? LIKE c.hash || '%')")
+		args = append(args, hash+"%", hash)
+	}
+	if branch != "" {
+		conditions = append(conditions, "(s.branch = ? OR c.branch = ?)")
+		args = append(args, branch, branch)
+	}
+	if opts.Source != "" {
+		conditions = append(conditions, "s.source = ?")
+		args = append(args, opts.Source)
+	}
+	if opts.ProjectPath != "" {
+		condition, projectArgs, err := c.projectCondition("s.project_path", opts.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, condition)
+		args = append(args, projectArgs...)
+	}
+	sqlQuery += strings.Join(conditions, " AND ") + " ORDER BY s.timestamp DESC, c.timestamp"
+
+	rows, err := c.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search commits: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]*CommitMatch)
+	for rows.Next() {
+		var session adapters.Session
+		var timestampUnix int64
+		var commitHash, commitBranch, subject *string
+		var commitTimestamp *int64
+
+		if err := rows.Scan(&session.ID, &session.Source, &session.ProjectPath, &session.Repo, &session.Branch, &session.FilePath,
+			&session.FirstMessage, &session.Summary, &session.Title, &timestampUnix, &session.MessageCount, &session.FileSize,
+			&commitHash, &commitBranch, &subject, &commitTimestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		match, ok := byID[session.ID]
+		if !ok {
+			if err := c.openSessionText(&session.FirstMessage, &session.Summary, &session.Title); err != nil {
+				return nil, err
+			}
+			session.Timestamp = time.Unix(timestampUnix, 0)
+			match = &CommitMatch{Session: session, Commits: []analysis.Commit{}}
+			byID[session.ID] = match
+		}
+		// Sessions found by their branch also list commits to other branches
+		if commitHash == nil || (hash == "" && *commitBranch != branch) {
+			continue
+		}
+		commit := analysis.Commit{Hash: *commitHash, Branch: *commitBranch, Timestamp: time.Unix(*commitTimestamp, 0)}
+		if commit.Subject, err = c.openText(*subject); err != nil {
+			return nil, err
+		}
+		match.Commits = append(match.Commits, commit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	matches := make([]CommitMatch, 0, len(byID))
+	for _, match := range byID {
+		matches = append(matches, *match)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Session.Timestamp.After(matches[j].Session.Timestamp)
+	})
+
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+	return matches, nil
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestFindSessionsByCommit(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	index := func(id, branch string, ts time.Time, commits ...analysis.Commit) {
+		t.Helper()
+		session := adapters.Session{ID: id, Source: "claude", ProjectPath: "/repo", Branch: branch, FilePath: filePath, Timestamp: ts}
+		if err := cache.IndexSessionWithDetails(context.Background(), session, "content", SessionDetails{Commits: commits}); err != nil {
+			t.Fatalf("IndexSessionWithDetails failed: %v", err)
+		}
+	}
+	index("parser", "feature/parser", now.Add(-time.Hour),
+		analysis.Commit{Hash: "1a2b3c4", Branch: "feature/parser", Subject: "Fix the parser", Timestamp: now.Add(-time.Hour)},
+		analysis.Commit{Hash: "5e6f7a8", Branch: "main", Subject: "Merge the parser", Timestamp: now.Add(-time.Hour)})
+	index("docs", "feature/parser", now)
+	index("other", "main", now, analysis.Commit{Hash: "9f9f9f9", Branch: "main", Subject: "Update the docs", Timestamp: now})
+
+	matches, err := cache.FindSessionsByCommit(CommitSearchOptions{Hash: "1A2B3C4D5E6F7A8B9C0D1E2F3A4B5C6D7E8F9A0B"})
+	if err != nil {
+		t.Fatalf("FindSessionsByCommit failed: %v", err)
+	}
+	if len(matches) != 1 || matches[0].Session.ID != "parser" || len(matches[0].Commits) != 1 {
+		t.Fatalf("expected a full hash to find the session that printed its abbreviation, got %+v", matches)
+	}
+	if commit := matches[0].Commits[0]; commit.Subject != "Fix the parser" || !commit.Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected commit: %+v", commit)
+	}
+
+	matches, err = cache.FindSessionsByCommit(CommitSearchOptions{Hash: "5e6f"})
+	if err != nil || len(matches) != 1 || matches[0].Session.ID != "parser" {
+		t.Fatalf("expected an abbreviated hash to match, got %+v (%v)", matches, err)
+	}
+
+	matches, err = cache.FindSessionsByCommit(CommitSearchOptions{Branch: "feature/parser"})
+	if err != nil || len(matches) != 2 || matches[0].Session.ID != "docs" || matches[1].Session.ID != "parser" {
+		t.Fatalf("expected the sessions on the branch newest first, got %+v (%v)", matches, err)
+	}
+	if len(matches[0].Commits) != 0 || len(matches[1].Commits) != 1 || matches[1].Commits[0].Hash != "1a2b3c4" {
+		t.Fatalf("expected only the commits to the branch, got %+v", matches)
+	}
+
+	matches, err = cache.FindSessionsByCommit(CommitSearchOptions{Branch: "main"})
+	if err != nil || len(matches) != 2 {
+		t.Fatalf("expected sessions committing to a branch to be found, got %+v (%v)", matches, err)
+	}
+
+	for _, opts := range []CommitSearchOptions{{}, {Hash: "abc"}, {Hash: "not-a-hash"}} {
+		if _, err := cache.FindSessionsByCommit(opts); err == nil {
+			t.Errorf("expected an error for %+v", opts)
+		}
+	}
+
+	// Reindexing replaces the previous commits
+	index("parser", "feature/parser", now.Add(-time.Hour))
+	matches, err = cache.FindSessionsByCommit(CommitSearchOptions{Hash: "1a2b3c4"})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("stale commit rows after reindex: %+v, %v", matches, err)
+	}
+}
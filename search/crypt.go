@@ -0,0 +1,249 @@
+package search
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize is the size of the key of an encrypted cache
+const KeySize = 32
+
+// keyCheck is stored encrypted in an encrypted cache, so opening it with the wrong key
+// fails instead of returning garbage
+const keyCheck = "ai-sessions search cache"
+
+// ErrWrongKey is returned when an encrypted cache is opened with a different key than
+// the one it was created with
+var ErrWrongKey = errors.New("the search cache is encrypted with a different key")
+
+// cacheCipher encrypts the session text of an encrypted cache (content, first messages,
+// summaries, notes, terms, and listing metadata) with AES-256-GCM. Terms are encrypted
+// with a nonce derived from the term itself, so equal terms encrypt alike and can still
+// be looked up; everything else gets a random nonce.
+type cacheCipher struct {
+	aead    cipher.AEAD
+	termKey []byte
+}
+
+func newCacheCipher(key []byte) (*cacheCipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid cache key: want %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(deriveKey(key, "content"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &cacheCipher{aead: aead, termKey: deriveKey(key, "terms")}, nil
+}
+
+// deriveKey derives the key of one use from the cache key
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("ai-sessions cache " + purpose))
+	return mac.Sum(nil)
+}
+
+func (c *cacheCipher) seal(nonce, plaintext []byte) []byte {
+	return c.aead.Seal(nonce, nonce, plaintext, nil)
+}
+
+func (c *cacheCipher) open(sealed []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, ErrWrongKey
+	}
+	plaintext, err := c.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return nil, ErrWrongKey
+	}
+	return plaintext, nil
+}
+
+// sealBytes encrypts data stored in the cache. Without encryption it is stored as is.
+func (c *Cache) sealBytes(data []byte) []byte {
+	if c.cipher == nil {
+		return data
+	}
+	nonce := make([]byte, c.cipher.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		panic(fmt.Sprintf("failed to generate nonce: %v", err))
+	}
+	return c.cipher.seal(nonce, data)
+}
+
+func (c *Cache) openBytes(data []byte) ([]byte, error) {
+	if c.cipher == nil {
+		return data, nil
+	}
+	return c.cipher.open(data)
+}
+
+// sealText encrypts text stored in the cache
+func (c *Cache) sealText(text string) string {
+	if c.cipher == nil {
+		return text
+	}
+	return base64.StdEncoding.EncodeToString(c.sealBytes([]byte(text)))
+}
+
+func (c *Cache) openText(text string) (string, error) {
+	if c.cipher == nil || text == "" {
+		return text, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+	plaintext, err := c.cipher.open(sealed)
+	return string(plaintext), err
+}
+
+// sealTerm encrypts a term so that it can be looked up by its encrypted form
+func (c *Cache) sealTerm(term string) string {
+	if c.cipher == nil {
+		return term
+	}
+	mac := hmac.New(sha256.New, c.cipher.termKey)
+	mac.Write([]byte(term))
+	nonce := mac.Sum(nil)[:c.cipher.aead.NonceSize()]
+	return base64.StdEncoding.EncodeToString(c.cipher.seal(nonce, []byte(term)))
+}
+
+func (c *Cache) openTerm(term string) (string, error) {
+	return c.openText(term)
+}
+
+// sealTerms encrypts terms for a query
+func (c *Cache) sealTerms(terms []string) []interface{} {
+	args := make([]interface{}, len(terms))
+	for i, term := range terms {
+		args[i] = c.sealTerm(term)
+	}
+	return args
+}
+
+// openSessionText decrypts the first message, summary, and title of a session read from
+// the cache
+func (c *Cache) openSessionText(firstMessage, summary, title *string) error {
+	var err error
+	for _, text := range []*string{firstMessage, summary, title} {
+		if *text, err = c.openText(*text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkKey verifies that an encrypted cache was created with the cache's key, and that
+// a plaintext cache isn't opened as encrypted or the other way around. A new cache
+// records its encryption.
+func (c *Cache) checkKey() error {
+	var stored string
+	err := c.db.QueryRow("SELECT value FROM cache_settings WHERE key = 'key_check'").Scan(&stored)
+	if err == sql.ErrNoRows {
+		var sessions int
+		if err := c.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&sessions); err != nil {
+			return fmt.Errorf("failed to inspect cache: %w", err)
+		}
+		if c.cipher == nil {
+			return nil
+		}
+		if sessions > 0 {
+			return fmt.Errorf("the search cache isn't encrypted: delete it to create an encrypted one")
+		}
+		if _, err := c.db.Exec("INSERT INTO cache_settings (key, value) VALUES ('key_check', ?)", c.sealText(keyCheck)); err != nil {
+			return fmt.Errorf("failed to initialize encryption: %w", err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to inspect cache: %w", err)
+	}
+	if c.cipher == nil {
+		return fmt.Errorf("the search cache is encrypted: open it with its key")
+	}
+	if check, err := c.openText(stored); err != nil || check != keyCheck {
+		return ErrWrongKey
+	}
+	return nil
+}
+
+// CopyAnnotations copies the tags, notes and saved searches of the cache to another one, such as an
+// encrypted cache replacing it. The index itself isn't copied; it is rebuilt from the
+// sessions.
+func (c *Cache) CopyAnnotations(dst *Cache) error {
+	rows, err := c.db.Query("SELECT source, session_id, tag, created_at FROM session_tags")
+	if err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var source, sessionID, tag string
+		var createdAt int64
+		if err := rows.Scan(&source, &sessionID, &tag, &createdAt); err != nil {
+			return fmt.Errorf("failed to read tags: %w", err)
+		}
+		if _, err := dst.db.Exec("INSERT OR IGNORE INTO session_tags (source, session_id, tag, created_at) VALUES (?, ?, ?, ?)",
+			source, sessionID, tag, createdAt); err != nil {
+			return fmt.Errorf("failed to copy tag: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	notes, err := c.db.Query("SELECT source, session_id, note, created_at FROM session_notes ORDER BY id")
+	if err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+	defer notes.Close()
+	for notes.Next() {
+		var source, sessionID, note string
+		var createdAt int64
+		if err := notes.Scan(&source, &sessionID, &note, &createdAt); err != nil {
+			return fmt.Errorf("failed to read notes: %w", err)
+		}
+		if note, err = c.openText(note); err != nil {
+			return err
+		}
+		if _, err := dst.db.Exec("INSERT INTO session_notes (source, session_id, note, created_at) VALUES (?, ?, ?, ?)",
+			source, sessionID, dst.sealText(note), createdAt); err != nil {
+			return fmt.Errorf("failed to copy note: %w", err)
+		}
+	}
+	if err := notes.Err(); err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	searches, err := c.db.Query("SELECT name, definition, created_at, updated_at FROM saved_searches")
+	if err != nil {
+		return fmt.Errorf("failed to read saved searches: %w", err)
+	}
+	defer searches.Close()
+	for searches.Next() {
+		var name, definition string
+		var createdAt, updatedAt int64
+		if err := searches.Scan(&name, &definition, &createdAt, &updatedAt); err != nil {
+			return fmt.Errorf("failed to read saved searches: %w", err)
+		}
+		if definition, err = c.openText(definition); err != nil {
+			return err
+		}
+		if _, err := dst.db.Exec("INSERT OR REPLACE INTO saved_searches (name, definition, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			name, dst.sealText(definition), createdAt, updatedAt); err != nil {
+			return fmt.Errorf("failed to copy saved search: %w", err)
+		}
+	}
+	return searches.Err()
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestEncryptedCache(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "cache.db")
+	key := bytes.Repeat([]byte{7}, KeySize)
+	cache, err := NewEncryptedCache(dbPath, key)
+	if err != nil {
+		t.Fatalf("NewEncryptedCache failed: %v", err)
+	}
+
+	filePath := filepath.Join(dir, "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/work", FirstMessage: "rotate the zebracorn credentials",
+		Title: "Rotate the zebracorn credentials", Timestamp: time.Now(), FilePath: filePath}
+	if err := cache.IndexSession(session, "rotate the zebracorn credentials in the vault"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+	if _, err := cache.AddNote("claude", "s1", "zebracorn follow-up"); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if err := cache.PutMetadata("k", "/k.jsonl", time.Unix(1, 0), 1, []byte("zebracorn metadata")); err != nil {
+		t.Fatalf("PutMetadata failed: %v", err)
+	}
+
+	results, err := cache.Search("zebracorn", "", "", 5)
+	if err != nil || len(results) != 1 {
+		t.Fatalf("expected to find the session, got %+v (%v)", results, err)
+	}
+	if results[0].Session.FirstMessage != session.FirstMessage || results[0].Session.Title != session.Title || !strings.Contains(results[0].Snippet, "zebracorn") {
+		t.Fatalf("expected decrypted results, got %+v", results[0])
+	}
+	if notes, err := cache.SessionNotes("claude", "s1"); err != nil || len(notes) != 1 || notes[0].Text != "zebracorn follow-up" {
+		t.Fatalf("expected the decrypted note, got %+v (%v)", notes, err)
+	}
+	if data, ok := cache.GetMetadata("k", time.Unix(1, 0), 1); !ok || string(data) != "zebracorn metadata" {
+		t.Fatalf("expected the decrypted metadata, got %q", data)
+	}
+	if terms, err := cache.TopTerms([]string{"s1"}, 10); err != nil || len(terms) == 0 {
+		t.Fatalf("expected readable top terms, got %v (%v)", terms, err)
+	}
+	cache.Close()
+
+	raw, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("read database: %v", err)
+	}
+	if bytes.Contains(raw, []byte("zebracorn")) {
+		t.Fatal("the database file contains session text in plaintext")
+	}
+
+	if _, err := NewEncryptedCache(dbPath, bytes.Repeat([]byte{8}, KeySize)); err != ErrWrongKey {
+		t.Fatalf("expected ErrWrongKey for another key, got %v", err)
+	}
+	if _, err := NewCache(dbPath); err == nil {
+		t.Fatal("expected an encrypted cache not to open without its key")
+	}
+
+	plainPath := filepath.Join(dir, "plain.db")
+	plain, err := NewCache(plainPath)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	if err := plain.IndexSession(session, "content"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+	plain.Close()
+	if _, err := NewEncryptedCache(plainPath, key); err == nil {
+		t.Fatal("expected a plaintext cache not to open as encrypted")
+	}
+}
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// contentHash returns the hash of a session's text, ignoring case and whitespace so that
+// copies written with different line endings or formatting match. Sessions without
+// text have no hash.
+func contentHash(content string) string {
+	words := strings.Fields(strings.ToLower(content))
+	if len(words) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// sealContentHash encrypts a content hash, leaving an empty one empty so that sessions
+// without text don't all match
+func (c *Cache) sealContentHash(hash string) string {
+	if hash == "" {
+		return ""
+	}
+	return c.sealTerm(hash)
+}
+
+// ContentHashes returns the content hashes of indexed sessions, by session ID. Sessions
+// without a hash are left out.
+func (c *Cache) ContentHashes(sessionIDs []string) (map[string]string, error) {
+	hashes := make(map[string]string)
+	for start := 0; start < len(sessionIDs); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(sessionIDs) {
+			end = len(sessionIDs)
+		}
+		batch := sessionIDs[start:end]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := "SELECT id, content_hash FROM sessions WHERE content_hash != '' AND id IN (" +
+			strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ")"
+		if err := c.scanContentHashes(hashes, query, args); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+func (c *Cache) scanContentHashes(hashes map[string]string, query string, args []interface{}) error {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load content hashes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return fmt.Errorf("failed to scan content hash: %w", err)
+		}
+		if hash, err = c.openTerm(hash); err != nil {
+			return err
+		}
+		hashes[id] = hash
+	}
+	return rows.Err()
+}
+
+// collapseDuplicateMatches keeps the best ranked of the matches with the same source and
+// content hash, returning the IDs of the others by the ID of the one kept. matches must
+// be ranked.
+func collapseDuplicateMatches(matches []*termMatches) ([]*termMatches, map[string][]string) {
+	type key struct{ source, hash string }
+
+	kept := make([]*termMatches, 0, len(matches))
+	first := make(map[key]string)
+	copies := make(map[string][]string)
+	for _, match := range matches {
+		if match.contentHash != "" {
+			k := key{match.source, match.contentHash}
+			if id, ok := first[k]; ok {
+				copies[id] = append(copies[id], match.id)
+				continue
+			}
+			first[k] = match.id
+		}
+		kept = append(kept, match)
+	}
+	return kept, copies
+}
@@ -0,0 +1,73 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSearchCollapsesDuplicates(t *testing.T) {
+	cache := newTempCache(t)
+	dir := t.TempDir()
+	start := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	index := func(id, source, content string, age time.Duration) {
+		t.Helper()
+		filePath := filepath.Join(dir, id+".jsonl")
+		if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+			t.Fatalf("write session file: %v", err)
+		}
+		session := adapters.Session{ID: id, Source: source, ProjectPath: "/repo", FilePath: filePath, Timestamp: start.Add(-age)}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+	index("copy", "claude", "Fix the  flaky webhook test\n", time.Hour)
+	index("original", "claude", "fix the flaky webhook test", 0)
+	index("codex", "codex", "fix the flaky webhook test", 2*time.Hour)
+	index("other", "claude", "the webhook retries", 3*time.Hour)
+
+	results, err := cache.SearchWithOptions("webhook", SearchOptions{CollapseDuplicates: true})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	var ids []string
+	for _, result := range results {
+		ids = append(ids, result.Session.ID)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected copies to be collapsed, got %v", ids)
+	}
+	var kept adapters.Session
+	for _, result := range results {
+		if result.Session.ID == "original" || result.Session.ID == "copy" {
+			kept = result.Session
+		}
+	}
+	if len(kept.Duplicates) != 1 || kept.ContentHash == "" {
+		t.Fatalf("expected the other copy in Duplicates, got %+v", kept)
+	}
+	if want := map[string]string{"original": "copy", "copy": "original"}[kept.ID]; kept.Duplicates[0].ID != want {
+		t.Fatalf("expected %s as the duplicate of %s, got %+v", want, kept.ID, kept.Duplicates)
+	}
+
+	// Without collapsing, every copy is returned
+	results, err = cache.SearchWithOptions("webhook", SearchOptions{})
+	if err != nil || len(results) != 4 {
+		t.Fatalf("expected every copy, got %d results, %v", len(results), err)
+	}
+
+	hashes, err := cache.ContentHashes([]string{"original", "copy", "other", "missing"})
+	if err != nil {
+		t.Fatalf("ContentHashes failed: %v", err)
+	}
+	if hashes["original"] == "" || hashes["original"] != hashes["copy"] || hashes["original"] == hashes["other"] {
+		t.Fatalf("unexpected content hashes: %v", hashes)
+	}
+	if _, ok := hashes["missing"]; ok {
+		t.Fatal("expected no hash for a session that isn't indexed")
+	}
+}
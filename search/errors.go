@@ -0,0 +1,125 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+// ErrorMatch is a session that ran into errors matching an error query
+type ErrorMatch struct {
+	Session adapters.Session        `json:"session"`
+	Errors  []analysis.SessionError `json:"errors"`
+}
+
+// ErrorSearchOptions holds filters for an error lookup
+type ErrorSearchOptions struct {
+	Kind        string // panic, traceback, exception, compiler, or error
+	Source      string
+	ProjectPath string // A project filter (see adapters.ProjectFilter)
+	Limit       int
+}
+
+// FindSessionsByError returns the indexed sessions whose tool output showed an error
+// containing every word of query, ignoring case, with the matching errors. Results are
+// ordered newest first. An empty query matches every error of the kind in opts.
+func (c *Cache) FindSessionsByError(query string, opts ErrorSearchOptions) ([]ErrorMatch, error) {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 && opts.Kind == "" {
+		return nil, fmt.Errorf("an error message or kind is required")
+	}
+
+	// Messages may be encrypted, so they are matched once read
+	sqlQuery := `
+		SELECT s.id, s.source, s.project_path, s.repo, s.branch, s.file_path, s.first_message, s.summary, s.title, s.timestamp, s.message_count, s.file_size,
+		       e.kind, e.message, e.count, e.timestamp
+		FROM session_errors e
+		JOIN sessions s ON s.id = e.session_id
+		WHERE 1`
+
+	var args []interface{}
+	if opts.Kind != "" {
+		sqlQuery += " AND e.kind = ?"
+		args = append(args, strings.ToLower(opts.Kind))
+	}
+	if opts.Source != "" {
+		sqlQuery += " AND s.source = ?"
+		args = append(args, opts.Source)
+	}
+	if opts.ProjectPath != "" {
+		condition, projectArgs, err := c.projectCondition("s.project_path", opts.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += " AND " + condition
+		args = append(args, projectArgs...)
+	}
+	sqlQuery += " ORDER BY s.timestamp DESC, s.id, e.position"
+
+	rows, err := c.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search errors: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []*ErrorMatch
+	byID := make(map[string]*ErrorMatch)
+	for rows.Next() {
+		var session adapters.Session
+		var timestampUnix, errorTimestamp int64
+		var sessionErr analysis.SessionError
+
+		if err := rows.Scan(&session.ID, &session.Source, &session.ProjectPath, &session.Repo, &session.Branch, &session.FilePath,
+			&session.FirstMessage, &session.Summary, &session.Title, &timestampUnix, &session.MessageCount, &session.FileSize,
+			&sessionErr.Kind, &sessionErr.Message, &sessionErr.Count, &errorTimestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if sessionErr.Message, err = c.openText(sessionErr.Message); err != nil {
+			return nil, err
+		}
+		if !containsWords(strings.ToLower(sessionErr.Message), words) {
+			continue
+		}
+		if errorTimestamp > 0 {
+			sessionErr.Timestamp = time.Unix(errorTimestamp, 0)
+		}
+
+		match, ok := byID[session.ID]
+		if !ok {
+			if err := c.openSessionText(&session.FirstMessage, &session.Summary, &session.Title); err != nil {
+				return nil, err
+			}
+			session.Timestamp = time.Unix(timestampUnix, 0)
+			match = &ErrorMatch{Session: session}
+			byID[session.ID] = match
+			matches = append(matches, match)
+		}
+		match.Errors = append(match.Errors, sessionErr)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+
+	results := make([]ErrorMatch, len(matches))
+	for i, match := range matches {
+		results[i] = *match
+	}
+	return results, nil
+}
+
+// containsWords reports whether text contains every one of words
+func containsWords(text string, words []string) bool {
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
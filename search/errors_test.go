@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestFindSessionsByError(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	index := func(id, source string, ts time.Time, errors ...analysis.SessionError) {
+		t.Helper()
+		session := adapters.Session{ID: id, Source: source, ProjectPath: "/repo", FilePath: filePath, Timestamp: ts}
+		if err := cache.IndexSessionWithDetails(context.Background(), session, "content", SessionDetails{Errors: errors}); err != nil {
+			t.Fatalf("IndexSessionWithDetails failed: %v", err)
+		}
+	}
+	index("old", "claude", now.Add(-time.Hour),
+		analysis.SessionError{Kind: analysis.ErrorKindPanic, Message: "panic: runtime error: index out of range [3] with length 3", Count: 2, Timestamp: now.Add(-time.Hour)})
+	index("new", "codex", now,
+		analysis.SessionError{Kind: analysis.ErrorKindCompiler, Message: "./main.go:10:2: undefined: foo", Count: 1},
+		analysis.SessionError{Kind: analysis.ErrorKindPanic, Message: "panic: runtime error: invalid memory address or nil pointer dereference", Count: 1})
+
+	matches, err := cache.FindSessionsByError("Runtime Error", ErrorSearchOptions{})
+	if err != nil {
+		t.Fatalf("FindSessionsByError failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Session.ID != "new" || matches[1].Session.ID != "old" {
+		t.Fatalf("expected both sessions newest first, got %+v", matches)
+	}
+	if len(matches[0].Errors) != 1 || matches[0].Errors[0].Kind != analysis.ErrorKindPanic {
+		t.Fatalf("expected only the matching error, got %+v", matches[0].Errors)
+	}
+	if got := matches[1].Errors[0]; got.Count != 2 || !got.Timestamp.Equal(now.Add(-time.Hour)) {
+		t.Fatalf("unexpected error details: %+v", got)
+	}
+
+	matches, err = cache.FindSessionsByError("", ErrorSearchOptions{Kind: analysis.ErrorKindCompiler})
+	if err != nil || len(matches) != 1 || matches[0].Session.ID != "new" {
+		t.Fatalf("kind filter failed: %+v, %v", matches, err)
+	}
+
+	matches, err = cache.FindSessionsByError("index range", ErrorSearchOptions{Source: "codex"})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("source filter failed: %+v, %v", matches, err)
+	}
+
+	if _, err := cache.FindSessionsByError(" ", ErrorSearchOptions{}); err == nil {
+		t.Fatal("expected an error without a message or kind")
+	}
+
+	// Reindexing replaces the previous errors
+	index("old", "claude", now.Add(-time.Hour))
+	matches, err = cache.FindSessionsByError("index out of range", ErrorSearchOptions{})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("stale errors after reindex: %+v, %v", matches, err)
+	}
+}
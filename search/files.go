@@ -0,0 +1,119 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// FileMatch is a session that touched files matching a file query
+type FileMatch struct {
+	Session adapters.Session          `json:"session"`
+	Files   map[string]map[string]int `json:"files"` // path -> operation -> count
+}
+
+// FileSearchOptions holds filters for a file lookup
+type FileSearchOptions struct {
+	Source      string
+	ProjectPath string // A project filter (see adapters.ProjectFilter)
+	Operation   string // Only match this operation (read, edit, write, create, delete)
+	Limit       int
+}
+
+// FindSessionsByFile returns indexed sessions that touched files matching the given path or glob.
+// Paths match exactly or by suffix, so a relative path like "cmd/main.go" finds sessions that
+// recorded "/repo/cmd/main.go". Patterns containing *, ? or [ are matched as SQLite globs.
+// Results are ordered newest first.
+func (c *Cache) FindSessionsByFile(pattern string, opts FileSearchOptions) ([]FileMatch, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, fmt.Errorf("file path is required")
+	}
+
+	sqlQuery := `
+		SELECT s.id, s.source, s.project_path, s.repo, s.branch, s.file_path, s.first_message, s.summary, s.title, s.timestamp, s.message_count, s.file_size,
+		       f.path, f.operation, f.count
+		FROM session_files f
+		JOIN sessions s ON s.id = f.session_id
+		WHERE `
+
+	condition, args := fileCondition("f.path", pattern)
+	sqlQuery += condition
+
+	if opts.Source != "" {
+		sqlQuery += " AND s.source = ?"
+		args = append(args, opts.Source)
+	}
+	if opts.ProjectPath != "" {
+		condition, projectArgs, err := c.projectCondition("s.project_path", opts.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		sqlQuery += " AND " + condition
+		args = append(args, projectArgs...)
+	}
+	if opts.Operation != "" {
+		sqlQuery += " AND f.operation = ?"
+		args = append(args, opts.Operation)
+	}
+
+	rows, err := c.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search files: %w", err)
+	}
+	defer rows.Close()
+
+	byID := make(map[string]*FileMatch)
+	for rows.Next() {
+		var session adapters.Session
+		var timestampUnix int64
+		var path, operation string
+		var count int
+
+		if err := rows.Scan(&session.ID, &session.Source, &session.ProjectPath, &session.Repo, &session.Branch, &session.FilePath,
+			&session.FirstMessage, &session.Summary, &session.Title, &timestampUnix, &session.MessageCount, &session.FileSize, &path, &operation, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if err := c.openSessionText(&session.FirstMessage, &session.Summary, &session.Title); err != nil {
+			return nil, err
+		}
+
+		match, ok := byID[session.ID]
+		if !ok {
+			session.Timestamp = time.Unix(timestampUnix, 0)
+			match = &FileMatch{Session: session, Files: make(map[string]map[string]int)}
+			byID[session.ID] = match
+		}
+		if match.Files[path] == nil {
+			match.Files[path] = make(map[string]int)
+		}
+		match.Files[path][operation] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	matches := make([]FileMatch, 0, len(byID))
+	for _, match := range byID {
+		matches = append(matches, *match)
+	}
+
+	// Sort by timestamp (newest first)
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Session.Timestamp.After(matches[j].Session.Timestamp)
+	})
+
+	if opts.Limit > 0 && len(matches) > opts.Limit {
+		matches = matches[:opts.Limit]
+	}
+
+	return matches, nil
+}
+
+// escapeLike escapes LIKE wildcards so the value matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,82 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestFindSessionsByFile(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	now := time.Now()
+	index := func(id, source string, ts time.Time, files ...analysis.FileActivity) {
+		t.Helper()
+		session := adapters.Session{ID: id, Source: source, ProjectPath: "/repo", FilePath: filePath, Timestamp: ts}
+		if err := cache.IndexSessionWithDetails(context.Background(), session, "content", SessionDetails{Files: files}); err != nil {
+			t.Fatalf("IndexSessionWithDetails failed: %v", err)
+		}
+	}
+	index("old", "claude", now.Add(-time.Hour),
+		analysis.FileActivity{Path: "/repo/cmd/main.go", Operations: map[string]int{analysis.OpRead: 2}})
+	index("new", "codex", now,
+		analysis.FileActivity{Path: "cmd/main.go", Operations: map[string]int{analysis.OpEdit: 1}},
+		analysis.FileActivity{Path: "search/schema.sql", Operations: map[string]int{analysis.OpEdit: 1}})
+	index("other", "claude", now, analysis.FileActivity{Path: "/repo/xcmd/main.go", Operations: map[string]int{analysis.OpRead: 1}})
+
+	matches, err := cache.FindSessionsByFile("cmd/main.go", FileSearchOptions{})
+	if err != nil {
+		t.Fatalf("FindSessionsByFile failed: %v", err)
+	}
+	if len(matches) != 2 || matches[0].Session.ID != "new" || matches[1].Session.ID != "old" {
+		t.Fatalf("expected suffix matches newest first, got %+v", matches)
+	}
+	if matches[1].Files["/repo/cmd/main.go"][analysis.OpRead] != 2 {
+		t.Fatalf("operation counts not returned: %+v", matches[1].Files)
+	}
+
+	matches, err = cache.FindSessionsByFile("*.sql", FileSearchOptions{})
+	if err != nil || len(matches) != 1 || matches[0].Session.ID != "new" {
+		t.Fatalf("glob lookup failed: %+v, %v", matches, err)
+	}
+
+	matches, err = cache.FindSessionsByFile("main.go", FileSearchOptions{Operation: analysis.OpEdit})
+	if err != nil || len(matches) != 1 || matches[0].Session.ID != "new" {
+		t.Fatalf("operation filter failed: %+v, %v", matches, err)
+	}
+
+	// Reindexing replaces the previous file activity
+	index("new", "codex", now)
+	matches, err = cache.FindSessionsByFile("*.sql", FileSearchOptions{})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("stale file rows after reindex: %+v, %v", matches, err)
+	}
+}
+
+func TestIndexSessionWithDetailsCancelled(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: time.Now()}
+	files := []analysis.FileActivity{{Path: "/repo/main.go", Operations: map[string]int{analysis.OpEdit: 1}}}
+	if err := cache.IndexSessionWithDetails(ctx, session, "content", SessionDetails{Files: files}); err == nil {
+		t.Fatal("expected indexing with a cancelled context to fail")
+	}
+	if health, err := cache.Health(); err != nil || health.Sessions != 0 {
+		t.Fatalf("expected nothing indexed, got %+v (%v)", health, err)
+	}
+}
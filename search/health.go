@@ -0,0 +1,94 @@
+package search
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxHealthProblems caps the integrity problems reported by Health
+const maxHealthProblems = 10
+
+// Health describes the state of a cache
+type Health struct {
+	Sessions      int      `json:"sessions"`           // Indexed sessions
+	SchemaVersion int      `json:"schema_version"`     // Last migration applied to the cache
+	Problems      []string `json:"problems,omitempty"` // Corruption found by SQLite's integrity check
+}
+
+// Health checks the cache database for corruption, and reads its schema version and
+// number of sessions. A damaged
+// cache is reported in Problems rather than as an error; it can be deleted and rebuilt.
+func (c *Cache) Health() (Health, error) {
+	var health Health
+	rows, err := c.db.Query(fmt.Sprintf("PRAGMA quick_check(%d)", maxHealthProblems))
+	if err != nil {
+		return health, fmt.Errorf("failed to check cache: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			return health, fmt.Errorf("failed to check cache: %w", err)
+		}
+		if result != "ok" {
+			health.Problems = append(health.Problems, result)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return health, fmt.Errorf("failed to check cache: %w", err)
+	}
+
+	if health.SchemaVersion, err = c.SchemaVersion(); err != nil {
+		health.Problems = append(health.Problems, err.Error())
+	}
+	if err := c.db.QueryRow("SELECT COUNT(*) FROM sessions").Scan(&health.Sessions); err != nil {
+		health.Problems = append(health.Problems, fmt.Sprintf("failed to count sessions: %v", err))
+	}
+	return health, nil
+}
+
+// SourceIndex describes the sessions of one source in the cache
+type SourceIndex struct {
+	Source      string    `json:"source"`
+	Sessions    int       `json:"sessions"`
+	LastIndexed time.Time `json:"last_indexed"` // When a session of the source was last indexed
+}
+
+// IndexedSources reports how many sessions of each source are indexed, and when they
+// were last indexed, ordered by source
+func (c *Cache) IndexedSources() ([]SourceIndex, error) {
+	rows, err := c.db.Query("SELECT source, COUNT(*), MAX(last_indexed) FROM sessions GROUP BY source ORDER BY source")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexed sources: %w", err)
+	}
+	defer rows.Close()
+
+	var sources []SourceIndex
+	for rows.Next() {
+		var index SourceIndex
+		var lastIndexed int64
+		if err := rows.Scan(&index.Source, &index.Sessions, &lastIndexed); err != nil {
+			return nil, fmt.Errorf("failed to scan indexed source: %w", err)
+		}
+		index.LastIndexed = time.Unix(lastIndexed, 0)
+		sources = append(sources, index)
+	}
+	return sources, rows.Err()
+}
+
+// SchemaVersion returns the version of the cache's schema, the last migration applied
+func (c *Cache) SchemaVersion() (int, error) {
+	return schemaVersion(c.db)
+}
+
+// Size returns the size of the cache database in bytes
+func (c *Cache) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := c.db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("failed to get cache size: %w", err)
+	}
+	if err := c.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("failed to get cache size: %w", err)
+	}
+	return pages * pageSize, nil
+}
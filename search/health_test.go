@@ -0,0 +1,38 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestCacheHealth(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/work/app", Timestamp: time.Now(), FilePath: filePath}
+	if err := cache.IndexSession(session, "fix the flaky test"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+
+	health, err := cache.Health()
+	if err != nil {
+		t.Fatalf("Health failed: %v", err)
+	}
+	if health.Sessions != 1 || len(health.Problems) != 0 {
+		t.Fatalf("expected a healthy cache with one session, got %+v", health)
+	}
+
+	sources, err := cache.IndexedSources()
+	if err != nil || len(sources) != 1 || sources[0].Source != "claude" || sources[0].Sessions != 1 || time.Since(sources[0].LastIndexed) > time.Minute {
+		t.Fatalf("expected one recently indexed claude session, got %+v (%v)", sources, err)
+	}
+	if size, err := cache.Size(); err != nil || size <= 0 {
+		t.Fatalf("expected the cache to have a size, got %d (%v)", size, err)
+	}
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+)
+
+const (
+	// maxSessionKeywords is the number of keywords stored for each session
+	maxSessionKeywords = 5
+
+	// minKeywordFrequency is how many times a term must occur in a session to be one of
+	// its keywords, so that a passing mention isn't one
+	minKeywordFrequency = 2
+)
+
+// sessionKeywords returns the most distinctive terms of a session: frequent in it, and
+// rare in the other indexed sessions, by TF-IDF. Numbers and stop words are left out.
+func (c *Cache) sessionKeywords(sessionID string, termFreqs map[string]int) ([]string, error) {
+	var terms []string
+	for term, freq := range termFreqs {
+		if freq >= minKeywordFrequency && !stopWords[term] && strings.IndexFunc(term, unicode.IsLetter) >= 0 {
+			terms = append(terms, term)
+		}
+	}
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	// Frequencies among the other sessions, so that reindexing a session doesn't change
+	// its keywords
+	docFreqs := make(map[string]int, len(terms))
+	for start := 0; start < len(terms); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(terms) {
+			end = len(terms)
+		}
+		batch := terms[start:end]
+
+		query := "SELECT term, COUNT(*) FROM term_index WHERE session_id != ? AND term IN ("
+		args := append([]interface{}{sessionID}, c.sealTerms(batch)...)
+		query += strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ") GROUP BY term"
+
+		if err := c.scanDocumentFrequencies(docFreqs, query, args); err != nil {
+			return nil, err
+		}
+	}
+
+	var others int
+	if err := c.db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id != ?", sessionID).Scan(&others); err != nil {
+		return nil, fmt.Errorf("failed to count sessions: %w", err)
+	}
+
+	scores := make(termVector, len(terms))
+	for _, term := range terms {
+		idf := math.Log(1 + float64(others+1)/float64(docFreqs[term]+1))
+		scores[term] = float64(termFreqs[term]) * idf
+	}
+	return topTerms(scores, maxSessionKeywords), nil
+}
+
+// SessionKeywords returns the keywords of indexed sessions, most distinctive first, by
+// session ID. Sessions without keywords are left out.
+func (c *Cache) SessionKeywords(sessionIDs []string) (map[string][]string, error) {
+	keywords := make(map[string][]string)
+	for start := 0; start < len(sessionIDs); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(sessionIDs) {
+			end = len(sessionIDs)
+		}
+		batch := sessionIDs[start:end]
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		query := "SELECT session_id, keyword FROM session_keywords WHERE session_id IN (" +
+			strings.TrimSuffix(strings.Repeat("?, ", len(batch)), ", ") + ") ORDER BY session_id, rank"
+		if err := c.scanSessionKeywords(keywords, query, args); err != nil {
+			return nil, err
+		}
+	}
+	return keywords, nil
+}
+
+func (c *Cache) scanSessionKeywords(keywords map[string][]string, query string, args []interface{}) error {
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to load keywords: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, keyword string
+		if err := rows.Scan(&id, &keyword); err != nil {
+			return fmt.Errorf("failed to scan keyword: %w", err)
+		}
+		if keyword, err = c.openTerm(keyword); err != nil {
+			return err
+		}
+		keywords[id] = append(keywords[id], keyword)
+	}
+	return rows.Err()
+}
+
+// keywordCondition returns an SQL condition selecting the sessions, whose ID is in the
+// given column, that have a keyword, and its argument
+func (c *Cache) keywordCondition(column, keyword string) (string, interface{}) {
+	return "EXISTS (SELECT 1 FROM session_keywords k WHERE k.session_id = " + column + " AND k.keyword = ?)",
+		c.sealTerm(strings.ToLower(strings.TrimSpace(keyword)))
+}
@@ -0,0 +1,59 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestSessionKeywords(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	index := func(id, content string) {
+		t.Helper()
+		session := adapters.Session{ID: id, Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: time.Now()}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+	index("other", "fix the failing tests and the build")
+	index("s1", "fix the kubernetes ingress. the kubernetes ingress and the build and the tests, 2024 2024 2024")
+
+	keywords, err := cache.SessionKeywords([]string{"s1", "other", "missing"})
+	if err != nil {
+		t.Fatalf("SessionKeywords failed: %v", err)
+	}
+	// Stop words, numbers, and terms used once are left out; rarer terms rank first
+	if want := []string{"ingress", "kubernetes"}; !reflect.DeepEqual(keywords["s1"], want) {
+		t.Fatalf("expected keywords %v, got %v", want, keywords["s1"])
+	}
+	if _, ok := keywords["other"]; ok {
+		t.Fatalf("expected no keywords for a session without repeated terms, got %v", keywords["other"])
+	}
+
+	results, err := cache.SearchWithOptions("build", SearchOptions{Keyword: " Kubernetes "})
+	if err != nil {
+		t.Fatalf("search failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Session.ID != "s1" {
+		t.Fatalf("expected the keyword to filter results, got %+v", results)
+	}
+	if !reflect.DeepEqual(results[0].Session.Keywords, []string{"ingress", "kubernetes"}) {
+		t.Fatalf("expected results to carry keywords, got %v", results[0].Session.Keywords)
+	}
+
+	// Reindexing replaces the keywords
+	index("s1", "deploy the helm chart, then the helm release")
+	results, err = cache.SearchWithOptions("build", SearchOptions{Keyword: "kubernetes"})
+	if err != nil || len(results) != 0 {
+		t.Fatalf("stale keywords after reindex: %+v, %v", results, err)
+	}
+}
@@ -0,0 +1,91 @@
+package search
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// GetMetadata returns the listing metadata stored for key, if it was stored for a file
+// with the same modification time and size. It implements adapters.MetadataCache.
+func (c *Cache) GetMetadata(key string, modTime time.Time, size int64) ([]byte, bool) {
+	var data []byte
+	err := c.db.QueryRow("SELECT data FROM session_metadata WHERE cache_key = ? AND file_mtime = ? AND file_size = ?",
+		key, modTime.UnixNano(), size).Scan(&data)
+	if err != nil {
+		return nil, false
+	}
+	data, err = c.openBytes(data)
+	return data, err == nil
+}
+
+// PutMetadata stores listing metadata for key, parsed from filePath, replacing any
+// previous entry. It implements adapters.MetadataCache.
+func (c *Cache) PutMetadata(key, filePath string, modTime time.Time, size int64, data []byte) error {
+	_, err := c.db.Exec("INSERT OR REPLACE INTO session_metadata (cache_key, file_path, file_mtime, file_size, data) VALUES (?, ?, ?, ?, ?)",
+		key, filePath, modTime.UnixNano(), size, c.sealBytes(data))
+	if err != nil {
+		return fmt.Errorf("failed to store metadata: %w", err)
+	}
+	return nil
+}
+
+// GetSessionFile returns the file a session was last seen in.
+// It implements adapters.MetadataCache.
+func (c *Cache) GetSessionFile(source, sessionID string) (string, bool) {
+	var filePath string
+	err := c.db.QueryRow("SELECT file_path FROM session_paths WHERE source = ? AND session_id = ?",
+		source, sessionID).Scan(&filePath)
+	if err != nil {
+		return "", false
+	}
+	return filePath, true
+}
+
+// PutSessionFile records the file a session is stored in.
+// It implements adapters.MetadataCache.
+func (c *Cache) PutSessionFile(source, sessionID, filePath string) error {
+	_, err := c.db.Exec("INSERT OR REPLACE INTO session_paths (source, session_id, file_path) VALUES (?, ?, ?)",
+		source, sessionID, filePath)
+	if err != nil {
+		return fmt.Errorf("failed to store session file: %w", err)
+	}
+	return nil
+}
+
+// PruneMetadata removes the listing metadata of files that no longer exist, returning how
+// many entries were removed.
+func (c *Cache) PruneMetadata() (int, error) {
+	rows, err := c.db.Query("SELECT DISTINCT file_path FROM session_metadata")
+	if err != nil {
+		return 0, fmt.Errorf("failed to list metadata files: %w", err)
+	}
+	var missing []string
+	for rows.Next() {
+		var filePath string
+		if err := rows.Scan(&filePath); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan metadata file: %w", err)
+		}
+		if _, err := os.Stat(filePath); errors.Is(err, fs.ErrNotExist) {
+			missing = append(missing, filePath)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list metadata files: %w", err)
+	}
+
+	removed := 0
+	for _, filePath := range missing {
+		result, err := c.db.Exec("DELETE FROM session_metadata WHERE file_path = ?", filePath)
+		if err != nil {
+			return removed, fmt.Errorf("failed to delete metadata: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		removed += int(n)
+	}
+	return removed, nil
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+var _ adapters.MetadataCache = (*Cache)(nil)
+
+func TestMetadataCacheMatchesFileState(t *testing.T) {
+	cache := newTempCache(t)
+	mtime := time.Unix(1700000000, 123)
+
+	if _, ok := cache.GetMetadata("v1:/a.jsonl", mtime, 10); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := cache.PutMetadata("v1:/a.jsonl", "/a.jsonl", mtime, 10, []byte(`{"id":"a"}`)); err != nil {
+		t.Fatalf("PutMetadata failed: %v", err)
+	}
+
+	if data, ok := cache.GetMetadata("v1:/a.jsonl", mtime, 10); !ok || string(data) != `{"id":"a"}` {
+		t.Fatalf("GetMetadata=%q, %v", data, ok)
+	}
+	if _, ok := cache.GetMetadata("v1:/a.jsonl", mtime.Add(time.Second), 10); ok {
+		t.Fatal("expected a miss after the file was modified")
+	}
+	if _, ok := cache.GetMetadata("v1:/a.jsonl", mtime, 11); ok {
+		t.Fatal("expected a miss after the file size changed")
+	}
+
+	if err := cache.PutMetadata("v1:/a.jsonl", "/a.jsonl", mtime.Add(time.Second), 11, []byte(`{"id":"b"}`)); err != nil {
+		t.Fatalf("PutMetadata failed: %v", err)
+	}
+	if data, ok := cache.GetMetadata("v1:/a.jsonl", mtime.Add(time.Second), 11); !ok || string(data) != `{"id":"b"}` {
+		t.Fatalf("GetMetadata after replace=%q, %v", data, ok)
+	}
+}
+
+func TestSessionFileIndex(t *testing.T) {
+	cache := newTempCache(t)
+
+	if _, ok := cache.GetSessionFile("claude", "s1"); ok {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := cache.PutSessionFile("claude", "s1", "/old/s1.jsonl"); err != nil {
+		t.Fatalf("PutSessionFile failed: %v", err)
+	}
+	if err := cache.PutSessionFile("claude", "s1", "/new/s1.jsonl"); err != nil {
+		t.Fatalf("PutSessionFile failed: %v", err)
+	}
+	if path, ok := cache.GetSessionFile("claude", "s1"); !ok || path != "/new/s1.jsonl" {
+		t.Fatalf("GetSessionFile=%q, %v", path, ok)
+	}
+	if _, ok := cache.GetSessionFile("codex", "s1"); ok {
+		t.Fatal("session files leaked across sources")
+	}
+}
+
+func TestPruneMetadataDropsMissingFiles(t *testing.T) {
+	cache := newTempCache(t)
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept.jsonl")
+	if err := os.WriteFile(kept, []byte("{}"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	deleted := filepath.Join(dir, "deleted.jsonl")
+	mtime := time.Unix(1700000000, 0)
+	for _, key := range []string{"v1:" + kept, "v1:" + deleted, "v1:" + deleted + "\x00variant"} {
+		filePath := kept
+		if strings.Contains(key, deleted) {
+			filePath = deleted
+		}
+		if err := cache.PutMetadata(key, filePath, mtime, 2, []byte("{}")); err != nil {
+			t.Fatalf("PutMetadata failed: %v", err)
+		}
+	}
+
+	removed, err := cache.PruneMetadata()
+	if err != nil || removed != 2 {
+		t.Fatalf("PruneMetadata removed %d (%v), want 2", removed, err)
+	}
+	if _, ok := cache.GetMetadata("v1:"+kept, mtime, 2); !ok {
+		t.Fatal("expected the metadata of an existing file to be kept")
+	}
+	if _, ok := cache.GetMetadata("v1:"+deleted, mtime, 2); ok {
+		t.Fatal("expected the metadata of a deleted file to be dropped")
+	}
+}
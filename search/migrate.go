@@ -0,0 +1,313 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ErrSchemaTooNew is returned when a cache was upgraded by a newer version of the
+// program, whose schema this one doesn't know
+var ErrSchemaTooNew = errors.New("the search cache was created by a newer version of aisessions")
+
+// migration upgrades the cache schema to its version. Migrations run in order, each in
+// its own transaction, and are never changed once released: a change to the schema is a
+// new migration appended to migrations.
+type migration struct {
+	version     int
+	description string
+	up          func(tx *sql.Tx) error
+}
+
+var migrations = []migration{
+	{version: 1, description: "create the initial schema", up: migrateInitialSchema},
+	{version: 2, description: "reindex first messages without command preambles", up: invalidateSessions},
+	{version: 3, description: "add session titles", up: addSessionTitles},
+	{version: 4, description: "add session repositories", up: addSessionRepos},
+	{version: 5, description: "add session branches and commits", up: addSessionCommits},
+	{version: 6, description: "add session keywords", up: addSessionKeywords},
+	{version: 7, description: "add session errors", up: addSessionErrors},
+	{version: 8, description: "add session content hashes", up: addContentHashes},
+	{version: 9, description: "add session sizes", up: addSessionSizes},
+	{version: 10, description: "add saved searches", up: addSavedSearches},
+	{version: 11, description: "add role-scoped terms", up: addRoleTerms},
+	{version: 12, description: "add the files of session metadata", up: addMetadataFiles},
+}
+
+// latestSchemaVersion is the schema version of caches opened by this program
+func latestSchemaVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// schemaVersionSQL records the migrations applied to a cache. Caches created before it
+// existed have no rows, and are at version 0.
+const schemaVersionSQL = `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    description TEXT NOT NULL,
+    applied_at INTEGER NOT NULL
+);`
+
+// migrate brings the schema of db up to the last of migrations, applying the ones it is
+// missing
+func migrate(db *sql.DB, migrations []migration) error {
+	if _, err := db.Exec(schemaVersionSQL); err != nil {
+		return fmt.Errorf("failed to create schema_version table: %w", err)
+	}
+	current, err := schemaVersion(db)
+	if err != nil {
+		return err
+	}
+	latest := migrations[len(migrations)-1].version
+	if current > latest {
+		return fmt.Errorf("%w (schema version %d, this version supports %d)", ErrSchemaTooNew, current, latest)
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		if err := applyMigration(db, m); err != nil {
+			return err
+		}
+		slog.Debug("Migrated search cache", "version", m.version, "migration", m.description)
+	}
+	return nil
+}
+
+// applyMigration applies m and records it in one transaction, unless another process
+// opening the cache applied it first
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM schema_version WHERE version = ?", m.version).Scan(&applied); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if applied > 0 {
+		return nil
+	}
+	if err := m.up(tx); err != nil {
+		return fmt.Errorf("failed to migrate cache to version %d (%s): %w", m.version, m.description, err)
+	}
+	if _, err := tx.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?, ?, ?)",
+		m.version, m.description, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return tx.Commit()
+}
+
+// schemaVersion returns the last migration applied to db, 0 when none was
+func schemaVersion(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_version").Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
+
+// migrateInitialSchema creates the tables of schema.sql. Caches created before schema
+// versioning already have some of them; those created before the files table existed
+// are reindexed to populate it.
+func migrateInitialSchema(tx *sql.Tx) error {
+	var hasSessions, hasFiles int
+	err := tx.QueryRow(`SELECT
+		COUNT(CASE WHEN name = 'sessions' THEN 1 END),
+		COUNT(CASE WHEN name = 'session_files' THEN 1 END)
+		FROM sqlite_master WHERE type = 'table'`).Scan(&hasSessions, &hasFiles)
+	if err != nil {
+		return fmt.Errorf("failed to inspect schema: %w", err)
+	}
+	if _, err := tx.Exec(schemaSQL); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+	if hasSessions > 0 && hasFiles == 0 {
+		if _, err := tx.Exec("UPDATE sessions SET file_mtime = 0"); err != nil {
+			return fmt.Errorf("failed to invalidate cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// invalidateSessions makes every session be reindexed, for migrations changing what is
+// derived from sessions rather than the schema
+func invalidateSessions(tx *sql.Tx) error {
+	if _, err := tx.Exec("UPDATE sessions SET file_mtime = 0"); err != nil {
+		return fmt.Errorf("failed to invalidate cache: %w", err)
+	}
+	return nil
+}
+
+// addSessionTitles adds the title column, filled in as sessions are reindexed
+func addSessionTitles(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE sessions ADD COLUMN title TEXT NOT NULL DEFAULT ''"); err != nil {
+		return fmt.Errorf("failed to add title column: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSessionRepos adds the repo column, filled in as sessions are reindexed
+func addSessionRepos(tx *sql.Tx) error {
+	if _, err := tx.Exec("ALTER TABLE sessions ADD COLUMN repo TEXT NOT NULL DEFAULT ''"); err != nil {
+		return fmt.Errorf("failed to add repo column: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSessionCommitsSQL adds the branch column and the commits created by sessions
+const addSessionCommitsSQL = `
+ALTER TABLE sessions ADD COLUMN branch TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS session_commits (
+    session_id TEXT NOT NULL,
+    hash TEXT NOT NULL,           -- Abbreviated, as printed by git
+    branch TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    timestamp INTEGER NOT NULL,
+    PRIMARY KEY (session_id, hash),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_commits_hash ON session_commits(hash);
+CREATE INDEX IF NOT EXISTS idx_session_commits_branch ON session_commits(branch);`
+
+// addSessionCommits adds session branches and commits, filled in as sessions are reindexed
+func addSessionCommits(tx *sql.Tx) error {
+	if _, err := tx.Exec(addSessionCommitsSQL); err != nil {
+		return fmt.Errorf("failed to add commits: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSessionKeywordsSQL adds the most distinctive terms of sessions
+const addSessionKeywordsSQL = `
+CREATE TABLE IF NOT EXISTS session_keywords (
+    session_id TEXT NOT NULL,
+    keyword TEXT NOT NULL,
+    rank INTEGER NOT NULL,        -- 0 for the most distinctive
+    PRIMARY KEY (session_id, keyword),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_keywords_keyword ON session_keywords(keyword);`
+
+// addSessionKeywords adds session keywords, computed as sessions are reindexed
+func addSessionKeywords(tx *sql.Tx) error {
+	if _, err := tx.Exec(addSessionKeywordsSQL); err != nil {
+		return fmt.Errorf("failed to add keywords: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSessionErrorsSQL adds the errors that showed up in the tool output of sessions
+const addSessionErrorsSQL = `
+CREATE TABLE IF NOT EXISTS session_errors (
+    session_id TEXT NOT NULL,
+    position INTEGER NOT NULL,    -- Order in which the errors first showed up
+    kind TEXT NOT NULL,           -- panic, traceback, exception, compiler, error
+    message TEXT NOT NULL,
+    count INTEGER NOT NULL,
+    timestamp INTEGER NOT NULL,
+    PRIMARY KEY (session_id, position),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_errors_kind ON session_errors(kind);`
+
+// addSessionErrors adds session errors, filled in as sessions are reindexed
+func addSessionErrors(tx *sql.Tx) error {
+	if _, err := tx.Exec(addSessionErrorsSQL); err != nil {
+		return fmt.Errorf("failed to add errors: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addContentHashesSQL adds the hash of the text of sessions, to find copies of a session
+const addContentHashesSQL = `
+ALTER TABLE sessions ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_sessions_content_hash ON sessions(content_hash);`
+
+// addContentHashes adds the content_hash column, filled in as sessions are reindexed
+func addContentHashes(tx *sql.Tx) error {
+	if _, err := tx.Exec(addContentHashesSQL); err != nil {
+		return fmt.Errorf("failed to add content_hash column: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSessionSizesSQL adds the number of messages of sessions and the size of their files
+const addSessionSizesSQL = `
+ALTER TABLE sessions ADD COLUMN message_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE sessions ADD COLUMN file_size INTEGER NOT NULL DEFAULT 0;`
+
+// addSessionSizes adds the message_count and file_size columns, filled in as sessions
+// are reindexed
+func addSessionSizes(tx *sql.Tx) error {
+	if _, err := tx.Exec(addSessionSizesSQL); err != nil {
+		return fmt.Errorf("failed to add size columns: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addSavedSearchesSQL adds named queries. Like tags and notes they are the user's, so
+// they aren't dropped when sessions are reindexed.
+const addSavedSearchesSQL = `
+CREATE TABLE IF NOT EXISTS saved_searches (
+    name TEXT PRIMARY KEY,
+    definition TEXT NOT NULL,     -- The query and its filters, as JSON
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);`
+
+// addSavedSearches adds the saved_searches table
+func addSavedSearches(tx *sql.Tx) error {
+	if _, err := tx.Exec(addSavedSearchesSQL); err != nil {
+		return fmt.Errorf("failed to add saved searches: %w", err)
+	}
+	return nil
+}
+
+// addRoleTermsSQL adds the terms of sessions by the role of the messages they appear in,
+// for searches scoped to a role
+const addRoleTermsSQL = `
+CREATE TABLE IF NOT EXISTS role_term_index (
+    term TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    role TEXT NOT NULL,           -- user, assistant, tool, system
+    term_frequency INTEGER NOT NULL,
+    PRIMARY KEY (term, session_id, role),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);`
+
+// addRoleTerms adds role-scoped terms, filled in as sessions are reindexed
+func addRoleTerms(tx *sql.Tx) error {
+	if _, err := tx.Exec(addRoleTermsSQL); err != nil {
+		return fmt.Errorf("failed to add role terms: %w", err)
+	}
+	return invalidateSessions(tx)
+}
+
+// addMetadataFilesSQL records the file each metadata entry was parsed from, so entries
+// can be dropped with their sessions and once their files are gone
+const addMetadataFilesSQL = `
+ALTER TABLE session_metadata ADD COLUMN file_path TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_session_metadata_file ON session_metadata(file_path);`
+
+// addMetadataFiles adds the file of each metadata entry. Existing entries don't know
+// theirs, so they are dropped to be parsed again.
+func addMetadataFiles(tx *sql.Tx) error {
+	if _, err := tx.Exec(addMetadataFilesSQL); err != nil {
+		return fmt.Errorf("failed to add metadata files: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM session_metadata"); err != nil {
+		return fmt.Errorf("failed to clear metadata: %w", err)
+	}
+	return nil
+}
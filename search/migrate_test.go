@@ -0,0 +1,131 @@
+package search
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestNewCacheIsAtLatestSchemaVersion(t *testing.T) {
+	cache := newTempCache(t)
+	version, err := cache.SchemaVersion()
+	if err != nil || version != latestSchemaVersion() {
+		t.Fatalf("expected schema version %d, got %d (%v)", latestSchemaVersion(), version, err)
+	}
+}
+
+func TestMigrateUnversionedCache(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	// A cache created before schema versioning and the files table
+	_, err = db.Exec(`
+		CREATE TABLE sessions (
+			id TEXT PRIMARY KEY, source TEXT NOT NULL, project_path TEXT NOT NULL, file_path TEXT NOT NULL,
+			first_message TEXT, summary TEXT, timestamp INTEGER NOT NULL, last_indexed INTEGER NOT NULL,
+			file_mtime INTEGER NOT NULL, doc_length INTEGER DEFAULT 0, content TEXT
+		);
+		INSERT INTO sessions VALUES ('s1', 'claude', '/repo', '/repo/s1.jsonl', 'hi', '', 0, 0, 4102444800, 1, 'hi');`)
+	db.Close()
+	if err != nil {
+		t.Fatalf("create old cache: %v", err)
+	}
+
+	cache, err := NewCache(dbPath)
+	if err != nil {
+		t.Fatalf("NewCache failed: %v", err)
+	}
+	defer cache.Close()
+	if version, err := cache.SchemaVersion(); err != nil || version != latestSchemaVersion() {
+		t.Fatalf("expected schema version %d, got %d (%v)", latestSchemaVersion(), version, err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "s1.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	if reindex, err := cache.NeedsReindex("s1", filePath); err != nil || !reindex {
+		t.Fatalf("expected the session to be reindexed to populate its files, got %v (%v)", reindex, err)
+	}
+	if tags, err := cache.ListTags(); err != nil || len(tags) != 0 {
+		t.Fatalf("expected the tags table to be created, got %v (%v)", tags, err)
+	}
+}
+
+func TestMigrateAppliesMissingMigrationsOnce(t *testing.T) {
+	cache := newTempCache(t)
+	session := adapters.Session{ID: "s1", Source: "claude", ProjectPath: "/repo", Timestamp: time.Now(), FilePath: writeTempFile(t)}
+	if err := cache.IndexSession(session, "fix the flaky test"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+
+	runs := 0
+	upgraded := append(migrations[:len(migrations):len(migrations)], migration{
+		version:     latestSchemaVersion() + 1,
+		description: "add a column",
+		up: func(tx *sql.Tx) error {
+			runs++
+			_, err := tx.Exec("ALTER TABLE sessions ADD COLUMN language TEXT")
+			return err
+		},
+	})
+	for i := 0; i < 2; i++ {
+		if err := migrate(cache.db, upgraded); err != nil {
+			t.Fatalf("migrate failed: %v", err)
+		}
+	}
+	if runs != 1 {
+		t.Fatalf("expected the migration to run once, ran %d times", runs)
+	}
+	if version, _ := cache.SchemaVersion(); version != latestSchemaVersion()+1 {
+		t.Fatalf("expected schema version %d, got %d", latestSchemaVersion()+1, version)
+	}
+	if health, err := cache.Health(); err != nil || health.Sessions != 1 {
+		t.Fatalf("expected the indexed session to be kept, got %+v (%v)", health, err)
+	}
+
+	// The program that doesn't know the new migration refuses the cache
+	if err := migrate(cache.db, migrations); !errors.Is(err, ErrSchemaTooNew) {
+		t.Fatalf("expected ErrSchemaTooNew, got %v", err)
+	}
+}
+
+func TestMigrateRollsBackFailedMigration(t *testing.T) {
+	cache := newTempCache(t)
+	failing := append(migrations[:len(migrations):len(migrations)], migration{
+		version:     latestSchemaVersion() + 1,
+		description: "fail halfway",
+		up: func(tx *sql.Tx) error {
+			if _, err := tx.Exec("CREATE TABLE half_done (id INTEGER)"); err != nil {
+				return err
+			}
+			return errors.New("boom")
+		},
+	})
+	if err := migrate(cache.db, failing); err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+	if version, _ := cache.SchemaVersion(); version != latestSchemaVersion() {
+		t.Fatalf("expected schema version %d after the failure, got %d", latestSchemaVersion(), version)
+	}
+	var tables int
+	if err := cache.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'half_done'").Scan(&tables); err != nil || tables != 0 {
+		t.Fatalf("expected the failed migration to be rolled back, found %d tables (%v)", tables, err)
+	}
+}
+
+func writeTempFile(t *testing.T) string {
+	t.Helper()
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	return filePath
+}
@@ -0,0 +1,83 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxNoteLength caps the length of a single note
+const maxNoteLength = 4000
+
+// Note is a free-text annotation attached to a session
+type Note struct {
+	ID        int64     `json:"id"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AddNote attaches a note to a session and returns it
+func (c *Cache) AddNote(source, sessionID, text string) (Note, error) {
+	if source == "" || sessionID == "" {
+		return Note{}, fmt.Errorf("source and session ID are required")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Note{}, fmt.Errorf("note cannot be empty")
+	}
+	if len(text) > maxNoteLength {
+		return Note{}, fmt.Errorf("note too long (max %d characters)", maxNoteLength)
+	}
+
+	now := time.Now()
+	res, err := c.db.Exec("INSERT INTO session_notes (source, session_id, note, created_at) VALUES (?, ?, ?, ?)",
+		source, sessionID, c.sealText(text), now.Unix())
+	if err != nil {
+		return Note{}, fmt.Errorf("failed to add note: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Note{}, fmt.Errorf("failed to add note: %w", err)
+	}
+
+	return Note{ID: id, Text: text, CreatedAt: time.Unix(now.Unix(), 0)}, nil
+}
+
+// DeleteNote removes a note by ID
+func (c *Cache) DeleteNote(id int64) error {
+	res, err := c.db.Exec("DELETE FROM session_notes WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete note: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("note not found: %d", id)
+	}
+	return nil
+}
+
+// SessionNotes returns the notes attached to a session, oldest first
+func (c *Cache) SessionNotes(source, sessionID string) ([]Note, error) {
+	rows, err := c.db.Query("SELECT id, note, created_at FROM session_notes WHERE source = ? AND session_id = ? ORDER BY created_at, id",
+		source, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var note Note
+		var createdAt int64
+		if err := rows.Scan(&note.ID, &note.Text, &createdAt); err != nil {
+			return nil, err
+		}
+		text, err := c.openText(note.Text)
+		if err != nil {
+			return nil, err
+		}
+		note.Text = text
+		note.CreatedAt = time.Unix(createdAt, 0)
+		notes = append(notes, note)
+	}
+	return notes, rows.Err()
+}
@@ -0,0 +1,39 @@
+package search
+
+import "testing"
+
+func TestSessionNotes(t *testing.T) {
+	cache := newTempCache(t)
+
+	first, err := cache.AddNote("claude", "s1", "  fix shipped in v1.2  ")
+	if err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if first.Text != "fix shipped in v1.2" {
+		t.Fatalf("note not trimmed: %q", first.Text)
+	}
+	if _, err := cache.AddNote("claude", "s1", "follow-up in PR #42"); err != nil {
+		t.Fatalf("AddNote failed: %v", err)
+	}
+	if _, err := cache.AddNote("claude", "s1", "   "); err == nil {
+		t.Fatal("expected error for empty note")
+	}
+
+	notes, err := cache.SessionNotes("claude", "s1")
+	if err != nil || len(notes) != 2 || notes[0].ID != first.ID {
+		t.Fatalf("SessionNotes=%+v, %v", notes, err)
+	}
+	if other, _ := cache.SessionNotes("codex", "s1"); len(other) != 0 {
+		t.Fatalf("notes leaked across sources: %+v", other)
+	}
+
+	if err := cache.DeleteNote(first.ID); err != nil {
+		t.Fatalf("DeleteNote failed: %v", err)
+	}
+	if err := cache.DeleteNote(first.ID); err == nil {
+		t.Fatal("expected error deleting a missing note")
+	}
+	if notes, _ := cache.SessionNotes("claude", "s1"); len(notes) != 1 {
+		t.Fatalf("note not deleted: %+v", notes)
+	}
+}
@@ -0,0 +1,170 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MessageRoles are the roles a query can be scoped to with role:
+var MessageRoles = []string{"user", "assistant", "tool", "system"}
+
+// ParsedQuery is a search query split into the words to search for and the field
+// filters it contains. Several values of a field match any of them; different fields
+// must all match.
+type ParsedQuery struct {
+	Text     string   // The query without its field filters
+	Roles    []string // role: only count words in messages with these roles
+	Files    []string // file: only match sessions that touched these files (paths or globs)
+	Sources  []string // source: only match sessions of these sources
+	Projects []string // project: only match sessions of these projects (see adapters.ProjectFilter)
+}
+
+// Empty reports whether the query has no field filters
+func (q ParsedQuery) Empty() bool {
+	return len(q.Roles) == 0 && len(q.Files) == 0 && len(q.Sources) == 0 && len(q.Projects) == 0
+}
+
+// ParseQuery extracts the field filters of a query: role:user, file:main.go,
+// source:codex and project:myrepo. A value with spaces is quoted, as in
+// project:"my repo". Other words, including ones with an unknown prefix like
+// http://example.com, are left in the text.
+func ParseQuery(query string) (ParsedQuery, error) {
+	var parsed ParsedQuery
+	var text []string
+	for _, word := range splitQuery(query) {
+		field, value, ok := strings.Cut(word, ":")
+		value = strings.Trim(value, `"`)
+		if !ok || value == "" {
+			text = append(text, word)
+			continue
+		}
+		switch strings.ToLower(field) {
+		case "role":
+			role := strings.ToLower(value)
+			if !containsString(MessageRoles, role) {
+				return ParsedQuery{}, fmt.Errorf("unknown role in query: %s (use %s)", value, strings.Join(MessageRoles, ", "))
+			}
+			parsed.Roles = append(parsed.Roles, role)
+		case "file":
+			parsed.Files = append(parsed.Files, value)
+		case "source":
+			parsed.Sources = append(parsed.Sources, strings.ToLower(value))
+		case "project":
+			parsed.Projects = append(parsed.Projects, value)
+		default:
+			text = append(text, word)
+		}
+	}
+	parsed.Text = strings.Join(text, " ")
+	return parsed, nil
+}
+
+// splitQuery splits a query on whitespace, keeping quoted text in one word
+func splitQuery(query string) []string {
+	var words []string
+	var word strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			word.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if word.Len() > 0 {
+				words = append(words, word.String())
+				word.Reset()
+			}
+		default:
+			word.WriteRune(r)
+		}
+	}
+	if word.Len() > 0 {
+		words = append(words, word.String())
+	}
+	return words
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fileCondition returns an SQL condition matching a file path column against a path or
+// glob, like FindSessionsByFile
+func fileCondition(column, pattern string) (string, []interface{}) {
+	if strings.ContainsAny(pattern, "*?[") {
+		return "(" + column + " GLOB ? OR " + column + " GLOB ?)", []interface{}{pattern, "*/" + strings.TrimPrefix(pattern, "/")}
+	}
+	return "(" + column + " = ? OR " + column + ` LIKE ? ESCAPE '\')`, []interface{}{pattern, "%/" + escapeLike(strings.TrimPrefix(pattern, "/"))}
+}
+
+// filterConditions returns the SQL conditions on the sessions table, aliased s, of the
+// field filters of a query other than role:
+func (c *Cache) filterConditions(parsed ParsedQuery) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	anyOf := func(parts []string) string {
+		return "(" + strings.Join(parts, " OR ") + ")"
+	}
+
+	if len(parsed.Sources) > 0 {
+		conditions = append(conditions, "s.source IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(parsed.Sources)), ", ")+")")
+		for _, source := range parsed.Sources {
+			args = append(args, source)
+		}
+	}
+	if len(parsed.Projects) > 0 {
+		var parts []string
+		for _, project := range parsed.Projects {
+			condition, projectArgs, err := c.projectCondition("s.project_path", project)
+			if err != nil {
+				return "", nil, err
+			}
+			parts = append(parts, condition)
+			args = append(args, projectArgs...)
+		}
+		conditions = append(conditions, anyOf(parts))
+	}
+	if len(parsed.Files) > 0 {
+		var parts []string
+		for _, file := range parsed.Files {
+			condition, fileArgs := fileCondition("f.path", file)
+			parts = append(parts, condition)
+			args = append(args, fileArgs...)
+		}
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM session_files f WHERE f.session_id = s.id AND "+anyOf(parts)+")")
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
+
+// exclusionConditions returns the SQL condition on the sessions table, aliased s, leaving
+// out the sessions of sources and projects
+func (c *Cache) exclusionConditions(sources, projects []string) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	if len(sources) > 0 {
+		conditions = append(conditions, "s.source NOT IN ("+strings.TrimSuffix(strings.Repeat("?, ", len(sources)), ", ")+")")
+		for _, source := range sources {
+			args = append(args, source)
+		}
+	}
+	for _, project := range projects {
+		if strings.TrimSpace(project) == "" {
+			continue
+		}
+		condition, projectArgs, err := c.projectCondition("s.project_path", project)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, "NOT ("+condition+")")
+		args = append(args, projectArgs...)
+	}
+	if len(conditions) == 0 {
+		return "1", nil, nil
+	}
+	return strings.Join(conditions, " AND "), args, nil
+}
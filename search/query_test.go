@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+	"github.com/yoavf/ai-sessions-mcp/analysis"
+)
+
+func TestParseQuery(t *testing.T) {
+	parsed, err := ParseQuery(`ROLE:User flaky test file:cmd/main.go source:Codex project:"my repo" https://example.com role:tool note:`)
+	if err != nil {
+		t.Fatalf("ParseQuery failed: %v", err)
+	}
+	want := ParsedQuery{
+		Text:     "flaky test https://example.com note:",
+		Roles:    []string{"user", "tool"},
+		Files:    []string{"cmd/main.go"},
+		Sources:  []string{"codex"},
+		Projects: []string{"my repo"},
+	}
+	if !reflect.DeepEqual(parsed, want) {
+		t.Fatalf("unexpected parsed query:\n%+v\nwant\n%+v", parsed, want)
+	}
+	if parsed, err := ParseQuery("plain words"); err != nil || !parsed.Empty() || parsed.Text != "plain words" {
+		t.Fatalf("expected no filters, got %+v (%v)", parsed, err)
+	}
+	if _, err := ParseQuery("role:usr retry"); err == nil {
+		t.Fatal("expected an error for an unknown role")
+	}
+}
+
+func TestSearchWithFieldFilters(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	for _, tc := range []struct {
+		session adapters.Session
+		details SessionDetails
+	}{
+		{
+			adapters.Session{ID: "asked", Source: "claude", ProjectPath: "/work/api"},
+			SessionDetails{
+				RoleContent: map[string]string{"user": "why does the deploy fail", "assistant": "checking"},
+				Files:       []analysis.FileActivity{{Path: "/work/api/cmd/main.go", Operations: map[string]int{"edit": 1}}},
+			},
+		},
+		{
+			adapters.Session{ID: "answered", Source: "codex", ProjectPath: "/work/web"},
+			SessionDetails{
+				RoleContent: map[string]string{"user": "hello", "assistant": "the deploy failed on a timeout"},
+				Files:       []analysis.FileActivity{{Path: "/work/web/deploy.sh", Operations: map[string]int{"read": 1}}},
+			},
+		},
+	} {
+		tc.session.Timestamp, tc.session.FilePath = time.Now(), filePath
+		content := tc.details.RoleContent["user"] + " " + tc.details.RoleContent["assistant"]
+		if err := cache.IndexSessionWithDetails(context.Background(), tc.session, content, tc.details); err != nil {
+			t.Fatalf("IndexSessionWithDetails failed: %v", err)
+		}
+	}
+
+	for query, want := range map[string][]string{
+		"deploy":                            {"answered", "asked"},
+		"deploy role:user":                  {"asked"},
+		"role:assistant deploy":             {"answered"},
+		"deploy role:user role:tool":        {"asked"},
+		"deploy file:main.go":               {"asked"},
+		"deploy file:*.sh":                  {"answered"},
+		"deploy file:main.go file:*.sh":     {"answered", "asked"},
+		"deploy source:codex":               {"answered"},
+		"deploy project:api":                {"asked"},
+		"deploy project:api role:assistant": {},
+	} {
+		results, err := cache.Search(query, "", "", 10)
+		if err != nil {
+			t.Fatalf("Search(%q) failed: %v", query, err)
+		}
+		got := []string{}
+		for _, result := range results {
+			got = append(got, result.Session.ID)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Search(%q) = %v, want %v", query, got, want)
+		}
+	}
+
+	if _, err := cache.Search("role:user", "", "", 10); err == nil {
+		t.Fatal("expected an error for a query with only filters")
+	}
+	suggestions, err := cache.Suggest("deplyo role:user")
+	if err != nil || len(suggestions) != 1 || suggestions[0].Term != "deplyo" {
+		t.Fatalf("expected a suggestion for the words only, got %+v (%v)", suggestions, err)
+	}
+	if corrected := CorrectQuery("deplyo role:user", suggestions); corrected != "deploy role:user" {
+		t.Fatalf("expected the filters to be kept, got %q", corrected)
+	}
+}
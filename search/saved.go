@@ -0,0 +1,167 @@
+package search
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxSavedSearchNameLength caps the length of a saved search's name
+const maxSavedSearchNameLength = 64
+
+// SavedSearch is a named query with its filters, to run a recurring search in one call
+type SavedSearch struct {
+	Name        string    `json:"name"`
+	Query       string    `json:"query"`
+	Source      string    `json:"source,omitempty"`
+	ProjectPath string    `json:"project_path,omitempty"`
+	Tag         string    `json:"tag,omitempty"`
+	Keyword     string    `json:"keyword,omitempty"`
+	Limit       int       `json:"limit,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// savedSearchDefinition is what is stored of a saved search besides its name and times,
+// encrypted with the cache's key like the rest of its text
+type savedSearchDefinition struct {
+	Query       string `json:"query"`
+	Source      string `json:"source,omitempty"`
+	ProjectPath string `json:"project_path,omitempty"`
+	Tag         string `json:"tag,omitempty"`
+	Keyword     string `json:"keyword,omitempty"`
+	Limit       int    `json:"limit,omitempty"`
+}
+
+// NormalizeSavedSearchName lower-cases a saved search's name and replaces whitespace
+// with dashes, like tags
+func NormalizeSavedSearchName(name string) (string, error) {
+	name = strings.Join(strings.Fields(strings.ToLower(name)), "-")
+	if name == "" {
+		return "", fmt.Errorf("saved search name cannot be empty")
+	}
+	if len(name) > maxSavedSearchNameLength {
+		return "", fmt.Errorf("saved search name too long (max %d characters): %s", maxSavedSearchNameLength, name)
+	}
+	return name, nil
+}
+
+// SaveSearch stores a saved search under its name, replacing the one of the same name,
+// and returns it as stored
+func (c *Cache) SaveSearch(saved SavedSearch) (SavedSearch, error) {
+	name, err := NormalizeSavedSearchName(saved.Name)
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	saved.Name = name
+	saved.Query = strings.TrimSpace(saved.Query)
+	if saved.Query == "" {
+		return SavedSearch{}, fmt.Errorf("query cannot be empty")
+	}
+	if saved.Limit < 0 {
+		return SavedSearch{}, fmt.Errorf("limit must not be negative")
+	}
+	if saved.Tag != "" {
+		if saved.Tag, err = NormalizeTag(saved.Tag); err != nil {
+			return SavedSearch{}, err
+		}
+	}
+
+	definition, err := json.Marshal(savedSearchDefinition{
+		Query:       saved.Query,
+		Source:      saved.Source,
+		ProjectPath: saved.ProjectPath,
+		Tag:         saved.Tag,
+		Keyword:     saved.Keyword,
+		Limit:       saved.Limit,
+	})
+	if err != nil {
+		return SavedSearch{}, fmt.Errorf("failed to encode saved search: %w", err)
+	}
+
+	now := time.Now().Unix()
+	if _, err := c.db.Exec(`INSERT INTO saved_searches (name, definition, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET definition = excluded.definition, updated_at = excluded.updated_at`,
+		name, c.sealText(string(definition)), now, now); err != nil {
+		return SavedSearch{}, fmt.Errorf("failed to save search: %w", err)
+	}
+	return c.SavedSearch(name)
+}
+
+// SavedSearch returns the saved search called name
+func (c *Cache) SavedSearch(name string) (SavedSearch, error) {
+	normalized, err := NormalizeSavedSearchName(name)
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	row := c.db.QueryRow("SELECT name, definition, created_at, updated_at FROM saved_searches WHERE name = ?", normalized)
+	saved, err := c.scanSavedSearch(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SavedSearch{}, fmt.Errorf("saved search not found: %s", normalized)
+	}
+	return saved, err
+}
+
+// SavedSearches returns every saved search, by name
+func (c *Cache) SavedSearches() ([]SavedSearch, error) {
+	rows, err := c.db.Query("SELECT name, definition, created_at, updated_at FROM saved_searches ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []SavedSearch{}
+	for rows.Next() {
+		saved, err := c.scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, saved)
+	}
+	return searches, rows.Err()
+}
+
+// DeleteSavedSearch removes the saved search called name
+func (c *Cache) DeleteSavedSearch(name string) error {
+	normalized, err := NormalizeSavedSearchName(name)
+	if err != nil {
+		return err
+	}
+	res, err := c.db.Exec("DELETE FROM saved_searches WHERE name = ?", normalized)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("saved search not found: %s", normalized)
+	}
+	return nil
+}
+
+func (c *Cache) scanSavedSearch(row interface{ Scan(...interface{}) error }) (SavedSearch, error) {
+	var saved SavedSearch
+	var sealed string
+	var createdAt, updatedAt int64
+	if err := row.Scan(&saved.Name, &sealed, &createdAt, &updatedAt); err != nil {
+		return SavedSearch{}, err
+	}
+	text, err := c.openText(sealed)
+	if err != nil {
+		return SavedSearch{}, err
+	}
+	var definition savedSearchDefinition
+	if err := json.Unmarshal([]byte(text), &definition); err != nil {
+		return SavedSearch{}, fmt.Errorf("failed to decode saved search %s: %w", saved.Name, err)
+	}
+	saved.Query = definition.Query
+	saved.Source = definition.Source
+	saved.ProjectPath = definition.ProjectPath
+	saved.Tag = definition.Tag
+	saved.Keyword = definition.Keyword
+	saved.Limit = definition.Limit
+	saved.CreatedAt = time.Unix(createdAt, 0)
+	saved.UpdatedAt = time.Unix(updatedAt, 0)
+	return saved, nil
+}
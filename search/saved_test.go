@@ -0,0 +1,69 @@
+package search
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+func TestSavedSearches(t *testing.T) {
+	cache := newTempCache(t)
+
+	saved, err := cache.SaveSearch(SavedSearch{Name: "Flaky CI", Query: "  flaky test timeout ", ProjectPath: "api", Tag: "CI Failures", Limit: 5})
+	if err != nil {
+		t.Fatalf("SaveSearch failed: %v", err)
+	}
+	if saved.Name != "flaky-ci" || saved.Query != "flaky test timeout" || saved.Tag != "ci-failures" || saved.CreatedAt.IsZero() {
+		t.Fatalf("unexpected saved search: %+v", saved)
+	}
+	if _, err := cache.SaveSearch(SavedSearch{Name: "empty", Query: " "}); err == nil {
+		t.Fatal("expected an error for an empty query")
+	}
+
+	// Saving under the same name replaces the search
+	if _, err := cache.SaveSearch(SavedSearch{Name: "flaky ci", Query: "flaky", Source: "codex"}); err != nil {
+		t.Fatalf("SaveSearch failed: %v", err)
+	}
+	if _, err := cache.SaveSearch(SavedSearch{Name: "deploys", Query: "deploy rollback"}); err != nil {
+		t.Fatalf("SaveSearch failed: %v", err)
+	}
+	got, err := cache.SavedSearch("FLAKY CI")
+	if err != nil || got.Query != "flaky" || got.Source != "codex" || got.ProjectPath != "" || got.Limit != 0 {
+		t.Fatalf("expected the replaced search, got %+v (%v)", got, err)
+	}
+
+	searches, err := cache.SavedSearches()
+	if err != nil || len(searches) != 2 || searches[0].Name != "deploys" || searches[1].Name != "flaky-ci" {
+		t.Fatalf("expected both searches by name, got %+v (%v)", searches, err)
+	}
+
+	if err := cache.DeleteSavedSearch("deploys"); err != nil {
+		t.Fatalf("DeleteSavedSearch failed: %v", err)
+	}
+	if err := cache.DeleteSavedSearch("deploys"); err == nil {
+		t.Fatal("expected an error deleting a missing search")
+	}
+	if _, err := cache.SavedSearch("deploys"); err == nil {
+		t.Fatal("expected an error for a missing search")
+	}
+}
+
+func TestCopyAnnotationsCopiesSavedSearches(t *testing.T) {
+	plain := newTempCache(t)
+	if _, err := plain.SaveSearch(SavedSearch{Name: "flaky", Query: "flaky test", Keyword: "ci"}); err != nil {
+		t.Fatalf("SaveSearch failed: %v", err)
+	}
+
+	encrypted, err := NewEncryptedCache(filepath.Join(t.TempDir(), "cache.db"), bytes.Repeat([]byte{7}, KeySize))
+	if err != nil {
+		t.Fatalf("NewEncryptedCache failed: %v", err)
+	}
+	defer encrypted.Close()
+	if err := plain.CopyAnnotations(encrypted); err != nil {
+		t.Fatalf("CopyAnnotations failed: %v", err)
+	}
+	saved, err := encrypted.SavedSearch("flaky")
+	if err != nil || saved.Query != "flaky test" || saved.Keyword != "ci" {
+		t.Fatalf("expected the saved search in the encrypted cache, got %+v (%v)", saved, err)
+	}
+}
@@ -1,3 +1,6 @@
+-- The schema of version 1. Later changes are migrations (see migrate.go), so this file
+-- is never changed.
+
 -- Session cache with metadata
 CREATE TABLE IF NOT EXISTS sessions (
     id TEXT PRIMARY KEY,
@@ -28,6 +31,65 @@ CREATE TABLE IF NOT EXISTS term_index (
 
 CREATE INDEX IF NOT EXISTS idx_term_index_term ON term_index(term);
 
+-- Files read or modified in each session, for file-based lookup
+CREATE TABLE IF NOT EXISTS session_files (
+    session_id TEXT NOT NULL,
+    path TEXT NOT NULL,
+    operation TEXT NOT NULL,      -- read, edit, write, create, delete
+    count INTEGER NOT NULL,
+    PRIMARY KEY (session_id, path, operation),
+    FOREIGN KEY (session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_files_path ON session_files(path);
+
+-- User-assigned tags. Keyed by source and session ID rather than referencing the
+-- sessions table so they survive reindexing.
+CREATE TABLE IF NOT EXISTS session_tags (
+    source TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+    PRIMARY KEY (source, session_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_tags_tag ON session_tags(tag);
+
+-- Free-text notes attached to sessions, keyed like session_tags
+CREATE TABLE IF NOT EXISTS session_notes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    source TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    note TEXT NOT NULL,
+    created_at INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_notes_session ON session_notes(source, session_id);
+
+-- Listing metadata parsed from session files, reused while a file is unchanged.
+-- Keys are chosen by the adapters (see adapters.MetadataCache).
+CREATE TABLE IF NOT EXISTS session_metadata (
+    cache_key TEXT PRIMARY KEY,
+    file_mtime INTEGER NOT NULL,  -- Modification time (ns) of the file the data was parsed from
+    file_size INTEGER NOT NULL,
+    data BLOB NOT NULL
+);
+
+-- Where each session's file lives, so a session can be opened by ID without scanning
+CREATE TABLE IF NOT EXISTS session_paths (
+    source TEXT NOT NULL,
+    session_id TEXT NOT NULL,
+    file_path TEXT NOT NULL,
+    PRIMARY KEY (source, session_id)
+);
+
+-- Settings of the cache itself, such as the encrypted value that checks the key of an
+-- encrypted cache
+CREATE TABLE IF NOT EXISTS cache_settings (
+    key TEXT PRIMARY KEY,
+    value TEXT NOT NULL
+);
+
 -- Global statistics for BM25
 CREATE TABLE IF NOT EXISTS search_stats (
     key TEXT PRIMARY KEY,
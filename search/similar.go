@@ -0,0 +1,339 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+const (
+	// similarityKeyTerms is how many of a session's highest-weighted terms are used to find candidates
+	similarityKeyTerms = 100
+
+	// similarityCandidateFactor controls how many candidates are fully scored per requested result
+	similarityCandidateFactor = 5
+
+	// maxSharedTerms caps the shared terms reported per result
+	maxSharedTerms = 10
+)
+
+// SimilarResult is a session ranked by similarity to another session
+type SimilarResult struct {
+	Session     adapters.Session `json:"session"`
+	Score       float64          `json:"score"`        // Cosine similarity in [0, 1]
+	SharedTerms []string         `json:"shared_terms"` // Distinctive terms both sessions use, strongest first
+}
+
+// SimilarOptions holds filters for a similarity lookup
+type SimilarOptions struct {
+	Source      string
+	ProjectPath string // A project filter (see adapters.ProjectFilter)
+	Limit       int
+}
+
+// termVector is a TF-IDF weighted term vector
+type termVector map[string]float64
+
+// FindSimilarSessions ranks indexed sessions by cosine similarity of their TF-IDF term vectors
+// to the given session. Candidates are the sessions that share the target's most distinctive
+// terms; only the strongest of those are scored against their full vectors.
+func (c *Cache) FindSimilarSessions(sessionID string, opts SimilarOptions) ([]SimilarResult, error) {
+	if opts.Limit <= 0 {
+		opts.Limit = 10
+	}
+
+	target, err := c.sessionVector(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if len(target) == 0 {
+		return nil, fmt.Errorf("session not indexed: %s", sessionID)
+	}
+
+	// Score candidates by their overlap on the target's key terms
+	keyTerms := topTerms(target, similarityKeyTerms)
+	query := `
+		SELECT ti.session_id, ti.term, ti.term_frequency
+		FROM term_index ti
+		JOIN sessions s ON s.id = ti.session_id
+		WHERE ti.session_id != ? AND ti.term IN (`
+	args := append([]interface{}{sessionID}, c.sealTerms(keyTerms)...)
+	query += strings.TrimSuffix(strings.Repeat("?, ", len(keyTerms)), ", ") + ")"
+	if opts.Source != "" {
+		query += " AND s.source = ?"
+		args = append(args, opts.Source)
+	}
+	if opts.ProjectPath != "" {
+		condition, projectArgs, err := c.projectCondition("s.project_path", opts.ProjectPath)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND " + condition
+		args = append(args, projectArgs...)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find candidates: %w", err)
+	}
+	overlap := make(map[string]float64)
+	for rows.Next() {
+		var id, term string
+		var freq int
+		if err := rows.Scan(&id, &term, &freq); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		if term, err = c.openTerm(term); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		overlap[id] += target[term] * (1 + math.Log(float64(freq)))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	candidates := make([]string, 0, len(overlap))
+	for id := range overlap {
+		candidates = append(candidates, id)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return overlap[candidates[i]] > overlap[candidates[j]]
+	})
+	if maxCandidates := opts.Limit * similarityCandidateFactor; len(candidates) > maxCandidates {
+		candidates = candidates[:maxCandidates]
+	}
+
+	// Rank the strongest candidates by full cosine similarity
+	targetNorm := target.norm()
+	results := make([]SimilarResult, 0, len(candidates))
+	for _, id := range candidates {
+		vector, err := c.sessionVector(id)
+		if err != nil {
+			return nil, err
+		}
+		session, err := c.indexedSession(id)
+		if err != nil {
+			return nil, err
+		}
+
+		score := 0.0
+		if denom := targetNorm * vector.norm(); denom > 0 {
+			score = target.dot(vector) / denom
+		}
+		results = append(results, SimilarResult{
+			Session:     session,
+			Score:       score,
+			SharedTerms: sharedTerms(withoutStopWords(target), vector, maxSharedTerms),
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
+}
+
+// TermComparison describes the topical overlap between two indexed sessions
+type TermComparison struct {
+	Similarity  float64  `json:"similarity"`   // Cosine similarity in [0, 1]
+	SharedTerms []string `json:"shared_terms"` // Distinctive terms both sessions use, strongest first
+	OnlyA       []string `json:"only_a"`       // Distinctive terms only the first session uses
+	OnlyB       []string `json:"only_b"`       // Distinctive terms only the second session uses
+}
+
+// CompareSessions compares the TF-IDF term vectors of two indexed sessions
+func (c *Cache) CompareSessions(sessionA, sessionB string, limit int) (TermComparison, error) {
+	if limit <= 0 {
+		limit = maxSharedTerms
+	}
+
+	a, err := c.sessionVector(sessionA)
+	if err != nil {
+		return TermComparison{}, err
+	}
+	if len(a) == 0 {
+		return TermComparison{}, fmt.Errorf("session not indexed: %s", sessionA)
+	}
+	b, err := c.sessionVector(sessionB)
+	if err != nil {
+		return TermComparison{}, err
+	}
+	if len(b) == 0 {
+		return TermComparison{}, fmt.Errorf("session not indexed: %s", sessionB)
+	}
+
+	topicsA, topicsB := withoutStopWords(a), withoutStopWords(b)
+	comparison := TermComparison{
+		SharedTerms: sharedTerms(topicsA, topicsB, limit),
+		OnlyA:       distinctTerms(topicsA, b, limit),
+		OnlyB:       distinctTerms(topicsB, a, limit),
+	}
+	if denom := a.norm() * b.norm(); denom > 0 {
+		comparison.Similarity = a.dot(b) / denom
+	}
+	return comparison, nil
+}
+
+// distinctTerms returns up to limit of v's highest-weighted terms that other lacks
+func distinctTerms(v, other termVector, limit int) []string {
+	terms := []string{}
+	for _, term := range topTerms(v, len(v)) {
+		if len(terms) >= limit {
+			break
+		}
+		if _, ok := other[term]; !ok {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// sessionVector builds the TF-IDF vector for an indexed session using
+// log-scaled term frequency and smoothed inverse document frequency.
+func (c *Cache) sessionVector(sessionID string) (termVector, error) {
+	rows, err := c.db.Query("SELECT term, term_frequency FROM term_index WHERE session_id = ?", sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session terms: %w", err)
+	}
+	termFreqs := make(map[string]int)
+	for rows.Next() {
+		var term string
+		var freq int
+		if err := rows.Scan(&term, &freq); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if term, err = c.openTerm(term); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		termFreqs[term] = freq
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read session terms: %w", err)
+	}
+	if len(termFreqs) == 0 {
+		return termVector{}, nil
+	}
+
+	stats, err := c.getStats()
+	if err != nil {
+		return nil, err
+	}
+
+	terms := make([]string, 0, len(termFreqs))
+	for term := range termFreqs {
+		terms = append(terms, term)
+	}
+	docFreqs, err := c.getDocumentFrequencies(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	vector := make(termVector, len(termFreqs))
+	for term, freq := range termFreqs {
+		df := docFreqs[term]
+		if df == 0 {
+			df = 1
+		}
+		idf := math.Log(1 + float64(stats.totalDocs)/float64(df))
+		vector[term] = (1 + math.Log(float64(freq))) * idf
+	}
+	return vector, nil
+}
+
+// indexedSession loads the cached metadata for a session
+func (c *Cache) indexedSession(sessionID string) (adapters.Session, error) {
+	var session adapters.Session
+	var timestampUnix int64
+	err := c.db.QueryRow(`
+		SELECT id, source, project_path, repo, branch, file_path, first_message, summary, title, timestamp, message_count, file_size
+		FROM sessions WHERE id = ?`, sessionID).Scan(&session.ID, &session.Source, &session.ProjectPath, &session.Repo, &session.Branch,
+		&session.FilePath, &session.FirstMessage, &session.Summary, &session.Title, &timestampUnix, &session.MessageCount, &session.FileSize)
+	if err == sql.ErrNoRows {
+		return session, fmt.Errorf("session not indexed: %s", sessionID)
+	}
+	if err != nil {
+		return session, fmt.Errorf("failed to load session: %w", err)
+	}
+	if err := c.openSessionText(&session.FirstMessage, &session.Summary, &session.Title); err != nil {
+		return session, err
+	}
+	session.Timestamp = time.Unix(timestampUnix, 0)
+	return session, nil
+}
+
+// sharedTerms returns up to limit terms present in both vectors, ordered by their combined weight
+func sharedTerms(a, b termVector, limit int) []string {
+	type weighted struct {
+		term   string
+		weight float64
+	}
+	var shared []weighted
+	for term, wa := range a {
+		if wb, ok := b[term]; ok {
+			shared = append(shared, weighted{term, wa * wb})
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		if shared[i].weight != shared[j].weight {
+			return shared[i].weight > shared[j].weight
+		}
+		return shared[i].term < shared[j].term
+	})
+
+	terms := make([]string, 0, limit)
+	for i := 0; i < len(shared) && i < limit; i++ {
+		terms = append(terms, shared[i].term)
+	}
+	return terms
+}
+
+// topTerms returns the n highest-weighted terms of a vector
+func topTerms(v termVector, n int) []string {
+	terms := make([]string, 0, len(v))
+	for term := range v {
+		terms = append(terms, term)
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if v[terms[i]] != v[terms[j]] {
+			return v[terms[i]] > v[terms[j]]
+		}
+		return terms[i] < terms[j]
+	})
+	if len(terms) > n {
+		terms = terms[:n]
+	}
+	return terms
+}
+
+// dot returns the dot product of two vectors
+func (v termVector) dot(other termVector) float64 {
+	sum := 0.0
+	for term, w := range v {
+		sum += w * other[term]
+	}
+	return sum
+}
+
+// norm returns the Euclidean length of the vector
+func (v termVector) norm() float64 {
+	sum := 0.0
+	for _, w := range v {
+		sum += w * w
+	}
+	return math.Sqrt(sum)
+}
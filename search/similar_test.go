@@ -0,0 +1,91 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestFindSimilarSessions(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	docs := map[string]string{
+		"target":  "flaky websocket reconnect test fails intermittently in CI retry timeout",
+		"similar": "debugging the flaky websocket reconnect test again, CI timeout on retry",
+		"related": "the websocket server needs a new endpoint for uploads",
+		"other":   "refactor billing invoices and tax calculation for european customers",
+	}
+	for id, content := range docs {
+		session := adapters.Session{ID: id, Source: "claude", ProjectPath: "/repo", FilePath: filePath, Timestamp: time.Now()}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	results, err := cache.FindSimilarSessions("target", SimilarOptions{Limit: 5})
+	if err != nil {
+		t.Fatalf("FindSimilarSessions failed: %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results sharing terms, got %+v", results)
+	}
+	if results[0].Session.ID != "similar" || results[1].Session.ID != "related" {
+		t.Fatalf("unexpected ranking: %s, %s", results[0].Session.ID, results[1].Session.ID)
+	}
+	if results[0].Score <= results[1].Score || results[0].Score > 1 {
+		t.Fatalf("unexpected scores: %f, %f", results[0].Score, results[1].Score)
+	}
+	if len(results[0].SharedTerms) == 0 {
+		t.Fatal("expected shared terms for the similar session")
+	}
+
+	if _, err := cache.FindSimilarSessions("missing", SimilarOptions{}); err == nil {
+		t.Fatal("expected an error for a session that is not indexed")
+	}
+}
+
+func TestCompareSessions(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	docs := map[string]string{
+		"first":  "migrate postgres schema add index users email",
+		"second": "migrate postgres schema rollback failed lock timeout",
+		"third":  "unrelated frontend styling tweaks",
+	}
+	for id, content := range docs {
+		session := adapters.Session{ID: id, Source: "codex", FilePath: filePath, Timestamp: time.Now()}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	comparison, err := cache.CompareSessions("first", "second", 10)
+	if err != nil {
+		t.Fatalf("CompareSessions failed: %v", err)
+	}
+	if len(comparison.SharedTerms) != 3 {
+		t.Fatalf("expected migrate/postgres/schema to be shared, got %v", comparison.SharedTerms)
+	}
+	for _, term := range comparison.OnlyA {
+		if term == "postgres" {
+			t.Fatalf("shared term reported as distinct: %v", comparison.OnlyA)
+		}
+	}
+	if len(comparison.OnlyA) != 4 || len(comparison.OnlyB) != 4 {
+		t.Fatalf("unexpected distinct terms: %v / %v", comparison.OnlyA, comparison.OnlyB)
+	}
+	if comparison.Similarity <= 0 || comparison.Similarity >= 1 {
+		t.Fatalf("unexpected similarity: %f", comparison.Similarity)
+	}
+}
@@ -0,0 +1,32 @@
+package search
+
+// stopWords are common English and conversational words that carry no topical meaning.
+// They are still indexed for search but skipped when reporting topics.
+var stopWords = map[string]bool{
+	"about": true, "after": true, "again": true, "all": true, "also": true, "am": true, "an": true,
+	"and": true, "any": true, "are": true, "as": true, "at": true, "be": true, "because": true,
+	"been": true, "before": true, "being": true, "but": true, "by": true, "can": true, "could": true,
+	"did": true, "do": true, "does": true, "doing": true, "done": true, "for": true, "from": true,
+	"had": true, "has": true, "have": true, "he": true, "her": true, "here": true, "him": true,
+	"his": true, "how": true, "if": true, "in": true, "into": true, "is": true, "it": true,
+	"its": true, "just": true, "let": true, "like": true, "me": true, "more": true, "my": true,
+	"need": true, "no": true, "not": true, "now": true, "of": true, "ok": true, "okay": true,
+	"on": true, "one": true, "only": true, "or": true, "other": true, "our": true, "out": true,
+	"please": true, "should": true, "so": true, "some": true, "than": true, "that": true,
+	"the": true, "their": true, "them": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "those": true, "to": true, "too": true, "up": true, "us": true,
+	"use": true, "using": true, "very": true, "want": true, "was": true, "we": true, "were": true,
+	"what": true, "when": true, "where": true, "which": true, "while": true, "who": true,
+	"why": true, "will": true, "with": true, "would": true, "yes": true, "you": true, "your": true,
+}
+
+// withoutStopWords returns a copy of v without stop words
+func withoutStopWords(v termVector) termVector {
+	filtered := make(termVector, len(v))
+	for term, w := range v {
+		if !stopWords[term] {
+			filtered[term] = w
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,237 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// maxSuggestions caps the suggestions offered for a query term
+const maxSuggestions = 3
+
+// TermSuggestion is a query term that matches no indexed session, with the indexed terms
+// closest to it, best first
+type TermSuggestion struct {
+	Term        string   `json:"term"`
+	Suggestions []string `json:"suggestions"`
+}
+
+// termDictionary is every indexed term with the number of sessions containing it, by
+// length in runes. It is built on first use and rebuilt once the index has changed.
+type termDictionary struct {
+	mu       sync.Mutex
+	version  dictionaryVersion
+	byLength map[int][]dictionaryTerm
+}
+
+type dictionaryTerm struct {
+	term string
+	docs int
+}
+
+// dictionaryVersion identifies a state of the index: it changes whenever a session is
+// indexed or removed, including by another process sharing the cache
+type dictionaryVersion struct {
+	sessions    int
+	lastIndexed int64
+	docLength   int64
+}
+
+// Suggest returns the terms of query that match no indexed session, with the closest
+// indexed terms by edit distance. Terms that match are left out, so nothing is returned
+// for a query whose terms are all indexed.
+func (c *Cache) Suggest(query string) ([]TermSuggestion, error) {
+	parsed, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	terms := Tokenize(parsed.Text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	docFreqs, err := c.getDocumentFrequencies(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	seen := make(map[string]bool)
+	for _, term := range terms {
+		if docFreqs[term] == 0 && !seen[term] {
+			seen[term] = true
+			missing = append(missing, term)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	byLength, err := c.dictionary.terms(c)
+	if err != nil {
+		return nil, err
+	}
+	var suggestions []TermSuggestion
+	for _, term := range missing {
+		if closest := closestTerms(term, byLength); len(closest) > 0 {
+			suggestions = append(suggestions, TermSuggestion{Term: term, Suggestions: closest})
+		}
+	}
+	return suggestions, nil
+}
+
+// CorrectQuery returns query with each term that has suggestions replaced by the best
+// one, keeping its field filters, or "" when there is nothing to correct
+func CorrectQuery(query string, suggestions []TermSuggestion) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	best := make(map[string]string, len(suggestions))
+	for _, s := range suggestions {
+		best[s.Term] = s.Suggestions[0]
+	}
+	var words []string
+	for _, word := range splitQuery(query) {
+		if parsed, err := ParseQuery(word); err != nil || !parsed.Empty() {
+			words = append(words, word)
+			continue
+		}
+		for _, term := range Tokenize(word) {
+			if replacement, ok := best[term]; ok {
+				term = replacement
+			}
+			words = append(words, term)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// terms returns the dictionary, rebuilding it if the index changed since it was built
+func (d *termDictionary) terms(c *Cache) (map[int][]dictionaryTerm, error) {
+	var version dictionaryVersion
+	if err := c.db.QueryRow("SELECT COUNT(*), COALESCE(MAX(last_indexed), 0), COALESCE(SUM(doc_length), 0) FROM sessions").
+		Scan(&version.sessions, &version.lastIndexed, &version.docLength); err != nil {
+		return nil, fmt.Errorf("failed to check the term dictionary: %w", err)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.byLength != nil && d.version == version {
+		return d.byLength, nil
+	}
+
+	rows, err := c.db.Query("SELECT term, COUNT(*) FROM term_index GROUP BY term")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the term dictionary: %w", err)
+	}
+	defer rows.Close()
+
+	byLength := make(map[int][]dictionaryTerm)
+	for rows.Next() {
+		var entry dictionaryTerm
+		if err := rows.Scan(&entry.term, &entry.docs); err != nil {
+			return nil, fmt.Errorf("failed to load the term dictionary: %w", err)
+		}
+		if entry.term, err = c.openTerm(entry.term); err != nil {
+			return nil, err
+		}
+		length := len([]rune(entry.term))
+		byLength[length] = append(byLength[length], entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load the term dictionary: %w", err)
+	}
+	d.byLength, d.version = byLength, version
+	return byLength, nil
+}
+
+// maxEditDistance is how far a suggestion may be from a term: short terms have many
+// neighbors, so they only get suggestions one edit away, and numbers get none
+func maxEditDistance(term string) int {
+	if strings.IndexFunc(term, func(r rune) bool { return !unicode.IsDigit(r) }) < 0 {
+		return 0
+	}
+	switch length := len([]rune(term)); {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// closestTerms returns the dictionary terms closest to term, the nearest and then the
+// most common first
+func closestTerms(term string, byLength map[int][]dictionaryTerm) []string {
+	maxDistance := maxEditDistance(term)
+	if maxDistance == 0 {
+		return nil
+	}
+
+	type candidate struct {
+		dictionaryTerm
+		distance int
+	}
+	var candidates []candidate
+	runes := []rune(term)
+	for length := len(runes) - maxDistance; length <= len(runes)+maxDistance; length++ {
+		for _, entry := range byLength[length] {
+			if distance := editDistance(runes, []rune(entry.term), maxDistance); distance <= maxDistance {
+				candidates = append(candidates, candidate{entry, distance})
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.docs != b.docs {
+			return a.docs > b.docs
+		}
+		return a.term < b.term
+	})
+
+	var closest []string
+	for _, c := range candidates {
+		if len(closest) == maxSuggestions {
+			break
+		}
+		closest = append(closest, c.term)
+	}
+	return closest
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters turning a into b, or max+1 once it is known to
+// exceed max
+func editDistance(a, b []rune, max int) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,104 @@
+package search
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestCacheSuggest(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	for id, content := range map[string]string{
+		"one":   "authentication fails after the token refresh",
+		"two":   "authentication middleware and authorization rules",
+		"three": "refresh the cache",
+	} {
+		session := adapters.Session{ID: id, Source: "claude", Timestamp: time.Now(), FilePath: filePath}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	suggestions, err := cache.Suggest("authetication refesh token")
+	if err != nil {
+		t.Fatalf("Suggest failed: %v", err)
+	}
+	want := []TermSuggestion{
+		{Term: "authetication", Suggestions: []string{"authentication"}},
+		{Term: "refesh", Suggestions: []string{"refresh"}},
+	}
+	if !reflect.DeepEqual(suggestions, want) {
+		t.Fatalf("unexpected suggestions: %+v", suggestions)
+	}
+	if corrected := CorrectQuery("Authetication refesh token", suggestions); corrected != "authentication refresh token" {
+		t.Fatalf("unexpected corrected query: %q", corrected)
+	}
+
+	if suggestions, err := cache.Suggest("authentication token"); err != nil || suggestions != nil {
+		t.Fatalf("expected no suggestions for indexed terms, got %+v (%v)", suggestions, err)
+	}
+	if suggestions, err := cache.Suggest("kubernetes 2024"); err != nil || suggestions != nil {
+		t.Fatalf("expected no suggestions without close terms, got %+v (%v)", suggestions, err)
+	}
+
+	// The dictionary picks up sessions indexed after it was built
+	session := adapters.Session{ID: "four", Source: "claude", Timestamp: time.Now(), FilePath: filePath}
+	if err := cache.IndexSession(session, "kubernetes deployment"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+	suggestions, err = cache.Suggest("kubernets")
+	if err != nil || len(suggestions) != 1 || suggestions[0].Suggestions[0] != "kubernetes" {
+		t.Fatalf("expected a suggestion from the new session, got %+v (%v)", suggestions, err)
+	}
+}
+
+func TestCacheSuggestEncrypted(t *testing.T) {
+	cache, err := NewEncryptedCache(filepath.Join(t.TempDir(), "cache.db"), bytes.Repeat([]byte{3}, KeySize))
+	if err != nil {
+		t.Fatalf("NewEncryptedCache failed: %v", err)
+	}
+	defer cache.Close()
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+	session := adapters.Session{ID: "one", Source: "claude", Timestamp: time.Now(), FilePath: filePath}
+	if err := cache.IndexSession(session, "webhook signature mismatch"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+
+	suggestions, err := cache.Suggest("signatrue")
+	if err != nil || len(suggestions) != 1 || suggestions[0].Suggestions[0] != "signature" {
+		t.Fatalf("expected a decrypted suggestion, got %+v (%v)", suggestions, err)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"retry", "retry", 0},
+		{"retyr", "retry", 1},
+		{"rety", "retry", 1},
+		{"authetication", "authentication", 1},
+		{"kitten", "sitting", 3},
+		{"", "abc", 3},
+	} {
+		if got := editDistance([]rune(tc.a), []rune(tc.b), 5); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+	if got := editDistance([]rune("kitten"), []rune("sitting"), 1); got != 2 {
+		t.Errorf("expected the distance to stop past the maximum, got %d", got)
+	}
+}
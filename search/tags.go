@@ -0,0 +1,221 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+// maxTagLength caps the length of a single tag
+const maxTagLength = 64
+
+// TagCount is a tag and the number of sessions carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TaggedSession is a session carrying a tag. Session metadata is only available
+// for sessions that have been indexed.
+type TaggedSession struct {
+	Source    string            `json:"source"`
+	SessionID string            `json:"session_id"`
+	Tags      []string          `json:"tags"`
+	TaggedAt  time.Time         `json:"tagged_at"`
+	Session   *adapters.Session `json:"session,omitempty"`
+}
+
+// NormalizeTag lower-cases a tag and replaces whitespace with dashes
+func NormalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	tag = strings.Join(strings.FieldsFunc(tag, unicode.IsSpace), "-")
+	if tag == "" {
+		return "", fmt.Errorf("tag cannot be empty")
+	}
+	if len(tag) > maxTagLength {
+		return "", fmt.Errorf("tag too long (max %d characters): %s", maxTagLength, tag)
+	}
+	return tag, nil
+}
+
+// TagSession adds tags to a session. Tags that are already present are left unchanged.
+func (c *Cache) TagSession(source, sessionID string, tags []string) error {
+	if source == "" || sessionID == "" {
+		return fmt.Errorf("source and session ID are required")
+	}
+
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, tag := range normalized {
+		if _, err := tx.Exec(
+			"INSERT OR IGNORE INTO session_tags (source, session_id, tag, created_at) VALUES (?, ?, ?, ?)",
+			source, sessionID, tag, now); err != nil {
+			return fmt.Errorf("failed to tag session: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UntagSession removes tags from a session
+func (c *Cache) UntagSession(source, sessionID string, tags []string) error {
+	normalized, err := normalizeTags(tags)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range normalized {
+		if _, err := c.db.Exec("DELETE FROM session_tags WHERE source = ? AND session_id = ? AND tag = ?",
+			source, sessionID, tag); err != nil {
+			return fmt.Errorf("failed to untag session: %w", err)
+		}
+	}
+	return nil
+}
+
+// SessionTags returns the tags of a session, sorted alphabetically
+func (c *Cache) SessionTags(source, sessionID string) ([]string, error) {
+	rows, err := c.db.Query("SELECT tag FROM session_tags WHERE source = ? AND session_id = ? ORDER BY tag",
+		source, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// ListTags returns every tag in use with its session count, most used first
+func (c *Cache) ListTags() ([]TagCount, error) {
+	rows, err := c.db.Query("SELECT tag, COUNT(*) AS n FROM session_tags GROUP BY tag ORDER BY n DESC, tag")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags := []TagCount{}
+	for rows.Next() {
+		var tc TagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tc)
+	}
+	return tags, rows.Err()
+}
+
+// SessionsByTag returns the sessions carrying a tag, most recently tagged first
+func (c *Cache) SessionsByTag(tag, source string, limit int) ([]TaggedSession, error) {
+	tag, err := NormalizeTag(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT t.source, t.session_id, t.created_at,
+		       s.project_path, s.repo, s.branch, s.file_path, s.first_message, s.summary, s.title, s.timestamp
+		FROM session_tags t
+		LEFT JOIN sessions s ON s.id = t.session_id AND s.source = t.source
+		WHERE t.tag = ?`
+	args := []interface{}{tag}
+	if source != "" {
+		query += " AND t.source = ?"
+		args = append(args, source)
+	}
+	query += " ORDER BY t.created_at DESC, t.session_id"
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tagged sessions: %w", err)
+	}
+
+	var results []TaggedSession
+	for rows.Next() {
+		var ts TaggedSession
+		var createdAt int64
+		var projectPath, repo, branch, filePath, firstMessage, summary, title sql.NullString
+		var timestamp sql.NullInt64
+		if err := rows.Scan(&ts.Source, &ts.SessionID, &createdAt,
+			&projectPath, &repo, &branch, &filePath, &firstMessage, &summary, &title, &timestamp); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		ts.TaggedAt = time.Unix(createdAt, 0)
+		if err := c.openSessionText(&firstMessage.String, &summary.String, &title.String); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if timestamp.Valid {
+			ts.Session = &adapters.Session{
+				ID:           ts.SessionID,
+				Source:       ts.Source,
+				ProjectPath:  projectPath.String,
+				Repo:         repo.String,
+				Branch:       branch.String,
+				FilePath:     filePath.String,
+				FirstMessage: firstMessage.String,
+				Summary:      summary.String,
+				Title:        title.String,
+				Timestamp:    time.Unix(timestamp.Int64, 0),
+			}
+		}
+		results = append(results, ts)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read rows: %w", err)
+	}
+
+	for i := range results {
+		if results[i].Tags, err = c.SessionTags(results[i].Source, results[i].SessionID); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// normalizeTags normalizes and de-duplicates a list of tags
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, fmt.Errorf("at least one tag is required")
+	}
+	seen := make(map[string]bool)
+	var normalized []string
+	for _, tag := range tags {
+		t, err := NormalizeTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestNormalizeTag(t *testing.T) {
+	tag, err := NormalizeTag("  Good   Example ")
+	if err != nil || tag != "good-example" {
+		t.Fatalf("NormalizeTag=%q, %v", tag, err)
+	}
+	if _, err := NormalizeTag("   "); err == nil {
+		t.Fatal("expected error for empty tag")
+	}
+}
+
+func TestSessionTags(t *testing.T) {
+	cache := newTempCache(t)
+
+	if err := cache.TagSession("claude", "s1", []string{"Postmortem", "bookmark", "postmortem"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+	if err := cache.TagSession("codex", "s2", []string{"postmortem"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+
+	tags, err := cache.SessionTags("claude", "s1")
+	if err != nil || len(tags) != 2 || tags[0] != "bookmark" || tags[1] != "postmortem" {
+		t.Fatalf("SessionTags=%v, %v", tags, err)
+	}
+
+	counts, err := cache.ListTags()
+	if err != nil || len(counts) != 2 || counts[0].Tag != "postmortem" || counts[0].Count != 2 {
+		t.Fatalf("ListTags=%+v, %v", counts, err)
+	}
+
+	tagged, err := cache.SessionsByTag("postmortem", "codex", 0)
+	if err != nil || len(tagged) != 1 || tagged[0].SessionID != "s2" || tagged[0].Session != nil {
+		t.Fatalf("SessionsByTag with source filter=%+v, %v", tagged, err)
+	}
+
+	if err := cache.UntagSession("claude", "s1", []string{"postmortem"}); err != nil {
+		t.Fatalf("UntagSession failed: %v", err)
+	}
+	if tags, _ := cache.SessionTags("claude", "s1"); len(tags) != 1 || tags[0] != "bookmark" {
+		t.Fatalf("tag not removed: %v", tags)
+	}
+}
+
+func TestTagsSurviveReindexAndFilterSearch(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	for _, id := range []string{"tagged", "untagged"} {
+		session := adapters.Session{ID: id, Source: "claude", FilePath: filePath, FirstMessage: "hello", Timestamp: time.Now()}
+		if err := cache.IndexSession(session, "deploy pipeline broke"); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+	if err := cache.TagSession("claude", "tagged", []string{"good-example"}); err != nil {
+		t.Fatalf("TagSession failed: %v", err)
+	}
+
+	// Reindexing replaces the session row but must keep its tags
+	session := adapters.Session{ID: "tagged", Source: "claude", FilePath: filePath, FirstMessage: "hello", Timestamp: time.Now()}
+	if err := cache.IndexSession(session, "deploy pipeline broke again"); err != nil {
+		t.Fatalf("IndexSession failed: %v", err)
+	}
+
+	results, err := cache.SearchWithOptions("deploy", SearchOptions{Tag: "Good Example"})
+	if err != nil {
+		t.Fatalf("SearchWithOptions failed: %v", err)
+	}
+	if len(results) != 1 || results[0].Session.ID != "tagged" {
+		t.Fatalf("tag filter not applied: %+v", results)
+	}
+
+	tagged, err := cache.SessionsByTag("good-example", "", 0)
+	if err != nil || len(tagged) != 1 || tagged[0].Session == nil || tagged[0].Session.FirstMessage != "hello" {
+		t.Fatalf("indexed metadata not joined: %+v, %v", tagged, err)
+	}
+}
@@ -0,0 +1,84 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// TopTerms returns the terms that best characterize a group of indexed sessions: terms
+// used by many of the sessions but rare across the whole index. Sessions that are not
+// indexed are ignored.
+func (c *Cache) TopTerms(sessionIDs []string, limit int) ([]string, error) {
+	if len(sessionIDs) == 0 || limit <= 0 {
+		return []string{}, nil
+	}
+
+	groupFreqs := make(map[string]int)
+	for start := 0; start < len(sessionIDs); start += maxQueryTerms {
+		end := start + maxQueryTerms
+		if end > len(sessionIDs) {
+			end = len(sessionIDs)
+		}
+		batch := sessionIDs[start:end]
+
+		query := "SELECT term, COUNT(*) FROM term_index WHERE session_id IN ("
+		args := make([]interface{}, len(batch))
+		for i, id := range batch {
+			if i > 0 {
+				query += ", "
+			}
+			query += "?"
+			args[i] = id
+		}
+		query += ") GROUP BY term"
+
+		rows, err := c.db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group terms: %w", err)
+		}
+		for rows.Next() {
+			var term string
+			var count int
+			if err := rows.Scan(&term, &count); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if term, err = c.openTerm(term); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			groupFreqs[term] += count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read group terms: %w", err)
+		}
+	}
+	if len(groupFreqs) == 0 {
+		return []string{}, nil
+	}
+
+	stats, err := c.getStats()
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]string, 0, len(groupFreqs))
+	for term := range groupFreqs {
+		terms = append(terms, term)
+	}
+	docFreqs, err := c.getDocumentFrequencies(terms)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(termVector, len(groupFreqs))
+	for term, count := range groupFreqs {
+		df := docFreqs[term]
+		if df == 0 {
+			df = 1
+		}
+		scores[term] = float64(count) * math.Log(1+float64(stats.totalDocs)/float64(df))
+	}
+
+	return topTerms(withoutStopWords(scores), limit), nil
+}
@@ -0,0 +1,43 @@
+package search
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/yoavf/ai-sessions-mcp/adapters"
+)
+
+func TestTopTerms(t *testing.T) {
+	cache := newTempCache(t)
+	filePath := filepath.Join(t.TempDir(), "session.jsonl")
+	if err := os.WriteFile(filePath, []byte("test"), 0o644); err != nil {
+		t.Fatalf("write session file: %v", err)
+	}
+
+	docs := map[string]string{
+		"a": "please fix the terraform module for the vpc",
+		"b": "please update terraform state for the vpc peering",
+		"c": "please fix the react component styling",
+		"d": "please review the react hooks",
+	}
+	for id, content := range docs {
+		session := adapters.Session{ID: id, Source: "claude", FilePath: filePath, Timestamp: time.Now()}
+		if err := cache.IndexSession(session, content); err != nil {
+			t.Fatalf("IndexSession failed: %v", err)
+		}
+	}
+
+	terms, err := cache.TopTerms([]string{"a", "b"}, 2)
+	if err != nil {
+		t.Fatalf("TopTerms failed: %v", err)
+	}
+	if len(terms) != 2 || terms[0] != "terraform" || terms[1] != "vpc" {
+		t.Fatalf("expected terraform and vpc, got %v", terms)
+	}
+
+	if terms, err := cache.TopTerms(nil, 5); err != nil || len(terms) != 0 {
+		t.Fatalf("expected no terms for an empty group, got %v, %v", terms, err)
+	}
+}
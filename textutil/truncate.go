@@ -0,0 +1,134 @@
+// Package textutil truncates text for display without splitting characters.
+package textutil
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis marks where text was cut
+const Ellipsis = "..."
+
+const zeroWidthJoiner = '\u200d'
+
+// Truncate shortens s to at most maxRunes characters, ending it with an ellipsis. It
+// cuts between user-perceived characters, so accents, emoji sequences, and flags are
+// dropped whole rather than split.
+func Truncate(s string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= maxRunes {
+		return s
+	}
+	if maxRunes <= len(Ellipsis) {
+		return s[:ClusterStart(s, runeOffset(s, maxRunes))]
+	}
+	return s[:ClusterStart(s, runeOffset(s, maxRunes-len(Ellipsis)))] + Ellipsis
+}
+
+// TruncateBytes shortens s to at most maxBytes bytes without splitting a character,
+// adding an ellipsis after the cut
+func TruncateBytes(s string, maxBytes int) string {
+	if len(s) <= maxBytes {
+		return s
+	}
+	return s[:ClusterStart(s, maxBytes)] + Ellipsis
+}
+
+// TruncateStart shortens s to at most maxRunes characters by cutting its start, which
+// is replaced with an ellipsis, keeping the end of paths and the like
+func TruncateStart(s string, maxRunes int) string {
+	if maxRunes <= 0 {
+		return ""
+	}
+	count := utf8.RuneCountInString(s)
+	if count <= maxRunes {
+		return s
+	}
+	if maxRunes <= len(Ellipsis) {
+		return s[ClusterEnd(s, runeOffset(s, count-maxRunes)):]
+	}
+	return Ellipsis + s[ClusterEnd(s, runeOffset(s, count-maxRunes+len(Ellipsis))):]
+}
+
+// ClusterStart moves the byte offset i back to the start of the character it falls in,
+// so s[:ClusterStart(s, i)] doesn't end with part of a character
+func ClusterStart(s string, i int) int {
+	if i >= len(s) {
+		return len(s)
+	}
+	for i > 0 && !isBoundary(s, i) {
+		i--
+	}
+	return max(i, 0)
+}
+
+// ClusterEnd moves the byte offset i forward to the end of the character it falls in,
+// so s[ClusterEnd(s, i):] doesn't start with part of a character
+func ClusterEnd(s string, i int) int {
+	if i <= 0 {
+		return 0
+	}
+	for i < len(s) && !isBoundary(s, i) {
+		i++
+	}
+	return min(i, len(s))
+}
+
+// runeOffset returns the byte offset of the n-th rune of s
+func runeOffset(s string, n int) int {
+	for i := range s {
+		if n == 0 {
+			return i
+		}
+		n--
+	}
+	return len(s)
+}
+
+// isBoundary reports whether s can be cut at byte offset i. It approximates Unicode's
+// grapheme cluster boundaries for what shows up in sessions: combining marks, variation
+// selectors, emoji modifiers and zero-width joiner sequences, and flags.
+func isBoundary(s string, i int) bool {
+	if i <= 0 || i >= len(s) {
+		return true
+	}
+	if !utf8.RuneStart(s[i]) {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRuneInString(s[:i])
+	next, _ := utf8.DecodeRuneInString(s[i:])
+	switch {
+	case prev == zeroWidthJoiner, extendsPrevious(next):
+		return false
+	case isRegionalIndicator(prev) && isRegionalIndicator(next):
+		// Flags are pairs of regional indicators: only cut between pairs
+		indicators := 0
+		for rest := s[:i]; rest != ""; indicators++ {
+			r, size := utf8.DecodeLastRuneInString(rest)
+			if !isRegionalIndicator(r) {
+				break
+			}
+			rest = rest[:len(rest)-size]
+		}
+		return indicators%2 == 0
+	case prev == '\r' && next == '\n':
+		return false
+	}
+	return true
+}
+
+// extendsPrevious reports whether r belongs to the character before it
+func extendsPrevious(r rune) bool {
+	return unicode.Is(unicode.M, r) ||
+		r == zeroWidthJoiner ||
+		(r >= 0xFE00 && r <= 0xFE0F) || // Variation selectors
+		(r >= 0x1F3FB && r <= 0x1F3FF) || // Emoji skin tone modifiers
+		(r >= 0xE0020 && r <= 0xE007F) || // Tags, as in subdivision flags
+		(r >= 0xE0100 && r <= 0xE01EF) // Variation selectors supplement
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
@@ -0,0 +1,76 @@
+package textutil
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxRunes int
+		want     string
+	}{
+		{"short", "hello", 10, "hello"},
+		{"ascii", "hello world", 8, "hello..."},
+		{"multi-byte", "héllo wörld", 8, "héllo..."},
+		{"cjk", "日本語のテキストです", 6, "日本語..."},
+		{"emoji", "ok 👍👍👍👍", 6, "ok ..."},
+		{"combining mark", "cafe\u0301 au lait", 8, "cafe\u0301..."},
+		{"combining mark split", "cafe\u0301 au lait", 7, "caf..."},
+		{"skin tone", "hi 👋🏽 there", 7, "hi ..."},
+		{"zwj family", "a \U0001F468\u200d\U0001F469\u200d\U0001F467 b", 7, "a ..."},
+		{"flags", "🇫🇷🇩🇪🇯🇵 trip", 6, "🇫🇷..."},
+		{"no room for ellipsis", "héllo", 2, "hé"},
+		{"zero", "hello", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Truncate(tt.input, tt.maxRunes)
+			if got != tt.want {
+				t.Errorf("Truncate(%q, %d) = %q, want %q", tt.input, tt.maxRunes, got, tt.want)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > max(tt.maxRunes, 0) {
+				t.Errorf("Truncate(%q, %d) = %q is invalid or too long", tt.input, tt.maxRunes, got)
+			}
+		})
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	if got := TruncateBytes("héllo", 2); got != "h..." {
+		t.Errorf("TruncateBytes split a multi-byte rune: %q", got)
+	}
+	if got := TruncateBytes("hello", 5); got != "hello" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestTruncateStart(t *testing.T) {
+	if got := TruncateStart("/home/ünïcode/project", 10); got != "...project" {
+		t.Errorf("got %q", got)
+	}
+	if got := TruncateStart("🇫🇷🇩🇪🇯🇵", 5); got != "...🇯🇵" {
+		t.Errorf("got %q", got)
+	}
+	if got := TruncateStart("short", 10); got != "short" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestClusterBoundaries(t *testing.T) {
+	s := "x👋🏽y"
+	// Every offset inside the waving hand and its modifier moves to its edges
+	for i := 1; i < len(s)-1; i++ {
+		if start := ClusterStart(s, i); start != 1 {
+			t.Errorf("ClusterStart(%d) = %d, want 1", i, start)
+		}
+		if end := ClusterEnd(s, i); i > 1 && end != len(s)-1 {
+			t.Errorf("ClusterEnd(%d) = %d, want %d", i, end, len(s)-1)
+		}
+	}
+	if ClusterStart(s, 100) != len(s) || ClusterEnd(s, -1) != 0 {
+		t.Error("expected out of range offsets to be clamped")
+	}
+}